@@ -1,10 +1,36 @@
 package main
 
 import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/logging"
 	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/logic"
 	"github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"os"
 )
 
 func main() {
+	//the files of the older versions of the Agent are moved to their XDG directories before being read or, for the
+	//log files, written
+	logger := logging.New("main")
+	_, migrationErr := client.MigrateLegacyFiles()
+	//logs are redacted (and written to the log file) before any library starts writing them
+	if err := logging.Install(os.Stderr); err != nil {
+		logger.Warning("cannot open the log file, logging to stderr only", "err", err)
+	}
+	if migrationErr != nil {
+		logger.Warning("cannot move the files of a previous version of the Agent", "err", migrationErr)
+	}
+	//developer mode: the Agent runs against a mocked cluster flooded with synthetic peers
+	if config, enabled, err := client.StressConfigFromEnv(); enabled {
+		if err != nil {
+			logger.Fatal(err, "invalid stress test configuration")
+		}
+		client.UseMockedAgentController()
+		logic.EnableStressTest(config)
+	}
 	app_indicator.Run(logic.OnReady, logic.OnExit)
+	//e.g. after an update of the Agent
+	if err := client.RestartIfRequested(); err != nil {
+		logger.Error(err, "cannot restart the Agent")
+	}
 }
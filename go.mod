@@ -55,16 +55,22 @@ replace github.com/grandcat/zeroconf => github.com/liqotech/zeroconf v1.0.1-0.20
 require (
 	github.com/agrison/go-commons-lang v0.0.0-20200208220349-58e9fcb95174
 	github.com/atotto/clipboard v0.1.4
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/gen2brain/beeep v0.0.0-20200526185328-e9c15c258e28
 	github.com/gen2brain/dlgs v0.0.0-20210406143744-f512297a108e
 	github.com/getlantern/systray v1.1.0
+	github.com/godbus/dbus/v5 v5.0.3
 	github.com/liqotech/liqo v0.0.0-20210420132036-80a671bd49d9
+	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/oleiade/lane v1.0.1
 	github.com/ozgio/strutil v0.3.0
 	github.com/skratchdot/open-golang v0.0.0-20200116055534-eef842397966
 	github.com/stretchr/testify v1.7.0
+	golang.org/x/net v0.0.0-20210226172049-e18ecbb05110
 	gopkg.in/yaml.v2 v2.4.0
 	k8s.io/api v0.20.1
 	k8s.io/apimachinery v0.20.1
 	k8s.io/client-go v12.0.0+incompatible
+	k8s.io/klog v1.0.0
+	k8s.io/klog/v2 v2.4.0
 )
@@ -124,14 +124,15 @@ function command_exists() {
 }
 
 function setup_arch_and_os() {
+	# ARCH and OS are mapped to the GOARCH and GOOS values naming the release assets.
 	ARCH=$(uname -m)
 	case $ARCH in
 		armv5*) ARCH="armv5" ;;
 		armv6*) ARCH="armv6" ;;
 		armv7*) ARCH="arm" ;;
-		aarch64) ARCH="arm64" ;;
+		aarch64 | arm64) ARCH="arm64" ;;
 		x86) ARCH="386" ;;
-		x86_64) ARCH="amd64" ;;
+		x86_64 | amd64) ARCH="amd64" ;;
 		i686) ARCH="386" ;;
 		i386) ARCH="386" ;;
 		*)
@@ -140,7 +141,7 @@ function setup_arch_and_os() {
 			;;
 	esac
 
-	OS=$(uname | tr '[:upper:]' '[:lower:]')
+	OS=$(uname -s | tr '[:upper:]' '[:lower:]')
 	case "$OS" in
 		"darwin"*) setup_darwin_package ;;
 			# Minimalist GNU for Windows
@@ -319,12 +320,19 @@ function download_agent() {
 	# Download both binary and repo code to access external resources.
 	setup_tmpdir
 	command_exists tar || fatal "[PRE-FLIGHT] [INSTALL]" "'tar' is not available"
-	ASSET_NAME="liqo-agent"
+	command_exists sha256sum || fatal "[PRE-FLIGHT] [INSTALL]" "'sha256sum' is not available"
+	# The asset of the platform is named as the one the Agent downloads when updating itself.
+	ASSET_NAME="liqo-agent-${OS}-${ARCH}.tar.gz"
 	RELEASE_CODE_URL="https://github.com/${LIQOAGENT_REPO}/archive/${DOWNLOAD_VERSION}.tar.gz"
-	RELEASE_ASSET_URL="https://github.com/${LIQOAGENT_REPO}/releases/download/${DOWNLOAD_VERSION}/${ASSET_NAME:-liqo-agent}.tar.gz"
+	RELEASE_ASSET_URL="https://github.com/${LIQOAGENT_REPO}/releases/download/${DOWNLOAD_VERSION}/${ASSET_NAME}"
 	download "${RELEASE_CODE_URL}" | tar xpzf - --directory="${AGENT_REPO_DOWNLOAD_DIR}" --strip 1 2>/dev/null ||
 		fatal "[PRE-FLIGHT] [INSTALL]" "Something went wrong while extracting the LiqoAgent archive"
-	download "${RELEASE_ASSET_URL}" | tar xpzf - --directory="${AGENT_BIN_DOWNLOAD_DIR}" 2>/dev/null ||
+	download "${RELEASE_ASSET_URL}" >"${AGENT_DIR}/${ASSET_NAME}"
+	download "${RELEASE_ASSET_URL}.sha256" >"${AGENT_DIR}/${ASSET_NAME}.sha256"
+	# The checksum file refers to the asset by its name, hence it is verified from the download directory.
+	(cd "${AGENT_DIR}" && sha256sum --check --status "${ASSET_NAME}.sha256") ||
+		fatal "[PRE-FLIGHT] [INSTALL]" "The checksum of the LiqoAgent executable does not match"
+	tar xpzf "${AGENT_DIR}/${ASSET_NAME}" --directory="${AGENT_BIN_DOWNLOAD_DIR}" 2>/dev/null ||
 		fatal "[PRE-FLIGHT] [INSTALL]" "Something went wrong while extracting the LiqoAgent executable"
 }
 
@@ -337,12 +345,18 @@ function setup_agent_environment() {
 
 	# Directory containing the Agent binary.
 	AGENT_BIN_INSTALL_DIR="${HOME}/.local/bin"
-	# Liqo Agent root directory containing all related resources.
+	# Liqo Agent data directory containing the installed resources.
 	AGENT_DATA_INSTALL_DIR="${AGENT_XDG_DATA_DIR}/liqo"
+	# Liqo Agent directory containing the config file.
+	AGENT_CONFIG_INSTALL_DIR="${AGENT_XDG_CONFIG_DIR}/liqo"
+	# Liqo Agent directory containing the state persisted across runs (and the log files).
+	AGENT_STATE_DIR="${XDG_STATE_HOME:-${HOME}/.local/state}/liqo"
+	# Liqo Agent directory containing the cached files.
+	AGENT_CACHE_DIR="${XDG_CACHE_HOME:-${HOME}/.cache}/liqo"
 	# Name of the Liqo Agent config file.
 	AGENT_CONFIG_FILE_NAME="agent_conf.yaml"
 	# Filepath of the Liqo Agent config file.
-	AGENT_CONF_FILE_PATH="${AGENT_DATA_INSTALL_DIR}/${AGENT_CONFIG_FILE_NAME}"
+	AGENT_CONF_FILE_PATH="${AGENT_CONFIG_INSTALL_DIR}/${AGENT_CONFIG_FILE_NAME}"
 	# Liqo subdirectory containing the notifications icons.
 	AGENT_ICONS_DIR="${AGENT_DATA_INSTALL_DIR}/icons"
 	# Directory storing the '.desktop' file.
@@ -354,7 +368,7 @@ function setup_agent_environment() {
 
 	if [[ "${1:-}" == "--create" ]]; then
 		mkdir --parent "${AGENT_XDG_CONFIG_DIR}" "${AGENT_XDG_DATA_DIR}" \
-			"${AGENT_BIN_INSTALL_DIR}" "${AGENT_ICONS_DIR}" "${AGENT_APP_DIR}" \
+			"${AGENT_BIN_INSTALL_DIR}" "${AGENT_ICONS_DIR}" "${AGENT_CONFIG_INSTALL_DIR}" "${AGENT_APP_DIR}" \
 			"${AGENT_THEME_DIR}" "${AGENT_AUTOSTART_DIR}"
 	fi
 }
@@ -430,7 +444,7 @@ function uninstall_agent() {
 	info "[AGENT] [UNINSTALL]" "Uninstalling Liqo Agent..."
 	# Uninstalling main components.
 	rm -f "${AGENT_BIN_INSTALL_DIR}/liqo-agent"
-	rm -rf "${AGENT_DATA_INSTALL_DIR}"
+	rm -rf "${AGENT_DATA_INSTALL_DIR}" "${AGENT_CONFIG_INSTALL_DIR}" "${AGENT_STATE_DIR}" "${AGENT_CACHE_DIR}"
 	# Uninstalling desktop application files.
 	rm -f "${AGENT_APP_DIR}/io.liqo.Agent.desktop"
 	rm -f "${AGENT_AUTOSTART_DIR}/io.liqo.Agent.desktop"
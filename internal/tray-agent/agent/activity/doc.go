@@ -0,0 +1,5 @@
+/*
+Package activity provides the activity feed of Liqo Agent, a bounded list of the relevant operations performed by
+the Agent (e.g. upgrades of the Liqo control plane) together with their outcome.
+*/
+package activity
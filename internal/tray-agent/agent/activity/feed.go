@@ -0,0 +1,103 @@
+package activity
+
+import (
+	"sync"
+	"time"
+)
+
+//DefaultMaxEntries is the default maximum number of Entries kept by a Feed.
+const DefaultMaxEntries = 100
+
+//Outcome is the result of the operation described by an Entry.
+type Outcome string
+
+const (
+	//OutcomeInfo describes an operation in progress or with no specific result.
+	OutcomeInfo Outcome = "info"
+	//OutcomeSuccess describes an operation successfully completed.
+	OutcomeSuccess Outcome = "success"
+	//OutcomeFailure describes a failed operation.
+	OutcomeFailure Outcome = "failure"
+)
+
+//Entry is a single element of the activity Feed.
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	//Source identifies the Agent feature which performed the operation (e.g. "upgrade").
+	Source  string  `json:"source"`
+	Message string  `json:"message"`
+	Outcome Outcome `json:"outcome"`
+}
+
+//Feed singleton.
+var feed = NewFeed(DefaultMaxEntries)
+
+//GetFeed returns the Feed singleton.
+func GetFeed() *Feed {
+	return feed
+}
+
+//Feed is a bounded list of Entries. When full, the oldest Entries are discarded.
+type Feed struct {
+	//entries contains the Entries, from the oldest to the newest.
+	entries []*Entry
+	//maxEntries is the maximum number of Entries kept by the Feed.
+	maxEntries int
+	//callbacks are executed, in registration order, after the addition of each Entry.
+	callbacks []func(e *Entry)
+	sync.RWMutex
+}
+
+//NewFeed returns a Feed keeping at most maxEntries Entries.
+func NewFeed(maxEntries int) *Feed {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &Feed{maxEntries: maxEntries}
+}
+
+//Add adds a new Entry to the Feed and returns it.
+func (f *Feed) Add(source string, message string, outcome Outcome) *Entry {
+	e := &Entry{
+		Timestamp: time.Now(),
+		Source:    source,
+		Message:   message,
+		Outcome:   outcome,
+	}
+	f.Lock()
+	f.entries = append(f.entries, e)
+	if exceeding := len(f.entries) - f.maxEntries; exceeding > 0 {
+		f.entries = append([]*Entry(nil), f.entries[exceeding:]...)
+	}
+	callbacks := f.callbacks
+	f.Unlock()
+	for _, callback := range callbacks {
+		callback(e)
+	}
+	return e
+}
+
+//Entries returns the Entries of the Feed, from the newest to the oldest.
+func (f *Feed) Entries() []*Entry {
+	f.RLock()
+	defer f.RUnlock()
+	entries := make([]*Entry, 0, len(f.entries))
+	for i := len(f.entries) - 1; i >= 0; i-- {
+		entries = append(entries, f.entries[i])
+	}
+	return entries
+}
+
+//OnAdd registers a callback executed after the addition of each Entry.
+func (f *Feed) OnAdd(callback func(e *Entry)) {
+	f.Lock()
+	defer f.Unlock()
+	f.callbacks = append(f.callbacks, callback)
+}
+
+//Clear removes all the Entries of the Feed.
+func (f *Feed) Clear() {
+	f.Lock()
+	defer f.Unlock()
+	f.entries = nil
+}
@@ -0,0 +1,23 @@
+package activity
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFeed(t *testing.T) {
+	f := NewFeed(2)
+	var added []*Entry
+	f.OnAdd(func(e *Entry) {
+		added = append(added, e)
+	})
+	f.Add("test", "first", OutcomeInfo)
+	f.Add("test", "second", OutcomeSuccess)
+	third := f.Add("test", "third", OutcomeFailure)
+	entries := f.Entries()
+	if assert.Equal(t, 2, len(entries), "feed exceeded its maximum size") {
+		assert.Equal(t, third, entries[0], "entries are not sorted from the newest")
+		assert.Equal(t, "second", entries[1].Message)
+	}
+	assert.Equal(t, 3, len(added), "callbacks not executed for each entry")
+}
@@ -0,0 +1,15 @@
+/*
+Package api provides the local HTTP API of Liqo Agent, which allows the LiqoDash and custom frontends
+to consume the information collected by the Agent.
+
+The API exposes:
+
+* a REST endpoint (/api/v1/status) returning a snapshot of the current Agent status
+
+* a WebSocket endpoint (/api/v1/events) streaming in real time the incremental status and peer events,
+avoiding clients having to poll the REST endpoint.
+
+* if enabled, an endpoint (/metrics) exporting the internal metrics of the Agent (see package metrics) in the
+Prometheus text format, e.g. the latency of the handling of the cluster events and of the cache synchronization.
+*/
+package api
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"sync"
+	"time"
+)
+
+//subscriberBuffLength is the buffer length of the channel of each event subscriber.
+const subscriberBuffLength = 100
+
+//EventType identifies the kind of an Event streamed by the local API.
+type EventType string
+
+//EventType identifiers.
+const (
+	//EventSnapshot is the first Event sent on a newly opened stream, carrying the full StatusData.
+	EventSnapshot EventType = "snapshot"
+	//EventStatusChanged signals a change of the general Agent status (e.g. running status, cluster name).
+	EventStatusChanged EventType = "statusChanged"
+	//EventPeerAddedOrUpdated signals a newly discovered peer or a change on a known one.
+	EventPeerAddedOrUpdated EventType = "peerAddedOrUpdated"
+	//EventPeerDeleted signals the removal of a peer.
+	EventPeerDeleted EventType = "peerDeleted"
+	//EventActivity signals a new entry of the Agent activity feed.
+	EventActivity EventType = "activity"
+)
+
+//Event is a single message of the event stream.
+type Event struct {
+	//Type is the kind of the Event.
+	Type EventType `json:"type"`
+	//Timestamp is the time instant the Event was generated.
+	Timestamp time.Time `json:"timestamp"`
+	//Data is the payload of the Event, e.g. a *StatusData or a *PeerData.
+	Data interface{} `json:"data,omitempty"`
+}
+
+//NewEvent returns a new Event of type eventType carrying data.
+func NewEvent(eventType EventType, data interface{}) *Event {
+	return &Event{
+		Type:      eventType,
+		Timestamp: time.Now(),
+		Data:      data,
+	}
+}
+
+//StatusData is the digest of the Agent status served by the local API.
+type StatusData struct {
+	Running          bool        `json:"running"`
+	Mode             string      `json:"mode"`
+	ClusterName      string      `json:"clusterName"`
+	IncomingPeerings int         `json:"incomingPeerings"`
+	OutgoingPeerings int         `json:"outgoingPeerings"`
+	Peers            []*PeerData `json:"peers"`
+}
+
+//PeerData contains the information on a peer served by the local API.
+type PeerData struct {
+	ClusterID       string `json:"clusterID"`
+	ClusterName     string `json:"clusterName"`
+	OutgoingPeering bool   `json:"outgoingPeering"`
+	IncomingPeering bool   `json:"incomingPeering"`
+	//OutgoingPhase is the phase of the outgoing peering, empty if neither established nor requested.
+	OutgoingPhase client.PeeringPhase `json:"outgoingPhase,omitempty"`
+	//IncomingPhase is the phase of the incoming peering, empty if neither established nor requested.
+	IncomingPhase client.PeeringPhase `json:"incomingPhase,omitempty"`
+	//CpuQuota is the CPU quota shared by the peer in the active outgoing peering.
+	CpuQuota string `json:"cpuQuota,omitempty"`
+	//MemQuota is the memory quota shared by the peer in the active outgoing peering.
+	MemQuota string `json:"memQuota,omitempty"`
+}
+
+//subscriber is a consumer of the Events published on an eventHub.
+type subscriber struct {
+	//events is the channel on which the published Events are delivered.
+	events chan *Event
+}
+
+//eventHub dispatches the published Events to all the registered subscribers.
+type eventHub struct {
+	subscribers map[*subscriber]struct{}
+	sync.RWMutex
+}
+
+//newEventHub returns a new eventHub with no subscribers.
+func newEventHub() *eventHub {
+	return &eventHub{subscribers: make(map[*subscriber]struct{})}
+}
+
+//subscribe registers and returns a new subscriber.
+func (h *eventHub) subscribe() *subscriber {
+	h.Lock()
+	defer h.Unlock()
+	s := &subscriber{events: make(chan *Event, subscriberBuffLength)}
+	h.subscribers[s] = struct{}{}
+	return s
+}
+
+//unsubscribe removes a subscriber, closing its channel. This is a no-op for an already removed subscriber.
+func (h *eventHub) unsubscribe(s *subscriber) {
+	h.Lock()
+	defer h.Unlock()
+	if _, present := h.subscribers[s]; present {
+		delete(h.subscribers, s)
+		close(s.events)
+	}
+}
+
+//publish delivers an Event to all the subscribers. In order not to block the publisher, the Event is dropped
+//for the subscribers whose buffer is full.
+func (h *eventHub) publish(e *Event) {
+	h.RLock()
+	defer h.RUnlock()
+	for s := range h.subscribers {
+		select {
+		case s.events <- e:
+		default:
+		}
+	}
+}
+
+//subscribersLen returns the number of registered subscribers.
+func (h *eventHub) subscribersLen() int {
+	h.RLock()
+	defer h.RUnlock()
+	return len(h.subscribers)
+}
@@ -0,0 +1,363 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/history"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/metrics"
+	"golang.org/x/net/websocket"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	//StatusPath is the path of the REST endpoint serving the StatusData.
+	StatusPath = "/api/v1/status"
+	//EventsPath is the path of the WebSocket endpoint streaming the Events.
+	EventsPath = "/api/v1/events"
+	//TopologyPath is the path of the REST endpoint serving the Topology. Use the 'format' query parameter
+	//to request a specific TopologyFormat instead of JSON data.
+	TopologyPath = "/api/v1/topology"
+	//HistoryPath is the path of the REST endpoint serving the peering history. Use the 'days' query parameter
+	//to select the observation window (defaults to defaultHistoryDays) and 'clusterID' to filter a single peer.
+	HistoryPath = "/api/v1/history"
+	//CapacityPath is the path of the REST endpoint serving the client.CapacityReport of the home cluster.
+	CapacityPath = "/api/v1/capacity"
+	//MetricsPath is the path of the endpoint serving the metrics of the Agent in the Prometheus text format.
+	MetricsPath = "/metrics"
+	//defaultHistoryDays is the default observation window of the HistoryPath endpoint.
+	defaultHistoryDays = 7
+	//shutdownTimeout is the maximum amount of time waited for the server graceful shutdown.
+	shutdownTimeout = 5 * time.Second
+)
+
+//Server singleton.
+var server *Server
+
+//Server is the local HTTP API exposed by Liqo Agent.
+type Server struct {
+	//hub dispatches the published Events to the connected WebSocket clients.
+	hub *eventHub
+	//statusFunc returns the StatusData served by the local API.
+	statusFunc func() *StatusData
+	//historyStore is the Store providing the peering history. If nil, no history is available.
+	historyStore *history.Store
+	//capacityFunc returns the client.CapacityReport served by the local API. If nil, no report is available.
+	capacityFunc func() (*client.CapacityReport, error)
+	//metricsRegistry is the metrics.Registry served by the MetricsPath endpoint. If nil, no metrics are exported.
+	metricsRegistry *metrics.Registry
+	//allowedOrigins contains the browser origins allowed to open the event stream, in addition to the local ones.
+	allowedOrigins map[string]bool
+	//httpServer is the underlying HTTP server. It is nil when the Server is not running.
+	httpServer *http.Server
+	//done is closed at server shutdown to terminate the active streams.
+	done chan struct{}
+	sync.RWMutex
+}
+
+//GetServer returns the Server singleton. The Server is not started until Start() is called.
+func GetServer() *Server {
+	if server == nil {
+		server = &Server{
+			hub:            newEventHub(),
+			allowedOrigins: make(map[string]bool),
+			statusFunc: func() *StatusData {
+				return &StatusData{Peers: []*PeerData{}}
+			},
+		}
+	}
+	return server
+}
+
+//SetStatusFunc sets the function providing the StatusData served by the local API.
+func (s *Server) SetStatusFunc(statusFunc func() *StatusData) {
+	s.Lock()
+	defer s.Unlock()
+	if statusFunc != nil {
+		s.statusFunc = statusFunc
+	}
+}
+
+//SetAllowedOrigins sets the browser origins (e.g. "https://liqodash.example.com") allowed to open the event stream.
+//Requests with no Origin header or coming from a local origin are always allowed.
+func (s *Server) SetAllowedOrigins(origins []string) {
+	s.Lock()
+	defer s.Unlock()
+	s.allowedOrigins = make(map[string]bool)
+	for _, o := range origins {
+		s.allowedOrigins[o] = true
+	}
+}
+
+//Running returns whether the Server is currently serving requests.
+func (s *Server) Running() bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.httpServer != nil
+}
+
+//Start starts serving the local API on the provided address.
+func (s *Server) Start(address string) error {
+	s.Lock()
+	defer s.Unlock()
+	if s.httpServer != nil {
+		return errors.New("the local API is already running")
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	s.done = make(chan struct{})
+	s.httpServer = &http.Server{Handler: s.handler()}
+	go func(srv *http.Server) {
+		_ = srv.Serve(listener)
+	}(s.httpServer)
+	return nil
+}
+
+//Stop gracefully stops the Server, closing all the active event streams.
+func (s *Server) Stop() {
+	s.Lock()
+	defer s.Unlock()
+	if s.httpServer == nil {
+		return
+	}
+	close(s.done)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	_ = s.httpServer.Shutdown(ctx)
+	s.httpServer = nil
+}
+
+//Publish streams an Event to all the clients currently connected to the event stream.
+func (s *Server) Publish(e *Event) {
+	s.hub.publish(e)
+}
+
+//handler returns the http.Handler routing the local API endpoints.
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(StatusPath, s.serveStatus)
+	mux.HandleFunc(TopologyPath, s.serveTopology)
+	mux.HandleFunc(HistoryPath, s.serveHistory)
+	mux.HandleFunc(CapacityPath, s.serveCapacity)
+	mux.HandleFunc(MetricsPath, s.serveMetrics)
+	mux.Handle(EventsPath, websocket.Server{
+		Handshake: s.checkOrigin,
+		Handler:   s.serveEvents,
+	})
+	return mux
+}
+
+//SetHistoryStore sets the Store providing the peering history served by the local API.
+func (s *Server) SetHistoryStore(store *history.Store) {
+	s.Lock()
+	defer s.Unlock()
+	s.historyStore = store
+}
+
+//SetCapacityFunc sets the function providing the client.CapacityReport served by the local API.
+func (s *Server) SetCapacityFunc(capacityFunc func() (*client.CapacityReport, error)) {
+	s.Lock()
+	defer s.Unlock()
+	s.capacityFunc = capacityFunc
+}
+
+//SetMetricsRegistry sets the metrics.Registry served by the local API. A nil Registry disables the MetricsPath
+//endpoint.
+func (s *Server) SetMetricsRegistry(registry *metrics.Registry) {
+	s.Lock()
+	defer s.Unlock()
+	s.metricsRegistry = registry
+}
+
+//status returns the current StatusData.
+func (s *Server) status() *StatusData {
+	s.RLock()
+	statusFunc := s.statusFunc
+	s.RUnlock()
+	return statusFunc()
+}
+
+//serveStatus is the handler of the StatusPath endpoint.
+func (s *Server) serveStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.status())
+}
+
+//serveTopology is the handler of the TopologyPath endpoint.
+func (s *Server) serveTopology(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	t := BuildTopology(s.status())
+	switch format := TopologyFormat(r.URL.Query().Get("format")); format {
+	case "":
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(t)
+	case TopologyFormatDOT:
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		_ = t.Export(format, w)
+	case TopologyFormatSVG, TopologyFormatPNG:
+		//the image is buffered in order to report rendering errors with a proper status code
+		buf := &bytes.Buffer{}
+		if err := t.Export(format, buf); err != nil {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if format == TopologyFormatSVG {
+			w.Header().Set("Content-Type", "image/svg+xml")
+		} else {
+			w.Header().Set("Content-Type", "image/png")
+		}
+		_, _ = buf.WriteTo(w)
+	default:
+		http.Error(w, "unknown topology format", http.StatusBadRequest)
+	}
+}
+
+//serveHistory is the handler of the HistoryPath endpoint.
+func (s *Server) serveHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	days := defaultHistoryDays
+	if d := r.URL.Query().Get("days"); d != "" {
+		var err error
+		if days, err = strconv.Atoi(d); err != nil || days <= 0 {
+			http.Error(w, "invalid number of days", http.StatusBadRequest)
+			return
+		}
+	}
+	s.RLock()
+	store := s.historyStore
+	s.RUnlock()
+	timelines := make([]*history.Timeline, 0)
+	if store != nil {
+		until := time.Now()
+		clusterID := r.URL.Query().Get("clusterID")
+		for _, t := range store.Timelines(until.AddDate(0, 0, -days), until) {
+			if clusterID == "" || t.ClusterID == clusterID {
+				timelines = append(timelines, t)
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(timelines)
+}
+
+//serveCapacity is the handler of the CapacityPath endpoint.
+func (s *Server) serveCapacity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.RLock()
+	capacityFunc := s.capacityFunc
+	s.RUnlock()
+	if capacityFunc == nil {
+		http.Error(w, "capacity report not available", http.StatusServiceUnavailable)
+		return
+	}
+	report, err := capacityFunc()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+//serveMetrics is the handler of the MetricsPath endpoint.
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.RLock()
+	registry := s.metricsRegistry
+	s.RUnlock()
+	if registry == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_ = registry.WriteText(w)
+}
+
+//serveEvents is the handler of the EventsPath endpoint. After a first EventSnapshot, it streams all the
+//published Events until the client disconnects or the Server is stopped.
+func (s *Server) serveEvents(ws *websocket.Conn) {
+	sub := s.hub.subscribe()
+	defer s.hub.unsubscribe(sub)
+	s.RLock()
+	done := s.done
+	s.RUnlock()
+	//the stream is unidirectional: client messages are discarded and only used to detect disconnections.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var msg []byte
+		for {
+			if err := websocket.Message.Receive(ws, &msg); err != nil {
+				return
+			}
+		}
+	}()
+	if err := websocket.JSON.Send(ws, NewEvent(EventSnapshot, s.status())); err != nil {
+		return
+	}
+	for {
+		select {
+		case e, open := <-sub.events:
+			if !open {
+				return
+			}
+			if err := websocket.JSON.Send(ws, e); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		case <-done:
+			return
+		}
+	}
+}
+
+//checkOrigin validates the Origin of a WebSocket handshake request. Since the local API is meant to be
+//reached from the local machine, only non-browser clients (no Origin header), local origins and explicitly
+//allowed ones are accepted.
+func (s *Server) checkOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	config.Origin = u
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return nil
+	}
+	s.RLock()
+	defer s.RUnlock()
+	if s.allowedOrigins[origin] {
+		return nil
+	}
+	return errors.New("origin not allowed")
+}
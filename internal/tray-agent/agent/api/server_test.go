@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/history"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/metrics"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testStatus() *StatusData {
+	return &StatusData{
+		Running:     true,
+		ClusterName: "home",
+		Peers:       []*PeerData{{ClusterID: "cl1", ClusterName: "test1"}},
+	}
+}
+
+func TestServer_Status(t *testing.T) {
+	s := GetServer()
+	s.SetStatusFunc(testStatus)
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+	resp, err := http.Get(ts.URL + StatusPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data := &StatusData{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(data), "status response is not valid")
+	assert.Equal(t, "home", data.ClusterName)
+	assert.Equal(t, 1, len(data.Peers))
+	resp2, err := http.Post(ts.URL+StatusPath, "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp2.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp2.StatusCode)
+}
+
+func TestServer_History(t *testing.T) {
+	s := GetServer()
+	store, _ := history.NewStore("", 0, 0)
+	_ = store.Add(history.Record{ClusterID: "cl1", Direction: history.DirectionOutgoing, Connected: true})
+	_ = store.Add(history.Record{ClusterID: "cl2", Direction: history.DirectionIncoming, Connected: true})
+	s.SetHistoryStore(store)
+	defer s.SetHistoryStore(nil)
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+	resp, err := http.Get(ts.URL + HistoryPath + "?days=1&clusterID=cl1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var timelines []*history.Timeline
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&timelines), "history response is not valid")
+	if assert.Equal(t, 1, len(timelines)) {
+		assert.Equal(t, "cl1", timelines[0].ClusterID)
+		assert.Equal(t, 1, len(timelines[0].Outgoing))
+	}
+	resp2, err := http.Get(ts.URL + HistoryPath + "?days=none")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp2.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp2.StatusCode)
+}
+
+func TestServer_Capacity(t *testing.T) {
+	s := GetServer()
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+	resp, err := http.Get(ts.URL + CapacityPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "capacity served without a provider")
+	s.SetCapacityFunc(func() (*client.CapacityReport, error) {
+		return &client.CapacityReport{Local: client.CapacityUsage{Nodes: 1, CpuAllocatable: 4000}}, nil
+	})
+	defer s.SetCapacityFunc(nil)
+	resp, err = http.Get(ts.URL + CapacityPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	report := &client.CapacityReport{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(report), "capacity response is not valid")
+	assert.Equal(t, int64(4000), report.Local.CpuAllocatable)
+}
+
+func TestServer_Metrics(t *testing.T) {
+	s := GetServer()
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+	resp, err := http.Get(ts.URL + MetricsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "metrics served while disabled")
+	registry := metrics.NewRegistry()
+	registry.RegisterGauge("peers", "Number of peers", func() []metrics.Sample {
+		return []metrics.Sample{{Value: 2}}
+	})
+	s.SetMetricsRegistry(registry)
+	defer s.SetMetricsRegistry(nil)
+	resp, err = http.Get(ts.URL + MetricsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+	assert.Contains(t, string(body), "liqo_agent_peers 2\n")
+}
+
+func TestServer_Events(t *testing.T) {
+	s := GetServer()
+	s.SetStatusFunc(testStatus)
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + EventsPath
+	ws, err := websocket.Dial(wsURL, "", "http://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	//the first Event is the snapshot
+	var e Event
+	assert.NoError(t, websocket.JSON.Receive(ws, &e))
+	assert.Equal(t, EventSnapshot, e.Type)
+	//test incremental events
+	s.Publish(NewEvent(EventPeerDeleted, &PeerData{ClusterID: "cl1"}))
+	assert.NoError(t, websocket.JSON.Receive(ws, &e))
+	assert.Equal(t, EventPeerDeleted, e.Type)
+	peer, ok := e.Data.(map[string]interface{})
+	if assert.True(t, ok, "event payload not received") {
+		assert.Equal(t, "cl1", peer["clusterID"])
+	}
+	//test origin check
+	_, err = websocket.Dial(wsURL, "", "http://malicious.example.com/")
+	assert.Error(t, err, "stream opened from a not allowed origin")
+	s.SetAllowedOrigins([]string{"https://dash.example.com"})
+	ws2, err := websocket.Dial(wsURL, "", "https://dash.example.com")
+	if assert.NoError(t, err, "stream not opened from an allowed origin") {
+		_ = ws2.Close()
+	}
+}
+
+func TestEventHub(t *testing.T) {
+	h := newEventHub()
+	s1 := h.subscribe()
+	s2 := h.subscribe()
+	assert.Equal(t, 2, h.subscribersLen())
+	h.publish(NewEvent(EventStatusChanged, nil))
+	assert.Equal(t, EventStatusChanged, (<-s1.events).Type)
+	assert.Equal(t, EventStatusChanged, (<-s2.events).Type)
+	//a full buffer must not block the publisher
+	for n := 0; n < subscriberBuffLength+1; n++ {
+		h.publish(NewEvent(EventStatusChanged, nil))
+	}
+	h.unsubscribe(s1)
+	h.unsubscribe(s1)
+	assert.Equal(t, 1, h.subscribersLen())
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+//TopologyFormat is an export format for a Topology.
+type TopologyFormat string
+
+//TopologyFormat values.
+const (
+	//TopologyFormatDOT exports the Topology in the Graphviz DOT language.
+	TopologyFormatDOT TopologyFormat = "dot"
+	//TopologyFormatSVG exports the Topology as an SVG image. It requires the Graphviz 'dot' command.
+	TopologyFormatSVG TopologyFormat = "svg"
+	//TopologyFormatPNG exports the Topology as a PNG image. It requires the Graphviz 'dot' command.
+	TopologyFormatPNG TopologyFormat = "png"
+)
+
+//TopologyFormats contains all the available TopologyFormat values.
+var TopologyFormats = []TopologyFormat{
+	TopologyFormatDOT,
+	TopologyFormatSVG,
+	TopologyFormatPNG,
+}
+
+//homeNodeID is the id of the TopologyNode representing the home cluster.
+const homeNodeID = "home"
+
+//dotCommand is the Graphviz command used to render a Topology as an image.
+const dotCommand = "dot"
+
+//Topology is the graph of the peerings between the home cluster and its peers.
+type Topology struct {
+	Nodes []*TopologyNode `json:"nodes"`
+	Edges []*TopologyEdge `json:"edges"`
+}
+
+//TopologyNode is a cluster in a Topology.
+type TopologyNode struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	//Home identifies the node representing the home cluster.
+	Home bool `json:"home"`
+}
+
+//TopologyEdge is a peering in a Topology. The edge is directed from the cluster sharing its resources to the one
+//consuming them. A peer with no peerings is linked to the home cluster by an edge in the PeeringNone phase.
+type TopologyEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	//Phase is the phase of the peering, "None" for a peer with no peerings.
+	Phase string `json:"phase"`
+	//Resources is a literal description of the shared resources, if known.
+	Resources string `json:"resources,omitempty"`
+}
+
+//BuildTopology builds the Topology described by a StatusData. Peers are sorted by ClusterID
+//in order to produce a stable output.
+func BuildTopology(status *StatusData) *Topology {
+	homeLabel := status.ClusterName
+	if homeLabel == "" {
+		homeLabel = homeNodeID
+	}
+	t := &Topology{
+		Nodes: []*TopologyNode{{ID: homeNodeID, Label: homeLabel, Home: true}},
+		Edges: []*TopologyEdge{},
+	}
+	peers := make([]*PeerData, len(status.Peers))
+	copy(peers, status.Peers)
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].ClusterID < peers[j].ClusterID
+	})
+	for _, peer := range peers {
+		label := peer.ClusterName
+		if label == "" {
+			label = peer.ClusterID
+		}
+		t.Nodes = append(t.Nodes, &TopologyNode{ID: peer.ClusterID, Label: label})
+		out := edgePhase(peer.OutgoingPhase, peer.OutgoingPeering)
+		in := edgePhase(peer.IncomingPhase, peer.IncomingPeering)
+		if out != client.PeeringNone {
+			edge := &TopologyEdge{From: peer.ClusterID, To: homeNodeID, Phase: out.String()}
+			if out == client.PeeringEstablished {
+				edge.Resources = describeResources(peer)
+			}
+			t.Edges = append(t.Edges, edge)
+		}
+		if in != client.PeeringNone {
+			t.Edges = append(t.Edges, &TopologyEdge{From: homeNodeID, To: peer.ClusterID, Phase: in.String()})
+		}
+		if out == client.PeeringNone && in == client.PeeringNone {
+			t.Edges = append(t.Edges, &TopologyEdge{From: homeNodeID, To: peer.ClusterID,
+				Phase: client.PeeringNone.String()})
+		}
+	}
+	return t
+}
+
+//edgePhase returns the phase of a peering, considering established a connected peering whose phase is unknown.
+func edgePhase(phase client.PeeringPhase, connected bool) client.PeeringPhase {
+	if phase == client.PeeringNone && connected {
+		return client.PeeringEstablished
+	}
+	return phase
+}
+
+//describeResources returns a literal description of the resources shared by a peer.
+func describeResources(peer *PeerData) string {
+	var res []string
+	if peer.CpuQuota != "" {
+		res = append(res, "CPU: "+peer.CpuQuota)
+	}
+	if peer.MemQuota != "" {
+		res = append(res, "RAM: "+peer.MemQuota)
+	}
+	return strings.Join(res, ", ")
+}
+
+//DOT returns the description of the Topology in the Graphviz DOT language.
+func (t *Topology) DOT() string {
+	b := strings.Builder{}
+	b.WriteString("digraph liqo {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box, style=rounded];\n")
+	for _, n := range t.Nodes {
+		if n.Home {
+			b.WriteString(fmt.Sprintf("\t%q [label=%q, style=\"rounded,bold\"];\n", n.ID, n.Label))
+		} else {
+			b.WriteString(fmt.Sprintf("\t%q [label=%q];\n", n.ID, n.Label))
+		}
+	}
+	for _, e := range t.Edges {
+		if attrs := e.dotAttributes(); len(attrs) > 0 {
+			b.WriteString(fmt.Sprintf("\t%q -> %q [%s];\n", e.From, e.To, strings.Join(attrs, ", ")))
+		} else {
+			b.WriteString(fmt.Sprintf("\t%q -> %q;\n", e.From, e.To))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+//dotAttributes returns the DOT attributes of the TopologyEdge. Established peerings are drawn as solid edges
+//labeled with the shared resources, the other phases as dashed edges labeled with the phase (red in case of error),
+//while peers with no peerings are linked by an undirected dotted edge.
+func (e *TopologyEdge) dotAttributes() []string {
+	var attrs []string
+	switch e.Phase {
+	case client.PeeringEstablished.String():
+		if e.Resources != "" {
+			attrs = append(attrs, fmt.Sprintf("label=%q", e.Resources))
+		}
+	case client.PeeringNone.String():
+		attrs = append(attrs, "style=dotted", "dir=none", "color=gray")
+	case client.PeeringError.String():
+		attrs = append(attrs, fmt.Sprintf("label=%q", e.Phase), "style=dashed", "color=red")
+	default:
+		attrs = append(attrs, fmt.Sprintf("label=%q", e.Phase), "style=dashed")
+	}
+	return attrs
+}
+
+//Export writes the Topology to w in the requested TopologyFormat. Image formats are rendered
+//using the Graphviz 'dot' command, which has to be available on the local system.
+func (t *Topology) Export(format TopologyFormat, w io.Writer) error {
+	switch format {
+	case TopologyFormatDOT:
+		_, err := io.WriteString(w, t.DOT())
+		return err
+	case TopologyFormatSVG, TopologyFormatPNG:
+		path, err := exec.LookPath(dotCommand)
+		if err != nil {
+			return errors.New("the Graphviz 'dot' command is required to export images")
+		}
+		cmd := exec.Command(path, "-T"+string(format))
+		cmd.Stdin = strings.NewReader(t.DOT())
+		cmd.Stdout = w
+		stderr := &bytes.Buffer{}
+		cmd.Stderr = stderr
+		if err = cmd.Run(); err != nil {
+			return fmt.Errorf("topology rendering failed: %s", strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown topology format %q", format)
+	}
+}
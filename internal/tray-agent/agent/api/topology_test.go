@@ -0,0 +1,49 @@
+package api
+
+import (
+	"bytes"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestBuildTopology(t *testing.T) {
+	status := &StatusData{
+		ClusterName: "home-cluster",
+		Peers: []*PeerData{
+			{ClusterID: "cl2", IncomingPeering: true},
+			{ClusterID: "cl1", ClusterName: "test1", OutgoingPeering: true, CpuQuota: "2", MemQuota: "4Gi"},
+			{ClusterID: "cl3", ClusterName: "test3"},
+			{ClusterID: "cl4", OutgoingPhase: client.PeeringAuthenticating, IncomingPhase: client.PeeringError},
+		},
+	}
+	topology := BuildTopology(status)
+	if assert.Equal(t, 5, len(topology.Nodes)) {
+		assert.True(t, topology.Nodes[0].Home, "the first node should be the home cluster")
+		assert.Equal(t, "home-cluster", topology.Nodes[0].Label)
+		//peers are sorted by ClusterID and unnamed peers are labeled with their ClusterID
+		assert.Equal(t, "cl1", topology.Nodes[1].ID)
+		assert.Equal(t, "cl2", topology.Nodes[2].Label)
+	}
+	//every peer is linked to the home cluster, even with no peerings
+	if assert.Equal(t, 5, len(topology.Edges)) {
+		assert.Equal(t, &TopologyEdge{From: "cl1", To: homeNodeID, Phase: "Established",
+			Resources: "CPU: 2, RAM: 4Gi"}, topology.Edges[0])
+		assert.Equal(t, &TopologyEdge{From: homeNodeID, To: "cl2", Phase: "Established"}, topology.Edges[1])
+		assert.Equal(t, &TopologyEdge{From: homeNodeID, To: "cl3", Phase: "None"}, topology.Edges[2])
+		assert.Equal(t, &TopologyEdge{From: "cl4", To: homeNodeID, Phase: "Authenticating"}, topology.Edges[3])
+		assert.Equal(t, &TopologyEdge{From: homeNodeID, To: "cl4", Phase: "Error"}, topology.Edges[4])
+	}
+	dot := topology.DOT()
+	assert.True(t, strings.HasPrefix(dot, "digraph liqo {"))
+	assert.Contains(t, dot, `"cl1" -> "home" [label="CPU: 2, RAM: 4Gi"];`)
+	assert.Contains(t, dot, `"home" -> "cl2";`)
+	assert.Contains(t, dot, `"home" -> "cl3" [style=dotted, dir=none, color=gray];`)
+	assert.Contains(t, dot, `"cl4" -> "home" [label="Authenticating", style=dashed];`)
+	assert.Contains(t, dot, `"home" -> "cl4" [label="Error", style=dashed, color=red];`)
+	buf := &bytes.Buffer{}
+	assert.NoError(t, topology.Export(TopologyFormatDOT, buf))
+	assert.Equal(t, dot, buf.String())
+	assert.Error(t, topology.Export("unknown", buf))
+}
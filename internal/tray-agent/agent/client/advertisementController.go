@@ -5,14 +5,11 @@ import (
 )
 
 //createAdvertisementController creates a new CRDController for the Liqo Advertisement CRD.
-func createAdvertisementController(kubeconfig string) (*CRDController, error) {
-	controller := &CRDController{}
+func createAdvertisementController(ctrl *AgentController, kubeconfig string) (*CRDController, error) {
 	//init client
 	newClient, err := advertisementApi.CreateAdvertisementClient(kubeconfig, nil, false, nil)
 	if err != nil {
 		return nil, err
 	}
-	controller.CRDClient = newClient
-	controller.resource = string(CRAdvertisement)
-	return controller, nil
+	return newCRDController(newClient, CRAdvertisement, ctrl.advertisementEventHandler), nil
 }
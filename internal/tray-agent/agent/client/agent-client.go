@@ -5,24 +5,32 @@ import (
 	"errors"
 	"flag"
 	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/logging"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/tracing"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/xdg"
 	"github.com/liqotech/liqo/pkg/crdClient"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 )
 
 const (
 	//EnvLiqoKConfig defines the env var containing the path of the kubeconfig file of the
 	//cluster associated to Liqo Agent.
 	EnvLiqoKConfig = "LIQO_KCONFIG"
-	//EnvLiqoPath defines the env var containing the path of the root directory of the Liqo Agent on the local file system.
-	EnvLiqoPath = "LIQO_PATH"
+	//EnvLiqoPath defines the env var selecting a single directory for all the Agent files (see xdg.EnvLiqoPath).
+	EnvLiqoPath = xdg.EnvLiqoPath
 )
 
+//logger writes the log entries of the AgentController.
+var logger = logging.New("client")
+
 //AgentController singleton.
 var agentCtrl *AgentController
 
@@ -57,25 +65,49 @@ var kubeconfArg *string
 //flagOnce prevents the program arguments flag redefinition which would cause panic.
 var flagOnce sync.Once
 
-//NotifyChan is the wrapper type for generic data sent over a NotifyChannel. After receiving such element from a
-//chan, it is then possible to try its conversion into a specific type.
+//NotifyDataGeneric is the wrapper type for generic data published on a Topic. After receiving such element from a
+//Subscription, it is then possible to try its conversion into a specific type.
 type NotifyDataGeneric interface{}
 
 //AgentController is the data structure that manages Tray Agent interaction with the cluster.
 type AgentController struct {
-	//notifyChannels is a set of channels used by the cache logic to notify a watched event.
-	notifyChannels map[NotifyChannel]chan NotifyDataGeneric
+	//events is the EventBus used by the cache logic to notify a watched event.
+	events *EventBus
 	//kubeClient is a standard kubernetes client.
 	kubeClient kubernetes.Interface
 	//agentConf contains Liqo Agent configuration parameters acquired from the cluster.
 	agentConf *agentConfiguration
 	//crdManager manages CRD operations.
-	*crdManager
+	crdManager *crdManager
+	//clientsMutex protects kubeClient and crdManager, replaced when the AgentController connects again (e.g. see
+	//SwitchContext) while the handlers of the previous caches may still be running.
+	clientsMutex sync.RWMutex
+	//coreCache watches the standard kubernetes resources.
+	coreCache *coreCache
 	//valid specifies whether the provided kubeconfig actually describes a correct configuration.
 	valid bool
 	//connected specifies whether all AgentController components are correctly up and running.
 	connected bool
-	mocked    bool
+	//heartbeat contains the reachability of the API server, periodically probed.
+	heartbeat heartbeatState
+	//cacheSync contains the *cacheSync progress of the last warm-up of the caches.
+	cacheSync atomic.Value
+	//watchDenied contains the resources the user is not allowed to watch, whose caches are fed by polling.
+	watchDenied map[watchedResource]bool
+	//pollingMutex protects watchDenied.
+	pollingMutex sync.RWMutex
+	//name is the name of an additional cluster (see ConnectCluster), empty for the main one.
+	name string
+	//kubeconfig is the path of the kubeconfig file of the cluster, whose context is used (the current one if empty).
+	kubeconfig string
+	context    string
+	//contextKubeconfig is the copy of the kubeconfig file selecting context, created for the CRD clients.
+	contextKubeconfig string
+	//clusters contains the AgentControllers of the additional clusters, by name.
+	clusters map[string]*AgentController
+	//clustersMutex protects clusters.
+	clustersMutex sync.RWMutex
+	mocked        bool
 }
 
 //Mocked returns if the AgentController is mocked (true).
@@ -88,26 +120,73 @@ func (ctrl *AgentController) Connected() bool {
 	return ctrl.connected
 }
 
-//NotifyChannel returns the NotifyChannel of type 'channelType'.
-func (ctrl *AgentController) NotifyChannel(channelType NotifyChannel) chan NotifyDataGeneric {
-	return ctrl.notifyChannels[channelType]
+//kube returns the kubernetes client of the AgentController, nil if not created yet.
+func (ctrl *AgentController) kube() kubernetes.Interface {
+	ctrl.clientsMutex.RLock()
+	defer ctrl.clientsMutex.RUnlock()
+	return ctrl.kubeClient
+}
+
+//setKubeClient replaces the kubernetes client of the AgentController.
+func (ctrl *AgentController) setKubeClient(kubeClient kubernetes.Interface) {
+	ctrl.clientsMutex.Lock()
+	defer ctrl.clientsMutex.Unlock()
+	ctrl.kubeClient = kubeClient
+}
+
+//Events returns the EventBus on which the AgentController publishes the cluster events.
+func (ctrl *AgentController) Events() *EventBus {
+	return ctrl.events
 }
 
-//StartCaches starts each available AgentController cache.
-func (ctrl *AgentController) StartCaches() error {
-	for _, crdCtrl := range ctrl.crdManager.clientMap {
-		if err := crdCtrl.StartCache(); err != nil {
+//StartCaches starts each available AgentController cache. Their initial synchronization is then awaited
+//concurrently, and its progress is reported by CacheSyncProgress.
+func (ctrl *AgentController) StartCaches() (err error) {
+	ctx, span := tracing.Start(context.Background(), "startCaches", nil)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+	var targets []syncTarget
+	for resource, crdCtrl := range ctrl.crds().clientMap {
+		crdCtrl.polling = ctrl.polled(watchedResource{Group: customResourceGroup(resource), Resource: string(resource)})
+		_, cacheSpan := tracing.Start(ctx, "startCache", tracing.Attributes{"resource": string(resource),
+			"polling": crdCtrl.polling != nil})
+		err = crdCtrl.StartCache()
+		cacheSpan.SetError(err)
+		cacheSpan.End()
+		if err != nil {
 			return err
 		}
+		targets = append(targets, crdCtrl.cache.syncTarget())
 	}
+	ctrl.startCoreCache()
+	targets = append(targets, ctrl.coreCache.syncTargets()...)
+	span.SetAttribute("caches", len(targets))
+	ctrl.warmUpCaches(targets)
 	return nil
 }
 
 //StopCaches stops all the CR caches running for the AgentController.
 func (ctrl *AgentController) StopCaches() {
-	for _, crdCtrl := range ctrl.crdManager.clientMap {
+	for _, crdCtrl := range ctrl.crds().clientMap {
 		crdCtrl.StopCache()
 	}
+	ctrl.stopCoreCache()
+}
+
+//RestartCaches stops and restarts the AgentController caches, discarding their content: the resources are
+//listed again from the cluster.
+func (ctrl *AgentController) RestartCaches() error {
+	if !ctrl.Connected() {
+		return newError(ErrNotConnected, "restart caches", nil)
+	}
+	logger.Debug("restarting the caches")
+	ctrl.StopCaches()
+	if err := ctrl.StartCaches(); err != nil {
+		return ClassifyError("restart caches", err)
+	}
+	return nil
 }
 
 /*acquireKubeconfig sets the EnvLiqoKConfig env variable.
@@ -120,7 +199,8 @@ the client connect to the local cluster.
 
 - If none of the two options are available, it defaults to $HOME/.kube/config.
 
-- If the selected path does not point to an existing file, users are asked to manually select a valid one.
+- If the selected path does not point to an existing file, users are asked to manually select a valid one, unless the
+Agent is running in a pod: the in-cluster configuration is then used.
 
 - At the end of the process, the env var EnvLiqoKConfig is set only if an existing file has been indicated.
 */
@@ -153,7 +233,10 @@ func acquireKubeconfig() {
 		}
 		//CASE 3: use default value
 		//check if selected path actually match a file
-		if _, err := os.Stat(*kubeconfArg); os.IsNotExist(err) {
+		if _, err := os.Stat(*kubeconfArg); os.IsNotExist(err) && InCluster() {
+			//running in a pod: the in-cluster configuration is used, with no kubeconfig file
+			logger.Info("no kubeconfig file found, using the in-cluster configuration")
+		} else if os.IsNotExist(err) {
 			//CASE 4: ask manual file selection
 			ok, _ := dlgs.Question("NO VALID KUBECONFIG FILE FOUND",
 				"Liqo could not find a valid kubeconfig file.\n "+
@@ -188,68 +271,125 @@ func acquireKubeconfig() {
 	}
 }
 
-//createKubeClient creates a new out-of-cluster client from a kubeconfig file.
-//If no value for kubeconfig is provided, it returns an error.
-//
-//The file path is retrieved from the env var specified by EnvLiqoKConfig.
-func createKubeClient() (kubernetes.Interface, error) {
+//inClusterConfig returns the configuration of the service account of the pod the Agent is running in.
+var inClusterConfig = rest.InClusterConfig
+
+//InCluster returns whether the Agent is running in a pod of a cluster, so that it can connect to it with the
+//in-cluster configuration when no kubeconfig file is provided.
+func InCluster() bool {
+	_, err := inClusterConfig()
+	return err == nil
+}
+
+//createKubeClient creates a new client from a context of a kubeconfig file. If context is empty, the current one
+//is used. If no value for kubeconfig is provided, the in-cluster configuration is used, if available; otherwise, it
+//returns an error.
+func createKubeClient(kubeconfig string, context string) (kubernetes.Interface, error) {
 	if mockedController {
 		return fake.NewSimpleClientset(), nil
 	}
-	kubeconfig, ok := os.LookupEnv(EnvLiqoKConfig)
-	if !ok || kubeconfig == "" {
-		return nil, errors.New("no kubeconfig provided")
+	if kubeconfig == "" {
+		//the service account token is read again by the client as it is rotated
+		cfg, err := inClusterConfig()
+		if err != nil {
+			return nil, errors.New("no kubeconfig provided")
+		}
+		return kubernetes.NewForConfig(cfg)
 	}
-	cfg, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
+		&clientcmd.ConfigOverrides{CurrentContext: context}).ClientConfig()
 	if err != nil {
 		return nil, err
 	}
 	return kubernetes.NewForConfig(cfg)
 }
 
+//newAgentController returns an AgentController, not yet connected, for a context of a kubeconfig file (the current
+//one if context is empty).
+func newAgentController(kubeconfig string, context string) *AgentController {
+	ctrl := &AgentController{
+		agentConf:  &agentConfiguration{},
+		kubeconfig: kubeconfig,
+		context:    context,
+		mocked:     mockedController,
+		//the EventBus is kept during the entire Agent execution.
+		events: NewEventBus(),
+	}
+	return ctrl
+}
+
 //GetAgentController returns an initialized AgentController singleton.
 func GetAgentController() *AgentController {
 	if agentCtrl == nil {
-		agentCtrl = &AgentController{
-			agentConf: &agentConfiguration{},
-		}
-		agentCtrl.mocked = mockedController
-		//init the notifyChannels that are kept open during the entire Agent execution.
-		agentCtrl.notifyChannels = make(map[NotifyChannel]chan NotifyDataGeneric)
-		for _, i := range notifyChannelNames {
-			agentCtrl.notifyChannels[i] = make(chan NotifyDataGeneric, notifyBuffLength)
-		}
 		var err error
 		//acquire configuration, try to connect clients, start caches.
 		acquireKubeconfig()
-		if agentCtrl.kubeClient, err = createKubeClient(); err == nil {
+		agentCtrl = newAgentController(os.Getenv(EnvLiqoKConfig), "")
+		var kubeClient kubernetes.Interface
+		if kubeClient, err = createKubeClient(agentCtrl.kubeconfig, agentCtrl.context); err == nil {
+			agentCtrl.setKubeClient(kubeClient)
 			if err = agentCtrl.initCRDManager(); err == nil {
-				if agentCtrl.ConnectionTest() {
-					if err = agentCtrl.StartCaches(); err == nil {
-						agentCtrl.connected = true
-						//init configuration data
-						agentCtrl.acquireClusterConfiguration()
-					} else {
-						//stop already started caches since Agent cannot work
-						//with a partially running system.
-						agentCtrl.StopCaches()
-					}
-				}
-
+				//transient connection failures are retried following the configured BackoffPolicy
+				conf, _ := GetLocalConfig()
+				err = Retry(context.TODO(), conf.GetBackoffPolicy(), agentCtrl.connect)
 			}
 		}
+		if err != nil {
+			logger.Warning("cannot connect to the cluster", "err", err)
+		}
 	}
 	return agentCtrl
 }
 
+//connect tests the connection to the cluster and starts the AgentController caches.
+func (ctrl *AgentController) connect() error {
+	if err := ctrl.checkConnection(); err != nil {
+		return err
+	}
+	ctrl.checkWatchPermissions(context.TODO())
+	if err := ctrl.StartCaches(); err != nil {
+		//stop already started caches since Agent cannot work
+		//with a partially running system.
+		ctrl.StopCaches()
+		return ClassifyError("start caches", err)
+	}
+	ctrl.connected = true
+	logger.Info("connected to the cluster", "context", ctrl.context)
+	//init configuration data
+	ctrl.acquireClusterConfiguration()
+	return nil
+}
+
+//Reconnect connects the AgentController to its cluster again, if not connected: the clients are rebuilt from the
+//kubeconfig file, so that a fixed file or an API server available again are picked up. The caches are started
+//again, and their events are delivered on the same EventBus.
+func (ctrl *AgentController) Reconnect() error {
+	if ctrl.Connected() {
+		return nil
+	}
+	ctrl.disconnect()
+	if err := ctrl.connectCluster(); err != nil {
+		ctrl.disconnect()
+		return err
+	}
+	return nil
+}
+
 //ConnectionTest checks the validity of the provided kubernetes configuration via
 //kubeconfig file by trying to establish a connection to the API server.
 func (ctrl *AgentController) ConnectionTest() bool {
-	_, err := ctrl.kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{
+	_ = ctrl.checkConnection()
+	return ctrl.valid
+}
+
+//checkConnection tries to establish a connection to the API server, returning the (classified) failure.
+func (ctrl *AgentController) checkConnection() error {
+	_, err := ctrl.kube().CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{
 		LabelSelector: masterNodeLabel,
 	})
 	if err == nil {
 		ctrl.valid = true
 	}
-	return ctrl.valid
+	return ClassifyError("connection test", err)
 }
@@ -16,4 +16,8 @@ func TestAgentControllerComponentsReadiness(t *testing.T) {
 		assert.NotNilf(t, crdCtrl, "%v CRDController is nil", crName)
 		assert.Truef(t, crdCtrl.Running(), "%v CRDController is not running", crName)
 	}
+	_, err := ctrl.StorageReport()
+	assert.NoError(t, err, "storage resources are not watched")
+	_, err = ctrl.HealthReport()
+	assert.NoError(t, err, "the Liqo control plane is not watched")
 }
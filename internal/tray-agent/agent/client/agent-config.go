@@ -53,11 +53,12 @@ func (ctrl *AgentController) acquireClusterConfiguration() {
 //getConfig retrieves the ClusterConfig CR which contains configuration data.
 func (ctrl *AgentController) getConfig() (*clusterConfig.ClusterConfig, error) {
 	if !ctrl.connected {
-		return nil, errors.New("no connection available")
+		return nil, newError(ErrNotConnected, "get ClusterConfig", nil)
 	}
 	objL, err := ctrl.Controller(CRClusterConfig).Resource(string(CRClusterConfig)).List(metav1.ListOptions{})
 	if err != nil {
-		return nil, err
+		return nil, classifyResourceError(ctrl.discoveryClient(), clusterConfig.GroupVersion, "list ClusterConfigs",
+			err)
 	}
 	confL := objL.(*clusterConfig.ClusterConfigList)
 	if len(confL.Items) < 1 {
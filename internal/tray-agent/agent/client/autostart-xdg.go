@@ -0,0 +1,213 @@
+// +build linux
+
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/xdg"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//The freedesktop AutostartProvider starts the Agent at login by means of a desktop entry in the autostart
+//directory of the user, $XDG_CONFIG_HOME/autostart (https://specifications.freedesktop.org/autostart-spec/).
+//The entry is a copy of the one installed as desktop application, if any, or else it is generated for the running
+//executable.
+func init() {
+	RegisterAutostartProvider(&xdgAutostart{})
+}
+
+const (
+	//DesktopEntryName is the name of the desktop entry of the Agent.
+	DesktopEntryName = "io.liqo.Agent.desktop"
+	//desktopEntryGroup is the group of the desktop entries containing the keys used by the Agent.
+	desktopEntryGroup = "[Desktop Entry]"
+	//defaultSystemConfigDirs is the default value of $XDG_CONFIG_DIRS.
+	defaultSystemConfigDirs = "/etc/xdg"
+)
+
+//xdgAutostart is the freedesktop AutostartProvider.
+type xdgAutostart struct{}
+
+//Name returns the user-friendly description of the mechanism.
+func (a *xdgAutostart) Name() string {
+	return "freedesktop autostart entry"
+}
+
+//userEntryPath returns the path of the autostart desktop entry of the user.
+func (a *xdgAutostart) userEntryPath() (string, error) {
+	base := xdg.DirConfig.BasePath()
+	if base == "" {
+		return "", fmt.Errorf("the config directory is not available")
+	}
+	return filepath.Join(base, "autostart", DesktopEntryName), nil
+}
+
+//systemEntryPath returns the path of the system-wide autostart desktop entry of the Agent, or an empty string if
+//there is none.
+func (a *xdgAutostart) systemEntryPath() string {
+	dirs := os.Getenv("XDG_CONFIG_DIRS")
+	if dirs == "" {
+		dirs = defaultSystemConfigDirs
+	}
+	for _, dir := range filepath.SplitList(dirs) {
+		//the directory of the user may be listed as well
+		if filepath.Clean(dir) == filepath.Clean(xdg.DirConfig.BasePath()) {
+			continue
+		}
+		path := filepath.Join(dir, "autostart", DesktopEntryName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+//Enabled returns whether the autostart desktop entry of the Agent exists and is enabled. The entry of the user
+//overrides the system-wide one.
+func (a *xdgAutostart) Enabled() (bool, error) {
+	path, err := a.userEntryPath()
+	if err != nil {
+		return false, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		system := a.systemEntryPath()
+		if system == "" {
+			return false, nil
+		}
+		data, err = ioutil.ReadFile(system)
+	}
+	if err != nil {
+		return false, err
+	}
+	keys := desktopEntryKeys(data)
+	return keys["Hidden"] != "true" && keys["X-GNOME-Autostart-enabled"] != "false", nil
+}
+
+//SetEnabled writes the autostart desktop entry of the Agent, or removes it. If a system-wide entry exists, it is
+//disabled by an entry of the user marked as hidden.
+func (a *xdgAutostart) SetEnabled(enabled bool) error {
+	path, err := a.userEntryPath()
+	if err != nil {
+		return err
+	}
+	if !enabled && a.systemEntryPath() == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	entry, err := a.entry()
+	if err != nil {
+		return err
+	}
+	hidden := "false"
+	if !enabled {
+		hidden = "true"
+	}
+	entry = setDesktopEntryKey(entry, "Hidden", hidden)
+	entry = setDesktopEntryKey(entry, "X-GNOME-Autostart-enabled", fmt.Sprint(enabled))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	//the file is executable, as required by some desktops to trust it
+	if err := ioutil.WriteFile(path, entry, 0755); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0755)
+}
+
+//entry returns the content of the desktop entry of the Agent: the one installed as desktop application, or else a
+//new one starting the running executable.
+func (a *xdgAutostart) entry() ([]byte, error) {
+	if base := xdg.DirData.BasePath(); base != "" {
+		if data, err := ioutil.ReadFile(filepath.Join(base, "applications", DesktopEntryName)); err == nil {
+			return data, nil
+		}
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.Join([]string{
+		desktopEntryGroup,
+		"Type=Application",
+		"Name=Liqo Agent",
+		`Comment=Tray bar app\nto interact with Liqo.`,
+		"Icon=io.liqo.Agent",
+		"Exec=" + quoteDesktopExec(executable),
+		"Terminal=false",
+		"StartupNotify=false",
+	}, "\n") + "\n"), nil
+}
+
+//desktopEntryKeys returns the keys of the main group of a desktop entry.
+func desktopEntryKeys(data []byte) map[string]string {
+	keys := make(map[string]string)
+	inGroup := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inGroup = line == desktopEntryGroup
+			continue
+		}
+		if !inGroup || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if n := strings.Index(line, "="); n > 0 {
+			keys[strings.TrimSpace(line[:n])] = strings.TrimSpace(line[n+1:])
+		}
+	}
+	return keys
+}
+
+//setDesktopEntryKey sets the value of a key of the main group of a desktop entry, adding it at the end of the group
+//if not present.
+func setDesktopEntryKey(data []byte, key string, value string) []byte {
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	out := make([]string, 0, len(lines)+1)
+	inGroup, set := false, false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			if inGroup && !set {
+				//the key is added before the blank lines separating the groups
+				end := len(out)
+				for end > 0 && strings.TrimSpace(out[end-1]) == "" {
+					end--
+				}
+				out = append(out[:end], append([]string{key + "=" + value}, out[end:]...)...)
+				set = true
+			}
+			inGroup = trimmed == desktopEntryGroup
+		} else if inGroup {
+			if n := strings.Index(trimmed, "="); n > 0 && strings.TrimSpace(trimmed[:n]) == key {
+				if !set {
+					out = append(out, key+"="+value)
+					set = true
+				}
+				continue
+			}
+		}
+		out = append(out, line)
+	}
+	if !set {
+		if !inGroup {
+			out = append(out, desktopEntryGroup)
+		}
+		out = append(out, key+"="+value)
+	}
+	return []byte(strings.Join(out, "\n") + "\n")
+}
+
+//quoteDesktopExec quotes a path as an argument of the Exec key of a desktop entry.
+func quoteDesktopExec(path string) string {
+	replacer := strings.NewReplacer(`\`, `\\\\`, `"`, `\\"`, "`", "\\\\`", `$`, `\\$`)
+	return `"` + replacer.Replace(path) + `"`
+}
@@ -0,0 +1,62 @@
+// +build linux
+
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestXDGAutostart(t *testing.T) {
+	home, err := ioutil.TempDir("", "liqo-agent-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer setTestEnv(t, map[string]string{"HOME": home, "XDG_CONFIG_HOME": "", "XDG_DATA_HOME": "",
+		"XDG_CONFIG_DIRS": filepath.Join(home, "etc")})()
+	entryPath := filepath.Join(home, ".config", "autostart", DesktopEntryName)
+	enabled, err := AutostartEnabled()
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+	//without an installed desktop application, an entry is generated for the running executable
+	assert.NoError(t, SetAutostartEnabled(true))
+	enabled, err = AutostartEnabled()
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+	keys := desktopEntryKeys(mustRead(t, entryPath))
+	assert.Equal(t, "Liqo Agent", keys["Name"])
+	assert.Contains(t, keys["Exec"], filepath.Base(os.Args[0]))
+	assert.NoError(t, SetAutostartEnabled(false))
+	_, err = os.Stat(entryPath)
+	assert.True(t, os.IsNotExist(err), "autostart entry not removed")
+	//the installed desktop application is copied
+	applications := filepath.Join(home, ".local", "share", "applications")
+	assert.NoError(t, os.MkdirAll(applications, 0755))
+	installed := "[Desktop Entry]\nName=Liqo Agent\nHidden=false\nExec=\"/opt/liqo-agent\"\n\n[Desktop Action x]\nName=X\n"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(applications, DesktopEntryName), []byte(installed), 0644))
+	assert.NoError(t, SetAutostartEnabled(true))
+	assert.Equal(t, "[Desktop Entry]\nName=Liqo Agent\nHidden=false\nExec=\"/opt/liqo-agent\"\n"+
+		"X-GNOME-Autostart-enabled=true\n\n[Desktop Action x]\nName=X\n", string(mustRead(t, entryPath)))
+	//a system-wide entry is overridden by a hidden entry of the user
+	assert.NoError(t, os.MkdirAll(filepath.Join(home, "etc", "autostart"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(home, "etc", "autostart", DesktopEntryName), []byte(installed),
+		0644))
+	assert.NoError(t, os.Remove(entryPath))
+	enabled, err = AutostartEnabled()
+	assert.NoError(t, err)
+	assert.True(t, enabled, "system-wide entry not detected")
+	assert.NoError(t, SetAutostartEnabled(false))
+	assert.Equal(t, "true", desktopEntryKeys(mustRead(t, entryPath))["Hidden"])
+	enabled, err = AutostartEnabled()
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+}
+
+func TestQuoteDesktopExec(t *testing.T) {
+	assert.Equal(t, `"/opt/liqo agent/liqo-agent"`, quoteDesktopExec("/opt/liqo agent/liqo-agent"))
+	assert.Equal(t, `"/opt/\\$HOME/\\"x\\"/a\\\\b"`, quoteDesktopExec(`/opt/$HOME/"x"/a\b`))
+}
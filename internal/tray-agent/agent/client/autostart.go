@@ -0,0 +1,62 @@
+package client
+
+import (
+	"errors"
+	"sync"
+)
+
+/*This file contains the management of the start of the Agent at login. The mechanism depends on the platform (e.g.
+the freedesktop autostart entries on Linux): each platform registers its AutostartProvider with
+RegisterAutostartProvider, from the init function of a file built only for that platform. On the platforms without
+a provider, the start at login cannot be managed by the Agent.*/
+
+//ErrAutostartUnsupported is returned when the start at login cannot be managed on the current platform.
+var ErrAutostartUnsupported = errors.New("the start at login is not supported on this platform")
+
+//AutostartProvider manages the start of the Agent at login on a platform.
+type AutostartProvider interface {
+	//Name returns the user-friendly description of the mechanism, e.g. "freedesktop autostart entry".
+	Name() string
+	//Enabled returns whether the Agent is started at login.
+	Enabled() (bool, error)
+	//SetEnabled enables or disables the start of the Agent at login.
+	SetEnabled(enabled bool) error
+}
+
+//autostart contains the AutostartProvider of the current platform.
+var autostart = struct {
+	provider AutostartProvider
+	sync.RWMutex
+}{}
+
+//RegisterAutostartProvider sets the AutostartProvider of the current platform, replacing the previous one.
+func RegisterAutostartProvider(provider AutostartProvider) {
+	autostart.Lock()
+	defer autostart.Unlock()
+	autostart.provider = provider
+}
+
+//GetAutostartProvider returns the AutostartProvider of the current platform, and whether one is registered.
+func GetAutostartProvider() (AutostartProvider, bool) {
+	autostart.RLock()
+	defer autostart.RUnlock()
+	return autostart.provider, autostart.provider != nil
+}
+
+//AutostartEnabled returns whether the Agent is started at login, or ErrAutostartUnsupported.
+func AutostartEnabled() (bool, error) {
+	provider, present := GetAutostartProvider()
+	if !present {
+		return false, ErrAutostartUnsupported
+	}
+	return provider.Enabled()
+}
+
+//SetAutostartEnabled enables or disables the start of the Agent at login, or returns ErrAutostartUnsupported.
+func SetAutostartEnabled(enabled bool) error {
+	provider, present := GetAutostartProvider()
+	if !present {
+		return ErrAutostartUnsupported
+	}
+	return provider.SetEnabled(enabled)
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+//DefaultBackoffPolicy is the BackoffPolicy used when no setting is provided in the LocalConfig.
+var DefaultBackoffPolicy = BackoffPolicy{
+	InitialDelay: time.Second,
+	Multiplier:   2,
+	MaxDelay:     30 * time.Second,
+	Jitter:       0.2,
+	MaxAttempts:  3,
+}
+
+//BackoffPolicy contains the parameters of an exponential backoff, used to space out the reconnections to the
+//cluster, the restarts of the caches and the retries of the failed operations.
+type BackoffPolicy struct {
+	//InitialDelay is the delay before the first retry.
+	InitialDelay time.Duration `yaml:"initialDelay,omitempty"`
+	//Multiplier is the factor the delay is multiplied by at each retry.
+	Multiplier float64 `yaml:"multiplier,omitempty"`
+	//MaxDelay is the cap of the delay.
+	MaxDelay time.Duration `yaml:"maxDelay,omitempty"`
+	//Jitter is the fraction (between 0 and 1] of the delay that is randomly added or subtracted to it,
+	//in order not to synchronize the retries of multiple clients.
+	Jitter float64 `yaml:"jitter,omitempty"`
+	//MaxAttempts is the maximum number of attempts performed by Retry, including the first one.
+	MaxAttempts int `yaml:"maxAttempts,omitempty"`
+}
+
+//withDefaults returns a copy of the BackoffPolicy whose unset (or invalid) parameters are replaced
+//by the DefaultBackoffPolicy ones.
+func (p BackoffPolicy) withDefaults() BackoffPolicy {
+	return p.withDefaultsOf(DefaultBackoffPolicy)
+}
+
+//withDefaultsOf returns a copy of the BackoffPolicy whose unset (or invalid) parameters are replaced
+//by the defaults ones.
+func (p BackoffPolicy) withDefaultsOf(defaults BackoffPolicy) BackoffPolicy {
+	if p.InitialDelay <= 0 {
+		p.InitialDelay = defaults.InitialDelay
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaults.Multiplier
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = defaults.MaxDelay
+	}
+	if p.MaxDelay < p.InitialDelay {
+		p.MaxDelay = p.InitialDelay
+	}
+	if p.Jitter <= 0 || p.Jitter > 1 {
+		p.Jitter = defaults.Jitter
+	}
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaults.MaxAttempts
+	}
+	return p
+}
+
+//Delay returns the delay before the retry number 'retry' (starting from 0), without jitter.
+func (p BackoffPolicy) Delay(retry int) time.Duration {
+	delay := float64(p.InitialDelay) * math.Pow(p.Multiplier, float64(retry))
+	if delay > float64(p.MaxDelay) || math.IsInf(delay, 1) {
+		return p.MaxDelay
+	}
+	return time.Duration(delay)
+}
+
+//NewBackoff returns a Backoff following the BackoffPolicy.
+func (p BackoffPolicy) NewBackoff() *Backoff {
+	return &Backoff{policy: p}
+}
+
+//Backoff computes the delays of a sequence of retries following a BackoffPolicy.
+type Backoff struct {
+	policy BackoffPolicy
+	//retries is the number of retries already performed.
+	retries int
+}
+
+//Next returns the (jittered) delay before the next retry.
+func (b *Backoff) Next() time.Duration {
+	delay := b.policy.Delay(b.retries)
+	b.retries++
+	if b.policy.Jitter == 0 {
+		return delay
+	}
+	jitter := (rand.Float64()*2 - 1) * b.policy.Jitter * float64(delay)
+	return delay + time.Duration(jitter)
+}
+
+//Retries returns the number of retries already performed.
+func (b *Backoff) Retries() int {
+	return b.retries
+}
+
+//Reset restarts the sequence of retries, e.g. after a success.
+func (b *Backoff) Reset() {
+	b.retries = 0
+}
+
+//Retry executes op until it succeeds, it fails with a non retryable error (see IsRetryable), the MaxAttempts
+//of the BackoffPolicy are performed or ctx is done. It returns the last error of op (or the ctx one).
+func Retry(ctx context.Context, policy BackoffPolicy, op func() error) error {
+	b := policy.NewBackoff()
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || !IsRetryable(err) || attempt >= policy.MaxAttempts {
+			return err
+		}
+		logger.Debug("retrying a failed operation", "attempt", attempt, "err", err)
+		select {
+		case <-time.After(b.Next()):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	"testing"
+	"time"
+)
+
+func TestBackoffPolicy(t *testing.T) {
+	var p BackoffPolicy
+	assert.NoError(t, yaml.Unmarshal([]byte("initialDelay: 100ms\nmaxDelay: 1s\nmultiplier: 3"), &p))
+	p = p.withDefaults()
+	assert.Equal(t, 100*time.Millisecond, p.InitialDelay)
+	assert.Equal(t, DefaultBackoffPolicy.Jitter, p.Jitter, "unset jitter not defaulted")
+	assert.Equal(t, DefaultBackoffPolicy.MaxAttempts, p.MaxAttempts, "unset attempts not defaulted")
+	assert.Equal(t, 100*time.Millisecond, p.Delay(0))
+	assert.Equal(t, 900*time.Millisecond, p.Delay(2))
+	assert.Equal(t, time.Second, p.Delay(3), "delay not capped")
+	assert.Equal(t, time.Second, p.Delay(1000), "delay not capped")
+	b := p.NewBackoff()
+	for retry := 0; retry < 4; retry++ {
+		delay, base := b.Next(), p.Delay(retry)
+		assert.InDelta(t, float64(base), float64(delay), p.Jitter*float64(base), "jitter out of range")
+	}
+	assert.Equal(t, 4, b.Retries())
+	b.Reset()
+	assert.Equal(t, 0, b.Retries())
+}
+
+func TestRetry(t *testing.T) {
+	p := BackoffPolicy{InitialDelay: time.Millisecond, Multiplier: 1, MaxDelay: time.Millisecond, Jitter: 0.1,
+		MaxAttempts: 3}
+	attempts := 0
+	err := Retry(context.TODO(), p, func() error {
+		attempts++
+		return newError(ErrTimeout, "op", nil)
+	})
+	assert.True(t, errors.Is(err, ErrTimeout))
+	assert.Equal(t, 3, attempts, "retryable errors not retried")
+	attempts = 0
+	err = Retry(context.TODO(), p, func() error {
+		attempts++
+		return newError(ErrForbidden, "op", nil)
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts, "non retryable errors retried")
+	attempts = 0
+	assert.NoError(t, Retry(context.TODO(), p, func() error {
+		attempts++
+		if attempts < 2 {
+			return newError(ErrNotConnected, "op", nil)
+		}
+		return nil
+	}))
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	p.InitialDelay, p.MaxDelay = time.Hour, time.Hour
+	assert.Equal(t, context.Canceled, Retry(ctx, p, func() error {
+		return newError(ErrTimeout, "op", nil)
+	}))
+}
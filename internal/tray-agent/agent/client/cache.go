@@ -0,0 +1,244 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/tracing"
+	"k8s.io/client-go/tools/cache"
+	"sort"
+	"sync"
+)
+
+//CacheEventType is the kind of change of a resource stored in a Cache.
+type CacheEventType int
+
+const (
+	//CacheAdded signals a new resource (including the ones found by the initial listing).
+	CacheAdded CacheEventType = iota
+	//CacheUpdated signals a change of a resource. Periodic resyncs are delivered as well,
+	//with Object and OldObject having the same ResourceVersion.
+	CacheUpdated
+	//CacheDeleted signals the removal of a resource.
+	CacheDeleted
+)
+
+//String returns the name of the CacheEventType, e.g. "added".
+func (t CacheEventType) String() string {
+	switch t {
+	case CacheAdded:
+		return "added"
+	case CacheUpdated:
+		return "updated"
+	case CacheDeleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+//CacheEvent is a change of a resource stored in a Cache.
+type CacheEvent struct {
+	Type CacheEventType
+	//Object is the resource after the change (or its last known state, for CacheDeleted).
+	Object interface{}
+	//OldObject is the resource before the change. It is set only for CacheUpdated.
+	OldObject interface{}
+}
+
+//CacheHandler is a subscriber of the events of a Cache.
+type CacheHandler func(event CacheEvent)
+
+//cacheSource starts the informer feeding a Cache, which delivers its events to handlers. It returns the
+//store of the informer, the channel that stops it and a function reporting whether its initial listing
+//has been completed.
+type cacheSource func(handlers cache.ResourceEventHandlerFuncs) (cache.Store, chan struct{}, cache.InformerSynced,
+	error)
+
+//Cache is the local copy of the resources of a kind, kept in sync with the cluster by an informer.
+//It provides the list, get and subscribe operations shared by all the caches of the AgentController:
+//the typed caches (e.g. ForeignClusterCache) wrap it to return their own resource type.
+//
+//The returned objects are shared with the cache and must not be modified. The read operations can be
+//performed on a nil Cache, which is empty.
+type Cache struct {
+	//resource is the name of the cached resource, e.g. "foreignclusters".
+	resource string
+	source   cacheSource
+	//lifecycle serializes Start and Stop.
+	lifecycle sync.Mutex
+	//mutex protects the following fields.
+	mutex     sync.RWMutex
+	store     cache.Store
+	stop      chan struct{}
+	hasSynced cache.InformerSynced
+	running   bool
+	//subscribers are indexed by a progressive id, in order to be removed.
+	subscribers map[int]CacheHandler
+	nextID      int
+}
+
+//newCache returns a stopped Cache of a resource, fed by source once started.
+func newCache(resource string, source cacheSource) *Cache {
+	return &Cache{
+		resource:    resource,
+		source:      source,
+		subscribers: make(map[int]CacheHandler),
+	}
+}
+
+//Resource returns the name of the cached resource.
+func (c *Cache) Resource() string {
+	return c.resource
+}
+
+//Start starts the informer feeding the Cache, if not already running.
+func (c *Cache) Start() error {
+	c.lifecycle.Lock()
+	defer c.lifecycle.Unlock()
+	if c.Running() {
+		return nil
+	}
+	if c.source == nil {
+		return errors.New("no source for the " + c.resource + " cache")
+	}
+	store, stop, hasSynced, err := c.source(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.tracedDispatch(CacheEvent{Type: CacheAdded, Object: obj})
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			c.tracedDispatch(CacheEvent{Type: CacheUpdated, Object: newObj, OldObject: oldObj})
+		},
+		DeleteFunc: func(obj interface{}) {
+			//the deletion may have been observed only by a relist
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			c.tracedDispatch(CacheEvent{Type: CacheDeleted, Object: obj})
+		},
+	})
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	c.store, c.stop, c.hasSynced, c.running = store, stop, hasSynced, true
+	c.mutex.Unlock()
+	return nil
+}
+
+//Stop stops the informer feeding the Cache, if running. The stored resources are kept until the next Start.
+func (c *Cache) Stop() {
+	c.lifecycle.Lock()
+	defer c.lifecycle.Unlock()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.running {
+		close(c.stop)
+		c.running = false
+	}
+}
+
+//Running returns whether the Cache is being fed by its informer.
+func (c *Cache) Running() bool {
+	if c == nil {
+		return false
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.running
+}
+
+//HasSynced returns whether the Cache completed its initial listing.
+func (c *Cache) HasSynced() bool {
+	c.mutex.RLock()
+	hasSynced := c.hasSynced
+	c.mutex.RUnlock()
+	return hasSynced != nil && hasSynced()
+}
+
+//syncTarget returns the syncTarget used to await the initial listing of the Cache.
+func (c *Cache) syncTarget() syncTarget {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return syncTarget{name: c.resource, hasSynced: c.HasSynced, stop: c.stop}
+}
+
+//List returns the resources stored in the Cache.
+func (c *Cache) List() []interface{} {
+	if c == nil {
+		return nil
+	}
+	c.mutex.RLock()
+	store := c.store
+	c.mutex.RUnlock()
+	if store == nil {
+		return nil
+	}
+	return store.List()
+}
+
+//Get returns the resource stored in the Cache with the given key (i.e. namespace/name, or name for the
+//cluster scoped resources).
+func (c *Cache) Get(key string) (obj interface{}, exists bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mutex.RLock()
+	store := c.store
+	c.mutex.RUnlock()
+	if store == nil {
+		return nil, false
+	}
+	obj, exists, err := store.GetByKey(key)
+	if err != nil {
+		return nil, false
+	}
+	return obj, exists
+}
+
+//Subscribe registers a handler for the events of the Cache. The handlers are executed sequentially, in
+//the order they were registered, by the goroutine of the informer. It returns the function that removes
+//the subscription.
+func (c *Cache) Subscribe(handler CacheHandler) (unsubscribe func()) {
+	c.mutex.Lock()
+	id := c.nextID
+	c.nextID++
+	c.subscribers[id] = handler
+	c.mutex.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mutex.Lock()
+			delete(c.subscribers, id)
+			c.mutex.Unlock()
+		})
+	}
+}
+
+//tracedDispatch dispatches an event observed by the informer within a "watch" tracing.Span, measuring the time the
+//subscribers take to accept it (e.g. blocked on a full Subscription).
+func (c *Cache) tracedDispatch(event CacheEvent) {
+	_, span := tracing.Start(context.Background(), "watch "+c.resource, tracing.Attributes{
+		"resource": c.resource,
+		"event":    event.Type.String(),
+	})
+	c.dispatch(event)
+	span.End()
+}
+
+//dispatch delivers an event to the current subscribers, in subscription order.
+func (c *Cache) dispatch(event CacheEvent) {
+	c.mutex.RLock()
+	ids := make([]int, 0, len(c.subscribers))
+	for id := range c.subscribers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	handlers := make([]CacheHandler, 0, len(ids))
+	for _, id := range ids {
+		handlers = append(handlers, c.subscribers[id])
+	}
+	c.mutex.RUnlock()
+	for _, h := range handlers {
+		h(event)
+	}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	discovery "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	var handlers cache.ResourceEventHandlerFuncs
+	starts := 0
+	c := newCache("foreignclusters", func(h cache.ResourceEventHandlerFuncs) (cache.Store, chan struct{},
+		cache.InformerSynced, error) {
+		starts++
+		handlers = h
+		return store, make(chan struct{}), func() bool { return true }, nil
+	})
+	assert.False(t, c.Running())
+	assert.Empty(t, c.List(), "stopped cache not empty")
+	var order []string
+	var events []CacheEvent
+	c.Subscribe(func(event CacheEvent) {
+		order = append(order, "first")
+		events = append(events, event)
+	})
+	unsubscribe := c.Subscribe(func(event CacheEvent) {
+		order = append(order, "second")
+	})
+	assert.NoError(t, c.Start())
+	assert.NoError(t, c.Start())
+	assert.Equal(t, 1, starts, "running cache started twice")
+	assert.True(t, c.Running())
+	assert.True(t, c.HasSynced())
+	//events are delivered in subscription order
+	fc := &discovery.ForeignCluster{ObjectMeta: metav1.ObjectMeta{Name: "fc"}}
+	assert.NoError(t, store.Add(fc))
+	handlers.OnAdd(fc)
+	assert.Equal(t, []string{"first", "second"}, order)
+	unsubscribe()
+	unsubscribe()
+	handlers.OnUpdate(fc, fc)
+	assert.Equal(t, []string{"first", "second", "first"}, order, "event delivered after unsubscribe")
+	//the tombstones of the deletions are unwrapped
+	handlers.OnDelete(cache.DeletedFinalStateUnknown{Key: "fc", Obj: fc})
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, CacheAdded, events[0].Type)
+		assert.Equal(t, CacheUpdated, events[1].Type)
+		assert.Equal(t, fc, events[1].OldObject)
+		assert.Equal(t, CacheDeleted, events[2].Type)
+		assert.Equal(t, fc, events[2].Object, "tombstone not unwrapped")
+	}
+	//typed access
+	fcs := ForeignClusterCache{c}
+	assert.Equal(t, []*discovery.ForeignCluster{fc}, fcs.List())
+	got, exists := fcs.Get("fc")
+	assert.True(t, exists)
+	assert.Equal(t, fc, got)
+	_, exists = fcs.Get("missing")
+	assert.False(t, exists)
+	c.Stop()
+	assert.False(t, c.Running())
+	assert.Len(t, c.List(), 1, "stored resources dropped at Stop")
+	//a nil Cache is empty
+	var empty ForeignClusterCache
+	assert.Empty(t, empty.List())
+	_, exists = empty.Get("fc")
+	assert.False(t, exists)
+	assert.False(t, empty.Running())
+}
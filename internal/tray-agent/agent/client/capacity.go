@@ -0,0 +1,131 @@
+package client
+
+import (
+	"errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sort"
+)
+
+//CapacityUsage contains the allocatable resources of a set of nodes and the amount requested by their pods.
+type CapacityUsage struct {
+	//Nodes is the number of nodes contributing to the allocatable resources.
+	Nodes int `json:"nodes"`
+	//CpuAllocatable is the allocatable CPU, in millicores.
+	CpuAllocatable int64 `json:"cpuAllocatable"`
+	//CpuRequested is the CPU requested by the scheduled pods, in millicores.
+	CpuRequested int64 `json:"cpuRequested"`
+	//MemAllocatable is the allocatable memory, in bytes.
+	MemAllocatable int64 `json:"memAllocatable"`
+	//MemRequested is the memory requested by the scheduled pods, in bytes.
+	MemRequested int64 `json:"memRequested"`
+}
+
+//add sums the content of another CapacityUsage.
+func (cu *CapacityUsage) add(other *CapacityUsage) {
+	cu.Nodes += other.Nodes
+	cu.CpuAllocatable += other.CpuAllocatable
+	cu.CpuRequested += other.CpuRequested
+	cu.MemAllocatable += other.MemAllocatable
+	cu.MemRequested += other.MemRequested
+}
+
+//PeerCapacity is the CapacityUsage of the virtual nodes extending the home cluster with the resources of a peer.
+type PeerCapacity struct {
+	ClusterID string `json:"clusterID"`
+	CapacityUsage
+}
+
+//CapacityReport summarizes the resources of the home cluster, distinguishing the local ones from the ones
+//extended by the peers through the Liqo virtual nodes.
+type CapacityReport struct {
+	//Local is the CapacityUsage of the physical nodes of the home cluster.
+	Local CapacityUsage `json:"local"`
+	//Peers is the overall CapacityUsage of the virtual nodes.
+	Peers CapacityUsage `json:"peers"`
+	//Total is the overall CapacityUsage of the home cluster.
+	Total CapacityUsage `json:"total"`
+	//PerPeer contains the CapacityUsage of each peer, sorted by ClusterID.
+	PerPeer []*PeerCapacity `json:"perPeer"`
+}
+
+//CapacityReport returns the current CapacityReport, built from the content of the AgentController caches.
+func (ctrl *AgentController) CapacityReport() (*CapacityReport, error) {
+	c := ctrl.coreCache
+	if c == nil || !c.running {
+		return nil, errors.New("cluster nodes are not watched")
+	}
+	nodes, err := c.factory.Core().V1().Nodes().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	pods, err := c.factory.Core().V1().Pods().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	return newCapacityReport(nodes, pods), nil
+}
+
+//newCapacityReport builds a CapacityReport from the nodes of the home cluster and the pods scheduled on them.
+func newCapacityReport(nodes []*corev1.Node, pods []*corev1.Pod) *CapacityReport {
+	report := &CapacityReport{PerPeer: make([]*PeerCapacity, 0)}
+	//usage of each node, by node name
+	usages := make(map[string]*CapacityUsage)
+	peers := make(map[string]*PeerCapacity)
+	for _, n := range nodes {
+		var usage *CapacityUsage
+		if n.Labels[labelVirtualNodeType] == virtualNodeType {
+			clusterID := n.Annotations[annVirtualNodeClusterID]
+			peer, present := peers[clusterID]
+			if !present {
+				peer = &PeerCapacity{ClusterID: clusterID}
+				peers[clusterID] = peer
+				report.PerPeer = append(report.PerPeer, peer)
+			}
+			usage = &peer.CapacityUsage
+		} else {
+			usage = &report.Local
+		}
+		usage.Nodes++
+		usage.CpuAllocatable += n.Status.Allocatable.Cpu().MilliValue()
+		usage.MemAllocatable += n.Status.Allocatable.Memory().Value()
+		usages[n.Name] = usage
+	}
+	for _, pod := range pods {
+		usage, present := usages[pod.Spec.NodeName]
+		if !present || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		cpu, mem := podRequests(pod)
+		usage.CpuRequested += cpu
+		usage.MemRequested += mem
+	}
+	sort.Slice(report.PerPeer, func(i, j int) bool {
+		return report.PerPeer[i].ClusterID < report.PerPeer[j].ClusterID
+	})
+	for _, peer := range report.PerPeer {
+		report.Peers.add(&peer.CapacityUsage)
+	}
+	report.Total.add(&report.Local)
+	report.Total.add(&report.Peers)
+	return report
+}
+
+//podRequests returns the CPU (in millicores) and memory (in bytes) requested by a pod, computed as the
+//kubernetes scheduler does: the sum of the requests of the containers, raised to the highest request
+//of the init containers.
+func podRequests(pod *corev1.Pod) (cpu int64, mem int64) {
+	for _, c := range pod.Spec.Containers {
+		cpu += c.Resources.Requests.Cpu().MilliValue()
+		mem += c.Resources.Requests.Memory().Value()
+	}
+	for _, c := range pod.Spec.InitContainers {
+		if initCpu := c.Resources.Requests.Cpu().MilliValue(); initCpu > cpu {
+			cpu = initCpu
+		}
+		if initMem := c.Resources.Requests.Memory().Value(); initMem > mem {
+			mem = initMem
+		}
+	}
+	return cpu, mem
+}
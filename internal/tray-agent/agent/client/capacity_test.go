@@ -0,0 +1,48 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestNewCapacityReport(t *testing.T) {
+	resources := func(cpu, mem string) corev1.ResourceList {
+		return corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(mem),
+		}
+	}
+	nodes := []*corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "local"}, Status: corev1.NodeStatus{Allocatable: resources("4", "8Gi")}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "liqo-cl1", Labels: map[string]string{labelVirtualNodeType: virtualNodeType},
+			Annotations: map[string]string{annVirtualNodeClusterID: "cl1"}},
+			Status: corev1.NodeStatus{Allocatable: resources("2", "4Gi")}},
+	}
+	container := func(cpu, mem string) corev1.Container {
+		return corev1.Container{Resources: corev1.ResourceRequirements{Requests: resources(cpu, mem)}}
+	}
+	pods := []*corev1.Pod{
+		{Spec: corev1.PodSpec{NodeName: "local", Containers: []corev1.Container{container("500m", "1Gi"),
+			container("500m", "1Gi")}, InitContainers: []corev1.Container{container("1500m", "1Gi")}}},
+		{Spec: corev1.PodSpec{NodeName: "liqo-cl1", Containers: []corev1.Container{container("1", "1Gi")}}},
+		//completed pods do not consume resources
+		{Spec: corev1.PodSpec{NodeName: "liqo-cl1", Containers: []corev1.Container{container("1", "1Gi")}},
+			Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+		//pending pods are not counted
+		{Spec: corev1.PodSpec{Containers: []corev1.Container{container("1", "1Gi")}}},
+	}
+	report := newCapacityReport(nodes, pods)
+	gi := int64(1024 * 1024 * 1024)
+	assert.Equal(t, CapacityUsage{Nodes: 1, CpuAllocatable: 4000, CpuRequested: 1500, MemAllocatable: 8 * gi,
+		MemRequested: 2 * gi}, report.Local, "wrong local capacity")
+	assert.Equal(t, CapacityUsage{Nodes: 1, CpuAllocatable: 2000, CpuRequested: 1000, MemAllocatable: 4 * gi,
+		MemRequested: gi}, report.Peers, "wrong capacity extended by peers")
+	assert.Equal(t, int64(6000), report.Total.CpuAllocatable)
+	if assert.Equal(t, 1, len(report.PerPeer)) {
+		assert.Equal(t, "cl1", report.PerPeer[0].ClusterID)
+		assert.Equal(t, report.Peers, report.PerPeer[0].CapacityUsage)
+	}
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const (
+	//DefaultCaptivePortalProbeURL is the default URL probed to detect a captive portal. It replies with an empty
+	//'204 No Content' response, unless the HTTP traffic is intercepted.
+	DefaultCaptivePortalProbeURL = "http://connectivitycheck.gstatic.com/generate_204"
+	//captivePortalTimeout is the time the probe URL has to reply.
+	captivePortalTimeout = 5 * time.Second
+)
+
+//CaptivePortal describes a captive portal intercepting the HTTP traffic, e.g. on hotel and airport Wi-Fi networks.
+type CaptivePortal struct {
+	//URL is the sign-in page of the portal.
+	URL string
+}
+
+//DetectCaptivePortal probes probeURL to check whether the HTTP traffic is intercepted by a captive portal, i.e.
+//whether the empty response is replaced by a redirection or by a page ('200 OK' with a body).
+//It returns nil if the probe succeeds without interceptions, if the network is not reachable at all, or if the
+//probe fails with any other status (e.g. an error of a proxy or of the probe server).
+func DetectCaptivePortal(ctx context.Context, probeURL string) *CaptivePortal {
+	httpClient := &http.Client{
+		Timeout: captivePortalTimeout,
+		//the redirection to the portal page must not be followed
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probeURL, nil)
+	if err != nil {
+		return nil
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	body, _ := io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16))
+	switch {
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		if location, err := resp.Location(); err == nil {
+			return &CaptivePortal{URL: location.String()}
+		}
+		return &CaptivePortal{URL: probeURL}
+	case resp.StatusCode == http.StatusOK && body > 0:
+		//the portal replaced the empty response with its own page
+		return &CaptivePortal{URL: probeURL}
+	default:
+		return nil
+	}
+}
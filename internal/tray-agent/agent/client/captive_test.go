@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDetectCaptivePortal(t *testing.T) {
+	handlers := map[string]http.HandlerFunc{
+		"/generate_204": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		},
+		"/redirect": func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "http://portal.example.com/login", http.StatusFound)
+		},
+		"/page": func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("<html>Sign in</html>"))
+		},
+		"/empty": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+		"/error": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+		},
+	}
+	mux := http.NewServeMux()
+	for path, h := range handlers {
+		mux.HandleFunc(path, h)
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	assert.Nil(t, DetectCaptivePortal(context.TODO(), server.URL+"/generate_204"), "false positive")
+	if portal := DetectCaptivePortal(context.TODO(), server.URL+"/redirect"); assert.NotNil(t, portal) {
+		assert.Equal(t, "http://portal.example.com/login", portal.URL)
+	}
+	if portal := DetectCaptivePortal(context.TODO(), server.URL+"/page"); assert.NotNil(t, portal) {
+		assert.Equal(t, server.URL+"/page", portal.URL)
+	}
+	//only a redirection or a page replacing the empty response reveal a portal
+	assert.Nil(t, DetectCaptivePortal(context.TODO(), server.URL+"/empty"), "empty 200 detected")
+	assert.Nil(t, DetectCaptivePortal(context.TODO(), server.URL+"/error"), "server error detected")
+	assert.Nil(t, DetectCaptivePortal(context.TODO(), server.URL+"/missing"), "404 detected")
+	server.Close()
+	assert.Nil(t, DetectCaptivePortal(context.TODO(), server.URL+"/generate_204"), "unreachable network detected")
+}
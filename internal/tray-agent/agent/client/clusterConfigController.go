@@ -5,31 +5,23 @@ import (
 )
 
 //createClusterConfigController creates a new CRDController for the Liqo ClusterConfig CRD.
-func createClusterConfigController(kubeconfig string) (*CRDController, error) {
-	controller := &CRDController{
-		addFunc:    clusterConfigAddFunc,
-		updateFunc: clusterConfigUpdateFunc,
-	}
+func createClusterConfigController(ctrl *AgentController, kubeconfig string) (*CRDController, error) {
 	//init client
 	newClient, err := clusterConfig.CreateClusterConfigClient(kubeconfig, false)
 	if err != nil {
 		return nil, err
 	}
-	controller.CRDClient = newClient
-	controller.resource = string(CRClusterConfig)
-	return controller, nil
-}
-
-//clusterConfigAddFunc is the ADD event handler for the ClusterConfig CRDController.
-func clusterConfigAddFunc(obj interface{}) {
-	config := obj.(*clusterConfig.ClusterConfig)
-	agentCtrl.NotifyChannel(ChanClusterName) <- getClusterName(config)
+	return newCRDController(newClient, CRClusterConfig, ctrl.clusterConfigEventHandler), nil
 }
 
-//clusterConfigUpdateFunc is the UPDATE event handler for the ClusterConfig CRDController.
-func clusterConfigUpdateFunc(_ interface{}, newObj interface{}) {
-	config := newObj.(*clusterConfig.ClusterConfig)
-	agentCtrl.NotifyChannel(ChanClusterName) <- getClusterName(config)
+//clusterConfigEventHandler is the event handler for the ClusterConfig CRDController. It signals the
+//ClusterName of the home cluster.
+func (ctrl *AgentController) clusterConfigEventHandler(event CacheEvent) {
+	if event.Type == CacheDeleted {
+		return
+	}
+	config := event.Object.(*clusterConfig.ClusterConfig)
+	ctrl.events.Publish(TopicClusterName, getClusterName(config))
 }
 
 //getClusterName extracts the ClusterName from a ClusterConfig CR.
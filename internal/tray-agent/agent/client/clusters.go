@@ -0,0 +1,186 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/xdg"
+	"io/ioutil"
+	"k8s.io/client-go/tools/clientcmd"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+/*This file contains the management of the additional clusters. Besides the main one, the Agent can connect to other
+clusters (e.g. the other contexts of the kubeconfig file) listed in the local configuration (see ClusterConfig).
+Each of them is managed by a dedicated AgentController, with its own caches and EventBus, owned by the
+AgentController of the main cluster.*/
+
+//ClusterConfig describes an additional cluster the Agent connects to.
+type ClusterConfig struct {
+	//Name identifies the cluster in the tray menu. It defaults to Context.
+	Name string `yaml:"name,omitempty"`
+	//Context is the name of the kubeconfig context of the cluster.
+	Context string `yaml:"context"`
+	//Kubeconfig is the path of the kubeconfig file containing Context. It defaults to the one of the main cluster.
+	Kubeconfig string `yaml:"kubeconfig,omitempty"`
+}
+
+//ClusterName returns the name identifying the cluster.
+func (c ClusterConfig) ClusterName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Context
+}
+
+//Name returns the name of the additional cluster managed by the AgentController, or an empty string for the
+//main cluster.
+func (ctrl *AgentController) Name() string {
+	return ctrl.name
+}
+
+//Context returns the kubeconfig context of the cluster managed by the AgentController, or an empty string if the
+//current one is used.
+func (ctrl *AgentController) Context() string {
+	return ctrl.context
+}
+
+//Kubeconfig returns the path of the kubeconfig file of the cluster managed by the AgentController.
+func (ctrl *AgentController) Kubeconfig() string {
+	return ctrl.kubeconfig
+}
+
+//ConnectCluster connects to an additional cluster, starting its caches, and returns its AgentController.
+//A cluster that cannot be reached is registered anyway, not connected, and the failure is returned: calling
+//ConnectCluster again retries the connection.
+func (ctrl *AgentController) ConnectCluster(conf ClusterConfig) (*AgentController, error) {
+	name := conf.ClusterName()
+	if name == "" {
+		return nil, errors.New("connect cluster: no context specified")
+	}
+	ctrl.clustersMutex.Lock()
+	if ctrl.clusters == nil {
+		ctrl.clusters = make(map[string]*AgentController)
+	}
+	cluster, present := ctrl.clusters[name]
+	if !present {
+		kubeconfig := conf.Kubeconfig
+		if kubeconfig == "" {
+			kubeconfig = ctrl.kubeconfig
+		}
+		cluster = newAgentController(kubeconfig, conf.Context)
+		cluster.name = name
+		ctrl.clusters[name] = cluster
+	}
+	ctrl.clustersMutex.Unlock()
+	if cluster.Connected() {
+		return cluster, nil
+	}
+	return cluster, cluster.connectCluster()
+}
+
+//connectCluster creates the clients of the cluster of the AgentController and connects to it.
+func (ctrl *AgentController) connectCluster() error {
+	op := "connect cluster"
+	if ctrl.name != "" {
+		op += " " + ctrl.name
+	}
+	kubeClient, err := createKubeClient(ctrl.kubeconfig, ctrl.context)
+	if err != nil {
+		return ClassifyError(op, err)
+	}
+	ctrl.setKubeClient(kubeClient)
+	if err = ctrl.initCRDManager(); err != nil {
+		return ClassifyError(op, err)
+	}
+	return ctrl.connect()
+}
+
+//Cluster returns the AgentController of an additional cluster, if registered.
+func (ctrl *AgentController) Cluster(name string) (*AgentController, bool) {
+	ctrl.clustersMutex.RLock()
+	defer ctrl.clustersMutex.RUnlock()
+	cluster, present := ctrl.clusters[name]
+	return cluster, present
+}
+
+//Clusters returns the AgentControllers of the additional clusters, sorted by name.
+func (ctrl *AgentController) Clusters() []*AgentController {
+	ctrl.clustersMutex.RLock()
+	defer ctrl.clustersMutex.RUnlock()
+	clusters := make([]*AgentController, 0, len(ctrl.clusters))
+	for _, cluster := range ctrl.clusters {
+		clusters = append(clusters, cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].name < clusters[j].name
+	})
+	return clusters
+}
+
+//DisconnectCluster stops the caches of an additional cluster and forgets it.
+func (ctrl *AgentController) DisconnectCluster(name string) {
+	ctrl.clustersMutex.Lock()
+	cluster, present := ctrl.clusters[name]
+	delete(ctrl.clusters, name)
+	ctrl.clustersMutex.Unlock()
+	if present {
+		cluster.disconnect()
+	}
+}
+
+//crdKubeconfig returns the path of the kubeconfig file used by the CRD clients. The Liqo CRD clients always use
+//the current context of the file: if a different context is selected, a copy of the file using it is created.
+func (ctrl *AgentController) crdKubeconfig() (string, error) {
+	if ctrl.kubeconfig == "" {
+		//the CRD clients fall back to the in-cluster configuration if the kubeconfig file does not exist
+		if InCluster() {
+			return "", nil
+		}
+		return "", errors.New("no kubeconfig provided")
+	}
+	if ctrl.context == "" || ctrl.mocked {
+		return ctrl.kubeconfig, nil
+	}
+	if ctrl.contextKubeconfig != "" {
+		return ctrl.contextKubeconfig, nil
+	}
+	path, err := writeContextKubeconfig(ctrl.kubeconfig, ctrl.context)
+	if err != nil {
+		return "", err
+	}
+	ctrl.contextKubeconfig = path
+	return path, nil
+}
+
+//writeContextKubeconfig writes, in a new temporary directory inside xdg.DirCache (or inside the system temporary
+//directory, if not available), a copy of a kubeconfig file whose current context is context, returning its path.
+func writeContextKubeconfig(kubeconfig string, context string) (string, error) {
+	config, err := clientcmd.LoadFromFile(kubeconfig)
+	if err != nil {
+		return "", err
+	}
+	if _, present := config.Contexts[context]; !present {
+		return "", fmt.Errorf("context %s not found in %s", context, kubeconfig)
+	}
+	//the relative paths are relative to the original file
+	if err = clientcmd.ResolveLocalPaths(config); err != nil {
+		return "", err
+	}
+	config.CurrentContext = context
+	cacheDir, err := xdg.DirCache.Ensure()
+	if err != nil {
+		cacheDir = ""
+	}
+	dir, err := ioutil.TempDir(cacheDir, "liqo-agent-context")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "config")
+	if err = clientcmd.WriteToFile(*config, path); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", err
+	}
+	return path, nil
+}
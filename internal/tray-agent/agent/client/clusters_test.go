@@ -0,0 +1,81 @@
+package client
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/test"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConnectCluster(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	_, err := ctrl.ConnectCluster(ClusterConfig{})
+	assert.Error(t, err, "cluster without context connected")
+	cluster, err := ctrl.ConnectCluster(ClusterConfig{Context: "staging"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ctrl.DisconnectCluster("staging")
+	assert.True(t, cluster.Connected())
+	assert.Equal(t, "staging", cluster.Name())
+	assert.Equal(t, "staging", cluster.Context())
+	assert.Empty(t, ctrl.Name())
+	again, err := ctrl.ConnectCluster(ClusterConfig{Context: "staging"})
+	assert.NoError(t, err)
+	assert.Same(t, cluster, again, "cluster connected twice")
+	found, present := ctrl.Cluster("staging")
+	assert.True(t, present)
+	assert.Same(t, cluster, found)
+	//the events of the cluster are published on its own EventBus
+	events := ctrl.Events().Subscribe(TopicPeerChanged, 0)
+	defer events.Unsubscribe()
+	fc := test.CreateForeignCluster("staging-peer", "remote")
+	assert.NoError(t, cluster.Controller(CRForeignCluster).Store.Add(fc))
+	select {
+	case data := <-cluster.Events().Subscribe(TopicPeerChanged, 0).C():
+		assert.Equal(t, "staging-peer", data.(*NotifyDataForeignCluster).ClusterID)
+	case <-time.After(time.Second):
+		t.Fatal("peer of the cluster not notified")
+	}
+	assert.Empty(t, events.C(), "peer of the cluster notified on the main cluster")
+	ctrl.DisconnectCluster("staging")
+	assert.Empty(t, ctrl.Clusters())
+	assert.False(t, cluster.Connected())
+	assert.False(t, cluster.Controller(CRForeignCluster).Running(), "caches of a disconnected cluster running")
+}
+
+func TestWriteContextKubeconfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liqo-contexts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := clientcmdapi.NewConfig()
+	config.Clusters["one"] = &clientcmdapi.Cluster{Server: "https://one:6443", CertificateAuthority: "ca.crt"}
+	config.Clusters["two"] = &clientcmdapi.Cluster{Server: "https://two:6443"}
+	config.Contexts["one"] = &clientcmdapi.Context{Cluster: "one"}
+	config.Contexts["two"] = &clientcmdapi.Context{Cluster: "two"}
+	config.CurrentContext = "one"
+	kubeconfig := filepath.Join(dir, "config")
+	assert.NoError(t, clientcmd.WriteToFile(*config, kubeconfig))
+	_, err = writeContextKubeconfig(kubeconfig, "missing")
+	assert.Error(t, err)
+	path, err := writeContextKubeconfig(kubeconfig, "two")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(filepath.Dir(path))
+	copied, err := clientcmd.LoadFromFile(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "two", copied.CurrentContext)
+		//the relative paths are resolved against the original file
+		assert.Equal(t, filepath.Join(dir, "ca.crt"), copied.Clusters["one"].CertificateAuthority)
+	}
+}
@@ -0,0 +1,299 @@
+package client
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*This file contains the schema of the ConfigFileName config file: its versioning, the migration of the files written
+by older versions of the Agent, and the validation of the settings.
+
+The version of a file is stored in its 'version' field, and files without it predate the versioning (version 0).
+Older files are migrated at load time, one version at a time, by the configMigrations functions operating on the
+raw YAML content; the original file is kept as a backup next to the migrated one.
+
+The validation drops the invalid settings, so that their default value applies, and reports them together in a
+ValidationError.*/
+
+//CurrentConfigVersion is the version of the config file written by this Agent.
+const CurrentConfigVersion = 1
+
+//minConfigDuration is the minimum non-zero duration accepted in the config file: shorter ones are likely numbers
+//without a unit (e.g. "30", read as 30ns).
+const minConfigDuration = time.Millisecond
+
+//configWeekdays contains the accepted abbreviations of the day names of the quiet hours (the longer names are
+//accepted as well, by their first three letters).
+var configWeekdays = map[string]bool{"sun": true, "mon": true, "tue": true, "wed": true, "thu": true, "fri": true,
+	"sat": true}
+
+//configMigrations contains, by source version, the function migrating the raw content of a config file to the
+//next version.
+var configMigrations = map[int]func(raw map[string]interface{}){
+	0: migrateConfigV0,
+}
+
+//ValidationError lists the problems found in a configuration. The invalid settings are dropped, so that their
+//default value applies.
+type ValidationError struct {
+	Problems []string
+}
+
+//Error returns the description of the problems.
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+//add records a problem of the configuration.
+func (e *ValidationError) add(format string, args ...interface{}) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+}
+
+//orNil returns the ValidationError, or nil if there are no problems.
+func (e *ValidationError) orNil() error {
+	if len(e.Problems) == 0 {
+		return nil
+	}
+	sort.Strings(e.Problems)
+	return e
+}
+
+//decodeLocalConfig decodes the content of a config file, migrating it to CurrentConfigVersion, and returns the
+//version it was written with.
+func decodeLocalConfig(data []byte) (config *LocalConfig, version int, err error) {
+	raw := map[string]interface{}{}
+	if err = yaml.Unmarshal(data, &raw); err != nil {
+		return nil, 0, err
+	}
+	if v, present := raw["version"]; present {
+		var ok bool
+		if version, ok = v.(int); !ok || version < 0 {
+			return nil, 0, fmt.Errorf("invalid version '%v'", v)
+		}
+	}
+	if version < CurrentConfigVersion {
+		for v := version; v < CurrentConfigVersion; v++ {
+			configMigrations[v](raw)
+		}
+		raw["version"] = CurrentConfigVersion
+		if data, err = yaml.Marshal(raw); err != nil {
+			return nil, 0, err
+		}
+	}
+	config = &LocalConfig{}
+	if err = yaml.Unmarshal(data, config); err != nil {
+		return nil, 0, err
+	}
+	return config, version, nil
+}
+
+//persistMigration keeps the content of a config file migrated from an older version as a backup, and replaces the
+//file with the migrated configuration.
+func persistMigration(liqoDir string, data []byte, version int, config *LocalConfig) error {
+	filePath := filepath.Join(liqoDir, ConfigFileName)
+	if err := ioutil.WriteFile(fmt.Sprintf("%s.v%d.bak", filePath, version), data, 0644); err != nil {
+		return err
+	}
+	migrated, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filePath, migrated, 0644); err != nil {
+		return err
+	}
+	logger.Info("configuration file migrated", "from", version, "to", CurrentConfigVersion)
+	return nil
+}
+
+//migrateConfigV0 migrates a config file predating the versioning. Those files were read leniently, e.g. accepting
+//"Banner" as a notification level or "warn" as a severity: their values are normalized.
+func migrateConfigV0(raw map[string]interface{}) {
+	normalize := func(value interface{}) interface{} {
+		if s, ok := value.(string); ok {
+			return strings.ToLower(strings.TrimSpace(s))
+		}
+		return value
+	}
+	for _, key := range []string{"notifyLevel", "colorScheme", "labelMode", "iconTheme", "language"} {
+		if value, present := raw[key]; present {
+			raw[key] = normalize(value)
+		}
+	}
+	if dnd, ok := raw["doNotDisturb"].(map[interface{}]interface{}); ok {
+		if threshold, present := dnd["threshold"]; present {
+			dnd["threshold"] = normalize(threshold)
+			if dnd["threshold"] == "warn" {
+				dnd["threshold"] = "warning"
+			}
+		}
+	}
+	if rules, ok := raw["quietHours"].([]interface{}); ok {
+		for _, r := range rules {
+			rule, ok := r.(map[interface{}]interface{})
+			if !ok {
+				continue
+			}
+			if days, ok := rule["days"].([]interface{}); ok {
+				for n := range days {
+					days[n] = normalize(days[n])
+				}
+			}
+		}
+	}
+}
+
+//Validate checks the settings of the configuration, dropping the invalid ones so that their default value applies.
+//The problems found are returned in a ValidationError.
+func (c *LocalConfig) Validate() error {
+	problems := &ValidationError{}
+	if c.Version > CurrentConfigVersion {
+		problems.add("version %d is newer than the supported one (%d): some settings may be ignored", c.Version,
+			CurrentConfigVersion)
+	}
+	validateChoice(problems, "notifyLevel", &c.NotifyLevel, "off", "icon", "banner")
+	validateChoice(problems, "colorScheme", &c.ColorScheme, "auto", "light", "dark")
+	validateChoice(problems, "labelMode", &c.LabelMode, "counts", "trend")
+	if c.CredentialsWarningDays < 0 {
+		problems.add("credentialsWarningDays: must not be negative")
+		c.CredentialsWarningDays = 0
+	}
+	if c.ListPageSize < 0 {
+		problems.add("listPageSize: must not be negative")
+		c.ListPageSize = 0
+	}
+	validateURL(problems, "captivePortalProbeUrl", &c.CaptivePortalProbeURL)
+	if c.DoNotDisturb != nil {
+		validateChoice(problems, "doNotDisturb.threshold", &c.DoNotDisturb.Threshold, "info", "warning", "error")
+	}
+	rules := c.QuietHours[:0]
+	for n, rule := range c.QuietHours {
+		if err := validateQuietHoursRule(rule); err != nil {
+			problems.add("quietHours[%d]: %v", n, err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	c.QuietHours = rules
+	if c.Intervals != nil {
+		for name, d := range map[string]*time.Duration{
+			"heartbeat":   &c.Intervals.Heartbeat,
+			"capacity":    &c.Intervals.Capacity,
+			"credentials": &c.Intervals.Credentials,
+			"upgrade":     &c.Intervals.Upgrade,
+			"agentUpdate": &c.Intervals.AgentUpdate,
+			"latency":     &c.Intervals.Latency,
+			"tunnel":      &c.Intervals.Tunnel,
+			"refresh":     &c.Intervals.Refresh,
+		} {
+			validateDuration(problems, "intervals."+name, d)
+		}
+	}
+	for operation, timeout := range c.OperationTimeouts {
+		validateDuration(problems, "operationTimeouts."+operation, &timeout)
+		if timeout == 0 {
+			delete(c.OperationTimeouts, operation)
+		}
+	}
+	for topic, debounce := range c.ListenerDebounce {
+		if debounce.Interval < 0 {
+			problems.add("listenerDebounce.%s.interval: must not be negative", topic)
+			delete(c.ListenerDebounce, topic)
+		}
+	}
+	if c.LocalAPI != nil && c.LocalAPI.Address != "" {
+		if _, _, err := net.SplitHostPort(c.LocalAPI.Address); err != nil {
+			problems.add("localApi.address: %v", err)
+			c.LocalAPI.Address = ""
+		}
+	}
+	if c.RemoteWrite != nil {
+		validateURL(problems, "remoteWrite.url", &c.RemoteWrite.URL)
+		validateDuration(problems, "remoteWrite.interval", &c.RemoteWrite.Interval)
+	}
+	if c.Tracing != nil {
+		validateURL(problems, "tracing.endpoint", &c.Tracing.Endpoint)
+		validateDuration(problems, "tracing.interval", &c.Tracing.Interval)
+	}
+	if c.SelfUpdate != nil {
+		validateURL(problems, "selfUpdate.releasesUrl", &c.SelfUpdate.ReleasesURL)
+	}
+	if c.Branding != nil {
+		validateURL(problems, "branding.helpUrl", &c.Branding.HelpURL)
+	}
+	if c.Redaction != nil {
+		patterns := c.Redaction.Patterns[:0]
+		for _, p := range c.Redaction.Patterns {
+			if _, err := regexp.Compile(p); err != nil {
+				problems.add("redaction.patterns: %v", err)
+				continue
+			}
+			patterns = append(patterns, p)
+		}
+		c.Redaction.Patterns = patterns
+	}
+	return problems.orNil()
+}
+
+//validateChoice drops a setting whose value is not one of the allowed ones.
+func validateChoice(problems *ValidationError, name string, value *string, allowed ...string) {
+	if *value == "" {
+		return
+	}
+	for _, a := range allowed {
+		if *value == a {
+			return
+		}
+	}
+	problems.add("%s: invalid value '%s', expected %s", name, *value, strings.Join(allowed, ", "))
+	*value = ""
+}
+
+//validateDuration drops a negative or too short duration.
+func validateDuration(problems *ValidationError, name string, d *time.Duration) {
+	switch {
+	case *d < 0:
+		problems.add("%s: must not be negative", name)
+	case *d > 0 && *d < minConfigDuration:
+		problems.add("%s: %v is too short, a unit is required (e.g. 30s)", name, *d)
+	default:
+		return
+	}
+	*d = 0
+}
+
+//validateURL drops a URL which is not an absolute http(s) one.
+func validateURL(problems *ValidationError, name string, value *string) {
+	if *value == "" {
+		return
+	}
+	if u, err := url.Parse(*value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		problems.add("%s: invalid URL '%s'", name, *value)
+		*value = ""
+	}
+}
+
+//validateQuietHoursRule checks the times and the days of a QuietHoursRule.
+func validateQuietHoursRule(rule QuietHoursRule) error {
+	for _, clock := range []string{rule.From, rule.To} {
+		if clock == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", clock); err != nil {
+			return fmt.Errorf("invalid time '%s', expected HH:MM", clock)
+		}
+	}
+	for _, day := range rule.Days {
+		if len(day) < 3 || !configWeekdays[strings.ToLower(day[:3])] {
+			return fmt.Errorf("invalid day '%s'", day)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfigMigration(t *testing.T) {
+	env, present := os.LookupEnv(EnvLiqoPath)
+	liqoPath, err := ioutil.TempDir("", "liqo-agent-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(liqoPath)
+		if present {
+			_ = os.Setenv(EnvLiqoPath, env)
+		} else {
+			_ = os.Unsetenv(EnvLiqoPath)
+		}
+		NewLocalConfig()
+		fileConfig.Lock()
+		fileConfig.Valid = false
+		fileConfig.Unlock()
+	}()
+	assert.NoError(t, os.Setenv(EnvLiqoPath, liqoPath), "PRE-TEST: envLiqoPath not set")
+	filePath := filepath.Join(liqoPath, ConfigFileName)
+	//a file predating the versioning
+	legacy := []byte("notifyLevel: ' Banner'\ndoNotDisturb:\n  threshold: WARN\nquietHours:\n  - from: '22:00'\n" +
+		"    days: [Sat, SUNDAY]\n")
+	assert.NoError(t, ioutil.WriteFile(filePath, legacy, 0644))
+	assert.NoError(t, LoadLocalConfig())
+	conf, valid := GetLocalConfig()
+	assert.True(t, valid)
+	assert.NoError(t, conf.Err())
+	assert.Equal(t, "banner", conf.GetNotifyLevel())
+	assert.Equal(t, "warning", conf.GetDoNotDisturb().Threshold)
+	assert.Equal(t, []string{"sat", "sunday"}, conf.GetQuietHours()[0].Days)
+	//the original file is kept as a backup, and the migrated one is versioned
+	backup, err := ioutil.ReadFile(filePath + ".v0.bak")
+	assert.NoError(t, err)
+	assert.Equal(t, legacy, backup)
+	migrated, _, err := decodeLocalConfig(mustRead(t, filePath))
+	assert.NoError(t, err)
+	assert.Equal(t, CurrentConfigVersion, migrated.Version)
+	assert.Equal(t, "banner", migrated.NotifyLevel)
+	//a current file is not migrated again
+	assert.NoError(t, os.Remove(filePath+".v0.bak"))
+	assert.NoError(t, LoadLocalConfig())
+	_, err = os.Stat(filePath + ".v0.bak")
+	assert.True(t, os.IsNotExist(err), "current file migrated")
+	//a file that cannot be decoded is not applied
+	assert.NoError(t, ioutil.WriteFile(filePath, []byte("version: two\n"), 0644))
+	assert.Error(t, LoadLocalConfig())
+	conf, valid = GetLocalConfig()
+	assert.False(t, valid)
+	assert.Error(t, conf.Err())
+	assert.Empty(t, conf.GetNotifyLevel())
+}
+
+func TestConfigValidation(t *testing.T) {
+	config, _, err := decodeLocalConfig([]byte(`
+version: 1
+notifyLevel: loud
+colorScheme: dark
+credentialsWarningDays: -1
+listPageSize: -5
+captivePortalProbeUrl: connectivity-check.example
+quietHours:
+  - from: '25:00'
+  - from: '22:00'
+    to: '07:00'
+  - days: [xyz]
+intervals:
+  heartbeat: 30
+  capacity: 1m
+  tunnel: -1s
+operationTimeouts:
+  peering: 2m
+  default: 5
+localApi:
+  enabled: true
+  address: localhost
+redaction:
+  patterns: ['corp-[0-9]+', '(']
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = config.Validate()
+	problems, ok := err.(*ValidationError)
+	if !assert.True(t, ok, "validation problems not reported") {
+		return
+	}
+	assert.Len(t, problems.Problems, 11)
+	//the valid settings are kept
+	assert.Equal(t, "dark", config.ColorScheme)
+	assert.Equal(t, []QuietHoursRule{{From: "22:00", To: "07:00"}}, config.QuietHours)
+	assert.Equal(t, IntervalsConfig{Capacity: time.Minute}, *config.Intervals)
+	assert.Equal(t, map[string]time.Duration{"peering": 2 * time.Minute}, config.OperationTimeouts)
+	assert.True(t, config.LocalAPI.Enabled)
+	assert.Equal(t, []string{"corp-[0-9]+"}, config.Redaction.Patterns)
+	//the invalid ones are dropped
+	assert.Empty(t, config.NotifyLevel)
+	assert.Zero(t, config.CredentialsWarningDays)
+	assert.Zero(t, config.ListPageSize)
+	assert.Empty(t, config.CaptivePortalProbeURL)
+	assert.Empty(t, config.LocalAPI.Address)
+	assert.NoError(t, config.Validate(), "invalid settings not dropped")
+	//a file written by a newer Agent is read anyway
+	config, version, err := decodeLocalConfig([]byte("version: 99\niconTheme: mono\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 99, version)
+	assert.Equal(t, "mono", config.IconTheme)
+	assert.Error(t, config.Validate())
+	//the invalid organization defaults are rejected
+	_, err = ParseOrgDefaults([]byte("notifyLevel: loud\n"))
+	assert.Error(t, err)
+	org, err := ParseOrgDefaults([]byte("notifyLevel: Icon\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "icon", org.NotifyLevel, "organization defaults not migrated")
+	assert.Zero(t, org.Version)
+}
+
+//mustRead returns the content of a file, failing the test if it cannot be read.
+func mustRead(t *testing.T, path string) []byte {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
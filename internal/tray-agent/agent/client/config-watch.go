@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"github.com/fsnotify/fsnotify"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/xdg"
+	"path/filepath"
+	"time"
+)
+
+/*This file contains the watch of the ConfigFileName config file, allowing to apply its manual changes without
+restarting the Agent. The directory containing the file is watched, rather than the file itself, so that the
+changes made by editors replacing the file (i.e. writing a new file and renaming it) are detected as well.*/
+
+//DefaultConfigWatchDelay is the time waited after the last change of the config file before reloading it, so that
+//a burst of writes is reloaded once.
+const DefaultConfigWatchDelay = 500 * time.Millisecond
+
+//WatchLocalConfig watches the ConfigFileName config file until ctx is done. After each change, the file is reloaded
+//(see ReloadLocalConfig): onChange is called if its content changed, onError if it cannot be read or has invalid
+//settings (which are dropped, while the valid ones are applied).
+//The callbacks are called sequentially, from a goroutine started by WatchLocalConfig.
+func WatchLocalConfig(ctx context.Context, delay time.Duration, onChange func(), onError func(error)) error {
+	liqoDir, err := xdg.DirConfig.Ensure()
+	if err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(liqoDir); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	go func() {
+		defer watcher.Close()
+		//the timer of the pending reload, started on the first change of the file
+		reload := time.NewTimer(delay)
+		reload.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				reload.Stop()
+				return
+			case event, open := <-watcher.Events:
+				if !open {
+					return
+				}
+				if filepath.Base(event.Name) != ConfigFileName || event.Op == fsnotify.Chmod {
+					continue
+				}
+				reload.Stop()
+				select {
+				case <-reload.C:
+				default:
+				}
+				reload.Reset(delay)
+			case err, open := <-watcher.Errors:
+				if !open {
+					return
+				}
+				if onError != nil {
+					onError(err)
+				}
+			case <-reload.C:
+				changed, err := ReloadLocalConfig()
+				if changed && onChange != nil {
+					onChange()
+				}
+				if err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchLocalConfig(t *testing.T) {
+	env, present := os.LookupEnv(EnvLiqoPath)
+	liqoPath, err := ioutil.TempDir("", "liqo-agent-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(liqoPath)
+		if present {
+			_ = os.Setenv(EnvLiqoPath, env)
+		} else {
+			_ = os.Unsetenv(EnvLiqoPath)
+		}
+		NewLocalConfig()
+		fileConfig.Lock()
+		fileConfig.Valid = false
+		fileConfig.Unlock()
+	}()
+	assert.NoError(t, os.Setenv(EnvLiqoPath, liqoPath), "PRE-TEST: envLiqoPath not set")
+	NewLocalConfig()
+	conf, _ := GetLocalConfig()
+	filePath := filepath.Join(liqoPath, ConfigFileName)
+	//reload
+	conf.SetIconTheme("mono")
+	assert.NoError(t, SaveLocalConfig())
+	changed, err := ReloadLocalConfig()
+	assert.NoError(t, err)
+	assert.False(t, changed, "the configuration saved by the Agent should not be a change")
+	assert.NoError(t, ioutil.WriteFile(filePath, []byte("iconTheme: default\nnotifyLevel: icon\n"), 0644))
+	changed, err = ReloadLocalConfig()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "icon", conf.GetNotifyLevel())
+	assert.NoError(t, ioutil.WriteFile(filePath, []byte("iconTheme: [\n"), 0644))
+	_, err = ReloadLocalConfig()
+	assert.Error(t, err, "invalid configuration reloaded")
+	assert.Equal(t, "default", conf.GetIconTheme(), "invalid configuration applied")
+	//watch
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan string, 10)
+	errs := make(chan error, 10)
+	assert.NoError(t, WatchLocalConfig(ctx, 50*time.Millisecond, func() {
+		changes <- conf.GetIconTheme()
+	}, func(err error) {
+		errs <- err
+	}))
+	assert.NoError(t, ioutil.WriteFile(filePath, []byte("iconTheme: mono\n"), 0644))
+	select {
+	case theme := <-changes:
+		assert.Equal(t, "mono", theme)
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("change of the configuration not detected")
+	}
+	//a file replaced by rename
+	tmpPath := filepath.Join(liqoPath, ConfigFileName+".tmp")
+	assert.NoError(t, ioutil.WriteFile(tmpPath, []byte("iconTheme: [\n"), 0644))
+	assert.NoError(t, os.Rename(tmpPath, filePath))
+	select {
+	case <-changes:
+		t.Fatal("invalid configuration applied")
+	case err := <-errs:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("invalid configuration not detected")
+	}
+	assert.Equal(t, "mono", conf.GetIconTheme())
+	//the own saves of the Agent are not changes
+	assert.NoError(t, SaveLocalConfig())
+	select {
+	case <-changes:
+		t.Fatal("the configuration saved by the Agent should not be a change")
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
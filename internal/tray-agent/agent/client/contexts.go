@@ -0,0 +1,97 @@
+package client
+
+import (
+	"fmt"
+	"k8s.io/client-go/tools/clientcmd"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+/*This file contains the switch of the kubeconfig context (or of the whole kubeconfig file) of an AgentController at
+runtime. Switching the context tears down the caches of the AgentController and rebuilds its clients and caches
+against the new context, keeping the EventBus (and therefore the listeners of the Agent logic) in place.*/
+
+//Contexts returns the names of the contexts of the kubeconfig file of the AgentController, sorted, together with
+//the active one.
+func (ctrl *AgentController) Contexts() (contexts []string, active string, err error) {
+	config, err := clientcmd.LoadFromFile(ctrl.kubeconfig)
+	if err != nil {
+		return nil, "", err
+	}
+	for name := range config.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+	active = ctrl.context
+	if active == "" {
+		active = config.CurrentContext
+	}
+	return contexts, active, nil
+}
+
+//SwitchContext connects the AgentController to another context of its kubeconfig file: the caches are stopped and
+//the clients and caches are rebuilt against the new context. If the new context cannot be reached, the previous one
+//is restored and the failure is returned.
+func (ctrl *AgentController) SwitchContext(context string) error {
+	config, err := clientcmd.LoadFromFile(ctrl.kubeconfig)
+	if err != nil {
+		return ClassifyError("switch context", err)
+	}
+	if _, present := config.Contexts[context]; !present {
+		return fmt.Errorf("switch context: context %s not found in %s", context, ctrl.kubeconfig)
+	}
+	//the current context of the file is used without copying the file
+	if context == config.CurrentContext {
+		context = ""
+	}
+	if context == ctrl.context && ctrl.Connected() {
+		return nil
+	}
+	previous := ctrl.context
+	ctrl.disconnect()
+	ctrl.context = context
+	if err = ctrl.connectCluster(); err != nil {
+		ctrl.disconnect()
+		ctrl.context = previous
+		_ = ctrl.connectCluster()
+		return ClassifyError("switch context", err)
+	}
+	return nil
+}
+
+//SwitchKubeconfig connects the AgentController to the current context of another kubeconfig file, like
+//SwitchContext. If the new file cannot be used, the previous file and context are restored and the failure is
+//returned.
+func (ctrl *AgentController) SwitchKubeconfig(kubeconfig string) error {
+	if kubeconfig == ctrl.kubeconfig && ctrl.Connected() {
+		return nil
+	}
+	if _, err := os.Stat(kubeconfig); err != nil && !ctrl.mocked {
+		return ClassifyError("switch kubeconfig", err)
+	}
+	previous, previousContext := ctrl.kubeconfig, ctrl.context
+	ctrl.disconnect()
+	ctrl.kubeconfig, ctrl.context = kubeconfig, ""
+	if err := ctrl.connectCluster(); err != nil {
+		ctrl.disconnect()
+		ctrl.kubeconfig, ctrl.context = previous, previousContext
+		_ = ctrl.connectCluster()
+		return ClassifyError("switch kubeconfig", err)
+	}
+	return os.Setenv(EnvLiqoKConfig, kubeconfig)
+}
+
+//disconnect stops the caches of the AgentController and removes the copy of the kubeconfig file selecting its
+//context, if any.
+func (ctrl *AgentController) disconnect() {
+	if ctrl.crds() != nil {
+		ctrl.StopCaches()
+	}
+	ctrl.connected = false
+	ctrl.valid = false
+	if ctrl.contextKubeconfig != "" {
+		_ = os.RemoveAll(filepath.Dir(ctrl.contextKubeconfig))
+		ctrl.contextKubeconfig = ""
+	}
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/test"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSwitchContext(t *testing.T) {
+	UseMockedAgentController()
+	dir, err := ioutil.TempDir("", "liqo-contexts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := clientcmdapi.NewConfig()
+	config.Clusters["one"] = &clientcmdapi.Cluster{Server: "https://one:6443"}
+	config.Contexts["one"] = &clientcmdapi.Context{Cluster: "one"}
+	config.Contexts["two"] = &clientcmdapi.Context{Cluster: "one"}
+	config.CurrentContext = "one"
+	kubeconfig := filepath.Join(dir, "config")
+	assert.NoError(t, clientcmd.WriteToFile(*config, kubeconfig))
+	ctrl := newAgentController(kubeconfig, "")
+	assert.NoError(t, ctrl.connectCluster())
+	defer ctrl.disconnect()
+	contexts, active, err := ctrl.Contexts()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", "two"}, contexts)
+	assert.Equal(t, "one", active)
+	assert.Error(t, ctrl.SwitchContext("missing"), "missing context selected")
+	assert.True(t, ctrl.Connected(), "disconnected by a missing context")
+	fcCtrl := ctrl.Controller(CRForeignCluster)
+	assert.NoError(t, fcCtrl.Store.Add(test.CreateForeignCluster("peer", "remote")))
+	//the caches are rebuilt against the new context
+	assert.NoError(t, ctrl.SwitchContext("two"))
+	assert.True(t, ctrl.Connected())
+	_, active, _ = ctrl.Contexts()
+	assert.Equal(t, "two", active)
+	assert.False(t, fcCtrl.Running(), "caches of the previous context running")
+	assert.Empty(t, ctrl.ForeignClusters().List(), "resources of the previous context cached")
+	//the shells point at the selected context
+	prev, present := os.LookupEnv(EnvLiqoKConfig)
+	assert.NoError(t, os.Setenv(EnvLiqoKConfig, kubeconfig))
+	env, err := ctrl.ShellEnv("")
+	if present {
+		_ = os.Setenv(EnvLiqoKConfig, prev)
+	} else {
+		_ = os.Unsetenv(EnvLiqoKConfig)
+	}
+	assert.NoError(t, err)
+	assert.Contains(t, env, EnvShellContext+"=two")
+	//the current context of the file is selected back
+	assert.NoError(t, ctrl.SwitchContext("one"))
+	assert.Empty(t, ctrl.Context())
+	assert.Empty(t, ctrl.contextKubeconfig)
+}
+
+func TestSwitchKubeconfig(t *testing.T) {
+	UseMockedAgentController()
+	dir, err := ioutil.TempDir("", "liqo-contexts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	prev, present := os.LookupEnv(EnvLiqoKConfig)
+	defer func() {
+		if present {
+			_ = os.Setenv(EnvLiqoKConfig, prev)
+		} else {
+			_ = os.Unsetenv(EnvLiqoKConfig)
+		}
+	}()
+	config := clientcmdapi.NewConfig()
+	config.Clusters["one"] = &clientcmdapi.Cluster{Server: "https://one:6443"}
+	config.Contexts["one"] = &clientcmdapi.Context{Cluster: "one"}
+	config.CurrentContext = "one"
+	kubeconfig := filepath.Join(dir, "config")
+	assert.NoError(t, clientcmd.WriteToFile(*config, kubeconfig))
+	config.Contexts["two"] = &clientcmdapi.Context{Cluster: "one"}
+	config.CurrentContext = "two"
+	other := filepath.Join(dir, "other")
+	assert.NoError(t, clientcmd.WriteToFile(*config, other))
+	ctrl := newAgentController(kubeconfig, "")
+	assert.NoError(t, ctrl.connectCluster())
+	defer ctrl.disconnect()
+	//the current context of the new file is selected
+	assert.NoError(t, ctrl.SwitchKubeconfig(other))
+	assert.True(t, ctrl.Connected())
+	assert.Equal(t, other, ctrl.Kubeconfig())
+	assert.Empty(t, ctrl.Context())
+	contexts, active, err := ctrl.Contexts()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", "two"}, contexts)
+	assert.Equal(t, "two", active)
+	assert.Equal(t, other, os.Getenv(EnvLiqoKConfig))
+}
+
+func TestReconnect(t *testing.T) {
+	UseMockedAgentController()
+	ctrl := newAgentController("/test/path", "")
+	assert.NoError(t, ctrl.Reconnect())
+	defer ctrl.disconnect()
+	assert.True(t, ctrl.Connected())
+	fcCtrl := ctrl.Controller(CRForeignCluster)
+	//a connected AgentController is left untouched
+	assert.NoError(t, ctrl.Reconnect())
+	assert.Equal(t, fcCtrl, ctrl.Controller(CRForeignCluster), "connected AgentController reconnected")
+	ctrl.disconnect()
+	assert.False(t, ctrl.Connected())
+	assert.NoError(t, ctrl.Reconnect())
+	assert.True(t, ctrl.Connected(), "AgentController not reconnected")
+}
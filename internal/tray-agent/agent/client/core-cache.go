@@ -0,0 +1,240 @@
+package client
+
+import (
+	"context"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"sync/atomic"
+)
+
+//coreResources contains the standard kubernetes resources watched by the coreCache.
+var coreResources = []coreResource{
+	{
+		watchedResource: watchedResource{Group: "storage.k8s.io", Resource: "storageclasses"},
+		object:          &storagev1.StorageClass{},
+		list: func(client kubernetes.Interface, namespace string) listFunc {
+			return func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.StorageV1().StorageClasses().List(context.TODO(), options)
+			}
+		},
+	},
+	{
+		watchedResource: watchedResource{Resource: "persistentvolumeclaims"},
+		object:          &corev1.PersistentVolumeClaim{},
+		list: func(client kubernetes.Interface, namespace string) listFunc {
+			return func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.CoreV1().PersistentVolumeClaims(namespace).List(context.TODO(), options)
+			}
+		},
+	},
+	{
+		watchedResource: watchedResource{Resource: "nodes"},
+		object:          &corev1.Node{},
+		list: func(client kubernetes.Interface, namespace string) listFunc {
+			return func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.CoreV1().Nodes().List(context.TODO(), options)
+			}
+		},
+	},
+	{
+		watchedResource: watchedResource{Resource: "pods"},
+		object:          &corev1.Pod{},
+		list:            listPods,
+	},
+	{
+		watchedResource: watchedResource{Resource: "namespaces"},
+		object:          &corev1.Namespace{},
+		list: func(client kubernetes.Interface, namespace string) listFunc {
+			return func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.CoreV1().Namespaces().List(context.TODO(), options)
+			}
+		},
+	},
+	{
+		watchedResource: watchedResource{Group: "apps", Resource: "deployments"},
+		liqo:            true,
+		object:          &appsv1.Deployment{},
+		list: func(client kubernetes.Interface, namespace string) listFunc {
+			return func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.AppsV1().Deployments(namespace).List(context.TODO(), options)
+			}
+		},
+	},
+	{
+		watchedResource: watchedResource{Group: "apps", Resource: "daemonsets"},
+		liqo:            true,
+		object:          &appsv1.DaemonSet{},
+		list: func(client kubernetes.Interface, namespace string) listFunc {
+			return func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.AppsV1().DaemonSets(namespace).List(context.TODO(), options)
+			}
+		},
+	},
+	{
+		watchedResource: watchedResource{Resource: "pods"},
+		liqo:            true,
+		object:          &corev1.Pod{},
+		list:            listPods,
+	},
+}
+
+//listPods returns the function listing the pods in a namespace.
+func listPods(client kubernetes.Interface, namespace string) listFunc {
+	return func(options metav1.ListOptions) (runtime.Object, error) {
+		return client.CoreV1().Pods(namespace).List(context.TODO(), options)
+	}
+}
+
+//coreCache watches the standard kubernetes resources required by the Agent, complementing the CRD caches.
+type coreCache struct {
+	//factory provides the informers of the watched cluster-wide resources.
+	factory informers.SharedInformerFactory
+	//liqoFactory provides the informers of the resources watched in the Liqo namespace.
+	liqoFactory informers.SharedInformerFactory
+	//liqoNamespace is the namespace the Liqo control plane is installed in.
+	liqoNamespace string
+	//stop is closed to stop the informers.
+	stop chan struct{}
+	//running specifies whether the informers are running.
+	running bool
+	//pending contains, for each Topic fed by the coreCache, a flag set when a notification is waiting
+	//to be consumed.
+	pending map[Topic]*int32
+}
+
+//startCoreCache starts (if not running) the informers of the standard kubernetes resources.
+func (ctrl *AgentController) startCoreCache() {
+	if ctrl.coreCache == nil {
+		ctrl.coreCache = &coreCache{}
+	}
+	c := ctrl.coreCache
+	if c.running {
+		return
+	}
+	c.pending = map[Topic]*int32{
+		TopicStorageChanged:    new(int32),
+		TopicHealthChanged:     new(int32),
+		TopicWorkloadsChanged:  new(int32),
+		TopicNamespacesChanged: new(int32),
+	}
+	c.factory = informers.NewSharedInformerFactory(ctrl.kube(), 0)
+	ctrl.usePollingInformers(c.factory, "", false)
+	storageHandler := ctrl.coalescedHandler(TopicStorageChanged)
+	c.factory.Storage().V1().StorageClasses().Informer().AddEventHandler(storageHandler)
+	c.factory.Core().V1().PersistentVolumeClaims().Informer().AddEventHandler(storageHandler)
+	c.factory.Core().V1().Nodes().Informer().AddEventHandler(storageHandler)
+	//only the pods mounting volume claims are relevant for the storage
+	c.factory.Core().V1().Pods().Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			return hasVolumeClaims(obj)
+		},
+		Handler: storageHandler,
+	})
+	//the offloaded pods are the ones scheduled on the virtual nodes, whose changes trigger a recheck as well
+	nodes := c.factory.Core().V1().Nodes().Lister()
+	workloadsHandler := ctrl.coalescedHandler(TopicWorkloadsChanged)
+	c.factory.Core().V1().Pods().Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			return isOffloadedPod(nodes, obj)
+		},
+		Handler: workloadsHandler,
+	})
+	c.factory.Core().V1().Nodes().Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isVirtualNode,
+		Handler:    workloadsHandler,
+	})
+	//the state of the offloading of a namespace depends on the virtual nodes and on its offloaded pods as well
+	namespacesHandler := ctrl.coalescedHandler(TopicNamespacesChanged)
+	c.factory.Core().V1().Namespaces().Informer().AddEventHandler(namespacesHandler)
+	c.factory.Core().V1().Nodes().Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isVirtualNode,
+		Handler:    namespacesHandler,
+	})
+	c.factory.Core().V1().Pods().Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			return isOffloadedPod(nodes, obj)
+		},
+		Handler: namespacesHandler,
+	})
+	conf, _ := GetLocalConfig()
+	c.liqoNamespace = conf.GetLiqoNamespace()
+	c.liqoFactory = informers.NewSharedInformerFactoryWithOptions(ctrl.kube(), 0,
+		informers.WithNamespace(c.liqoNamespace))
+	ctrl.usePollingInformers(c.liqoFactory, c.liqoNamespace, true)
+	healthHandler := ctrl.coalescedHandler(TopicHealthChanged)
+	c.liqoFactory.Apps().V1().Deployments().Informer().AddEventHandler(healthHandler)
+	c.liqoFactory.Apps().V1().DaemonSets().Informer().AddEventHandler(healthHandler)
+	c.liqoFactory.Core().V1().Pods().Informer().AddEventHandler(healthHandler)
+	c.stop = make(chan struct{})
+	c.factory.Start(c.stop)
+	c.liqoFactory.Start(c.stop)
+	c.running = true
+}
+
+//syncTargets returns the informers of the coreCache, whose initial synchronization is awaited by the warm-up.
+func (c *coreCache) syncTargets() []syncTarget {
+	informers := []struct {
+		name     string
+		informer cache.SharedIndexInformer
+	}{
+		{"storageclasses", c.factory.Storage().V1().StorageClasses().Informer()},
+		{"persistentvolumeclaims", c.factory.Core().V1().PersistentVolumeClaims().Informer()},
+		{"nodes", c.factory.Core().V1().Nodes().Informer()},
+		{"pods", c.factory.Core().V1().Pods().Informer()},
+		{"namespaces", c.factory.Core().V1().Namespaces().Informer()},
+		{"liqo/deployments", c.liqoFactory.Apps().V1().Deployments().Informer()},
+		{"liqo/daemonsets", c.liqoFactory.Apps().V1().DaemonSets().Informer()},
+		{"liqo/pods", c.liqoFactory.Core().V1().Pods().Informer()},
+	}
+	targets := make([]syncTarget, 0, len(informers))
+	for _, inf := range informers {
+		targets = append(targets, syncTarget{name: inf.name, hasSynced: inf.informer.HasSynced, stop: c.stop})
+	}
+	return targets
+}
+
+//stopCoreCache stops (if running) the informers of the standard kubernetes resources.
+func (ctrl *AgentController) stopCoreCache() {
+	if c := ctrl.coreCache; c != nil && c.running {
+		close(c.stop)
+		c.running = false
+	}
+}
+
+//coalescedHandler returns an event handler notifying any event on a Topic.
+func (ctrl *AgentController) coalescedHandler(topic Topic) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			ctrl.notifyCoalesced(topic)
+		},
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			ctrl.notifyCoalesced(topic)
+		},
+		DeleteFunc: func(obj interface{}) {
+			ctrl.notifyCoalesced(topic)
+		},
+	}
+}
+
+//notifyCoalesced publishes a notification on a Topic fed by the coreCache, unless another one is
+//already waiting to be consumed: the receivers are expected to process the whole cache content at once.
+func (ctrl *AgentController) notifyCoalesced(topic Topic) {
+	flag := ctrl.coreCache.pending[topic]
+	if !atomic.CompareAndSwapInt32(flag, 0, 1) {
+		return
+	}
+	if !ctrl.events.TryPublish(topic, struct{}{}) {
+		atomic.StoreInt32(flag, 0)
+	}
+}
+
+//consumeCoalesced marks the notifications on a Topic fed by the coreCache as consumed.
+func (c *coreCache) consumeCoalesced(topic Topic) {
+	atomic.StoreInt32(c.pending[topic], 0)
+}
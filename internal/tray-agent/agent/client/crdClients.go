@@ -1,11 +1,9 @@
 package client
 
 import (
-	"errors"
+	"fmt"
 	"github.com/liqotech/liqo/pkg/crdClient"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
-	"os"
 )
 
 //CustomResource defines the CRD managed by Liqo Agent.
@@ -26,6 +24,14 @@ var customResources = []CustomResource{
 	CRClusterConfig,
 	CRAdvertisement,
 	CRForeignCluster,
+	CRResourceOffer,
+	CRResourceRequest,
+	CRTunnelEndpoint,
+}
+
+//customResourceGroup returns the API group of a CustomResource.
+func customResourceGroup(resource CustomResource) string {
+	return crdClient.Registry[string(resource)].Resource.Group
 }
 
 //crdManager stores the resources necessary to manage the CRDs.
@@ -34,40 +40,59 @@ type crdManager struct {
 	clientMap map[CustomResource]*CRDController
 }
 
+//crdControllerFactories contains, for each CustomResource, the function creating its CRDController.
+var crdControllerFactories = map[CustomResource]func(ctrl *AgentController, kubeconfig string) (*CRDController,
+	error){
+	CRClusterConfig:   createClusterConfigController,
+	CRAdvertisement:   createAdvertisementController,
+	CRForeignCluster:  createForeignClusterController,
+	CRResourceOffer:   createResourceOfferController,
+	CRResourceRequest: createResourceRequestController,
+	CRTunnelEndpoint:  createTunnelEndpointController,
+}
+
 //initCRDManager creates and initializes the crdManager, loading the CRDController for each
 //required CRD.
 func (ctrl *AgentController) initCRDManager() error {
 	//struct init
 	manager := &crdManager{clientMap: make(map[CustomResource]*CRDController)}
-	ctrl.crdManager = manager
-	kubeconfig, set := os.LookupEnv(EnvLiqoKConfig)
-	if !set {
-		return errors.New("no kubeconfig provided")
-	}
-	//creation of each single CRDController and registration to the manager
-	var err error
-	var crdCtrl *CRDController
-	//	CLUSTERCONFIG
-	crdCtrl, err = createClusterConfigController(kubeconfig)
-	if err != nil {
-		return errors.New("connection error on clusterconfigs client creation")
-	}
-	manager.clientMap[CRClusterConfig] = crdCtrl
-	//	ADVERTISEMENT
-	crdCtrl, err = createAdvertisementController(kubeconfig)
+	//the manager is replaced once filled, even partially, so that its running caches can be stopped
+	defer func() {
+		ctrl.clientsMutex.Lock()
+		ctrl.crdManager = manager
+		ctrl.clientsMutex.Unlock()
+	}()
+	kubeconfig, err := ctrl.crdKubeconfig()
 	if err != nil {
-		return errors.New("connection error on advertisements client creation")
+		return err
 	}
-	manager.clientMap[CRAdvertisement] = crdCtrl
-	//	FOREIGNCLUSTER
-	crdCtrl, err = createForeignClusterController(kubeconfig)
-	if err != nil {
-		return errors.New("connection error on foreignclusters client creation")
+	//creation of each single CRDController and registration to the manager
+	for _, resource := range customResources {
+		crdCtrl, err := crdControllerFactories[resource](ctrl, kubeconfig)
+		if err != nil {
+			return fmt.Errorf("connection error on %s client creation", resource)
+		}
+		manager.clientMap[resource] = crdCtrl
 	}
-	manager.clientMap[CRForeignCluster] = crdCtrl
 	return nil
 }
 
+//crds returns the crdManager of the AgentController, nil if not created yet.
+func (ctrl *AgentController) crds() *crdManager {
+	ctrl.clientsMutex.RLock()
+	defer ctrl.clientsMutex.RUnlock()
+	return ctrl.crdManager
+}
+
+//Controller returns (if present) the CRDController for a specific CRD.
+func (ctrl *AgentController) Controller(resource CustomResource) *CRDController {
+	manager := ctrl.crds()
+	if manager == nil {
+		return nil
+	}
+	return manager.Controller(resource)
+}
+
 //Controller returns (if present) the CRDController for a specific CRD.
 func (m *crdManager) Controller(resource CustomResource) *CRDController {
 	//controller, present = m.clientMap[resource]
@@ -78,48 +103,52 @@ func (m *crdManager) Controller(resource CustomResource) *CRDController {
 type CRDController struct {
 	//CRDClient to perform CRUD operations on the CRD.
 	*crdClient.CRDClient
-	//resource is the CRD literal identifier.
-	resource string
-	//running specifies whether the CRD cache is running.
-	running bool
-	//addFunc is the handler for the 'resource added' event.
-	addFunc func(obj interface{})
-	//updateFunc is the handler for the 'resource updated' event.
-	updateFunc func(oldObj interface{}, newObj interface{})
-	//deleteFunc is the handler for the 'resource deleted' event.
-	deleteFunc func(obj interface{})
+	//cache is the local copy of the CRs, fed by the CRDClient.
+	cache *Cache
+	//polling is the BackoffPolicy of the polling feeding the cache, if the CRs cannot be watched.
+	polling *BackoffPolicy
+}
+
+//newCRDController returns a CRDController for a CRD, whose cache events are delivered to handler (if not nil).
+func newCRDController(client *crdClient.CRDClient, resource CustomResource, handler CacheHandler) *CRDController {
+	c := &CRDController{CRDClient: client}
+	c.cache = newCache(string(resource), func(handlers cache.ResourceEventHandlerFuncs) (cache.Store,
+		chan struct{}, cache.InformerSynced, error) {
+		return watchCRDResources(client, string(resource), handlers, c.polling)
+	})
+	if handler != nil {
+		c.cache.Subscribe(handler)
+	}
+	return c
+}
+
+//Cache returns the cache of the CRs.
+func (c *CRDController) Cache() *Cache {
+	if c == nil {
+		return nil
+	}
+	return c.cache
 }
 
 //Running returns whether the controller cache is running.
 func (c *CRDController) Running() bool {
-	return c.running
+	return c.Cache().Running()
 }
 
 //StartCache starts the CRD cache and the sending of signals
-//on the Controller notifyChannels.
+//on the Controller EventBus.
 func (c *CRDController) StartCache() error {
-	if c.running {
-		return nil
-	}
-	ehf := cache.ResourceEventHandlerFuncs{
-		AddFunc:    c.addFunc,
-		UpdateFunc: c.updateFunc,
-		DeleteFunc: c.deleteFunc,
+	if err := c.cache.Start(); err != nil {
+		return err
 	}
-	lo := metav1.ListOptions{}
-	var err error
-	c.Store, c.Stop, err = crdClient.WatchResources(
-		c.CRDClient, c.resource, "", 0, ehf, lo)
-	if err == nil {
-		c.running = true
-	}
-	return err
+	//the CRDClient operations rely on the informer store
+	c.cache.mutex.RLock()
+	c.Store, c.Stop = c.cache.store, c.cache.stop
+	c.cache.mutex.RUnlock()
+	return nil
 }
 
 //StopCache stops (if running) the cache associated for the CRD.
 func (c *CRDController) StopCache() {
-	if c.running {
-		close(c.Stop)
-		c.running = false
-	}
+	c.cache.Stop()
 }
@@ -0,0 +1,361 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/redact"
+	"github.com/liqotech/liqo/pkg/discovery"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*This file contains the credential helpers, the extension point providing the authentication material required to
+peer with some clusters (e.g. a token issued by a corporate SSO for the remote cluster). Before starting an outgoing
+peering, the first CredentialHelper matching the peer is asked for its credential, which is handed to Liqo as the
+auth token of the peer (a Secret labelled with discovery.AuthTokenLabel in the Liqo namespace).
+
+The helpers are either registered in-process with RegisterCredentialHelper or, as ExecCredentialHelper, external
+commands listed in the 'credentialHelpers' field of the local configuration. The credentials are kept in the
+CredentialCache until their expiry.*/
+
+//Environment variables identifying the peer to the commands of the ExecCredentialHelper.
+const (
+	//EnvHelperPeerClusterID contains the ClusterID of the peer.
+	EnvHelperPeerClusterID = "LIQO_PEER_CLUSTER_ID"
+	//EnvHelperPeerClusterName contains the ClusterName of the peer.
+	EnvHelperPeerClusterName = "LIQO_PEER_CLUSTER_NAME"
+	//EnvHelperPeerAuthURL contains the URL of the authentication service of the peer.
+	EnvHelperPeerAuthURL = "LIQO_PEER_AUTH_URL"
+)
+
+//DefaultCredentialTTL is the time a credential without expiry is kept in the CredentialCache.
+const DefaultCredentialTTL = time.Hour
+
+//credentialExpiryMargin is the time before its expiry a cached credential is considered expired, so that it does
+//not expire while the peering is being established.
+const credentialExpiryMargin = time.Minute
+
+//peerAuthSecretPrefix precedes the ClusterID of a peer in the name of the Secret containing its auth token.
+const peerAuthSecretPrefix = "liqo-agent-auth-"
+
+//CredentialRequest identifies the peer a credential is requested for.
+type CredentialRequest struct {
+	ClusterID   string
+	ClusterName string
+	//AuthURL is the URL of the authentication service of the peer.
+	AuthURL string
+}
+
+//PeerCredential is the authentication material provided by a CredentialHelper.
+type PeerCredential struct {
+	//Token is the auth token presented to the peer.
+	Token string `json:"token"`
+	//Expiry is the instant after which the credential is no more valid. If zero, the credential is cached for
+	//DefaultCredentialTTL.
+	Expiry time.Time `json:"expiry,omitempty"`
+}
+
+//CredentialHelper provides the authentication material required to peer with some clusters.
+type CredentialHelper interface {
+	//Name identifies the CredentialHelper, e.g. in the CredentialCache and in the error messages.
+	Name() string
+	//Matches returns whether the CredentialHelper provides the credential of a peer.
+	Matches(req CredentialRequest) bool
+	//Credential retrieves the credential of a peer.
+	Credential(ctx context.Context, req CredentialRequest) (*PeerCredential, error)
+}
+
+//CredentialHelperConfig describes an ExecCredentialHelper in the local configuration.
+type CredentialHelperConfig struct {
+	Name string `yaml:"name"`
+	//Command is the executable run to retrieve a credential, with its Args.
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args,omitempty"`
+	//Peers contains the ClusterIDs or ClusterNames of the peers the helper is used for. If empty, it is used for all
+	//the peers.
+	Peers []string `yaml:"peers,omitempty"`
+}
+
+//ExecCredentialHelper is a CredentialHelper running an external command. The command receives the peer in the
+//EnvHelperPeerClusterID, EnvHelperPeerClusterName and EnvHelperPeerAuthURL env variables and writes the credential
+//to its standard output, either as a PeerCredential JSON object (e.g. {"token": "...", "expiry":
+//"2021-05-01T10:00:00Z"}) or as the bare token.
+type ExecCredentialHelper struct {
+	conf CredentialHelperConfig
+}
+
+//NewExecCredentialHelper returns the ExecCredentialHelper described by a CredentialHelperConfig.
+func NewExecCredentialHelper(conf CredentialHelperConfig) *ExecCredentialHelper {
+	return &ExecCredentialHelper{conf: conf}
+}
+
+//Name implements the CredentialHelper interface.
+func (h *ExecCredentialHelper) Name() string {
+	if h.conf.Name != "" {
+		return h.conf.Name
+	}
+	return h.conf.Command
+}
+
+//Matches implements the CredentialHelper interface.
+func (h *ExecCredentialHelper) Matches(req CredentialRequest) bool {
+	if len(h.conf.Peers) == 0 {
+		return true
+	}
+	for _, p := range h.conf.Peers {
+		if p == req.ClusterID || (req.ClusterName != "" && p == req.ClusterName) {
+			return true
+		}
+	}
+	return false
+}
+
+//Credential implements the CredentialHelper interface.
+func (h *ExecCredentialHelper) Credential(ctx context.Context, req CredentialRequest) (*PeerCredential, error) {
+	if h.conf.Command == "" {
+		return nil, errors.New("no command specified")
+	}
+	cmd := exec.CommandContext(ctx, h.conf.Command, h.conf.Args...)
+	cmd.Env = append(os.Environ(), EnvHelperPeerClusterID+"="+req.ClusterID,
+		EnvHelperPeerClusterName+"="+req.ClusterName, EnvHelperPeerAuthURL+"="+req.AuthURL)
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			//the output of the helper may contain the credential
+			return nil, fmt.Errorf("%v: %s", err, redact.String(msg))
+		}
+		return nil, err
+	}
+	return parseHelperOutput(stdout.Bytes())
+}
+
+//parseHelperOutput parses the credential written by the command of an ExecCredentialHelper.
+func parseHelperOutput(output []byte) (*PeerCredential, error) {
+	output = bytes.TrimSpace(output)
+	cred := &PeerCredential{}
+	if bytes.HasPrefix(output, []byte("{")) {
+		if err := json.Unmarshal(output, cred); err != nil {
+			return nil, fmt.Errorf("invalid output: %v", err)
+		}
+	} else {
+		cred.Token = string(output)
+	}
+	if cred.Token == "" || strings.ContainsAny(cred.Token, "\n\r") {
+		return nil, errors.New("invalid output: no token provided")
+	}
+	return cred, nil
+}
+
+//registeredHelpers contains the CredentialHelpers registered with RegisterCredentialHelper.
+var registeredHelpers = struct {
+	helpers []CredentialHelper
+	sync.RWMutex
+}{}
+
+//RegisterCredentialHelper registers a CredentialHelper, asked for the credentials of the peers before the ones of
+//the local configuration.
+func RegisterCredentialHelper(h CredentialHelper) {
+	registeredHelpers.Lock()
+	defer registeredHelpers.Unlock()
+	registeredHelpers.helpers = append(registeredHelpers.helpers, h)
+}
+
+//credentialHelper returns the first CredentialHelper matching a peer, if any.
+func credentialHelper(req CredentialRequest) (CredentialHelper, bool) {
+	registeredHelpers.RLock()
+	helpers := append([]CredentialHelper(nil), registeredHelpers.helpers...)
+	registeredHelpers.RUnlock()
+	conf, _ := GetLocalConfig()
+	for _, c := range conf.GetCredentialHelpers() {
+		helpers = append(helpers, NewExecCredentialHelper(c))
+	}
+	for _, h := range helpers {
+		if h.Matches(req) {
+			return h, true
+		}
+	}
+	return nil, false
+}
+
+//CredentialCache keeps the credentials provided by the CredentialHelpers until their expiry. The credentials are
+//kept in memory only.
+type CredentialCache struct {
+	credentials map[string]cachedCredential
+	sync.Mutex
+}
+
+//cachedCredential is a credential kept in the CredentialCache.
+type cachedCredential struct {
+	PeerCredential
+	clusterID string
+	//expires specifies whether the expiry has been provided by the CredentialHelper, rather than being the end of
+	//DefaultCredentialTTL.
+	expires bool
+}
+
+//credentialCache is the CredentialCache singleton.
+var credentialCache = &CredentialCache{credentials: make(map[string]cachedCredential)}
+
+//GetCredentialCache returns the CredentialCache singleton.
+func GetCredentialCache() *CredentialCache {
+	return credentialCache
+}
+
+//credentialKey returns the key of the credential of a peer provided by a CredentialHelper.
+func credentialKey(helper string, clusterID string) string {
+	return helper + "/" + clusterID
+}
+
+//Get returns the credential of a peer provided by a CredentialHelper, if cached and not expired.
+func (c *CredentialCache) Get(helper string, clusterID string) (PeerCredential, bool) {
+	c.Lock()
+	defer c.Unlock()
+	key := credentialKey(helper, clusterID)
+	cred, present := c.credentials[key]
+	if present && time.Now().Add(credentialExpiryMargin).After(cred.Expiry) {
+		delete(c.credentials, key)
+		return PeerCredential{}, false
+	}
+	return cred.PeerCredential, present
+}
+
+//Put caches the credential of a peer provided by a CredentialHelper. A credential without expiry is kept for
+//DefaultCredentialTTL.
+func (c *CredentialCache) Put(helper string, clusterID string, cred PeerCredential) {
+	expires := !cred.Expiry.IsZero()
+	if !expires {
+		cred.Expiry = time.Now().Add(DefaultCredentialTTL)
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.credentials[credentialKey(helper, clusterID)] = cachedCredential{PeerCredential: cred, clusterID: clusterID,
+		expires: expires}
+}
+
+//Expiring returns the cached credentials whose expiry has been provided by their CredentialHelper, sorted by peer.
+//The User of each CredentialInfo is the ClusterID of the peer.
+func (c *CredentialCache) Expiring() []*CredentialInfo {
+	c.Lock()
+	defer c.Unlock()
+	credentials := make([]*CredentialInfo, 0)
+	for _, cred := range c.credentials {
+		if cred.expires {
+			credentials = append(credentials, &CredentialInfo{User: cred.clusterID, Kind: CredentialPeerToken,
+				Expiry: cred.Expiry, ClusterID: cred.clusterID})
+		}
+	}
+	sort.Slice(credentials, func(a, b int) bool {
+		return credentials[a].ClusterID < credentials[b].ClusterID
+	})
+	return credentials
+}
+
+//Forget removes the cached credentials of a peer, e.g. when refused.
+func (c *CredentialCache) Forget(clusterID string) {
+	c.Lock()
+	defer c.Unlock()
+	for key := range c.credentials {
+		if strings.HasSuffix(key, "/"+clusterID) {
+			delete(c.credentials, key)
+		}
+	}
+}
+
+//Clear removes all the cached credentials.
+func (c *CredentialCache) Clear() {
+	c.Lock()
+	defer c.Unlock()
+	c.credentials = make(map[string]cachedCredential)
+}
+
+//ProvidePeerCredentials asks the CredentialHelper matching the peer described by a ForeignCluster (if any) for its
+//credential, using the cached one if still valid, and hands it to Liqo as the auth token of the peer. It returns
+//the name of the CredentialHelper used, or an empty string if no helper matches the peer.
+func (ctrl *AgentController) ProvidePeerCredentials(ctx context.Context, foreignCluster string) (string, error) {
+	fc, exists := ctrl.ForeignClusters().Get(foreignCluster)
+	if !exists {
+		return "", fmt.Errorf("peer credentials: ForeignCluster %s not found", foreignCluster)
+	}
+	req := CredentialRequest{
+		ClusterID:   fc.Spec.ClusterIdentity.ClusterID,
+		ClusterName: fc.Spec.ClusterIdentity.ClusterName,
+		AuthURL:     fc.Spec.AuthUrl,
+	}
+	helper, found := credentialHelper(req)
+	if !found {
+		return "", nil
+	}
+	cache := GetCredentialCache()
+	//a refused credential is not reused
+	if fc.Status.AuthStatus == discovery.AuthStatusRefused ||
+		fc.Status.AuthStatus == discovery.AuthStatusEmptyRefused {
+		cache.Forget(req.ClusterID)
+	}
+	cred, cached := cache.Get(helper.Name(), req.ClusterID)
+	if !cached {
+		fresh, err := helper.Credential(ctx, req)
+		if err != nil {
+			return helper.Name(), ClassifyError("peer credentials", fmt.Errorf("credential helper %s: %w",
+				helper.Name(), err))
+		}
+		cred = *fresh
+		cache.Put(helper.Name(), req.ClusterID, cred)
+	}
+	if err := ctrl.storePeerAuthToken(ctx, req.ClusterID, cred.Token); err != nil {
+		return helper.Name(), ClassifyError("peer credentials", err)
+	}
+	return helper.Name(), nil
+}
+
+//RefreshPeerCredential discards the cached credential of a peer and asks the CredentialHelper matching it for a new
+//one, handed to Liqo as the auth token of the peer.
+func (ctrl *AgentController) RefreshPeerCredential(ctx context.Context, clusterID string) error {
+	GetCredentialCache().Forget(clusterID)
+	for _, fc := range ctrl.ForeignClusters().List() {
+		if fc.Spec.ClusterIdentity.ClusterID == clusterID {
+			_, err := ctrl.ProvidePeerCredentials(ctx, fc.Name)
+			return err
+		}
+	}
+	return fmt.Errorf("peer credentials: peer %s not found", clusterID)
+}
+
+//storePeerAuthToken creates (or updates) the Secret containing the auth token of a peer, read by Liqo when
+//authenticating with it.
+func (ctrl *AgentController) storePeerAuthToken(ctx context.Context, clusterID string, token string) error {
+	conf, _ := GetLocalConfig()
+	secrets := ctrl.kube().CoreV1().Secrets(conf.GetLiqoNamespace())
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: peerAuthSecretPrefix + clusterID,
+			Labels: map[string]string{
+				discovery.ClusterIdLabel: clusterID,
+				discovery.AuthTokenLabel: "",
+			},
+		},
+		Data: map[string][]byte{"token": []byte(token)},
+	}
+	_, err := secrets.Create(ctx, secret, metav1.CreateOptions{})
+	if !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	existing, err := secrets.Get(ctx, secret.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	existing.Labels = secret.Labels
+	existing.Data = map[string][]byte{"token": []byte(token)}
+	_, err = secrets.Update(ctx, existing, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/test"
+	"github.com/liqotech/liqo/pkg/discovery"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+//testCredentialHelper is a CredentialHelper counting the requested credentials.
+type testCredentialHelper struct {
+	clusterID string
+	calls     int
+	err       error
+	expiry    time.Time
+}
+
+func (h *testCredentialHelper) Name() string {
+	return "test"
+}
+
+func (h *testCredentialHelper) Matches(req CredentialRequest) bool {
+	return req.ClusterID == h.clusterID
+}
+
+func (h *testCredentialHelper) Credential(ctx context.Context, req CredentialRequest) (*PeerCredential, error) {
+	h.calls++
+	if h.err != nil {
+		return nil, h.err
+	}
+	return &PeerCredential{Token: "token-" + req.ClusterID, Expiry: h.expiry}, nil
+}
+
+func TestExecCredentialHelper(t *testing.T) {
+	h := NewExecCredentialHelper(CredentialHelperConfig{
+		Command: "sh",
+		Args:    []string{"-c", `echo "{\"token\": \"sso-$LIQO_PEER_CLUSTER_NAME\", \"expiry\": \"2030-01-01T00:00:00Z\"}"`},
+		Peers:   []string{"remote"},
+	})
+	assert.Equal(t, "sh", h.Name())
+	assert.True(t, h.Matches(CredentialRequest{ClusterID: "c1", ClusterName: "remote"}))
+	assert.False(t, h.Matches(CredentialRequest{ClusterID: "c2", ClusterName: "other"}))
+	cred, err := h.Credential(context.TODO(), CredentialRequest{ClusterID: "c1", ClusterName: "remote"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "sso-remote", cred.Token)
+		assert.Equal(t, 2030, cred.Expiry.Year())
+	}
+	//the bare token is accepted as well
+	cred, err = parseHelperOutput([]byte("plain-token\n"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "plain-token", cred.Token)
+		assert.True(t, cred.Expiry.IsZero())
+	}
+	_, err = parseHelperOutput([]byte(" \n"))
+	assert.Error(t, err, "empty credential accepted")
+	_, err = NewExecCredentialHelper(CredentialHelperConfig{Command: "sh", Args: []string{"-c", "echo denied >&2; exit 1"}}).
+		Credential(context.TODO(), CredentialRequest{ClusterID: "c1"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "denied")
+	}
+}
+
+func TestCredentialCache(t *testing.T) {
+	c := &CredentialCache{credentials: make(map[string]cachedCredential)}
+	c.Put("h", "c1", PeerCredential{Token: "t1"})
+	cred, present := c.Get("h", "c1")
+	assert.True(t, present)
+	assert.Equal(t, "t1", cred.Token)
+	//the credentials about to expire are not returned
+	c.Put("h", "c2", PeerCredential{Token: "t2", Expiry: time.Now().Add(time.Second)})
+	_, present = c.Get("h", "c2")
+	assert.False(t, present, "expiring credential returned")
+	c.Forget("c1")
+	_, present = c.Get("h", "c1")
+	assert.False(t, present, "forgotten credential returned")
+	//only the credentials with an expiry provided by their helper are expiring
+	expiry := time.Now().Add(time.Hour)
+	c.Put("h", "c3", PeerCredential{Token: "t3", Expiry: expiry})
+	c.Put("h", "c4", PeerCredential{Token: "t4"})
+	assert.Equal(t, []*CredentialInfo{{User: "c3", Kind: CredentialPeerToken, Expiry: expiry, ClusterID: "c3"}},
+		c.Expiring())
+}
+
+func TestProvidePeerCredentials(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	GetCredentialCache().Clear()
+	helper := &testCredentialHelper{clusterID: "cred-fc"}
+	RegisterCredentialHelper(helper)
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Add(test.CreateForeignCluster("cred-fc", "remote")))
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Add(test.CreateForeignCluster("plain-fc", "plain")))
+	//the peers without a matching helper are left alone
+	name, err := ctrl.ProvidePeerCredentials(context.TODO(), "plain-fc")
+	assert.NoError(t, err)
+	assert.Empty(t, name)
+	name, err = ctrl.ProvidePeerCredentials(context.TODO(), "cred-fc")
+	assert.NoError(t, err)
+	assert.Equal(t, "test", name)
+	secret, err := ctrl.kubeClient.CoreV1().Secrets("liqo").Get(context.TODO(), peerAuthSecretPrefix+"cred-fc",
+		metav1.GetOptions{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "token-cred-fc", string(secret.Data["token"]))
+		assert.Equal(t, "cred-fc", secret.Labels[discovery.ClusterIdLabel])
+		assert.Contains(t, secret.Labels, discovery.AuthTokenLabel)
+	}
+	//the cached credential is reused, and the Secret updated
+	_, err = ctrl.ProvidePeerCredentials(context.TODO(), "cred-fc")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, helper.calls, "cached credential not reused")
+	//a refused credential is requested again
+	fc, _ := ctrl.ForeignClusters().Get("cred-fc")
+	fc = fc.DeepCopy()
+	fc.Status.AuthStatus = discovery.AuthStatusRefused
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Update(fc))
+	_, err = ctrl.ProvidePeerCredentials(context.TODO(), "cred-fc")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, helper.calls, "refused credential reused")
+	GetCredentialCache().Forget("cred-fc")
+	helper.err = errors.New("sso unavailable")
+	_, err = ctrl.ProvidePeerCredentials(context.TODO(), "cred-fc")
+	assert.Error(t, err)
+	assert.Equal(t, 3, helper.calls)
+	//the expiring peering tokens are reported with the credentials, and can be refreshed
+	helper.err, helper.expiry = nil, time.Now().Add(48*time.Hour)
+	assert.NoError(t, ctrl.RefreshPeerCredential(context.TODO(), "cred-fc"))
+	assert.Equal(t, 4, helper.calls)
+	assert.Error(t, ctrl.RefreshPeerCredential(context.TODO(), "missing"))
+	credentials, err := ctrl.Credentials()
+	if assert.NoError(t, err) && assert.Len(t, credentials, 1) {
+		assert.Equal(t, CredentialPeerToken, credentials[0].Kind)
+		assert.Equal(t, "remote", credentials[0].User)
+		assert.True(t, credentials[0].ExpiresWithin(72*time.Hour))
+	}
+	GetCredentialCache().Clear()
+}
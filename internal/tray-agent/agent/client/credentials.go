@@ -0,0 +1,193 @@
+package client
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"strings"
+	"time"
+)
+
+//DefaultCredentialsWarningDays is the default number of days before the expiry of a credential when the user
+//starts being warned.
+const DefaultCredentialsWarningDays = 14
+
+//CredentialKind identifies the type of a credential used to access the cluster.
+type CredentialKind string
+
+const (
+	//CredentialClientCertificate is an x509 client certificate.
+	CredentialClientCertificate CredentialKind = "client certificate"
+	//CredentialToken is a bearer token in the JWT format.
+	CredentialToken CredentialKind = "token"
+	//CredentialAuthProvider is an access token cached by an authentication provider (e.g. OIDC).
+	CredentialAuthProvider CredentialKind = "auth-provider token"
+	//CredentialPeerToken is the auth token of a peer provided by a CredentialHelper and kept in the CredentialCache.
+	CredentialPeerToken CredentialKind = "peering token"
+)
+
+//CredentialInfo describes the expiry of a credential contained in the kubeconfig file, or of a peering token.
+type CredentialInfo struct {
+	//User is the name of the kubeconfig user the credential belongs to or, for a CredentialPeerToken, of the peer.
+	User string
+	Kind CredentialKind
+	//ClusterID is the ClusterID of the peer a CredentialPeerToken is used for.
+	ClusterID string
+	//Expiry is the instant after which the credential is no more valid.
+	Expiry time.Time
+	//Refreshable specifies whether the credential can be automatically renewed by the client.
+	Refreshable bool
+}
+
+//ExpiresWithin returns whether the credential is expired or expires within the duration d from now.
+func (ci *CredentialInfo) ExpiresWithin(d time.Duration) bool {
+	return time.Now().Add(d).After(ci.Expiry)
+}
+
+//Expired returns whether the credential is already expired.
+func (ci *CredentialInfo) Expired() bool {
+	return ci.ExpiresWithin(0)
+}
+
+//Credentials returns the expiring credentials used by the context of the kubeconfig file the AgentController is
+//connected with, followed by the expiring peering tokens kept in the CredentialCache.
+func (ctrl *AgentController) Credentials() ([]*CredentialInfo, error) {
+	credentials := make([]*CredentialInfo, 0)
+	if !ctrl.mocked {
+		if ctrl.kubeconfig == "" {
+			return nil, errors.New("no kubeconfig provided")
+		}
+		var err error
+		if credentials, err = InspectKubeconfig(ctrl.kubeconfig, ctrl.context); err != nil {
+			return nil, err
+		}
+	}
+	//the peers are named by their ClusterName, if known
+	names := make(map[string]string)
+	for _, fc := range ctrl.ForeignClusters().List() {
+		names[fc.Spec.ClusterIdentity.ClusterID] = fc.Spec.ClusterIdentity.ClusterName
+	}
+	for _, c := range GetCredentialCache().Expiring() {
+		if name := names[c.ClusterID]; name != "" {
+			c.User = name
+		}
+		credentials = append(credentials, c)
+	}
+	return credentials, nil
+}
+
+//RefreshCredentials performs a request to the API server, so that the refreshable credentials cached in the
+//kubeconfig file are renewed by the client.
+func (ctrl *AgentController) RefreshCredentials() error {
+	if ctrl.kube() == nil {
+		return newError(ErrNotConnected, "refresh credentials", nil)
+	}
+	_, err := ctrl.kube().Discovery().ServerVersion()
+	return ClassifyError("refresh credentials", err)
+}
+
+//InspectKubeconfig returns the expiring credentials used by a context (the current one if empty) of a kubeconfig
+//file. Credentials with no expiry (e.g. static tokens) are not included.
+func InspectKubeconfig(path string, context string) ([]*CredentialInfo, error) {
+	config, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = clientcmd.ResolveLocalPaths(config); err != nil {
+		return nil, err
+	}
+	if context == "" {
+		context = config.CurrentContext
+	}
+	ctx, present := config.Contexts[context]
+	if !present {
+		return nil, errors.New("context " + context + " not found in the kubeconfig file")
+	}
+	authInfo, present := config.AuthInfos[ctx.AuthInfo]
+	if !present {
+		return nil, errors.New("no user for the context " + context + " in the kubeconfig file")
+	}
+	return inspectAuthInfo(ctx.AuthInfo, authInfo)
+}
+
+//inspectAuthInfo returns the expiring credentials of a kubeconfig user.
+func inspectAuthInfo(user string, authInfo *clientcmdapi.AuthInfo) ([]*CredentialInfo, error) {
+	credentials := make([]*CredentialInfo, 0)
+	//client certificate
+	certData := authInfo.ClientCertificateData
+	if len(certData) == 0 && authInfo.ClientCertificate != "" {
+		var err error
+		if certData, err = ioutil.ReadFile(authInfo.ClientCertificate); err != nil {
+			return nil, err
+		}
+	}
+	if len(certData) > 0 {
+		expiry, err := certificateExpiry(certData)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, &CredentialInfo{User: user, Kind: CredentialClientCertificate, Expiry: expiry})
+	}
+	//bearer token
+	token := authInfo.Token
+	if token == "" && authInfo.TokenFile != "" {
+		if data, err := ioutil.ReadFile(authInfo.TokenFile); err == nil {
+			token = strings.TrimSpace(string(data))
+		}
+	}
+	if expiry, ok := tokenExpiry(token); ok {
+		credentials = append(credentials, &CredentialInfo{User: user, Kind: CredentialToken, Expiry: expiry})
+	}
+	//token cached by an authentication provider, renewed by the client using its refresh token
+	if ap := authInfo.AuthProvider; ap != nil {
+		expiry, ok := tokenExpiry(ap.Config["id-token"])
+		if !ok {
+			if t, err := time.Parse(time.RFC3339, ap.Config["expiry"]); err == nil {
+				expiry, ok = t, true
+			}
+		}
+		if ok {
+			credentials = append(credentials, &CredentialInfo{User: user, Kind: CredentialAuthProvider, Expiry: expiry,
+				Refreshable: ap.Config["refresh-token"] != "" || ap.Name == "gcp"})
+		}
+	}
+	return credentials, nil
+}
+
+//certificateExpiry returns the expiry of the first certificate contained in PEM encoded data.
+func certificateExpiry(data []byte) (time.Time, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return time.Time{}, errors.New("no valid client certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+//tokenExpiry returns the expiry contained in the 'exp' claim of a JWT token. If the token is not a JWT or
+//has no expiry, ok == false.
+func tokenExpiry(token string) (expiry time.Time, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err = json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+//testCertificate returns a PEM encoded self-signed certificate expiring at notAfter.
+func testCertificate(t *testing.T, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+//testToken returns an unsigned JWT token expiring at exp.
+func testToken(exp time.Time) string {
+	payload := `{"sub":"test","exp":` + strconv.FormatInt(exp.Unix(), 10) + `}`
+	return "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + "."
+}
+
+func TestInspectKubeconfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liqo-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certExpiry := time.Now().Add(5 * 24 * time.Hour).Truncate(time.Second).UTC()
+	tokenExp := time.Now().Add(time.Hour).Truncate(time.Second)
+	//the certificate file path is relative to the kubeconfig file
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "client.crt"), testCertificate(t, certExpiry), 0600))
+	config := clientcmdapi.NewConfig()
+	config.Clusters["cluster"] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:6443"}
+	config.AuthInfos["user"] = &clientcmdapi.AuthInfo{
+		ClientCertificate: "client.crt",
+		AuthProvider: &clientcmdapi.AuthProviderConfig{Name: "oidc", Config: map[string]string{
+			"id-token":      testToken(tokenExp),
+			"refresh-token": "refresh",
+		}},
+	}
+	config.AuthInfos["other"] = &clientcmdapi.AuthInfo{Token: testToken(time.Now())}
+	config.Contexts["ctx"] = &clientcmdapi.Context{Cluster: "cluster", AuthInfo: "user"}
+	config.CurrentContext = "ctx"
+	path := filepath.Join(dir, "config")
+	assert.NoError(t, clientcmd.WriteToFile(*config, path))
+
+	credentials, err := InspectKubeconfig(path, "")
+	assert.NoError(t, err, "kubeconfig inspection failed")
+	if assert.Equal(t, 2, len(credentials), "wrong number of expiring credentials") {
+		assert.Equal(t, CredentialClientCertificate, credentials[0].Kind)
+		assert.True(t, certExpiry.Equal(credentials[0].Expiry), "wrong certificate expiry")
+		assert.False(t, credentials[0].Refreshable)
+		assert.True(t, credentials[0].ExpiresWithin(7*24*time.Hour))
+		assert.False(t, credentials[0].Expired())
+		assert.Equal(t, CredentialAuthProvider, credentials[1].Kind)
+		assert.True(t, tokenExp.Equal(credentials[1].Expiry), "wrong token expiry")
+		assert.True(t, credentials[1].Refreshable)
+	}
+	//a context other than the current one describes its own user
+	config.Contexts["other"] = &clientcmdapi.Context{Cluster: "cluster", AuthInfo: "other"}
+	assert.NoError(t, clientcmd.WriteToFile(*config, path))
+	credentials, err = InspectKubeconfig(path, "other")
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(credentials), "wrong number of expiring credentials") {
+		assert.Equal(t, "other", credentials[0].User)
+		assert.Equal(t, CredentialToken, credentials[0].Kind)
+	}
+	_, err = InspectKubeconfig(path, "missing")
+	assert.Error(t, err, "missing context inspected")
+	_, ok := tokenExpiry("static-token")
+	assert.False(t, ok, "expiry found in a non JWT token")
+}
@@ -26,7 +26,7 @@ const (
 func (ctrl *AgentController) AcquireDashboardConfig() error {
 	//cleanup LiqoDash env vars
 	if !ctrl.Connected() || !ctrl.ValidConfiguration() {
-		return errors.New("cluster connection not available")
+		return newError(ErrNotConnected, "get LiqoDash configuration", nil)
 	}
 	var err error
 	if err = os.Unsetenv(EnvLiqoDashHost); err != nil {
@@ -38,12 +38,12 @@ func (ctrl *AgentController) AcquireDashboardConfig() error {
 	//preliminary check to verify the LiqoDash pod is running
 	var dashPodL *corev1.PodList
 	dashConf := ctrl.agentConf.dashboard
-	dashPodL, err = ctrl.kubeClient.CoreV1().Pods(dashConf.namespace).List(context.TODO(), metav1.ListOptions{
+	dashPodL, err = ctrl.kube().CoreV1().Pods(dashConf.namespace).List(context.TODO(), metav1.ListOptions{
 		LabelSelector: "app=" + dashConf.label,
 		FieldSelector: fields.OneTermEqualSelector("status.phase", "Running").String(),
 	})
 	if err != nil {
-		return errors.New("cluster connection not available")
+		return ClassifyError("list LiqoDash pods", err)
 	}
 	if len(dashPodL.Items) < 1 {
 		return errors.New("the LiqoDash is not currently available")
@@ -89,7 +89,7 @@ func (ctrl *AgentController) getDashboardConfigRemote() bool {
 	/*search for a LiqoDash Ingress. To increase security, it must contain
 	a 'tls' field with at least one explicitly specified 'host' (https connection)*/
 	dashConf := ctrl.agentConf.dashboard
-	ingrL, err := ctrl.kubeClient.NetworkingV1beta1().Ingresses(dashConf.namespace).List(context.TODO(), metav1.ListOptions{
+	ingrL, err := ctrl.kube().NetworkingV1beta1().Ingresses(dashConf.namespace).List(context.TODO(), metav1.ListOptions{
 		LabelSelector: "app=" + dashConf.label,
 	})
 	if err != nil || len(ingrL.Items) < 1 {
@@ -120,7 +120,7 @@ func (ctrl *AgentController) getDashboardConfigLocal() bool {
 	if !ctrl.Connected() || !ctrl.ValidConfiguration() {
 		return false
 	}
-	c := ctrl.kubeClient
+	c := ctrl.kube()
 	dashConf := ctrl.agentConf.dashboard
 	var nodePortNo, masterIP string
 	found := false
@@ -178,12 +178,12 @@ func (ctrl *AgentController) getDashboardConfigLocal() bool {
 func (ctrl *AgentController) GetLiqoDashSecret() (*string, error) {
 	var token = ""
 	if !ctrl.Connected() || !ctrl.ValidConfiguration() {
-		return &token, errors.New("no connection to the cluster")
+		return &token, newError(ErrNotConnected, "get LiqoDash token", nil)
 	}
 	errNoToken := errors.New("cannot retrieve token")
 	/*In order to better prune its search, the secret is retrieved by its name, using the
 	service account associated with it.*/
-	c := ctrl.kubeClient
+	c := ctrl.kube()
 	dashConf := ctrl.agentConf.dashboard
 	ServiceAccountsL, err := c.CoreV1().ServiceAccounts(dashConf.namespace).List(context.TODO(), metav1.ListOptions{
 		LabelSelector: "app=" + dashConf.label,
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"net"
+	"strings"
+)
+
+//Classes of the errors returned by the AgentController operations. Use errors.Is() to check the class of an error.
+var (
+	//ErrNotConnected signals that the cluster can not be reached, or that no connection has been configured.
+	ErrNotConnected = errors.New("not connected to the cluster")
+	//ErrForbidden signals that the credentials of the Agent are not authorized to perform the operation.
+	ErrForbidden = errors.New("operation not allowed")
+	//ErrCRDMissing signals that a resource required by the Agent is not installed in the cluster.
+	ErrCRDMissing = errors.New("required resource not installed")
+	//ErrVersionSkew signals that the cluster serves a version of the Liqo resources not supported by the Agent.
+	ErrVersionSkew = errors.New("unsupported Liqo version")
+	//ErrTimeout signals that the operation did not complete in time.
+	ErrTimeout = errors.New("operation timed out")
+)
+
+//Error is an error returned by an AgentController operation, belonging to one of the error classes.
+type Error struct {
+	//Class is the error class, e.g. ErrNotConnected.
+	Class error
+	//Op is the operation that failed.
+	Op string
+	//Err is the underlying error, if any.
+	Err error
+}
+
+//Error implements the error interface.
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.Op != "" {
+		b.WriteString(e.Op)
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Class.Error())
+	if e.Err != nil {
+		b.WriteString(": ")
+		b.WriteString(e.Err.Error())
+	}
+	return b.String()
+}
+
+//Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+//Is reports whether target is the class of the Error.
+func (e *Error) Is(target error) bool {
+	return target == e.Class
+}
+
+//newError returns an Error of the given class.
+func newError(class error, op string, err error) error {
+	return &Error{Class: class, Op: op, Err: err}
+}
+
+//ErrorClass returns the class of err, or nil if err does not belong to any class.
+func ErrorClass(err error) error {
+	for _, class := range []error{ErrNotConnected, ErrForbidden, ErrCRDMissing, ErrVersionSkew, ErrTimeout} {
+		if errors.Is(err, class) {
+			return class
+		}
+	}
+	return nil
+}
+
+//IsRetryable returns whether the operation failed with err may succeed if retried later.
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrNotConnected) || errors.Is(err, ErrTimeout)
+}
+
+//IsAuthenticationError returns whether err reports that the API server rejected the credentials of the Agent (e.g.
+//an expired certificate or token), differently from an authenticated request that is not allowed.
+func IsAuthenticationError(err error) bool {
+	var status apierrors.APIStatus
+	return errors.As(err, &status) && status.Status().Reason == metav1.StatusReasonUnauthorized
+}
+
+//ClassifyError wraps err, returned by the op operation, into an Error of the matching class. Errors already
+//classified, or not belonging to any class, are returned unchanged.
+func ClassifyError(op string, err error) error {
+	if err == nil || ErrorClass(err) != nil {
+		return err
+	}
+	var netErr net.Error
+	switch {
+	case apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err):
+		return newError(ErrForbidden, op, err)
+	case meta.IsNoMatchError(err) || isMissingResource(err):
+		return newError(ErrCRDMissing, op, err)
+	case apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err) || errors.Is(err, context.DeadlineExceeded):
+		return newError(ErrTimeout, op, err)
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return newError(ErrTimeout, op, err)
+		}
+		return newError(ErrNotConnected, op, err)
+	default:
+		return err
+	}
+}
+
+//isMissingResource returns whether err reports that the API server does not serve the requested resource
+//(differently from a missing object of a served resource).
+func isMissingResource(err error) bool {
+	var status apierrors.APIStatus
+	if !apierrors.IsNotFound(err) || !errors.As(err, &status) {
+		return false
+	}
+	details := status.Status().Details
+	return details == nil || details.Name == ""
+}
+
+//discoveryClient returns the discovery client of the cluster, or nil if no cluster client is available.
+func (ctrl *AgentController) discoveryClient() discovery.DiscoveryInterface {
+	if ctrl.kube() == nil {
+		return nil
+	}
+	return ctrl.kube().Discovery()
+}
+
+//classifyResourceError is like ClassifyError, but it additionally distinguishes a resource that is not installed
+//(ErrCRDMissing) from a resource served with versions not supported by the Agent (ErrVersionSkew), inspecting
+//the groups served by the API server.
+func classifyResourceError(disco discovery.DiscoveryInterface, gv schema.GroupVersion, op string, err error) error {
+	err = ClassifyError(op, err)
+	if !errors.Is(err, ErrCRDMissing) || disco == nil {
+		return err
+	}
+	groups, dErr := disco.ServerGroups()
+	if dErr != nil {
+		return err
+	}
+	for _, g := range groups.Groups {
+		if g.Name != gv.Group {
+			continue
+		}
+		for _, v := range g.Versions {
+			if v.Version == gv.Version {
+				return err
+			}
+		}
+		return newError(ErrVersionSkew, op, err)
+	}
+	return err
+}
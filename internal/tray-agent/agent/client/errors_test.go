@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"net"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	gr := schema.GroupResource{Group: "discovery.liqo.io", Resource: "foreignclusters"}
+	for name, tc := range map[string]struct {
+		err   error
+		class error
+	}{
+		"forbidden":        {apierrors.NewForbidden(gr, "fc1", errors.New("denied")), ErrForbidden},
+		"unauthorized":     {apierrors.NewUnauthorized("expired"), ErrForbidden},
+		"missing resource": {apierrors.NewNotFound(gr, ""), ErrCRDMissing},
+		"missing object":   {apierrors.NewNotFound(gr, "fc1"), nil},
+		"server timeout":   {apierrors.NewTimeoutError("slow", 1), ErrTimeout},
+		"deadline":         {fmt.Errorf("list: %w", context.DeadlineExceeded), ErrTimeout},
+		"refused":          {&net.OpError{Op: "dial", Err: errors.New("connection refused")}, ErrNotConnected},
+		"generic":          {errors.New("generic"), nil},
+	} {
+		err := ClassifyError("op", tc.err)
+		assert.Equal(t, tc.class, ErrorClass(err), "wrong class for the %s error", name)
+		assert.True(t, errors.Is(err, tc.err), "the %s error is not wrapped", name)
+	}
+	assert.Nil(t, ClassifyError("op", nil))
+	classified := newError(ErrTimeout, "op", nil)
+	assert.Equal(t, classified, ClassifyError("other", classified), "classified error wrapped twice")
+	assert.True(t, IsRetryable(fmt.Errorf("wrapped: %w", classified)))
+	assert.False(t, IsRetryable(newError(ErrForbidden, "op", nil)))
+	assert.Equal(t, "op: operation timed out", classified.Error())
+	assert.True(t, IsAuthenticationError(ClassifyError("op", apierrors.NewUnauthorized("expired"))))
+	assert.False(t, IsAuthenticationError(ClassifyError("op", apierrors.NewForbidden(gr, "fc1", errors.New("denied")))))
+	assert.False(t, IsAuthenticationError(errors.New("generic")))
+}
+
+func TestClassifyResourceError(t *testing.T) {
+	gv := schema.GroupVersion{Group: "discovery.liqo.io", Version: "v1alpha1"}
+	missing := apierrors.NewNotFound(gv.WithResource("foreignclusters").GroupResource(), "")
+	disco := fake.NewSimpleClientset().Discovery().(*fakediscovery.FakeDiscovery)
+	assert.True(t, errors.Is(classifyResourceError(disco, gv, "op", missing), ErrCRDMissing),
+		"group not served by the cluster")
+	disco.Resources = []*metav1.APIResourceList{{GroupVersion: "discovery.liqo.io/v1beta1"}}
+	assert.True(t, errors.Is(classifyResourceError(disco, gv, "op", missing), ErrVersionSkew),
+		"group served with a different version")
+	disco.Resources = []*metav1.APIResourceList{{GroupVersion: gv.String()}}
+	assert.True(t, errors.Is(classifyResourceError(disco, gv, "op", missing), ErrCRDMissing),
+		"group served with the same version")
+	assert.True(t, errors.Is(classifyResourceError(nil, gv, "op", missing), ErrCRDMissing))
+}
@@ -0,0 +1,181 @@
+package client
+
+import "sync"
+
+//notifyBuffLength is the default buffer length of a Subscription.
+const notifyBuffLength = 100
+
+//Topic identifies a kind of event published on the EventBus of an AgentController. Adding a new event only
+//requires a new Topic, since the EventBus creates the topics on first use.
+type Topic string
+
+//Topics of the events published by the AgentController. The payload of each event is specified next to its Topic.
+const (
+	//TopicPeerChanged signals a new, updated or removed peer (*NotifyDataForeignCluster, see its Deleted field).
+	//Additions, updates and removals share the same Topic so that a single Subscription receives them in order.
+	TopicPeerChanged Topic = "peerChanged"
+	//TopicClusterName transmits the current ClusterName of the Liqo cluster the Agent is connected to (string).
+	TopicClusterName Topic = "clusterName"
+	//TopicStorageChanged signals a change of the storage resources of the home cluster or of the pods using them
+	//(struct{}, coalesced).
+	TopicStorageChanged Topic = "storageChanged"
+	//TopicHealthChanged signals a change of the state of the Liqo control plane components (struct{}, coalesced).
+	TopicHealthChanged Topic = "healthChanged"
+	//TopicWorkloadsChanged signals a change of the pods offloaded to the peers (struct{}, coalesced).
+	TopicWorkloadsChanged Topic = "workloadsChanged"
+	//TopicOfferChanged signals a change of the resources offered by a peer (*NotifyDataOfferChanged).
+	TopicOfferChanged Topic = "offerChanged"
+	//TopicHeartbeat signals that the API server became unreachable or reachable again (*NotifyDataHeartbeat).
+	TopicHeartbeat Topic = "heartbeat"
+	//TopicNamespacesChanged signals a change of the namespaces of the home cluster or of the state of their
+	//offloading (struct{}, coalesced).
+	TopicNamespacesChanged Topic = "namespacesChanged"
+)
+
+//String returns the name of the Topic, e.g. "peerDeleted".
+func (t Topic) String() string {
+	return string(t)
+}
+
+//EventBus delivers the events published on a Topic to all its Subscriptions (fan-out).
+//The events published on a Topic before it is ever subscribed are retained and delivered to its first Subscription,
+//so that the events produced while the Agent starts up are not lost.
+type EventBus struct {
+	topics map[Topic]*topicState
+	mutex  sync.Mutex
+}
+
+//topicState contains the Subscriptions of a Topic.
+type topicState struct {
+	subscriptions []*Subscription
+	//subscribed specifies whether the Topic has ever been subscribed.
+	subscribed bool
+	//retained contains the events published before the first Subscription.
+	retained []NotifyDataGeneric
+}
+
+//Subscription is a buffered subscription to a Topic of an EventBus.
+type Subscription struct {
+	topic Topic
+	bus   *EventBus
+	ch    chan NotifyDataGeneric
+	//done is closed when the Subscription is cancelled, releasing the publishers waiting on a full buffer.
+	done chan struct{}
+	once sync.Once
+}
+
+//NewEventBus returns a new EventBus without Subscriptions.
+func NewEventBus() *EventBus {
+	return &EventBus{topics: make(map[Topic]*topicState)}
+}
+
+//state returns the topicState of a Topic, creating it if needed. The EventBus mutex must be held.
+func (b *EventBus) state(topic Topic) *topicState {
+	ts, present := b.topics[topic]
+	if !present {
+		ts = &topicState{}
+		b.topics[topic] = ts
+	}
+	return ts
+}
+
+//Subscribe returns a new Subscription to a Topic, buffering up to buffer events (notifyBuffLength if buffer is not
+//positive). The first Subscription of a Topic receives the events retained so far.
+func (b *EventBus) Subscribe(topic Topic, buffer int) *Subscription {
+	if buffer <= 0 {
+		buffer = notifyBuffLength
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	ts := b.state(topic)
+	if len(ts.retained) > buffer {
+		buffer = len(ts.retained)
+	}
+	s := &Subscription{topic: topic, bus: b, ch: make(chan NotifyDataGeneric, buffer), done: make(chan struct{})}
+	for _, data := range ts.retained {
+		s.ch <- data
+	}
+	ts.retained = nil
+	ts.subscribed = true
+	ts.subscriptions = append(ts.subscriptions, s)
+	return s
+}
+
+//receivers returns the current Subscriptions of a Topic. If the Topic has never been subscribed, data is retained
+//(retained == true) and no Subscription is returned.
+func (b *EventBus) receivers(topic Topic, data NotifyDataGeneric) (subscriptions []*Subscription, retained bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	ts := b.state(topic)
+	if !ts.subscribed {
+		ts.retained = append(ts.retained, data)
+		return nil, true
+	}
+	return append([]*Subscription(nil), ts.subscriptions...), false
+}
+
+//Publish delivers an event to all the Subscriptions of a Topic, waiting for the ones whose buffer is full.
+func (b *EventBus) Publish(topic Topic, data NotifyDataGeneric) {
+	subscriptions, _ := b.receivers(topic, data)
+	for _, s := range subscriptions {
+		select {
+		case s.ch <- data:
+		case <-s.done:
+		}
+	}
+}
+
+//TryPublish delivers an event to the Subscriptions of a Topic whose buffer is not full, without waiting.
+//It returns false if the event has been neither delivered nor retained.
+func (b *EventBus) TryPublish(topic Topic, data NotifyDataGeneric) bool {
+	subscriptions, delivered := b.receivers(topic, data)
+	for _, s := range subscriptions {
+		select {
+		case s.ch <- data:
+			delivered = true
+		default:
+		}
+	}
+	return delivered
+}
+
+//Subscribers returns the number of the current Subscriptions of a Topic.
+func (b *EventBus) Subscribers(topic Topic) int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if ts, present := b.topics[topic]; present {
+		return len(ts.subscriptions)
+	}
+	return 0
+}
+
+//Topic returns the Topic of the Subscription.
+func (s *Subscription) Topic() Topic {
+	return s.topic
+}
+
+//C returns the channel delivering the events of the Subscription.
+func (s *Subscription) C() <-chan NotifyDataGeneric {
+	return s.ch
+}
+
+//Len returns the number of the events waiting to be received.
+func (s *Subscription) Len() int {
+	return len(s.ch)
+}
+
+//Unsubscribe cancels the Subscription: no more events are delivered on it. It can be called more than once.
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() {
+		close(s.done)
+		s.bus.mutex.Lock()
+		defer s.bus.mutex.Unlock()
+		ts := s.bus.state(s.topic)
+		for idx, sub := range ts.subscriptions {
+			if sub == s {
+				ts.subscriptions = append(ts.subscriptions[:idx], ts.subscriptions[idx+1:]...)
+				break
+			}
+		}
+	})
+}
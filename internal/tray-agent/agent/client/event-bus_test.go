@@ -0,0 +1,51 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus()
+	const topic Topic = "testEvent"
+	//the events published before the first Subscription are retained, even beyond its buffer
+	bus.Publish(topic, 1)
+	bus.Publish(topic, 2)
+	first := bus.Subscribe(topic, 1)
+	assert.Equal(t, 2, first.Len(), "retained events not delivered")
+	assert.Equal(t, 1, <-first.C())
+	assert.Equal(t, 2, <-first.C())
+	//fan-out
+	second := bus.Subscribe(topic, 0)
+	assert.Equal(t, 0, second.Len(), "retained events delivered twice")
+	assert.Equal(t, 2, bus.Subscribers(topic))
+	bus.Publish(topic, 3)
+	assert.Equal(t, 3, <-first.C())
+	assert.Equal(t, 3, <-second.C())
+	//TryPublish skips the full Subscriptions
+	assert.True(t, bus.TryPublish(topic, 4))
+	assert.True(t, bus.TryPublish(topic, 5))
+	assert.True(t, bus.TryPublish(topic, 6), "event not delivered to the Subscription with free buffer")
+	assert.Equal(t, 2, first.Len())
+	assert.Equal(t, 3, second.Len())
+	first.Unsubscribe()
+	second.Unsubscribe()
+	second.Unsubscribe()
+	assert.Equal(t, 0, bus.Subscribers(topic))
+	assert.False(t, bus.TryPublish(topic, 7), "event retained after the Topic has been subscribed")
+	//a publisher blocked on a full buffer is released when the Subscription is cancelled
+	full := bus.Subscribe(topic, 1)
+	bus.Publish(topic, 8)
+	done := make(chan struct{})
+	go func() {
+		bus.Publish(topic, 9)
+		close(done)
+	}()
+	full.Unsubscribe()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publisher blocked on a cancelled Subscription")
+	}
+}
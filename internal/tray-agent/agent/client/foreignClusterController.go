@@ -7,19 +7,12 @@ import (
 )
 
 //createForeignClusterController creates a new CRDController for the Liqo ForeignCluster CRD.
-func createForeignClusterController(kubeconfig string) (*CRDController, error) {
-	controller := &CRDController{
-		addFunc:    foreignclusterAddFunc,
-		updateFunc: foreignclusterUpdateFunc,
-		deleteFunc: foreignclusterDeleteFunc,
-	}
+func createForeignClusterController(ctrl *AgentController, kubeconfig string) (*CRDController, error) {
 	newClient, err := discovery.CreateForeignClusterClient(kubeconfig)
 	if err != nil {
 		return nil, err
 	}
-	controller.CRDClient = newClient
-	controller.resource = string(CRForeignCluster)
-	return controller, nil
+	return newCRDController(newClient, CRForeignCluster, ctrl.foreignClusterEventHandler), nil
 }
 
 //NotifyDataForeignCluster is a NotifyDataGeneric sub-type used to exchange data concerning ForeignClusters events.
@@ -28,6 +21,8 @@ type NotifyDataForeignCluster struct {
 	Name        string
 	ClusterID   string
 	ClusterName string
+	//Deleted specifies whether the ForeignCluster has been removed.
+	Deleted bool
 	//LocalDiscovered identifies whether the peer has been discovered inside the home cluster LAN.
 	LocalDiscovered bool
 	//Trusted identifies whether the ForeignCluster has a valid certificate.
@@ -35,6 +30,8 @@ type NotifyDataForeignCluster struct {
 	//AuthStatus determines if the home cluster has been correctly authenticated on the foreign cluster.
 	//This property determines the possibility to perform an outgoing peering.
 	AuthStatus discovery2.AuthStatus
+	//Labels contains the labels of the ForeignCluster CR (e.g. its region), used to group the peers.
+	Labels map[string]string
 	//OutPeering contains information about the current status of the outgoing peering towards this foreign cluster.
 	OutPeering struct {
 		//Connected  determines whether the outgoing peering is established and running.
@@ -45,11 +42,23 @@ type NotifyDataForeignCluster struct {
 		//MemQuota is the literal representation of the CPU quota shared by the foreign cluster in the currently
 		//active outgoing peering.
 		MemQuota string
+		//VirtualNode is the name of the virtual node created for the currently active outgoing peering.
+		VirtualNode string
+		//Phase is the phase of the outgoing peering.
+		Phase PeeringPhase
 	}
 	//InPeering contains information about the current status of the incoming peering from this foreign cluster.
 	InPeering struct {
 		//Connected determines whether the incoming peering is established and running.
 		Connected bool
+		//CpuQuota is the literal representation of the CPU quota shared with the foreign cluster in the currently
+		//active incoming peering.
+		CpuQuota string
+		//MemQuota is the literal representation of the memory quota shared with the foreign cluster in the
+		//currently active incoming peering.
+		MemQuota string
+		//Phase is the phase of the incoming peering.
+		Phase PeeringPhase
 	}
 }
 
@@ -67,37 +76,42 @@ func (d *NotifyDataForeignCluster) loadPeerInfo(fc *discovery.ForeignCluster) {
 	}
 	d.Trusted = fc.Spec.TrustMode
 	d.AuthStatus = fc.Status.AuthStatus
+	d.Labels = fc.Labels
 }
 
-//loadPeeringInfo loads useful data about peerings established with a ForeignCluster.
-func (d *NotifyDataForeignCluster) loadPeeringInfo(fc *discovery.ForeignCluster) {
+//loadPeeringInfo loads useful data about peerings established with a ForeignCluster, looking up the shared
+//resources in the Advertisements cached by ctrl.
+func (d *NotifyDataForeignCluster) loadPeeringInfo(ctrl *AgentController, fc *discovery.ForeignCluster) {
 	//OUTGOING PEERING
+	d.OutPeering.Phase = outgoingPeeringPhase(fc)
 	if fc.Status.Outgoing.Joined && fc.Status.Outgoing.AdvertisementStatus == sharing.AdvertisementAccepted {
 		d.OutPeering.Connected = true
 		//try to recover details on shared resources
-		if advCtl := GetAgentController().Controller(CRAdvertisement); advCtl.Running() {
-			if obj, exist, err := advCtl.Store.GetByKey(fc.Status.Outgoing.Advertisement.Name); exist && err == nil {
-				if foreignAdv, ok := obj.(*sharing.Advertisement); ok {
-					quotas := foreignAdv.Spec.ResourceQuota.Hard
-					d.OutPeering.CpuQuota = quotas.Cpu().String()
-					d.OutPeering.MemQuota = quotas.Memory().String()
-				}
+		if ref := fc.Status.Outgoing.Advertisement; ref != nil {
+			if foreignAdv, exist := ctrl.Advertisements().Get(ref.Name); exist {
+				quotas := foreignAdv.Spec.ResourceQuota.Hard
+				d.OutPeering.CpuQuota = quotas.Cpu().String()
+				d.OutPeering.MemQuota = quotas.Memory().String()
 			}
 		}
 	}
 	//INCOMING PEERING
+	d.InPeering.Phase = incomingPeeringPhase(fc)
 	if fc.Status.Incoming.Joined && fc.Status.Incoming.AdvertisementStatus == sharing.AdvertisementAccepted {
 		d.InPeering.Connected = true
 	}
+	d.loadSharingInfo(ctrl)
 }
 
 //			**** EVENT FUNCTIONS ****
-//	The following functions are the callbacks the ForeignCluster Controller uses to handle the events
-//	of the correspondent cache.
 
-//foreignclusterAddFunc is the ADD event handler for the ForeignCluster CRDController.
-func foreignclusterAddFunc(obj interface{}) {
-	fc := obj.(*discovery.ForeignCluster)
+//foreignClusterEventHandler is the event handler for the ForeignCluster CRDController. It signals the new,
+//changed and removed peers.
+func (ctrl *AgentController) foreignClusterEventHandler(event CacheEvent) {
+	fc, ok := event.Object.(*discovery.ForeignCluster)
+	if !ok {
+		return
+	}
 	/*There are some cases when a just created ForeignCluster already contains information about a peering
 	(pending or accepted), e.g. for a FC discovered due to an incoming peering request or with a peering
 	established before the Agent start.*/
@@ -110,32 +124,12 @@ func foreignclusterAddFunc(obj interface{}) {
 	handle the case of a ForeignCluster created with no ClusterID and unable to correctly complete
 	the authn process (e.g. refused/emptyRefused status). This may happen especially when performing manual discovery.
 	*/
-	if fc.Spec.ClusterIdentity.ClusterID == "" {
-		return
-	}
-	data := &NotifyDataForeignCluster{}
-	data.loadPeerInfo(fc)
-	data.loadPeeringInfo(fc)
-	agentCtrl.NotifyChannel(ChanPeerAddedOrUpdated) <- data
-}
-
-//foreignclusterUpdateFunc is the UPDATE event handler for the ForeignCluster CRDController.
-func foreignclusterUpdateFunc(_ interface{}, newObj interface{}) {
-	fcNew := newObj.(*discovery.ForeignCluster)
-	if fcNew.Spec.ClusterIdentity.ClusterID == "" {
+	if event.Type != CacheDeleted && fc.Spec.ClusterIdentity.ClusterID == "" {
 		return
 	}
 	data := &NotifyDataForeignCluster{}
-	data.loadPeerInfo(fcNew)
-	data.loadPeeringInfo(fcNew)
-	agentCtrl.NotifyChannel(ChanPeerAddedOrUpdated) <- data
-}
-
-//foreignclusterDeleteFunc is the DELETE event handler for the ForeignCluster CRDController.
-func foreignclusterDeleteFunc(obj interface{}) {
-	fc := obj.(*discovery.ForeignCluster)
-	data := &NotifyDataForeignCluster{}
 	data.loadPeerInfo(fc)
-	data.loadPeeringInfo(fc)
-	agentCtrl.NotifyChannel(ChanPeerDeleted) <- data
+	data.loadPeeringInfo(ctrl, fc)
+	data.Deleted = event.Type == CacheDeleted
+	ctrl.events.Publish(TopicPeerChanged, data)
 }
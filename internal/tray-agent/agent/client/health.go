@@ -0,0 +1,178 @@
+package client
+
+import (
+	"errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	//reasonCrashLoop is the waiting reason of a container repeatedly failing at startup.
+	reasonCrashLoop = "CrashLoopBackOff"
+	//notReadyGracePeriod is the time a running pod can stay not Ready before being reported, so that the pods
+	//starting or briefly failing a readiness probe are not reported.
+	notReadyGracePeriod = time.Minute
+)
+
+//KeyLiqoComponents contains the names of the key components of the Liqo control plane, in order of importance.
+//The components whose name contains one of them are reported first.
+var KeyLiqoComponents = []string{"controller-manager", "network-manager", "gateway", "discovery"}
+
+//ComponentHealth contains the readiness of a component of the Liqo control plane.
+type ComponentHealth struct {
+	Name string `json:"name"`
+	//Kind is the kind of the workload running the component (Deployment or DaemonSet).
+	Kind string `json:"kind"`
+	//Desired is the number of replicas the component should run.
+	Desired int32 `json:"desired"`
+	//Ready is the number of ready replicas of the component.
+	Ready int32 `json:"ready"`
+	//Restarts is the overall number of restarts of the containers of the component.
+	Restarts int32 `json:"restarts"`
+	//CrashLooping contains the names of the pods of the component whose containers are crash-looping.
+	CrashLooping []string `json:"crashLooping,omitempty"`
+	//NotReady contains the names of the running pods of the component not Ready for more than a grace period.
+	NotReady []string `json:"notReady,omitempty"`
+	//Key specifies whether the component is one of the KeyLiqoComponents.
+	Key bool `json:"key"`
+}
+
+//Healthy returns whether all the replicas of the component are ready and none of them is crash-looping or not
+//Ready.
+func (ch *ComponentHealth) Healthy() bool {
+	return ch.Ready >= ch.Desired && len(ch.CrashLooping) == 0 && len(ch.NotReady) == 0
+}
+
+//keyRank returns the position of the component in KeyLiqoComponents, or len(KeyLiqoComponents) if it is not a key
+//component.
+func (ch *ComponentHealth) keyRank() int {
+	for rank, name := range KeyLiqoComponents {
+		if strings.Contains(ch.Name, name) {
+			return rank
+		}
+	}
+	return len(KeyLiqoComponents)
+}
+
+//HealthReport contains the readiness of the components of the Liqo control plane.
+type HealthReport struct {
+	//Namespace is the namespace the Liqo control plane is installed in.
+	Namespace string `json:"namespace"`
+	//Components contains the components of the Liqo control plane: the KeyLiqoComponents first, and then the
+	//others sorted by name.
+	Components []*ComponentHealth `json:"components"`
+}
+
+//Unhealthy returns the number of components not Healthy.
+func (hr *HealthReport) Unhealthy() int {
+	count := 0
+	for _, c := range hr.Components {
+		if !c.Healthy() {
+			count++
+		}
+	}
+	return count
+}
+
+//HealthReport returns the current HealthReport, built from the content of the AgentController caches.
+func (ctrl *AgentController) HealthReport() (*HealthReport, error) {
+	c := ctrl.coreCache
+	if c == nil || !c.running {
+		return nil, errors.New("the Liqo control plane is not watched")
+	}
+	c.consumeCoalesced(TopicHealthChanged)
+	deployments, err := c.liqoFactory.Apps().V1().Deployments().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	daemonSets, err := c.liqoFactory.Apps().V1().DaemonSets().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	pods, err := c.liqoFactory.Core().V1().Pods().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	report := newHealthReport(deployments, daemonSets, pods, time.Now())
+	report.Namespace = c.liqoNamespace
+	return report, nil
+}
+
+//newHealthReport builds a HealthReport from the workloads of the Liqo namespace and their pods, at time now.
+func newHealthReport(deployments []*appsv1.Deployment, daemonSets []*appsv1.DaemonSet,
+	pods []*corev1.Pod, now time.Time) *HealthReport {
+	report := &HealthReport{Components: make([]*ComponentHealth, 0, len(deployments)+len(daemonSets))}
+	for _, d := range deployments {
+		desired := int32(1)
+		if d.Spec.Replicas != nil {
+			desired = *d.Spec.Replicas
+		}
+		component := &ComponentHealth{Name: d.Name, Kind: "Deployment", Desired: desired,
+			Ready: d.Status.ReadyReplicas}
+		addPodsHealth(component, d.Spec.Selector, pods, now)
+		report.Components = append(report.Components, component)
+	}
+	for _, ds := range daemonSets {
+		component := &ComponentHealth{Name: ds.Name, Kind: "DaemonSet", Desired: ds.Status.DesiredNumberScheduled,
+			Ready: ds.Status.NumberReady}
+		addPodsHealth(component, ds.Spec.Selector, pods, now)
+		report.Components = append(report.Components, component)
+	}
+	for _, c := range report.Components {
+		c.Key = c.keyRank() < len(KeyLiqoComponents)
+	}
+	sort.Slice(report.Components, func(i, j int) bool {
+		ci, cj := report.Components[i], report.Components[j]
+		if ri, rj := ci.keyRank(), cj.keyRank(); ri != rj {
+			return ri < rj
+		}
+		return ci.Name < cj.Name
+	})
+	return report
+}
+
+//addPodsHealth adds to a ComponentHealth the restarts, crash-loops and readiness of the pods matching its selector.
+func addPodsHealth(component *ComponentHealth, selector *metav1.LabelSelector, pods []*corev1.Pod, now time.Time) {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil || s.Empty() {
+		return
+	}
+	for _, pod := range pods {
+		if !s.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		crashLooping := false
+		for _, cs := range pod.Status.ContainerStatuses {
+			component.Restarts += cs.RestartCount
+			if cs.State.Waiting != nil && cs.State.Waiting.Reason == reasonCrashLoop {
+				crashLooping = true
+			}
+		}
+		if crashLooping {
+			component.CrashLooping = append(component.CrashLooping, pod.Name)
+		} else if podNotReady(pod, now) {
+			component.NotReady = append(component.NotReady, pod.Name)
+		}
+	}
+	sort.Strings(component.CrashLooping)
+	sort.Strings(component.NotReady)
+}
+
+//podNotReady returns whether a running pod has not been Ready for more than notReadyGracePeriod.
+func podNotReady(pod *corev1.Pod, now time.Time) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status != corev1.ConditionTrue &&
+				now.Sub(condition.LastTransitionTime.Time) > notReadyGracePeriod
+		}
+	}
+	return false
+}
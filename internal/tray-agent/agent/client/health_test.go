@@ -0,0 +1,67 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func TestNewHealthReport(t *testing.T) {
+	selector := func(app string) *metav1.LabelSelector {
+		return &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}}
+	}
+	replicas := int32(1)
+	now := time.Now()
+	deployments := []*appsv1.Deployment{
+		{ObjectMeta: metav1.ObjectMeta{Name: "liqo-gateway"},
+			Spec:   appsv1.DeploymentSpec{Replicas: &replicas, Selector: selector("gateway")},
+			Status: appsv1.DeploymentStatus{ReadyReplicas: 0}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "liqo-controller-manager"},
+			Spec:   appsv1.DeploymentSpec{Replicas: &replicas, Selector: selector("controller-manager")},
+			Status: appsv1.DeploymentStatus{ReadyReplicas: 1}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "liqo-auth"},
+			Spec:   appsv1.DeploymentSpec{Replicas: &replicas, Selector: selector("auth")},
+			Status: appsv1.DeploymentStatus{ReadyReplicas: 1}},
+	}
+	daemonSets := []*appsv1.DaemonSet{
+		{ObjectMeta: metav1.ObjectMeta{Name: "liqo-route"}, Spec: appsv1.DaemonSetSpec{Selector: selector("route")},
+			Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 2, NumberReady: 2}},
+	}
+	pods := []*corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "liqo-gateway-1", Labels: map[string]string{"app": "gateway"}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{RestartCount: 5,
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reasonCrashLoop}}}}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "liqo-auth-1", Labels: map[string]string{"app": "auth"}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{RestartCount: 1}}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "liqo-controller-manager-1",
+			Labels: map[string]string{"app": "controller-manager"}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, Conditions: []corev1.PodCondition{{
+				Type: corev1.PodReady, Status: corev1.ConditionFalse, LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+			}}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "liqo-route-1", Labels: map[string]string{"app": "route"}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, Conditions: []corev1.PodCondition{{
+				Type: corev1.PodReady, Status: corev1.ConditionFalse, LastTransitionTime: metav1.NewTime(now),
+			}}}},
+	}
+	report := newHealthReport(deployments, daemonSets, pods, now)
+	if assert.Equal(t, 4, len(report.Components)) {
+		manager, gateway := report.Components[0], report.Components[1]
+		auth, route := report.Components[2], report.Components[3]
+		assert.Equal(t, "liqo-controller-manager", manager.Name, "key components are not first")
+		assert.True(t, manager.Key)
+		assert.False(t, manager.Healthy())
+		assert.Equal(t, []string{"liqo-controller-manager-1"}, manager.NotReady)
+		assert.Equal(t, "liqo-auth", auth.Name, "components are not sorted")
+		assert.False(t, auth.Key)
+		assert.True(t, auth.Healthy())
+		assert.Equal(t, int32(1), auth.Restarts)
+		assert.False(t, gateway.Healthy())
+		assert.Equal(t, []string{"liqo-gateway-1"}, gateway.CrashLooping)
+		assert.Equal(t, "DaemonSet", route.Kind)
+		assert.True(t, route.Healthy(), "pod reported within the not Ready grace period")
+	}
+	assert.Equal(t, 2, report.Unhealthy())
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/metrics"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sync"
+	"time"
+)
+
+const (
+	//DefaultHeartbeatInterval is the interval between two consecutive probes of the API server.
+	DefaultHeartbeatInterval = 5 * time.Second
+	//heartbeatTimeout is the time the API server has to reply to a probe.
+	heartbeatTimeout = 3 * time.Second
+	//readyzPath is the path of the readiness endpoint of the API server.
+	readyzPath = "/readyz"
+)
+
+//NotifyDataHeartbeat is a NotifyDataGeneric sub-type used to signal that the API server became unreachable
+//or reachable again.
+type NotifyDataHeartbeat struct {
+	Reachable bool
+	//Err is the failure of the probe, if the API server is not reachable.
+	Err error
+	//Since is the moment of the change.
+	Since time.Time
+}
+
+//heartbeatState contains the outcome of the last probe of the API server.
+type heartbeatState struct {
+	//probed specifies whether the API server has been probed at least once.
+	probed    bool
+	reachable bool
+	since     time.Time
+	sync.Mutex
+}
+
+//Probe performs a lightweight request (the readiness endpoint or, if not available, the server version) to check
+//whether the API server is reachable, independently of the state of the caches. A server that denies or does not
+//expose the readiness endpoint is considered reachable, since it replied.
+func (ctrl *AgentController) Probe(ctx context.Context) error {
+	if ctrl.kube() == nil {
+		return newError(ErrNotConnected, "heartbeat", nil)
+	}
+	disco := ctrl.kube().Discovery()
+	rc := disco.RESTClient()
+	if rc == nil {
+		_, err := disco.ServerVersion()
+		return ClassifyError("heartbeat", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, heartbeatTimeout)
+	defer cancel()
+	err := rc.Get().AbsPath(readyzPath).Timeout(heartbeatTimeout).Do(ctx).Error()
+	if apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err) || apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return newError(ErrTimeout, "heartbeat", err)
+	}
+	return ClassifyError("heartbeat", err)
+}
+
+//Heartbeat probes the API server and records its reachability. When it changes, a NotifyDataHeartbeat is published
+//on TopicHeartbeat. The first probe is signaled only if the API server is not reachable.
+func (ctrl *AgentController) Heartbeat(ctx context.Context) error {
+	start := time.Now()
+	err := ctrl.Probe(ctx)
+	reachable := err == nil
+	if reachable {
+		metrics.GetRegistry().Timer("probe_seconds", "Duration of the successful probes of the API server", nil).
+			ObserveSince(start)
+	}
+	h := &ctrl.heartbeat
+	h.Lock()
+	changed := h.probed && h.reachable != reachable || !h.probed && !reachable
+	h.probed = true
+	if changed || h.since.IsZero() {
+		h.reachable, h.since = reachable, time.Now()
+	}
+	since := h.since
+	h.Unlock()
+	if changed {
+		ctrl.events.Publish(TopicHeartbeat, &NotifyDataHeartbeat{Reachable: reachable, Err: err, Since: since})
+	}
+	return err
+}
+
+//Reachable returns whether the API server replied to the last probe. Before the first one, it returns
+//whether the AgentController is connected.
+func (ctrl *AgentController) Reachable() bool {
+	h := &ctrl.heartbeat
+	h.Lock()
+	defer h.Unlock()
+	if !h.probed {
+		return ctrl.connected
+	}
+	return h.reachable
+}
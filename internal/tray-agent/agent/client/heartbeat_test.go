@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHeartbeat(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	ch := ctrl.Events().Subscribe(TopicHeartbeat, 0).C()
+	assert.NoError(t, ctrl.Heartbeat(context.TODO()))
+	assert.True(t, ctrl.Reachable())
+	assert.Len(t, ch, 0, "reachable API server notified at first probe")
+	kubeClient := ctrl.kubeClient
+	ctrl.kubeClient = nil
+	err := ctrl.Heartbeat(context.TODO())
+	assert.True(t, errors.Is(err, ErrNotConnected))
+	assert.False(t, ctrl.Reachable())
+	if assert.Len(t, ch, 1, "unreachable API server not notified") {
+		hb := (<-ch).(*NotifyDataHeartbeat)
+		assert.False(t, hb.Reachable)
+		assert.Equal(t, err, hb.Err)
+	}
+	_ = ctrl.Heartbeat(context.TODO())
+	assert.Len(t, ch, 0, "unchanged reachability notified")
+	ctrl.kubeClient = kubeClient
+	assert.NoError(t, ctrl.Heartbeat(context.TODO()))
+	if assert.Len(t, ch, 1, "reachable API server not notified") {
+		assert.True(t, (<-ch).(*NotifyDataHeartbeat).Reachable)
+	}
+}
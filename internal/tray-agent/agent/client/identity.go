@@ -0,0 +1,360 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/xdg"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*This file contains the trust-on-first-use verification of the identity of the peers. The identity of a peer is
+made of its cluster ID and of the fingerprint of its CA certificate. When first peering with a cluster, the user can
+pin its identity in the TrustStore: any later change of the identity of a pinned peer (e.g. a different CA behind the
+same cluster ID) is then detected. The certificate is retrieved from different sources depending on the state of the
+peering: a fingerprint is only compared with the pinned one if they come from the same source.*/
+
+//TrustedPeersFileName is the basename of the file storing the pinned identities inside the Liqo Agent directory.
+const TrustedPeersFileName = "trusted_peers.yaml"
+
+//identityDialTimeout is the time limit for retrieving the certificate served by the authentication service of a peer.
+const identityDialTimeout = 5 * time.Second
+
+//IdentitySource identifies where the certificate of a PeerIdentity has been retrieved from.
+type IdentitySource string
+
+const (
+	//IdentitySourceKubeconfig is the CA certificate of the API server in the kubeconfig of the identity of the
+	//peering, available once a peering has been established.
+	IdentitySourceKubeconfig IdentitySource = "identity"
+	//IdentitySourceAuthService is the certificate served by the authentication service of the peer: the topmost
+	//CA of the served chain or, if the service serves no CA, its own certificate.
+	IdentitySourceAuthService IdentitySource = "authService"
+)
+
+//identitySources contains the IdentitySource values, in order of preference.
+var identitySources = []IdentitySource{IdentitySourceKubeconfig, IdentitySourceAuthService}
+
+//PeerIdentity is the identity of a peer.
+type PeerIdentity struct {
+	ClusterID   string `yaml:"clusterID"`
+	ClusterName string `yaml:"clusterName,omitempty"`
+	//CAFingerprint is the SHA-256 fingerprint of the CA certificate of the peer, e.g. "AB:CD:...". It is empty if
+	//the certificate could not be retrieved.
+	CAFingerprint string `yaml:"caFingerprint,omitempty"`
+	//Source is the IdentitySource of CAFingerprint. It is empty for the identities pinned before it was recorded.
+	Source IdentitySource `yaml:"source,omitempty"`
+	//PinnedAt is the instant the identity has been pinned, zero if it is not pinned.
+	PinnedAt time.Time `yaml:"pinnedAt,omitempty"`
+	//fingerprints contains the fingerprints retrieved from each available IdentitySource.
+	fingerprints map[IdentitySource]string
+}
+
+//From returns the PeerIdentity with the fingerprint retrieved from an IdentitySource, which is empty if not
+//available. An empty source returns the PeerIdentity unchanged.
+func (id PeerIdentity) From(source IdentitySource) PeerIdentity {
+	if source == "" || source == id.Source {
+		return id
+	}
+	id.CAFingerprint, id.Source = id.fingerprints[source], source
+	return id
+}
+
+//String returns a human readable description of the PeerIdentity.
+func (id PeerIdentity) String() string {
+	str := strings.Builder{}
+	str.WriteString("Cluster ID: " + id.ClusterID + "\n")
+	if id.ClusterName != "" {
+		str.WriteString("Cluster name: " + id.ClusterName + "\n")
+	}
+	fingerprint := id.CAFingerprint
+	if fingerprint == "" {
+		fingerprint = "unavailable"
+	}
+	str.WriteString("CA fingerprint (SHA-256): " + fingerprint)
+	switch id.Source {
+	case IdentitySourceKubeconfig:
+		str.WriteString("\nSource: API server CA of the peering identity")
+	case IdentitySourceAuthService:
+		str.WriteString("\nSource: certificate of the authentication service")
+	}
+	return str.String()
+}
+
+//IdentityVerdict is the outcome of the verification of a PeerIdentity against the pinned one.
+type IdentityVerdict int
+
+const (
+	//IdentityUnknown signals a peer whose identity has not been pinned.
+	IdentityUnknown IdentityVerdict = iota
+	//IdentityVerified signals a peer whose identity matches the pinned one.
+	IdentityVerified
+	//IdentityUnverifiable signals a pinned peer whose CA certificate could not be retrieved.
+	IdentityUnverifiable
+	//IdentityChanged signals a pinned peer whose identity is different from the pinned one.
+	IdentityChanged
+)
+
+//Fingerprint returns the SHA-256 fingerprint of a DER encoded certificate, e.g. "AB:CD:...".
+func Fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	parts := make([]string, len(sum))
+	for n, b := range sum {
+		parts[n] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+//pemFingerprint returns the Fingerprint of the last certificate of PEM encoded data (i.e. the root of a chain).
+func pemFingerprint(data []byte) (string, error) {
+	var der []byte
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "CERTIFICATE" {
+			der = block.Bytes
+		}
+	}
+	if der == nil {
+		return "", errors.New("no certificate found")
+	}
+	return Fingerprint(der), nil
+}
+
+//PeerIdentity returns the identity of the peer described by a ForeignCluster. Its CA certificate is taken from the
+//kubeconfig of the identity of the peering, if available, or from the certificates served by its authentication
+//service. The fingerprints of both sources are kept (see PeerIdentity.From), so that an identity pinned before the
+//peering can be verified afterwards. If no certificate can be retrieved, the returned PeerIdentity has no
+//CAFingerprint and err describes the failure.
+func (ctrl *AgentController) PeerIdentity(ctx context.Context, foreignCluster string) (PeerIdentity, error) {
+	fc, exists := ctrl.ForeignClusters().Get(foreignCluster)
+	if !exists {
+		return PeerIdentity{}, fmt.Errorf("peer identity: ForeignCluster %s not found", foreignCluster)
+	}
+	id := PeerIdentity{
+		ClusterID:    fc.Spec.ClusterIdentity.ClusterID,
+		ClusterName:  fc.Spec.ClusterIdentity.ClusterName,
+		fingerprints: make(map[IdentitySource]string),
+	}
+	var err error
+	for _, ref := range []*corev1.ObjectReference{fc.Status.Outgoing.IdentityRef, fc.Status.Incoming.IdentityRef} {
+		if ref == nil {
+			continue
+		}
+		var fingerprint string
+		if fingerprint, err = ctrl.identitySecretFingerprint(ctx, ref.Namespace, ref.Name); err == nil {
+			id.fingerprints[IdentitySourceKubeconfig] = fingerprint
+			break
+		}
+	}
+	if fc.Spec.AuthUrl != "" {
+		fingerprint, authErr := servedFingerprint(ctx, fc.Spec.AuthUrl)
+		if authErr == nil {
+			id.fingerprints[IdentitySourceAuthService] = fingerprint
+		} else if len(id.fingerprints) == 0 {
+			err = authErr
+		}
+	}
+	for _, source := range identitySources {
+		if fingerprint, present := id.fingerprints[source]; present {
+			id.CAFingerprint, id.Source = fingerprint, source
+			return id, nil
+		}
+	}
+	if err == nil {
+		err = errors.New("no identity or authentication service available")
+	}
+	return id, ClassifyError("peer identity", err)
+}
+
+//identitySecretFingerprint returns the Fingerprint of the CA certificate of the kubeconfig stored in an identity
+//secret.
+func (ctrl *AgentController) identitySecretFingerprint(ctx context.Context, namespace string, name string) (string,
+	error) {
+	secret, err := ctrl.kube().CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	kubeconfig, err := clientcmd.Load(secret.Data["kubeconfig"])
+	if err != nil {
+		return "", err
+	}
+	for _, cluster := range kubeconfig.Clusters {
+		if len(cluster.CertificateAuthorityData) > 0 {
+			return pemFingerprint(cluster.CertificateAuthorityData)
+		}
+	}
+	return "", errors.New("no CA certificate in the identity kubeconfig")
+}
+
+//servedFingerprint returns the Fingerprint of the topmost CA certificate of the chain served at a https URL or, if
+//no CA is served, of the leaf certificate, which changes whenever it is rotated. The chain is not verified: it is
+//the pinned Fingerprint that is trusted.
+func servedFingerprint(ctx context.Context, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+	dialer := &net.Dialer{Timeout: identityDialTimeout}
+	rawConn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return "", err
+	}
+	defer rawConn.Close()
+	//the certificates are only read, not trusted
+	conn := tls.Client(rawConn, &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: true})
+	_ = conn.SetDeadline(time.Now().Add(identityDialTimeout))
+	if err = conn.Handshake(); err != nil {
+		return "", err
+	}
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return "", errors.New("no certificate served")
+	}
+	cert := certs[0]
+	for _, c := range certs[1:] {
+		if c.IsCA {
+			cert = c
+		}
+	}
+	return Fingerprint(cert.Raw), nil
+}
+
+//trustedPeers is the content of the TrustedPeersFileName file.
+type trustedPeers struct {
+	Peers []PeerIdentity `yaml:"peers"`
+}
+
+//TrustStore persists the pinned identities of the peers on the local file system.
+type TrustStore struct {
+	//path is the path of the file storing the identities. If empty, they are kept in memory only.
+	path   string
+	pinned map[string]PeerIdentity
+	sync.RWMutex
+}
+
+//trustStore is the TrustStore singleton.
+var trustStore *TrustStore
+
+//trustStoreOnce protects the trustStore singleton initialization.
+var trustStoreOnce sync.Once
+
+//GetTrustStore returns the TrustStore singleton, persisted in the TrustedPeersFileName file inside the xdg.DirState
+//directory. If the directory is not available, the identities are kept in memory only.
+func GetTrustStore() *TrustStore {
+	trustStoreOnce.Do(func() {
+		trustStore = NewTrustStore(xdg.DirState.File(TrustedPeersFileName))
+	})
+	return trustStore
+}
+
+//NewTrustStore returns a TrustStore persisted in path, loading the identities previously pinned there (if any).
+//An unreadable file is ignored, starting with no pinned identity.
+func NewTrustStore(path string) *TrustStore {
+	s := &TrustStore{path: path, pinned: make(map[string]PeerIdentity)}
+	if path == "" {
+		return s
+	}
+	if data, err := ioutil.ReadFile(path); err == nil {
+		var content trustedPeers
+		if yaml.Unmarshal(data, &content) == nil {
+			for _, id := range content.Peers {
+				s.pinned[id.ClusterID] = id
+			}
+		}
+	}
+	return s
+}
+
+//Pinned returns the pinned identity of a peer, if any.
+func (s *TrustStore) Pinned(clusterID string) (PeerIdentity, bool) {
+	s.RLock()
+	defer s.RUnlock()
+	id, present := s.pinned[clusterID]
+	return id, present
+}
+
+//Verify compares the identity of a peer with the pinned one, which is returned as well. Only the fingerprint
+//retrieved from the IdentitySource of the pinned one is compared: if it is not available, the identity is
+//IdentityUnverifiable. The identities pinned with no source are verified against any available fingerprint.
+func (s *TrustStore) Verify(id PeerIdentity) (IdentityVerdict, PeerIdentity) {
+	pinned, present := s.Pinned(id.ClusterID)
+	if !present {
+		return IdentityUnknown, pinned
+	}
+	if pinned.Source == "" {
+		for _, fingerprint := range id.fingerprints {
+			if fingerprint == pinned.CAFingerprint {
+				return IdentityVerified, pinned
+			}
+		}
+	}
+	id = id.From(pinned.Source)
+	switch {
+	case id.CAFingerprint == "":
+		return IdentityUnverifiable, pinned
+	case id.CAFingerprint != pinned.CAFingerprint:
+		return IdentityChanged, pinned
+	default:
+		return IdentityVerified, pinned
+	}
+}
+
+//Pin records the identity of a peer as the trusted one, replacing the previous one, and saves it.
+func (s *TrustStore) Pin(id PeerIdentity) error {
+	if id.CAFingerprint == "" {
+		return errors.New("the identity of " + id.ClusterID + " has no CA fingerprint")
+	}
+	s.Lock()
+	defer s.Unlock()
+	id.PinnedAt = time.Now()
+	s.pinned[id.ClusterID] = id
+	return s.save()
+}
+
+//Unpin forgets the pinned identity of a peer and saves the change.
+func (s *TrustStore) Unpin(clusterID string) error {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.pinned, clusterID)
+	return s.save()
+}
+
+//save writes the pinned identities to the file of the TrustStore. It must be called with the lock held.
+func (s *TrustStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	content := trustedPeers{Peers: make([]PeerIdentity, 0, len(s.pinned))}
+	for _, id := range s.pinned {
+		content.Peers = append(content.Peers, id)
+	}
+	sort.Slice(content.Peers, func(i, j int) bool {
+		return content.Peers[i].ClusterID < content.Peers[j].ClusterID
+	})
+	data, err := yaml.Marshal(&content)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, data, 0600)
+}
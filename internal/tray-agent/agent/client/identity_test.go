@@ -0,0 +1,233 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	fingerprint := Fingerprint([]byte("test"))
+	assert.Len(t, strings.Split(fingerprint, ":"), 32)
+	assert.Equal(t, "9F:86:D0:81", fingerprint[:11])
+	//the fingerprint of a chain is the one of its root
+	leaf, root := testCertificate(t, time.Now().Add(time.Hour)), testCertificate(t, time.Now().Add(time.Hour))
+	block, _ := pem.Decode(root)
+	got, err := pemFingerprint(append(leaf, root...))
+	assert.NoError(t, err)
+	assert.Equal(t, Fingerprint(block.Bytes), got)
+	_, err = pemFingerprint([]byte("no certificate"))
+	assert.Error(t, err)
+}
+
+func TestTrustStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liqo-trust")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, TrustedPeersFileName)
+	s := NewTrustStore(path)
+	id := PeerIdentity{ClusterID: "c1", ClusterName: "one", CAFingerprint: "AA:BB"}
+	verdict, _ := s.Verify(id)
+	assert.Equal(t, IdentityUnknown, verdict)
+	assert.Error(t, s.Pin(PeerIdentity{ClusterID: "c2"}), "identity without fingerprint pinned")
+	assert.NoError(t, s.Pin(id))
+	//the pinned identities are persisted
+	s = NewTrustStore(path)
+	verdict, pinned := s.Verify(id)
+	assert.Equal(t, IdentityVerified, verdict)
+	assert.Equal(t, "one", pinned.ClusterName)
+	assert.False(t, pinned.PinnedAt.IsZero())
+	verdict, _ = s.Verify(PeerIdentity{ClusterID: "c1", CAFingerprint: "CC:DD"})
+	assert.Equal(t, IdentityChanged, verdict)
+	verdict, _ = s.Verify(PeerIdentity{ClusterID: "c1"})
+	assert.Equal(t, IdentityUnverifiable, verdict)
+	assert.NoError(t, s.Unpin("c1"))
+	verdict, _ = NewTrustStore(path).Verify(id)
+	assert.Equal(t, IdentityUnknown, verdict)
+}
+
+func TestPeerIdentity(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	_, err := ctrl.PeerIdentity(context.TODO(), "missing")
+	assert.Error(t, err)
+	//the CA is taken from the identity of the peering
+	ca := testCertificate(t, time.Now().Add(time.Hour))
+	config := clientcmdapi.NewConfig()
+	config.Clusters["remote"] = &clientcmdapi.Cluster{Server: "https://remote:6443", CertificateAuthorityData: ca}
+	kubeconfig, err := clientcmd.Write(*config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ctrl.kubeClient.CoreV1().Secrets("liqo").Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "remote-identity", Namespace: "liqo"},
+		Data:       map[string][]byte{"kubeconfig": kubeconfig},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	fc := &v1alpha1.ForeignCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "id-fc"},
+		Spec: v1alpha1.ForeignClusterSpec{
+			ClusterIdentity: v1alpha1.ClusterIdentity{ClusterID: "id-fc", ClusterName: "remote"},
+		},
+		Status: v1alpha1.ForeignClusterStatus{
+			Outgoing: v1alpha1.Outgoing{IdentityRef: &corev1.ObjectReference{Namespace: "liqo", Name: "remote-identity"}},
+		},
+	}
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Add(fc))
+	id, err := ctrl.PeerIdentity(context.TODO(), "id-fc")
+	assert.NoError(t, err)
+	block, _ := pem.Decode(ca)
+	assert.Equal(t, "id-fc", id.ClusterID)
+	assert.Equal(t, "remote", id.ClusterName)
+	assert.Equal(t, Fingerprint(block.Bytes), id.CAFingerprint)
+	assert.Equal(t, IdentitySourceKubeconfig, id.Source)
+	//without identity, the CA is taken from the certificate served by the authentication service
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	fc2 := fc.DeepCopy()
+	fc2.Name = "id-fc2"
+	fc2.Status = v1alpha1.ForeignClusterStatus{}
+	fc2.Spec.AuthUrl = server.URL
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Add(fc2))
+	id, err = ctrl.PeerIdentity(context.TODO(), "id-fc2")
+	assert.NoError(t, err)
+	assert.Equal(t, Fingerprint(server.Certificate().Raw), id.CAFingerprint)
+	assert.Equal(t, IdentitySourceAuthService, id.Source)
+	//the identity is returned also when the certificate is not available
+	fc2.Spec.AuthUrl = ""
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Update(fc2))
+	id, err = ctrl.PeerIdentity(context.TODO(), "id-fc2")
+	assert.Error(t, err)
+	assert.Equal(t, "id-fc", id.ClusterID)
+	assert.Empty(t, id.CAFingerprint)
+}
+
+//testCertificateChain returns a CA certificate and a leaf certificate signed by it for the 127.0.0.1 address.
+func testCertificateChain(t *testing.T) (ca *x509.Certificate, leaf tls.Certificate) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ca, err = x509.ParseCertificate(caDer); err != nil {
+		t.Fatal(err)
+	}
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "auth"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	leafDer, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca, tls.Certificate{Certificate: [][]byte{leafDer, caDer}, PrivateKey: leafKey}
+}
+
+//test that an identity pinned from the authentication service is still verified once the peering is established.
+func TestPeerIdentitySources(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	//the authentication service serves its certificate together with the CA
+	ca, leaf := testCertificateChain(t)
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{leaf}}
+	server.StartTLS()
+	defer server.Close()
+	fc := &v1alpha1.ForeignCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "src-fc"},
+		Spec: v1alpha1.ForeignClusterSpec{
+			ClusterIdentity: v1alpha1.ClusterIdentity{ClusterID: "src-fc", ClusterName: "remote"},
+			AuthUrl:         server.URL,
+		},
+	}
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Add(fc))
+	id, err := ctrl.PeerIdentity(context.TODO(), "src-fc")
+	assert.NoError(t, err)
+	assert.Equal(t, Fingerprint(ca.Raw), id.CAFingerprint, "the leaf certificate is fingerprinted")
+	assert.Equal(t, IdentitySourceAuthService, id.Source)
+	s := NewTrustStore("")
+	assert.NoError(t, s.Pin(id))
+	//once peered, the API server CA of the identity is preferred, but the pin is verified with the auth service
+	apiServerCA := testCertificate(t, time.Now().Add(time.Hour))
+	config := clientcmdapi.NewConfig()
+	config.Clusters["remote"] = &clientcmdapi.Cluster{Server: "https://remote:6443",
+		CertificateAuthorityData: apiServerCA}
+	kubeconfig, err := clientcmd.Write(*config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ctrl.kubeClient.CoreV1().Secrets("liqo").Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "src-identity", Namespace: "liqo"},
+		Data:       map[string][]byte{"kubeconfig": kubeconfig},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	fc = fc.DeepCopy()
+	fc.Status.Outgoing.IdentityRef = &corev1.ObjectReference{Namespace: "liqo", Name: "src-identity"}
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Update(fc))
+	id, err = ctrl.PeerIdentity(context.TODO(), "src-fc")
+	assert.NoError(t, err)
+	assert.Equal(t, IdentitySourceKubeconfig, id.Source)
+	verdict, pinned := s.Verify(id)
+	assert.Equal(t, IdentityVerified, verdict, "identity changed by the peering")
+	assert.Equal(t, Fingerprint(ca.Raw), id.From(pinned.Source).CAFingerprint)
+	//a different certificate from the same source is a change
+	pinned.CAFingerprint = "AA:BB"
+	assert.NoError(t, s.Pin(pinned))
+	verdict, _ = s.Verify(id)
+	assert.Equal(t, IdentityChanged, verdict)
+	//with the authentication service unreachable, the pinned identity cannot be verified
+	server.Close()
+	id, err = ctrl.PeerIdentity(context.TODO(), "src-fc")
+	assert.NoError(t, err, "identity of the peering not available")
+	verdict, _ = s.Verify(id)
+	assert.Equal(t, IdentityUnverifiable, verdict)
+	//the identities pinned with no source are verified with any fingerprint
+	block, _ := pem.Decode(apiServerCA)
+	assert.NoError(t, s.Pin(PeerIdentity{ClusterID: "src-fc", CAFingerprint: Fingerprint(block.Bytes)}))
+	verdict, _ = s.Verify(id)
+	assert.Equal(t, IdentityVerified, verdict)
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	discovery2 "github.com/liqotech/liqo/pkg/discovery"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net"
+	"strings"
+)
+
+/*This file contains the data a user hands to the administrator of another cluster to establish a peering: the
+cluster ID of the home cluster, the command creating on the other cluster the ForeignCluster pointing to the
+authentication service of the home cluster (manual discovery, see StartPeeringWith), and the kubeconfig the virtual
+node of a peer uses to reach it.*/
+
+const (
+	//clusterIDConfigMap is the name of the ConfigMap (and of its key) containing the cluster ID of the home cluster.
+	clusterIDConfigMap = "cluster-id"
+	//authServiceName is the name of the Service exposing the authentication service of the home cluster.
+	authServiceName = "liqo-auth"
+)
+
+//LocalClusterID returns the cluster ID of the home cluster, stored by Liqo in a ConfigMap of its namespace.
+func (ctrl *AgentController) LocalClusterID(ctx context.Context) (string, error) {
+	if ctrl.kube() == nil {
+		return "", newError(ErrNotConnected, "get local cluster ID", nil)
+	}
+	conf, _ := GetLocalConfig()
+	cm, err := ctrl.kube().CoreV1().ConfigMaps(conf.GetLiqoNamespace()).Get(ctx, clusterIDConfigMap,
+		metav1.GetOptions{})
+	if err != nil {
+		return "", ClassifyError("get local cluster ID", err)
+	}
+	id := cm.Data[clusterIDConfigMap]
+	if id == "" {
+		return "", errors.New("get local cluster ID: the cluster ID has not been generated yet")
+	}
+	return id, nil
+}
+
+//HomeAuthURL returns the URL the peers reach the authentication service of the home cluster at. As Liqo does, the
+//address and port set in the discovery configuration of the ClusterConfig are preferred; otherwise, they are taken
+//from the authentication Service, exposed either as LoadBalancer or as NodePort.
+func (ctrl *AgentController) HomeAuthURL(ctx context.Context) (string, error) {
+	if ctrl.kube() == nil {
+		return "", newError(ErrNotConnected, "get authentication service", nil)
+	}
+	var address, port string
+	if config, err := ctrl.cachedClusterConfig(); err == nil {
+		address = config.Spec.DiscoveryConfig.AuthServiceAddress
+		port = config.Spec.DiscoveryConfig.AuthServicePort
+	}
+	if address == "" || port == "" {
+		conf, _ := GetLocalConfig()
+		svc, err := ctrl.kube().CoreV1().Services(conf.GetLiqoNamespace()).Get(ctx, authServiceName,
+			metav1.GetOptions{})
+		if err != nil {
+			return "", ClassifyError("get authentication service", err)
+		}
+		svcAddress, svcPort, err := ctrl.authServiceEndpoint(ctx, svc)
+		if err != nil {
+			return "", fmt.Errorf("get authentication service: %w", err)
+		}
+		if address == "" {
+			address = svcAddress
+		}
+		if port == "" {
+			port = svcPort
+		}
+	}
+	return "https://" + net.JoinHostPort(address, port), nil
+}
+
+//authServiceEndpoint returns the address and port the authentication Service is reachable at from outside the
+//cluster.
+func (ctrl *AgentController) authServiceEndpoint(ctx context.Context, svc *corev1.Service) (string, string, error) {
+	if len(svc.Spec.Ports) == 0 {
+		return "", "", errors.New("the service exposes no port")
+	}
+	switch svc.Spec.Type {
+	case corev1.ServiceTypeLoadBalancer:
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if ingress.Hostname != "" {
+				return ingress.Hostname, fmt.Sprint(svc.Spec.Ports[0].Port), nil
+			}
+			if ingress.IP != "" {
+				return ingress.IP, fmt.Sprint(svc.Spec.Ports[0].Port), nil
+			}
+		}
+		return "", "", errors.New("no external address assigned to the LoadBalancer service")
+	case corev1.ServiceTypeNodePort:
+		nodes, err := ctrl.kube().CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return "", "", err
+		}
+		address, err := discovery2.GetAddressFromNodeList(nodes.Items)
+		if err != nil {
+			return "", "", err
+		}
+		return address, fmt.Sprint(svc.Spec.Ports[0].NodePort), nil
+	default:
+		return "", "", fmt.Errorf("a %s service is not reachable from the peers: set the address of the "+
+			"authentication service in the ClusterConfig", svc.Spec.Type)
+	}
+}
+
+//JoinCommand returns the shell command that, run against another cluster, creates the ForeignCluster starting a
+//peering with the home cluster.
+func (ctrl *AgentController) JoinCommand(ctx context.Context) (string, error) {
+	authURL, err := ctrl.HomeAuthURL(ctx)
+	if err != nil {
+		return "", err
+	}
+	manifest := []string{
+		"apiVersion: discovery.liqo.io/v1alpha1",
+		"kind: ForeignCluster",
+		"metadata:",
+		"  name: " + manualPeerName(authURL),
+		"  labels:",
+		fmt.Sprintf("    %s: %s", discovery2.DiscoveryTypeLabel, discovery2.ManualDiscovery),
+		"spec:",
+		"  join: true",
+		fmt.Sprintf("  discoveryType: %s", discovery2.ManualDiscovery),
+		"  authUrl: " + authURL,
+	}
+	return "cat <<EOF | kubectl apply -f -\n" + strings.Join(manifest, "\n") + "\nEOF\n", nil
+}
+
+//VirtualNodeKubeconfig returns the kubeconfig the virtual node of a peer uses to reach it, stored in the identity
+//of the outgoing peering.
+func (ctrl *AgentController) VirtualNodeKubeconfig(ctx context.Context, clusterID string) ([]byte, error) {
+	if ctrl.kube() == nil {
+		return nil, newError(ErrNotConnected, "get virtual node kubeconfig", nil)
+	}
+	for _, fc := range ctrl.ForeignClusters().List() {
+		if fc.Spec.ClusterIdentity.ClusterID != clusterID {
+			continue
+		}
+		ref := fc.Status.Outgoing.IdentityRef
+		if ref == nil {
+			return nil, fmt.Errorf("get virtual node kubeconfig: no outgoing peering with %s",
+				fc.Spec.ClusterIdentity.ClusterName)
+		}
+		secret, err := ctrl.kube().CoreV1().Secrets(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, ClassifyError("get virtual node kubeconfig", err)
+		}
+		kubeconfig := secret.Data["kubeconfig"]
+		if len(kubeconfig) == 0 {
+			return nil, errors.New("get virtual node kubeconfig: no kubeconfig in the identity of the peering")
+		}
+		return kubeconfig, nil
+	}
+	return nil, fmt.Errorf("get virtual node kubeconfig: peer %s not found", clusterID)
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	clusterConfig "github.com/liqotech/liqo/apis/config/v1alpha1"
+	"github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestLocalClusterID(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	ctx := context.TODO()
+	_, err := ctrl.LocalClusterID(ctx)
+	assert.Error(t, err, "cluster ID read without ConfigMap")
+	_, err = ctrl.kubeClient.CoreV1().ConfigMaps(DefaultLiqoNamespace).Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterIDConfigMap, Namespace: DefaultLiqoNamespace},
+		Data:       map[string]string{clusterIDConfigMap: "home-id"},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	id, err := ctrl.LocalClusterID(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "home-id", id)
+}
+
+func TestJoinCommand(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	ctx := context.TODO()
+	_, err := ctrl.JoinCommand(ctx)
+	assert.Error(t, err, "join command without authentication service")
+	//NodePort service: the address of a node is used
+	_, err = ctrl.kubeClient.CoreV1().Services(DefaultLiqoNamespace).Create(ctx, &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: authServiceName, Namespace: DefaultLiqoNamespace},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeNodePort,
+			Ports: []corev1.ServicePort{{Port: 443, NodePort: 30443}},
+		},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	_, err = ctrl.kubeClient.CoreV1().Nodes().Create(ctx, &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+			{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+		}},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	authURL, err := ctrl.HomeAuthURL(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://10.0.0.1:30443", authURL)
+	cmd, err := ctrl.JoinCommand(ctx)
+	assert.NoError(t, err)
+	assert.Contains(t, cmd, "kubectl apply -f -")
+	assert.Contains(t, cmd, "name: manual-10-0-0-1-30443")
+	assert.Contains(t, cmd, "authUrl: https://10.0.0.1:30443")
+	//the address set in the ClusterConfig is preferred
+	config := &clusterConfig.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: "join-config"}}
+	config.Spec.DiscoveryConfig.AuthServiceAddress = "auth.example.com"
+	config.Spec.DiscoveryConfig.AuthServicePort = "443"
+	assert.NoError(t, ctrl.Controller(CRClusterConfig).Store.Add(config))
+	authURL, err = ctrl.HomeAuthURL(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://auth.example.com:443", authURL)
+}
+
+func TestVirtualNodeKubeconfig(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	ctx := context.TODO()
+	_, err := ctrl.VirtualNodeKubeconfig(ctx, "kc-peer")
+	assert.Error(t, err, "kubeconfig of a missing peer")
+	fc := &v1alpha1.ForeignCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kc-peer"},
+		Spec: v1alpha1.ForeignClusterSpec{
+			ClusterIdentity: v1alpha1.ClusterIdentity{ClusterID: "kc-peer", ClusterName: "remote"},
+		},
+	}
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Add(fc))
+	_, err = ctrl.VirtualNodeKubeconfig(ctx, "kc-peer")
+	assert.Error(t, err, "kubeconfig without outgoing peering")
+	fc = fc.DeepCopy()
+	fc.Status.Outgoing.IdentityRef = &corev1.ObjectReference{Namespace: DefaultLiqoNamespace, Name: "kc-identity"}
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Update(fc))
+	_, err = ctrl.kubeClient.CoreV1().Secrets(DefaultLiqoNamespace).Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "kc-identity", Namespace: DefaultLiqoNamespace},
+		Data:       map[string][]byte{"kubeconfig": []byte("apiVersion: v1\nkind: Config\n")},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	kubeconfig, err := ctrl.VirtualNodeKubeconfig(ctx, "kc-peer")
+	assert.NoError(t, err)
+	assert.Equal(t, "apiVersion: v1\nkind: Config\n", string(kubeconfig))
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"github.com/fsnotify/fsnotify"
+	"io/ioutil"
+	"k8s.io/client-go/tools/clientcmd"
+	"path/filepath"
+	"time"
+)
+
+/*This file contains the watch of the kubeconfig file of the main cluster, so that a rotated certificate or a new
+token written by an external tool (e.g. a cloud CLI or an exec credential plugin caching its credentials) are
+picked up without restarting the Agent. Besides the kubeconfig file, the files it references for the active context
+(client certificate and key, token file, certificate authority) are watched as well. As for the config file, their
+directories are watched, so that the files replaced by a rename are detected.*/
+
+//DefaultKubeconfigWatchDelay is the time waited after the last change of the kubeconfig files before reporting
+//it, so that a burst of writes (e.g. a certificate and its key) is reported once.
+const DefaultKubeconfigWatchDelay = time.Second
+
+//kubeconfigFiles returns the kubeconfig file and the files it references for a context (the current one if empty):
+//the credentials of its user and the certificate authority of its cluster.
+func kubeconfigFiles(kubeconfig string, context string) []string {
+	files := []string{filepath.Clean(kubeconfig)}
+	config, err := clientcmd.LoadFromFile(kubeconfig)
+	if err != nil || clientcmd.ResolveLocalPaths(config) != nil {
+		return files
+	}
+	if context == "" {
+		context = config.CurrentContext
+	}
+	ctx, present := config.Contexts[context]
+	if !present {
+		return files
+	}
+	if authInfo, present := config.AuthInfos[ctx.AuthInfo]; present {
+		files = appendFiles(files, authInfo.ClientCertificate, authInfo.ClientKey, authInfo.TokenFile)
+	}
+	if cluster, present := config.Clusters[ctx.Cluster]; present {
+		files = appendFiles(files, cluster.CertificateAuthority)
+	}
+	return files
+}
+
+//appendFiles appends to files the non empty paths.
+func appendFiles(files []string, paths ...string) []string {
+	for _, p := range paths {
+		if p != "" {
+			files = append(files, filepath.Clean(p))
+		}
+	}
+	return files
+}
+
+//filesDigest returns a digest of the content of a set of files. The missing files do not contribute to it.
+func filesDigest(files []string) [sha256.Size]byte {
+	h := sha256.New()
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		_, _ = h.Write([]byte(f))
+		_, _ = h.Write(data)
+	}
+	var digest [sha256.Size]byte
+	copy(digest[:], h.Sum(nil))
+	return digest
+}
+
+//WatchKubeconfig watches the kubeconfig file of the AgentController, and the files it references for the active
+//context, until ctx is done. onChange is called, from a goroutine started by WatchKubeconfig, when their content
+//changes; onError when the watch fails. The files are the ones of the kubeconfig file in use when the watch
+//starts: after a switch to another file, the watch has to be started again.
+func (ctrl *AgentController) WatchKubeconfig(ctx context.Context, delay time.Duration, onChange func(),
+	onError func(error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	kubeconfig, kubeContext := ctrl.kubeconfig, ctrl.context
+	//watched contains the watched files, and dirs their directories
+	watched := make(map[string]bool)
+	dirs := make(map[string]bool)
+	watch := func(files []string) {
+		for _, f := range files {
+			watched[f] = true
+			if dir := filepath.Dir(f); !dirs[dir] {
+				if err := watcher.Add(dir); err != nil {
+					logger.Debug("cannot watch a kubeconfig directory", "dir", dir, "err", err)
+					continue
+				}
+				dirs[dir] = true
+			}
+		}
+	}
+	//the directory of the kubeconfig file is required
+	kubeDir := filepath.Dir(filepath.Clean(kubeconfig))
+	if err := watcher.Add(kubeDir); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	dirs[kubeDir] = true
+	files := kubeconfigFiles(kubeconfig, kubeContext)
+	watch(files)
+	last := filesDigest(files)
+	go func() {
+		defer watcher.Close()
+		//the timer of the pending check, started on the first change of the files
+		check := time.NewTimer(delay)
+		check.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				check.Stop()
+				return
+			case event, open := <-watcher.Events:
+				if !open {
+					return
+				}
+				if !watched[filepath.Clean(event.Name)] || event.Op == fsnotify.Chmod {
+					continue
+				}
+				check.Stop()
+				select {
+				case <-check.C:
+				default:
+				}
+				check.Reset(delay)
+			case err, open := <-watcher.Errors:
+				if !open {
+					return
+				}
+				if onError != nil {
+					onError(err)
+				}
+			case <-check.C:
+				//the referenced files may have changed as well
+				files := kubeconfigFiles(kubeconfig, kubeContext)
+				watch(files)
+				if digest := filesDigest(files); digest != last {
+					last = digest
+					if onChange != nil {
+						onChange()
+					}
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+//ReloadKubeconfig rebuilds the clients and the caches of the AgentController from its kubeconfig file, e.g. after
+//its credentials have been rotated. The caches list again the resources from the cluster. On failure, the
+//AgentController is left disconnected (see Reconnect).
+func (ctrl *AgentController) ReloadKubeconfig() error {
+	ctrl.disconnect()
+	if err := ctrl.connectCluster(); err != nil {
+		ctrl.disconnect()
+		return ClassifyError("reload kubeconfig", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchKubeconfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liqo-kubeconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certDir := filepath.Join(dir, "certs")
+	assert.NoError(t, os.MkdirAll(certDir, 0755))
+	cert := filepath.Join(certDir, "client.crt")
+	assert.NoError(t, ioutil.WriteFile(cert, []byte("cert-1"), 0600))
+	config := clientcmdapi.NewConfig()
+	config.Clusters["one"] = &clientcmdapi.Cluster{Server: "https://one:6443"}
+	config.AuthInfos["user"] = &clientcmdapi.AuthInfo{ClientCertificate: "certs/client.crt"}
+	config.Contexts["one"] = &clientcmdapi.Context{Cluster: "one", AuthInfo: "user"}
+	config.CurrentContext = "one"
+	kubeconfig := filepath.Join(dir, "config")
+	assert.NoError(t, clientcmd.WriteToFile(*config, kubeconfig))
+	assert.Equal(t, []string{kubeconfig, cert}, kubeconfigFiles(kubeconfig, ""))
+	ctrl := newAgentController(kubeconfig, "")
+	changes := make(chan struct{}, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, ctrl.WatchKubeconfig(ctx, 10*time.Millisecond, func() {
+		changes <- struct{}{}
+	}, nil))
+	changed := func() bool {
+		select {
+		case <-changes:
+			return true
+		case <-time.After(time.Second):
+			return false
+		}
+	}
+	//the rotation of a referenced certificate is detected
+	assert.NoError(t, ioutil.WriteFile(cert, []byte("cert-2"), 0600))
+	assert.True(t, changed(), "certificate rotation not detected")
+	//a write with the same content is not a change
+	assert.NoError(t, ioutil.WriteFile(cert, []byte("cert-2"), 0600))
+	assert.False(t, changed(), "unchanged certificate reported")
+	//a kubeconfig file replaced by a rename is detected
+	config.AuthInfos["user"].Token = "token"
+	assert.NoError(t, clientcmd.WriteToFile(*config, kubeconfig+".new"))
+	assert.NoError(t, os.Rename(kubeconfig+".new", kubeconfig))
+	assert.True(t, changed(), "kubeconfig replacement not detected")
+	//the other files are ignored
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "other"), []byte("other"), 0600))
+	assert.False(t, changed(), "unrelated file reported")
+}
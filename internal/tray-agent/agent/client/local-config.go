@@ -2,11 +2,13 @@ package client
 
 import (
 	"errors"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/xdg"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 )
 
 //ConfigFileName is the basename of the Agent configuration file.
@@ -15,18 +17,292 @@ const ConfigFileName = "agent_conf.yaml"
 //fileConfig contains Liqo Agent configuration parameters acquired from the cluster.
 var fileConfig = &LocalConfiguration{}
 
+//DefaultLiqoNamespace is the default namespace the Liqo control plane is installed in.
+const DefaultLiqoNamespace = "liqo"
+
+//DefaultListPageSize is the default number of entries of the long lists of the menu displayed at once.
+const DefaultListPageSize = 10
+
+//DefaultLocalAPIAddress is the default listening address of the Liqo Agent local API.
+const DefaultLocalAPIAddress = "127.0.0.1:6446"
+
 //LocalConfig maps the information of a Liqo Agent configuration file, containing persistent settings data.
 type LocalConfig struct {
+	//Version is the version of the config file format (see CurrentConfigVersion).
+	Version int `yaml:"version,omitempty"`
 	//Kubeconfig contains the path of the kubeconfig file.
 	Kubeconfig string `yaml:"kubeconfig,omitempty"`
+	//Clusters contains the additional clusters the Agent connects to, besides the one of Kubeconfig.
+	Clusters []ClusterConfig `yaml:"clusters,omitempty"`
+	//CredentialHelpers contains the commands providing the authentication material required to peer with some
+	//clusters (see ExecCredentialHelper).
+	CredentialHelpers []CredentialHelperConfig `yaml:"credentialHelpers,omitempty"`
+	//LocalAPI contains the settings of the Liqo Agent local API.
+	LocalAPI *LocalAPIConfig `yaml:"localApi,omitempty"`
+	//RemoteWrite contains the settings of the push of the Agent metrics to a Prometheus remote-write endpoint.
+	RemoteWrite *RemoteWriteConfig `yaml:"remoteWrite,omitempty"`
+	//Tracing contains the settings of the export of the Agent traces to an OpenTelemetry collector.
+	Tracing *TracingConfig `yaml:"tracing,omitempty"`
+	//CredentialsWarningDays is the number of days before the expiry of the cluster credentials when the user
+	//starts being warned. It defaults to DefaultCredentialsWarningDays.
+	CredentialsWarningDays int `yaml:"credentialsWarningDays,omitempty"`
+	//LiqoNamespace is the namespace the Liqo control plane is installed in. It defaults to DefaultLiqoNamespace.
+	LiqoNamespace string `yaml:"liqoNamespace,omitempty"`
+	//ChartRepository is the helm repository checked for new versions of the Liqo chart.
+	//It defaults to DefaultChartRepository.
+	ChartRepository string `yaml:"chartRepository,omitempty"`
+	//SelfUpdate contains the settings of the check for new versions of the Agent.
+	SelfUpdate *SelfUpdateConfig `yaml:"selfUpdate,omitempty"`
+	//Redaction contains the settings of the redaction layer scrubbing sensitive data from the Agent outputs.
+	Redaction *RedactionConfig `yaml:"redaction,omitempty"`
+	//IconTheme is the name of the theme used to draw the tray icon (e.g. "default" or "accessible").
+	IconTheme string `yaml:"iconTheme,omitempty"`
+	//ColorScheme selects the variant of the tray icons for light or dark panels: "auto" (the default) follows the
+	//color scheme preferred by the desktop, while "light" and "dark" override it.
+	ColorScheme string `yaml:"colorScheme,omitempty"`
+	//IconBadge specifies whether the number of active peerings is drawn as a badge on the tray icon, e.g. when the
+	//desktop does not display the label next to it.
+	IconBadge bool `yaml:"iconBadge,omitempty"`
+	//Language is the language of the menu and of the notifications (e.g. "it"). If empty, it is detected from the
+	//environment.
+	Language string `yaml:"language,omitempty"`
+	//DisableIconAnimations specifies whether the changes of the tray icon are displayed without animations.
+	DisableIconAnimations bool `yaml:"disableIconAnimations,omitempty"`
+	//GuiBackend is the name of the graphic backend used to display the tray icon and its menu (e.g. "sni"). If
+	//empty, the first available one is used.
+	GuiBackend string `yaml:"guiBackend,omitempty"`
+	//QuietHours contains the recurring intervals during which only critical notifications are displayed.
+	QuietHours []QuietHoursRule `yaml:"quietHours,omitempty"`
+	//DoNotDisturb contains the settings of the Do Not Disturb mode.
+	DoNotDisturb *DoNotDisturbConfig `yaml:"doNotDisturb,omitempty"`
+	//NotifyWhileLocked specifies whether the banners are displayed also while the desktop session is locked,
+	//instead of being delivered as a digest when it is unlocked.
+	NotifyWhileLocked bool `yaml:"notifyWhileLocked,omitempty"`
+	//Terminal is the command launching the terminal emulator, followed by the flag introducing the command to run
+	//(e.g. "alacritty -e"). If empty, a known terminal emulator is searched.
+	Terminal string `yaml:"terminal,omitempty"`
+	//LoginCommand is the command signing in again to the cluster when its credentials expired (e.g. "aws sso login").
+	//If empty, the command is derived from the credential plugin of the kubeconfig file (see LoginMethod).
+	LoginCommand string `yaml:"loginCommand,omitempty"`
+	//Menu contains the customized layout of the tray menu.
+	Menu *MenuLayoutConfig `yaml:"menu,omitempty"`
+	//ListPageSize is the number of entries of the long lists of the menu (e.g. the peers) displayed at once, the
+	//others being displayed by pages. It defaults to DefaultListPageSize.
+	ListPageSize int `yaml:"listPageSize,omitempty"`
+	//Peers contains the preferences of the user about the peers (e.g. their aliases), by ClusterID.
+	Peers map[string]PeerPreferences `yaml:"peers,omitempty"`
+	//Hotkeys contains the global keyboard shortcuts of the Agent.
+	Hotkeys *HotkeysConfig `yaml:"hotkeys,omitempty"`
+	//Backoff contains the parameters of the backoff applied to reconnections, cache restarts and retries.
+	//The unset ones default to the DefaultBackoffPolicy ones.
+	Backoff *BackoffPolicy `yaml:"backoff,omitempty"`
+	//Polling contains the parameters of the polling intervals of the resources the user is not allowed to watch.
+	//The unset ones default to the DefaultPollingPolicy ones.
+	Polling *BackoffPolicy `yaml:"polling,omitempty"`
+	//CaptivePortalProbeURL is the URL probed to detect a captive portal, replying with '204 No Content'.
+	//It defaults to DefaultCaptivePortalProbeURL.
+	CaptivePortalProbeURL string `yaml:"captivePortalProbeUrl,omitempty"`
+	//StartupSplash specifies whether a window displays the progress of the Agent startup.
+	StartupSplash bool `yaml:"startupSplash,omitempty"`
+	//NotifyLevel is the notification level ("off", "icon" or "banner") used until the user selects one.
+	NotifyLevel string `yaml:"notifyLevel,omitempty"`
+	//Intervals contains the periods of the checks performed by the Agent. The unset ones keep their default value.
+	Intervals *IntervalsConfig `yaml:"intervals,omitempty"`
+	//Branding contains the customizations of the Agent appearance.
+	Branding *BrandingConfig `yaml:"branding,omitempty"`
+	//LabelMode is the content of the tray label: "counts" (the active peerings, default) or "trend" (a sparkline
+	//of the CPU acquired from the peers over the last hour).
+	LabelMode string `yaml:"labelMode,omitempty"`
+	//LabelFormat is a custom format of the tray label, overriding LabelMode: the name of a preset ("counts",
+	//"trend", "compact" or "cluster") or a format string with the {{in}}, {{out}}, {{cpuAcquired}}, {{cpuTrend}}
+	//and {{clusterAlias}} placeholders.
+	LabelFormat string `yaml:"labelFormat,omitempty"`
+	//LabelAlways specifies whether the tray label with a custom format is displayed also when no peering is active.
+	LabelAlways bool `yaml:"labelAlways,omitempty"`
+	//PeerGroupLabel is the ForeignCluster label (e.g. "topology.kubernetes.io/region") the peers list is grouped by.
+	//If empty, the peers are listed without grouping.
+	PeerGroupLabel string `yaml:"peerGroupLabel,omitempty"`
+	//OperationTimeouts contains the time limits of the operations started from the tray menu, by operation name
+	//(e.g. "peering"), and the "default" one for the others. The unset ones keep their default value.
+	OperationTimeouts map[string]time.Duration `yaml:"operationTimeouts,omitempty"`
+	//ListenerDebounce contains the debouncing of the listeners of the cluster events, by Topic (e.g.
+	//"healthChanged"). The unset ones keep their default value.
+	ListenerDebounce map[string]DebounceConfig `yaml:"listenerDebounce,omitempty"`
+	//ReadOnly specifies whether the write actions of the Agent (e.g. peerings and installation changes) are
+	//disabled. If set by the organization-wide defaults, it cannot be disabled locally.
+	ReadOnly bool `yaml:"readOnly,omitempty"`
+	//DebugLogging specifies whether the debug entries are written to the Agent logs.
+	DebugLogging bool `yaml:"debugLogging,omitempty"`
+	//OrgDefaults contains the location of the organization-wide defaults in the cluster.
+	OrgDefaults *OrgDefaultsConfig `yaml:"orgDefaults,omitempty"`
+	//StartupActions contains the actions performed automatically when the Agent starts, in order.
+	StartupActions []StartupActionConfig `yaml:"startupActions,omitempty"`
+}
+
+//DebounceConfig contains the debouncing of a listener of the cluster events: its callback is executed at most once
+//per Interval, and the events received in the meantime are coalesced.
+type DebounceConfig struct {
+	//Interval is the minimum interval between two executions of the callback. Zero disables the debouncing.
+	Interval time.Duration `yaml:"interval"`
+	//Trailing specifies whether the last of the coalesced events is handled at the end of the Interval (the
+	//default) or discarded.
+	Trailing *bool `yaml:"trailing,omitempty"`
+}
+
+//IntervalsConfig contains the periods of the checks performed by the Agent.
+type IntervalsConfig struct {
+	//Heartbeat is the period of the probes of the API server.
+	Heartbeat time.Duration `yaml:"heartbeat,omitempty"`
+	//Capacity is the refresh period of the capacity overview.
+	Capacity time.Duration `yaml:"capacity,omitempty"`
+	//Credentials is the period of the check of the credentials expiry.
+	Credentials time.Duration `yaml:"credentials,omitempty"`
+	//Upgrade is the period of the check for new Liqo versions.
+	Upgrade time.Duration `yaml:"upgrade,omitempty"`
+	//AgentUpdate is the period of the check for new Agent versions.
+	AgentUpdate time.Duration `yaml:"agentUpdate,omitempty"`
+	//Latency is the period of the measurement of the latency towards the peers with an active peering.
+	Latency time.Duration `yaml:"latency,omitempty"`
+	//Tunnel is the period of the check of the network connectivity towards the peers with an active peering.
+	Tunnel time.Duration `yaml:"tunnel,omitempty"`
+	//Refresh is the minimum interval between two refreshes of the status and of the tray label: the changes
+	//occurring in the meantime are displayed together.
+	Refresh time.Duration `yaml:"refresh,omitempty"`
+}
+
+//BrandingConfig contains the customizations of the Agent appearance, e.g. set by an organization.
+type BrandingConfig struct {
+	//Title is the header displayed at the top of the tray menu.
+	Title string `yaml:"title,omitempty"`
+	//HelpURL is the page opened by the "Help" menu entry. It defaults to the Liqo documentation.
+	HelpURL string `yaml:"helpUrl,omitempty"`
+}
+
+//MenuLayoutConfig contains the layout of the sections of the tray menu, identified by their names.
+//The sections not listed in Order keep their default position after the listed ones.
+type MenuLayoutConfig struct {
+	//Order contains the sections in the order they are displayed.
+	Order []string `yaml:"order,omitempty"`
+	//Pinned contains the sections displayed at the top of the menu.
+	Pinned []string `yaml:"pinned,omitempty"`
+	//Hidden contains the sections not displayed.
+	Hidden []string `yaml:"hidden,omitempty"`
+}
+
+//PeerPreferences contains the preferences of the user about a peer.
+type PeerPreferences struct {
+	//Alias is the friendly name displayed in place of the ClusterName of the peer, if set.
+	Alias string `yaml:"alias,omitempty"`
+	//Favorite specifies whether the peer is pinned at the top of the peers list.
+	Favorite bool `yaml:"favorite,omitempty"`
+}
+
+//HotkeysConfig contains the key combinations (e.g. "CTRL+ALT+L") of the global keyboard shortcuts of the Agent.
+//The shortcuts with an empty key combination are not bound.
+type HotkeysConfig struct {
+	//OpenMenu is the shortcut displaying the tray menu or, if the menu cannot be opened, the Status window.
+	OpenMenu string `yaml:"openMenu,omitempty"`
+	//Toggle is the shortcut turning Liqo on and off.
+	Toggle string `yaml:"toggle,omitempty"`
+	//Refresh is the shortcut refreshing the peers, the contexts and the status.
+	Refresh string `yaml:"refresh,omitempty"`
+}
+
+//DoNotDisturbConfig contains the settings of the Do Not Disturb mode, which silences the notifications as during the
+//quiet hours until disabled.
+type DoNotDisturbConfig struct {
+	//Enabled specifies whether the Do Not Disturb mode is enabled.
+	Enabled bool `yaml:"enabled,omitempty"`
+	//Threshold is the minimum severity ("info", "warning" or "error") of the notifications displayed as banners in
+	//Do Not Disturb mode and during the quiet hours. It defaults to "error".
+	Threshold string `yaml:"threshold,omitempty"`
+}
+
+//QuietHoursRule is a recurring interval of quiet hours, e.g. from 22:00 to 08:00, or the whole weekend.
+type QuietHoursRule struct {
+	//From is the start time (HH:MM) of the interval. The interval spans midnight if From is later than To.
+	From string `yaml:"from,omitempty"`
+	//To is the end time (HH:MM) of the interval. If From and To are equal (or empty), the rule lasts the whole day.
+	To string `yaml:"to,omitempty"`
+	//Days contains the days (mon, tue, ...) the interval starts on. If empty, the rule applies every day.
+	Days []string `yaml:"days,omitempty"`
+}
+
+//RedactionConfig contains the settings of the redaction layer. Tokens, certificates and keys are always redacted.
+type RedactionConfig struct {
+	//KeepServerURLs specifies whether the server URLs are kept in the Agent outputs.
+	KeepServerURLs bool `yaml:"keepServerUrls,omitempty"`
+	//Patterns contains additional regular expressions whose matches are redacted.
+	Patterns []string `yaml:"patterns,omitempty"`
+}
+
+//LocalAPIConfig contains the settings of the local HTTP API exposed by Liqo Agent.
+type LocalAPIConfig struct {
+	//Enabled specifies whether the local API is started.
+	Enabled bool `yaml:"enabled"`
+	//Address is the listening address of the local API. It defaults to DefaultLocalAPIAddress.
+	Address string `yaml:"address,omitempty"`
+	//AllowedOrigins contains the browser origins (e.g. the LiqoDash address) allowed to open the event stream,
+	//in addition to the local ones.
+	AllowedOrigins []string `yaml:"allowedOrigins,omitempty"`
+	//Metrics specifies whether the internal metrics of the Agent are exported, in the Prometheus text format, by
+	//the /metrics endpoint of the local API.
+	Metrics bool `yaml:"metrics,omitempty"`
+}
+
+//RemoteWriteConfig contains the settings of the push of the Agent metrics to a Prometheus remote-write endpoint.
+type RemoteWriteConfig struct {
+	//URL is the address of the remote-write endpoint. If empty, the metrics are not pushed.
+	URL string `yaml:"url"`
+	//Username and Password are the credentials of the basic authentication, if Username is set.
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	//PasswordFile is the path of the file containing the password, read instead of Password if set.
+	PasswordFile string `yaml:"passwordFile,omitempty"`
+	//Interval is the interval between two pushes. It defaults to metrics.DefaultRemoteWriteInterval.
+	Interval time.Duration `yaml:"interval,omitempty"`
+	//Labels are added to all the pushed series, e.g. {"instance": "jump-host"}.
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+//SelfUpdateConfig contains the settings of the check for new versions of the Agent.
+type SelfUpdateConfig struct {
+	//Disabled specifies whether the check is disabled.
+	Disabled bool `yaml:"disabled,omitempty"`
+	//Install specifies whether the new versions can be downloaded and installed from the tray menu. Otherwise, the
+	//menu opens the page of the release.
+	Install bool `yaml:"install,omitempty"`
+	//ReleasesURL is the URL of the GitHub API returning the latest release of the Agent, e.g. of a mirror. It
+	//defaults to DefaultAgentReleasesURL.
+	ReleasesURL string `yaml:"releasesUrl,omitempty"`
+}
+
+//TracingConfig contains the settings of the export of the Agent traces to an OpenTelemetry collector.
+type TracingConfig struct {
+	//Endpoint is the URL of the OTLP/HTTP collector (e.g. "http://localhost:4318"), to which the traces path is
+	//appended if missing. If empty, the OTEL_EXPORTER_OTLP_TRACES_ENDPOINT and OTEL_EXPORTER_OTLP_ENDPOINT env
+	//variables are used, and the traces are not exported if they are not set.
+	Endpoint string `yaml:"endpoint,omitempty"`
+	//Headers are added to the export requests, e.g. the authentication ones.
+	Headers map[string]string `yaml:"headers,omitempty"`
+	//Interval is the interval between two exports. It defaults to tracing.DefaultExportInterval.
+	Interval time.Duration `yaml:"interval,omitempty"`
 }
 
 //LocalConfiguration stores the LocalConfig configuration acquired from a local config file and a validity flag.
+//The settings not provided by the file are completed with the organization-wide defaults, if any (see OrgDefaults).
 type LocalConfiguration struct {
-	//Content maps the content of the config file.
+	//Content maps the effective configuration: the content of the config file, completed with the
+	//organization-wide defaults.
 	Content *LocalConfig
+	//local maps the content of the config file, which is written by SaveLocalConfig.
+	local *LocalConfig
+	//org contains the organization-wide defaults, if any.
+	org *LocalConfig
 	//Valid specifies whether LocalConfiguration contains a valid Content to read.
 	Valid bool
+	//err contains the problems of the config file found by the last load, if any.
+	err error
 	sync.RWMutex
 }
 
@@ -34,54 +310,157 @@ type LocalConfiguration struct {
 func NewLocalConfig() *LocalConfiguration {
 	fileConfig.Lock()
 	defer fileConfig.Unlock()
-	fileConfig.Content = &LocalConfig{}
+	fileConfig.local = &LocalConfig{Version: CurrentConfigVersion}
+	fileConfig.err = nil
+	fileConfig.refresh()
 	return fileConfig
 }
 
 //LoadLocalConfig loads configuration data from a config file ConfigFileName on the local filesystem
-//(if present and valid). The config file structure is mapped on the LocalConfig type.
-func LoadLocalConfig() {
+//(if present and valid). The config file structure is mapped on the LocalConfig type: a file written by an older
+//version of the Agent is migrated, and the invalid settings are dropped (see LocalConfig.Validate). The problems
+//found are returned, and kept until the next load (see LocalConfiguration.Err).
+func LoadLocalConfig() error {
 	lc := NewLocalConfig()
-	liqoDir, present := os.LookupEnv(EnvLiqoPath)
-	if !present {
-		return
+	liqoDir := xdg.DirConfig.Path()
+	if liqoDir == "" {
+		return nil
 	}
-	filePath := filepath.Join(liqoDir, ConfigFileName)
-	yamlFile, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return
+	config, err := readLocalConfig(liqoDir)
+	if os.IsNotExist(err) {
+		return nil
 	}
 	lc.Lock()
 	defer lc.Unlock()
-	err = yaml.Unmarshal(yamlFile, lc.Content)
-	if err != nil {
-		return
+	lc.err = err
+	if config == nil {
+		lc.Valid = false
+		return err
 	}
+	lc.local = config
+	lc.refresh()
 	lc.Valid = true
+	return err
+}
+
+//ReloadLocalConfig reads again the ConfigFileName config file, replacing the current configuration, and returns
+//whether its content changed (e.g. it is not the one just written by SaveLocalConfig). A missing file is read as an
+//empty configuration. A file that cannot be decoded is not applied, while the problems found by the validation are
+//returned together with the applied configuration.
+func ReloadLocalConfig() (changed bool, err error) {
+	liqoDir := xdg.DirConfig.Path()
+	if liqoDir == "" {
+		return false, errors.New("the config directory is not available")
+	}
+	reloaded, err := readLocalConfig(liqoDir)
+	if os.IsNotExist(err) {
+		reloaded, err = &LocalConfig{Version: CurrentConfigVersion}, nil
+	}
+	if reloaded == nil {
+		return false, err
+	}
+	fileConfig.Lock()
+	defer fileConfig.Unlock()
+	fileConfig.err = err
+	current, marshalErr := yaml.Marshal(fileConfig.local)
+	if marshalErr != nil {
+		return false, marshalErr
+	}
+	next, marshalErr := yaml.Marshal(reloaded)
+	if marshalErr != nil {
+		return false, marshalErr
+	}
+	fileConfig.Valid = true
+	if string(current) == string(next) {
+		return false, err
+	}
+	fileConfig.local = reloaded
+	fileConfig.refresh()
+	return true, err
+}
+
+//ReadLocalConfig reads the ConfigFileName config file in liqoDir into a new LocalConfiguration, independent of the
+//one returned by GetLocalConfig. A missing file is read as an empty configuration. A file that cannot be read or
+//decoded returns a nil LocalConfiguration, while the problems found by the validation are returned together with
+//the valid settings.
+func ReadLocalConfig(liqoDir string) (*LocalConfiguration, error) {
+	config, err := readLocalConfig(liqoDir)
+	if os.IsNotExist(err) {
+		config, err = &LocalConfig{Version: CurrentConfigVersion}, nil
+	}
+	if config == nil {
+		return nil, err
+	}
+	lc := &LocalConfiguration{local: config, Valid: true, err: err}
+	lc.refresh()
+	return lc, err
+}
+
+//readLocalConfig reads the ConfigFileName config file in liqoDir, migrating and validating it. A file that cannot
+//be read or decoded returns a nil configuration, while the problems found by the validation (see
+//LocalConfig.Validate) are returned together with the valid settings.
+func readLocalConfig(liqoDir string) (*LocalConfig, error) {
+	data, err := ioutil.ReadFile(filepath.Join(liqoDir, ConfigFileName))
+	if err != nil {
+		return nil, err
+	}
+	config, version, err := decodeLocalConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	problems := config.Validate()
+	if version < CurrentConfigVersion {
+		if err := persistMigration(liqoDir, data, version, config); err != nil {
+			logger.Warning("cannot save the migrated configuration file", "err", err)
+		}
+	}
+	return config, problems
+}
+
+//ResetLocalConfig removes the ConfigFileName config file, restoring the default configuration (completed with the
+//organization-wide defaults, if any).
+func ResetLocalConfig() error {
+	NewLocalConfig()
+	filePath := xdg.DirConfig.File(ConfigFileName)
+	if filePath == "" {
+		return nil
+	}
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
 }
 
 //SaveLocalConfig saves the configuration data in the internal LocalConfiguration to a
-//config file on the local file system named after ConfigFileName.
+//config file on the local file system named after ConfigFileName, inside the xdg.DirConfig directory.
 func SaveLocalConfig() error {
-	liqoDir, present := os.LookupEnv(EnvLiqoPath)
-	if !present {
-		return errors.New("envLiqoPath not set")
+	liqoDir, err := xdg.DirConfig.Ensure()
+	if err != nil {
+		return err
 	}
 	fileConfig.RLock()
 	defer fileConfig.RUnlock()
-	if _, err := os.Stat(liqoDir); err != nil {
-		return err
-	}
-	if fileConfig.Content == nil {
+	if fileConfig.local == nil {
 		return errors.New("trying to save nil configuration")
 	}
-	data, err := yaml.Marshal(fileConfig.Content)
+	data, err := yaml.Marshal(fileConfig.local)
 	if err != nil {
 		return err
 	}
 	return ioutil.WriteFile(filepath.Join(liqoDir, ConfigFileName), data, 0644)
 }
 
+//MarshalLocalConfig returns the YAML encoding of the effective configuration, i.e. the content of the config file
+//merged with the organization defaults.
+func MarshalLocalConfig() ([]byte, error) {
+	fileConfig.RLock()
+	defer fileConfig.RUnlock()
+	if fileConfig.Content == nil {
+		return yaml.Marshal(&LocalConfig{})
+	}
+	return yaml.Marshal(fileConfig.Content)
+}
+
 //GetLocalConfig returns configuration data acquired from a config file on the local file system.
 func GetLocalConfig() (config *LocalConfiguration, valid bool) {
 	fileConfig.RLock()
@@ -92,6 +471,34 @@ func GetLocalConfig() (config *LocalConfiguration, valid bool) {
 	return fileConfig, fileConfig.Valid
 }
 
+//Err returns the problems of the config file found by the last load, if any: a *ValidationError if some settings
+//have been dropped, or the failure to read or decode the file.
+func (lc *LocalConfiguration) Err() error {
+	lc.RLock()
+	defer lc.RUnlock()
+	return lc.err
+}
+
+//refresh recomputes the effective configuration after a change of the local one or of the organization defaults.
+//It must be called with the lock held.
+func (lc *LocalConfiguration) refresh() {
+	if lc.local == nil {
+		lc.local = &LocalConfig{Version: CurrentConfigVersion}
+	}
+	lc.Content = mergeLocalConfig(lc.local, lc.org)
+}
+
+//update applies a change to the content of the config file and refreshes the effective configuration.
+func (lc *LocalConfiguration) update(change func(local *LocalConfig)) {
+	lc.Lock()
+	defer lc.Unlock()
+	if lc.local == nil {
+		lc.local = &LocalConfig{Version: CurrentConfigVersion}
+	}
+	change(lc.local)
+	lc.refresh()
+}
+
 //LocalConfig getters and setters.
 /* These methods are required to ensure a safe access between goroutines. */
 
@@ -108,11 +515,581 @@ func (lc *LocalConfiguration) GetKubeconfig() string {
 //SetKubeconfig sets the 'kubeconfig' field for the local configuration. Use SaveLocalConfig to write the updated
 //configuration to the ConfigFileName file.
 func (lc *LocalConfiguration) SetKubeconfig(path string) {
-	lc.Lock()
-	defer lc.Unlock()
+	lc.update(func(local *LocalConfig) {
+		local.Kubeconfig = path
+	})
+}
+
+//GetClusters returns a copy of the 'clusters' field for the local configuration.
+func (lc *LocalConfiguration) GetClusters() []ClusterConfig {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return nil
+	}
+	return append([]ClusterConfig(nil), lc.Content.Clusters...)
+}
+
+//GetCredentialHelpers returns a copy of the 'credentialHelpers' field for the local configuration.
+func (lc *LocalConfiguration) GetCredentialHelpers() []CredentialHelperConfig {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return nil
+	}
+	return append([]CredentialHelperConfig(nil), lc.Content.CredentialHelpers...)
+}
+
+//GetLocalAPI returns a copy of the 'localApi' field for the local configuration. If no setting is provided, the local
+//API is disabled.
+func (lc *LocalConfiguration) GetLocalAPI() LocalAPIConfig {
+	lc.RLock()
+	defer lc.RUnlock()
+	conf := LocalAPIConfig{Address: DefaultLocalAPIAddress}
+	if lc.Content == nil || lc.Content.LocalAPI == nil {
+		return conf
+	}
+	conf.Enabled = lc.Content.LocalAPI.Enabled
+	conf.Metrics = lc.Content.LocalAPI.Metrics
+	if lc.Content.LocalAPI.Address != "" {
+		conf.Address = lc.Content.LocalAPI.Address
+	}
+	conf.AllowedOrigins = append(conf.AllowedOrigins, lc.Content.LocalAPI.AllowedOrigins...)
+	return conf
+}
+
+//GetRemoteWrite returns a copy of the 'remoteWrite' field for the local configuration.
+func (lc *LocalConfiguration) GetRemoteWrite() RemoteWriteConfig {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil || lc.Content.RemoteWrite == nil {
+		return RemoteWriteConfig{}
+	}
+	conf := *lc.Content.RemoteWrite
+	conf.Labels = make(map[string]string, len(lc.Content.RemoteWrite.Labels))
+	for name, value := range lc.Content.RemoteWrite.Labels {
+		conf.Labels[name] = value
+	}
+	return conf
+}
+
+//GetTracing returns a copy of the 'tracing' field for the local configuration.
+func (lc *LocalConfiguration) GetTracing() TracingConfig {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil || lc.Content.Tracing == nil {
+		return TracingConfig{}
+	}
+	conf := *lc.Content.Tracing
+	conf.Headers = make(map[string]string, len(lc.Content.Tracing.Headers))
+	for name, value := range lc.Content.Tracing.Headers {
+		conf.Headers[name] = value
+	}
+	return conf
+}
+
+//GetCredentialsWarningDays returns the 'credentialsWarningDays' field for the local configuration, or
+//DefaultCredentialsWarningDays if not set.
+func (lc *LocalConfiguration) GetCredentialsWarningDays() int {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil || lc.Content.CredentialsWarningDays <= 0 {
+		return DefaultCredentialsWarningDays
+	}
+	return lc.Content.CredentialsWarningDays
+}
+
+//GetListPageSize returns the 'listPageSize' field for the local configuration, or DefaultListPageSize if not set.
+func (lc *LocalConfiguration) GetListPageSize() int {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil || lc.Content.ListPageSize <= 0 {
+		return DefaultListPageSize
+	}
+	return lc.Content.ListPageSize
+}
+
+//GetRedaction returns a copy of the 'redaction' field for the local configuration.
+func (lc *LocalConfiguration) GetRedaction() RedactionConfig {
+	lc.RLock()
+	defer lc.RUnlock()
+	conf := RedactionConfig{}
+	if lc.Content == nil || lc.Content.Redaction == nil {
+		return conf
+	}
+	conf.KeepServerURLs = lc.Content.Redaction.KeepServerURLs
+	conf.Patterns = append(conf.Patterns, lc.Content.Redaction.Patterns...)
+	return conf
+}
+
+//GetLiqoNamespace returns the 'liqoNamespace' field for the local configuration, or DefaultLiqoNamespace if not set.
+func (lc *LocalConfiguration) GetLiqoNamespace() string {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil || lc.Content.LiqoNamespace == "" {
+		return DefaultLiqoNamespace
+	}
+	return lc.Content.LiqoNamespace
+}
+
+//GetChartRepository returns the 'chartRepository' field for the local configuration, or DefaultChartRepository
+//if not set.
+func (lc *LocalConfiguration) GetChartRepository() string {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil || lc.Content.ChartRepository == "" {
+		return DefaultChartRepository
+	}
+	return lc.Content.ChartRepository
+}
+
+//GetIconTheme returns the 'iconTheme' field for the local configuration.
+func (lc *LocalConfiguration) GetIconTheme() string {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return ""
+	}
+	return lc.Content.IconTheme
+}
+
+//SetIconTheme sets the 'iconTheme' field for the local configuration. Use SaveLocalConfig to write the updated
+//configuration to the ConfigFileName file.
+func (lc *LocalConfiguration) SetIconTheme(theme string) {
+	lc.update(func(local *LocalConfig) {
+		local.IconTheme = theme
+	})
+}
+
+//GetColorScheme returns the 'colorScheme' field for the local configuration.
+func (lc *LocalConfiguration) GetColorScheme() string {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return ""
+	}
+	return lc.Content.ColorScheme
+}
+
+//SetColorScheme sets the 'colorScheme' field for the local configuration. Use SaveLocalConfig to write the updated
+//configuration to the ConfigFileName file.
+func (lc *LocalConfiguration) SetColorScheme(scheme string) {
+	lc.update(func(local *LocalConfig) {
+		local.ColorScheme = scheme
+	})
+}
+
+//GetIconBadge returns the 'iconBadge' field for the local configuration.
+func (lc *LocalConfiguration) GetIconBadge() bool {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return false
+	}
+	return lc.Content.IconBadge
+}
+
+//SetIconBadge sets the 'iconBadge' field for the local configuration. Use SaveLocalConfig to write the updated
+//configuration to the ConfigFileName file.
+func (lc *LocalConfiguration) SetIconBadge(badge bool) {
+	lc.update(func(local *LocalConfig) {
+		local.IconBadge = badge
+	})
+}
+
+//GetLanguage returns the 'language' field for the local configuration.
+func (lc *LocalConfiguration) GetLanguage() string {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return ""
+	}
+	return lc.Content.Language
+}
+
+//SetLanguage sets the 'language' field for the local configuration. Use SaveLocalConfig to write the updated
+//configuration to the ConfigFileName file.
+func (lc *LocalConfiguration) SetLanguage(language string) {
+	lc.update(func(local *LocalConfig) {
+		local.Language = language
+	})
+}
+
+//GetDisableIconAnimations returns the 'disableIconAnimations' field for the local configuration.
+func (lc *LocalConfiguration) GetDisableIconAnimations() bool {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return false
+	}
+	return lc.Content.DisableIconAnimations
+}
+
+//GetNotifyWhileLocked returns the 'notifyWhileLocked' field for the local configuration.
+func (lc *LocalConfiguration) GetNotifyWhileLocked() bool {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return false
+	}
+	return lc.Content.NotifyWhileLocked
+}
+
+//GetGuiBackend returns the 'guiBackend' field for the local configuration.
+func (lc *LocalConfiguration) GetGuiBackend() string {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return ""
+	}
+	return lc.Content.GuiBackend
+}
+
+//GetQuietHours returns a copy of the 'quietHours' field for the local configuration.
+func (lc *LocalConfiguration) GetQuietHours() []QuietHoursRule {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return nil
+	}
+	return append([]QuietHoursRule(nil), lc.Content.QuietHours...)
+}
+
+//GetDoNotDisturb returns a copy of the 'doNotDisturb' field for the local configuration.
+func (lc *LocalConfiguration) GetDoNotDisturb() DoNotDisturbConfig {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil || lc.Content.DoNotDisturb == nil {
+		return DoNotDisturbConfig{}
+	}
+	return *lc.Content.DoNotDisturb
+}
+
+//SetDoNotDisturb enables or disables the Do Not Disturb mode in the local configuration. Use SaveLocalConfig to
+//write the updated configuration to the ConfigFileName file.
+func (lc *LocalConfiguration) SetDoNotDisturb(enabled bool) {
+	lc.update(func(local *LocalConfig) {
+		dnd := DoNotDisturbConfig{}
+		if local.DoNotDisturb != nil {
+			dnd = *local.DoNotDisturb
+		}
+		dnd.Enabled = enabled
+		local.DoNotDisturb = &dnd
+	})
+}
+
+//GetTerminal returns the 'terminal' field for the local configuration.
+func (lc *LocalConfiguration) GetTerminal() string {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return ""
+	}
+	return lc.Content.Terminal
+}
+
+//GetLoginCommand returns the 'loginCommand' field for the local configuration.
+func (lc *LocalConfiguration) GetLoginCommand() string {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return ""
+	}
+	return lc.Content.LoginCommand
+}
+
+//GetCaptivePortalProbeURL returns the 'captivePortalProbeUrl' field for the local configuration,
+//or DefaultCaptivePortalProbeURL if not set.
+func (lc *LocalConfiguration) GetCaptivePortalProbeURL() string {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil || lc.Content.CaptivePortalProbeURL == "" {
+		return DefaultCaptivePortalProbeURL
+	}
+	return lc.Content.CaptivePortalProbeURL
+}
+
+//GetStartupSplash returns the 'startupSplash' field for the local configuration.
+func (lc *LocalConfiguration) GetStartupSplash() bool {
+	lc.RLock()
+	defer lc.RUnlock()
 	if lc.Content == nil {
-		lc.Content = &LocalConfig{Kubeconfig: path}
-		return
+		return false
+	}
+	return lc.Content.StartupSplash
+}
+
+//GetNotifyLevel returns the 'notifyLevel' field for the local configuration.
+func (lc *LocalConfiguration) GetNotifyLevel() string {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return ""
+	}
+	return lc.Content.NotifyLevel
+}
+
+//GetLabelMode returns the 'labelMode' field for the local configuration.
+func (lc *LocalConfiguration) GetLabelMode() string {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return ""
+	}
+	return lc.Content.LabelMode
+}
+
+//SetLabelMode sets the 'labelMode' field for the local configuration. Use SaveLocalConfig to write the updated
+//configuration to the ConfigFileName file.
+func (lc *LocalConfiguration) SetLabelMode(mode string) {
+	lc.update(func(local *LocalConfig) {
+		local.LabelMode = mode
+	})
+}
+
+//GetLabelFormat returns the 'labelFormat' field for the local configuration.
+func (lc *LocalConfiguration) GetLabelFormat() string {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return ""
+	}
+	return lc.Content.LabelFormat
+}
+
+//GetLabelAlways returns the 'labelAlways' field for the local configuration.
+func (lc *LocalConfiguration) GetLabelAlways() bool {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return false
+	}
+	return lc.Content.LabelAlways
+}
+
+//GetPeerGroupLabel returns the 'peerGroupLabel' field for the local configuration.
+func (lc *LocalConfiguration) GetPeerGroupLabel() string {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return ""
 	}
-	lc.Content.Kubeconfig = path
+	return lc.Content.PeerGroupLabel
+}
+
+//SetPeerGroupLabel sets the 'peerGroupLabel' field for the local configuration. Use SaveLocalConfig to write the
+//updated configuration to the ConfigFileName file.
+func (lc *LocalConfiguration) SetPeerGroupLabel(label string) {
+	lc.update(func(local *LocalConfig) {
+		local.PeerGroupLabel = label
+	})
+}
+
+//GetOperationTimeout returns the configured time limit of an operation, falling back to the "default" one.
+//It is zero if neither is set.
+func (lc *LocalConfiguration) GetOperationTimeout(operation string) time.Duration {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return 0
+	}
+	if timeout, present := lc.Content.OperationTimeouts[operation]; present {
+		return timeout
+	}
+	return lc.Content.OperationTimeouts["default"]
+}
+
+//GetListenerDebounce returns the configured debouncing of the listener of a Topic. If it is not set,
+//present == false.
+func (lc *LocalConfiguration) GetListenerDebounce(topic Topic) (debounce DebounceConfig, present bool) {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return DebounceConfig{}, false
+	}
+	debounce, present = lc.Content.ListenerDebounce[topic.String()]
+	return
+}
+
+//GetIntervals returns a copy of the 'intervals' field for the local configuration. The unset intervals are zero.
+func (lc *LocalConfiguration) GetIntervals() IntervalsConfig {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil || lc.Content.Intervals == nil {
+		return IntervalsConfig{}
+	}
+	return *lc.Content.Intervals
+}
+
+//GetSelfUpdate returns a copy of the 'selfUpdate' field for the local configuration, with ReleasesURL defaulting to
+//DefaultAgentReleasesURL.
+func (lc *LocalConfiguration) GetSelfUpdate() SelfUpdateConfig {
+	lc.RLock()
+	defer lc.RUnlock()
+	conf := SelfUpdateConfig{}
+	if lc.Content != nil && lc.Content.SelfUpdate != nil {
+		conf = *lc.Content.SelfUpdate
+	}
+	if conf.ReleasesURL == "" {
+		conf.ReleasesURL = DefaultAgentReleasesURL
+	}
+	return conf
+}
+
+//GetBranding returns a copy of the 'branding' field for the local configuration.
+func (lc *LocalConfiguration) GetBranding() BrandingConfig {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil || lc.Content.Branding == nil {
+		return BrandingConfig{}
+	}
+	return *lc.Content.Branding
+}
+
+//GetMenuLayout returns a copy of the 'menu' field for the local configuration.
+func (lc *LocalConfiguration) GetMenuLayout() MenuLayoutConfig {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil || lc.Content.Menu == nil {
+		return MenuLayoutConfig{}
+	}
+	return MenuLayoutConfig{
+		Order:  append([]string(nil), lc.Content.Menu.Order...),
+		Pinned: append([]string(nil), lc.Content.Menu.Pinned...),
+		Hidden: append([]string(nil), lc.Content.Menu.Hidden...),
+	}
+}
+
+//GetHotkeys returns a copy of the 'hotkeys' field for the local configuration.
+func (lc *LocalConfiguration) GetHotkeys() HotkeysConfig {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil || lc.Content.Hotkeys == nil {
+		return HotkeysConfig{}
+	}
+	return *lc.Content.Hotkeys
+}
+
+//GetBackoffPolicy returns the BackoffPolicy for the local configuration, completed with the default parameters.
+func (lc *LocalConfiguration) GetBackoffPolicy() BackoffPolicy {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil || lc.Content.Backoff == nil {
+		return DefaultBackoffPolicy
+	}
+	return lc.Content.Backoff.withDefaults()
+}
+
+//GetPollingPolicy returns the BackoffPolicy of the polling intervals for the local configuration, completed with
+//the default parameters.
+func (lc *LocalConfiguration) GetPollingPolicy() BackoffPolicy {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil || lc.Content.Polling == nil {
+		return DefaultPollingPolicy
+	}
+	return lc.Content.Polling.withDefaultsOf(DefaultPollingPolicy)
+}
+
+//GetPeerPreferences returns a copy of the 'peers' field for the local configuration.
+func (lc *LocalConfiguration) GetPeerPreferences() map[string]PeerPreferences {
+	lc.RLock()
+	defer lc.RUnlock()
+	prefs := make(map[string]PeerPreferences)
+	if lc.Content == nil {
+		return prefs
+	}
+	for clusterID, p := range lc.Content.Peers {
+		prefs[clusterID] = p
+	}
+	return prefs
+}
+
+//SetPeerAlias sets the alias of a peer in the 'peers' field for the local configuration, removing it if empty.
+//Use SaveLocalConfig to write the updated configuration to the ConfigFileName file.
+func (lc *LocalConfiguration) SetPeerAlias(clusterID string, alias string) {
+	lc.updatePeer(clusterID, func(p *PeerPreferences) {
+		p.Alias = alias
+	})
+}
+
+//SetPeerFavorite sets whether a peer is a favorite one in the 'peers' field for the local configuration.
+//Use SaveLocalConfig to write the updated configuration to the ConfigFileName file.
+func (lc *LocalConfiguration) SetPeerFavorite(clusterID string, favorite bool) {
+	lc.updatePeer(clusterID, func(p *PeerPreferences) {
+		p.Favorite = favorite
+	})
+}
+
+//updatePeer applies a change to the preferences of a peer, dropping them once empty. The map is copied, since it
+//may be shared with the effective configuration.
+func (lc *LocalConfiguration) updatePeer(clusterID string, change func(p *PeerPreferences)) {
+	lc.update(func(local *LocalConfig) {
+		peers := make(map[string]PeerPreferences, len(local.Peers)+1)
+		for id, p := range local.Peers {
+			peers[id] = p
+		}
+		p := peers[clusterID]
+		change(&p)
+		if p == (PeerPreferences{}) {
+			delete(peers, clusterID)
+		} else {
+			peers[clusterID] = p
+		}
+		if len(peers) == 0 {
+			peers = nil
+		}
+		local.Peers = peers
+	})
+}
+
+//SetMenuLayout sets the 'menu' field for the local configuration. Use SaveLocalConfig to write the updated
+//configuration to the ConfigFileName file.
+func (lc *LocalConfiguration) SetMenuLayout(layout MenuLayoutConfig) {
+	lc.update(func(local *LocalConfig) {
+		local.Menu = &layout
+	})
+}
+
+//GetReadOnly returns the 'readOnly' field for the local configuration.
+func (lc *LocalConfiguration) GetReadOnly() bool {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return false
+	}
+	return lc.Content.ReadOnly
+}
+
+//SetReadOnly sets the 'readOnly' field for the local configuration. Use SaveLocalConfig to write the updated
+//configuration to the ConfigFileName file.
+func (lc *LocalConfiguration) SetReadOnly(readOnly bool) {
+	lc.update(func(local *LocalConfig) {
+		local.ReadOnly = readOnly
+	})
+}
+
+//GetDebugLogging returns the 'debugLogging' field for the local configuration.
+func (lc *LocalConfiguration) GetDebugLogging() bool {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return false
+	}
+	return lc.Content.DebugLogging
+}
+
+//SetDebugLogging sets the 'debugLogging' field for the local configuration. Use SaveLocalConfig to write the
+//updated configuration to the ConfigFileName file.
+func (lc *LocalConfiguration) SetDebugLogging(enabled bool) {
+	lc.update(func(local *LocalConfig) {
+		local.DebugLogging = enabled
+	})
+}
+
+//ReadOnlyEnforced returns whether the read-only mode is enforced by the organization-wide defaults.
+func (lc *LocalConfiguration) ReadOnlyEnforced() bool {
+	lc.RLock()
+	defer lc.RUnlock()
+	return lc.org != nil && lc.org.ReadOnly
 }
@@ -1,7 +1,9 @@
 package client
 
 import (
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -43,3 +45,51 @@ func TestLocalConfiguration(t *testing.T) {
 		_ = os.Setenv(EnvLiqoPath, env)
 	}
 }
+
+func TestReadLocalConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liqo-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	//a missing file is read as an empty configuration
+	lc, err := ReadLocalConfig(dir)
+	if assert.NoError(t, err) && assert.NotNil(t, lc) {
+		assert.True(t, lc.Valid)
+		assert.Empty(t, lc.GetLabelMode())
+	}
+	path := filepath.Join(dir, ConfigFileName)
+	data := fmt.Sprintf("version: %d\nlabelMode: trend\ncolorScheme: unknown\n", CurrentConfigVersion)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(data), 0644))
+	lc, err = ReadLocalConfig(dir)
+	if assert.NotNil(t, lc) {
+		assert.Equal(t, "trend", lc.GetLabelMode())
+		assert.Empty(t, lc.GetColorScheme(), "invalid setting kept")
+		assert.NotSame(t, fileConfig, lc)
+	}
+	assert.IsType(t, &ValidationError{}, err)
+	assert.NoError(t, ioutil.WriteFile(path, []byte("labelMode: [trend"), 0644))
+	lc, err = ReadLocalConfig(dir)
+	assert.Nil(t, lc)
+	assert.Error(t, err)
+}
+
+func TestPeerPreferences(t *testing.T) {
+	lc := &LocalConfiguration{}
+	lc.SetPeerAlias("id1", "staging")
+	lc.SetPeerFavorite("id1", true)
+	lc.SetPeerFavorite("id2", true)
+	assert.Equal(t, map[string]PeerPreferences{
+		"id1": {Alias: "staging", Favorite: true},
+		"id2": {Favorite: true},
+	}, lc.GetPeerPreferences())
+	//the returned preferences are a copy
+	lc.GetPeerPreferences()["id1"] = PeerPreferences{}
+	assert.Equal(t, "staging", lc.GetPeerPreferences()["id1"].Alias)
+	//the empty preferences are dropped
+	lc.SetPeerFavorite("id2", false)
+	lc.SetPeerAlias("id1", "")
+	assert.Equal(t, map[string]PeerPreferences{"id1": {Favorite: true}}, lc.GetPeerPreferences())
+	lc.SetPeerFavorite("id1", false)
+	assert.Nil(t, lc.local.Peers)
+}
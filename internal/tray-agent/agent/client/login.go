@@ -0,0 +1,127 @@
+package client
+
+import (
+	"errors"
+	//the OIDC authentication provider renews the id-token cached in the kubeconfig file using its refresh token
+	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
+	"k8s.io/client-go/tools/clientcmd"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*This file contains the support of the credentials obtained at runtime by an exec credential plugin (e.g. the
+helpers of the cloud providers, or an OIDC login helper) or by an authentication provider. The client runs the plugin
+(or refreshes the provider token) whenever the cached credentials expire, until a new sign-in of the user is
+required: the LoginMethod of the kubeconfig user then provides the command signing in again.*/
+
+//execCredentialsFailure is the prefix of the errors returned by the client when the exec plugin fails to provide
+//the credentials.
+const execCredentialsFailure = "getting credentials: "
+
+//LoginMethod describes how the credentials of a kubeconfig user are obtained at runtime.
+type LoginMethod struct {
+	//User is the name of the kubeconfig user.
+	User string
+	//Plugin is the command of the exec plugin or the name of the authentication provider, e.g. "aws" or "oidc".
+	Plugin string
+	//Command is the command (with its arguments) signing in the user again. If empty, no command is known.
+	Command []string
+	//Env contains the environment variables set for the exec plugin, in the NAME=value format.
+	Env []string
+}
+
+//knownLoginCommands contains the commands signing in again the users of the common exec plugins, by the name of
+//the plugin command. The plugins not listed are run interactively, since the login helpers (e.g. kubectl oidc-login)
+//open the sign-in page themselves when the cached credentials expired.
+var knownLoginCommands = map[string][]string{
+	"aws":                    {"aws", "sso", "login"},
+	"aws-iam-authenticator":  {"aws", "sso", "login"},
+	"gcloud":                 {"gcloud", "auth", "login"},
+	"gke-gcloud-auth-plugin": {"gcloud", "auth", "login"},
+	"kubelogin":              {"az", "login"},
+}
+
+//knownProviderLoginCommands contains the commands signing in again the users of the authentication providers.
+var knownProviderLoginCommands = map[string][]string{
+	"gcp": {"gcloud", "auth", "login"},
+}
+
+//KubeconfigLogin returns the LoginMethod of the user of the cluster the AgentController is connected to, or nil if
+//its credentials are not obtained at runtime (e.g. a client certificate) and no command is configured in the
+//'loginCommand' field of the local configuration.
+func (ctrl *AgentController) KubeconfigLogin() (*LoginMethod, error) {
+	var method *LoginMethod
+	if !ctrl.mocked {
+		if ctrl.kubeconfig == "" {
+			return nil, errors.New("no kubeconfig provided")
+		}
+		var err error
+		if method, err = InspectLogin(ctrl.kubeconfig, ctrl.context); err != nil {
+			return nil, err
+		}
+	}
+	conf, _ := GetLocalConfig()
+	if command := strings.Fields(conf.GetLoginCommand()); len(command) > 0 {
+		if method == nil {
+			method = &LoginMethod{}
+		}
+		method.Command = command
+	}
+	return method, nil
+}
+
+//InspectLogin returns the LoginMethod of the user of a context (the current one if empty) of a kubeconfig file, or
+//nil if its credentials are not obtained at runtime.
+func InspectLogin(kubeconfig string, context string) (*LoginMethod, error) {
+	config, err := clientcmd.LoadFromFile(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	if context == "" {
+		context = config.CurrentContext
+	}
+	ctx, present := config.Contexts[context]
+	if !present {
+		return nil, errors.New("context " + context + " not found in the kubeconfig file")
+	}
+	authInfo, present := config.AuthInfos[ctx.AuthInfo]
+	if !present {
+		return nil, errors.New("no user for the context " + context + " in the kubeconfig file")
+	}
+	switch {
+	case authInfo.Exec != nil:
+		method := &LoginMethod{User: ctx.AuthInfo, Plugin: filepath.Base(authInfo.Exec.Command)}
+		for _, env := range authInfo.Exec.Env {
+			method.Env = append(method.Env, env.Name+"="+env.Value)
+		}
+		if command, known := knownLoginCommands[method.Plugin]; known {
+			method.Command = command
+		} else {
+			method.Command = append([]string{authInfo.Exec.Command}, authInfo.Exec.Args...)
+		}
+		return method, nil
+	case authInfo.AuthProvider != nil:
+		return &LoginMethod{User: ctx.AuthInfo, Plugin: authInfo.AuthProvider.Name,
+			Command: knownProviderLoginCommands[authInfo.AuthProvider.Name]}, nil
+	default:
+		return nil, nil
+	}
+}
+
+//IsLoginRequiredError returns whether err reports that the credentials of the Agent are no longer valid and can
+//not be renewed without a new sign-in of the user: the API server rejected them, or the exec plugin failed to
+//provide new ones.
+func IsLoginRequiredError(err error) bool {
+	return IsAuthenticationError(err) || err != nil && strings.Contains(err.Error(), execCredentialsFailure)
+}
+
+//LoginEnv returns the environment of the LoginMethod command: the one of the Agent, pointing kubectl at the
+//cluster the Agent is connected to, together with the variables set for the exec plugin.
+func (ctrl *AgentController) LoginEnv(method *LoginMethod) []string {
+	env := os.Environ()
+	if shellEnv, err := ctrl.ShellEnv(""); err == nil {
+		env = append(env, shellEnv...)
+	}
+	return append(env, method.Env...)
+}
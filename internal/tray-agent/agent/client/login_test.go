@@ -0,0 +1,94 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInspectLogin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liqo-login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := clientcmdapi.NewConfig()
+	config.Clusters["cluster"] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:6443"}
+	config.AuthInfos["aws"] = &clientcmdapi.AuthInfo{Exec: &clientcmdapi.ExecConfig{Command: "/usr/local/bin/aws",
+		Args: []string{"eks", "get-token"}, Env: []clientcmdapi.ExecEnvVar{{Name: "AWS_PROFILE", Value: "dev"}}}}
+	config.AuthInfos["oidc-login"] = &clientcmdapi.AuthInfo{Exec: &clientcmdapi.ExecConfig{Command: "kubectl",
+		Args: []string{"oidc-login", "get-token"}}}
+	config.AuthInfos["oidc"] = &clientcmdapi.AuthInfo{AuthProvider: &clientcmdapi.AuthProviderConfig{Name: "oidc"}}
+	config.AuthInfos["static"] = &clientcmdapi.AuthInfo{Token: "token"}
+	for user := range config.AuthInfos {
+		config.Contexts[user] = &clientcmdapi.Context{Cluster: "cluster", AuthInfo: user}
+	}
+	config.CurrentContext = "aws"
+	kubeconfig := filepath.Join(dir, "config")
+	assert.NoError(t, clientcmd.WriteToFile(*config, kubeconfig))
+	//the known plugins are signed in with their command
+	method, err := InspectLogin(kubeconfig, "")
+	if assert.NoError(t, err) && assert.NotNil(t, method) {
+		assert.Equal(t, "aws", method.User)
+		assert.Equal(t, "aws", method.Plugin)
+		assert.Equal(t, []string{"aws", "sso", "login"}, method.Command)
+		assert.Equal(t, []string{"AWS_PROFILE=dev"}, method.Env)
+	}
+	//the other plugins are run interactively
+	method, err = InspectLogin(kubeconfig, "oidc-login")
+	if assert.NoError(t, err) && assert.NotNil(t, method) {
+		assert.Equal(t, []string{"kubectl", "oidc-login", "get-token"}, method.Command)
+	}
+	//no command is known for the OIDC provider, whose tokens are refreshed by the client
+	method, err = InspectLogin(kubeconfig, "oidc")
+	if assert.NoError(t, err) && assert.NotNil(t, method) {
+		assert.Equal(t, "oidc", method.Plugin)
+		assert.Empty(t, method.Command)
+	}
+	method, err = InspectLogin(kubeconfig, "static")
+	assert.NoError(t, err)
+	assert.Nil(t, method, "static credentials need no login")
+	_, err = InspectLogin(kubeconfig, "missing")
+	assert.Error(t, err)
+}
+
+func TestIsLoginRequiredError(t *testing.T) {
+	assert.False(t, IsLoginRequiredError(nil))
+	assert.True(t, IsLoginRequiredError(ClassifyError("connection test", apierrors.NewUnauthorized("expired"))))
+	//the failures of the exec plugin are returned by the client as connection errors
+	execErr := &url.Error{Op: "Get", URL: "https://127.0.0.1:6443/api",
+		Err: fmt.Errorf("getting credentials: %v", errors.New("exec: exit status 1"))}
+	assert.True(t, IsLoginRequiredError(ClassifyError("connection test", execErr)))
+	assert.False(t, IsLoginRequiredError(ClassifyError("connection test", apierrors.NewForbidden(
+		schema.GroupResource{Resource: "nodes"}, "node", errors.New("denied")))))
+}
+
+func TestInCluster(t *testing.T) {
+	defer func(f func() (*rest.Config, error)) {
+		inClusterConfig = f
+	}(inClusterConfig)
+	inClusterConfig = func() (*rest.Config, error) {
+		return nil, rest.ErrNotInCluster
+	}
+	assert.False(t, InCluster())
+	_, err := newAgentController("", "").crdKubeconfig()
+	assert.Error(t, err, "no kubeconfig provided out of a cluster")
+	inClusterConfig = func() (*rest.Config, error) {
+		return &rest.Config{Host: "https://10.96.0.1:443"}, nil
+	}
+	assert.True(t, InCluster())
+	//the CRD clients fall back to the in-cluster configuration
+	path, err := newAgentController("", "").crdKubeconfig()
+	assert.NoError(t, err)
+	assert.Empty(t, path)
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	discovery "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	discovery2 "github.com/liqotech/liqo/pkg/discovery"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net/url"
+	"strings"
+)
+
+/*This file contains the initiation of an outgoing peering with a peer typed by the user. A peer already discovered is
+identified by its cluster ID, while any other peer is reached through the URL of its authentication service: in this
+case a ForeignCluster is created by hand (manual discovery), and Liqo retrieves the identity of the peer from it.*/
+
+//manualPeerPrefix precedes the host of the authentication service in the name of a ForeignCluster created by hand.
+const manualPeerPrefix = "manual-"
+
+//maxResourceNameLength is the maximum length of the name of a ForeignCluster.
+const maxResourceNameLength = 63
+
+//StartPeeringWith starts an outgoing peering with a peer, identified either by the cluster ID of a discovered peer
+//or by the URL of its authentication service (e.g. "https://10.0.0.1:30443"). In the latter case, if no
+//ForeignCluster points to that URL, a new one is created. The name of the ForeignCluster of the peer is returned.
+func (ctrl *AgentController) StartPeeringWith(peer string) (string, error) {
+	peer = strings.TrimSpace(peer)
+	if peer == "" {
+		return "", errors.New("start peering: no peer provided")
+	}
+	authURL, isURL := parseAuthURL(peer)
+	for _, fc := range ctrl.ForeignClusters().List() {
+		if (isURL && strings.TrimSuffix(fc.Spec.AuthUrl, "/") == authURL) ||
+			(!isURL && fc.Spec.ClusterIdentity.ClusterID == peer) {
+			if fc.Spec.Join {
+				return fc.Name, nil
+			}
+			return fc.Name, ctrl.StartStopOutPeering(fc.Name, true)
+		}
+	}
+	if !isURL {
+		return "", fmt.Errorf("start peering: no peer with cluster ID %s has been discovered, provide the URL "+
+			"of its authentication service", peer)
+	}
+	fc := &discovery.ForeignCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   manualPeerName(authURL),
+			Labels: map[string]string{discovery2.DiscoveryTypeLabel: string(discovery2.ManualDiscovery)},
+		},
+		Spec: discovery.ForeignClusterSpec{
+			Join:          true,
+			DiscoveryType: discovery2.ManualDiscovery,
+			AuthUrl:       authURL,
+			TrustMode:     discovery2.TrustModeUnknown,
+		},
+	}
+	fcCtrl := ctrl.Controller(CRForeignCluster)
+	_, err := fcCtrl.Resource(string(CRForeignCluster)).Create(fc, metav1.CreateOptions{})
+	if err != nil {
+		return "", classifyResourceError(ctrl.discoveryClient(), discovery.GroupVersion, "create ForeignCluster", err)
+	}
+	return fc.Name, nil
+}
+
+//parseAuthURL returns the normalized URL of an authentication service, if peer is an absolute http(s) URL.
+func parseAuthURL(peer string) (string, bool) {
+	u, err := url.Parse(peer)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", false
+	}
+	return strings.TrimSuffix(u.String(), "/"), true
+}
+
+//manualPeerName returns the name of the ForeignCluster created for an authentication service, e.g.
+//"manual-10-0-0-1-30443" for "https://10.0.0.1:30443".
+func manualPeerName(authURL string) string {
+	u, _ := url.Parse(authURL)
+	name := strings.Builder{}
+	name.WriteString(manualPeerPrefix)
+	dash := false
+	for _, r := range strings.ToLower(u.Host) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			name.WriteRune(r)
+			dash = false
+		} else if !dash {
+			name.WriteRune('-')
+			dash = true
+		}
+	}
+	str := strings.TrimRight(name.String(), "-")
+	if len(str) > maxResourceNameLength {
+		str = strings.TrimRight(str[:maxResourceNameLength], "-")
+	}
+	return str
+}
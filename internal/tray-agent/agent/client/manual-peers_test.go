@@ -0,0 +1,43 @@
+package client
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/test"
+	"github.com/liqotech/liqo/pkg/discovery"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStartPeeringWith(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Add(test.CreateForeignCluster("discovered", "remote")))
+	_, err := ctrl.StartPeeringWith("  ")
+	assert.Error(t, err)
+	//a discovered peer is joined
+	name, err := ctrl.StartPeeringWith("discovered")
+	assert.NoError(t, err)
+	assert.Equal(t, "discovered", name)
+	fc, _ := ctrl.ForeignClusters().Get("discovered")
+	assert.True(t, fc.Spec.Join, "peering with a discovered peer not started")
+	//an unknown cluster ID cannot be reached
+	_, err = ctrl.StartPeeringWith("unknown")
+	assert.Error(t, err)
+	_, err = ctrl.StartPeeringWith("ftp://10.0.0.1")
+	assert.Error(t, err, "non-http URL accepted")
+	//a ForeignCluster is created for an authentication service
+	name, err = ctrl.StartPeeringWith("https://Auth.Example.com:30443/")
+	assert.NoError(t, err)
+	assert.Equal(t, "manual-auth-example-com-30443", name)
+	fc, exists := ctrl.ForeignClusters().Get(name)
+	if assert.True(t, exists, "ForeignCluster not created") {
+		assert.True(t, fc.Spec.Join)
+		assert.Equal(t, discovery.ManualDiscovery, fc.Spec.DiscoveryType)
+		assert.Equal(t, "https://Auth.Example.com:30443", fc.Spec.AuthUrl)
+	}
+	//the same authentication service is not added twice
+	again, err := ctrl.StartPeeringWith("https://Auth.Example.com:30443")
+	assert.NoError(t, err)
+	assert.Equal(t, name, again)
+	assert.Len(t, ctrl.ForeignClusters().List(), 2)
+}
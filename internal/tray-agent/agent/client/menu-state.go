@@ -0,0 +1,126 @@
+package client
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/xdg"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+//MenuStateFileName is the basename of the file storing the menu state inside the Liqo Agent directory.
+const MenuStateFileName = "agent_state.yaml"
+
+//MenuState contains the state of the tray menu left by the user, restored at the next start of the Agent.
+//Differently from the LocalConfig, it is written by the Agent itself.
+type MenuState struct {
+	//NotifyLevel is the last notification level selected by the user. If nil, the default one is used.
+	NotifyLevel *int `yaml:"notifyLevel,omitempty"`
+	//Stopped specifies whether the user stopped the Agent.
+	Stopped bool `yaml:"stopped,omitempty"`
+	//LastPeer is the ClusterID of the last peer the user interacted with.
+	LastPeer string `yaml:"lastPeer,omitempty"`
+	//PeerSearch is the text searched in the peers list, empty if no search is active.
+	PeerSearch string `yaml:"peerSearch,omitempty"`
+	//PeerGroupLabel is the last label the user grouped the peers list by (empty for no grouping). If nil, the
+	//configured one is used.
+	PeerGroupLabel *string `yaml:"peerGroupLabel,omitempty"`
+	//ExpandedLists contains the number of pages displayed by the paginated lists the user expanded with
+	//"Show more…", by tag of the list (e.g. the peers list or one of its groups).
+	ExpandedLists map[string]int `yaml:"expandedLists,omitempty"`
+}
+
+//MenuStateStore persists the MenuState on the local file system.
+type MenuStateStore struct {
+	//path is the path of the file storing the MenuState. If empty, the state is kept in memory only.
+	path  string
+	state MenuState
+	sync.RWMutex
+}
+
+//menuStateStore is the MenuStateStore singleton.
+var menuStateStore *MenuStateStore
+
+//menuStateOnce protects the menuStateStore singleton initialization.
+var menuStateOnce sync.Once
+
+//GetMenuStateStore returns the MenuStateStore singleton, persisted in the MenuStateFileName file inside
+//the xdg.DirState directory. If the directory is not available, the state is kept in memory only.
+func GetMenuStateStore() *MenuStateStore {
+	menuStateOnce.Do(func() {
+		menuStateStore = NewMenuStateStore(xdg.DirState.File(MenuStateFileName))
+	})
+	return menuStateStore
+}
+
+//NewMenuStateStore returns a MenuStateStore persisted in path, loading the state previously saved there (if any).
+//An unreadable state is ignored, starting from an empty one.
+func NewMenuStateStore(path string) *MenuStateStore {
+	s := &MenuStateStore{path: path}
+	if path == "" {
+		return s
+	}
+	if data, err := ioutil.ReadFile(path); err == nil {
+		var state MenuState
+		if yaml.Unmarshal(data, &state) == nil {
+			s.state = state
+		}
+	}
+	return s
+}
+
+//State returns a copy of the current MenuState.
+func (s *MenuStateStore) State() MenuState {
+	s.RLock()
+	defer s.RUnlock()
+	state := s.state
+	if state.NotifyLevel != nil {
+		level := *state.NotifyLevel
+		state.NotifyLevel = &level
+	}
+	if state.PeerGroupLabel != nil {
+		label := *state.PeerGroupLabel
+		state.PeerGroupLabel = &label
+	}
+	if state.ExpandedLists != nil {
+		expanded := make(map[string]int, len(state.ExpandedLists))
+		for tag, pages := range state.ExpandedLists {
+			expanded[tag] = pages
+		}
+		state.ExpandedLists = expanded
+	}
+	return state
+}
+
+//Update applies a change to the MenuState and saves it.
+func (s *MenuStateStore) Update(change func(state *MenuState)) error {
+	s.Lock()
+	defer s.Unlock()
+	change(&s.state)
+	if s.path == "" {
+		return nil
+	}
+	data, err := yaml.Marshal(&s.state)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, data, 0644)
+}
+
+//Reset clears the MenuState, removing the file storing it.
+func (s *MenuStateStore) Reset() error {
+	s.Lock()
+	defer s.Unlock()
+	s.state = MenuState{}
+	if s.path == "" {
+		return nil
+	}
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
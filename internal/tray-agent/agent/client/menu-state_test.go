@@ -0,0 +1,52 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMenuStateStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liqo-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, MenuStateFileName)
+	s := NewMenuStateStore(path)
+	assert.Equal(t, MenuState{}, s.State(), "non empty initial state")
+	level, label := 1, "region"
+	assert.NoError(t, s.Update(func(state *MenuState) {
+		state.NotifyLevel = &level
+		state.Stopped = true
+		state.LastPeer = "cl1"
+		state.PeerSearch = "eu"
+		state.PeerGroupLabel = &label
+		state.ExpandedLists = map[string]int{"Q_PEERS": 3}
+	}))
+	//the state is restored from the file
+	restored := NewMenuStateStore(path).State()
+	if assert.NotNil(t, restored.NotifyLevel) {
+		assert.Equal(t, 1, *restored.NotifyLevel)
+	}
+	assert.True(t, restored.Stopped)
+	assert.Equal(t, "cl1", restored.LastPeer)
+	assert.Equal(t, "eu", restored.PeerSearch)
+	if assert.NotNil(t, restored.PeerGroupLabel) {
+		assert.Equal(t, "region", *restored.PeerGroupLabel)
+	}
+	assert.Equal(t, map[string]int{"Q_PEERS": 3}, restored.ExpandedLists)
+	//the returned state is a copy
+	*restored.NotifyLevel = 2
+	copied := s.State()
+	*copied.PeerGroupLabel = "zone"
+	copied.ExpandedLists["Q_PEERS"] = 1
+	assert.Equal(t, 1, *s.State().NotifyLevel)
+	assert.Equal(t, "region", *s.State().PeerGroupLabel)
+	assert.Equal(t, 3, s.State().ExpandedLists["Q_PEERS"])
+	//a corrupted file is ignored
+	assert.NoError(t, ioutil.WriteFile(path, []byte("stopped: [\n"), 0644))
+	assert.Equal(t, MenuState{}, NewMenuStateStore(path).State())
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sort"
+	"strings"
+)
+
+//OffloadingState is the state of the offloading of a namespace towards the peers.
+type OffloadingState string
+
+const (
+	//OffloadingDisabled is the state of a namespace whose pods are not offloaded.
+	OffloadingDisabled OffloadingState = "Disabled"
+	//OffloadingReady is the state of a namespace whose pods can be offloaded to the peers.
+	OffloadingReady OffloadingState = "Ready"
+	//OffloadingError is the state of a namespace whose offloading is enabled but not working.
+	OffloadingError OffloadingState = "Error"
+)
+
+//systemNamespacePrefix precedes the names of the kubernetes system namespaces, never offloaded.
+const systemNamespacePrefix = "kube-"
+
+//NamespaceOffloading describes the offloading of a namespace of the home cluster.
+type NamespaceOffloading struct {
+	Name  string
+	State OffloadingState
+	//Message explains the State, e.g. the reason of an OffloadingError.
+	Message string
+	//Offloaded is the number of pods of the namespace running on the virtual nodes.
+	Offloaded int
+}
+
+//Enabled returns whether the offloading of the namespace is enabled.
+func (o *NamespaceOffloading) Enabled() bool {
+	return o.State != OffloadingDisabled
+}
+
+//NamespaceOffloadings returns the offloading of the namespaces of the home cluster, sorted by name, built from the
+//content of the AgentController caches. The system namespaces and the Liqo namespace are not included.
+func (ctrl *AgentController) NamespaceOffloadings() ([]*NamespaceOffloading, error) {
+	c := ctrl.coreCache
+	if c == nil || !c.running {
+		return nil, errors.New("namespaces are not watched")
+	}
+	c.consumeCoalesced(TopicNamespacesChanged)
+	namespaces, err := c.factory.Core().V1().Namespaces().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	pods, err := c.factory.Core().V1().Pods().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := c.factory.Core().V1().Nodes().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	return newNamespaceOffloadings(namespaces, pods, nodes, c.liqoNamespace), nil
+}
+
+//newNamespaceOffloadings returns the offloading of the namespaces, except the system ones and liqoNamespace.
+//An enabled namespace is in OffloadingError if it is being deleted, if no virtual node is ready to host its pods
+//or if some of its offloaded pods are failing.
+func newNamespaceOffloadings(namespaces []*corev1.Namespace, pods []*corev1.Pod, nodes []*corev1.Node,
+	liqoNamespace string) []*NamespaceOffloading {
+	readyNodes := 0
+	for _, n := range nodes {
+		if isVirtualNode(n) && nodeReady(n) == corev1.ConditionTrue && !n.Spec.Unschedulable {
+			readyNodes++
+		}
+	}
+	virtualNodes := make(map[string]bool)
+	for _, n := range nodes {
+		virtualNodes[n.Name] = isVirtualNode(n)
+	}
+	offloaded := make(map[string]int)
+	for _, pod := range pods {
+		if virtualNodes[pod.Spec.NodeName] {
+			offloaded[pod.Namespace]++
+		}
+	}
+	failing := make(map[string]int)
+	for _, f := range newWorkloadFailures(pods, nodes) {
+		failing[f.Namespace]++
+	}
+	result := make([]*NamespaceOffloading, 0, len(namespaces))
+	for _, ns := range namespaces {
+		if ns.Name == liqoNamespace || strings.HasPrefix(ns.Name, systemNamespacePrefix) {
+			continue
+		}
+		o := &NamespaceOffloading{Name: ns.Name, State: OffloadingDisabled, Offloaded: offloaded[ns.Name]}
+		if ns.Labels[LabelOffloadingEnabled] == "true" {
+			switch {
+			case ns.Status.Phase == corev1.NamespaceTerminating:
+				o.State, o.Message = OffloadingError, "the namespace is being deleted"
+			case failing[ns.Name] > 0:
+				o.State, o.Message = OffloadingError, fmt.Sprintf("%d offloaded pods failing", failing[ns.Name])
+			case readyNodes == 0:
+				o.State, o.Message = OffloadingError, "no virtual node is ready to host the pods"
+			default:
+				o.State, o.Message = OffloadingReady, fmt.Sprintf("%d pods offloaded", o.Offloaded)
+			}
+		}
+		result = append(result, o)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+//SetNamespaceOffloading enables or disables the offloading of a namespace, setting or removing its
+//LabelOffloadingEnabled label.
+func (ctrl *AgentController) SetNamespaceOffloading(namespace string, enabled bool) error {
+	if ctrl.kube() == nil {
+		return newError(ErrNotConnected, "set namespace offloading", nil)
+	}
+	value := "null"
+	if enabled {
+		value = `"true"`
+	}
+	patch := []byte(fmt.Sprintf(`{"metadata":{"labels":{"%s":%s}}}`, LabelOffloadingEnabled, value))
+	_, err := ctrl.kube().CoreV1().Namespaces().Patch(context.TODO(), namespace, types.MergePatchType, patch,
+		metav1.PatchOptions{})
+	if err != nil {
+		return ClassifyError("patch namespace", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func TestNewNamespaceOffloadings(t *testing.T) {
+	enabled := map[string]string{LabelOffloadingEnabled: "true"}
+	namespaces := []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web", Labels: enabled}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "liqo"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "jobs", Labels: enabled}},
+	}
+	pods := []*corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "web"}, Spec: corev1.PodSpec{NodeName: "liqo-cl1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "batch", Namespace: "jobs"}, Spec: corev1.PodSpec{NodeName: "liqo-cl1"},
+			Status: corev1.PodStatus{Phase: corev1.PodFailed}},
+	}
+	//without virtual nodes the enabled namespaces cannot be offloaded
+	list := newNamespaceOffloadings(namespaces, nil, nil, "liqo")
+	if !assert.Len(t, list, 3, "system namespaces listed") {
+		return
+	}
+	assert.Equal(t, []string{"default", "jobs", "web"}, []string{list[0].Name, list[1].Name, list[2].Name},
+		"namespaces are not sorted")
+	assert.False(t, list[0].Enabled())
+	assert.Equal(t, OffloadingError, list[2].State)
+	nodes := []*corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "liqo-cl1", Labels: map[string]string{labelVirtualNodeType: virtualNodeType},
+			Annotations: map[string]string{annVirtualNodeClusterID: "cl1"}},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}}},
+	}
+	list = newNamespaceOffloadings(namespaces, pods, nodes, "liqo")
+	assert.Equal(t, OffloadingError, list[1].State, "namespace with failing pods is ready")
+	assert.Equal(t, "1 offloaded pods failing", list[1].Message)
+	assert.Equal(t, OffloadingReady, list[2].State)
+	assert.Equal(t, 1, list[2].Offloaded)
+}
+
+func TestSetNamespaceOffloading(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	_, err := ctrl.kubeClient.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "offload-ns"}}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	state := func() OffloadingState {
+		list, _ := ctrl.NamespaceOffloadings()
+		for _, o := range list {
+			if o.Name == "offload-ns" {
+				return o.State
+			}
+		}
+		return ""
+	}
+	assert.Eventually(t, func() bool {
+		return state() == OffloadingDisabled
+	}, 5*time.Second, 10*time.Millisecond, "namespace not listed")
+	assert.NoError(t, ctrl.SetNamespaceOffloading("offload-ns", true))
+	assert.Eventually(t, func() bool {
+		return state() == OffloadingError
+	}, 5*time.Second, 10*time.Millisecond, "offloading not enabled")
+	assert.NoError(t, ctrl.SetNamespaceOffloading("offload-ns", false))
+	assert.Eventually(t, func() bool {
+		return state() == OffloadingDisabled
+	}, 5*time.Second, 10*time.Millisecond, "offloading not disabled")
+	assert.Error(t, ctrl.SetNamespaceOffloading("missing-ns", true))
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"fmt"
+	sharing "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"sort"
+	"strconv"
+)
+
+//OfferChangeKind describes how a field of a resource offer changed.
+type OfferChangeKind string
+
+//OfferChangeKind values.
+const (
+	OfferAdded     OfferChangeKind = "added"
+	OfferRemoved   OfferChangeKind = "removed"
+	OfferIncreased OfferChangeKind = "increased"
+	OfferDecreased OfferChangeKind = "decreased"
+	OfferChanged   OfferChangeKind = "changed"
+)
+
+//OfferChange is a single difference between two versions of the resource offer (Advertisement) of a peer.
+type OfferChange struct {
+	//Field identifies the changed item, e.g. "quota cpu" or "label zone".
+	Field string
+	Old   string
+	New   string
+	Kind  OfferChangeKind
+}
+
+//String returns a human readable description of the OfferChange, e.g. "quota memory: 8Gi → 4Gi (decreased)".
+func (c *OfferChange) String() string {
+	switch c.Kind {
+	case OfferAdded:
+		return fmt.Sprintf("%s: %s (added)", c.Field, c.New)
+	case OfferRemoved:
+		return fmt.Sprintf("%s: %s (removed)", c.Field, c.Old)
+	default:
+		return fmt.Sprintf("%s: %s → %s (%s)", c.Field, c.Old, c.New, c.Kind)
+	}
+}
+
+//NotifyDataOfferChanged is a NotifyDataGeneric sub-type used to signal a change of the resource offer of a peer.
+type NotifyDataOfferChanged struct {
+	//ClusterID is the ClusterID of the peer offering the resources.
+	ClusterID string
+	Changes   []*OfferChange
+}
+
+//DiffOffers returns the differences between two versions of the resource offer of a peer, sorted by field.
+//The fields refreshed at each renewal of the Advertisement (e.g. its timestamps) are ignored.
+func DiffOffers(oldAdv *sharing.Advertisement, newAdv *sharing.Advertisement) []*OfferChange {
+	changes := make([]*OfferChange, 0)
+	changes = append(changes, diffResourceLists("quota", oldAdv.Spec.ResourceQuota.Hard,
+		newAdv.Spec.ResourceQuota.Hard)...)
+	changes = append(changes, diffResourceLists("price", oldAdv.Spec.Prices, newAdv.Spec.Prices)...)
+	changes = append(changes, diffStringMaps("label", oldAdv.Spec.Labels, newAdv.Spec.Labels)...)
+	changes = append(changes, diffStringMaps("property", resourceNameMap(oldAdv.Spec.Properties),
+		resourceNameMap(newAdv.Spec.Properties))...)
+	if o, n := len(oldAdv.Spec.Images), len(newAdv.Spec.Images); o != n {
+		changes = append(changes, &OfferChange{Field: "images", Old: strconv.Itoa(o), New: strconv.Itoa(n),
+			Kind: quantityChangeKind(int64(n - o))})
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+//diffResourceLists returns the differences between two corev1.ResourceList.
+func diffResourceLists(prefix string, oldList corev1.ResourceList, newList corev1.ResourceList) []*OfferChange {
+	var changes []*OfferChange
+	for name, oldQ := range oldList {
+		field := prefix + " " + string(name)
+		newQ, present := newList[name]
+		if !present {
+			changes = append(changes, &OfferChange{Field: field, Old: oldQ.String(), Kind: OfferRemoved})
+			continue
+		}
+		if cmp := newQ.Cmp(oldQ); cmp != 0 {
+			changes = append(changes, &OfferChange{Field: field, Old: oldQ.String(), New: newQ.String(),
+				Kind: quantityChangeKind(int64(cmp))})
+		}
+	}
+	for name, newQ := range newList {
+		if _, present := oldList[name]; !present {
+			changes = append(changes, &OfferChange{Field: prefix + " " + string(name), New: newQ.String(),
+				Kind: OfferAdded})
+		}
+	}
+	return changes
+}
+
+//diffStringMaps returns the differences between two maps of strings.
+func diffStringMaps(prefix string, oldMap map[string]string, newMap map[string]string) []*OfferChange {
+	var changes []*OfferChange
+	for k, oldV := range oldMap {
+		newV, present := newMap[k]
+		switch {
+		case !present:
+			changes = append(changes, &OfferChange{Field: prefix + " " + k, Old: oldV, Kind: OfferRemoved})
+		case newV != oldV:
+			changes = append(changes, &OfferChange{Field: prefix + " " + k, Old: oldV, New: newV, Kind: OfferChanged})
+		}
+	}
+	for k, newV := range newMap {
+		if _, present := oldMap[k]; !present {
+			changes = append(changes, &OfferChange{Field: prefix + " " + k, New: newV, Kind: OfferAdded})
+		}
+	}
+	return changes
+}
+
+//resourceNameMap converts a map indexed by corev1.ResourceName into a map of strings.
+func resourceNameMap(m map[corev1.ResourceName]string) map[string]string {
+	converted := make(map[string]string, len(m))
+	for k, v := range m {
+		converted[string(k)] = v
+	}
+	return converted
+}
+
+//quantityChangeKind returns the OfferChangeKind for a quantity variation with the given sign.
+func quantityChangeKind(sign int64) OfferChangeKind {
+	if sign > 0 {
+		return OfferIncreased
+	}
+	return OfferDecreased
+}
+
+//advertisementEventHandler is the event handler for the Advertisement CRDController. It signals the changes
+//of the resource offer of a peer on TopicOfferChanged.
+func (ctrl *AgentController) advertisementEventHandler(event CacheEvent) {
+	if event.Type != CacheUpdated {
+		return
+	}
+	oldAdv, okOld := event.OldObject.(*sharing.Advertisement)
+	newAdv, okNew := event.Object.(*sharing.Advertisement)
+	if !okOld || !okNew || oldAdv.ResourceVersion == newAdv.ResourceVersion {
+		return
+	}
+	changes := DiffOffers(oldAdv, newAdv)
+	if len(changes) == 0 {
+		return
+	}
+	ctrl.events.Publish(TopicOfferChanged, &NotifyDataOfferChanged{
+		ClusterID: newAdv.Spec.ClusterId,
+		Changes:   changes,
+	})
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	sharing "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"testing"
+)
+
+func TestDiffOffers(t *testing.T) {
+	oldAdv := &sharing.Advertisement{Spec: sharing.AdvertisementSpec{
+		ResourceQuota: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("4"),
+			corev1.ResourceMemory: resource.MustParse("8Gi"),
+			corev1.ResourcePods:   resource.MustParse("110"),
+		}},
+		Labels:     map[string]string{"zone": "eu-1", "gpu": "true"},
+		Properties: map[corev1.ResourceName]string{"storageClasses": "standard,fast"},
+	}}
+	newAdv := oldAdv.DeepCopy()
+	assert.Empty(t, DiffOffers(oldAdv, newAdv), "differences found between equal offers")
+	newAdv.Spec.ResourceQuota.Hard[corev1.ResourceCPU] = resource.MustParse("4000m")
+	newAdv.Spec.ResourceQuota.Hard[corev1.ResourceMemory] = resource.MustParse("4Gi")
+	delete(newAdv.Spec.ResourceQuota.Hard, corev1.ResourcePods)
+	newAdv.Spec.ResourceQuota.Hard[corev1.ResourceEphemeralStorage] = resource.MustParse("10Gi")
+	delete(newAdv.Spec.Labels, "gpu")
+	newAdv.Spec.Properties["storageClasses"] = "standard"
+	var descriptions []string
+	for _, c := range DiffOffers(oldAdv, newAdv) {
+		descriptions = append(descriptions, c.String())
+	}
+	assert.Equal(t, []string{
+		"label gpu: true (removed)",
+		"property storageClasses: standard,fast → standard (changed)",
+		"quota ephemeral-storage: 10Gi (added)",
+		"quota memory: 8Gi → 4Gi (decreased)",
+		"quota pods: 110 (removed)",
+	}, descriptions)
+}
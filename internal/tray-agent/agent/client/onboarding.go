@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	sharing "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	discovery2 "github.com/liqotech/liqo/pkg/discovery"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+/*This file contains the onboarding checklist of an outgoing peering, following its progress from the authentication
+to the peer up to the virtual node able to host the offloaded pods. Each step is evaluated from the status of the
+ForeignCluster (authentication, Advertisement and network resources) and of the virtual node of the peer.*/
+
+//OnboardingState is the state of a step of the onboarding checklist.
+type OnboardingState int
+
+const (
+	//OnboardingPending signals a step not completed yet.
+	OnboardingPending OnboardingState = iota
+	//OnboardingPassed signals a completed step.
+	OnboardingPassed
+	//OnboardingFailed signals a step that cannot be completed without an intervention of the user.
+	OnboardingFailed
+)
+
+//Steps of the onboarding checklist, in order.
+const (
+	StepAuthentication = "Authentication"
+	StepResources      = "Resources negotiated"
+	StepNetwork        = "Network established"
+	StepVirtualNode    = "Virtual node ready"
+	StepSchedulable    = "Test pod schedulable"
+)
+
+//OnboardingStep is a step of the onboarding checklist of a peering.
+type OnboardingStep struct {
+	Name  string
+	State OnboardingState
+	//Detail describes the current state of the step, e.g. "waiting for the tunnel endpoint".
+	Detail string
+	//Hint is the remediation suggested for a pending or failed step.
+	Hint string
+}
+
+//OnboardingChecklist returns the onboarding checklist of the outgoing peering with the peer described by a
+//ForeignCluster. The steps following a not completed one are pending.
+func (ctrl *AgentController) OnboardingChecklist(ctx context.Context, fcName string) ([]OnboardingStep, error) {
+	fc, exists := ctrl.ForeignClusters().Get(fcName)
+	if !exists {
+		return nil, fmt.Errorf("onboarding checklist: peer %s not found", fcName)
+	}
+	status := fc.Status
+	steps := make([]OnboardingStep, 0, 5)
+	//authentication
+	auth := OnboardingStep{Name: StepAuthentication}
+	switch {
+	case status.AuthStatus == discovery2.AuthStatusAccepted || status.Outgoing.AvailableIdentity:
+		auth.State, auth.Detail = OnboardingPassed, "identity accepted by the peer"
+	case status.AuthStatus == discovery2.AuthStatusRefused || status.AuthStatus == discovery2.AuthStatusEmptyRefused:
+		auth.State, auth.Detail = OnboardingFailed, "the peer refused the authentication"
+		auth.Hint = "Insert the auth token of the peer manually from its menu entry, or configure a credential helper."
+	default:
+		auth.Detail = "waiting for the authentication service of the peer"
+		auth.Hint = "Check that the authentication URL " + fc.Spec.AuthUrl + " is reachable from this cluster."
+	}
+	steps = append(steps, auth)
+	//resources negotiation
+	res := OnboardingStep{Name: StepResources}
+	switch {
+	case status.Outgoing.AdvertisementStatus == sharing.AdvertisementAccepted:
+		res.State, res.Detail = OnboardingPassed, "Advertisement accepted"
+	case status.Outgoing.AdvertisementStatus == sharing.AdvertisementRefused:
+		res.State, res.Detail = OnboardingFailed, "Advertisement refused"
+		res.Hint = "Check the advertisement policy in the ClusterConfig of this cluster: it may not accept the " +
+			"resources offered by the peer."
+	case status.Outgoing.Advertisement != nil:
+		res.Detail = "Advertisement received, waiting for its acceptance"
+	default:
+		res.Detail = "waiting for the Advertisement of the peer"
+		res.Hint = "The peer may not be sharing resources: check its ClusterConfig."
+	}
+	steps = append(steps, res)
+	//network
+	network := OnboardingStep{Name: StepNetwork}
+	switch {
+	case status.Network.TunnelEndpoint.Available:
+		network.State, network.Detail = OnboardingPassed, "tunnel established"
+	case status.Network.LocalNetworkConfig.Available && status.Network.RemoteNetworkConfig.Available:
+		network.Detail = "network parameters exchanged, waiting for the tunnel"
+		network.Hint = "Check that the gateway of the peer is reachable from the gateway of this cluster."
+	default:
+		network.Detail = "waiting for the exchange of the network parameters"
+	}
+	steps = append(steps, network)
+	//virtual node
+	node, err := ctrl.virtualNode(ctx, fc.Spec.ClusterIdentity.ClusterID)
+	vn := OnboardingStep{Name: StepVirtualNode}
+	schedulable := OnboardingStep{Name: StepSchedulable}
+	switch {
+	case err != nil:
+		vn.Detail = "waiting for the virtual node"
+		if !kerrors.IsNotFound(err) {
+			vn.Detail = "cannot read the virtual node: " + err.Error()
+		}
+	case nodeReady(node) == corev1.ConditionTrue:
+		vn.State, vn.Detail = OnboardingPassed, "node "+node.Name+" ready"
+	case nodeReady(node) == corev1.ConditionFalse:
+		vn.State, vn.Detail = OnboardingFailed, "node "+node.Name+" not ready"
+		vn.Hint = "Check the logs of the virtual kubelet of the peer in the liqo namespace."
+	default:
+		vn.Detail = "node " + node.Name + " registered, waiting for its readiness"
+	}
+	steps = append(steps, vn)
+	//schedulability
+	if vn.State == OnboardingPassed {
+		schedulable.State, schedulable.Detail, schedulable.Hint = nodeSchedulability(node)
+	} else {
+		schedulable.Detail = "waiting for the virtual node"
+	}
+	steps = append(steps, schedulable)
+	//the steps following a not completed one cannot be considered completed
+	for index := 1; index < len(steps); index++ {
+		if steps[index-1].State != OnboardingPassed && steps[index].State != OnboardingPending {
+			steps[index].State = OnboardingPending
+		}
+	}
+	return steps, nil
+}
+
+//OnboardingDone returns whether all the steps of an onboarding checklist are completed, and whether any failed.
+func OnboardingDone(steps []OnboardingStep) (completed bool, failed bool) {
+	completed = len(steps) > 0
+	for _, s := range steps {
+		completed = completed && s.State == OnboardingPassed
+		failed = failed || s.State == OnboardingFailed
+	}
+	return completed, failed
+}
+
+//virtualNode returns the virtual node of a peer.
+func (ctrl *AgentController) virtualNode(ctx context.Context, clusterID string) (*corev1.Node, error) {
+	if ctrl.kube() == nil {
+		return nil, newError(ErrNotConnected, "get virtual node", nil)
+	}
+	name := ctrl.VirtualNodeName(clusterID)
+	if c := ctrl.coreCache; c != nil && c.running {
+		return c.factory.Core().V1().Nodes().Lister().Get(name)
+	}
+	return ctrl.kube().CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+}
+
+//nodeReady returns the status of the Ready condition of a node.
+func nodeReady(node *corev1.Node) corev1.ConditionStatus {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status
+		}
+	}
+	return corev1.ConditionUnknown
+}
+
+//nodeSchedulability checks whether a pod tolerating the taint of the virtual nodes can be scheduled on a ready
+//node, i.e. the node is not cordoned and offers some allocatable CPU, memory and pods.
+func nodeSchedulability(node *corev1.Node) (OnboardingState, string, string) {
+	if node.Spec.Unschedulable {
+		return OnboardingFailed, "node " + node.Name + " cordoned", "Uncordon the node with 'kubectl uncordon " +
+			node.Name + "'."
+	}
+	for _, r := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourcePods} {
+		if q, present := node.Status.Allocatable[r]; !present || q.IsZero() {
+			return OnboardingFailed, fmt.Sprintf("no allocatable %s on node %s", r, node.Name),
+				"The peer is offering no " + string(r) + ": check the resources shared by the peer."
+		}
+	}
+	return OnboardingPassed, "node " + node.Name + " accepts pods", ""
+}
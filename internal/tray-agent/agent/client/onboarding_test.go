@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/test"
+	sharing "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/liqotech/liqo/pkg/discovery"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func TestOnboardingChecklist(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	_, err := ctrl.OnboardingChecklist(context.TODO(), "missing")
+	assert.Error(t, err)
+	fc := test.CreateForeignCluster("onboard-fc", "remote")
+	fc.Status.AuthStatus = discovery.AuthStatusAccepted
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Add(fc))
+	steps, err := ctrl.OnboardingChecklist(context.TODO(), "onboard-fc")
+	if assert.NoError(t, err) && assert.Len(t, steps, 5) {
+		assert.Equal(t, StepAuthentication, steps[0].Name)
+		assert.Equal(t, OnboardingPassed, steps[0].State)
+		assert.Equal(t, OnboardingPending, steps[1].State)
+		assert.NotEmpty(t, steps[1].Hint, "pending step without remediation hint")
+		assert.Equal(t, OnboardingPending, steps[4].State)
+	}
+	completed, failed := OnboardingDone(steps)
+	assert.False(t, completed)
+	assert.False(t, failed)
+	//a refused Advertisement fails the negotiation of the resources
+	fc = fc.DeepCopy()
+	fc.Status.Outgoing.AdvertisementStatus = sharing.AdvertisementRefused
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Update(fc))
+	steps, _ = ctrl.OnboardingChecklist(context.TODO(), "onboard-fc")
+	assert.Equal(t, OnboardingFailed, steps[1].State)
+	_, failed = OnboardingDone(steps)
+	assert.True(t, failed)
+	//all the steps are completed once the virtual node accepts pods
+	fc = fc.DeepCopy()
+	fc.Status.Outgoing.AdvertisementStatus = sharing.AdvertisementAccepted
+	fc.Status.Network.TunnelEndpoint.Available = true
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Update(fc))
+	_, err = ctrl.kubeClient.CoreV1().Nodes().Create(context.TODO(), &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        virtualNodePrefix + "onboard-fc",
+			Labels:      map[string]string{labelVirtualNodeType: virtualNodeType},
+			Annotations: map[string]string{annVirtualNodeClusterID: "onboard-fc"},
+		},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("2"),
+				corev1.ResourceMemory: resource.MustParse("4Gi"),
+				corev1.ResourcePods:   resource.MustParse("110"),
+			},
+		},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		steps, _ = ctrl.OnboardingChecklist(context.TODO(), "onboard-fc")
+		completed, _ = OnboardingDone(steps)
+		return completed
+	}, 5*time.Second, 10*time.Millisecond, "onboarding not completed with a ready virtual node")
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"strings"
+)
+
+//DefaultOrgDefaultsConfigMap is the default name of the ConfigMap containing the organization-wide defaults.
+const DefaultOrgDefaultsConfigMap = "liqo-agent-defaults"
+
+//OrgDefaultsConfig contains the location of the organization-wide defaults of the Agent: a ConfigMap of the cluster
+//storing, in its ConfigFileName key, a configuration with the same format of the local one. Its settings apply
+//when not provided by the local configuration, so that admins can centrally configure fleets of desktops.
+type OrgDefaultsConfig struct {
+	//Enabled specifies whether the organization-wide defaults are acquired.
+	Enabled bool `yaml:"enabled"`
+	//ConfigMap is the name of the ConfigMap. It defaults to DefaultOrgDefaultsConfigMap.
+	ConfigMap string `yaml:"configMap,omitempty"`
+	//Namespace is the namespace of the ConfigMap. It defaults to the Liqo namespace.
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+//orgExcludedFields are the LocalConfig fields (by yaml key) that the organization-wide defaults cannot set,
+//since they select the cluster and the defaults themselves, or the commands run by the Agent.
+var orgExcludedFields = map[string]bool{
+	"version":           true,
+	"kubeconfig":        true,
+	"clusters":          true,
+	"credentialHelpers": true,
+	"orgDefaults":       true,
+}
+
+//GetOrgDefaults returns the 'orgDefaults' field for the local configuration, completed with the default values.
+func (lc *LocalConfiguration) GetOrgDefaults() OrgDefaultsConfig {
+	lc.RLock()
+	defer lc.RUnlock()
+	conf := OrgDefaultsConfig{ConfigMap: DefaultOrgDefaultsConfigMap, Namespace: DefaultLiqoNamespace}
+	if lc.Content == nil {
+		return conf
+	}
+	if lc.Content.LiqoNamespace != "" {
+		conf.Namespace = lc.Content.LiqoNamespace
+	}
+	if lc.Content.OrgDefaults == nil {
+		return conf
+	}
+	conf.Enabled = lc.Content.OrgDefaults.Enabled
+	if lc.Content.OrgDefaults.ConfigMap != "" {
+		conf.ConfigMap = lc.Content.OrgDefaults.ConfigMap
+	}
+	if lc.Content.OrgDefaults.Namespace != "" {
+		conf.Namespace = lc.Content.OrgDefaults.Namespace
+	}
+	return conf
+}
+
+//SetOrgDefaults sets the organization-wide defaults completing the local configuration. A nil org removes them.
+func (lc *LocalConfiguration) SetOrgDefaults(org *LocalConfig) {
+	lc.Lock()
+	defer lc.Unlock()
+	lc.org = org
+	lc.refresh()
+}
+
+//OrgDefaults returns the organization-wide defaults currently applied, or nil if none.
+func (lc *LocalConfiguration) OrgDefaults() *LocalConfig {
+	lc.RLock()
+	defer lc.RUnlock()
+	return lc.org
+}
+
+//ParseOrgDefaults parses the organization-wide defaults, in the format of the ConfigFileName config file (migrated
+//if written for an older version of the Agent). The settings the organization cannot provide (see
+//orgExcludedFields) are dropped, while the invalid ones are rejected.
+func ParseOrgDefaults(data []byte) (*LocalConfig, error) {
+	org, _, err := decodeLocalConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := org.Validate(); err != nil {
+		return nil, err
+	}
+	v := reflect.ValueOf(org).Elem()
+	for n := 0; n < v.NumField(); n++ {
+		if orgExcludedFields[yamlKey(v.Type().Field(n))] {
+			v.Field(n).Set(reflect.Zero(v.Field(n).Type()))
+		}
+	}
+	return org, nil
+}
+
+//LoadOrgDefaults acquires the organization-wide defaults from the cluster, if enabled in the local configuration,
+//and applies them. It returns whether they were found: a missing ConfigMap is not an error.
+func (ctrl *AgentController) LoadOrgDefaults() (bool, error) {
+	conf, _ := GetLocalConfig()
+	orgConf := conf.GetOrgDefaults()
+	if !orgConf.Enabled {
+		conf.SetOrgDefaults(nil)
+		return false, nil
+	}
+	if !ctrl.Connected() {
+		return false, newError(ErrNotConnected, "get organization defaults", nil)
+	}
+	cm, err := ctrl.kube().CoreV1().ConfigMaps(orgConf.Namespace).Get(context.TODO(), orgConf.ConfigMap,
+		metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		conf.SetOrgDefaults(nil)
+		return false, nil
+	}
+	if err != nil {
+		return false, ClassifyError("get organization defaults", err)
+	}
+	data, present := cm.Data[ConfigFileName]
+	if !present {
+		return false, fmt.Errorf("ConfigMap %s/%s has no %s key", orgConf.Namespace, orgConf.ConfigMap,
+			ConfigFileName)
+	}
+	org, err := ParseOrgDefaults([]byte(data))
+	if err != nil {
+		return false, fmt.Errorf("invalid organization defaults in ConfigMap %s/%s: %v", orgConf.Namespace,
+			orgConf.ConfigMap, err)
+	}
+	conf.SetOrgDefaults(org)
+	return true, nil
+}
+
+//mergeLocalConfig returns the configuration with the settings of local, completed with the org ones for the
+//settings local does not provide. Nested settings (e.g. the intervals) are merged field by field.
+//The returned configuration may share data with its arguments, which must not be modified afterwards.
+func mergeLocalConfig(local *LocalConfig, org *LocalConfig) *LocalConfig {
+	if org == nil {
+		return local
+	}
+	merged := *local
+	mergeFields(reflect.ValueOf(&merged).Elem(), reflect.ValueOf(org).Elem())
+	return &merged
+}
+
+//mergeFields sets the zero fields of the struct dst to the value of the corresponding src ones.
+func mergeFields(dst reflect.Value, src reflect.Value) {
+	for n := 0; n < dst.NumField(); n++ {
+		d, s := dst.Field(n), src.Field(n)
+		switch {
+		case s.IsZero():
+		case d.IsZero():
+			d.Set(s)
+		case d.Kind() == reflect.Ptr && d.Elem().Kind() == reflect.Struct:
+			//the local struct is copied, so that it is not modified
+			copied := reflect.New(d.Elem().Type())
+			copied.Elem().Set(d.Elem())
+			mergeFields(copied.Elem(), s.Elem())
+			d.Set(copied)
+		}
+	}
+}
+
+//yamlKey returns the key of a struct field in the yaml format.
+func yamlKey(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("yaml"), ",")[0]
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func TestOrgDefaults(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	conf := NewLocalConfig()
+	defer conf.SetOrgDefaults(nil)
+	//disabled
+	found, err := ctrl.LoadOrgDefaults()
+	assert.NoError(t, err)
+	assert.False(t, found, "organization defaults acquired while disabled")
+	conf.update(func(local *LocalConfig) {
+		local.OrgDefaults = &OrgDefaultsConfig{Enabled: true}
+		local.IconTheme = "accessible"
+		local.Intervals = &IntervalsConfig{Heartbeat: 2 * time.Second}
+	})
+	//enabled, but no ConfigMap
+	found, err = ctrl.LoadOrgDefaults()
+	assert.NoError(t, err)
+	assert.False(t, found, "missing ConfigMap not ignored")
+	_, err = ctrl.kubeClient.CoreV1().ConfigMaps(DefaultLiqoNamespace).Create(context.TODO(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: DefaultOrgDefaultsConfigMap, Namespace: DefaultLiqoNamespace},
+		Data: map[string]string{ConfigFileName: `
+kubeconfig: /org/kubeconfig
+iconTheme: default
+notifyLevel: icon
+intervals:
+  heartbeat: 10s
+  capacity: 1m
+branding:
+  title: ACME Liqo
+`},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	found, err = ctrl.LoadOrgDefaults()
+	assert.NoError(t, err)
+	assert.True(t, found, "organization defaults not acquired")
+	//the local settings take precedence
+	assert.Equal(t, "accessible", conf.GetIconTheme())
+	assert.Equal(t, IntervalsConfig{Heartbeat: 2 * time.Second, Capacity: time.Minute}, conf.GetIntervals())
+	assert.Equal(t, "icon", conf.GetNotifyLevel())
+	assert.Equal(t, "ACME Liqo", conf.GetBranding().Title)
+	assert.Empty(t, conf.GetKubeconfig(), "kubeconfig set by the organization defaults")
+	//the organization defaults are not saved in the local config file
+	assert.Empty(t, conf.local.NotifyLevel)
+	assert.Nil(t, conf.local.Branding)
+	assert.Equal(t, 2*time.Second, conf.local.Intervals.Heartbeat)
+	assert.Zero(t, conf.local.Intervals.Capacity, "local settings modified by the merge")
+	//local changes keep the organization defaults
+	conf.SetIconTheme("default")
+	assert.Equal(t, "ACME Liqo", conf.GetBranding().Title)
+	//the read-only mode set by the organization cannot be disabled locally
+	assert.False(t, conf.ReadOnlyEnforced())
+	conf.SetOrgDefaults(&LocalConfig{ReadOnly: true})
+	conf.SetReadOnly(false)
+	assert.True(t, conf.ReadOnlyEnforced())
+	assert.True(t, conf.GetReadOnly())
+	//invalid content
+	_, err = ParseOrgDefaults([]byte("intervals: [1, 2]"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	discovery "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	sharing "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/liqotech/liqo/pkg/crdClient"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"net"
+	"net/url"
+	"time"
+)
+
+/*This file contains the details of the active peerings displayed in the peers list: the resources shared in each
+direction, the virtual node and the latency towards the peer.
+
+The resources are read from the ResourceOffers and the ResourceRequests of the home cluster:
+	-	the ResourceOffer sent by a peer (whose ClusterId is the one of the peer) contains the resources the peer
+		shares with the home cluster, and references the virtual node created for them;
+	-	the ResourceRequest of a peer (whose ClusterIdentity is the one of the peer) lives in the namespace where the
+		home cluster publishes its own ResourceOffer for that peer, containing the resources shared with it.*/
+
+const (
+	//CRResourceOffer is the resource id for the ResourceOffer CRD.
+	CRResourceOffer CustomResource = "resourceoffers"
+	//CRResourceRequest is the resource id for the ResourceRequest CRD.
+	CRResourceRequest CustomResource = "resourcerequests"
+	//peerLatencyDialTimeout is the time limit for the connection measuring the latency towards a peer.
+	peerLatencyDialTimeout = 5 * time.Second
+)
+
+//namespacedKeyer returns the namespace/name key of a CR, the same used by the informers storing it.
+func namespacedKeyer(obj runtime.Object) (string, error) {
+	return cache.MetaNamespaceKeyFunc(obj)
+}
+
+//createResourceOfferController creates a new CRDController for the Liqo ResourceOffer CRD.
+func createResourceOfferController(ctrl *AgentController, kubeconfig string) (*CRDController, error) {
+	crdClient.AddToRegistry(string(CRResourceOffer), &sharing.ResourceOffer{}, &sharing.ResourceOfferList{},
+		namespacedKeyer, schema.GroupResource{Group: sharing.GroupVersion.Group, Resource: string(CRResourceOffer)})
+	newClient, err := newSharingClient(kubeconfig, &sharing.GroupVersion)
+	if err != nil {
+		return nil, err
+	}
+	return newCRDController(newClient, CRResourceOffer, ctrl.sharingEventHandler), nil
+}
+
+//createResourceRequestController creates a new CRDController for the Liqo ResourceRequest CRD.
+func createResourceRequestController(ctrl *AgentController, kubeconfig string) (*CRDController, error) {
+	crdClient.AddToRegistry(string(CRResourceRequest), &discovery.ResourceRequest{}, &discovery.ResourceRequestList{},
+		namespacedKeyer, schema.GroupResource{Group: discovery.GroupVersion.Group,
+			Resource: string(CRResourceRequest)})
+	newClient, err := newSharingClient(kubeconfig, &discovery.GroupVersion)
+	if err != nil {
+		return nil, err
+	}
+	return newCRDController(newClient, CRResourceRequest, ctrl.sharingEventHandler), nil
+}
+
+//newSharingClient returns a CRDClient for the CRDs of an API group version.
+func newSharingClient(kubeconfig string, gv *schema.GroupVersion) (*crdClient.CRDClient, error) {
+	config, err := crdClient.NewKubeconfig(kubeconfig, gv, nil)
+	if err != nil {
+		return nil, err
+	}
+	return crdClient.NewFromConfig(config)
+}
+
+//sharingEventHandler is the event handler for the ResourceOffer and ResourceRequest CRDControllers. It signals
+//the peer whose shared resources changed as updated.
+func (ctrl *AgentController) sharingEventHandler(event CacheEvent) {
+	var clusterID string
+	switch obj := event.Object.(type) {
+	case *sharing.ResourceOffer:
+		clusterID = obj.Spec.ClusterId
+		//the offers of the home cluster are related to the peer requesting them in the same namespace
+		if _, present := ctrl.peerForeignCluster(clusterID); !present {
+			clusterID = ctrl.namespaceRequester(obj.Namespace)
+		}
+	case *discovery.ResourceRequest:
+		clusterID = obj.Spec.ClusterIdentity.ClusterID
+	}
+	fc, present := ctrl.peerForeignCluster(clusterID)
+	if !present {
+		return
+	}
+	data := &NotifyDataForeignCluster{}
+	data.loadPeerInfo(fc)
+	data.loadPeeringInfo(ctrl, fc)
+	ctrl.events.Publish(TopicPeerChanged, data)
+}
+
+//peerForeignCluster returns the cached ForeignCluster of the peer with the given ClusterID.
+func (ctrl *AgentController) peerForeignCluster(clusterID string) (*discovery.ForeignCluster, bool) {
+	if clusterID == "" {
+		return nil, false
+	}
+	for _, fc := range ctrl.ForeignClusters().List() {
+		if fc.Spec.ClusterIdentity.ClusterID == clusterID {
+			return fc, true
+		}
+	}
+	return nil, false
+}
+
+//namespaceRequester returns the ClusterID of the peer with a ResourceRequest in the given namespace, if any.
+func (ctrl *AgentController) namespaceRequester(namespace string) string {
+	for _, r := range ctrl.ResourceRequests().List() {
+		if r.Namespace == namespace {
+			return r.Spec.ClusterIdentity.ClusterID
+		}
+	}
+	return ""
+}
+
+//loadSharingInfo loads the resources shared in the active peerings with a peer and the name of its virtual node.
+func (d *NotifyDataForeignCluster) loadSharingInfo(ctrl *AgentController) {
+	var requestNamespaces []string
+	for _, r := range ctrl.ResourceRequests().List() {
+		if r.Spec.ClusterIdentity.ClusterID == d.ClusterID {
+			requestNamespaces = append(requestNamespaces, r.Namespace)
+		}
+	}
+	for _, offer := range ctrl.ResourceOffers().List() {
+		quotas := offer.Spec.ResourceQuota.Hard
+		switch {
+		case offer.Spec.ClusterId == d.ClusterID && d.OutPeering.Connected:
+			if quotas != nil {
+				d.OutPeering.CpuQuota = quotas.Cpu().String()
+				d.OutPeering.MemQuota = quotas.Memory().String()
+			}
+			d.OutPeering.VirtualNode = offer.Status.VnodeReference.Name
+		case offer.Spec.ClusterId != d.ClusterID && d.InPeering.Connected &&
+			containsString(requestNamespaces, offer.Namespace) && quotas != nil:
+			d.InPeering.CpuQuota = quotas.Cpu().String()
+			d.InPeering.MemQuota = quotas.Memory().String()
+		}
+	}
+	if d.OutPeering.Connected && d.OutPeering.VirtualNode == "" {
+		d.OutPeering.VirtualNode = ctrl.virtualNodeName(d.ClusterID)
+	}
+}
+
+//virtualNodeName returns the name of the virtual node extending the home cluster with the resources of a peer,
+//looked up in the cached nodes.
+func (ctrl *AgentController) virtualNodeName(clusterID string) string {
+	c := ctrl.coreCache
+	if c == nil || !c.running {
+		return ""
+	}
+	nodes, err := c.factory.Core().V1().Nodes().Lister().List(labels.Everything())
+	if err != nil {
+		return ""
+	}
+	for _, n := range nodes {
+		if isVirtualNode(n) && n.Annotations[annVirtualNodeClusterID] == clusterID {
+			return n.Name
+		}
+	}
+	return ""
+}
+
+//containsString returns whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+//PeerLatency measures the latency towards a peer as the time required to connect to its authentication service.
+func (ctrl *AgentController) PeerLatency(ctx context.Context, foreignCluster string) (time.Duration, error) {
+	fc, exists := ctrl.ForeignClusters().Get(foreignCluster)
+	if !exists {
+		return 0, fmt.Errorf("peer latency: ForeignCluster %s not found", foreignCluster)
+	}
+	if fc.Spec.AuthUrl == "" {
+		return 0, ClassifyError("peer latency", errors.New("no authentication service available"))
+	}
+	u, err := url.Parse(fc.Spec.AuthUrl)
+	if err != nil {
+		return 0, ClassifyError("peer latency", err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+	dialer := &net.Dialer{Timeout: peerLatencyDialTimeout}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return 0, ClassifyError("peer latency", err)
+	}
+	latency := time.Since(start)
+	_ = conn.Close()
+	return latency, nil
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	discovery "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	sharing "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	objectReferences "github.com/liqotech/liqo/pkg/object-references"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net"
+	"testing"
+)
+
+func TestPeerSharingInfo(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	fc := &discovery.ForeignCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "details-fc"},
+		Spec: discovery.ForeignClusterSpec{
+			ClusterIdentity: discovery.ClusterIdentity{ClusterID: "details-fc", ClusterName: "remote"},
+		},
+		Status: discovery.ForeignClusterStatus{
+			Outgoing: discovery.Outgoing{Joined: true, AdvertisementStatus: sharing.AdvertisementAccepted},
+			Incoming: discovery.Incoming{Joined: true, AdvertisementStatus: sharing.AdvertisementAccepted},
+		},
+	}
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Add(fc))
+	quota := func(cpu string, mem string) corev1.ResourceQuotaSpec {
+		return corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(mem),
+		}}
+	}
+	//the offer of the peer, and the one of the home cluster in the namespace of the request of the peer
+	assert.NoError(t, ctrl.Controller(CRResourceOffer).Store.Add(&sharing.ResourceOffer{
+		ObjectMeta: metav1.ObjectMeta{Name: "offer-remote", Namespace: "tenant-details"},
+		Spec:       sharing.ResourceOfferSpec{ClusterId: "details-fc", ResourceQuota: quota("4", "8Gi")},
+		Status: sharing.ResourceOfferStatus{
+			VnodeReference: objectReferences.NodeReference{Name: "liqo-details-fc"},
+		},
+	}))
+	assert.NoError(t, ctrl.Controller(CRResourceRequest).Store.Add(&discovery.ResourceRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "request-details-fc", Namespace: "tenant-home"},
+		Spec: discovery.ResourceRequestSpec{
+			ClusterIdentity: discovery.ClusterIdentity{ClusterID: "details-fc"},
+		},
+	}))
+	assert.NoError(t, ctrl.Controller(CRResourceOffer).Store.Add(&sharing.ResourceOffer{
+		ObjectMeta: metav1.ObjectMeta{Name: "offer-home", Namespace: "tenant-home"},
+		Spec:       sharing.ResourceOfferSpec{ClusterId: "home", ResourceQuota: quota("2", "4Gi")},
+	}))
+	data := &NotifyDataForeignCluster{}
+	data.loadPeerInfo(fc)
+	data.loadPeeringInfo(ctrl, fc)
+	assert.Equal(t, "4", data.OutPeering.CpuQuota)
+	assert.Equal(t, "8Gi", data.OutPeering.MemQuota)
+	assert.Equal(t, "liqo-details-fc", data.OutPeering.VirtualNode)
+	assert.Equal(t, "2", data.InPeering.CpuQuota)
+	assert.Equal(t, "4Gi", data.InPeering.MemQuota)
+	//without active peerings, no resource is shared
+	idle := fc.DeepCopy()
+	idle.Status = discovery.ForeignClusterStatus{}
+	data = &NotifyDataForeignCluster{}
+	data.loadPeeringInfo(ctrl, idle)
+	assert.Empty(t, data.OutPeering.CpuQuota)
+	assert.Empty(t, data.OutPeering.VirtualNode)
+	assert.Empty(t, data.InPeering.CpuQuota)
+}
+
+func TestPeerLatency(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	_, err := ctrl.PeerLatency(context.TODO(), "missing")
+	assert.Error(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+	fc := &discovery.ForeignCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "latency-fc"},
+		Spec: discovery.ForeignClusterSpec{
+			ClusterIdentity: discovery.ClusterIdentity{ClusterID: "latency-fc"},
+			AuthUrl:         "https://" + listener.Addr().String(),
+		},
+	}
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Add(fc))
+	latency, err := ctrl.PeerLatency(context.TODO(), "latency-fc")
+	assert.NoError(t, err)
+	assert.True(t, latency > 0)
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sort"
+	"strings"
+)
+
+/*This file contains the collection of the remote diagnostics of a peer: the status of the resources of the home
+cluster related to the peering (ForeignCluster, Advertisements, virtual node) and the recent logs of the Liqo
+components, so that the user does not have to assemble them by hand when reporting a peering issue.
+
+The collection goes on as far as the RBAC permissions of the user allow: the resources that cannot be read are
+listed in the summary of the PeerDiagnostics.*/
+
+//DefaultDiagnosticsLogLines is the default number of most recent log lines collected for each Liqo container.
+const DefaultDiagnosticsLogLines = 500
+
+//DiagnosticsFile is a file of the PeerDiagnostics.
+type DiagnosticsFile struct {
+	//Name is the path of the file inside the diagnostics, e.g. "logs/pod/container.log".
+	Name string
+	Data []byte
+}
+
+//PeerDiagnostics contains the diagnostics of a peer collected from the home cluster.
+type PeerDiagnostics struct {
+	ClusterID   string
+	ClusterName string
+	Files       []DiagnosticsFile
+	//Skipped contains the descriptions of the resources that could not be collected, each with its reason.
+	Skipped []string
+}
+
+//add appends a file to the PeerDiagnostics.
+func (d *PeerDiagnostics) add(name string, data []byte) {
+	d.Files = append(d.Files, DiagnosticsFile{Name: name, Data: data})
+}
+
+//addObject appends a file containing the JSON representation of a resource.
+func (d *PeerDiagnostics) addObject(name string, obj interface{}) {
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		d.skip(name, err)
+		return
+	}
+	d.add(name, data)
+}
+
+//skip records a resource that could not be collected.
+func (d *PeerDiagnostics) skip(what string, err error) {
+	reason := err.Error()
+	if errors.Is(ClassifyError("diagnostics", err), ErrForbidden) {
+		reason = "not allowed by RBAC"
+	}
+	d.Skipped = append(d.Skipped, what+": "+reason)
+}
+
+//Summary returns a human readable description of the PeerDiagnostics content.
+func (d *PeerDiagnostics) Summary() string {
+	str := strings.Builder{}
+	str.WriteString(fmt.Sprintf("Remote diagnostics of %s (%s)\n\nCollected files:\n", d.ClusterName, d.ClusterID))
+	for _, f := range d.Files {
+		str.WriteString(fmt.Sprintf("- %s (%d bytes)\n", f.Name, len(f.Data)))
+	}
+	if len(d.Skipped) > 0 {
+		str.WriteString("\nNot collected:\n")
+		for _, s := range d.Skipped {
+			str.WriteString("- " + s + "\n")
+		}
+	}
+	return str.String()
+}
+
+//podLogs returns the most recent lines of the log of a container. It is a variable to be replaced in the tests,
+//since the fake clientset does not serve the logs.
+var podLogs = func(ctx context.Context, kubeClient kubernetes.Interface, namespace string, pod string,
+	container string, lines int64) ([]byte, error) {
+	return kubeClient.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: container,
+		TailLines: &lines,
+	}).DoRaw(ctx)
+}
+
+//CollectPeerDiagnostics collects from the home cluster the diagnostics of the peer described by a ForeignCluster:
+//the status of the ForeignCluster, of the Advertisements of its peerings and of its virtual node, and the last
+//logLines lines of the logs of the containers running in the Liqo namespace. The log lines mentioning the peer are
+//also gathered in a dedicated file.
+//
+//The resources that cannot be read (e.g. because of RBAC) are recorded in the Skipped ones: an error is returned
+//only if the ForeignCluster is not found or ctx expires.
+func (ctrl *AgentController) CollectPeerDiagnostics(ctx context.Context, foreignCluster string,
+	logLines int64) (*PeerDiagnostics, error) {
+	fc, exists := ctrl.ForeignClusters().Get(foreignCluster)
+	if !exists {
+		return nil, fmt.Errorf("peer diagnostics: ForeignCluster %s not found", foreignCluster)
+	}
+	if logLines <= 0 {
+		logLines = DefaultDiagnosticsLogLines
+	}
+	d := &PeerDiagnostics{
+		ClusterID:   fc.Spec.ClusterIdentity.ClusterID,
+		ClusterName: fc.Spec.ClusterIdentity.ClusterName,
+	}
+	d.addObject("resources/foreigncluster.json", fc)
+	//Advertisements sent by the peer
+	for _, adv := range ctrl.Advertisements().List() {
+		if adv.Spec.ClusterId == d.ClusterID {
+			d.addObject("resources/advertisement-"+adv.Name+".json", adv)
+		}
+	}
+	//virtual node
+	nodeName := ctrl.VirtualNodeName(d.ClusterID)
+	if node, err := ctrl.kube().CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{}); err == nil {
+		d.addObject("resources/virtual-node.json", node)
+	} else {
+		d.skip("Node "+nodeName, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return d, ClassifyError("peer diagnostics", err)
+	}
+	//logs of the Liqo components
+	conf, _ := GetLocalConfig()
+	namespace := conf.GetLiqoNamespace()
+	pods, err := ctrl.kube().CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		d.skip("Pods in namespace "+namespace, err)
+		return d, ClassifyError("peer diagnostics", ctx.Err())
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].Name < pods.Items[j].Name
+	})
+	peerLines := bytes.Buffer{}
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			logs, err := podLogs(ctx, ctrl.kube(), namespace, pod.Name, container.Name, logLines)
+			if err != nil {
+				if ctx.Err() != nil {
+					return d, ClassifyError("peer diagnostics", ctx.Err())
+				}
+				d.skip(fmt.Sprintf("Logs of %s/%s", pod.Name, container.Name), err)
+				continue
+			}
+			d.add(fmt.Sprintf("logs/%s/%s.log", pod.Name, container.Name), logs)
+			collectPeerLines(&peerLines, pod.Name+"/"+container.Name, logs, d.ClusterID, d.ClusterName)
+		}
+	}
+	d.add("logs/peer-lines.log", peerLines.Bytes())
+	return d, nil
+}
+
+//collectPeerLines writes to w the lines of logs mentioning the peer, each preceded by source.
+func collectPeerLines(w *bytes.Buffer, source string, logs []byte, clusterID string, clusterName string) {
+	scanner := bufio.NewScanner(bytes.NewReader(logs))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, clusterID) || (clusterName != "" && strings.Contains(line, clusterName)) {
+			w.WriteString("[" + source + "] " + line + "\n")
+		}
+	}
+}
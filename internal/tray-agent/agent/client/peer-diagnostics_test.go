@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	sharing "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"strings"
+	"testing"
+)
+
+func TestCollectPeerDiagnostics(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	_, err := ctrl.CollectPeerDiagnostics(context.TODO(), "missing", 0)
+	assert.Error(t, err)
+	//the fake clientset does not serve the logs
+	defer func(original func(context.Context, kubernetes.Interface, string, string, string, int64) ([]byte,
+		error)) {
+		podLogs = original
+	}(podLogs)
+	podLogs = func(ctx context.Context, kubeClient kubernetes.Interface, namespace string, pod string,
+		container string, lines int64) ([]byte, error) {
+		if container == "denied" {
+			return nil, apierrors.NewForbidden(schema.GroupResource{Resource: "pods/log"}, pod, nil)
+		}
+		return []byte("starting\npeering with diag-fc established\n"), nil
+	}
+	fc := &v1alpha1.ForeignCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "diag-fc"},
+		Spec: v1alpha1.ForeignClusterSpec{
+			ClusterIdentity: v1alpha1.ClusterIdentity{ClusterID: "diag-fc", ClusterName: "remote"},
+		},
+	}
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Add(fc))
+	adv := &sharing.Advertisement{
+		ObjectMeta: metav1.ObjectMeta{Name: "advertisement-diag-fc"},
+		Spec:       sharing.AdvertisementSpec{ClusterId: "diag-fc"},
+	}
+	assert.NoError(t, ctrl.Controller(CRAdvertisement).Store.Add(adv))
+	_, err = ctrl.kubeClient.CoreV1().Pods("liqo").Create(context.TODO(), &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "liqo-controller", Namespace: "liqo"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "manager"}, {Name: "denied"}}},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	d, err := ctrl.CollectPeerDiagnostics(context.TODO(), "diag-fc", 0)
+	assert.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range d.Files {
+		files[f.Name] = string(f.Data)
+	}
+	assert.Contains(t, files, "resources/foreigncluster.json")
+	assert.Contains(t, files, "resources/advertisement-advertisement-diag-fc.json")
+	assert.Contains(t, files, "logs/liqo-controller/manager.log")
+	assert.Equal(t, "[liqo-controller/manager] peering with diag-fc established\n", files["logs/peer-lines.log"])
+	//the resources not available or not allowed are reported
+	summary := d.Summary()
+	assert.Contains(t, summary, "Node "+virtualNodePrefix+"diag-fc")
+	assert.Contains(t, summary, "Logs of liqo-controller/denied: not allowed by RBAC")
+	assert.False(t, strings.Contains(summary, "manager: "), "collected logs reported as missing")
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	discovery "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	sharing "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	discovery2 "github.com/liqotech/liqo/pkg/discovery"
+)
+
+//PeeringPhase is the phase of a peering (outgoing or incoming) with a peer.
+type PeeringPhase string
+
+const (
+	//PeeringNone is the phase of a peering neither established nor requested.
+	PeeringNone PeeringPhase = ""
+	//PeeringPending is the phase of a requested peering waiting for the negotiation of the resources.
+	PeeringPending PeeringPhase = "Pending"
+	//PeeringAuthenticating is the phase of a requested peering waiting for the authentication on the peer.
+	PeeringAuthenticating PeeringPhase = "Authenticating"
+	//PeeringEstablished is the phase of an established peering.
+	PeeringEstablished PeeringPhase = "Established"
+	//PeeringDisconnecting is the phase of an established peering being torn down.
+	PeeringDisconnecting PeeringPhase = "Disconnecting"
+	//PeeringError is the phase of a requested peering which cannot be established, e.g. since the authentication
+	//or the resources have been refused.
+	PeeringError PeeringPhase = "Error"
+)
+
+//String returns the name of the PeeringPhase, "None" for PeeringNone.
+func (p PeeringPhase) String() string {
+	if p == PeeringNone {
+		return "None"
+	}
+	return string(p)
+}
+
+//outgoingPeeringPhase returns the phase of the outgoing peering towards the peer represented by a ForeignCluster.
+func outgoingPeeringPhase(fc *discovery.ForeignCluster) PeeringPhase {
+	out := fc.Status.Outgoing
+	established := out.Joined && out.AdvertisementStatus == sharing.AdvertisementAccepted
+	switch {
+	case out.Joined && (!fc.Spec.Join || fc.DeletionTimestamp != nil):
+		return PeeringDisconnecting
+	case established:
+		return PeeringEstablished
+	case !fc.Spec.Join:
+		return PeeringNone
+	case fc.Status.AuthStatus == discovery2.AuthStatusRefused ||
+		fc.Status.AuthStatus == discovery2.AuthStatusEmptyRefused ||
+		out.AdvertisementStatus == sharing.AdvertisementRefused:
+		return PeeringError
+	case fc.Status.AuthStatus != discovery2.AuthStatusAccepted:
+		return PeeringAuthenticating
+	default:
+		return PeeringPending
+	}
+}
+
+//incomingPeeringPhase returns the phase of the incoming peering from the peer represented by a ForeignCluster.
+func incomingPeeringPhase(fc *discovery.ForeignCluster) PeeringPhase {
+	in := fc.Status.Incoming
+	requested := in.Joined || in.PeeringRequest != nil
+	switch {
+	case !requested:
+		return PeeringNone
+	case in.Joined && fc.DeletionTimestamp != nil:
+		return PeeringDisconnecting
+	case in.AdvertisementStatus == sharing.AdvertisementRefused:
+		return PeeringError
+	case in.Joined && in.AdvertisementStatus == sharing.AdvertisementAccepted:
+		return PeeringEstablished
+	default:
+		return PeeringPending
+	}
+}
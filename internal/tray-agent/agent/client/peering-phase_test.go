@@ -0,0 +1,43 @@
+package client
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/test"
+	sharing "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	discovery2 "github.com/liqotech/liqo/pkg/discovery"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"testing"
+)
+
+func TestPeeringPhase(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	fc := test.CreateForeignCluster("phase-fc", "remote")
+	assert.Equal(t, PeeringNone, outgoingPeeringPhase(fc))
+	assert.Equal(t, PeeringNone, incomingPeeringPhase(fc))
+	assert.Equal(t, "None", PeeringNone.String())
+	//outgoing peering
+	fc.Spec.Join = true
+	assert.Equal(t, PeeringAuthenticating, outgoingPeeringPhase(fc))
+	fc.Status.AuthStatus = discovery2.AuthStatusRefused
+	assert.Equal(t, PeeringError, outgoingPeeringPhase(fc), "refused authentication not detected")
+	fc.Status.AuthStatus = discovery2.AuthStatusAccepted
+	assert.Equal(t, PeeringPending, outgoingPeeringPhase(fc))
+	fc.Status.Outgoing.Joined = true
+	fc.Status.Outgoing.AdvertisementStatus = sharing.AdvertisementAccepted
+	assert.Equal(t, PeeringEstablished, outgoingPeeringPhase(fc))
+	fc.Spec.Join = false
+	assert.Equal(t, PeeringDisconnecting, outgoingPeeringPhase(fc))
+	//incoming peering
+	fc.Status.Incoming.PeeringRequest = &corev1.ObjectReference{Name: "phase-fc"}
+	assert.Equal(t, PeeringPending, incomingPeeringPhase(fc))
+	fc.Status.Incoming.AdvertisementStatus = sharing.AdvertisementRefused
+	assert.Equal(t, PeeringError, incomingPeeringPhase(fc))
+	fc.Status.Incoming.Joined = true
+	fc.Status.Incoming.AdvertisementStatus = sharing.AdvertisementAccepted
+	assert.Equal(t, PeeringEstablished, incomingPeeringPhase(fc))
+	data := &NotifyDataForeignCluster{}
+	data.loadPeeringInfo(GetAgentController(), fc)
+	assert.Equal(t, PeeringDisconnecting, data.OutPeering.Phase)
+	assert.Equal(t, PeeringEstablished, data.InPeering.Phase)
+}
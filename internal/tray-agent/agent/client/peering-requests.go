@@ -0,0 +1,111 @@
+package client
+
+import (
+	"errors"
+	discovery "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sort"
+	"time"
+)
+
+/*This file contains the approval of the incoming peering requests. Liqo establishes the incoming peerings on its own,
+so the decision of the user is recorded on the ForeignCluster with the PeeringApprovalAnnotation: an accepted request
+is left in place, while a rejected one is also deleted, tearing down the incoming peering.*/
+
+const (
+	//PeeringApprovalAnnotation is the annotation of a ForeignCluster recording the decision of the user about its
+	//incoming peering request.
+	PeeringApprovalAnnotation = "liqo.io/agent-peering-approval"
+	//PeeringApprovalAccepted is the value of the PeeringApprovalAnnotation for an accepted request.
+	PeeringApprovalAccepted = "accepted"
+	//PeeringApprovalRejected is the value of the PeeringApprovalAnnotation for a rejected request.
+	PeeringApprovalRejected = "rejected"
+)
+
+//PeeringRequest describes an incoming peering request awaiting the decision of the user.
+type PeeringRequest struct {
+	//ForeignCluster is the name of the ForeignCluster CR of the requesting peer.
+	ForeignCluster string
+	ClusterID      string
+	ClusterName    string
+	//Received is the creation time of the ForeignCluster.
+	Received time.Time
+}
+
+//PendingPeeringRequests returns the incoming peering requests not accepted nor rejected yet, the oldest first.
+func (ctrl *AgentController) PendingPeeringRequests() []PeeringRequest {
+	var requests []PeeringRequest
+	for _, fc := range ctrl.ForeignClusters().List() {
+		if fc.Status.Incoming.PeeringRequest == nil || fc.Spec.ClusterIdentity.ClusterID == "" {
+			continue
+		}
+		if _, decided := fc.Annotations[PeeringApprovalAnnotation]; decided {
+			continue
+		}
+		requests = append(requests, PeeringRequest{
+			ForeignCluster: fc.Name,
+			ClusterID:      fc.Spec.ClusterIdentity.ClusterID,
+			ClusterName:    fc.Spec.ClusterIdentity.ClusterName,
+			Received:       fc.CreationTimestamp.Time,
+		})
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if !requests[i].Received.Equal(requests[j].Received) {
+			return requests[i].Received.Before(requests[j].Received)
+		}
+		return requests[i].ForeignCluster < requests[j].ForeignCluster
+	})
+	return requests
+}
+
+//AcceptPeeringRequest records on a ForeignCluster the approval of its incoming peering request.
+func (ctrl *AgentController) AcceptPeeringRequest(foreignCluster string) error {
+	_, err := ctrl.decidePeeringRequest(foreignCluster, PeeringApprovalAccepted)
+	return err
+}
+
+//RejectPeeringRequest records on a ForeignCluster the rejection of its incoming peering request, deleting the
+//PeeringRequest in order to tear down the incoming peering.
+func (ctrl *AgentController) RejectPeeringRequest(foreignCluster string) error {
+	request, err := ctrl.decidePeeringRequest(foreignCluster, PeeringApprovalRejected)
+	if err != nil || request == "" {
+		return err
+	}
+	return ctrl.deletePeeringRequest(request)
+}
+
+//deletePeeringRequest deletes a PeeringRequest, tearing down the incoming peering it established.
+func (ctrl *AgentController) deletePeeringRequest(request string) error {
+	fcCtrl := ctrl.Controller(CRForeignCluster)
+	err := fcCtrl.Resource("peeringrequests").Delete(request, metav1.DeleteOptions{})
+	//the request may have already been withdrawn by the peer
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return classifyResourceError(ctrl.discoveryClient(), discovery.GroupVersion, "delete PeeringRequest", err)
+}
+
+//decidePeeringRequest sets the PeeringApprovalAnnotation of a ForeignCluster, returning the name of its
+//PeeringRequest.
+func (ctrl *AgentController) decidePeeringRequest(foreignCluster string, decision string) (string, error) {
+	fcCtrl := ctrl.Controller(CRForeignCluster)
+	cached, exist := ctrl.ForeignClusters().Get(foreignCluster)
+	if !exist {
+		return "", errors.New("no such ForeignCluster found")
+	}
+	//the cached object is shared
+	fc := cached.DeepCopy()
+	if fc.Annotations == nil {
+		fc.Annotations = make(map[string]string)
+	}
+	fc.Annotations[PeeringApprovalAnnotation] = decision
+	_, err := fcCtrl.Resource(string(CRForeignCluster)).Update(foreignCluster, fc, metav1.UpdateOptions{})
+	if err != nil {
+		return "", classifyResourceError(ctrl.discoveryClient(), discovery.GroupVersion, "update ForeignCluster", err)
+	}
+	if ref := fc.Status.Incoming.PeeringRequest; ref != nil {
+		return ref.Name, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/test"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func TestPeeringRequests(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	fcCtrl := ctrl.Controller(CRForeignCluster)
+	now := time.Now()
+	for n, id := range []string{"req-new", "req-old", "req-none"} {
+		fc := test.CreateForeignCluster(id, id+"-name")
+		fc.CreationTimestamp = metav1.NewTime(now.Add(-time.Duration(n) * time.Minute))
+		if id != "req-none" {
+			fc.Status.Incoming.PeeringRequest = &corev1.ObjectReference{Name: "pr-" + id}
+		}
+		assert.NoError(t, fcCtrl.Store.Add(fc))
+	}
+	requests := ctrl.PendingPeeringRequests()
+	if assert.Len(t, requests, 2, "peers without a request listed") {
+		assert.Equal(t, "req-old", requests[0].ForeignCluster, "requests not sorted by age")
+		assert.Equal(t, "req-old-name", requests[0].ClusterName)
+		assert.Equal(t, "req-new", requests[1].ForeignCluster)
+	}
+	assert.NoError(t, ctrl.AcceptPeeringRequest("req-old"))
+	fc, _ := ctrl.ForeignClusters().Get("req-old")
+	assert.Equal(t, PeeringApprovalAccepted, fc.Annotations[PeeringApprovalAnnotation])
+	//the PeeringRequest of the rejected peer is not found, as if already withdrawn
+	assert.NoError(t, ctrl.RejectPeeringRequest("req-new"))
+	fc, _ = ctrl.ForeignClusters().Get("req-new")
+	assert.Equal(t, PeeringApprovalRejected, fc.Annotations[PeeringApprovalAnnotation])
+	assert.Empty(t, ctrl.PendingPeeringRequests(), "decided requests still pending")
+	assert.Error(t, ctrl.AcceptPeeringRequest("missing"))
+}
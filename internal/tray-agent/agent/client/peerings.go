@@ -10,15 +10,13 @@ import (
 //a peer (start = true) or to stop it if already active.
 func (ctrl *AgentController) StartStopOutPeering(foreignCluster string, start bool) error {
 	fcCtrl := ctrl.Controller(CRForeignCluster)
-	obj, exist, err := fcCtrl.Store.GetByKey(foreignCluster)
-	if err != nil {
-		return err
-	}
+	cached, exist := ctrl.ForeignClusters().Get(foreignCluster)
 	if !exist {
 		return errors.New("no such ForeignCluster found")
 	}
-	fc := obj.(*discovery.ForeignCluster)
+	//the cached object is shared
+	fc := cached.DeepCopy()
 	fc.Spec.Join = start
-	_, err = fcCtrl.Resource(string(CRForeignCluster)).Update(foreignCluster, fc, metav1.UpdateOptions{})
-	return err
+	_, err := fcCtrl.Resource(string(CRForeignCluster)).Update(foreignCluster, fc, metav1.UpdateOptions{})
+	return classifyResourceError(ctrl.discoveryClient(), discovery.GroupVersion, "update ForeignCluster", err)
 }
@@ -0,0 +1,304 @@
+package client
+
+import (
+	"context"
+	authv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*This file contains the polling fallback of the caches. In restricted environments the user may be allowed to list
+the resources watched by the Agent, but not to watch them: the informers of those resources would fail forever,
+leaving the status stale.
+
+The watch permissions are checked (with a SelfSubjectAccessReview) when connecting to the cluster. The informers of
+the resources that cannot be watched are fed by a pollingListWatch instead, which lists them periodically and
+turns the differences into watch events. The polling interval adapts to the activity of the resources: it starts from
+the InitialDelay of the polling BackoffPolicy and grows exponentially up to its MaxDelay while nothing changes,
+restarting from the InitialDelay at each change.*/
+
+//DefaultPollingPolicy is the BackoffPolicy of the polling intervals used when no setting is provided in the
+//LocalConfig.
+var DefaultPollingPolicy = BackoffPolicy{
+	InitialDelay: 5 * time.Second,
+	Multiplier:   2,
+	MaxDelay:     2 * time.Minute,
+	Jitter:       0.1,
+	MaxAttempts:  1,
+}
+
+//watchedResource is a kind of resources watched by the caches of the AgentController.
+type watchedResource struct {
+	Group    string
+	Resource string
+	//Namespace is the namespace the resources are watched in, empty for all the namespaces.
+	Namespace string
+}
+
+//String returns a human readable description of the watchedResource, e.g. "pods (liqo)".
+func (r watchedResource) String() string {
+	str := r.Resource
+	if r.Group != "" {
+		str += "." + r.Group
+	}
+	if r.Namespace != "" {
+		str += " (" + r.Namespace + ")"
+	}
+	return str
+}
+
+//listFunc lists the resources of a kind.
+type listFunc func(options metav1.ListOptions) (runtime.Object, error)
+
+//coreResource is a standard kubernetes resource watched by the coreCache.
+type coreResource struct {
+	watchedResource
+	//liqo specifies whether the resources are watched in the Liqo namespace.
+	liqo bool
+	//object is an empty object of the resource type.
+	object runtime.Object
+	//list returns the function listing the resources in a namespace.
+	list func(client kubernetes.Interface, namespace string) listFunc
+}
+
+//watchedResources returns the resources watched by the caches of the AgentController, with the ones watched in
+//the Liqo namespace (see coreResources) set in liqoNamespace.
+func watchedResources(liqoNamespace string) []watchedResource {
+	var resources []watchedResource
+	for _, cr := range customResources {
+		resources = append(resources, watchedResource{Group: customResourceGroup(cr), Resource: string(cr)})
+	}
+	for _, r := range coreResources {
+		if r.liqo {
+			r.Namespace = liqoNamespace
+		}
+		resources = append(resources, r.watchedResource)
+	}
+	return resources
+}
+
+//checkWatchPermissions checks (as an RBAC pre-flight) which resources watched by the caches the user is not allowed
+//to watch: their caches are then fed by polling. It must be called before starting the caches.
+func (ctrl *AgentController) checkWatchPermissions(ctx context.Context) {
+	if ctrl.mocked {
+		return
+	}
+	conf, _ := GetLocalConfig()
+	denied := make(map[watchedResource]bool)
+	for _, r := range watchedResources(conf.GetLiqoNamespace()) {
+		if allowed, err := canWatch(ctx, ctrl.kube(), r); err == nil && !allowed {
+			denied[r] = true
+		}
+	}
+	ctrl.pollingMutex.Lock()
+	ctrl.watchDenied = denied
+	ctrl.pollingMutex.Unlock()
+}
+
+//canWatch returns whether the user is allowed to watch a resource. In case of error (e.g. the authorization API is
+//not available), the resource is expected to be watched as usual.
+func canWatch(ctx context.Context, client kubernetes.Interface, r watchedResource) (bool, error) {
+	review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Verb:      "watch",
+				Group:     r.Group,
+				Resource:  r.Resource,
+				Namespace: r.Namespace,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return true, err
+	}
+	return review.Status.Allowed, nil
+}
+
+//polled returns the BackoffPolicy of the polling of a resource that cannot be watched, nil if it is watched.
+func (ctrl *AgentController) polled(r watchedResource) *BackoffPolicy {
+	ctrl.pollingMutex.RLock()
+	denied := ctrl.watchDenied[r]
+	ctrl.pollingMutex.RUnlock()
+	if !denied {
+		return nil
+	}
+	conf, _ := GetLocalConfig()
+	policy := conf.GetPollingPolicy()
+	return &policy
+}
+
+//PolledResources returns the sorted descriptions of the resources whose caches are fed by polling, since the user
+//is not allowed to watch them.
+func (ctrl *AgentController) PolledResources() []string {
+	ctrl.pollingMutex.RLock()
+	defer ctrl.pollingMutex.RUnlock()
+	resources := make([]string, 0, len(ctrl.watchDenied))
+	for r := range ctrl.watchDenied {
+		resources = append(resources, r.String())
+	}
+	sort.Strings(resources)
+	return resources
+}
+
+//usePollingInformers registers in factory the polled informers of the coreResources that cannot be watched: the
+//typed informers of factory then return them.
+func (ctrl *AgentController) usePollingInformers(factory informers.SharedInformerFactory, namespace string, liqo bool) {
+	for _, r := range coreResources {
+		if r.liqo != liqo {
+			continue
+		}
+		wr := r.watchedResource
+		wr.Namespace = namespace
+		policy := ctrl.polled(wr)
+		if policy == nil {
+			continue
+		}
+		lw := newPollingListWatch(r.list(ctrl.kube(), namespace), *policy)
+		object := r.object
+		factory.InformerFor(object, func(_ kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+			return cache.NewSharedIndexInformer(lw, object, resync,
+				cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		})
+	}
+}
+
+//pollingListWatch is a cache.ListerWatcher for the resources that cannot be watched: its watches list the
+//resources at adaptive intervals (see BackoffPolicy), delivering their differences as watch events.
+type pollingListWatch struct {
+	list   listFunc
+	policy BackoffPolicy
+	//mutex protects known.
+	mutex sync.Mutex
+	//known contains the resources returned by the last listing, indexed by key.
+	known map[string]runtime.Object
+}
+
+//newPollingListWatch returns a pollingListWatch of the resources listed by list, polled following policy.
+func newPollingListWatch(list listFunc, policy BackoffPolicy) *pollingListWatch {
+	return &pollingListWatch{list: list, policy: policy, known: make(map[string]runtime.Object)}
+}
+
+//List lists the resources, recording them as the starting point of the next watch.
+func (lw *pollingListWatch) List(options metav1.ListOptions) (runtime.Object, error) {
+	list, err := lw.list(options)
+	if err != nil {
+		return nil, err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+	lw.mutex.Lock()
+	defer lw.mutex.Unlock()
+	lw.known = make(map[string]runtime.Object, len(items))
+	for _, item := range items {
+		if key, err := cache.MetaNamespaceKeyFunc(item); err == nil {
+			lw.known[key] = item
+		}
+	}
+	return list, nil
+}
+
+//Watch returns a watch.Interface delivering the changes found by the periodic listings of the resources.
+func (lw *pollingListWatch) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	w := &pollingWatch{result: make(chan watch.Event), stop: make(chan struct{})}
+	go lw.poll(w)
+	return w, nil
+}
+
+//poll lists the resources until w is stopped. The interval between the listings grows while nothing changes.
+func (lw *pollingListWatch) poll(w *pollingWatch) {
+	defer close(w.result)
+	backoff := lw.policy.NewBackoff()
+	for {
+		select {
+		case <-time.After(backoff.Next()):
+		case <-w.stop:
+			return
+		}
+		list, err := lw.list(metav1.ListOptions{})
+		if err != nil {
+			continue
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			continue
+		}
+		events := lw.diff(items)
+		if len(events) > 0 {
+			backoff.Reset()
+		}
+		for _, e := range events {
+			select {
+			case w.result <- e:
+			case <-w.stop:
+				return
+			}
+		}
+	}
+}
+
+//diff records the listed resources, returning the watch events of their differences with the previous listing.
+func (lw *pollingListWatch) diff(items []runtime.Object) []watch.Event {
+	lw.mutex.Lock()
+	defer lw.mutex.Unlock()
+	var events []watch.Event
+	current := make(map[string]runtime.Object, len(items))
+	for _, item := range items {
+		key, err := cache.MetaNamespaceKeyFunc(item)
+		if err != nil {
+			continue
+		}
+		current[key] = item
+		old, present := lw.known[key]
+		switch {
+		case !present:
+			events = append(events, watch.Event{Type: watch.Added, Object: item})
+		case resourceVersion(old) != resourceVersion(item):
+			events = append(events, watch.Event{Type: watch.Modified, Object: item})
+		}
+	}
+	for key, old := range lw.known {
+		if _, present := current[key]; !present {
+			events = append(events, watch.Event{Type: watch.Deleted, Object: old})
+		}
+	}
+	lw.known = current
+	return events
+}
+
+//resourceVersion returns the ResourceVersion of a resource.
+func resourceVersion(obj runtime.Object) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return accessor.GetResourceVersion()
+}
+
+//pollingWatch is the watch.Interface returned by a pollingListWatch.
+type pollingWatch struct {
+	result chan watch.Event
+	stop   chan struct{}
+	once   sync.Once
+}
+
+//Stop stops the polling.
+func (w *pollingWatch) Stop() {
+	w.once.Do(func() {
+		close(w.stop)
+	})
+}
+
+//ResultChan returns the channel delivering the changes found by the polling.
+func (w *pollingWatch) ResultChan() <-chan watch.Event {
+	return w.result
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"testing"
+	"time"
+)
+
+//testPollingPolicy is a BackoffPolicy with short polling intervals.
+var testPollingPolicy = BackoffPolicy{InitialDelay: 10 * time.Millisecond, Multiplier: 2,
+	MaxDelay: 40 * time.Millisecond, Jitter: 0.1, MaxAttempts: 1}
+
+func TestPollingListWatch(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	pods := kubeClient.CoreV1().Pods("ns")
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns", ResourceVersion: "1"}}
+	_, err := pods.Create(context.TODO(), pod, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	lw := newPollingListWatch(listPods(kubeClient, ""), testPollingPolicy)
+	list, err := lw.List(metav1.ListOptions{})
+	if assert.NoError(t, err) {
+		assert.Len(t, list.(*corev1.PodList).Items, 1)
+	}
+	w, err := lw.Watch(metav1.ListOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer w.Stop()
+	next := func() watch.Event {
+		select {
+		case e := <-w.ResultChan():
+			return e
+		case <-time.After(time.Second):
+			return watch.Event{}
+		}
+	}
+	//the changes are delivered as watch events
+	pod2 := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p2", Namespace: "ns", ResourceVersion: "1"}}
+	_, err = pods.Create(context.TODO(), pod2, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	e := next()
+	if assert.Equal(t, watch.Added, e.Type) {
+		assert.Equal(t, "p2", e.Object.(*corev1.Pod).Name)
+	}
+	pod.ResourceVersion = "2"
+	_, err = pods.Update(context.TODO(), pod, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	e = next()
+	if assert.Equal(t, watch.Modified, e.Type) {
+		assert.Equal(t, "p1", e.Object.(*corev1.Pod).Name)
+	}
+	assert.NoError(t, pods.Delete(context.TODO(), "p2", metav1.DeleteOptions{}))
+	e = next()
+	if assert.Equal(t, watch.Deleted, e.Type) {
+		assert.Equal(t, "p2", e.Object.(*corev1.Pod).Name)
+	}
+	//the watch is closed once stopped
+	w.Stop()
+	w.Stop()
+	assert.Eventually(t, func() bool {
+		_, open := <-w.ResultChan()
+		return !open
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCanWatch(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (
+		bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authv1.SelfSubjectAccessReview)
+		review.Status.Allowed = review.Spec.ResourceAttributes.Resource != "pods"
+		return true, review, nil
+	})
+	allowed, err := canWatch(context.TODO(), kubeClient, watchedResource{Resource: "nodes"})
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, err = canWatch(context.TODO(), kubeClient, watchedResource{Resource: "pods", Namespace: "liqo"})
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	//the resources are watched if the permissions cannot be checked
+	kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (
+		bool, runtime.Object, error) {
+		return true, nil, errors.New("not available")
+	})
+	allowed, err = canWatch(context.TODO(), kubeClient, watchedResource{Resource: "pods"})
+	assert.Error(t, err)
+	assert.True(t, allowed)
+}
+
+func TestPollingCoreCache(t *testing.T) {
+	conf := NewLocalConfig()
+	conf.update(func(local *LocalConfig) {
+		local.Polling = &BackoffPolicy{InitialDelay: 10 * time.Millisecond, MaxDelay: 40 * time.Millisecond}
+	})
+	defer NewLocalConfig()
+	assert.Equal(t, 10*time.Millisecond, conf.GetPollingPolicy().InitialDelay)
+	assert.Equal(t, DefaultPollingPolicy.Multiplier, conf.GetPollingPolicy().Multiplier)
+	kubeClient := fake.NewSimpleClientset()
+	//the pods cannot be watched
+	kubeClient.PrependWatchReactor("pods", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		return true, nil, errors.New("forbidden")
+	})
+	ctrl := &AgentController{
+		kubeClient:  kubeClient,
+		watchDenied: map[watchedResource]bool{{Resource: "pods"}: true},
+	}
+	assert.Equal(t, []string{"pods"}, ctrl.PolledResources())
+	ctrl.startCoreCache()
+	defer ctrl.stopCoreCache()
+	informer := ctrl.coreCache.factory.Core().V1().Pods().Informer()
+	assert.True(t, cache.WaitForCacheSync(ctrl.coreCache.stop, informer.HasSynced))
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns"}}
+	_, err := kubeClient.CoreV1().Pods("ns").Create(context.TODO(), pod, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		_, exists, _ := informer.GetStore().GetByKey("ns/p1")
+		return exists
+	}, time.Second, 10*time.Millisecond, "polled pod not cached")
+}
@@ -0,0 +1,213 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	//DefaultChartRepository is the default helm repository providing the Liqo chart.
+	DefaultChartRepository = "https://helm.liqo.io"
+	//LiqoChartName is the name of the Liqo helm chart.
+	LiqoChartName = "liqo"
+	//helmReleaseSelector selects the secrets storing the deployed revisions of the helm releases.
+	helmReleaseSelector = "owner=helm,status=deployed"
+	//chartIndexTimeout is the timeout for the retrieval of the chart repository index.
+	chartIndexTimeout = 30 * time.Second
+)
+
+//LiqoRelease describes the helm release of Liqo installed in the cluster.
+type LiqoRelease struct {
+	//Name is the name of the helm release.
+	Name      string
+	Namespace string
+	//Revision is the revision number of the deployed release.
+	Revision     int
+	ChartVersion string
+	AppVersion   string
+}
+
+//helmRelease maps the fields of interest of a release stored by helm.
+type helmRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Chart     struct {
+		Metadata struct {
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			AppVersion string `json:"appVersion"`
+		} `json:"metadata"`
+	} `json:"chart"`
+}
+
+//InstalledLiqoRelease returns the deployed helm release of the Liqo chart in the Liqo namespace.
+func (ctrl *AgentController) InstalledLiqoRelease() (*LiqoRelease, error) {
+	if ctrl.kube() == nil {
+		return nil, newError(ErrNotConnected, "get Liqo release", nil)
+	}
+	conf, _ := GetLocalConfig()
+	namespace := conf.GetLiqoNamespace()
+	secrets, err := ctrl.kube().CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: helmReleaseSelector,
+	})
+	if err != nil {
+		return nil, ClassifyError("list helm releases", err)
+	}
+	var installed *LiqoRelease
+	for i := range secrets.Items {
+		release, err := decodeHelmRelease(secrets.Items[i].Data["release"])
+		if err != nil || !strings.EqualFold(release.chartName, LiqoChartName) {
+			continue
+		}
+		if installed == nil || release.Revision > installed.Revision {
+			installed = &release.LiqoRelease
+		}
+	}
+	if installed == nil {
+		return nil, fmt.Errorf("no helm release of Liqo found in namespace '%s'", namespace)
+	}
+	return installed, nil
+}
+
+//decodedRelease is a LiqoRelease decoded from a helm release, with the name of its chart.
+type decodedRelease struct {
+	LiqoRelease
+	chartName string
+}
+
+//decodeHelmRelease decodes the content of a helm release secret, stored as a gzipped JSON document
+//encoded in base64.
+func decodeHelmRelease(data []byte) (*decodedRelease, error) {
+	compressed, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, err
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	var hr helmRelease
+	if err = json.NewDecoder(reader).Decode(&hr); err != nil {
+		return nil, err
+	}
+	return &decodedRelease{
+		LiqoRelease: LiqoRelease{
+			Name:         hr.Name,
+			Namespace:    hr.Namespace,
+			Revision:     hr.Version,
+			ChartVersion: hr.Chart.Metadata.Version,
+			AppVersion:   hr.Chart.Metadata.AppVersion,
+		},
+		chartName: hr.Chart.Metadata.Name,
+	}, nil
+}
+
+//chartIndex maps the fields of interest of the index of a helm chart repository.
+type chartIndex struct {
+	Entries map[string][]struct {
+		Version string `yaml:"version"`
+	} `yaml:"entries"`
+}
+
+//LatestChartVersion returns the latest stable version of the Liqo chart available in a helm chart repository.
+func LatestChartVersion(repository string) (string, error) {
+	httpClient := &http.Client{Timeout: chartIndexTimeout}
+	resp, err := httpClient.Get(strings.TrimSuffix(repository, "/") + "/index.yaml")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("chart repository replied with status %d", resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return latestIndexVersion(data)
+}
+
+//latestIndexVersion returns the latest stable version of the Liqo chart listed in a chart repository index.
+func latestIndexVersion(data []byte) (string, error) {
+	var index chartIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return "", err
+	}
+	latest := ""
+	for _, entry := range index.Entries[LiqoChartName] {
+		//pre-releases are not proposed for upgrades
+		if strings.Contains(entry.Version, "-") {
+			continue
+		}
+		if latest == "" || CompareVersions(entry.Version, latest) > 0 {
+			latest = entry.Version
+		}
+	}
+	if latest == "" {
+		return "", errors.New("no Liqo chart found in the repository")
+	}
+	return latest, nil
+}
+
+//CompareVersions compares two versions in the (v)MAJOR.MINOR.PATCH(-PRERELEASE) format, returning a positive
+//number if a is newer than b, a negative one if it is older and 0 if they are equal. Missing numbers are
+//considered 0, while a pre-release is older than the corresponding release.
+func CompareVersions(a string, b string) int {
+	aNum, aPre := splitVersion(a)
+	bNum, bPre := splitVersion(b)
+	for i := 0; i < len(aNum) || i < len(bNum); i++ {
+		var x, y int
+		if i < len(aNum) {
+			x = aNum[i]
+		}
+		if i < len(bNum) {
+			y = bNum[i]
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	default:
+		return strings.Compare(aPre, bPre)
+	}
+}
+
+//splitVersion splits a version into its numeric components and its pre-release suffix.
+func splitVersion(v string) (numbers []int, preRelease string) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if index := strings.IndexAny(v, "-+"); index >= 0 {
+		if v[index] == '-' {
+			preRelease = v[index+1:]
+			if plus := strings.Index(preRelease, "+"); plus >= 0 {
+				preRelease = preRelease[:plus]
+			}
+		}
+		v = v[:index]
+	}
+	for _, n := range strings.Split(v, ".") {
+		num, _ := strconv.Atoi(n)
+		numbers = append(numbers, num)
+	}
+	return numbers, preRelease
+}
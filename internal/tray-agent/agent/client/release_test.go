@@ -0,0 +1,76 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+//testHelmRelease returns the content of a helm release secret.
+func testHelmRelease(t *testing.T, chart string, version string, revision string) []byte {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	_, err := w.Write([]byte(`{"name":"liqo","namespace":"liqo","version":` + revision +
+		`,"chart":{"metadata":{"name":"` + chart + `","version":"` + version + `","appVersion":"` + version + `"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, w.Close())
+	return []byte(base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+func TestInstalledLiqoRelease(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	_, err := ctrl.InstalledLiqoRelease()
+	assert.Error(t, err, "Liqo release found in an empty cluster")
+	secrets := ctrl.kubeClient.CoreV1().Secrets(DefaultLiqoNamespace)
+	for name, data := range map[string][]byte{
+		"sh.helm.release.v1.liqo.v1":  testHelmRelease(t, "liqo", "0.1.0", "1"),
+		"sh.helm.release.v1.liqo.v2":  testHelmRelease(t, "liqo", "0.2.0", "2"),
+		"sh.helm.release.v1.other.v3": testHelmRelease(t, "other", "1.0.0", "3"),
+	} {
+		_, err = secrets.Create(context.TODO(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"owner": "helm", "status": "deployed"}},
+			Data:       map[string][]byte{"release": data},
+		}, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	release, err := ctrl.InstalledLiqoRelease()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "0.2.0", release.ChartVersion, "wrong Liqo release selected")
+		assert.Equal(t, 2, release.Revision)
+	}
+}
+
+func TestLatestIndexVersion(t *testing.T) {
+	index := `
+apiVersion: v1
+entries:
+  liqo:
+  - version: v0.2.0
+  - version: v0.10.1
+  - version: v0.11.0-rc1
+  other:
+  - version: v9.0.0
+`
+	latest, err := latestIndexVersion([]byte(index))
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.10.1", latest, "wrong latest version")
+	_, err = latestIndexVersion([]byte("entries: {}"))
+	assert.Error(t, err)
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.True(t, CompareVersions("v0.10.0", "v0.9.9") > 0)
+	assert.True(t, CompareVersions("0.1", "v0.1.0") == 0)
+	assert.True(t, CompareVersions("v1.0.0-rc1", "v1.0.0") < 0)
+	assert.True(t, CompareVersions("v1.0.0-rc2", "v1.0.0-rc1") > 0)
+	assert.True(t, CompareVersions("v1.0.0+build", "v1.0.0") == 0)
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	clusterConfig "github.com/liqotech/liqo/apis/config/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//ResourceSharingPercentage returns the percentage of the resources of the home cluster offered to the peers, set in
+//the outgoing Advertisement configuration of the ClusterConfig.
+func (ctrl *AgentController) ResourceSharingPercentage() (int32, error) {
+	config, err := ctrl.cachedClusterConfig()
+	if err != nil {
+		return 0, err
+	}
+	return config.Spec.AdvertisementConfig.OutgoingConfig.ResourceSharingPercentage, nil
+}
+
+//SetResourceSharingPercentage changes the percentage of the resources of the home cluster offered to the peers,
+//updating the ClusterConfig. The percentage must be between 0 and 100.
+func (ctrl *AgentController) SetResourceSharingPercentage(percentage int32) error {
+	if percentage < 0 || percentage > 100 {
+		return fmt.Errorf("invalid resource sharing percentage %d: it must be between 0 and 100", percentage)
+	}
+	cached, err := ctrl.cachedClusterConfig()
+	if err != nil {
+		return err
+	}
+	//the cached object is shared
+	config := cached.DeepCopy()
+	config.Spec.AdvertisementConfig.OutgoingConfig.ResourceSharingPercentage = percentage
+	_, err = ctrl.Controller(CRClusterConfig).Resource(string(CRClusterConfig)).Update(config.Name, config,
+		metav1.UpdateOptions{})
+	if err != nil {
+		return classifyResourceError(ctrl.discoveryClient(), clusterConfig.GroupVersion, "update ClusterConfig", err)
+	}
+	return nil
+}
+
+//cachedClusterConfig returns the ClusterConfig of the home cluster from the cache.
+func (ctrl *AgentController) cachedClusterConfig() (*clusterConfig.ClusterConfig, error) {
+	if !ctrl.Connected() {
+		return nil, newError(ErrNotConnected, "get ClusterConfig", nil)
+	}
+	configs := ctrl.ClusterConfigs().List()
+	if len(configs) == 0 {
+		return nil, errors.New("no ClusterConfig is present")
+	}
+	return configs[0], nil
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	clusterConfig "github.com/liqotech/liqo/apis/config/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestResourceSharingPercentage(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	_, err := ctrl.ResourceSharingPercentage()
+	assert.Error(t, err, "percentage read without ClusterConfig")
+	config := &clusterConfig.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: "sharing-config"}}
+	config.Spec.AdvertisementConfig.OutgoingConfig.ResourceSharingPercentage = 30
+	assert.NoError(t, ctrl.Controller(CRClusterConfig).Store.Add(config))
+	percentage, err := ctrl.ResourceSharingPercentage()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(30), percentage)
+	assert.NoError(t, ctrl.SetResourceSharingPercentage(75))
+	percentage, _ = ctrl.ResourceSharingPercentage()
+	assert.Equal(t, int32(75), percentage)
+	assert.Equal(t, int32(30), config.Spec.AdvertisementConfig.OutgoingConfig.ResourceSharingPercentage,
+		"cached ClusterConfig modified")
+	assert.Error(t, ctrl.SetResourceSharingPercentage(101))
+}
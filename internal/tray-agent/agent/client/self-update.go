@@ -0,0 +1,314 @@
+package client
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*This file contains the self-update of the Agent: the check of its latest release published on GitHub, and the
+replacement of the running executable with the one of the release. The executable is replaced atomically (i.e.
+the new one is written next to it and renamed over it), so that the running Agent is not affected until it is
+restarted (see RequestRestart).
+The release asset is installed only if its SHA-256 digest matches the one published with the release in the
+AgentChecksumAssetName asset, and all the requests are performed over https. Each release provides an asset per
+supported platform (see AgentAssetName): an Agent running on a platform without asset is not updated.*/
+
+const (
+	//DefaultAgentReleasesURL is the default URL of the GitHub API returning the latest release of the Agent.
+	DefaultAgentReleasesURL = "https://api.github.com/repos/liqotech/liqo-agent/releases/latest"
+	//agentExecutableName is the name of the Agent executable inside AgentAssetName.
+	agentExecutableName = "liqo-agent"
+	//agentReleaseTimeout is the timeout for the retrieval of the latest release.
+	agentReleaseTimeout = 30 * time.Second
+	//agentDownloadTimeout is the timeout for the download of the release asset.
+	agentDownloadTimeout = 10 * time.Minute
+	//maxAgentExecutableSize bounds the size of the executable extracted from the release asset.
+	maxAgentExecutableSize = 512 << 20
+	//maxAgentChecksumSize bounds the size of the AgentChecksumAssetName asset.
+	maxAgentChecksumSize = 4 << 10
+)
+
+//AgentAssetName is the name of the release asset containing the Agent executable for the platform the Agent is
+//running on, a gzipped tar archive, e.g. "liqo-agent-linux-amd64.tar.gz".
+var AgentAssetName = agentAssetName(runtime.GOOS, runtime.GOARCH)
+
+//AgentChecksumAssetName is the name of the release asset containing the SHA-256 digest of AgentAssetName, in the
+//format of the sha256sum utility.
+var AgentChecksumAssetName = AgentAssetName + ".sha256"
+
+//agentAssetName returns the name of the release asset containing the Agent executable for a platform.
+func agentAssetName(goos string, goarch string) string {
+	return fmt.Sprintf("liqo-agent-%s-%s.tar.gz", goos, goarch)
+}
+
+//AgentVersion is the version of the running Agent, set at build time (e.g. with
+//-ldflags "-X github.com/liqotech/liqo-agent/internal/tray-agent/agent/client.AgentVersion=v0.3.0").
+//The development builds, with the default value, are never updated.
+var AgentVersion = devAgentVersion
+
+//devAgentVersion is the version of the development builds.
+const devAgentVersion = "dev"
+
+//AgentRelease describes a release of the Agent.
+type AgentRelease struct {
+	//Version is the tag of the release (e.g. "v0.3.0").
+	Version string
+	//PageURL is the web page of the release, describing its changes.
+	PageURL string
+	//AssetURL is the download URL of the AgentAssetName asset. It is empty if the release does not provide it, e.g.
+	//because it is not built for the platform of the running Agent.
+	AssetURL string
+	//ChecksumURL is the download URL of the AgentChecksumAssetName asset. It is empty if the release does not
+	//provide it.
+	ChecksumURL string
+}
+
+//githubRelease maps the fields of interest of a release returned by the GitHub API.
+type githubRelease struct {
+	TagName    string `json:"tag_name"`
+	HTMLURL    string `json:"html_url"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+	Assets     []struct {
+		Name               string `json:"name"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+//NewerThan returns whether the release is newer than a version. The development builds are never outdated.
+func (r *AgentRelease) NewerThan(version string) bool {
+	if version == "" || version == devAgentVersion {
+		return false
+	}
+	return CompareVersions(r.Version, version) > 0
+}
+
+//LatestAgentRelease returns the latest stable release of the Agent, retrieved from the GitHub API at releasesURL.
+func LatestAgentRelease(ctx context.Context, releasesURL string) (*AgentRelease, error) {
+	if err := requireHTTPS(releasesURL); err != nil {
+		return nil, err
+	}
+	httpClient := &http.Client{Timeout: agentReleaseTimeout}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, releasesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("release server replied with status %d", resp.StatusCode)
+	}
+	var gr githubRelease
+	if err = json.NewDecoder(resp.Body).Decode(&gr); err != nil {
+		return nil, err
+	}
+	//pre-releases are not proposed for updates
+	if gr.TagName == "" || gr.Draft || gr.Prerelease {
+		return nil, errors.New("no stable release of the Agent found")
+	}
+	release := &AgentRelease{Version: gr.TagName, PageURL: gr.HTMLURL}
+	for _, asset := range gr.Assets {
+		switch asset.Name {
+		case AgentAssetName:
+			release.AssetURL = asset.BrowserDownloadURL
+		case AgentChecksumAssetName:
+			release.ChecksumURL = asset.BrowserDownloadURL
+		}
+	}
+	return release, nil
+}
+
+//InstallAgentRelease downloads the executable of a release and replaces the one at executable with it. The running
+//Agent keeps running the previous executable until it is restarted. The release asset is verified against the
+//SHA-256 digest published with the release before it is extracted.
+func InstallAgentRelease(ctx context.Context, release *AgentRelease, executable string) error {
+	if release.AssetURL == "" {
+		return fmt.Errorf("release %s does not provide the %s asset: %s/%s is not supported", release.Version,
+			AgentAssetName, runtime.GOOS, runtime.GOARCH)
+	}
+	if release.ChecksumURL == "" {
+		return fmt.Errorf("release %s does not provide the %s asset", release.Version, AgentChecksumAssetName)
+	}
+	ctx, cancel := context.WithTimeout(ctx, agentDownloadTimeout)
+	defer cancel()
+	checksum, err := downloadAgentChecksum(ctx, release.ChecksumURL)
+	if err != nil {
+		return err
+	}
+	//the new executable is written in the same directory, so that it can be renamed over the current one
+	archive, err := ioutil.TempFile(filepath.Dir(executable), "."+filepath.Base(executable)+"-asset-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+	digest := sha256.New()
+	if err = downloadAgentAsset(ctx, release.AssetURL, io.MultiWriter(archive, digest),
+		maxAgentExecutableSize); err != nil {
+		return err
+	}
+	if sum := hex.EncodeToString(digest.Sum(nil)); sum != checksum {
+		return fmt.Errorf("the %s asset of release %s does not match its checksum", AgentAssetName, release.Version)
+	}
+	if _, err = archive.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(executable), "."+filepath.Base(executable)+"-update-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	err = extractAgentExecutable(archive, tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), executable); err != nil {
+		return err
+	}
+	logger.Info("Agent executable updated", "version", release.Version, "path", executable)
+	return nil
+}
+
+//downloadAgentAsset writes to out the release asset at assetURL, failing if it is larger than maxSize bytes.
+func downloadAgentAsset(ctx context.Context, assetURL string, out io.Writer, maxSize int64) error {
+	if err := requireHTTPS(assetURL); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, assetURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("release server replied with status %d", resp.StatusCode)
+	}
+	n, err := io.Copy(out, io.LimitReader(resp.Body, maxSize+1))
+	if err == nil && n > maxSize {
+		err = fmt.Errorf("the release asset at %s is too large", assetURL)
+	}
+	return err
+}
+
+//downloadAgentChecksum returns the lowercase hex SHA-256 digest contained in the AgentChecksumAssetName asset at
+//checksumURL.
+func downloadAgentChecksum(ctx context.Context, checksumURL string) (string, error) {
+	buf := &strings.Builder{}
+	if err := downloadAgentAsset(ctx, checksumURL, buf, maxAgentChecksumSize); err != nil {
+		return "", err
+	}
+	//the format of sha256sum: "<digest>  <file name>"
+	fields := strings.Fields(buf.String())
+	if len(fields) == 0 {
+		return "", fmt.Errorf("the %s asset is empty", AgentChecksumAssetName)
+	}
+	checksum := strings.ToLower(fields[0])
+	if _, err := hex.DecodeString(checksum); err != nil || len(checksum) != 2*sha256.Size {
+		return "", fmt.Errorf("the %s asset does not contain a SHA-256 digest", AgentChecksumAssetName)
+	}
+	return checksum, nil
+}
+
+//requireHTTPS returns an error if rawURL is not an https URL. The releases are only retrieved over https, so that
+//they cannot be tampered with in transit.
+func requireHTTPS(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("refusing to download the Agent release from %s: https is required", rawURL)
+	}
+	return nil
+}
+
+//extractAgentExecutable writes to out the Agent executable contained in a gzipped tar archive.
+func extractAgentExecutable(archive io.Reader, out io.Writer) error {
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return fmt.Errorf("%s not found in the release asset", agentExecutableName)
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg || filepath.Base(header.Name) != agentExecutableName {
+			continue
+		}
+		if header.Size > maxAgentExecutableSize {
+			return fmt.Errorf("%s is too large (%d bytes)", agentExecutableName, header.Size)
+		}
+		_, err = io.Copy(out, io.LimitReader(reader, maxAgentExecutableSize))
+		return err
+	}
+}
+
+//restart specifies whether the Agent has to be started again once it exits, e.g. after an update.
+var restart = struct {
+	requested bool
+	sync.Mutex
+}{}
+
+//RequestRestart requests to start the Agent again once it exits (see RestartIfRequested).
+func RequestRestart() {
+	restart.Lock()
+	defer restart.Unlock()
+	restart.requested = true
+}
+
+//RestartIfRequested starts a new Agent process, with the same arguments of the current one, if requested by
+//RequestRestart. It is meant to be called once the current Agent has exited its main loop.
+func RestartIfRequested() error {
+	restart.Lock()
+	requested := restart.requested
+	restart.Unlock()
+	if !requested {
+		return nil
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//agentAsset returns a gzipped tar archive containing a file.
+func agentAsset(t *testing.T, name string, content []byte) []byte {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)),
+		Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+//agentChecksum returns the content of the AgentChecksumAssetName asset for an asset.
+func agentChecksum(asset []byte) []byte {
+	sum := sha256.Sum256(asset)
+	return []byte(hex.EncodeToString(sum[:]) + "  " + AgentAssetName + "\n")
+}
+
+//useTLSServer makes the http.Client instances with the default transport trust the certificate of server, and
+//returns a function restoring the default transport.
+func useTLSServer(server *httptest.Server) func() {
+	transport := http.DefaultTransport
+	http.DefaultTransport = server.Client().Transport
+	return func() {
+		http.DefaultTransport = transport
+	}
+}
+
+func TestAgentSelfUpdate(t *testing.T) {
+	var latest string
+	asset := agentAsset(t, "liqo-agent", []byte("new agent"))
+	empty := agentAsset(t, "README.md", []byte("readme"))
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases/latest":
+			_, _ = w.Write([]byte(latest))
+		case "/download/" + AgentAssetName:
+			_, _ = w.Write(asset)
+		case "/download/" + AgentChecksumAssetName:
+			_, _ = w.Write(agentChecksum(asset))
+		case "/download/empty.tar.gz":
+			_, _ = w.Write(empty)
+		case "/download/empty.tar.gz.sha256":
+			_, _ = w.Write(agentChecksum(empty))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer useTLSServer(server)()
+	releasesURL := server.URL + "/releases/latest"
+	latest = fmt.Sprintf(`{"tag_name": "v0.3.0", "html_url": "https://example.com/v0.3.0", "assets": [
+		{"name": "other.zip", "browser_download_url": "%[1]s/download/other.zip"},
+		{"name": "%[2]s", "browser_download_url": "%[1]s/download/%[2]s"},
+		{"name": "%[3]s", "browser_download_url": "%[1]s/download/%[3]s"}]}`,
+		server.URL, AgentAssetName, AgentChecksumAssetName)
+	release, err := LatestAgentRelease(context.Background(), releasesURL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "v0.3.0", release.Version)
+	assert.Equal(t, "https://example.com/v0.3.0", release.PageURL)
+	assert.Equal(t, server.URL+"/download/"+AgentAssetName, release.AssetURL)
+	assert.Equal(t, server.URL+"/download/"+AgentChecksumAssetName, release.ChecksumURL)
+	assert.True(t, release.NewerThan("v0.2.9"))
+	assert.False(t, release.NewerThan("v0.3.0"))
+	assert.False(t, release.NewerThan("0.4.0"))
+	assert.False(t, release.NewerThan(devAgentVersion), "development build updated")
+	//a release not built for the platform of the Agent is not installed
+	assert.Equal(t, "liqo-agent-linux-arm64.tar.gz", agentAssetName("linux", "arm64"))
+	other := "liqo-agent-other-arch.tar.gz"
+	latest = fmt.Sprintf(`{"tag_name": "v0.3.0", "assets": [
+		{"name": "%[2]s", "browser_download_url": "%[1]s/download/%[2]s"},
+		{"name": "%[2]s.sha256", "browser_download_url": "%[1]s/download/%[2]s.sha256"}]}`, server.URL, other)
+	unsupported, err := LatestAgentRelease(context.Background(), releasesURL)
+	if assert.NoError(t, err) {
+		assert.Empty(t, unsupported.AssetURL, "asset of another platform selected")
+		assert.Error(t, InstallAgentRelease(context.Background(), unsupported, filepath.Join(os.TempDir(), "none")))
+	}
+	//the pre-releases are not proposed
+	latest = `{"tag_name": "v0.4.0-rc.1", "prerelease": true}`
+	_, err = LatestAgentRelease(context.Background(), releasesURL)
+	assert.Error(t, err)
+	_, err = LatestAgentRelease(context.Background(), server.URL+"/missing")
+	assert.Error(t, err)
+	//the releases are not retrieved over plain http
+	_, err = LatestAgentRelease(context.Background(), "http"+strings.TrimPrefix(releasesURL, "https"))
+	assert.Error(t, err)
+	//the executable is replaced
+	dir, err := ioutil.TempDir("", "liqo-agent-update")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	executable := filepath.Join(dir, "liqo-agent")
+	assert.NoError(t, ioutil.WriteFile(executable, []byte("old agent"), 0755))
+	assert.NoError(t, InstallAgentRelease(context.Background(), release, executable))
+	assert.Equal(t, "new agent", string(mustRead(t, executable)))
+	info, err := os.Stat(executable)
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	}
+	//an asset not matching its checksum, not downloaded over https or without checksum leaves the current
+	//executable in place
+	release.AssetURL = server.URL + "/download/empty.tar.gz"
+	assert.Error(t, InstallAgentRelease(context.Background(), release, executable))
+	release.AssetURL = "http" + strings.TrimPrefix(server.URL, "https") + "/download/" + AgentAssetName
+	assert.Error(t, InstallAgentRelease(context.Background(), release, executable))
+	release.AssetURL, release.ChecksumURL = server.URL+"/download/"+AgentAssetName, ""
+	assert.Error(t, InstallAgentRelease(context.Background(), release, executable))
+	//a release without the executable leaves the current one in place
+	release.AssetURL = server.URL + "/download/empty.tar.gz"
+	release.ChecksumURL = release.AssetURL + ".sha256"
+	assert.Error(t, InstallAgentRelease(context.Background(), release, executable))
+	release.AssetURL = ""
+	assert.Error(t, InstallAgentRelease(context.Background(), release, executable))
+	assert.Equal(t, "new agent", string(mustRead(t, executable)))
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(t, files, 1, "temporary files left")
+}
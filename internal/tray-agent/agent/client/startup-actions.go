@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"strings"
+)
+
+//Types of the actions performed at the Agent startup.
+const (
+	//StartupEnableOffloading enables the offloading of the namespaces listed in StartupActionConfig.Namespaces.
+	StartupEnableOffloading = "enableOffloading"
+	//StartupPeer starts an outgoing peering with the peers listed in StartupActionConfig.Peers, by cluster ID or
+	//authentication service URL.
+	StartupPeer = "peer"
+	//StartupQuietUntil silences the non critical notifications until the StartupActionConfig.Until time of the day.
+	StartupQuietUntil = "quietUntil"
+)
+
+//StartupActionConfig is an action performed automatically when the Agent starts, e.g.
+//	startupActions:
+//	- type: enableOffloading
+//	  namespaces: [ "dev", "ci" ]
+//	- type: peer
+//	  peers: [ "https://10.0.0.1:30443" ]
+//	- type: quietUntil
+//	  until: "09:00"
+type StartupActionConfig struct {
+	//Type is the type of the action (e.g. StartupPeer).
+	Type string `yaml:"type"`
+	//Namespaces contains the namespaces of a StartupEnableOffloading action.
+	Namespaces []string `yaml:"namespaces,omitempty"`
+	//Peers contains the peers of a StartupPeer action.
+	Peers []string `yaml:"peers,omitempty"`
+	//Until is the "HH:MM" time of the day ending a StartupQuietUntil action.
+	Until string `yaml:"until,omitempty"`
+}
+
+//String returns a short description of the action, e.g. "enableOffloading dev, ci".
+func (a StartupActionConfig) String() string {
+	switch a.Type {
+	case StartupEnableOffloading:
+		return fmt.Sprintf("%s %s", a.Type, strings.Join(a.Namespaces, ", "))
+	case StartupPeer:
+		return fmt.Sprintf("%s %s", a.Type, strings.Join(a.Peers, ", "))
+	case StartupQuietUntil:
+		return fmt.Sprintf("%s %s", a.Type, a.Until)
+	default:
+		return a.Type
+	}
+}
+
+//GetStartupActions returns a copy of the 'startupActions' field for the local configuration.
+func (lc *LocalConfiguration) GetStartupActions() []StartupActionConfig {
+	lc.RLock()
+	defer lc.RUnlock()
+	if lc.Content == nil {
+		return nil
+	}
+	return append([]StartupActionConfig(nil), lc.Content.StartupActions...)
+}
+
+//EnableOffloading sets the LabelOffloadingEnabled label on a set of namespaces, enabling their offloading.
+//It returns the names of the namespaces whose offloading has been enabled, stopping at the first failure.
+func (ctrl *AgentController) EnableOffloading(namespaces []string) ([]string, error) {
+	if ctrl.kube() == nil {
+		return nil, newError(ErrNotConnected, "enable offloading", nil)
+	}
+	var enabled []string
+	patch := []byte(fmt.Sprintf(`{"metadata":{"labels":{"%s":"true"}}}`, LabelOffloadingEnabled))
+	for _, ns := range namespaces {
+		_, err := ctrl.kube().CoreV1().Namespaces().Patch(context.TODO(), ns, types.MergePatchType, patch,
+			metav1.PatchOptions{})
+		if err != nil {
+			return enabled, fmt.Errorf("cannot enable the offloading of namespace '%s': %w", ns,
+				ClassifyError("patch namespace", err))
+		}
+		enabled = append(enabled, ns)
+	}
+	return enabled, nil
+}
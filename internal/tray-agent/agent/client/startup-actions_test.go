@@ -0,0 +1,34 @@
+package client
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestEnableOffloading(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	core := ctrl.kubeClient.CoreV1()
+	for _, name := range []string{"dev", "ci"} {
+		_, err := core.Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}},
+			metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	enabled, err := ctrl.EnableOffloading([]string{"dev", "ci"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dev", "ci"}, enabled)
+	ns, err := core.Namespaces().Get(context.TODO(), "ci", metav1.GetOptions{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "true", ns.Labels[LabelOffloadingEnabled], "offloading label not set")
+	}
+	//the actions stop at the first missing namespace
+	enabled, err = ctrl.EnableOffloading([]string{"dev", "missing", "ci"})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"dev"}, enabled)
+	assert.Equal(t, "enableOffloading dev, ci", StartupActionConfig{Type: StartupEnableOffloading,
+		Namespaces: []string{"dev", "ci"}}.String())
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/xdg"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+//StatusCacheFileName is the basename of the file storing the last known status inside the cache directory.
+const StatusCacheFileName = "status_cache.yaml"
+
+//CachedPeer contains the last known state of a peer.
+type CachedPeer struct {
+	ClusterID   string `yaml:"clusterID"`
+	ClusterName string `yaml:"clusterName,omitempty"`
+	//Outgoing specifies whether the outgoing peering was active.
+	Outgoing bool `yaml:"outgoing,omitempty"`
+	//Incoming specifies whether the incoming peering was active.
+	Incoming bool `yaml:"incoming,omitempty"`
+}
+
+//CachedStatus contains the last known status of the cluster, displayed while the Agent is not connected to it.
+type CachedStatus struct {
+	//ClusterName is the name of the cluster the status refers to.
+	ClusterName string `yaml:"clusterName,omitempty"`
+	//Context is the kubeconfig context of the cluster.
+	Context string `yaml:"context,omitempty"`
+	//Peers contains the peers discovered by the cluster, sorted by name.
+	Peers []CachedPeer `yaml:"peers,omitempty"`
+	//SavedAt is the moment the status was observed.
+	SavedAt time.Time `yaml:"savedAt"`
+}
+
+//StatusCache persists the last known CachedStatus on the local file system.
+type StatusCache struct {
+	//path is the path of the file storing the CachedStatus. If empty, the status is kept in memory only.
+	path   string
+	status *CachedStatus
+	sync.RWMutex
+}
+
+//statusCache is the StatusCache singleton.
+var statusCache *StatusCache
+
+//statusCacheOnce protects the statusCache singleton initialization.
+var statusCacheOnce sync.Once
+
+//GetStatusCache returns the StatusCache singleton, persisted in the StatusCacheFileName file inside the xdg.DirCache
+//directory. If the directory is not available, the status is kept in memory only.
+func GetStatusCache() *StatusCache {
+	statusCacheOnce.Do(func() {
+		statusCache = NewStatusCache(xdg.DirCache.File(StatusCacheFileName))
+	})
+	return statusCache
+}
+
+//NewStatusCache returns a StatusCache persisted in path, loading the status previously saved there (if any).
+//An unreadable status is ignored.
+func NewStatusCache(path string) *StatusCache {
+	c := &StatusCache{path: path}
+	if path == "" {
+		return c
+	}
+	if data, err := ioutil.ReadFile(path); err == nil {
+		var status CachedStatus
+		if yaml.Unmarshal(data, &status) == nil && !status.SavedAt.IsZero() {
+			c.status = &status
+		}
+	}
+	return c
+}
+
+//Status returns a copy of the last known CachedStatus, if any.
+func (c *StatusCache) Status() (CachedStatus, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	if c.status == nil {
+		return CachedStatus{}, false
+	}
+	status := *c.status
+	status.Peers = append([]CachedPeer(nil), c.status.Peers...)
+	return status, true
+}
+
+//Save stores a CachedStatus, replacing the previous one.
+func (c *StatusCache) Save(status CachedStatus) error {
+	c.Lock()
+	defer c.Unlock()
+	c.status = &status
+	if c.path == "" {
+		return nil
+	}
+	data, err := yaml.Marshal(&status)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, data, 0600)
+}
+
+//Reset clears the CachedStatus, removing the file storing it.
+func (c *StatusCache) Reset() error {
+	c.Lock()
+	defer c.Unlock()
+	c.status = nil
+	if c.path == "" {
+		return nil
+	}
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
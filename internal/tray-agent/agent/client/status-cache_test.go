@@ -0,0 +1,44 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStatusCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liqo-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cache", StatusCacheFileName)
+	c := NewStatusCache(path)
+	_, present := c.Status()
+	assert.False(t, present, "non empty initial status")
+	saved := CachedStatus{ClusterName: "home", Peers: []CachedPeer{{ClusterID: "cl1", ClusterName: "peer",
+		Outgoing: true}}, SavedAt: time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)}
+	assert.NoError(t, c.Save(saved))
+	//the status is restored from the file
+	restored, present := NewStatusCache(path).Status()
+	if assert.True(t, present) {
+		assert.Equal(t, "home", restored.ClusterName)
+		assert.Equal(t, saved.Peers, restored.Peers)
+		assert.True(t, saved.SavedAt.Equal(restored.SavedAt))
+	}
+	//the returned status is a copy
+	restored, _ = c.Status()
+	restored.Peers[0].ClusterName = "changed"
+	restored, _ = c.Status()
+	assert.Equal(t, "peer", restored.Peers[0].ClusterName)
+	assert.NoError(t, c.Reset())
+	_, present = NewStatusCache(path).Status()
+	assert.False(t, present, "status not removed")
+	//a corrupted file is ignored
+	assert.NoError(t, ioutil.WriteFile(path, []byte("peers: [\n"), 0644))
+	_, present = NewStatusCache(path).Status()
+	assert.False(t, present)
+}
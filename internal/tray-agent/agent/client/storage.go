@@ -0,0 +1,226 @@
+package client
+
+import (
+	"errors"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sort"
+	"strings"
+)
+
+const (
+	//annDefaultStorageClass is the annotation identifying the default StorageClass of a cluster.
+	annDefaultStorageClass = "storageclass.kubernetes.io/is-default-class"
+	//labelVirtualNodeType is the label set by Liqo on the virtual nodes representing its peers.
+	labelVirtualNodeType = "type"
+	//virtualNodeType is the value of the labelVirtualNodeType label for the Liqo virtual nodes.
+	virtualNodeType = "virtual-node"
+	//annVirtualNodeClusterID is the annotation containing the ClusterID of the peer represented by a virtual node.
+	annVirtualNodeClusterID = "cluster-id"
+	//propertyStorageClasses is the property of the Advertisements and ResourceOffers listing, comma separated, the
+	//StorageClasses of the peer.
+	propertyStorageClasses corev1.ResourceName = "storageClasses"
+)
+
+//StorageClassInfo contains the relevant information of a StorageClass of the home cluster.
+type StorageClassInfo struct {
+	Name        string
+	Provisioner string
+	//Default specifies whether this is the default StorageClass of the cluster.
+	Default bool
+}
+
+//VolumeClaimInfo contains the relevant information of a PersistentVolumeClaim of the home cluster.
+type VolumeClaimInfo struct {
+	Namespace    string
+	Name         string
+	StorageClass string
+	//Phase is the literal representation of the claim phase (e.g. Pending, Bound, Lost).
+	Phase string
+	//Capacity is the literal representation of the storage capacity of a bound claim.
+	Capacity string
+	//ClusterIDs contains the ClusterIDs of the peers running pods that mount the claim.
+	ClusterIDs []string
+}
+
+//Bound returns whether the claim is bound to a volume.
+func (vc *VolumeClaimInfo) Bound() bool {
+	return vc.Phase == string(corev1.ClaimBound)
+}
+
+//OffloadingWarning describes a pod offloaded to a peer that cannot satisfy some of its volume claims, since the
+//peer does not offer their StorageClass.
+type OffloadingWarning struct {
+	Namespace string
+	Pod       string
+	//ClusterID is the ClusterID of the peer the pod was offloaded to.
+	ClusterID string
+	//Claims contains the names of the volume claims mounted by the pod whose StorageClass is not offered by the peer.
+	Claims []string
+}
+
+//Key returns an identifier of the OffloadingWarning, unique for each offloaded pod.
+func (w *OffloadingWarning) Key() string {
+	return strings.Join([]string{w.ClusterID, w.Namespace, w.Pod}, "/")
+}
+
+//StorageReport summarizes the storage resources of the home cluster and their usability across peers.
+type StorageReport struct {
+	StorageClasses []*StorageClassInfo
+	Claims         []*VolumeClaimInfo
+	Warnings       []*OffloadingWarning
+}
+
+//StorageReport returns the current StorageReport, built from the content of the AgentController caches.
+func (ctrl *AgentController) StorageReport() (*StorageReport, error) {
+	c := ctrl.coreCache
+	if c == nil || !c.running {
+		return nil, errors.New("storage resources are not watched")
+	}
+	//the report includes all the changes notified so far
+	c.consumeCoalesced(TopicStorageChanged)
+	classes, err := c.factory.Storage().V1().StorageClasses().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	claims, err := c.factory.Core().V1().PersistentVolumeClaims().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	pods, err := c.factory.Core().V1().Pods().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := c.factory.Core().V1().Nodes().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	return newStorageReport(classes, claims, pods, nodes, ctrl.peerStorageClasses()), nil
+}
+
+//peerStorageClasses returns, by ClusterID, the StorageClasses the peers declare in the properties of their
+//Advertisements and ResourceOffers.
+func (ctrl *AgentController) peerStorageClasses() map[string][]string {
+	peerClasses := make(map[string][]string)
+	add := func(clusterID string, properties map[corev1.ResourceName]string) {
+		for _, sc := range strings.Split(properties[propertyStorageClasses], ",") {
+			if sc = strings.TrimSpace(sc); sc != "" {
+				peerClasses[clusterID] = appendUnique(peerClasses[clusterID], sc)
+			}
+		}
+	}
+	for _, adv := range ctrl.Advertisements().List() {
+		add(adv.Spec.ClusterId, adv.Spec.Properties)
+	}
+	for _, offer := range ctrl.ResourceOffers().List() {
+		add(offer.Spec.ClusterId, offer.Spec.Properties)
+	}
+	return peerClasses
+}
+
+//newStorageReport builds a StorageReport from the storage resources of the home cluster, matching the pods
+//scheduled on the Liqo virtual nodes with the claims they mount. A pod is reported if the peer it is offloaded to
+//does not offer the StorageClass of some of its claims, according to peerClasses (the StorageClasses offered by
+//each peer, by ClusterID).
+func newStorageReport(classes []*storagev1.StorageClass, claims []*corev1.PersistentVolumeClaim,
+	pods []*corev1.Pod, nodes []*corev1.Node, peerClasses map[string][]string) *StorageReport {
+	report := &StorageReport{
+		StorageClasses: make([]*StorageClassInfo, 0, len(classes)),
+		Claims:         make([]*VolumeClaimInfo, 0, len(claims)),
+		Warnings:       make([]*OffloadingWarning, 0),
+	}
+	for _, sc := range classes {
+		report.StorageClasses = append(report.StorageClasses, &StorageClassInfo{
+			Name:        sc.Name,
+			Provisioner: sc.Provisioner,
+			Default:     sc.Annotations[annDefaultStorageClass] == "true",
+		})
+	}
+	sort.Slice(report.StorageClasses, func(i, j int) bool {
+		return report.StorageClasses[i].Name < report.StorageClasses[j].Name
+	})
+	claimMap := make(map[string]*VolumeClaimInfo)
+	for _, pvc := range claims {
+		info := &VolumeClaimInfo{
+			Namespace: pvc.Namespace,
+			Name:      pvc.Name,
+			Phase:     string(pvc.Status.Phase),
+		}
+		if pvc.Spec.StorageClassName != nil {
+			info.StorageClass = *pvc.Spec.StorageClassName
+		}
+		if q, present := pvc.Status.Capacity[corev1.ResourceStorage]; present {
+			info.Capacity = q.String()
+		}
+		claimMap[pvc.Namespace+"/"+pvc.Name] = info
+		report.Claims = append(report.Claims, info)
+	}
+	sort.Slice(report.Claims, func(i, j int) bool {
+		if report.Claims[i].Namespace != report.Claims[j].Namespace {
+			return report.Claims[i].Namespace < report.Claims[j].Namespace
+		}
+		return report.Claims[i].Name < report.Claims[j].Name
+	})
+	virtualNodes := make(map[string]string)
+	for _, n := range nodes {
+		if n.Labels[labelVirtualNodeType] == virtualNodeType {
+			virtualNodes[n.Name] = n.Annotations[annVirtualNodeClusterID]
+		}
+	}
+	for _, pod := range pods {
+		clusterID, offloaded := virtualNodes[pod.Spec.NodeName]
+		if !offloaded || !hasVolumeClaims(pod) {
+			continue
+		}
+		w := &OffloadingWarning{
+			Namespace: pod.Namespace,
+			Pod:       pod.Name,
+			ClusterID: clusterID,
+		}
+		for _, v := range pod.Spec.Volumes {
+			if v.PersistentVolumeClaim == nil {
+				continue
+			}
+			info, present := claimMap[pod.Namespace+"/"+v.PersistentVolumeClaim.ClaimName]
+			if present {
+				info.ClusterIDs = appendUnique(info.ClusterIDs, clusterID)
+			}
+			//the claims of unknown or no StorageClass cannot be matched
+			if !present || info.StorageClass == "" || !containsString(peerClasses[clusterID], info.StorageClass) {
+				w.Claims = append(w.Claims, v.PersistentVolumeClaim.ClaimName)
+			}
+		}
+		if len(w.Claims) > 0 {
+			report.Warnings = append(report.Warnings, w)
+		}
+	}
+	sort.Slice(report.Warnings, func(i, j int) bool {
+		return report.Warnings[i].Key() < report.Warnings[j].Key()
+	})
+	return report
+}
+
+//hasVolumeClaims returns whether obj is a pod mounting at least a PersistentVolumeClaim.
+func hasVolumeClaims(obj interface{}) bool {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return false
+	}
+	for _, v := range pod.Spec.Volumes {
+		if v.PersistentVolumeClaim != nil {
+			return true
+		}
+	}
+	return false
+}
+
+//appendUnique appends s to the slice only if not already present.
+func appendUnique(slice []string, s string) []string {
+	for _, e := range slice {
+		if e == s {
+			return slice
+		}
+	}
+	return append(slice, s)
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	sharing "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestNewStorageReport(t *testing.T) {
+	scName := "standard"
+	classes := []*storagev1.StorageClass{
+		{ObjectMeta: metav1.ObjectMeta{Name: "slow"}, Provisioner: "test"},
+		{ObjectMeta: metav1.ObjectMeta{Name: scName, Annotations: map[string]string{annDefaultStorageClass: "true"}},
+			Provisioner: "test"},
+	}
+	claims := []*corev1.PersistentVolumeClaim{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "ns"},
+			Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &scName},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound,
+				Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "ns"},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+		},
+	}
+	nodes := []*corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "local"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "liqo-cl1", Labels: map[string]string{labelVirtualNodeType: virtualNodeType},
+			Annotations: map[string]string{annVirtualNodeClusterID: "cl1"}}},
+	}
+	volume := corev1.Volume{Name: "v", VolumeSource: corev1.VolumeSource{
+		PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}}}
+	pods := []*corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "local-pod", Namespace: "ns"},
+			Spec: corev1.PodSpec{NodeName: "local", Volumes: []corev1.Volume{volume}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "offloaded-pod", Namespace: "ns"},
+			Spec: corev1.PodSpec{NodeName: "liqo-cl1", Volumes: []corev1.Volume{volume}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "stateless-pod", Namespace: "ns"},
+			Spec: corev1.PodSpec{NodeName: "liqo-cl1"}},
+	}
+	report := newStorageReport(classes, claims, pods, nodes, nil)
+	if assert.Equal(t, 2, len(report.StorageClasses)) {
+		assert.False(t, report.StorageClasses[0].Default)
+		assert.True(t, report.StorageClasses[1].Default, "default StorageClass not detected")
+	}
+	if assert.Equal(t, 2, len(report.Claims)) {
+		assert.Equal(t, "cache", report.Claims[0].Name, "claims are not sorted")
+		assert.False(t, report.Claims[0].Bound())
+		assert.True(t, report.Claims[1].Bound())
+		assert.Equal(t, "1Gi", report.Claims[1].Capacity)
+		assert.Equal(t, []string{"cl1"}, report.Claims[1].ClusterIDs)
+	}
+	if assert.Equal(t, 1, len(report.Warnings), "wrong number of offloading warnings") {
+		assert.Equal(t, "offloaded-pod", report.Warnings[0].Pod)
+		assert.Equal(t, "cl1", report.Warnings[0].ClusterID)
+		assert.Equal(t, []string{"data"}, report.Warnings[0].Claims)
+	}
+	//the pods are not reported if the peer offers the StorageClass of their claims
+	report = newStorageReport(classes, claims, pods, nodes, map[string][]string{"cl1": {"fast", scName}})
+	assert.Empty(t, report.Warnings)
+	report = newStorageReport(classes, claims, pods, nodes, map[string][]string{"cl1": {"slow"}, "cl2": {scName}})
+	assert.Len(t, report.Warnings, 1)
+}
+
+func TestPeerStorageClasses(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	offer := &sharing.ResourceOffer{ObjectMeta: metav1.ObjectMeta{Name: "sc-offer", Namespace: "sc-ns"},
+		Spec: sharing.ResourceOfferSpec{ClusterId: "sc-peer",
+			Properties: map[corev1.ResourceName]string{propertyStorageClasses: "standard, fast"}}}
+	assert.NoError(t, ctrl.Controller(CRResourceOffer).Store.Add(offer))
+	adv := &sharing.Advertisement{ObjectMeta: metav1.ObjectMeta{Name: "sc-adv"},
+		Spec: sharing.AdvertisementSpec{ClusterId: "sc-peer",
+			Properties: map[corev1.ResourceName]string{propertyStorageClasses: "fast,local"}}}
+	assert.NoError(t, ctrl.Controller(CRAdvertisement).Store.Add(adv))
+	assert.Equal(t, map[string][]string{"sc-peer": {"fast", "local", "standard"}}, ctrl.peerStorageClasses())
+}
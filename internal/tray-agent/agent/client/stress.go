@@ -0,0 +1,307 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	discovery "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	sharing "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	discovery2 "github.com/liqotech/liqo/pkg/discovery"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+//EnvStressTest is the name of the env var enabling the stress-test developer mode. Its value is a comma separated
+//list of key=value settings overriding the DefaultStressConfig ones (e.g. "peers=1000,rate=500,duration=1m").
+//The supported keys are the yaml tags of StressConfig.
+const EnvStressTest = "LIQO_AGENT_STRESS"
+
+//DefaultStressConfig is the StressConfig used for the settings not provided in EnvStressTest.
+var DefaultStressConfig = StressConfig{
+	Peers:       300,
+	Rate:        100,
+	Duration:    30 * time.Second,
+	MaxLatency:  50 * time.Millisecond,
+	MaxSettling: 5 * time.Second,
+}
+
+//StressConfig configures the stress-test developer mode, which injects synthetic ForeignClusters and
+//a stream of changes into the mocked AgentController, in order to validate the Agent at scale.
+type StressConfig struct {
+	//Peers is the number of synthetic ForeignClusters injected at the start.
+	Peers int `yaml:"peers"`
+	//Rate is the number of changes (updates, removals, re-additions) per second injected after the start.
+	Rate int `yaml:"rate"`
+	//Duration is the duration of the injection of the changes.
+	Duration time.Duration `yaml:"duration"`
+	//MaxLatency is the longest acceptable execution time of an event handler (zero disables the check).
+	MaxLatency time.Duration `yaml:"maxLatency"`
+	//MaxSettling is the longest acceptable time between the end of the injection and the menu displaying
+	//the final set of peers.
+	MaxSettling time.Duration `yaml:"maxSettling"`
+	//Report, if set, is the path of the file the report of the stress test is written to, in JSON format.
+	Report string `yaml:"report"`
+}
+
+//StressConfigFromEnv returns the StressConfig set by the EnvStressTest env var. enabled is false if the env var
+//is not set.
+func StressConfigFromEnv() (config StressConfig, enabled bool, err error) {
+	spec, enabled := os.LookupEnv(EnvStressTest)
+	if !enabled {
+		return StressConfig{}, false, nil
+	}
+	config, err = ParseStressConfig(spec)
+	return config, true, err
+}
+
+//ParseStressConfig parses a comma separated list of key=value settings (e.g. "peers=1000,duration=1m").
+//Missing settings keep the DefaultStressConfig value.
+func ParseStressConfig(spec string) (StressConfig, error) {
+	config := DefaultStressConfig
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return config, fmt.Errorf("invalid stress-test setting '%s'", field)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		var err error
+		switch key {
+		case "peers":
+			config.Peers, err = strconv.Atoi(value)
+		case "rate":
+			config.Rate, err = strconv.Atoi(value)
+		case "duration":
+			config.Duration, err = time.ParseDuration(value)
+		case "maxLatency":
+			config.MaxLatency, err = time.ParseDuration(value)
+		case "maxSettling":
+			config.MaxSettling, err = time.ParseDuration(value)
+		case "report":
+			config.Report = value
+		default:
+			err = errors.New("unknown setting")
+		}
+		if err != nil {
+			return config, fmt.Errorf("invalid stress-test setting '%s': %v", field, err)
+		}
+	}
+	if config.Peers < 0 || config.Rate < 0 || config.Duration < 0 {
+		return config, errors.New("invalid stress-test settings: negative values")
+	}
+	return config, nil
+}
+
+//StressStats are the counters of the changes injected by RunStress.
+type StressStats struct {
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+	Deleted int `json:"deleted"`
+	//Peers is the number of synthetic ForeignClusters existing at the end of the injection.
+	Peers int `json:"peers"`
+	//InjectionTime is the time spent injecting the initial ForeignClusters.
+	InjectionTime time.Duration `json:"injectionTime"`
+	//Elapsed is the overall duration of the injection.
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+const (
+	//stressTickInterval is the interval the changes injected by RunStress are batched in.
+	stressTickInterval = 10 * time.Millisecond
+	//stressMaxInFlight is the maximum number of injected changes not yet dispatched by the cache. The fake
+	//informer panics when its (fixed size) event queue overflows.
+	stressMaxInFlight = 50
+)
+
+//RunStress injects the synthetic ForeignClusters and the stream of changes described by config into the cache of
+//the mocked AgentController. It blocks until the injection is completed or ctx is done.
+//
+//It is available only for a mocked AgentController (see UseMockedAgentController), since it would otherwise
+//modify the resources of a real cluster.
+func (ctrl *AgentController) RunStress(ctx context.Context, config StressConfig) (StressStats, error) {
+	var stats StressStats
+	if !ctrl.mocked {
+		return stats, errors.New("the stress test requires the mocked AgentController")
+	}
+	fcCtrl := ctrl.Controller(CRForeignCluster)
+	if fcCtrl == nil || !fcCtrl.Running() {
+		return stats, newError(ErrNotConnected, "stress test", nil)
+	}
+	//the fake informer forwards each event to its watcher too, which must be drained
+	w, err := fcCtrl.Resource(string(CRForeignCluster)).Watch(metav1.ListOptions{})
+	if err != nil {
+		return stats, err
+	}
+	go func() {
+		for range w.ResultChan() {
+		}
+	}()
+	var dispatched int64
+	unsubscribe := fcCtrl.Cache().Subscribe(func(CacheEvent) {
+		atomic.AddInt64(&dispatched, 1)
+	})
+	defer unsubscribe()
+	store := &throttledStore{stressStore: fcCtrl.Store, ctx: ctx, dispatched: &dispatched}
+	start := time.Now()
+	rnd := rand.New(rand.NewSource(start.UnixNano()))
+	peers := make(map[int]*discovery.ForeignCluster, config.Peers)
+	for n := 0; n < config.Peers; n++ {
+		fc := syntheticForeignCluster(n)
+		if err := store.Add(fc); err != nil {
+			return stats, err
+		}
+		peers[n] = fc
+		stats.Added++
+	}
+	stats.InjectionTime = time.Since(start)
+	if config.Rate > 0 && config.Peers > 0 {
+		ticker := time.NewTicker(stressTickInterval)
+		defer ticker.Stop()
+		deadline := time.After(config.Duration)
+		changes := 0
+		changeStart := time.Now()
+	loop:
+		for {
+			select {
+			case <-ctx.Done():
+				break loop
+			case <-deadline:
+				break loop
+			case <-ticker.C:
+				due := int(time.Since(changeStart).Seconds() * float64(config.Rate))
+				for ; changes < due; changes++ {
+					if err := injectStressChange(store, peers, rnd.Intn(config.Peers), rnd, &stats); err != nil {
+						return stats, err
+					}
+				}
+			}
+		}
+	}
+	stats.Peers = len(peers)
+	stats.Elapsed = time.Since(start)
+	return stats, ctx.Err()
+}
+
+//stressStore is the subset of the cache.Store operations used by the stress test.
+type stressStore interface {
+	Add(obj interface{}) error
+	Update(obj interface{}) error
+	Delete(obj interface{}) error
+}
+
+//throttledStore is a stressStore waiting for the cache to dispatch the previous changes before injecting a new one,
+//when more than stressMaxInFlight changes are pending.
+type throttledStore struct {
+	stressStore
+	ctx context.Context
+	//injected is the number of changes injected so far.
+	injected int64
+	//dispatched is the number of changes dispatched by the cache so far.
+	dispatched *int64
+}
+
+//wait blocks until less than maxInFlight changes are pending, so that a new one can be injected.
+func (s *throttledStore) wait(maxInFlight int64) error {
+	for s.injected-atomic.LoadInt64(s.dispatched) >= maxInFlight {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-time.After(time.Millisecond):
+		}
+	}
+	s.injected++
+	return nil
+}
+
+func (s *throttledStore) Add(obj interface{}) error {
+	if err := s.wait(stressMaxInFlight); err != nil {
+		return err
+	}
+	return s.stressStore.Add(obj)
+}
+
+func (s *throttledStore) Update(obj interface{}) error {
+	if err := s.wait(stressMaxInFlight); err != nil {
+		return err
+	}
+	return s.stressStore.Update(obj)
+}
+
+//Delete waits for all the pending changes to be dispatched before removing obj: the fake informer looks up
+//the updated resources while dispatching their changes, and fails if they were removed in the meantime.
+func (s *throttledStore) Delete(obj interface{}) error {
+	if err := s.wait(1); err != nil {
+		return err
+	}
+	return s.stressStore.Delete(obj)
+}
+
+//injectStressChange changes the n-th synthetic ForeignCluster: a removed peer is added back, otherwise
+//it is either updated (most of the times) or removed.
+func injectStressChange(store stressStore, peers map[int]*discovery.ForeignCluster, n int, rnd *rand.Rand,
+	stats *StressStats) error {
+	fc, present := peers[n]
+	switch {
+	case !present:
+		fc = syntheticForeignCluster(n)
+		peers[n] = fc
+		stats.Added++
+		return store.Add(fc)
+	case rnd.Intn(10) == 0:
+		delete(peers, n)
+		stats.Deleted++
+		return store.Delete(fc)
+	default:
+		fc = fc.DeepCopy()
+		switch rnd.Intn(3) {
+		case 0:
+			fc.Spec.ClusterIdentity.ClusterName = fmt.Sprintf("stress-peer-%d-%d", n, rnd.Intn(1000))
+		case 1:
+			fc.Spec.Join = !fc.Spec.Join
+			fc.Status.Outgoing.Joined = fc.Spec.Join
+			if fc.Spec.Join {
+				fc.Status.Outgoing.AdvertisementStatus = sharing.AdvertisementAccepted
+			} else {
+				fc.Status.Outgoing.AdvertisementStatus = ""
+			}
+		default:
+			fc.Status.Incoming.Joined = !fc.Status.Incoming.Joined
+			fc.Status.Incoming.AdvertisementStatus = sharing.AdvertisementAccepted
+		}
+		fc.ResourceVersion = strconv.Itoa(rnd.Int())
+		peers[n] = fc
+		stats.Updated++
+		return store.Update(fc)
+	}
+}
+
+//syntheticForeignCluster returns the n-th synthetic ForeignCluster injected by the stress test.
+func syntheticForeignCluster(n int) *discovery.ForeignCluster {
+	clusterID := fmt.Sprintf("stress-%05d", n)
+	fc := &discovery.ForeignCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            clusterID,
+			ResourceVersion: "1",
+		},
+		Spec: discovery.ForeignClusterSpec{
+			ClusterIdentity: discovery.ClusterIdentity{
+				ClusterID:   clusterID,
+				ClusterName: fmt.Sprintf("stress-peer-%d", n),
+			},
+			DiscoveryType: discovery2.ManualDiscovery,
+		},
+	}
+	if n%5 == 0 {
+		fc.Spec.DiscoveryType = discovery2.LanDiscovery
+	}
+	fc.Status.AuthStatus = discovery2.AuthStatusAccepted
+	return fc
+}
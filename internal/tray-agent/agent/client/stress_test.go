@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseStressConfig(t *testing.T) {
+	config, err := ParseStressConfig("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultStressConfig, config)
+	config, err = ParseStressConfig("peers=1000, duration=1m,report=/tmp/report.json")
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, config.Peers)
+	assert.Equal(t, time.Minute, config.Duration)
+	assert.Equal(t, DefaultStressConfig.Rate, config.Rate, "unset setting not defaulted")
+	assert.Equal(t, "/tmp/report.json", config.Report)
+	for _, spec := range []string{"peers", "peers=many", "unknown=1", "rate=-1"} {
+		_, err = ParseStressConfig(spec)
+		assert.Errorf(t, err, "invalid spec %s accepted", spec)
+	}
+}
+
+func TestRunStress(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	//drain the peer events
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func(ch <-chan NotifyDataGeneric) {
+		for {
+			select {
+			case <-ch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}(ctrl.Events().Subscribe(TopicPeerChanged, 0).C())
+	stats, err := ctrl.RunStress(context.Background(), StressConfig{Peers: 50, Rate: 500, Duration: 200 * time.Millisecond})
+	assert.NoError(t, err)
+	assert.Greater(t, stats.Updated+stats.Deleted, 0, "no change injected")
+	assert.Equal(t, stats.Added-stats.Deleted, stats.Peers, "wrong count of the existing peers")
+	assert.Len(t, ctrl.ForeignClusters().List(), stats.Peers, "cache not matching the injected peers")
+}
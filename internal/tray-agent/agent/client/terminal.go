@@ -0,0 +1,64 @@
+package client
+
+import (
+	"errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/clientcmd"
+	"os"
+)
+
+//Environment variables set in the shells opened by the Agent, in addition to KUBECONFIG.
+const (
+	//EnvShellContext contains the kubeconfig context of the cluster the Agent is connected to.
+	EnvShellContext = "LIQO_CONTEXT"
+	//EnvShellPeerClusterID contains the ClusterID of the peer the shell is opened for.
+	EnvShellPeerClusterID = "LIQO_PEER_CLUSTER_ID"
+	//EnvShellVirtualNode contains the name of the virtual node representing the peer the shell is opened for.
+	EnvShellVirtualNode = "LIQO_VIRTUAL_NODE"
+)
+
+//virtualNodePrefix is the prefix of the name Liqo assigns to the virtual node of a peer, followed by its ClusterID.
+const virtualNodePrefix = "liqo-"
+
+//ShellEnv returns the environment variables pointing kubectl at the cluster the Agent is connected to.
+//If clusterID is not empty, the variables also identify the peer and the virtual node representing it.
+//An error is returned if the cluster cannot be resolved, so that no command is run against a different one.
+func (ctrl *AgentController) ShellEnv(clusterID string) ([]string, error) {
+	kubeconfig, present := os.LookupEnv(EnvLiqoKConfig)
+	if !present || kubeconfig == "" {
+		return nil, newError(ErrNotConnected, "shell environment", errors.New("no kubeconfig available"))
+	}
+	env := []string{"KUBECONFIG=" + kubeconfig}
+	//a context selected at runtime (see SwitchContext) is the current one of the copy of the kubeconfig file
+	if ctrl.context != "" {
+		path, err := ctrl.crdKubeconfig()
+		if err != nil {
+			return nil, newError(ErrNotConnected, "shell environment", err)
+		}
+		env = []string{"KUBECONFIG=" + path, EnvShellContext + "=" + ctrl.context}
+	} else if config, err := clientcmd.LoadFromFile(kubeconfig); err == nil && config.CurrentContext != "" {
+		env = append(env, EnvShellContext+"="+config.CurrentContext)
+	}
+	if clusterID != "" {
+		env = append(env, EnvShellPeerClusterID+"="+clusterID, EnvShellVirtualNode+"="+ctrl.VirtualNodeName(clusterID))
+	}
+	return env, nil
+}
+
+//VirtualNodeName returns the name of the virtual node representing a peer. If the node is not found in the cache,
+//the name follows the Liqo naming convention.
+func (ctrl *AgentController) VirtualNodeName(clusterID string) string {
+	if c := ctrl.coreCache; c != nil && c.running {
+		nodes, err := c.factory.Core().V1().Nodes().Lister().List(labels.SelectorFromSet(labels.Set{
+			labelVirtualNodeType: virtualNodeType,
+		}))
+		if err == nil {
+			for _, n := range nodes {
+				if n.Annotations[annVirtualNodeClusterID] == clusterID {
+					return n.Name
+				}
+			}
+		}
+	}
+	return virtualNodePrefix + clusterID
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShellEnv(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	dir, err := ioutil.TempDir("", "liqo-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	kubeconfig := filepath.Join(dir, "config")
+	err = ioutil.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: home
+  cluster:
+    server: https://127.0.0.1:6443
+users:
+- name: admin
+contexts:
+- name: home-admin
+  context:
+    cluster: home
+    user: admin
+current-context: home-admin
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev, present := os.LookupEnv(EnvLiqoKConfig)
+	assert.NoError(t, os.Setenv(EnvLiqoKConfig, kubeconfig))
+	defer func() {
+		if present {
+			_ = os.Setenv(EnvLiqoKConfig, prev)
+		} else {
+			_ = os.Unsetenv(EnvLiqoKConfig)
+		}
+	}()
+	env, err := ctrl.ShellEnv("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"KUBECONFIG=" + kubeconfig, EnvShellContext + "=home-admin"}, env)
+	env, err = ctrl.ShellEnv("cl1")
+	assert.NoError(t, err)
+	assert.Contains(t, env, EnvShellPeerClusterID+"=cl1")
+	assert.Contains(t, env, EnvShellVirtualNode+"=liqo-cl1")
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	"github.com/liqotech/liqo/pkg/crdClient"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"strings"
+	"time"
+)
+
+/*This file contains the checks of the network connectivity towards the peers. The health of the tunnel towards a
+peer combines:
+	-	the state of the connection reported by the Liqo gateway in the TunnelEndpoint of the peer;
+	-	the readiness of the liqo-gateway component of the home cluster;
+	-	the reachability of the peer, probed with a connection to its authentication service (see PeerLatency),
+		whose duration is reported as latency.*/
+
+const (
+	//CRTunnelEndpoint is the resource id for the TunnelEndpoint CRD.
+	CRTunnelEndpoint CustomResource = "tunnelendpoints"
+	//liqoGatewayName is the name of the Deployment of the Liqo gateway, managing the tunnels towards the peers.
+	liqoGatewayName = "liqo-gateway"
+)
+
+//TunnelHealth describes the health of the network connectivity towards a peer.
+type TunnelHealth struct {
+	ClusterID string
+	//Connection is the state of the tunnel reported by the Liqo gateway (connected, connecting or error), empty if
+	//no tunnel has been set up yet.
+	Connection netv1alpha1.ConnectionStatus
+	//Message is the optional message reported by the Liqo gateway for the tunnel.
+	Message string
+	//GatewayReady specifies whether the liqo-gateway component of the home cluster is ready.
+	GatewayReady bool
+	//Reachable specifies whether the peer answered the probe.
+	Reachable bool
+	//Latency is the duration of the probe of a reachable peer.
+	Latency time.Duration
+	//Checked is the time of the check.
+	Checked time.Time
+}
+
+//Degraded returns whether the connectivity towards the peer is not working properly.
+func (h *TunnelHealth) Degraded() bool {
+	return h.Connection != netv1alpha1.Connected || !h.GatewayReady || !h.Reachable
+}
+
+//Reason returns the reasons why the connectivity towards the peer is degraded, empty if it is not.
+func (h *TunnelHealth) Reason() string {
+	var reasons []string
+	switch h.Connection {
+	case netv1alpha1.Connected:
+	case "":
+		reasons = append(reasons, "no tunnel established")
+	default:
+		tunnel := "tunnel " + string(h.Connection)
+		if h.Message != "" {
+			tunnel += " (" + h.Message + ")"
+		}
+		reasons = append(reasons, tunnel)
+	}
+	if !h.GatewayReady {
+		reasons = append(reasons, liqoGatewayName+" not ready")
+	}
+	if !h.Reachable {
+		reasons = append(reasons, "peer unreachable")
+	}
+	return strings.Join(reasons, ", ")
+}
+
+//createTunnelEndpointController creates a new CRDController for the Liqo TunnelEndpoint CRD.
+func createTunnelEndpointController(_ *AgentController, kubeconfig string) (*CRDController, error) {
+	crdClient.AddToRegistry(string(CRTunnelEndpoint), &netv1alpha1.TunnelEndpoint{},
+		&netv1alpha1.TunnelEndpointList{}, namespacedKeyer, schema.GroupResource{Group: netv1alpha1.GroupVersion.Group,
+			Resource: string(CRTunnelEndpoint)})
+	newClient, err := newSharingClient(kubeconfig, &netv1alpha1.GroupVersion)
+	if err != nil {
+		return nil, err
+	}
+	return newCRDController(newClient, CRTunnelEndpoint, nil), nil
+}
+
+//CheckTunnelHealth checks the network connectivity towards the peer represented by a ForeignCluster.
+func (ctrl *AgentController) CheckTunnelHealth(ctx context.Context, foreignCluster string) (*TunnelHealth, error) {
+	fc, exists := ctrl.ForeignClusters().Get(foreignCluster)
+	if !exists {
+		return nil, fmt.Errorf("tunnel health: ForeignCluster %s not found", foreignCluster)
+	}
+	h := &TunnelHealth{ClusterID: fc.Spec.ClusterIdentity.ClusterID, GatewayReady: ctrl.gatewayReady()}
+	for _, te := range ctrl.TunnelEndpoints().List() {
+		if te.Spec.ClusterID == h.ClusterID {
+			h.Connection = te.Status.Connection.Status
+			h.Message = te.Status.Connection.StatusMessage
+			break
+		}
+	}
+	latency, err := ctrl.PeerLatency(ctx, foreignCluster)
+	h.Reachable, h.Latency = err == nil, latency
+	h.Checked = time.Now()
+	return h, nil
+}
+
+//gatewayReady returns whether the Deployment of the Liqo gateway has all its replicas ready. If the Liqo control
+//plane is not watched, the gateway is considered ready.
+func (ctrl *AgentController) gatewayReady() bool {
+	c := ctrl.coreCache
+	if c == nil || !c.running {
+		return true
+	}
+	deployment, err := c.liqoFactory.Apps().V1().Deployments().Lister().Deployments(c.liqoNamespace).Get(
+		liqoGatewayName)
+	if errors.IsNotFound(err) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	return deployment.Status.ReadyReplicas >= desired
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	discovery "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTunnelHealthReason(t *testing.T) {
+	h := &TunnelHealth{Connection: netv1alpha1.Connected, GatewayReady: true, Reachable: true}
+	assert.False(t, h.Degraded())
+	assert.Empty(t, h.Reason())
+	h.Connection, h.Message = netv1alpha1.ConnectionError, "handshake failed"
+	h.Reachable = false
+	assert.True(t, h.Degraded())
+	assert.Equal(t, "tunnel error (handshake failed), peer unreachable", h.Reason())
+	h = &TunnelHealth{}
+	assert.Equal(t, "no tunnel established, liqo-gateway not ready, peer unreachable", h.Reason())
+}
+
+func TestCheckTunnelHealth(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	_, err := ctrl.CheckTunnelHealth(context.TODO(), "missing")
+	assert.Error(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Add(&discovery.ForeignCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "tunnel-fc"},
+		Spec: discovery.ForeignClusterSpec{
+			ClusterIdentity: discovery.ClusterIdentity{ClusterID: "tunnel-cl"},
+			AuthUrl:         "https://" + listener.Addr().String(),
+		},
+	}))
+	//no tunnel and no gateway
+	h, err := ctrl.CheckTunnelHealth(context.TODO(), "tunnel-fc")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "tunnel-cl", h.ClusterID)
+	assert.True(t, h.Reachable)
+	assert.True(t, h.Latency > 0)
+	assert.False(t, h.GatewayReady, "missing liqo-gateway is ready")
+	assert.True(t, h.Degraded())
+	//connected tunnel with a ready gateway
+	assert.NoError(t, ctrl.Controller(CRTunnelEndpoint).Store.Add(&netv1alpha1.TunnelEndpoint{
+		ObjectMeta: metav1.ObjectMeta{Name: "tep-tunnel-cl"},
+		Spec:       netv1alpha1.TunnelEndpointSpec{ClusterID: "tunnel-cl"},
+		Status: netv1alpha1.TunnelEndpointStatus{
+			Connection: netv1alpha1.Connection{Status: netv1alpha1.Connected}},
+	}))
+	replicas := int32(1)
+	_, err = ctrl.kubeClient.AppsV1().Deployments(ctrl.coreCache.liqoNamespace).Create(context.TODO(),
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: liqoGatewayName},
+			Spec:   appsv1.DeploymentSpec{Replicas: &replicas},
+			Status: appsv1.DeploymentStatus{ReadyReplicas: 1}}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		h, err = ctrl.CheckTunnelHealth(context.TODO(), "tunnel-fc")
+		return err == nil && !h.Degraded()
+	}, 5*time.Second, 10*time.Millisecond, "healthy tunnel is degraded")
+	//unreachable peer
+	_ = listener.Close()
+	h, _ = ctrl.CheckTunnelHealth(context.TODO(), "tunnel-fc")
+	assert.False(t, h.Reachable)
+	assert.Equal(t, "peer unreachable", h.Reason())
+}
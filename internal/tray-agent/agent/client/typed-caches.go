@@ -0,0 +1,167 @@
+package client
+
+import (
+	clusterConfig "github.com/liqotech/liqo/apis/config/v1alpha1"
+	discovery "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	sharing "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+)
+
+//			**** TYPED CACHES ****
+//	The following types wrap the Cache of a CRD to return its own resource type. Adding the cache of a new
+//	CRD only requires a CRDController factory (see crdControllerFactories) and, if needed, a typed wrapper.
+
+//crdCache returns the Cache of a CRD, or nil if its CRDController is not available.
+func (ctrl *AgentController) crdCache(resource CustomResource) *Cache {
+	if ctrl == nil || ctrl.crds() == nil {
+		return nil
+	}
+	return ctrl.Controller(resource).Cache()
+}
+
+//ForeignClusterCache is the Cache of the ForeignClusters.
+type ForeignClusterCache struct {
+	*Cache
+}
+
+//ForeignClusters returns the Cache of the ForeignClusters.
+func (ctrl *AgentController) ForeignClusters() ForeignClusterCache {
+	return ForeignClusterCache{ctrl.crdCache(CRForeignCluster)}
+}
+
+//List returns the cached ForeignClusters.
+func (c ForeignClusterCache) List() []*discovery.ForeignCluster {
+	var fcs []*discovery.ForeignCluster
+	for _, obj := range c.Cache.List() {
+		if fc, ok := obj.(*discovery.ForeignCluster); ok {
+			fcs = append(fcs, fc)
+		}
+	}
+	return fcs
+}
+
+//Get returns the cached ForeignCluster with the given name.
+func (c ForeignClusterCache) Get(name string) (*discovery.ForeignCluster, bool) {
+	obj, exists := c.Cache.Get(name)
+	fc, ok := obj.(*discovery.ForeignCluster)
+	return fc, exists && ok
+}
+
+//AdvertisementCache is the Cache of the Advertisements.
+type AdvertisementCache struct {
+	*Cache
+}
+
+//Advertisements returns the Cache of the Advertisements.
+func (ctrl *AgentController) Advertisements() AdvertisementCache {
+	return AdvertisementCache{ctrl.crdCache(CRAdvertisement)}
+}
+
+//List returns the cached Advertisements.
+func (c AdvertisementCache) List() []*sharing.Advertisement {
+	var advs []*sharing.Advertisement
+	for _, obj := range c.Cache.List() {
+		if adv, ok := obj.(*sharing.Advertisement); ok {
+			advs = append(advs, adv)
+		}
+	}
+	return advs
+}
+
+//Get returns the cached Advertisement with the given name.
+func (c AdvertisementCache) Get(name string) (*sharing.Advertisement, bool) {
+	obj, exists := c.Cache.Get(name)
+	adv, ok := obj.(*sharing.Advertisement)
+	return adv, exists && ok
+}
+
+//ResourceOfferCache is the Cache of the ResourceOffers.
+type ResourceOfferCache struct {
+	*Cache
+}
+
+//ResourceOffers returns the Cache of the ResourceOffers.
+func (ctrl *AgentController) ResourceOffers() ResourceOfferCache {
+	return ResourceOfferCache{ctrl.crdCache(CRResourceOffer)}
+}
+
+//List returns the cached ResourceOffers.
+func (c ResourceOfferCache) List() []*sharing.ResourceOffer {
+	var offers []*sharing.ResourceOffer
+	for _, obj := range c.Cache.List() {
+		if offer, ok := obj.(*sharing.ResourceOffer); ok {
+			offers = append(offers, offer)
+		}
+	}
+	return offers
+}
+
+//ResourceRequestCache is the Cache of the ResourceRequests.
+type ResourceRequestCache struct {
+	*Cache
+}
+
+//ResourceRequests returns the Cache of the ResourceRequests.
+func (ctrl *AgentController) ResourceRequests() ResourceRequestCache {
+	return ResourceRequestCache{ctrl.crdCache(CRResourceRequest)}
+}
+
+//List returns the cached ResourceRequests.
+func (c ResourceRequestCache) List() []*discovery.ResourceRequest {
+	var requests []*discovery.ResourceRequest
+	for _, obj := range c.Cache.List() {
+		if request, ok := obj.(*discovery.ResourceRequest); ok {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+//ClusterConfigCache is the Cache of the ClusterConfigs.
+type ClusterConfigCache struct {
+	*Cache
+}
+
+//ClusterConfigs returns the Cache of the ClusterConfigs.
+func (ctrl *AgentController) ClusterConfigs() ClusterConfigCache {
+	return ClusterConfigCache{ctrl.crdCache(CRClusterConfig)}
+}
+
+//List returns the cached ClusterConfigs.
+func (c ClusterConfigCache) List() []*clusterConfig.ClusterConfig {
+	var configs []*clusterConfig.ClusterConfig
+	for _, obj := range c.Cache.List() {
+		if config, ok := obj.(*clusterConfig.ClusterConfig); ok {
+			configs = append(configs, config)
+		}
+	}
+	return configs
+}
+
+//Get returns the cached ClusterConfig with the given name.
+func (c ClusterConfigCache) Get(name string) (*clusterConfig.ClusterConfig, bool) {
+	obj, exists := c.Cache.Get(name)
+	config, ok := obj.(*clusterConfig.ClusterConfig)
+	return config, exists && ok
+}
+
+//TunnelEndpointCache is the Cache of the TunnelEndpoints.
+type TunnelEndpointCache struct {
+	*Cache
+}
+
+//TunnelEndpoints returns the Cache of the TunnelEndpoints.
+func (ctrl *AgentController) TunnelEndpoints() TunnelEndpointCache {
+	return TunnelEndpointCache{ctrl.crdCache(CRTunnelEndpoint)}
+}
+
+//List returns the cached TunnelEndpoints.
+func (c TunnelEndpointCache) List() []*netv1alpha1.TunnelEndpoint {
+	var endpoints []*netv1alpha1.TunnelEndpoint
+	for _, obj := range c.Cache.List() {
+		if te, ok := obj.(*netv1alpha1.TunnelEndpoint); ok {
+			endpoints = append(endpoints, te)
+		}
+	}
+	return endpoints
+}
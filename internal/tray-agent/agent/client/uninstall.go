@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sort"
+	"strings"
+)
+
+const (
+	//LabelOffloadingEnabled is the namespace label enabling the offloading of its pods towards the peers.
+	LabelOffloadingEnabled = "liqo.io/enabled"
+	//offloadingEnabledSelector selects the namespaces whose offloading is enabled.
+	offloadingEnabledSelector = LabelOffloadingEnabled + "=true"
+)
+
+//UninstallReport is the pre-flight report of everything removed by the uninstallation of Liqo.
+type UninstallReport struct {
+	//Release is the helm release of Liqo to be uninstalled. It is nil if no release has been found.
+	Release *LiqoRelease
+	//OutgoingPeerings contains the names of the peers providing resources to the home cluster.
+	OutgoingPeerings []string
+	//IncomingPeerings contains the names of the peers consuming resources of the home cluster.
+	IncomingPeerings []string
+	//OffloadingNamespaces contains the namespaces whose offloading is enabled.
+	OffloadingNamespaces []string
+	//VirtualNodes contains the Liqo virtual nodes representing the peers.
+	VirtualNodes []string
+	//OffloadedPods contains the pods (namespace/name) currently running on the virtual nodes.
+	OffloadedPods []string
+}
+
+//Empty returns whether nothing would be removed by the uninstallation.
+func (r *UninstallReport) Empty() bool {
+	return r.Release == nil && len(r.OutgoingPeerings) == 0 && len(r.IncomingPeerings) == 0 &&
+		len(r.OffloadingNamespaces) == 0 && len(r.VirtualNodes) == 0 && len(r.OffloadedPods) == 0
+}
+
+//String returns a human readable description of the report.
+func (r *UninstallReport) String() string {
+	b := strings.Builder{}
+	if r.Release != nil {
+		b.WriteString(fmt.Sprintf("Helm release: %s (chart %s) in namespace '%s'\n", r.Release.Name,
+			r.Release.ChartVersion, r.Release.Namespace))
+	} else {
+		b.WriteString("Helm release: not found\n")
+	}
+	writeReportList(&b, "Outgoing peerings", r.OutgoingPeerings)
+	writeReportList(&b, "Incoming peerings", r.IncomingPeerings)
+	writeReportList(&b, "Namespaces with offloading", r.OffloadingNamespaces)
+	writeReportList(&b, "Virtual nodes", r.VirtualNodes)
+	writeReportList(&b, "Offloaded pods", r.OffloadedPods)
+	return b.String()
+}
+
+//writeReportList writes a titled list of items of an UninstallReport.
+func writeReportList(b *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		b.WriteString(fmt.Sprintf("%s: none\n", title))
+		return
+	}
+	b.WriteString(fmt.Sprintf("%s (%d): %s\n", title, len(items), strings.Join(items, ", ")))
+}
+
+//UninstallReport returns the pre-flight report of the uninstallation of Liqo from the connected cluster.
+//The data are retrieved directly from the cluster, in order not to rely on possibly stale caches.
+func (ctrl *AgentController) UninstallReport() (*UninstallReport, error) {
+	if ctrl.kube() == nil {
+		return nil, newError(ErrNotConnected, "uninstall report", nil)
+	}
+	report := &UninstallReport{}
+	if release, err := ctrl.InstalledLiqoRelease(); err == nil {
+		report.Release = release
+	}
+	for _, fc := range ctrl.ForeignClusters().List() {
+		name := fc.Spec.ClusterIdentity.ClusterName
+		if name == "" {
+			name = fc.Spec.ClusterIdentity.ClusterID
+		}
+		if fc.Spec.Join || fc.Status.Outgoing.Joined {
+			report.OutgoingPeerings = append(report.OutgoingPeerings, name)
+		}
+		if fc.Status.Incoming.Joined {
+			report.IncomingPeerings = append(report.IncomingPeerings, name)
+		}
+	}
+	namespaces, err := ctrl.kube().CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{
+		LabelSelector: offloadingEnabledSelector,
+	})
+	if err != nil {
+		return nil, ClassifyError("list namespaces", err)
+	}
+	for _, ns := range namespaces.Items {
+		report.OffloadingNamespaces = append(report.OffloadingNamespaces, ns.Name)
+	}
+	nodes, err := ctrl.kube().CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labelVirtualNodeType + "=" + virtualNodeType,
+	})
+	if err != nil {
+		return nil, ClassifyError("list virtual nodes", err)
+	}
+	virtualNodes := make(map[string]bool)
+	for _, n := range nodes.Items {
+		virtualNodes[n.Name] = true
+		report.VirtualNodes = append(report.VirtualNodes, n.Name)
+	}
+	if len(virtualNodes) > 0 {
+		pods, err := ctrl.kube().CoreV1().Pods(corev1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, ClassifyError("list pods", err)
+		}
+		for _, p := range pods.Items {
+			if virtualNodes[p.Spec.NodeName] {
+				report.OffloadedPods = append(report.OffloadedPods, p.Namespace+"/"+p.Name)
+			}
+		}
+	}
+	for _, s := range [][]string{report.OutgoingPeerings, report.IncomingPeerings, report.OffloadingNamespaces,
+		report.VirtualNodes, report.OffloadedPods} {
+		sort.Strings(s)
+	}
+	return report, nil
+}
+
+//StopAllPeerings triggers the teardown of all the peerings of the home cluster: the outgoing ones are stopped on
+//their ForeignCluster, while the incoming ones are torn down deleting their PeeringRequest.
+//It returns the number of peerings whose teardown has been requested.
+func (ctrl *AgentController) StopAllPeerings() (int, error) {
+	count := 0
+	for _, fc := range ctrl.ForeignClusters().List() {
+		if fc.Spec.Join {
+			if err := ctrl.StartStopOutPeering(fc.Name, false); err != nil {
+				return count, fmt.Errorf("cannot stop the outgoing peering with '%s': %w",
+					fc.Spec.ClusterIdentity.ClusterName, err)
+			}
+			count++
+		}
+		if ref := fc.Status.Incoming.PeeringRequest; ref != nil {
+			if err := ctrl.deletePeeringRequest(ref.Name); err != nil {
+				return count, fmt.Errorf("cannot stop the incoming peering with '%s': %w",
+					fc.Spec.ClusterIdentity.ClusterName, err)
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+//DisableOffloading removes the LabelOffloadingEnabled label from all the namespaces having it.
+//It returns the names of the namespaces whose offloading has been disabled.
+func (ctrl *AgentController) DisableOffloading() ([]string, error) {
+	if ctrl.kube() == nil {
+		return nil, newError(ErrNotConnected, "disable offloading", nil)
+	}
+	namespaces, err := ctrl.kube().CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{
+		LabelSelector: offloadingEnabledSelector,
+	})
+	if err != nil {
+		return nil, ClassifyError("list namespaces", err)
+	}
+	var disabled []string
+	patch := []byte(fmt.Sprintf(`{"metadata":{"labels":{"%s":null}}}`, LabelOffloadingEnabled))
+	for _, ns := range namespaces.Items {
+		_, err = ctrl.kube().CoreV1().Namespaces().Patch(context.TODO(), ns.Name, types.MergePatchType, patch,
+			metav1.PatchOptions{})
+		if err != nil {
+			return disabled, fmt.Errorf("cannot disable the offloading of namespace '%s': %w", ns.Name,
+				ClassifyError("patch namespace", err))
+		}
+		disabled = append(disabled, ns.Name)
+	}
+	return disabled, nil
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/test"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestUninstallReport(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	report, err := ctrl.UninstallReport()
+	if assert.NoError(t, err) {
+		assert.True(t, report.Empty(), "non empty report for an empty cluster")
+	}
+	fc := test.CreateForeignCluster("cl1", "peer1")
+	fc.Spec.Join = true
+	fc.Status.Incoming.Joined = true
+	fc.Status.Incoming.PeeringRequest = &corev1.ObjectReference{Name: "pr-cl1"}
+	assert.NoError(t, ctrl.Controller(CRForeignCluster).Store.Add(fc))
+	core := ctrl.kubeClient.CoreV1()
+	for _, ns := range []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "offloaded", Labels: map[string]string{LabelOffloadingEnabled: "true"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "local"}},
+	} {
+		_, err = core.Namespaces().Create(context.TODO(), ns, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	_, err = core.Nodes().Create(context.TODO(), &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "liqo-cl1",
+		Labels: map[string]string{labelVirtualNodeType: virtualNodeType}}}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	for name, node := range map[string]string{"remote": "liqo-cl1", "home": "worker"} {
+		_, err = core.Pods("offloaded").Create(context.TODO(), &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "offloaded"},
+			Spec:       corev1.PodSpec{NodeName: node},
+		}, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	report, err = ctrl.UninstallReport()
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"peer1"}, report.OutgoingPeerings)
+		assert.Equal(t, []string{"peer1"}, report.IncomingPeerings)
+		assert.Equal(t, []string{"offloaded"}, report.OffloadingNamespaces)
+		assert.Equal(t, []string{"liqo-cl1"}, report.VirtualNodes)
+		assert.Equal(t, []string{"offloaded/remote"}, report.OffloadedPods)
+		assert.Contains(t, report.String(), "Helm release: not found")
+	}
+	//both the outgoing and the incoming peering are torn down
+	stopped, err := ctrl.StopAllPeerings()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stopped)
+	if fc, present := ctrl.ForeignClusters().Get("cl1"); assert.True(t, present) {
+		assert.False(t, fc.Spec.Join, "outgoing peering not stopped")
+	}
+	disabled, err := ctrl.DisableOffloading()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"offloaded"}, disabled)
+	ns, err := core.Namespaces().Get(context.TODO(), "offloaded", metav1.GetOptions{})
+	if assert.NoError(t, err) {
+		_, present := ns.Labels[LabelOffloadingEnabled]
+		assert.False(t, present, "offloading label not removed")
+	}
+}
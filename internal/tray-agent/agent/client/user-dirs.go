@@ -0,0 +1,126 @@
+package client
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/logging"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/xdg"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+/*This file contains the migration of the files of the older versions of the Agent, which kept all their files in a
+single directory, $XDG_DATA_HOME/liqo: the files left there are moved to their current xdg.Dir by MigrateLegacyFiles.*/
+
+//legacyFiles contains the files kept in the legacy directory by the older versions of the Agent, with their
+//current xdg.Dir.
+var legacyFiles = struct {
+	dirs map[string]xdg.Dir
+	sync.Mutex
+}{dirs: map[string]xdg.Dir{
+	ConfigFileName:       xdg.DirConfig,
+	MenuStateFileName:    xdg.DirState,
+	TrustedPeersFileName: xdg.DirState,
+}}
+
+func init() {
+	//the log files, the current one and the rotated ones
+	for _, name := range logging.FileNames() {
+		legacyFiles.dirs[name] = xdg.DirState
+	}
+}
+
+//RegisterLegacyFile registers a file kept in the legacy directory by the older versions of the Agent, so that
+//MigrateLegacyFiles moves it to its current xdg.Dir. It is meant to be called by the init functions of the packages
+//persisting their own files.
+func RegisterLegacyFile(name string, dir xdg.Dir) {
+	legacyFiles.Lock()
+	defer legacyFiles.Unlock()
+	legacyFiles.dirs[name] = dir
+}
+
+//LegacyDir returns the directory containing all the files of the older versions of the Agent, $XDG_DATA_HOME/liqo.
+//It is empty if EnvLiqoPath selects a single directory for all the files.
+func LegacyDir() string {
+	if _, present := os.LookupEnv(EnvLiqoPath); present {
+		return ""
+	}
+	return xdg.DirData.Path()
+}
+
+//MigrateLegacyFiles moves the files left in the LegacyDir by the older versions of the Agent to their current
+//xdg.Dir. A file is not moved if its current xdg.Dir already contains one with the same name. It returns the paths
+//of the moved files, and goes on in case of failures, returning the first one.
+func MigrateLegacyFiles() (moved []string, err error) {
+	legacyDir := LegacyDir()
+	if legacyDir == "" {
+		return nil, nil
+	}
+	legacyFiles.Lock()
+	names := make([]string, 0, len(legacyFiles.dirs))
+	for name := range legacyFiles.dirs {
+		names = append(names, name)
+	}
+	legacyFiles.Unlock()
+	sort.Strings(names)
+	for _, name := range names {
+		legacyFiles.Lock()
+		dir := legacyFiles.dirs[name]
+		legacyFiles.Unlock()
+		source := filepath.Join(legacyDir, name)
+		if _, statErr := os.Stat(source); statErr != nil {
+			continue
+		}
+		destination := dir.File(name)
+		if destination == "" || destination == source {
+			continue
+		}
+		if _, statErr := os.Stat(destination); statErr == nil {
+			continue
+		}
+		if moveErr := moveFile(source, destination); moveErr != nil {
+			if err == nil {
+				err = moveErr
+			}
+			continue
+		}
+		logger.Info("file moved to its XDG directory", "from", source, "to", destination)
+		moved = append(moved, destination)
+	}
+	return moved, err
+}
+
+//moveFile moves a file, creating the destination directory if needed. Across file systems, the file is copied and
+//then removed.
+func moveFile(source, destination string) error {
+	if err := os.MkdirAll(filepath.Dir(destination), 0700); err != nil {
+		return err
+	}
+	if err := os.Rename(source, destination); err == nil {
+		return nil
+	}
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(destination, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(destination)
+		return err
+	}
+	if err = out.Close(); err != nil {
+		_ = os.Remove(destination)
+		return err
+	}
+	return os.Remove(source)
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/logging"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/xdg"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//setTestEnv sets the env variables of a test, returning a function restoring their previous values.
+func setTestEnv(t *testing.T, vars map[string]string) func() {
+	previous := map[string]*string{}
+	for name, value := range vars {
+		if old, present := os.LookupEnv(name); present {
+			previous[name] = &old
+		} else {
+			previous[name] = nil
+		}
+		var err error
+		if value == "" {
+			err = os.Unsetenv(name)
+		} else {
+			err = os.Setenv(name, value)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return func() {
+		for name, old := range previous {
+			if old != nil {
+				_ = os.Setenv(name, *old)
+			} else {
+				_ = os.Unsetenv(name)
+			}
+		}
+	}
+}
+
+func TestLegacyDir(t *testing.T) {
+	home, err := ioutil.TempDir("", "liqo-agent-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer setTestEnv(t, map[string]string{EnvLiqoPath: "", "HOME": home, "XDG_DATA_HOME": "relative/data"})()
+	assert.Equal(t, filepath.Join(home, ".local", "share", "liqo"), LegacyDir())
+	//EnvLiqoPath selects a single directory for all the files, and disables the migration
+	defer setTestEnv(t, map[string]string{EnvLiqoPath: filepath.Join(home, "portable")})()
+	assert.Empty(t, LegacyDir())
+	moved, err := MigrateLegacyFiles()
+	assert.NoError(t, err)
+	assert.Empty(t, moved)
+}
+
+func TestMigrateLegacyFiles(t *testing.T) {
+	home, err := ioutil.TempDir("", "liqo-agent-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer setTestEnv(t, map[string]string{EnvLiqoPath: "", "HOME": home, "XDG_CONFIG_HOME": "",
+		"XDG_STATE_HOME": "", "XDG_CACHE_HOME": "", "XDG_DATA_HOME": ""})()
+	legacyDir := LegacyDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(legacyDir, "icons"), 0755))
+	for _, name := range []string{ConfigFileName, MenuStateFileName, TrustedPeersFileName, "registered.jsonl",
+		logging.FileName} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(legacyDir, name), []byte("legacy "+name), 0600))
+	}
+	RegisterLegacyFile("registered.jsonl", xdg.DirState)
+	defer func() {
+		legacyFiles.Lock()
+		delete(legacyFiles.dirs, "registered.jsonl")
+		legacyFiles.Unlock()
+	}()
+	//a file already in its current directory is not overwritten
+	statePath, err := xdg.DirState.Ensure()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(statePath, MenuStateFileName), []byte("current"), 0600))
+	moved, err := MigrateLegacyFiles()
+	assert.NoError(t, err)
+	//the log files are moved to the directory they are written to
+	assert.Equal(t, []string{xdg.DirState.File(logging.FileName), xdg.DirConfig.File(ConfigFileName),
+		xdg.DirState.File("registered.jsonl"), xdg.DirState.File(TrustedPeersFileName)}, moved)
+	assert.Equal(t, logging.Path(), xdg.DirState.File(logging.FileName))
+	for _, path := range moved {
+		assert.Equal(t, "legacy "+filepath.Base(path), string(mustRead(t, path)))
+		_, err = os.Stat(filepath.Join(legacyDir, filepath.Base(path)))
+		assert.True(t, os.IsNotExist(err), "legacy file not removed")
+	}
+	assert.Equal(t, "current", string(mustRead(t, xdg.DirState.File(MenuStateFileName))))
+	assert.Equal(t, "legacy "+MenuStateFileName, string(mustRead(t, filepath.Join(legacyDir, MenuStateFileName))))
+	//the installed resources are left in place
+	_, err = os.Stat(filepath.Join(legacyDir, "icons"))
+	assert.NoError(t, err)
+	//the files are moved once
+	moved, err = MigrateLegacyFiles()
+	assert.NoError(t, err)
+	assert.Empty(t, moved)
+}
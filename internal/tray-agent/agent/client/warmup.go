@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/metrics"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/tracing"
+	"github.com/liqotech/liqo/pkg/crdClient"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+//cacheSyncWorkers bounds the number of caches concurrently warmed up.
+const cacheSyncWorkers = 4
+
+//CacheSyncProgress contains the aggregate progress of the initial synchronization of the AgentController caches.
+type CacheSyncProgress struct {
+	Synced int
+	Total  int
+}
+
+//Done returns whether all the caches are synchronized.
+func (p CacheSyncProgress) Done() bool {
+	return p.Synced >= p.Total
+}
+
+//String returns a human readable description of the CacheSyncProgress, e.g. "3/11 caches synced".
+func (p CacheSyncProgress) String() string {
+	return fmt.Sprintf("%d/%d caches synced", p.Synced, p.Total)
+}
+
+//cacheSync contains the counters of a cache warm-up. They are accessed atomically.
+type cacheSync struct {
+	synced int32
+	total  int32
+}
+
+//syncTarget is a cache waited for by the warm-up.
+type syncTarget struct {
+	//name is the name of the cached resource, e.g. "foreignclusters".
+	name string
+	//hasSynced returns whether the cache completed its initial listing.
+	hasSynced cache.InformerSynced
+	//stop is closed when the cache is stopped.
+	stop <-chan struct{}
+}
+
+//CacheSyncProgress returns the progress of the initial synchronization of the AgentController caches.
+func (ctrl *AgentController) CacheSyncProgress() CacheSyncProgress {
+	progress, ok := ctrl.cacheSync.Load().(*cacheSync)
+	if !ok {
+		return CacheSyncProgress{}
+	}
+	return CacheSyncProgress{
+		Synced: int(atomic.LoadInt32(&progress.synced)),
+		Total:  int(atomic.LoadInt32(&progress.total)),
+	}
+}
+
+//warmUpCaches waits, with a pool of at most cacheSyncWorkers goroutines, for the initial synchronization
+//of the targets, whose progress is reported by CacheSyncProgress. It does not block.
+func (ctrl *AgentController) warmUpCaches(targets []syncTarget) {
+	progress := &cacheSync{total: int32(len(targets))}
+	ctrl.cacheSync.Store(progress)
+	tasks := make(chan syncTarget, len(targets))
+	for _, t := range targets {
+		tasks <- t
+	}
+	close(tasks)
+	workers := cacheSyncWorkers
+	if len(targets) < workers {
+		workers = len(targets)
+	}
+	//the synchronization time of each cache is measured since the beginning of the warm-up
+	start := time.Now()
+	timer := metrics.GetRegistry().Timer("cache_sync_seconds", "Time to the initial synchronization of the caches",
+		nil)
+	ctx, span := tracing.Start(context.Background(), "warmUpCaches", tracing.Attributes{"caches": len(targets)})
+	pending := int32(len(targets))
+	if pending == 0 {
+		span.End()
+	}
+	for w := 0; w < workers; w++ {
+		go func() {
+			for t := range tasks {
+				//the span of each cache lasts from the beginning of the warm-up to its synchronization
+				_, cacheSpan := tracing.Start(ctx, "syncCache", tracing.Attributes{"resource": t.name})
+				if cache.WaitForCacheSync(t.stop, t.hasSynced) {
+					timer.ObserveSince(start)
+					atomic.AddInt32(&progress.synced, 1)
+				} else {
+					cacheSpan.SetError(errors.New("cache stopped before its synchronization"))
+				}
+				cacheSpan.End()
+				if atomic.AddInt32(&pending, -1) == 0 {
+					span.End()
+				}
+			}
+		}()
+	}
+}
+
+//watchCRDResources starts an informer for a CRD, like crdClient.WatchResources, additionally returning
+//a function reporting whether its initial listing has been completed.
+func watchCRDResources(client *crdClient.CRDClient, resource string, handlers cache.ResourceEventHandlerFuncs,
+	polling *BackoffPolicy) (cache.Store, chan struct{}, cache.InformerSynced, error) {
+	if crdClient.Fake {
+		store, stop, err := crdClient.WatchResources(client, resource, "", 0, handlers, metav1.ListOptions{})
+		return store, stop, func() bool { return true }, err
+	}
+	res, ok := crdClient.Registry[resource]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("reflection for api %v not set", resource)
+	}
+	var lw cache.ListerWatcher = &cache.ListWatch{
+		ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
+			return client.Resource(resource).Namespace("").List(metav1.ListOptions{})
+		},
+		WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
+			return client.Resource(resource).Namespace("").Watch(metav1.ListOptions{})
+		},
+	}
+	//the CRs that cannot be watched are polled
+	if polling != nil {
+		lw = newPollingListWatch(func(lo metav1.ListOptions) (runtime.Object, error) {
+			return client.Resource(resource).Namespace("").List(metav1.ListOptions{})
+		}, *polling)
+	}
+	store, controller := cache.NewInformer(
+		lw,
+		reflect.New(res.SingularType).Interface().(runtime.Object),
+		0,
+		handlers,
+	)
+	stop := make(chan struct{}, 1)
+	go controller.Run(stop)
+	return store, stop, controller.HasSynced, nil
+}
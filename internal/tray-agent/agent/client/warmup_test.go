@@ -0,0 +1,40 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestWarmUpCaches(t *testing.T) {
+	UseMockedAgentController()
+	DestroyMockedAgentController()
+	ctrl := GetAgentController()
+	stop := make(chan struct{})
+	release := make(chan struct{})
+	var targets []syncTarget
+	for n := 0; n < 2*cacheSyncWorkers; n++ {
+		targets = append(targets, syncTarget{hasSynced: func() bool { return true }, stop: stop})
+	}
+	//a cache never synchronized until released
+	targets = append(targets, syncTarget{hasSynced: func() bool {
+		select {
+		case <-release:
+			return true
+		default:
+			return false
+		}
+	}, stop: stop})
+	ctrl.warmUpCaches(targets)
+	assert.Eventually(t, func() bool {
+		return ctrl.CacheSyncProgress().Synced == len(targets)-1
+	}, 5*time.Second, 10*time.Millisecond, "synced caches not counted")
+	progress := ctrl.CacheSyncProgress()
+	assert.False(t, progress.Done())
+	assert.Equal(t, "8/9 caches synced", progress.String())
+	close(release)
+	assert.Eventually(t, func() bool {
+		return ctrl.CacheSyncProgress().Done()
+	}, 5*time.Second, 10*time.Millisecond, "warm-up not completed")
+	close(stop)
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"sort"
+	"strings"
+)
+
+//reasonEvicted is the status reason of a pod evicted from its node.
+const reasonEvicted = "Evicted"
+
+//WorkloadFailure describes an offloaded pod failing on the peer it has been offloaded to.
+type WorkloadFailure struct {
+	Namespace string
+	Pod       string
+	//ClusterID is the ClusterID of the peer running the pod.
+	ClusterID string
+	//Reason is the kind of failure: CrashLoopBackOff, Evicted or Failed.
+	Reason string
+	//Message is the optional message reported for the failure.
+	Message string
+}
+
+//Key returns an identifier of the WorkloadFailure, unique for each failing pod and kind of failure.
+func (f *WorkloadFailure) Key() string {
+	return strings.Join([]string{f.ClusterID, f.Namespace, f.Pod, f.Reason}, "/")
+}
+
+//WorkloadFailures returns the failures of the pods offloaded to the peers, built from the content of the
+//AgentController caches.
+func (ctrl *AgentController) WorkloadFailures() ([]*WorkloadFailure, error) {
+	c := ctrl.coreCache
+	if c == nil || !c.running {
+		return nil, errors.New("offloaded workloads are not watched")
+	}
+	c.consumeCoalesced(TopicWorkloadsChanged)
+	pods, err := c.factory.Core().V1().Pods().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := c.factory.Core().V1().Nodes().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	return newWorkloadFailures(pods, nodes), nil
+}
+
+//newWorkloadFailures returns the failures of the pods scheduled on the Liqo virtual nodes, sorted by Key.
+func newWorkloadFailures(pods []*corev1.Pod, nodes []*corev1.Node) []*WorkloadFailure {
+	virtualNodes := make(map[string]string)
+	for _, n := range nodes {
+		if n.Labels[labelVirtualNodeType] == virtualNodeType {
+			virtualNodes[n.Name] = n.Annotations[annVirtualNodeClusterID]
+		}
+	}
+	failures := make([]*WorkloadFailure, 0)
+	for _, pod := range pods {
+		clusterID, offloaded := virtualNodes[pod.Spec.NodeName]
+		if !offloaded {
+			continue
+		}
+		f := &WorkloadFailure{Namespace: pod.Namespace, Pod: pod.Name, ClusterID: clusterID}
+		switch {
+		case pod.Status.Phase == corev1.PodFailed && pod.Status.Reason == reasonEvicted:
+			f.Reason, f.Message = reasonEvicted, pod.Status.Message
+		case pod.Status.Phase == corev1.PodFailed:
+			f.Reason, f.Message = string(corev1.PodFailed), pod.Status.Message
+		default:
+			for _, cs := range pod.Status.ContainerStatuses {
+				if cs.State.Waiting != nil && cs.State.Waiting.Reason == reasonCrashLoop {
+					f.Reason, f.Message = reasonCrashLoop, "container "+cs.Name+" is repeatedly failing"
+					break
+				}
+			}
+		}
+		if f.Reason != "" {
+			failures = append(failures, f)
+		}
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].Key() < failures[j].Key()
+	})
+	return failures
+}
+
+//isOffloadedPod returns whether obj is a pod scheduled on a Liqo virtual node.
+func isOffloadedPod(nodes listersv1.NodeLister, obj interface{}) bool {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok || pod.Spec.NodeName == "" {
+		return false
+	}
+	node, err := nodes.Get(pod.Spec.NodeName)
+	return err == nil && isVirtualNode(node)
+}
+
+//isVirtualNode returns whether obj is a Liqo virtual node.
+func isVirtualNode(obj interface{}) bool {
+	node, ok := obj.(*corev1.Node)
+	return ok && node.Labels[labelVirtualNodeType] == virtualNodeType
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestNewWorkloadFailures(t *testing.T) {
+	nodes := []*corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "liqo-cl1", Labels: map[string]string{labelVirtualNodeType: virtualNodeType},
+			Annotations: map[string]string{annVirtualNodeClusterID: "cl1"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "worker"}},
+	}
+	crashLoop := corev1.ContainerStatus{Name: "app", State: corev1.ContainerState{
+		Waiting: &corev1.ContainerStateWaiting{Reason: reasonCrashLoop}}}
+	pods := []*corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "crashing", Namespace: "ns"}, Spec: corev1.PodSpec{NodeName: "liqo-cl1"},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{crashLoop}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "evicted", Namespace: "ns"}, Spec: corev1.PodSpec{NodeName: "liqo-cl1"},
+			Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: reasonEvicted, Message: "low memory"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "failed", Namespace: "ns"}, Spec: corev1.PodSpec{NodeName: "liqo-cl1"},
+			Status: corev1.PodStatus{Phase: corev1.PodFailed}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "ns"}, Spec: corev1.PodSpec{NodeName: "liqo-cl1"},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "local", Namespace: "ns"}, Spec: corev1.PodSpec{NodeName: "worker"},
+			Status: corev1.PodStatus{Phase: corev1.PodFailed}},
+	}
+	failures := newWorkloadFailures(pods, nodes)
+	if assert.Equal(t, 3, len(failures), "wrong number of failures") {
+		assert.Equal(t, "cl1/ns/crashing/"+reasonCrashLoop, failures[0].Key())
+		assert.Equal(t, reasonEvicted, failures[1].Reason)
+		assert.Equal(t, "low memory", failures[1].Message)
+		assert.Equal(t, string(corev1.PodFailed), failures[2].Reason)
+	}
+	assert.False(t, isVirtualNode(nodes[1]))
+	assert.True(t, isVirtualNode(nodes[0]))
+}
@@ -0,0 +1,9 @@
+/*
+Package format provides the formatting helpers used by the status, the menus and the notifications of Liqo Agent,
+so that durations ("3m ago"), resource quantities ("1.5 CPU", "2.0Gi") and counts ("1,234 events") are rendered
+consistently across the Indicator.
+
+The numbers follow the Locale of the user, detected from the LC_ALL, LC_NUMERIC and LANG environment variables
+(e.g. "1.234,5" with it_IT.UTF-8), which can be changed with SetLocale().
+*/
+package format
@@ -0,0 +1,208 @@
+package format
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//day is the duration of a day, the largest unit used for the durations.
+const day = 24 * time.Hour
+
+//durationUnits are the units used for the durations, from the largest one.
+var durationUnits = []struct {
+	size   time.Duration
+	symbol string
+}{
+	{day, "d"},
+	{time.Hour, "h"},
+	{time.Minute, "m"},
+	{time.Second, "s"},
+}
+
+//byteUnits are the binary suffixes of the memory quantities, following the Kubernetes notation.
+var byteUnits = []string{"Ki", "Mi", "Gi", "Ti", "Pi", "Ei"}
+
+//Number returns a number with the given digits after the decimal separator, e.g. "1,234.5" (DefaultLocale).
+func Number(v float64, precision int) string {
+	l := CurrentLocale()
+	text := strconv.FormatFloat(math.Abs(v), 'f', precision, 64)
+	integer, fraction := text, ""
+	if index := strings.IndexByte(text, '.'); index >= 0 {
+		integer, fraction = text[:index], text[index+1:]
+	}
+	str := strings.Builder{}
+	if v < 0 && strings.Trim(text, "0.") != "" {
+		str.WriteString("-")
+	}
+	str.WriteString(group(integer, l.GroupSeparator))
+	if fraction != "" {
+		str.WriteString(l.DecimalSeparator + fraction)
+	}
+	return str.String()
+}
+
+//group inserts the separator between the groups of thousands of the digits of an integer.
+func group(digits string, separator string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	head := len(digits) % 3
+	parts := make([]string, 0, len(digits)/3+1)
+	if head > 0 {
+		parts = append(parts, digits[:head])
+	}
+	for index := head; index < len(digits); index += 3 {
+		parts = append(parts, digits[index:index+3])
+	}
+	return strings.Join(parts, separator)
+}
+
+//Integer returns an integer, e.g. "1,234" (DefaultLocale).
+func Integer(n int) string {
+	return Number(float64(n), 0)
+}
+
+//Count returns an integer followed by the singular or plural form of a noun, e.g. "1 event" or "1,234 events".
+func Count(n int, singular string, plural string) string {
+	if n == 1 {
+		return Integer(n) + " " + singular
+	}
+	return Integer(n) + " " + plural
+}
+
+//Percent returns a ratio as a rounded percentage, e.g. "35%".
+func Percent(ratio float64) string {
+	return Number(math.Round(ratio*100), 0) + "%"
+}
+
+//Bar returns a unicode bar of the given width (in cells) filled according to ratio, clamped to [0, 1], e.g.
+//"▕███▌░░░░░░▏". Half cells are used to improve the resolution.
+func Bar(ratio float64, width int) string {
+	if ratio < 0 {
+		ratio = 0
+	} else if ratio > 1 {
+		ratio = 1
+	}
+	halves := int(ratio*float64(2*width) + 0.5)
+	b := strings.Builder{}
+	b.WriteString("▕")
+	b.WriteString(strings.Repeat("█", halves/2))
+	if halves%2 == 1 {
+		b.WriteString("▌")
+	}
+	b.WriteString(strings.Repeat("░", width-(halves+1)/2))
+	b.WriteString("▏")
+	return b.String()
+}
+
+//Duration returns a duration with its two most significant units, e.g. "3d 4h", "2h 30m" or "45s". The durations
+//shorter than a second are expressed in milliseconds, e.g. "850ms".
+func Duration(d time.Duration) string {
+	return duration(d, 2)
+}
+
+//ShortDuration returns a duration with its most significant unit, e.g. "3m" or "2d".
+func ShortDuration(d time.Duration) string {
+	return duration(d, 1)
+}
+
+//duration returns a duration with at most the given number of units, starting from its most significant one and
+//truncating the remainder. The zero units are omitted, e.g. "2h" instead of "2h 0m".
+func duration(d time.Duration, units int) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	if d < time.Second {
+		return sign + Integer(int(d/time.Millisecond)) + "ms"
+	}
+	parts := make([]string, 0, units)
+	for _, u := range durationUnits {
+		n := d / u.size
+		if n > 0 {
+			parts = append(parts, Integer(int(n))+u.symbol)
+			d -= n * u.size
+		}
+		if len(parts) > 0 {
+			if units--; units == 0 {
+				break
+			}
+		}
+	}
+	return sign + strings.Join(parts, " ")
+}
+
+//Ago returns how long ago an instant was with respect to now, e.g. "3m ago", or "just now" within a second.
+func Ago(t time.Time, now time.Time) string {
+	elapsed := now.Sub(t)
+	if elapsed < time.Second {
+		return "just now"
+	}
+	return ShortDuration(elapsed) + " ago"
+}
+
+//Until returns how long it takes to reach an instant with respect to now, e.g. "in 3m", or "now" within a second.
+func Until(t time.Time, now time.Time) string {
+	left := t.Sub(now)
+	if left < time.Second {
+		return "now"
+	}
+	return "in " + ShortDuration(left)
+}
+
+//Cores returns an amount of CPU expressed in millicores as cores, e.g. "1.5 CPU".
+func Cores(millicores int64) string {
+	return Number(float64(millicores)/1000, 1) + " CPU"
+}
+
+//Bytes returns an amount of memory with the largest fitting binary unit, e.g. "2.0Gi" or "512B".
+func Bytes(bytes int64) string {
+	if bytes < 1024 && bytes > -1024 {
+		return Integer(int(bytes)) + "B"
+	}
+	value, unit := float64(bytes)/1024, 0
+	for math.Abs(value) >= 1024 && unit < len(byteUnits)-1 {
+		value /= 1024
+		unit++
+	}
+	return Number(value, 1) + byteUnits[unit]
+}
+
+//CPUQuantity returns the literal representation of a Kubernetes CPU quantity (e.g. "1500m") as cores, e.g.
+//"1.5 CPU". A malformed quantity is returned unchanged.
+func CPUQuantity(quantity string) string {
+	q, err := resource.ParseQuantity(quantity)
+	if err != nil {
+		return quantity
+	}
+	return Cores(q.MilliValue())
+}
+
+//MemoryQuantity returns the literal representation of a Kubernetes memory quantity (e.g. "2048Mi") with the largest
+//fitting binary unit, e.g. "2.0Gi". A malformed quantity is returned unchanged.
+func MemoryQuantity(quantity string) string {
+	q, err := resource.ParseQuantity(quantity)
+	if err != nil {
+		return quantity
+	}
+	return Bytes(q.Value())
+}
+
+//Quantity returns the literal representation of a Kubernetes quantity of the given resource, e.g. "cpu" or
+//"memory". The quantities of the other resources (e.g. "pods") are rendered as integers.
+func Quantity(resourceName string, quantity string) string {
+	switch resourceName {
+	case "cpu":
+		return CPUQuantity(quantity)
+	case "memory", "ephemeral-storage", "storage":
+		return MemoryQuantity(quantity)
+	}
+	q, err := resource.ParseQuantity(quantity)
+	if err != nil {
+		return quantity
+	}
+	return Integer(int(q.Value()))
+}
@@ -0,0 +1,68 @@
+package format
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLocale(t *testing.T) {
+	assert.Equal(t, DefaultLocale, ParseLocale(""))
+	assert.Equal(t, DefaultLocale, ParseLocale("C"))
+	assert.Equal(t, Locale{Name: "it_IT", DecimalSeparator: ",", GroupSeparator: "."}, ParseLocale("it_IT.UTF-8"))
+	assert.Equal(t, "\u00a0", ParseLocale("fr_FR@euro").GroupSeparator)
+	defer SetLocale(CurrentLocale())
+	SetLocale(DefaultLocale)
+	assert.Equal(t, "1,234,567.9", Number(1234567.89, 1))
+	assert.Equal(t, "-1,000", Number(-1000, 0))
+	assert.Equal(t, "0", Number(-0.2, 0))
+	SetLocale(ParseLocale("de_DE"))
+	assert.Equal(t, "1.234.567,9", Number(1234567.89, 1))
+	assert.Equal(t, "1,5 CPU", Cores(1500))
+}
+
+func TestCounts(t *testing.T) {
+	defer SetLocale(CurrentLocale())
+	SetLocale(DefaultLocale)
+	assert.Equal(t, "999", Integer(999))
+	assert.Equal(t, "1 event", Count(1, "event", "events"))
+	assert.Equal(t, "1,234 events", Count(1234, "event", "events"))
+	assert.Equal(t, "0 events", Count(0, "event", "events"))
+	assert.Equal(t, "35%", Percent(0.349))
+	assert.Equal(t, "▕███▌░░░░░░▏", Bar(0.35, 10))
+	assert.Equal(t, "▕░░░░▏", Bar(-1, 4))
+	assert.Equal(t, "▕████▏", Bar(1.5, 4))
+}
+
+func TestDurations(t *testing.T) {
+	defer SetLocale(CurrentLocale())
+	SetLocale(DefaultLocale)
+	assert.Equal(t, "850ms", Duration(850*time.Millisecond))
+	assert.Equal(t, "45s", Duration(45*time.Second))
+	assert.Equal(t, "3m 20s", Duration(200*time.Second))
+	assert.Equal(t, "2h 30m", Duration(150*time.Minute+10*time.Second))
+	assert.Equal(t, "2h", Duration(2*time.Hour+10*time.Second))
+	assert.Equal(t, "3d 4h", Duration(76*time.Hour+5*time.Minute))
+	assert.Equal(t, "-5m", Duration(-5*time.Minute))
+	assert.Equal(t, "3m", ShortDuration(200*time.Second))
+	now := time.Now()
+	assert.Equal(t, "3m ago", Ago(now.Add(-200*time.Second), now))
+	assert.Equal(t, "just now", Ago(now, now))
+	assert.Equal(t, "in 2h", Until(now.Add(150*time.Minute), now))
+	assert.Equal(t, "now", Until(now.Add(-time.Minute), now))
+}
+
+func TestQuantities(t *testing.T) {
+	defer SetLocale(CurrentLocale())
+	SetLocale(DefaultLocale)
+	assert.Equal(t, "1.5 CPU", Cores(1500))
+	assert.Equal(t, "512B", Bytes(512))
+	assert.Equal(t, "1.5Ki", Bytes(1536))
+	assert.Equal(t, "2.0Gi", Bytes(2<<30))
+	assert.Equal(t, "0.5 CPU", CPUQuantity("500m"))
+	assert.Equal(t, "4.0 CPU", Quantity("cpu", "4"))
+	assert.Equal(t, "2.0Gi", MemoryQuantity("2048Mi"))
+	assert.Equal(t, "7.5Gi", Quantity("memory", "8053063680"))
+	assert.Equal(t, "110", Quantity("pods", "110"))
+	assert.Equal(t, "not a quantity", CPUQuantity("not a quantity"))
+}
@@ -0,0 +1,89 @@
+package format
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+//Locale contains the conventions used to render the numbers.
+type Locale struct {
+	//Name is the name of the locale, e.g. "it_IT".
+	Name string
+	//DecimalSeparator separates the integer and the fractional part of a number, e.g. ".".
+	DecimalSeparator string
+	//GroupSeparator separates the groups of thousands of a number, e.g. ",".
+	GroupSeparator string
+}
+
+//DefaultLocale is the Locale used when the one of the user is unknown.
+var DefaultLocale = Locale{Name: "en_US", DecimalSeparator: ".", GroupSeparator: ","}
+
+//languageSeparators maps the languages into their decimal and group separators (a no-break space where the groups
+//are separated by a space). The languages not listed here use the ones of DefaultLocale.
+var languageSeparators = map[string][2]string{
+	"da": {",", "."},
+	"de": {",", "."},
+	"es": {",", "."},
+	"id": {",", "."},
+	"it": {",", "."},
+	"nl": {",", "."},
+	"pt": {",", "."},
+	"tr": {",", "."},
+	"cs": {",", "\u00a0"},
+	"fi": {",", "\u00a0"},
+	"fr": {",", "\u00a0"},
+	"nb": {",", "\u00a0"},
+	"pl": {",", "\u00a0"},
+	"ru": {",", "\u00a0"},
+	"sv": {",", "\u00a0"},
+	"uk": {",", "\u00a0"},
+}
+
+//localeEnvs are the environment variables specifying the locale of the user, by decreasing priority.
+var localeEnvs = []string{"LC_ALL", "LC_NUMERIC", "LANG"}
+
+//ParseLocale returns the Locale described by a POSIX locale name, e.g. "it_IT.UTF-8". An unknown or empty name
+//results in DefaultLocale.
+func ParseLocale(name string) Locale {
+	//remove the codeset and the modifier, e.g. "de_DE.UTF-8@euro" -> "de_DE"
+	if index := strings.IndexAny(name, ".@"); index >= 0 {
+		name = name[:index]
+	}
+	language := strings.ToLower(strings.SplitN(name, "_", 2)[0])
+	separators, present := languageSeparators[language]
+	if !present {
+		return DefaultLocale
+	}
+	return Locale{Name: name, DecimalSeparator: separators[0], GroupSeparator: separators[1]}
+}
+
+//DetectLocale returns the Locale of the user, taken from the environment.
+func DetectLocale() Locale {
+	for _, env := range localeEnvs {
+		if name := os.Getenv(env); name != "" {
+			return ParseLocale(name)
+		}
+	}
+	return DefaultLocale
+}
+
+//current is the Locale used by the formatting helpers.
+var current = DetectLocale()
+
+//currentMutex protects current.
+var currentMutex sync.RWMutex
+
+//SetLocale changes the Locale used by the formatting helpers.
+func SetLocale(l Locale) {
+	currentMutex.Lock()
+	defer currentMutex.Unlock()
+	current = l
+}
+
+//CurrentLocale returns the Locale used by the formatting helpers.
+func CurrentLocale() Locale {
+	currentMutex.RLock()
+	defer currentMutex.RUnlock()
+	return current
+}
@@ -0,0 +1,8 @@
+/*
+Package history provides a small bounded local store persisting the peering up/down transitions observed
+by Liqo Agent, together with the resource levels shared at that time.
+
+The stored Records can be used to reconstruct when each peer was connected over the past days, e.g. to
+correlate peering outages with application incidents.
+*/
+package history
@@ -0,0 +1,423 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/xdg"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	//FileName is the basename of the file storing the peering history inside the Liqo Agent directory.
+	FileName = "peering_history.jsonl"
+	//DefaultMaxRecords is the default maximum number of Records kept by a Store.
+	DefaultMaxRecords = 10000
+	//DefaultRetention is the default maximum age of the Records kept by a Store.
+	DefaultRetention = 7 * 24 * time.Hour
+	//compactionSlack is the fraction (1/compactionSlack) of the maximum number of Records that the file backing
+	//a Store can additionally contain, as removed Records, before being compacted.
+	compactionSlack = 5
+)
+
+//Direction defines the direction of a peering.
+type Direction string
+
+const (
+	//DirectionIncoming defines a peering where the home cluster shares its own resources with a foreign cluster.
+	DirectionIncoming Direction = "incoming"
+	//DirectionOutgoing defines a peering where the home cluster is consuming the resources of a foreign cluster.
+	DirectionOutgoing Direction = "outgoing"
+)
+
+//Record is a single peering transition.
+type Record struct {
+	Timestamp   time.Time `json:"timestamp"`
+	ClusterID   string    `json:"clusterID"`
+	ClusterName string    `json:"clusterName,omitempty"`
+	Direction   Direction `json:"direction"`
+	//Connected specifies whether the peering has been established (true) or torn down.
+	Connected bool `json:"connected"`
+	//CpuQuota is the CPU quota shared in the peering at the time of the transition.
+	CpuQuota string `json:"cpuQuota,omitempty"`
+	//MemQuota is the memory quota shared in the peering at the time of the transition.
+	MemQuota string `json:"memQuota,omitempty"`
+}
+
+//Interval is a time span during which a peering was connected.
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	//Open specifies whether the peering is still connected at the End of the Interval.
+	Open bool `json:"open"`
+}
+
+//Timeline collects the connection Intervals of both the peerings with a peer.
+type Timeline struct {
+	ClusterID   string     `json:"clusterID"`
+	ClusterName string     `json:"clusterName,omitempty"`
+	Outgoing    []Interval `json:"outgoing"`
+	Incoming    []Interval `json:"incoming"`
+}
+
+//Store singleton.
+var store *Store
+
+//the peering history was kept in the legacy directory of the Agent by its older versions
+func init() {
+	client.RegisterLegacyFile(FileName, xdg.DirState)
+}
+
+//storeOnce protects the store singleton initialization.
+var storeOnce sync.Once
+
+//GetStore returns the Store singleton, persisted in the FileName file inside the xdg.DirState directory.
+//If the directory is not available, the Store works in memory only.
+func GetStore() *Store {
+	storeOnce.Do(func() {
+		store, _ = NewStore(xdg.DirState.File(FileName), DefaultMaxRecords, DefaultRetention)
+	})
+	return store
+}
+
+//Store is a bounded, file-backed collection of peering Records. Records are appended to a JSON-lines file,
+//which is compacted once the expired and exceeding Records it still contains are more than a fraction of the
+//maximum number of Records, so that it is not rewritten on every Add.
+type Store struct {
+	//path of the file backing the Store. If empty, the Store is not persisted.
+	path string
+	//maxRecords is the maximum number of Records kept by the Store.
+	maxRecords int
+	//retention is the maximum age of the Records kept by the Store.
+	retention time.Duration
+	//records contains the stored Records, sorted by Timestamp.
+	records []Record
+	//fileRecords is the number of Records in the file backing the Store, including the removed ones.
+	fileRecords int
+	sync.RWMutex
+}
+
+//NewStore returns a Store backed by the file at path, loading the Records it already contains.
+//An empty path creates a Store that is not persisted. Even in case of loading errors a working Store is returned.
+func NewStore(path string, maxRecords int, retention time.Duration) (*Store, error) {
+	if maxRecords <= 0 {
+		maxRecords = DefaultMaxRecords
+	}
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	s := &Store{
+		path:       path,
+		maxRecords: maxRecords,
+		retention:  retention,
+	}
+	return s, s.load()
+}
+
+//load reads the Records from the file backing the Store, discarding the expired ones.
+func (s *Store) load() error {
+	if s.path == "" {
+		return nil
+	}
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	s.Lock()
+	defer s.Unlock()
+	scanner := bufio.NewScanner(f)
+	corrupted := false
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			//a truncated line can be left by an abrupt exit: the rest of the file is still usable
+			corrupted = true
+			continue
+		}
+		s.records = append(s.records, r)
+	}
+	sort.SliceStable(s.records, func(i, j int) bool {
+		return s.records[i].Timestamp.Before(s.records[j].Timestamp)
+	})
+	s.fileRecords = len(s.records)
+	s.prune(time.Now())
+	if s.stale() || corrupted {
+		if err := s.compact(); err != nil {
+			return err
+		}
+	}
+	if corrupted {
+		return errors.New("some corrupted history records have been discarded")
+	}
+	return scanner.Err()
+}
+
+//prune removes the expired and exceeding Records from memory. They are removed from the file backing the Store
+//by the next compaction.
+func (s *Store) prune(now time.Time) {
+	first := 0
+	limit := now.Add(-s.retention)
+	for first < len(s.records) && s.records[first].Timestamp.Before(limit) {
+		first++
+	}
+	if exceeding := len(s.records) - first - s.maxRecords; exceeding > 0 {
+		first += exceeding
+	}
+	if first > 0 {
+		s.records = append([]Record(nil), s.records[first:]...)
+	}
+}
+
+//stale returns whether the file backing the Store contains too many removed Records, and needs to be compacted.
+func (s *Store) stale() bool {
+	slack := s.maxRecords / compactionSlack
+	if slack < 1 {
+		slack = 1
+	}
+	return s.fileRecords-len(s.records) > slack
+}
+
+//compact rewrites the file backing the Store with the Records currently kept in memory.
+func (s *Store) compact() error {
+	if s.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for i := range s.records {
+		if err = enc.Encode(&s.records[i]); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err = os.Rename(tmpPath, s.path); err == nil {
+		s.fileRecords = len(s.records)
+	}
+	return err
+}
+
+//Add stores a new Record. If the Record has no Timestamp, the current time is used.
+func (s *Store) Add(r Record) error {
+	if r.Timestamp.IsZero() {
+		r.Timestamp = time.Now()
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.records = append(s.records, r)
+	s.prune(time.Now())
+	if s.path == "" {
+		return nil
+	}
+	if s.fileRecords++; s.stale() {
+		return s.compact()
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(f).Encode(&r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+//Clear removes all the Records, also from the file backing the Store.
+func (s *Store) Clear() error {
+	s.Lock()
+	defer s.Unlock()
+	s.records = nil
+	s.fileRecords = 0
+	if s.path == "" {
+		return nil
+	}
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+//Records returns the stored Records concerning a peer, not older than since. If clusterID is empty, the Records
+//of all the peers are returned.
+func (s *Store) Records(clusterID string, since time.Time) []Record {
+	s.RLock()
+	defer s.RUnlock()
+	records := make([]Record, 0)
+	for _, r := range s.records {
+		if (clusterID == "" || r.ClusterID == clusterID) && !r.Timestamp.Before(since) {
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+//ClusterIDs returns the sorted list of the peers having at least one stored Record.
+func (s *Store) ClusterIDs() []string {
+	s.RLock()
+	defer s.RUnlock()
+	set := make(map[string]bool)
+	for _, r := range s.records {
+		set[r.ClusterID] = true
+	}
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+//LastRecord returns the most recent Record concerning the peering of a given direction with a peer.
+//If no Record exists, present == false.
+func (s *Store) LastRecord(clusterID string, direction Direction) (record Record, present bool) {
+	s.RLock()
+	defer s.RUnlock()
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if s.records[i].ClusterID == clusterID && s.records[i].Direction == direction {
+			return s.records[i], true
+		}
+	}
+	return Record{}, false
+}
+
+//Intervals reconstructs the time spans during which the peering of a given direction with a peer was connected,
+//between since and until. A peering still connected at until produces an open Interval ending at until.
+func (s *Store) Intervals(clusterID string, direction Direction, since time.Time, until time.Time) []Interval {
+	s.RLock()
+	defer s.RUnlock()
+	intervals := make([]Interval, 0)
+	var start time.Time
+	connected := false
+	for _, r := range s.records {
+		if r.ClusterID != clusterID || r.Direction != direction || r.Timestamp.After(until) {
+			continue
+		}
+		switch {
+		case r.Connected && !connected:
+			start = r.Timestamp
+			connected = true
+		case !r.Connected && connected:
+			if r.Timestamp.After(since) {
+				if start.Before(since) {
+					start = since
+				}
+				intervals = append(intervals, Interval{Start: start, End: r.Timestamp})
+			}
+			connected = false
+		}
+	}
+	if connected {
+		if start.Before(since) {
+			start = since
+		}
+		intervals = append(intervals, Interval{Start: start, End: until, Open: true})
+	}
+	return intervals
+}
+
+//Timelines returns the Timeline of each peer having at least one stored Record, between since and until.
+//The ClusterName of each Timeline is the most recent one stored for the peer.
+func (s *Store) Timelines(since time.Time, until time.Time) []*Timeline {
+	ids := s.ClusterIDs()
+	timelines := make([]*Timeline, 0, len(ids))
+	for _, id := range ids {
+		t := &Timeline{
+			ClusterID: id,
+			Outgoing:  s.Intervals(id, DirectionOutgoing, since, until),
+			Incoming:  s.Intervals(id, DirectionIncoming, since, until),
+		}
+		s.RLock()
+		for i := len(s.records) - 1; i >= 0; i-- {
+			if s.records[i].ClusterID == id && s.records[i].ClusterName != "" {
+				t.ClusterName = s.records[i].ClusterName
+				break
+			}
+		}
+		s.RUnlock()
+		timelines = append(timelines, t)
+	}
+	return timelines
+}
+
+//PeerState is the state of the peerings with a peer at a given time, reconstructed from the stored Records.
+type PeerState struct {
+	ClusterID   string
+	ClusterName string
+	//OutConnected specifies whether the outgoing peering was connected.
+	OutConnected bool
+	//InConnected specifies whether the incoming peering was connected.
+	InConnected bool
+	//CpuQuota is the CPU quota shared in the outgoing peering.
+	CpuQuota string
+	//MemQuota is the memory quota shared in the outgoing peering.
+	MemQuota string
+}
+
+//StatusAt reconstructs the state of the peerings at time t, returning the peers having at least one connected
+//peering, sorted by ClusterID. The second value returned is the Timestamp of the oldest stored Record: the peerings
+//established before it are not known. If the Store is empty, present == false.
+func (s *Store) StatusAt(t time.Time) (peers []PeerState, oldest time.Time, present bool) {
+	s.RLock()
+	defer s.RUnlock()
+	if len(s.records) == 0 {
+		return nil, time.Time{}, false
+	}
+	states := make(map[string]*PeerState)
+	for _, r := range s.records {
+		if r.Timestamp.After(t) {
+			break
+		}
+		st, present := states[r.ClusterID]
+		if !present {
+			st = &PeerState{ClusterID: r.ClusterID}
+			states[r.ClusterID] = st
+		}
+		if r.ClusterName != "" {
+			st.ClusterName = r.ClusterName
+		}
+		if r.Direction == DirectionOutgoing {
+			st.OutConnected = r.Connected
+			st.CpuQuota, st.MemQuota = r.CpuQuota, r.MemQuota
+		} else {
+			st.InConnected = r.Connected
+		}
+	}
+	peers = make([]PeerState, 0, len(states))
+	for _, st := range states {
+		if st.OutConnected || st.InConnected {
+			peers = append(peers, *st)
+		}
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].ClusterID < peers[j].ClusterID
+	})
+	return peers, s.records[0].Timestamp, true
+}
@@ -0,0 +1,146 @@
+package history
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liqo-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, FileName)
+	s, err := NewStore(path, 3, time.Hour)
+	assert.NoError(t, err, "creation of a Store with no file failed")
+	now := time.Now()
+	//expired record
+	assert.NoError(t, s.Add(Record{Timestamp: now.Add(-2 * time.Hour), ClusterID: "cl1", Direction: DirectionOutgoing}))
+	assert.Equal(t, 0, len(s.Records("", time.Time{})), "expired record has been stored")
+	assert.NoError(t, s.Add(Record{Timestamp: now.Add(-30 * time.Minute), ClusterID: "cl1",
+		Direction: DirectionOutgoing, Connected: true}))
+	assert.NoError(t, s.Add(Record{Timestamp: now.Add(-20 * time.Minute), ClusterID: "cl2",
+		Direction: DirectionIncoming, Connected: true}))
+	assert.NoError(t, s.Add(Record{Timestamp: now.Add(-10 * time.Minute), ClusterID: "cl1",
+		Direction: DirectionOutgoing, Connected: false}))
+	assert.Equal(t, []string{"cl1", "cl2"}, s.ClusterIDs())
+	assert.Equal(t, 2, len(s.Records("cl1", time.Time{})))
+	last, present := s.LastRecord("cl1", DirectionOutgoing)
+	assert.True(t, present)
+	assert.False(t, last.Connected)
+	//the store is bounded
+	assert.NoError(t, s.Add(Record{Timestamp: now.Add(-5 * time.Minute), ClusterID: "cl1",
+		Direction: DirectionOutgoing, Connected: true}))
+	assert.Equal(t, 3, len(s.Records("", time.Time{})), "store exceeded its maximum size")
+	//test persistence
+	s2, err := NewStore(path, 3, time.Hour)
+	assert.NoError(t, err)
+	stored, loaded := s.Records("", time.Time{}), s2.Records("", time.Time{})
+	if assert.Equal(t, len(stored), len(loaded), "loaded records differ from stored ones") {
+		for i := range stored {
+			assert.True(t, stored[i].Timestamp.Equal(loaded[i].Timestamp), "loaded timestamp differs from stored one")
+			loaded[i].Timestamp = stored[i].Timestamp
+			assert.Equal(t, stored[i], loaded[i], "loaded record differs from stored one")
+		}
+	}
+}
+
+func TestStore_Intervals(t *testing.T) {
+	s, _ := NewStore("", 0, 0)
+	now := time.Now()
+	add := func(ago time.Duration, connected bool) {
+		_ = s.Add(Record{Timestamp: now.Add(-ago), ClusterID: "cl1", Direction: DirectionOutgoing, Connected: connected})
+	}
+	add(5*time.Hour, true)
+	add(4*time.Hour, false)
+	add(3*time.Hour, true)
+	//repeated transitions are ignored
+	add(150*time.Minute, true)
+	add(2*time.Hour, false)
+	add(time.Hour, true)
+	intervals := s.Intervals("cl1", DirectionOutgoing, now.Add(-270*time.Minute), now)
+	if assert.Equal(t, 3, len(intervals)) {
+		//the first interval is clipped
+		assert.Equal(t, Interval{Start: now.Add(-270 * time.Minute), End: now.Add(-4 * time.Hour)}, intervals[0])
+		assert.Equal(t, Interval{Start: now.Add(-3 * time.Hour), End: now.Add(-2 * time.Hour)}, intervals[1])
+		assert.Equal(t, Interval{Start: now.Add(-time.Hour), End: now, Open: true}, intervals[2])
+	}
+	assert.Equal(t, 0, len(s.Intervals("cl1", DirectionIncoming, now.Add(-time.Hour), now)))
+	timelines := s.Timelines(now.Add(-270*time.Minute), now)
+	if assert.Equal(t, 1, len(timelines)) {
+		assert.Equal(t, "cl1", timelines[0].ClusterID)
+		assert.Equal(t, intervals, timelines[0].Outgoing)
+		assert.Equal(t, 0, len(timelines[0].Incoming))
+	}
+}
+
+func TestStatusAt(t *testing.T) {
+	s, _ := NewStore("", 0, 0)
+	_, _, present := s.StatusAt(time.Now())
+	assert.False(t, present, "status reconstructed from an empty Store")
+	now := time.Now()
+	assert.NoError(t, s.Add(Record{Timestamp: now.Add(-3 * time.Hour), ClusterID: "cl1", ClusterName: "one",
+		Direction: DirectionOutgoing, Connected: true, CpuQuota: "2", MemQuota: "4Gi"}))
+	assert.NoError(t, s.Add(Record{Timestamp: now.Add(-2 * time.Hour), ClusterID: "cl2",
+		Direction: DirectionIncoming, Connected: true}))
+	assert.NoError(t, s.Add(Record{Timestamp: now.Add(-90 * time.Minute), ClusterID: "cl1", ClusterName: "one",
+		Direction: DirectionOutgoing, Connected: true, CpuQuota: "4", MemQuota: "8Gi"}))
+	assert.NoError(t, s.Add(Record{Timestamp: now.Add(-time.Hour), ClusterID: "cl1",
+		Direction: DirectionOutgoing, Connected: false}))
+	peers, oldest, present := s.StatusAt(now.Add(-150 * time.Minute))
+	assert.True(t, present)
+	assert.True(t, oldest.Equal(now.Add(-3*time.Hour)))
+	assert.Equal(t, []PeerState{
+		{ClusterID: "cl1", ClusterName: "one", OutConnected: true, CpuQuota: "2", MemQuota: "4Gi"},
+	}, peers)
+	peers, _, _ = s.StatusAt(now.Add(-80 * time.Minute))
+	if assert.Len(t, peers, 2) {
+		assert.Equal(t, "4", peers[0].CpuQuota, "resources change not reconstructed")
+	}
+	//the peers without connected peerings are not returned
+	peers, _, _ = s.StatusAt(now)
+	assert.Equal(t, []PeerState{{ClusterID: "cl2", InConnected: true}}, peers)
+	peers, _, _ = s.StatusAt(now.Add(-4 * time.Hour))
+	assert.Empty(t, peers)
+}
+
+func TestStore_Compaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liqo-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, FileName)
+	s, _ := NewStore(path, 10, time.Hour)
+	lines := func() int {
+		data, _ := ioutil.ReadFile(path)
+		return bytes.Count(data, []byte("\n"))
+	}
+	now := time.Now()
+	compactions := 0
+	for n := 0; n < 50; n++ {
+		before := lines()
+		assert.NoError(t, s.Add(Record{Timestamp: now.Add(time.Duration(n) * time.Second), ClusterID: "cl1",
+			Direction: DirectionOutgoing, Connected: n%2 == 0}))
+		if lines() <= before {
+			compactions++
+		}
+		assert.LessOrEqual(t, lines(), 12, "file not compacted")
+		assert.LessOrEqual(t, len(s.Records("", time.Time{})), 10, "store exceeded its maximum size")
+	}
+	//the file is compacted in batches, once it contains more than 2 (20% of 10) removed records
+	assert.Equal(t, 13, compactions)
+	s2, _ := NewStore(path, 10, time.Hour)
+	stored, loaded := s.Records("", time.Time{}), s2.Records("", time.Time{})
+	if assert.Equal(t, len(stored), len(loaded), "loaded records differ from stored ones") {
+		for i := range stored {
+			assert.True(t, stored[i].Timestamp.Equal(loaded[i].Timestamp), "loaded record differs from stored one")
+		}
+	}
+}
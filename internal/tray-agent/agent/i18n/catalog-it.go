@@ -0,0 +1,173 @@
+package i18n
+
+//catalogIt is the Italian Catalog.
+var catalogIt = Catalog{
+	//menu
+	"Start LiqoAgent":                     "Avvia LiqoAgent",
+	"Stop LiqoAgent":                      "Arresta LiqoAgent",
+	"Set {} mode":                         "Imposta la modalità {}",
+	"Kubeconfig context":                  "Contesto del kubeconfig",
+	"Kubeconfig context: {}":              "Contesto del kubeconfig: {}",
+	"Peering request from {}":             "Richiesta di peering da {}",
+	"Peering request from {} (+{} more)":  "Richiesta di peering da {} (+{} altre)",
+	"Accept":                              "Accetta",
+	"Peer with a cluster…":                "Esegui il peering con un cluster…",
+	"Reject":                              "Rifiuta",
+	"Onboarding {}: {}/{} steps":          "Avvio di {}: {}/{} passi",
+	"Onboarding {}: {}/{} steps, failing": "Avvio di {}: {}/{} passi, in errore",
+	"Onboarding {}: {}/{} steps, stalled": "Avvio di {}: {}/{} passi, bloccato",
+	"Onboarding {}: ready":                "Avvio di {}: pronto",
+	"Onboarding {}: peer removed":         "Avvio di {}: peer rimosso",
+	"Establishing peering with {}":        "Peering con {} in corso",
+	"Resource sharing":                    "Condivisione delle risorse",
+	"Resource sharing: {}%":               "Condivisione delle risorse: {}%",
+	"Custom…":                             "Personalizzata…",
+	"Custom ({}%)…":                       "Personalizzata ({}%)…",
+	"Show more… ({})":                     "Mostra altri… ({})",
+	"Peers":                               "Peer",
+	"Resources":                           "Risorse",
+	"Offloading":                          "Offloading",
+	"Namespaces":                          "Namespace",
+	"Diagnostics":                         "Diagnostica",
+	"Maintenance":                         "Manutenzione",
+	"Settings":                            "Impostazioni",
+	"Open the Liqo Agent menu":            "Apri il menu di Liqo Agent",
+	"Turn Liqo on and off":                "Attiva e disattiva Liqo",
+	"Refresh the Liqo Agent":              "Aggiorna Liqo Agent",
+	"Clusters":                            "Cluster",
+	"• Reconnect":                         "• Riconnetti",
+	"Reconnect now":                       "Riconnetti ora",
+	"Reconnect now (next attempt at {})":  "Riconnetti ora (prossimo tentativo alle {})",
+	"Offline: last known status, {}":      "Offline: ultimo stato noto, {}",
+	"Export topology":                     "Esporta la topologia",
+	"Peering history":                     "Storico dei peering",
+	"Storage":                             "Storage",
+	"Capacity":                            "Capacità",
+	"Status…":                             "Stato…",
+	"Credentials":                         "Credenziali",
+	"• Refresh credentials":               "• Rinnova le credenziali",
+	"• Log in again ({})":                 "• Accedi di nuovo ({})",
+	"Cluster health":                      "Salute del cluster",
+	"Activity":                            "Attività",
+	"Background tasks":                    "Attività in background",
+	"Upgrade Liqo…":                       "Aggiorna Liqo…",
+	"Update Liqo Agent…":                  "Aggiorna Liqo Agent…",
+	"Uninstall Liqo…":                     "Disinstalla Liqo…",
+	"Reset Agent…":                        "Reimposta l'Agent…",
+	"Settings…":                           "Impostazioni…",
+	"Notifications Settings":              "Impostazioni delle notifiche",
+	"Quiet hours":                         "Ore di silenzio",
+	"Icon Theme Settings":                 "Tema dell'icona",
+	"Group Peers By…":                     "Raggruppa i peer per…",
+	"Search Peers…":                       "Cerca peer…",
+	"Search Peers: \"{}\"":                "Cerca peer: \"{}\"",
+	"Read-only Mode":                      "Modalità di sola lettura",
+	"Startup":                             "Avvio",
+	"Start at login":                      "Avvia all'accesso",
+	"Logs":                                "Log",
+	"Debug logging":                       "Log di debug",
+	"Open log file":                       "Apri il file di log",
+	"Export support bundle…":              "Esporta il pacchetto per il supporto…",
+	"Language…":                           "Lingua…",
+	"Customize menu…":                     "Personalizza il menu…",
+	"Help":                                "Aiuto",
+	"Quit":                                "Esci",
+	"Other":                               "Altro",
+	//peer entries
+	"• Insert auth token manually":    "• Inserisci il token di autenticazione",
+	"OUTGOING PEERING":                "PEERING IN USCITA",
+	"INCOMING PEERING":                "PEERING IN INGRESSO",
+	"PEERING DETAILS":                 "DETTAGLI DEL PEERING",
+	"• Request peering":               "• Richiedi il peering",
+	"• Stop peering":                  "• Interrompi il peering",
+	"• Open terminal here":            "• Apri un terminale qui",
+	"• Verify identity…":              "• Verifica l'identità…",
+	"• Collect remote diagnostics…":   "• Raccogli la diagnostica remota…",
+	"• Rename…":                       "• Rinomina…",
+	"• Add to Favorites":              "• Aggiungi ai preferiti",
+	"• Remove from Favorites":         "• Rimuovi dai preferiti",
+	"Copy to Clipboard":               "Copia negli appunti",
+	"• Local cluster ID":              "• ID del cluster locale",
+	"• Join command":                  "• Comando di collegamento",
+	"• Copy virtual node kubeconfig":  "• Copia il kubeconfig del nodo virtuale",
+	"no connections in the last days": "nessuna connessione negli ultimi giorni",
+	"STORAGE CLASSES":                 "STORAGE CLASS",
+	"VOLUME CLAIMS":                   "VOLUME CLAIM",
+	"OFFLOADING WARNINGS":             "AVVISI DI OFFLOADING",
+	//notifications
+	"Liqo Agent is now connected to the context {}":      "Liqo Agent è ora connesso al contesto {}",
+	"Liqo Agent is now connected to the cluster of {}":   "Liqo Agent è ora connesso al cluster di {}",
+	"LIQO AGENT - INVALID CONFIGURATION":                 "LIQO AGENT - CONFIGURAZIONE NON VALIDA",
+	"The peering request from {} has been accepted":      "La richiesta di peering da {} è stata accettata",
+	"The peering request from {} has been rejected":      "La richiesta di peering da {} è stata rifiutata",
+	"Liqo Agent: PEERING REQUEST NOT UPDATED":            "Liqo Agent: RICHIESTA DI PEERING NON AGGIORNATA",
+	"Liqo Agent: PEERING REQUEST":                        "Liqo Agent: RICHIESTA DI PEERING",
+	"{} requests an incoming peering":                    "{} richiede un peering in ingresso",
+	"Accept peering":                                     "Accetta il peering",
+	"Dismiss":                                            "Ignora",
+	"The peering with {} has been started":               "Il peering con {} è stato avviato",
+	"The peering with {} is ready to host pods":          "Il peering con {} è pronto a ospitare pod",
+	"Liqo Agent: PEERING FAILED":                         "Liqo Agent: PEERING NON RIUSCITO",
+	"The outgoing peering with {} cannot be established": "Il peering in uscita con {} non può essere stabilito",
+	"The incoming peering with {} cannot be established": "Il peering in ingresso con {} non può essere stabilito",
+	"Liqo Agent: TUNNEL DEGRADED":                        "Liqo Agent: TUNNEL DEGRADATO",
+	"The connection with {} is degraded: {}":             "La connessione con {} è degradata: {}",
+	"Liqo Agent: STARTUP ACTIONS":                        "Liqo Agent: AZIONI DI AVVIO",
+	"Liqo Agent: STARTUP ACTIONS FAILED":                 "Liqo Agent: AZIONI DI AVVIO NON RIUSCITE",
+	"Liqo Agent: INVALID DO NOT DISTURB THRESHOLD":       "Liqo Agent: SOGLIA DI NON DISTURBARE NON VALIDA",
+	"Liqo Agent: METRICS REMOTE WRITE UNAVAILABLE":       "Liqo Agent: INVIO REMOTO DELLE METRICHE NON DISPONIBILE",
+	"Liqo Agent: {} NOTIFICATIONS WHILE LOCKED":          "Liqo Agent: {} NOTIFICHE A SESSIONE BLOCCATA",
+	"and {} more":         "e altre {}",
+	"Do Not Disturb: ON":  "Non disturbare: ATTIVO",
+	"Do Not Disturb: OFF": "Non disturbare: DISATTIVATO",
+	"All the notifications are displayed as banners":                  "Tutte le notifiche sono mostrate come banner",
+	"Only the error notifications are displayed as banners":           "Solo le notifiche di errore sono mostrate come banner",
+	"Only the {} and error notifications are displayed as banners":    "Solo le notifiche di tipo {} e di errore sono mostrate come banner",
+	"{} startup actions completed":                                    "{} azioni di avvio completate",
+	"Liqo Agent: PEERING ONBOARDING":                                  "Liqo Agent: AVVIO DEL PEERING",
+	"Liqo Agent: PEERING ONBOARDING FAILED":                           "Liqo Agent: AVVIO DEL PEERING NON RIUSCITO",
+	"Liqo Agent: PEERING ONBOARDING STALLED":                          "Liqo Agent: AVVIO DEL PEERING BLOCCATO",
+	"Hide the onboarding checklist":                                   "Nascondi l'elenco dei passi di avvio",
+	"Liqo Agent: CONTEXT SWITCH FAILED":                               "Liqo Agent: CAMBIO DI CONTESTO NON RIUSCITO",
+	"Liqo Agent: KUBECONFIG SWITCH FAILED":                            "Liqo Agent: CAMBIO DI KUBECONFIG NON RIUSCITO",
+	"Liqo Agent: KUBECONFIG RELOAD FAILED":                            "Liqo Agent: RICARICAMENTO DEL KUBECONFIG NON RIUSCITO",
+	"Liqo Agent: AUTHENTICATION FAILED":                               "Liqo Agent: AUTENTICAZIONE NON RIUSCITA",
+	"The cluster rejected the credentials of the kubeconfig file: {}": "Il cluster ha rifiutato le credenziali del file kubeconfig: {}",
+	"Retry":                                               "Riprova",
+	"Liqo Agent: LOGIN REQUIRED":                          "Liqo Agent: ACCESSO RICHIESTO",
+	"Token expired, re-login required: {}":                "Token scaduto, è necessario accedere di nuovo: {}",
+	"Log in":                                              "Accedi",
+	"Liqo Agent: PEERING COMMAND FAILED":                  "Liqo Agent: COMANDO DI PEERING NON RIUSCITO",
+	"Liqo Agent: LIQO COMPONENT FAILING":                  "Liqo Agent: COMPONENTE DI LIQO IN ERRORE",
+	"Liqo Agent: PEER IDENTITY CHANGED":                   "Liqo Agent: IDENTITÀ DEL PEER CAMBIATA",
+	"Liqo Agent: OFFLOADED WORKLOAD FAILING":              "Liqo Agent: CARICO DI LAVORO REMOTO IN ERRORE",
+	"Liqo Agent: {} CHANGED ITS OFFER":                    "Liqo Agent: {} HA CAMBIATO LA SUA OFFERTA",
+	"Liqo Agent: RESET COMPLETED":                         "Liqo Agent: REIMPOSTAZIONE COMPLETATA",
+	"Liqo Agent: LIQO UNINSTALLED":                        "Liqo Agent: LIQO DISINSTALLATO",
+	"The selected data have been cleared":                 "I dati selezionati sono stati cancellati",
+	"Liqo has been removed from the cluster":              "Liqo è stato rimosso dal cluster",
+	"The network requires sign-in":                        "La rete richiede l'accesso",
+	"The peering topology was exported to {}":             "La topologia dei peering è stata esportata in {}",
+	"The remote diagnostics of the peer were saved to {}": "La diagnostica remota del peer è stata salvata in {}",
+	"The LiqoDash access token was copied in your clipboard": "Il token di accesso a LiqoDash è stato copiato " +
+		"negli appunti",
+	"LiqoDash access token was not found":        "Il token di accesso a LiqoDash non è stato trovato",
+	"Liqo Agent could not save settings changes": "Liqo Agent non è riuscito a salvare le impostazioni",
+	"NEW OUTGOING PEERING ESTABLISHED":           "NUOVO PEERING IN USCITA STABILITO",
+	"NEW PEERING ACCEPTED":                       "NUOVO PEERING ACCETTATO",
+	"OUTGOING PEERING CLOSED":                    "PEERING IN USCITA CHIUSO",
+	"INCOMING PEERING CLOSED":                    "PEERING IN INGRESSO CHIUSO",
+	"{} is now sharing its resources":            "{} sta ora condividendo le sue risorse",
+	"You are now sharing resources to {}":        "Stai ora condividendo le tue risorse con {}",
+	"{} resources are no more available":         "Le risorse di {} non sono più disponibili",
+	"You stopped sharing resources to {}":        "Hai smesso di condividere le tue risorse con {}",
+	"The kubeconfig of the virtual node was copied to your clipboard": "Il kubeconfig del nodo virtuale è stato " +
+		"copiato negli appunti",
+	"The local cluster ID was copied to your clipboard": "L'ID del cluster locale è stato copiato negli appunti",
+	"The join command was copied to your clipboard":     "Il comando di collegamento è stato copiato negli appunti",
+	"Liqo Agent: cannot copy the kubeconfig of the virtual node": "Liqo Agent: impossibile copiare il kubeconfig " +
+		"del nodo virtuale",
+	"Liqo Agent: cannot copy the local cluster ID":    "Liqo Agent: impossibile copiare l'ID del cluster locale",
+	"Liqo Agent: cannot copy the join command":        "Liqo Agent: impossibile copiare il comando di collegamento",
+	"Liqo Agent could not copy to the clipboard:\n{}": "Liqo Agent non è riuscito a copiare negli appunti:\n{}",
+}
@@ -0,0 +1,12 @@
+/*
+Package i18n provides the message catalogs translating the menu, the notifications and the dialog boxes of Liqo Agent.
+
+The messages are written in English across the Agent and translated by T() when they are displayed, so that the
+language can be changed at runtime with SetLanguage(): the Indicator keeps the English messages and renders them
+again in the new language (see OnChange). The entries of a Catalog may contain "{}" placeholders, matching the
+variable parts of a message (e.g. "Peering request from {}").
+
+The language is detected from the LC_ALL, LC_MESSAGES and LANG environment variables (e.g. "it" with it_IT.UTF-8):
+the messages missing from its Catalog are displayed in English.
+*/
+package i18n
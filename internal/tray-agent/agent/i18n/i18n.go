@@ -0,0 +1,214 @@
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//DefaultLanguage is the language the messages are written in, used when the one of the user has no Catalog.
+const DefaultLanguage = "en"
+
+//placeholder matches the variable part of a message in the entries of a Catalog.
+const placeholder = "{}"
+
+//Catalog maps the English messages into their translation in a language.
+type Catalog map[string]string
+
+//pattern is a Catalog entry containing placeholders.
+type pattern struct {
+	regexp *regexp.Regexp
+	//parts contains the text of the translation around the placeholders.
+	parts []string
+}
+
+//catalogs contains the Catalog of each supported language, except DefaultLanguage.
+var catalogs = map[string]Catalog{
+	"it": catalogIt,
+}
+
+//languageNames contains the names of the supported languages, in the language itself.
+var languageNames = map[string]string{
+	DefaultLanguage: "English",
+	"it":            "Italiano",
+}
+
+//localeEnvs are the environment variables specifying the language of the user, by decreasing priority.
+var localeEnvs = []string{"LC_ALL", "LC_MESSAGES", "LANG"}
+
+//state contains the current language, with the patterns of its Catalog and the callbacks notified of its changes.
+var state = struct {
+	language  string
+	patterns  []pattern
+	callbacks []func()
+	sync.RWMutex
+}{language: DefaultLanguage}
+
+func init() {
+	_ = SetLanguage(DetectLanguage())
+}
+
+//RegisterCatalog adds the Catalog of a language, replacing the existing one. The name of the language is displayed
+//in the language selection.
+func RegisterCatalog(language string, name string, c Catalog) {
+	state.Lock()
+	defer state.Unlock()
+	catalogs[language] = c
+	languageNames[language] = name
+	if state.language == language {
+		state.patterns = compilePatterns(c)
+	}
+}
+
+//Languages returns the supported languages, DefaultLanguage first.
+func Languages() []string {
+	state.RLock()
+	defer state.RUnlock()
+	languages := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		languages = append(languages, l)
+	}
+	sort.Strings(languages)
+	return append([]string{DefaultLanguage}, languages...)
+}
+
+//LanguageName returns the name of a supported language in the language itself, e.g. "Italiano".
+func LanguageName(language string) string {
+	state.RLock()
+	defer state.RUnlock()
+	if name, present := languageNames[language]; present {
+		return name
+	}
+	return language
+}
+
+//ParseLanguage returns the supported language of a POSIX locale name, e.g. "it" for "it_IT.UTF-8". An unsupported
+//or empty name results in DefaultLanguage.
+func ParseLanguage(name string) string {
+	if index := strings.IndexAny(name, "_.@"); index >= 0 {
+		name = name[:index]
+	}
+	language := strings.ToLower(name)
+	state.RLock()
+	defer state.RUnlock()
+	if _, present := catalogs[language]; !present {
+		return DefaultLanguage
+	}
+	return language
+}
+
+//DetectLanguage returns the supported language of the user, taken from the environment.
+func DetectLanguage() string {
+	for _, env := range localeEnvs {
+		if name := os.Getenv(env); name != "" {
+			return ParseLanguage(name)
+		}
+	}
+	return DefaultLanguage
+}
+
+//Language returns the language the messages are translated into.
+func Language() string {
+	state.RLock()
+	defer state.RUnlock()
+	return state.language
+}
+
+//SetLanguage changes the language the messages are translated into, notifying the OnChange callbacks if it changed.
+func SetLanguage(language string) error {
+	state.Lock()
+	c, present := catalogs[language]
+	if !present && language != DefaultLanguage {
+		state.Unlock()
+		return fmt.Errorf("language %s not supported", language)
+	}
+	if language == state.language {
+		state.Unlock()
+		return nil
+	}
+	state.language = language
+	state.patterns = compilePatterns(c)
+	callbacks := append([]func(){}, state.callbacks...)
+	state.Unlock()
+	for _, callback := range callbacks {
+		callback()
+	}
+	return nil
+}
+
+//OnChange registers a callback invoked after each change of the language.
+func OnChange(callback func()) {
+	state.Lock()
+	defer state.Unlock()
+	state.callbacks = append(state.callbacks, callback)
+}
+
+//T returns the translation of a message in the current language, keeping its indentation. The messages missing
+//from its Catalog are returned unchanged.
+func T(message string) string {
+	trimmed := strings.TrimLeft(message, " ")
+	if trimmed == "" {
+		return message
+	}
+	return message[:len(message)-len(trimmed)] + translate(trimmed)
+}
+
+//translate returns the translation of a message in the current language.
+func translate(message string) string {
+	state.RLock()
+	defer state.RUnlock()
+	if state.language == DefaultLanguage {
+		return message
+	}
+	if translation, present := catalogs[state.language][message]; present {
+		return translation
+	}
+	for _, p := range state.patterns {
+		matches := p.regexp.FindStringSubmatch(message)
+		if matches == nil {
+			continue
+		}
+		str := strings.Builder{}
+		str.WriteString(p.parts[0])
+		for index, part := range p.parts[1:] {
+			if index+1 < len(matches) {
+				str.WriteString(matches[index+1])
+			}
+			str.WriteString(part)
+		}
+		return str.String()
+	}
+	return message
+}
+
+//compilePatterns returns the patterns of the Catalog entries containing placeholders, the longest first so that
+//the most specific entry is used.
+func compilePatterns(c Catalog) []pattern {
+	keys := make([]string, 0)
+	for key := range c {
+		if strings.Contains(key, placeholder) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	patterns := make([]pattern, 0, len(keys))
+	for _, key := range keys {
+		literals := strings.Split(key, placeholder)
+		for index := range literals {
+			literals[index] = regexp.QuoteMeta(literals[index])
+		}
+		patterns = append(patterns, pattern{
+			regexp: regexp.MustCompile("(?s)^" + strings.Join(literals, "(.+?)") + "$"),
+			parts:  strings.Split(c[key], placeholder),
+		})
+	}
+	return patterns
+}
@@ -0,0 +1,45 @@
+package i18n
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseLanguage(t *testing.T) {
+	assert.Equal(t, DefaultLanguage, ParseLanguage(""))
+	assert.Equal(t, DefaultLanguage, ParseLanguage("C"))
+	assert.Equal(t, DefaultLanguage, ParseLanguage("xx_XX.UTF-8"))
+	assert.Equal(t, "it", ParseLanguage("it_IT.UTF-8"))
+	assert.Equal(t, "it", ParseLanguage("it"))
+	assert.Equal(t, []string{DefaultLanguage, "it"}, Languages())
+	assert.Equal(t, "Italiano", LanguageName("it"))
+}
+
+func TestTranslate(t *testing.T) {
+	defer SetLanguage(Language())
+	assert.NoError(t, SetLanguage(DefaultLanguage))
+	changes := 0
+	OnChange(func() {
+		changes++
+	})
+	assert.Equal(t, "Quit", T("Quit"))
+	assert.Error(t, SetLanguage("xx"))
+	assert.NoError(t, SetLanguage("it"))
+	assert.Equal(t, 1, changes, "language change not notified")
+	assert.NoError(t, SetLanguage("it"))
+	assert.Equal(t, 1, changes, "unchanged language notified")
+	assert.Equal(t, "Esci", T("Quit"))
+	assert.Equal(t, "Not in the catalog", T("Not in the catalog"))
+	assert.Equal(t, "", T(""))
+	//the indentation is kept
+	assert.Equal(t, "   • Verifica l'identità…", T("   • Verify identity…"))
+	//placeholders
+	assert.Equal(t, "Richiesta di peering da cluster-1", T("Peering request from cluster-1"))
+	assert.Equal(t, "Richiesta di peering da cluster-1 (+2 altre)", T("Peering request from cluster-1 (+2 more)"))
+	RegisterCatalog("it", "Italiano", Catalog{"{} of {}": "{} di {}"})
+	assert.Equal(t, "uno di due", T("uno of due"))
+	assert.Equal(t, "Quit", T("Quit"), "replaced Catalog still used")
+	RegisterCatalog("it", "Italiano", catalogIt)
+	assert.NoError(t, SetLanguage(DefaultLanguage))
+	assert.Equal(t, "Peering request from cluster-1", T("Peering request from cluster-1"))
+}
@@ -0,0 +1,16 @@
+/*
+Package logging provides the structured logging of Liqo Agent.
+
+Install() routes the logs of the Agent and of its dependencies (standard library logger and klog) through the
+redaction layer (see package redact) to stderr and to a size-rotated log file in the XDG state directory
+(e.g. ~/.local/state/liqo/agent.log, see package xdg).
+
+Each component of the Agent logs through its own Logger, writing single-line entries made of a message followed
+by key=value pairs, e.g.:
+
+	I0420 18:30:00.000000   1234 hotkey.go:79] hotkey activated component="indicator" id="status"
+
+Debug entries are discarded unless the debug logging is enabled at runtime with SetDebug(), which also raises the
+verbosity of the libraries using klog.
+*/
+package logging
@@ -0,0 +1,209 @@
+package logging
+
+import (
+	"flag"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/redact"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/xdg"
+	"io"
+	klogv1 "k8s.io/klog"
+	"k8s.io/klog/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	//FileName is the basename of the Agent log file.
+	FileName = "agent.log"
+	//MaxFileSize is the size (in bytes) the log file is rotated at.
+	MaxFileSize = 10 << 20
+	//MaxBackups is the number of rotated log files kept besides the current one (agent.log.1 being the newest).
+	MaxBackups = 3
+	//DebugVerbosity is the klog verbosity level of the debug entries.
+	DebugVerbosity = 4
+)
+
+//logFile is the log file currently written, if any.
+var logFile struct {
+	file *rotatingFile
+	sync.Mutex
+}
+
+//klogFlags contains the flags of klog and klog/v2, registered once: registering them again would write the settings
+//read by the concurrent log calls.
+var klogFlags = struct {
+	v1 *flag.FlagSet
+	v2 *flag.FlagSet
+}{
+	v1: flag.NewFlagSet("klog", flag.ContinueOnError),
+	v2: flag.NewFlagSet("klog/v2", flag.ContinueOnError),
+}
+
+func init() {
+	klogv1.InitFlags(klogFlags.v1)
+	klog.InitFlags(klogFlags.v2)
+}
+
+//Dir returns the directory of the Agent log files, xdg.DirState (by default ~/.local/state/liqo). If it is not
+//available, the logs are written to the liqo subdirectory of the temporary directory.
+func Dir() string {
+	if dir := xdg.DirState.Path(); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "liqo")
+}
+
+//Path returns the path of the current Agent log file.
+func Path() string {
+	return filepath.Join(Dir(), FileName)
+}
+
+//FileNames returns the basenames of the Agent log files, from the current one to the oldest rotated one.
+func FileNames() []string {
+	names := make([]string, 0, MaxBackups+1)
+	for n := 0; n <= MaxBackups; n++ {
+		names = append(names, backupName(FileName, n))
+	}
+	return names
+}
+
+//Files returns the paths of the existing Agent log files, from the current one to the oldest rotated one.
+func Files() []string {
+	var files []string
+	for _, name := range FileNames() {
+		path := filepath.Join(Dir(), name)
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+//Install routes the logs of the Agent and of its dependencies, redacted, to stderr and to the log file (see Path).
+//If the log file cannot be opened, the logs are written only to stderr and the error is returned.
+func Install(stderr io.Writer) error {
+	file, err := openRotatingFile(Path(), MaxFileSize, MaxBackups)
+	if err != nil {
+		redact.Install(stderr)
+		return err
+	}
+	logFile.Lock()
+	if logFile.file != nil {
+		_ = logFile.file.Close()
+	}
+	logFile.file = file
+	logFile.Unlock()
+	redact.Install(io.MultiWriter(stderr, file))
+	return nil
+}
+
+//Close flushes the pending entries and closes the log file. The following entries are written only to stderr.
+func Close() error {
+	klogv1.Flush()
+	klog.Flush()
+	logFile.Lock()
+	defer logFile.Unlock()
+	if logFile.file == nil {
+		return nil
+	}
+	err := logFile.file.Close()
+	logFile.file = nil
+	return err
+}
+
+//SetDebug enables or disables at runtime the debug entries of the Agent, raising (or restoring) the verbosity of
+//the libraries using klog as well.
+func SetDebug(enabled bool) {
+	level := "0"
+	if enabled {
+		level = strconv.Itoa(DebugVerbosity)
+	}
+	_ = klogFlags.v1.Set("v", level)
+	_ = klogFlags.v2.Set("v", level)
+}
+
+//DebugEnabled returns whether the debug entries are logged.
+func DebugEnabled() bool {
+	return klog.V(DebugVerbosity).Enabled()
+}
+
+//Logger writes the structured log entries of a component of the Agent.
+type Logger struct {
+	//values contains the key=value pairs added to each entry.
+	values []interface{}
+}
+
+//New returns the Logger of a component of the Agent (e.g. "indicator"), adding it to each entry.
+func New(component string) *Logger {
+	return &Logger{values: []interface{}{"component", component}}
+}
+
+//With returns a copy of the Logger adding the key=value pairs to each entry.
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+	return &Logger{values: append(append([]interface{}{}, l.values...), keysAndValues...)}
+}
+
+//Debug logs a debug entry, discarded unless the debug logging is enabled (see SetDebug).
+func (l *Logger) Debug(msg string, keysAndValues ...interface{}) {
+	if klog.V(DebugVerbosity).Enabled() {
+		klog.InfoDepth(1, l.entry(msg, nil, keysAndValues))
+	}
+}
+
+//Info logs an informational entry.
+func (l *Logger) Info(msg string, keysAndValues ...interface{}) {
+	klog.InfoDepth(1, l.entry(msg, nil, keysAndValues))
+}
+
+//Warning logs a warning entry.
+func (l *Logger) Warning(msg string, keysAndValues ...interface{}) {
+	klog.WarningDepth(1, l.entry(msg, nil, keysAndValues))
+}
+
+//Error logs an error entry, reporting err.
+func (l *Logger) Error(err error, msg string, keysAndValues ...interface{}) {
+	klog.ErrorDepth(1, l.entry(msg, err, keysAndValues))
+}
+
+//Fatal logs an error entry, reporting err, and terminates the program.
+func (l *Logger) Fatal(err error, msg string, keysAndValues ...interface{}) {
+	klog.FatalDepth(1, l.entry(msg, err, keysAndValues))
+}
+
+//entry returns the text of an entry: the message, the error (if any) and the key=value pairs of the Logger and of
+//the call, e.g. 'cache restarted err="timeout" component="client" cluster="home"'.
+func (l *Logger) entry(msg string, err error, keysAndValues []interface{}) string {
+	b := &strings.Builder{}
+	b.WriteString(msg)
+	if err != nil {
+		writeValues(b, []interface{}{"err", err})
+	}
+	writeValues(b, l.values)
+	writeValues(b, keysAndValues)
+	return b.String()
+}
+
+//writeValues writes a list of key=value pairs. The strings (and the values describing themselves as strings) are
+//quoted, and a missing value is reported as such.
+func writeValues(b *strings.Builder, keysAndValues []interface{}) {
+	for n := 0; n < len(keysAndValues); n += 2 {
+		var value interface{} = "(MISSING)"
+		if n+1 < len(keysAndValues) {
+			value = keysAndValues[n+1]
+		}
+		fmt.Fprintf(b, " %v=", keysAndValues[n])
+		switch v := value.(type) {
+		case string:
+			b.WriteString(strconv.Quote(v))
+		case error:
+			b.WriteString(strconv.Quote(v.Error()))
+		case fmt.Stringer:
+			b.WriteString(strconv.Quote(v.String()))
+		default:
+			fmt.Fprintf(b, "%+v", v)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"k8s.io/klog/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liqo-logging")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "logs", FileName)
+	r, err := openRotatingFile(path, 10, 2)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err = r.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, r.Close())
+	read := func(n int) string {
+		data, _ := ioutil.ReadFile(backupName(path, n))
+		return string(data)
+	}
+	assert.Equal(t, "fourth\n", read(0))
+	assert.Equal(t, "third\n", read(1))
+	assert.Equal(t, "second\n", read(2))
+	_, err = os.Stat(backupName(path, 3))
+	assert.True(t, os.IsNotExist(err), "too many backups kept")
+	_, err = r.Write([]byte("closed\n"))
+	assert.Error(t, err, "write to closed file succeeded")
+	//an existing file is appended
+	r, err = openRotatingFile(path, 100, 2)
+	assert.NoError(t, err)
+	_, _ = r.Write([]byte("fifth\n"))
+	_ = r.Close()
+	assert.Equal(t, "fourth\nfifth\n", read(0))
+}
+
+func TestLogger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liqo-logging")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	_ = os.Setenv("XDG_STATE_HOME", dir)
+	defer os.Unsetenv("XDG_STATE_HOME")
+	assert.Equal(t, filepath.Join(dir, "liqo", FileName), Path())
+	stderr := &bytes.Buffer{}
+	if !assert.NoError(t, Install(stderr)) {
+		return
+	}
+	defer SetDebug(false)
+	l := New("test").With("cluster", "home")
+	l.Info("cache restarted", "attempt", 2, "header", "Bearer abc")
+	l.Debug("hidden entry")
+	SetDebug(true)
+	assert.True(t, DebugEnabled())
+	l.Debug("visible entry")
+	l.Error(errors.New("timeout"), "connection failed", "missing")
+	SetDebug(false)
+	assert.False(t, DebugEnabled())
+	klog.Flush()
+	assert.NoError(t, Close())
+	data, err := ioutil.ReadFile(Path())
+	assert.NoError(t, err)
+	out := string(data)
+	assert.Equal(t, stderr.String(), out, "stderr and log file differ")
+	assert.Contains(t, out, `cache restarted component="test" cluster="home" attempt=2 header="Bearer [REDACTED]"`)
+	assert.NotContains(t, out, "hidden entry")
+	assert.Contains(t, out, "visible entry")
+	assert.Contains(t, out, `connection failed err="timeout" component="test" cluster="home" missing="(MISSING)"`)
+	assert.Equal(t, 3, strings.Count(out, "\n"))
+	assert.Equal(t, []string{Path()}, Files())
+}
+
+func TestSetDebugConcurrently(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liqo-logging")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	_ = os.Setenv("XDG_STATE_HOME", dir)
+	defer os.Unsetenv("XDG_STATE_HOME")
+	if !assert.NoError(t, Install(ioutil.Discard)) {
+		return
+	}
+	defer Close()
+	defer SetDebug(false)
+	//the entries are logged while the debug logging is toggled
+	l := New("test")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for k := 0; k < 100; k++ {
+			l.Debug("debug entry", "n", k)
+			l.Info("info entry", "n", k)
+		}
+	}()
+	for k := 0; k < 100; k++ {
+		SetDebug(k%2 == 0)
+	}
+	<-done
+	SetDebug(true)
+	assert.True(t, DebugEnabled())
+}
@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+//rotatingFile is an io.Writer appending to a file, which is rotated once it reaches its maximum size: the current
+//file becomes the first backup (path.1), the existing backups are shifted and the oldest one is removed.
+type rotatingFile struct {
+	path    string
+	maxSize int64
+	backups int
+	file    *os.File
+	//size is the size of the current file.
+	size  int64
+	mutex sync.Mutex
+}
+
+//openRotatingFile opens (or creates) a rotatingFile, creating its directory if needed.
+func openRotatingFile(path string, maxSize int64, backups int) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &rotatingFile{path: path, maxSize: maxSize, backups: backups, file: file, size: info.Size()}, nil
+}
+
+//backupName returns the path of the n-th backup of a rotatingFile, the file itself for n == 0.
+func backupName(path string, n int) string {
+	if n == 0 {
+		return path
+	}
+	return path + "." + strconv.Itoa(n)
+}
+
+//Write appends p to the file, rotating it first if p would exceed its maximum size. Each Write call is kept
+//in a single file.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+//rotate shifts the backups and starts a new, empty, file.
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+	for n := r.backups; n > 0; n-- {
+		_ = os.Rename(backupName(r.path, n-1), backupName(r.path, n))
+	}
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	r.file, r.size = file, 0
+	return nil
+}
+
+//Close closes the file. The following writes fail.
+func (r *rotatingFile) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
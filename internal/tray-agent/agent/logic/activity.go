@@ -0,0 +1,45 @@
+package logic
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/api"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strconv"
+)
+
+const (
+	//titleActivity is the title of the QUICK showing the activity feed.
+	titleActivity = "Activity"
+	//activityMenuEntries is the maximum number of activity.Entry displayed in the tray menu.
+	activityMenuEntries = 10
+	//activityTimeLayout is the layout used to display the time of an activity.Entry.
+	activityTimeLayout = "Jan 02 15:04"
+)
+
+//refreshActivity updates the content of the activity QUICK with the most recent entries of the activity feed.
+func refreshActivity(quick *app.MenuNode, feed *activity.Feed) {
+	entries := feed.Entries()
+	quick.FreeListChildren()
+	quick.SetIsEnabled(len(entries) > 0)
+	for index, e := range entries {
+		if index == activityMenuEntries {
+			break
+		}
+		var mark string
+		switch e.Outcome {
+		case activity.OutcomeSuccess:
+			mark = "✔ "
+		case activity.OutcomeFailure:
+			mark = "✖ "
+		default:
+			mark = "• "
+		}
+		title := mark + e.Timestamp.Format(activityTimeLayout) + " " + e.Message
+		quick.UseListChild(title, strconv.Itoa(index)).SetIsEnabled(false)
+	}
+}
+
+//publishActivity streams to the local API clients a new entry of the activity feed.
+func publishActivity(e *activity.Entry) {
+	api.GetServer().Publish(api.NewEvent(api.EventActivity, e))
+}
@@ -0,0 +1,80 @@
+package logic
+
+import (
+	"context"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+)
+
+/*This file contains the "Start at login" OPTION, enabling or disabling the start of the Agent at login by means of
+the mechanism of the platform (see client.AutostartProvider), e.g. the freedesktop autostart entry. Unlike the other
+settings, the choice is not saved in the local configuration: the OPTION reflects the current state of the platform,
+which the user may change also from the desktop settings. On the platforms without an AutostartProvider, the
+OPTION is disabled.*/
+
+const (
+	//oAutostart is the tag of the OPTION toggling the start of the Agent at login.
+	oAutostart = "O_AUTOSTART"
+	//activitySourceAutostart is the activity.Feed source of the changes of the start at login.
+	activitySourceAutostart = "autostart"
+)
+
+//startQuickStartup is the wrapper function to register QUICK "Startup" and its OPTION "Start at login".
+func startQuickStartup(i *app.Indicator) {
+	quick := i.AddQuick("Startup", qStartup, nil)
+	quick.AddOption("Start at login", oAutostart, "Start Liqo Agent when logging in to the desktop", true,
+		app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			optionToggleAutostart(i)
+		}))
+	updateQuickStartup(i)
+}
+
+//optionToggleAutostart is the callback for the OPTION "Start at login", enabling or disabling the start of the
+//Agent at login.
+func optionToggleAutostart(i *app.Indicator) {
+	enabled, err := client.AutostartEnabled()
+	if err == nil {
+		enabled = !enabled
+		err = client.SetAutostartEnabled(enabled)
+	}
+	updateQuickStartup(i)
+	if err != nil {
+		logger.Warning("cannot change the start at login", "err", err)
+		activity.GetFeed().Add(activitySourceAutostart, "Start at login change failed: "+err.Error(),
+			activity.OutcomeFailure)
+		i.ShowWarning("LIQO AGENT", "Liqo Agent could not change the start at login:\n"+err.Error())
+		return
+	}
+	msg := "Start at login disabled"
+	if enabled {
+		msg = "Start at login enabled"
+	}
+	activity.GetFeed().Add(activitySourceAutostart, msg, activity.OutcomeSuccess)
+}
+
+//updateQuickStartup refreshes the checkbox of the OPTION "Start at login" according to the current state of the
+//platform.
+func updateQuickStartup(i *app.Indicator) {
+	quick, present := i.Quick(qStartup)
+	if !present {
+		return
+	}
+	option, present := quick.Option(oAutostart)
+	if !present {
+		return
+	}
+	provider, supported := client.GetAutostartProvider()
+	if !supported {
+		option.SetIsChecked(false)
+		option.SetIsEnabled(false)
+		option.SetTooltip(client.ErrAutostartUnsupported.Error())
+		return
+	}
+	enabled, err := provider.Enabled()
+	if err != nil {
+		logger.Warning("cannot read the start at login", "err", err)
+	}
+	option.SetIsChecked(enabled)
+	option.SetTooltip("Start Liqo Agent when logging in to the desktop (" + provider.Name() + ")")
+}
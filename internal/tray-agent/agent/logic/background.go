@@ -0,0 +1,129 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/format"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"time"
+)
+
+/*This file contains the Diagnostics view of the background tasks of the Agent: the QUICK "Background tasks" lists
+the registered Timers (tag, period and next trigger) and Listeners (channel, handled events and last event), followed
+by the refreshes of the status and of the tray label, e.g.
+	⏱ T_HEARTBEAT: every 30s, next in 12s
+	⏸ T_UPGRADE: every 24h, paused
+	⚡ peerChanged: 12 events, last 3m ago
+	⟳ refresh: 40 refreshes, 25 requests merged, 2ms on average
+Clicking an entry pauses or resumes the task, while clicking the refreshes entry performs the pending refresh.*/
+
+const (
+	//titleBackground is the title of the QUICK listing the background tasks.
+	titleBackground = "Background tasks"
+	//tBackground is the tag of the Timer refreshing the background tasks QUICK.
+	tBackground = "T_BACKGROUND"
+	//backgroundRefreshInterval is the interval between two refreshes of the background tasks QUICK.
+	backgroundRefreshInterval = 5 * time.Second
+	//tagTimerPrefix precedes the tag of a Timer in the tag of its entry.
+	tagTimerPrefix = "timer/"
+	//tagListenerPrefix precedes the name of the channel of a Listener in the tag of its entry.
+	tagListenerPrefix = "listener/"
+	//tagRefresh is the tag of the entry of the refreshes of the status and of the tray label.
+	tagRefresh = "refresh"
+)
+
+//startQuickBackgroundTasks is the wrapper function to register QUICK "Background tasks".
+func startQuickBackgroundTasks(i *app.Indicator) {
+	node := i.AddQuick(titleBackground, qBackground, nil)
+	_ = i.StartTimer(tBackground, backgroundRefreshInterval, func(args ...interface{}) {
+		refreshBackgroundTasks(i, node)
+	})
+	refreshBackgroundTasks(i, node)
+}
+
+//refreshBackgroundTasks updates the content of the background tasks QUICK. The entries are kept across the
+//refreshes, updating their titles.
+func refreshBackgroundTasks(i *app.Indicator, quick *app.MenuNode) {
+	now := time.Now()
+	timers, listeners := i.Timers(), i.Listeners()
+	quick.SetTitle(fmt.Sprintf("%s (%s, %s)", titleBackground, format.Count(len(timers), "timer", "timers"),
+		format.Count(len(listeners), "listener", "listeners")))
+	for _, t := range timers {
+		timer := t
+		useBackgroundEntry(i, quick, tagTimerPrefix+timer.Tag(), timerDescription(timer, now), func() {
+			timer.SetActive(!timer.Active())
+		})
+	}
+	for _, l := range listeners {
+		listener := l
+		useBackgroundEntry(i, quick, tagListenerPrefix+listenerName(listener), listenerDescription(listener, now),
+			func() {
+				listener.SetPaused(!listener.Paused())
+			})
+	}
+	useBackgroundEntry(i, quick, tagRefresh, refreshDescription(i.RefreshStats()), i.FlushRefresh)
+}
+
+//refreshDescription returns the title of the entry of the refreshes of the status and of the tray label.
+func refreshDescription(stats app.RefreshStats) string {
+	if stats.Performed == 0 {
+		return "⟳ refresh: no refreshes"
+	}
+	return fmt.Sprintf("⟳ refresh: %s, %s merged, %s on average", format.Count(stats.Performed, "refresh",
+		"refreshes"), format.Count(stats.Merged(), "request", "requests"), format.Duration(stats.AverageTime()))
+}
+
+//useBackgroundEntry shows the entry of a background task, whose click runs toggle.
+func useBackgroundEntry(i *app.Indicator, quick *app.MenuNode, tag string, title string, toggle func()) {
+	if entry, present := quick.ListChild(tag); present {
+		entry.SetTitle(title)
+		return
+	}
+	entry := quick.UseListChild(title, tag)
+	entry.Connect(false, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		toggle()
+		refreshBackgroundTasks(i, quick)
+	}))
+}
+
+//timerDescription returns the title of the entry of a Timer.
+func timerDescription(t *app.Timer, now time.Time) string {
+	schedule := t.Schedule().String()
+	if t.Interval() > 0 {
+		schedule = "every " + format.Duration(t.Interval())
+	}
+	if !t.Active() {
+		return fmt.Sprintf("⏸ %s: %s, paused", t.Tag(), schedule)
+	}
+	if t.NextFire().IsZero() {
+		return fmt.Sprintf("⏱ %s: %s, done", t.Tag(), schedule)
+	}
+	return fmt.Sprintf("⏱ %s: %s, next %s", t.Tag(), schedule, format.Until(t.NextFire(), now))
+}
+
+//listenerName returns the name of a Listener, e.g. "peerDeleted", preceded by the name of its cluster for the
+//additional clusters, e.g. "staging/peerDeleted".
+func listenerName(l *app.Listener) string {
+	if l.Cluster != "" {
+		return l.Cluster + "/" + l.Tag.String()
+	}
+	return l.Tag.String()
+}
+
+//listenerDescription returns the title of the entry of a Listener.
+func listenerDescription(l *app.Listener, now time.Time) string {
+	stats := l.Stats()
+	name := listenerName(l)
+	if l.Paused() {
+		return fmt.Sprintf("⏸ %s: paused, %s skipped", name, format.Count(stats.Skipped, "event", "events"))
+	}
+	if stats.Handled == 0 {
+		return fmt.Sprintf("⚡ %s: no events", name)
+	}
+	description := fmt.Sprintf("⚡ %s: %s, last %s", name, format.Count(stats.Handled, "event", "events"),
+		format.Ago(stats.LastEvent, now))
+	if stats.Coalesced > 0 {
+		description += fmt.Sprintf(", %d coalesced", stats.Coalesced)
+	}
+	return description
+}
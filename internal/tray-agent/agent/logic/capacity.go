@@ -0,0 +1,67 @@
+package logic
+
+import (
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/format"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"time"
+)
+
+const (
+	//titleCapacity is the title of the QUICK showing the capacity overview of the home cluster.
+	titleCapacity = "Capacity"
+	//tCapacity is the tag of the Timer refreshing the capacity overview.
+	tCapacity = "T_CAPACITY"
+	//capacityRefreshInterval is the refresh interval of the capacity overview.
+	capacityRefreshInterval = 30 * time.Second
+	//gaugeWidth is the number of cells of the bar gauges displayed in the capacity overview.
+	gaugeWidth = 10
+)
+
+/*refreshCapacity updates the content of the capacity QUICK. The capacity overview has the following structure:
+	-	LOCAL: the resources of the physical nodes of the home cluster
+	-	PEERS: the resources extended by the peers through the virtual nodes, detailed for each peer
+	-	TOTAL: the overall resources of the home cluster
+Each section shows the CPU and memory requests with respect to the allocatable resources.
+*/
+func refreshCapacity(quick *app.MenuNode, report *client.CapacityReport, status app.StatusInterface) {
+	quick.FreeListChildren()
+	addCapacitySection(quick, "LOCAL", "local", &report.Local)
+	peersNode := addCapacitySection(quick, "PEERS", "peers", &report.Peers)
+	for _, peer := range report.PerPeer {
+		name := peerName(status, peer.ClusterID)
+		peerNode := peersNode.UseListChild(peerDataIndentation+name, peer.ClusterID)
+		addCapacityRows(peerNode, &peer.CapacityUsage)
+	}
+	addCapacitySection(quick, "TOTAL", "total", &report.Total)
+}
+
+//addCapacitySection adds to the capacity QUICK a section displaying a client.CapacityUsage.
+func addCapacitySection(quick *app.MenuNode, title string, tag string, usage *client.CapacityUsage) *app.MenuNode {
+	node := quick.UseListChild(fmt.Sprintf("%s (%d nodes)", title, usage.Nodes), tag)
+	addCapacityRows(node, usage)
+	return node
+}
+
+//addCapacityRows adds to a MenuNode the CPU and memory gauges of a client.CapacityUsage.
+func addCapacityRows(node *app.MenuNode, usage *client.CapacityUsage) {
+	cpu := fmt.Sprintf("%sCPU %s %s/%s cores", peerDataIndentation,
+		gauge(usage.CpuRequested, usage.CpuAllocatable, gaugeWidth),
+		format.Number(float64(usage.CpuRequested)/1000, 1), format.Number(float64(usage.CpuAllocatable)/1000, 1))
+	node.UseListChild(cpu, "cpu").SetIsEnabled(false)
+	mem := fmt.Sprintf("%sRAM %s %s/%s GiB", peerDataIndentation,
+		gauge(usage.MemRequested, usage.MemAllocatable, gaugeWidth),
+		format.Number(float64(usage.MemRequested)/(1<<30), 1), format.Number(float64(usage.MemAllocatable)/(1<<30), 1))
+	node.UseListChild(mem, "mem").SetIsEnabled(false)
+}
+
+//gauge returns a unicode bar gauge of the given width representing the used/total ratio,
+//followed by the percentage, e.g. "▕███▌░░░░░░▏ 35%" (see format.Bar).
+func gauge(used int64, total int64, width int) string {
+	ratio := 0.0
+	if total > 0 {
+		ratio = float64(used) / float64(total)
+	}
+	return format.Bar(ratio, width) + " " + format.Percent(ratio)
+}
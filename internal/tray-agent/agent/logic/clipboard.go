@@ -0,0 +1,45 @@
+package logic
+
+import (
+	"context"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+)
+
+/*This file contains the menu entries copying to the clipboard the data users hand to the administrators of other
+clusters to establish a peering: the QUICK "Copy to Clipboard" copies the cluster ID of the home cluster and the
+command starting a peering with it, while the entry of each peer copies the kubeconfig its virtual node uses.*/
+
+const (
+	//titleClipboard is the title of the QUICK copying the data about the home cluster to the clipboard.
+	titleClipboard = "Copy to Clipboard"
+	//tagClipboardClusterID is the tag of the entry copying the cluster ID of the home cluster.
+	tagClipboardClusterID = "cluster-id"
+	//tagClipboardJoin is the tag of the entry copying the command starting a peering with the home cluster.
+	tagClipboardJoin = "join"
+	//tagPeerKubeconfig is the tag of the entry of a peer copying the kubeconfig of its virtual node.
+	tagPeerKubeconfig = "kubeconfig"
+	//titlePeerKubeconfig is the title of the entry of a peer copying the kubeconfig of its virtual node.
+	titlePeerKubeconfig = "Copy virtual node kubeconfig"
+)
+
+//startQuickClipboard is the wrapper function to register the QUICK "Copy to Clipboard".
+func startQuickClipboard(i *app.Indicator) {
+	quick := i.AddQuick(titleClipboard, qClipboard, nil)
+	quick.UseListChild("• Local cluster ID", tagClipboardClusterID).ConnectCopy("local cluster ID",
+		func(ctx context.Context) (string, error) {
+			return i.AgentCtrl().LocalClusterID(ctx)
+		})
+	quick.UseListChild("• Join command", tagClipboardJoin).ConnectCopy("join command",
+		func(ctx context.Context) (string, error) {
+			return i.AgentCtrl().JoinCommand(ctx)
+		})
+}
+
+//createPeerKubeconfig creates the entry of a peer copying the kubeconfig of its virtual node.
+func createPeerKubeconfig(peerNode *app.MenuNode, clusterID string) {
+	node := peerNode.UseListChild(peerDataIndentation+"• "+titlePeerKubeconfig, tagPeerKubeconfig)
+	node.ConnectCopy("kubeconfig of the virtual node", func(ctx context.Context) (string, error) {
+		kubeconfig, err := app.GetIndicator().AgentCtrl().VirtualNodeKubeconfig(ctx, clusterID)
+		return string(kubeconfig), err
+	})
+}
@@ -0,0 +1,511 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/format"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*This file contains the sections of the additional clusters (see client.ClusterConfig). The Clusters QUICK lists
+them, each with a submenu showing its connection, its peers and their peerings, e.g.
+
+	● staging: 2 peers, ↓1 ↑1
+	    ✔ connected to staging-cluster
+	    peer-1 ↑
+	    peer-2 ↓
+	⊘ edge: unreachable
+	    ✖ connection refused
+	    • Reconnect
+
+The icon in front of each cluster summarizes its state, and the peering events of each cluster are notified naming
+the cluster, independently from the ones of the main cluster. The peers of the additional clusters are fed by
+dedicated Listeners (see app.Indicator.ListenCluster).*/
+
+const (
+	//titleClusters is the title of the QUICK listing the additional clusters.
+	titleClusters = "Clusters"
+	//titleClusterReconnect is the title of the entry retrying the connection to a cluster.
+	titleClusterReconnect = "• Reconnect"
+	//tagClusterReconnect is the tag of the entry retrying the connection to a cluster.
+	tagClusterReconnect = "reconnect"
+	//notificationClusterPrefix precedes the name of a cluster in the ID of its app.Notification values.
+	notificationClusterPrefix = "cluster/"
+)
+
+//clusterIconGlyphs contains the glyphs displaying, in the menu, the app.Icon summarizing the state of a cluster.
+var clusterIconGlyphs = map[app.Icon]string{
+	app.IconLiqoNoConn: "⊘",
+	app.IconLiqoMain:   "○",
+	app.IconLiqoPurple: "●",
+}
+
+//clusterView is the state of an additional cluster displayed in its section of the tray menu.
+type clusterView struct {
+	conf client.ClusterConfig
+	//attempted specifies whether the connection has been attempted.
+	attempted bool
+	connected bool
+	//err is the failure of the last connection attempt.
+	err error
+	//clusterName is the name of the Liqo cluster, as in its ClusterConfig.
+	clusterName string
+	//peers contains the peers of the cluster, by ClusterID.
+	peers map[string]*client.NotifyDataForeignCluster
+}
+
+//icon returns the app.Icon summarizing the state of the cluster.
+func (v *clusterView) icon() app.Icon {
+	if !v.connected {
+		return app.IconLiqoNoConn
+	}
+	for _, p := range v.peers {
+		if p.OutPeering.Connected || p.InPeering.Connected {
+			return app.IconLiqoPurple
+		}
+	}
+	return app.IconLiqoMain
+}
+
+//peerings returns the number of incoming and outgoing peerings of the cluster.
+func (v *clusterView) peerings() (in int, out int) {
+	for _, p := range v.peers {
+		if p.InPeering.Connected {
+			in++
+		}
+		if p.OutPeering.Connected {
+			out++
+		}
+	}
+	return in, out
+}
+
+//clusterViews contains the state of the additional clusters, by name.
+var clusterViews = make(map[string]*clusterView)
+
+//clusterViewsMutex protects clusterViews.
+var clusterViewsMutex sync.Mutex
+
+//startQuickClusters is the wrapper function to register QUICK "Clusters", listing the additional clusters of the
+//local configuration. The QUICK is hidden if no additional cluster is configured.
+func startQuickClusters(i *app.Indicator) {
+	node := i.AddQuick(titleClusters, qClusters, nil)
+	forgetClusterViews()
+	conf, _ := client.GetLocalConfig()
+	clusters := conf.GetClusters()
+	node.SetIsVisible(false)
+	for _, c := range clusters {
+		if c.ClusterName() == "" {
+			continue
+		}
+		useClusterView(c)
+		refreshClusterEntry(i, c.ClusterName())
+		go connectCluster(i, c)
+	}
+}
+
+//useClusterView registers the clusterView of an additional cluster, if missing.
+func useClusterView(conf client.ClusterConfig) {
+	clusterViewsMutex.Lock()
+	defer clusterViewsMutex.Unlock()
+	if _, present := clusterViews[conf.ClusterName()]; !present {
+		clusterViews[conf.ClusterName()] = &clusterView{conf: conf,
+			peers: make(map[string]*client.NotifyDataForeignCluster)}
+	}
+}
+
+//updateClusterView changes the clusterView of an additional cluster, if registered.
+func updateClusterView(name string, change func(v *clusterView)) {
+	clusterViewsMutex.Lock()
+	defer clusterViewsMutex.Unlock()
+	if v, present := clusterViews[name]; present {
+		change(v)
+	}
+}
+
+//forgetClusterViews discards the state of all the additional clusters.
+func forgetClusterViews() {
+	clusterViewsMutex.Lock()
+	defer clusterViewsMutex.Unlock()
+	clusterViews = make(map[string]*clusterView)
+}
+
+//connectCluster connects to an additional cluster, starting the Listeners of its events once connected. A failure
+//is notified, and the connection can then be retried from the entry of the cluster.
+func connectCluster(i *app.Indicator, conf client.ClusterConfig) {
+	name := conf.ClusterName()
+	cluster, err := i.AgentCtrl().ConnectCluster(conf)
+	updateClusterView(name, func(v *clusterView) {
+		v.attempted, v.connected, v.err = true, err == nil, err
+	})
+	notificationID := notificationClusterPrefix + name + "/connection"
+	if err != nil {
+		i.ShowNotification(app.Notification{
+			ID:       notificationID,
+			Title:    clusterNotificationTitle(name, "CLUSTER UNREACHABLE"),
+			Message:  fmt.Sprintf("Liqo Agent could not connect to %s: %v", name, err),
+			Severity: app.SeverityWarning,
+			Category: app.CategoryConnection,
+		})
+	} else {
+		i.DismissNotification(notificationID)
+		if _, listening := i.ClusterListener(name, client.TopicPeerChanged); !listening {
+			i.ListenCluster(cluster, client.TopicPeerChanged, listenClusterPeerChanged, name)
+			i.ListenCluster(cluster, client.TopicClusterName, listenClusterClusterName, name)
+			i.ListenCluster(cluster, client.TopicOfferChanged, listenClusterOfferChanged, name)
+			configureListenerDebounce(i)
+		}
+	}
+	refreshClusterEntry(i, name)
+}
+
+//disconnectClusters stops the Listeners and the caches of all the additional clusters.
+func disconnectClusters(i *app.Indicator) {
+	for _, cluster := range i.AgentCtrl().Clusters() {
+		i.StopClusterListeners(cluster.Name())
+		i.AgentCtrl().DisconnectCluster(cluster.Name())
+	}
+	forgetClusterViews()
+}
+
+//listenClusterPeerChanged is the callback of the peer events of an additional cluster (whose name is the first of
+//args), dispatching them in order to listenClusterPeerAddedOrUpdated and listenClusterPeerDeleted.
+func listenClusterPeerChanged(data client.NotifyDataGeneric, args ...interface{}) {
+	fcData, ok := data.(*client.NotifyDataForeignCluster)
+	if !ok {
+		panic("wrong NotifyData type for an event Listener")
+	}
+	if fcData.Deleted {
+		listenClusterPeerDeleted(data, args...)
+	} else {
+		listenClusterPeerAddedOrUpdated(data, args...)
+	}
+}
+
+//listenClusterPeerAddedOrUpdated is the callback refreshing the section of an additional cluster (whose name is
+//the first of args) when one of its peers is added or updated.
+func listenClusterPeerAddedOrUpdated(data client.NotifyDataGeneric, args ...interface{}) {
+	fcData, ok := data.(*client.NotifyDataForeignCluster)
+	if !ok {
+		panic("wrong NotifyData type for an event Listener")
+	}
+	name := args[0].(string)
+	var old *client.NotifyDataForeignCluster
+	updateClusterView(name, func(v *clusterView) {
+		old = v.peers[fcData.ClusterID]
+		v.peers[fcData.ClusterID] = fcData
+	})
+	i := app.GetIndicator()
+	notifyClusterPeerings(i, name, old, fcData)
+	refreshClusterEntry(i, name)
+}
+
+//listenClusterPeerDeleted is the callback refreshing the section of an additional cluster (whose name is the first
+//of args) when one of its peers is removed.
+func listenClusterPeerDeleted(data client.NotifyDataGeneric, args ...interface{}) {
+	fcData, ok := data.(*client.NotifyDataForeignCluster)
+	if !ok {
+		panic("wrong NotifyData type for an event Listener")
+	}
+	name := args[0].(string)
+	var old *client.NotifyDataForeignCluster
+	updateClusterView(name, func(v *clusterView) {
+		old = v.peers[fcData.ClusterID]
+		delete(v.peers, fcData.ClusterID)
+	})
+	i := app.GetIndicator()
+	notifyClusterPeerings(i, name, old, nil)
+	if quick, present := i.Quick(qClusters); present {
+		if entry, present := quick.ListChild(name); present {
+			entry.FreeListChild(fcData.ClusterID)
+		}
+	}
+	refreshClusterEntry(i, name)
+}
+
+//listenClusterClusterName is the callback displaying the ClusterName of an additional cluster (whose name is the
+//first of args).
+func listenClusterClusterName(data client.NotifyDataGeneric, args ...interface{}) {
+	clusterName, ok := data.(string)
+	if !ok {
+		panic("wrong NotifyData type for an event Listener")
+	}
+	name := args[0].(string)
+	updateClusterView(name, func(v *clusterView) {
+		v.clusterName = clusterName
+	})
+	refreshClusterEntry(app.GetIndicator(), name)
+}
+
+//listenClusterOfferChanged is the callback notifying the changes of the resources offered by a peer of an
+//additional cluster (whose name is the first of args).
+func listenClusterOfferChanged(data client.NotifyDataGeneric, args ...interface{}) {
+	offer, ok := data.(*client.NotifyDataOfferChanged)
+	if !ok {
+		return
+	}
+	name := args[0].(string)
+	peer := offer.ClusterID
+	updateClusterView(name, func(v *clusterView) {
+		if p, present := v.peers[offer.ClusterID]; present && p.ClusterName != "" {
+			peer = p.ClusterName
+		}
+	})
+	n := app.Notification{
+		ID:       notificationClusterPrefix + name + "/offer/" + offer.ClusterID,
+		Title:    clusterNotificationTitle(name, peer+" CHANGED ITS OFFER"),
+		Message:  offerChangeMessage(offer.Changes),
+		Severity: app.SeverityInfo,
+		Category: app.CategoryResources,
+		Target:   app.NotificationTarget{Kind: "ForeignCluster", Name: offer.ClusterID},
+	}
+	if offerShrunk(offer.Changes) {
+		n.Severity = app.SeverityWarning
+	}
+	app.GetIndicator().ShowNotification(n)
+}
+
+//notifyClusterPeerings notifies the peerings with a peer of an additional cluster established or closed between
+//two updates (old or current are nil if the peer has just been added or removed).
+func notifyClusterPeerings(i *app.Indicator, cluster string, old *client.NotifyDataForeignCluster,
+	current *client.NotifyDataForeignCluster) {
+	peer := current
+	if peer == nil {
+		peer = old
+	}
+	wasOut, isOut := old != nil && old.OutPeering.Connected, current != nil && current.OutPeering.Connected
+	if wasOut != isOut {
+		notifyClusterPeering(i, cluster, peer, app.PeeringOutgoing, isOut)
+	}
+	wasIn, isIn := old != nil && old.InPeering.Connected, current != nil && current.InPeering.Connected
+	if wasIn != isIn {
+		notifyClusterPeering(i, cluster, peer, app.PeeringIncoming, isIn)
+	}
+}
+
+//notifyClusterPeering notifies a peering of an additional cluster that has been established (on) or closed. The
+//notifications of each peering replace each other.
+func notifyClusterPeering(i *app.Indicator, cluster string, peer *client.NotifyDataForeignCluster,
+	direction app.PeeringType, on bool) {
+	name := clusterPeerName(peer)
+	var event, message, id string
+	switch {
+	case direction == app.PeeringOutgoing && on:
+		event, message = "OUTGOING PEERING ESTABLISHED", name+" is now sharing its resources with "+cluster
+	case direction == app.PeeringOutgoing:
+		event, message = "OUTGOING PEERING CLOSED", name+" resources are no more available to "+cluster
+	case on:
+		event, message = "PEERING ACCEPTED", cluster+" is now sharing resources to "+name
+	default:
+		event, message = "INCOMING PEERING CLOSED", cluster+" stopped sharing resources to "+name
+	}
+	id = "outgoing"
+	if direction == app.PeeringIncoming {
+		id = "incoming"
+	}
+	i.ShowNotification(app.Notification{
+		ID:       fmt.Sprintf("%s%s/peering/%s/%s", notificationClusterPrefix, cluster, id, peer.ClusterID),
+		Title:    clusterNotificationTitle(cluster, event),
+		Message:  message,
+		Severity: app.SeverityInfo,
+		Category: app.CategoryPeering,
+		Target:   app.NotificationTarget{Kind: "ForeignCluster", Name: peer.Name},
+	})
+}
+
+//clusterNotificationTitle returns the title of a notification about an additional cluster.
+func clusterNotificationTitle(cluster string, event string) string {
+	return fmt.Sprintf("Liqo Agent [%s]: %s", cluster, event)
+}
+
+//clusterPeerName returns the name displaying a peer of an additional cluster.
+func clusterPeerName(peer *client.NotifyDataForeignCluster) string {
+	if peer.ClusterName != "" {
+		return peer.ClusterName
+	}
+	return labelPeerUnknown + " " + peer.ClusterID
+}
+
+/*refreshClusterEntry updates the entry of an additional cluster in the Clusters QUICK, which is shown once at least
+one cluster is displayed. The entry contains:
+	1-	STATUS: the connection to the cluster
+	2-	RECONNECT: retries the connection, if failed
+	3-	PEERS: an entry for each peer of the cluster, starting or stopping the outgoing peering
+*/
+func refreshClusterEntry(i *app.Indicator, name string) {
+	quick, present := i.Quick(qClusters)
+	if !present {
+		return
+	}
+	var view clusterView
+	var found bool
+	updateClusterView(name, func(v *clusterView) {
+		view, found = *v, true
+		view.peers = make(map[string]*client.NotifyDataForeignCluster, len(v.peers))
+		for id, p := range v.peers {
+			view.peers[id] = p
+		}
+	})
+	if !found {
+		quick.FreeListChild(name)
+		return
+	}
+	entry, present := quick.ListChild(name)
+	if !present {
+		entry = quick.UseListChild("", name)
+		status := entry.UseListChild("", tagStatus)
+		status.SetIsEnabled(false)
+		reconnect := entry.UseListChild(titleClusterReconnect, tagClusterReconnect)
+		conf := view.conf
+		reconnect.Connect(false, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			connectCluster(e.Indicator, conf)
+		}))
+	}
+	entry.SetTitle(clusterEntryTitle(&view))
+	status, _ := entry.ListChild(tagStatus)
+	status.SetTitle(clusterStatusText(&view))
+	reconnect, _ := entry.ListChild(tagClusterReconnect)
+	reconnect.SetIsVisible(view.attempted && !view.connected)
+	refreshClusterPeers(entry, name, view.peers)
+	quick.SetIsVisible(true)
+	quick.SetTitle(clustersQuickTitle())
+}
+
+//refreshClusterPeers updates the entries of the peers of an additional cluster. The entries of the removed peers
+//are freed by listenClusterPeerDeleted.
+func refreshClusterPeers(entry *app.MenuNode, cluster string, peers map[string]*client.NotifyDataForeignCluster) {
+	ids := make([]string, 0, len(peers))
+	for id := range peers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		peer := peers[id]
+		peerNode, present := entry.ListChild(id)
+		if !present {
+			peerNode = entry.UseListChild("", id)
+			peerNode.UseListChild("", tagStatus).SetIsEnabled(false)
+			cmd := peerNode.UseListChild("", tagPeeringCmd)
+			cmd.SetWriteAction(true)
+			cmd.SetCooldown(peeringCmdCooldown)
+			peerID := id
+			cmd.Connect(false, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+				toggleClusterPeering(e.Indicator, cluster, peerID)
+			}))
+		}
+		title := clusterPeerName(peer)
+		if peer.InPeering.Connected {
+			title += " ↓"
+		}
+		if peer.OutPeering.Connected {
+			title += " ↑"
+		}
+		peerNode.SetTitle(title)
+		status, _ := peerNode.ListChild(tagStatus)
+		status.SetTitle(clusterPeerStatus(peer))
+		cmd, _ := peerNode.ListChild(tagPeeringCmd)
+		if peer.OutPeering.Connected {
+			cmd.SetTitle(titlePeeringCmdStop)
+		} else {
+			cmd.SetTitle(titlePeeringCmdStart)
+		}
+	}
+}
+
+//toggleClusterPeering starts the outgoing peering towards a peer of an additional cluster, or stops it if active.
+func toggleClusterPeering(i *app.Indicator, cluster string, peerID string) {
+	ctrl, present := i.AgentCtrl().Cluster(cluster)
+	if !present {
+		return
+	}
+	var peer *client.NotifyDataForeignCluster
+	updateClusterView(cluster, func(v *clusterView) {
+		peer = v.peers[peerID]
+	})
+	if peer == nil {
+		return
+	}
+	if !peer.OutPeering.Connected {
+		err := runOperation(i.Context(), i, opPeerCredentials, func(ctx context.Context) error {
+			_, err := ctrl.ProvidePeerCredentials(ctx, peer.Name)
+			return err
+		})
+		if err != nil {
+			i.ShowClientError(clusterNotificationTitle(cluster, "PEER AUTHENTICATION FAILED"), err)
+			return
+		}
+	}
+	if err := ctrl.StartStopOutPeering(peer.Name, !peer.OutPeering.Connected); err != nil {
+		i.ShowClientError(clusterNotificationTitle(cluster, "PEERING FAILED"), err)
+	}
+}
+
+//clusterEntryTitle returns the title of the entry of an additional cluster, e.g. "● staging: 2 peers, ↓1 ↑1".
+func clusterEntryTitle(v *clusterView) string {
+	name := v.conf.ClusterName()
+	glyph := clusterIconGlyphs[v.icon()]
+	switch {
+	case !v.attempted:
+		return fmt.Sprintf("%s %s: connecting…", glyph, name)
+	case !v.connected:
+		return fmt.Sprintf("%s %s: unreachable", glyph, name)
+	}
+	title := fmt.Sprintf("%s %s: %s", glyph, name, format.Count(len(v.peers), "peer", "peers"))
+	if in, out := v.peerings(); in+out > 0 {
+		title += fmt.Sprintf(", ↓%d ↑%d", in, out)
+	}
+	return title
+}
+
+//clusterStatusText returns the description of the connection to an additional cluster.
+func clusterStatusText(v *clusterView) string {
+	switch {
+	case !v.attempted:
+		return "connecting to context " + v.conf.Context + "…"
+	case !v.connected:
+		return "✖ " + v.err.Error()
+	case v.clusterName != "":
+		return "✔ connected to " + v.clusterName
+	default:
+		return "✔ connected to context " + v.conf.Context
+	}
+}
+
+//clusterPeerStatus returns the description of the peerings with a peer of an additional cluster.
+func clusterPeerStatus(peer *client.NotifyDataForeignCluster) string {
+	var parts []string
+	if peer.OutPeering.Connected {
+		out := "outgoing peering"
+		if peer.OutPeering.CpuQuota != "" {
+			out += fmt.Sprintf(" (%s CPU, %s)", peer.OutPeering.CpuQuota, peer.OutPeering.MemQuota)
+		}
+		parts = append(parts, out)
+	}
+	if peer.InPeering.Connected {
+		parts = append(parts, "incoming peering")
+	}
+	if len(parts) == 0 {
+		return "no active peering"
+	}
+	return strings.Join(parts, ", ")
+}
+
+//clustersQuickTitle returns the title of the Clusters QUICK, e.g. "Clusters (3, 1 unreachable)".
+func clustersQuickTitle() string {
+	clusterViewsMutex.Lock()
+	defer clusterViewsMutex.Unlock()
+	unreachable := 0
+	for _, v := range clusterViews {
+		if v.attempted && !v.connected {
+			unreachable++
+		}
+	}
+	if unreachable == 0 {
+		return fmt.Sprintf("%s (%d)", titleClusters, len(clusterViews))
+	}
+	return fmt.Sprintf("%s (%d, %d unreachable)", titleClusters, len(clusterViews), unreachable)
+}
@@ -0,0 +1,149 @@
+package logic
+
+import (
+	"context"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*This file contains the hot-reload of the local configuration: the changes of the agent_conf.yaml config file (e.g.
+made with an editor) are applied while the Agent is running, as after a reset of the Agent, instead of requiring a
+restart. Besides the settings applied by reapplySettings, the periods of the Timers and the kubeconfig file of the
+main cluster are updated. A file that cannot be decoded is not applied, and its invalid settings are dropped (see
+client.LocalConfig.Validate): in both cases, the user is shown the problems, as at startup.*/
+
+//activitySourceConfig is the activity.Feed source of the reloads and of the problems of the configuration file.
+const activitySourceConfig = "config"
+
+//configWatch contains the watch of the configuration file.
+var configWatch = struct {
+	sync.Mutex
+	//cancel stops the watch, if started.
+	cancel context.CancelFunc
+	//kubeconfig is the kubeconfig path of the configuration last applied. The main cluster is switched to another
+	//kubeconfig file only when this path changes, so that a kubeconfig passed by command line is kept otherwise.
+	kubeconfig string
+}{}
+
+//startConfigWatch starts watching the configuration file, unless the AgentController is mocked.
+func startConfigWatch(i *app.Indicator) {
+	if i.AgentCtrl().Mocked() {
+		return
+	}
+	configWatch.Lock()
+	defer configWatch.Unlock()
+	if configWatch.cancel != nil {
+		return
+	}
+	conf, _ := client.GetLocalConfig()
+	configWatch.kubeconfig = conf.GetKubeconfig()
+	ctx, cancel := context.WithCancel(context.Background())
+	err := client.WatchLocalConfig(ctx, client.DefaultConfigWatchDelay, func() {
+		applyConfigReload(app.GetIndicator())
+	}, func(err error) {
+		showConfigError(app.GetIndicator(), err)
+	})
+	if err != nil {
+		cancel()
+		logger.Warning("cannot watch the configuration file", "err", err)
+		return
+	}
+	configWatch.cancel = cancel
+}
+
+//stopConfigWatch stops watching the configuration file, if started.
+func stopConfigWatch() {
+	configWatch.Lock()
+	defer configWatch.Unlock()
+	if configWatch.cancel != nil {
+		configWatch.cancel()
+		configWatch.cancel = nil
+	}
+}
+
+//applyConfigReload applies the configuration reloaded from the configuration file.
+func applyConfigReload(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	kubeconfig := conf.GetKubeconfig()
+	configWatch.Lock()
+	switchNeeded := kubeconfig != "" && kubeconfig != configWatch.kubeconfig
+	configWatch.kubeconfig = kubeconfig
+	configWatch.Unlock()
+	if switchNeeded {
+		switchKubeconfig(i.Context(), i, kubeconfig)
+	}
+	reapplySettings(i)
+	configureTimerIntervals(i)
+	logger.Info("configuration file reloaded")
+	activity.GetFeed().Add(activitySourceConfig, "Configuration file reloaded", activity.OutcomeSuccess)
+}
+
+//reportConfigError shows the problems of the configuration file found at startup, if any.
+func reportConfigError(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	if err := conf.Err(); err != nil {
+		showConfigError(i, err)
+	}
+}
+
+//showConfigError shows the user the problems of the configuration file: the settings dropped by the validation,
+//or the failure to read the whole file.
+func showConfigError(i *app.Indicator, err error) {
+	var message string
+	if problems, ok := err.(*client.ValidationError); ok {
+		message = "The following settings of " + client.ConfigFileName + " are invalid and have been ignored:\n- " +
+			strings.Join(problems.Problems, "\n- ")
+	} else {
+		message = client.ConfigFileName + " could not be read:\n" + err.Error()
+	}
+	logger.Warning("invalid configuration file", "err", err)
+	activity.GetFeed().Add(activitySourceConfig, "Invalid configuration file: "+err.Error(),
+		activity.OutcomeFailure)
+	i.ShowError("LIQO AGENT - INVALID CONFIGURATION", message)
+}
+
+//configureTimerIntervals applies the configured periods of the checks to the running Timers.
+func configureTimerIntervals(i *app.Indicator) {
+	configured := intervals()
+	for tag, interval := range map[string]time.Duration{
+		tHeartbeat:    configuredInterval(configured.Heartbeat, client.DefaultHeartbeatInterval),
+		tCapacity:     configuredInterval(configured.Capacity, capacityRefreshInterval),
+		tUsageTrend:   configuredInterval(configured.Capacity, capacityRefreshInterval),
+		tCredentials:  configuredInterval(configured.Credentials, credentialsCheckInterval),
+		tUpgrade:      configuredInterval(configured.Upgrade, upgradeCheckInterval),
+		tAgentUpdate:  configuredInterval(configured.AgentUpdate, agentUpdateCheckInterval),
+		tPeerLatency:  configuredInterval(configured.Latency, peerLatencyInterval),
+		tTunnelHealth: configuredInterval(configured.Tunnel, tunnelHealthInterval),
+	} {
+		if timer, present := i.Timer(tag); present {
+			timer.SetInterval(interval)
+		}
+	}
+}
+
+//switchKubeconfig connects the main cluster to the current context of another kubeconfig file, removing the peers
+//of the previous one.
+func switchKubeconfig(ctx context.Context, i *app.Indicator, kubeconfig string) {
+	forgetPeers(i)
+	err := runOperation(ctx, i, opSwitchKubeconfig, func(ctx context.Context) error {
+		return i.AgentCtrl().SwitchKubeconfig(kubeconfig)
+	})
+	refreshContexts(i)
+	refreshPeeringRequests(i)
+	refreshResourceSharing(i)
+	updateReconnection(i)
+	if err != nil {
+		activity.GetFeed().Add(activitySourceContexts, "Switch to kubeconfig "+kubeconfig+" failed",
+			activity.OutcomeFailure)
+		i.ShowClientError("Liqo Agent: KUBECONFIG SWITCH FAILED", err)
+		return
+	}
+	activity.GetFeed().Add(activitySourceContexts, "Switched to kubeconfig "+kubeconfig, activity.OutcomeSuccess)
+	startKubeconfigWatch(i)
+	i.Notify("Liqo Agent", "Liqo Agent is now connected to the cluster of "+kubeconfig, app.NotifyIconDefault,
+		app.IconLiqoNil)
+}
@@ -0,0 +1,97 @@
+package logic
+
+import (
+	"context"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"sync"
+)
+
+/*This file contains the ACTION switching the kubeconfig context of the main cluster at runtime. The contexts of the
+kubeconfig file are listed as its LIST children, the active one being checked: selecting another context rebuilds
+the caches of the AgentController against it (see client.AgentController.SwitchContext), and the peers of the
+previous context are removed from the menu.*/
+
+const (
+	//aContexts is the tag of the ACTION listing the kubeconfig contexts.
+	aContexts = "A_CONTEXTS"
+	//titleContexts is the title of the ACTION listing the kubeconfig contexts.
+	titleContexts = "Kubeconfig context"
+	//activitySourceContexts is the activity.Feed source of the context switches.
+	activitySourceContexts = "contexts"
+)
+
+//shownContexts contains the kubeconfig contexts currently listed by the aContexts ACTION.
+var shownContexts = struct {
+	names map[string]bool
+	sync.Mutex
+}{names: make(map[string]bool)}
+
+//startActionContexts is the wrapper function to register the ACTION "Kubeconfig context".
+func startActionContexts(i *app.Indicator) {
+	i.AddAction(titleContexts, aContexts, nil)
+	refreshContexts(i)
+}
+
+//refreshContexts refreshes the LIST children of the aContexts ACTION with the contexts of the kubeconfig file.
+//If the file cannot be read, the ACTION is disabled.
+func refreshContexts(i *app.Indicator) {
+	action, present := i.Action(aContexts)
+	if !present {
+		return
+	}
+	contexts, active, err := i.AgentCtrl().Contexts()
+	shownContexts.Lock()
+	defer shownContexts.Unlock()
+	listed := make(map[string]bool, len(contexts))
+	for _, name := range contexts {
+		listed[name] = true
+		child, present := action.ListChild(name)
+		if !present {
+			child = action.UseListChild(name, name)
+			child.SetIsRadio(true)
+			contextName := name
+			child.Connect(false, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+				switchContext(ctx, e.Indicator, contextName)
+			}))
+		}
+		child.SetIsChecked(name == active)
+	}
+	for name := range shownContexts.names {
+		if !listed[name] {
+			action.FreeListChild(name)
+		}
+	}
+	shownContexts.names = listed
+	if err != nil || len(contexts) == 0 {
+		action.SetTitle(titleContexts)
+		action.SetIsEnabled(false)
+		return
+	}
+	action.SetTitle(titleContexts + ": " + active)
+	action.SetIsEnabled(true)
+}
+
+//switchContext connects the main cluster to another kubeconfig context, removing the peers of the previous one.
+func switchContext(ctx context.Context, i *app.Indicator, name string) {
+	_, active, _ := i.AgentCtrl().Contexts()
+	if name == active && i.AgentCtrl().Connected() {
+		return
+	}
+	forgetPeers(i)
+	err := runOperation(ctx, i, opSwitchContext, func(ctx context.Context) error {
+		return i.AgentCtrl().SwitchContext(name)
+	})
+	refreshContexts(i)
+	refreshPeeringRequests(i)
+	refreshResourceSharing(i)
+	updateReconnection(i)
+	if err != nil {
+		activity.GetFeed().Add(activitySourceContexts, "Switch to context "+name+" failed", activity.OutcomeFailure)
+		i.ShowClientError("Liqo Agent: CONTEXT SWITCH FAILED", err)
+		return
+	}
+	activity.GetFeed().Add(activitySourceContexts, "Switched to context "+name, activity.OutcomeSuccess)
+	i.Notify("Liqo Agent", "Liqo Agent is now connected to the context "+name, app.NotifyIconDefault,
+		app.IconLiqoNil)
+}
@@ -0,0 +1,224 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/format"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strings"
+	"time"
+)
+
+const (
+	//titleCredentials is the title of the QUICK showing the expiry of the cluster credentials.
+	titleCredentials = "Credentials"
+	//titleRefreshCredentials is the title of the menu entry to refresh the cluster credentials.
+	titleRefreshCredentials = "• Refresh credentials"
+	//tagRefreshCredentials is the tag of the menu entry to refresh the cluster credentials.
+	tagRefreshCredentials = "refresh"
+	//tCredentials is the tag of the Timer checking the expiry of the cluster credentials.
+	tCredentials = "T_CREDENTIALS"
+	//credentialsCheckInterval is the interval between two checks of the cluster credentials.
+	credentialsCheckInterval = 6 * time.Hour
+	//refreshCredentialsCooldown is the minimum interval between two refreshes of the credentials from the menu.
+	refreshCredentialsCooldown = 5 * time.Second
+)
+
+//checkCredentials inspects the cluster credentials, refreshing the credentials QUICK and warning the user
+//about the ones expiring within the configured number of days.
+func checkCredentials(i *app.Indicator) {
+	credentials, err := i.AgentCtrl().Credentials()
+	quick, present := i.Quick(qCredentials)
+	if err != nil {
+		if present {
+			quick.SetIsEnabled(false)
+		}
+		return
+	}
+	if present {
+		refreshCredentialsQuick(i, quick, credentials)
+		refreshLoginEntry(i, quick)
+	}
+	conf, _ := client.GetLocalConfig()
+	window := time.Duration(conf.GetCredentialsWarningDays()) * 24 * time.Hour
+	var expiring []*client.CredentialInfo
+	for _, c := range credentials {
+		if !c.ExpiresWithin(window) {
+			continue
+		}
+		//refreshable credentials are renewed by the client: only an expiry which already happened is notified
+		if c.Refreshable && !c.Expired() {
+			continue
+		}
+		expiring = append(expiring, c)
+		i.Notify("Liqo Agent: CREDENTIALS EXPIRING",
+			fmt.Sprintf("the %s of %s %s", c.Kind, credentialOwner(c), expiryText(c, time.Now())),
+			app.NotifyIconWarning, app.IconLiqoWarning)
+	}
+	refreshPendingCredentials(i, credentials, expiring)
+}
+
+//refreshPendingCredentials registers the expiring credentials as a pending problem. Selecting it starts their refresh.
+func refreshPendingCredentials(i *app.Indicator, credentials []*client.CredentialInfo,
+	expiring []*client.CredentialInfo) {
+	if len(expiring) == 0 {
+		i.Pending().Remove(pendingCredentials)
+		return
+	}
+	title := fmt.Sprintf("%d credentials expiring", len(expiring))
+	if len(expiring) == 1 {
+		c := expiring[0]
+		title = fmt.Sprintf("The %s of '%s' %s", c.Kind, c.User, expiryText(c, time.Now()))
+	}
+	i.Pending().Add(app.PendingItem{
+		ID:    pendingCredentials,
+		Kind:  app.PendingProblem,
+		Title: title,
+		Action: app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			refreshCredentials(ctx, e.Indicator, credentials)
+		}),
+	})
+}
+
+//refreshCredentialsQuick updates the content of the credentials QUICK. The refresh entry is kept across the updates,
+//so that its cooldown applies.
+func refreshCredentialsQuick(i *app.Indicator, quick *app.MenuNode, credentials []*client.CredentialInfo) {
+	previous := quick.ListChildrenLen()
+	refresh, hasRefresh := quick.ListChild(tagRefreshCredentials)
+	if hasRefresh {
+		previous--
+	}
+	if _, hasLogin := quick.ListChild(tagLogin); hasLogin {
+		previous--
+	}
+	for index := 0; index < previous; index++ {
+		quick.FreeListChild(fmt.Sprint(index))
+	}
+	quick.SetIsEnabled(len(credentials) > 0)
+	for index, c := range credentials {
+		title := fmt.Sprintf("%s (%s): %s", c.Kind, c.User, expiryText(c, time.Now()))
+		quick.UseListChild(title, fmt.Sprint(index)).SetIsEnabled(false)
+	}
+	if len(credentials) == 0 {
+		quick.FreeListChild(tagRefreshCredentials)
+		return
+	}
+	if !hasRefresh {
+		refresh = quick.UseListChild(titleRefreshCredentials, tagRefreshCredentials)
+		refresh.SetWriteAction(true)
+		refresh.SetCooldown(refreshCredentialsCooldown)
+	}
+	refresh.Disconnect()
+	refresh.Connect(false, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		refreshCredentials(ctx, i, credentials)
+	}))
+}
+
+//refreshLoginEntry updates the entry of the credentials QUICK signing in again to the cluster, available if the
+//login command of the user is known.
+func refreshLoginEntry(i *app.Indicator, quick *app.MenuNode) {
+	method, ok := loginMethod(i)
+	if !ok {
+		quick.FreeListChild(tagLogin)
+		return
+	}
+	quick.SetIsEnabled(true)
+	title := titleLogin + " (" + strings.Join(method.Command, " ") + ")"
+	entry, present := quick.ListChild(tagLogin)
+	if present {
+		entry.SetTitle(title)
+	} else {
+		entry = quick.UseListChild(title, tagLogin)
+	}
+	entry.Disconnect()
+	entry.Connect(false, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		login(e.Indicator, method)
+	}))
+}
+
+//refreshCredentials renews the refreshable cluster credentials and requests the peering tokens again to their
+//CredentialHelper. The other ones can not be renewed by the Agent: users are then offered to select a new kubeconfig
+//file, used starting from the next Agent execution.
+func refreshCredentials(ctx context.Context, i *app.Indicator, credentials []*client.CredentialInfo) {
+	if !writeAllowed(i, "the refresh of the credentials") {
+		return
+	}
+	var kubeconfigCredentials []*client.CredentialInfo
+	for _, c := range credentials {
+		if c.Kind != client.CredentialPeerToken {
+			kubeconfigCredentials = append(kubeconfigCredentials, c)
+			continue
+		}
+		clusterID := c.ClusterID
+		err := runOperation(ctx, i, opRefreshCredentials, func(ctx context.Context) error {
+			return i.AgentCtrl().RefreshPeerCredential(ctx, clusterID)
+		})
+		if err != nil {
+			i.ShowWarning("Liqo Agent: CREDENTIALS NOT REFRESHED", err.Error())
+		}
+	}
+	credentials = kubeconfigCredentials
+	if len(credentials) == 0 {
+		checkCredentials(i)
+		return
+	}
+	refreshable := true
+	for _, c := range credentials {
+		refreshable = refreshable && c.Refreshable
+	}
+	if refreshable {
+		err := runOperation(ctx, i, opRefreshCredentials, func(context.Context) error {
+			return i.AgentCtrl().RefreshCredentials()
+		})
+		if err != nil {
+			i.ShowWarning("Liqo Agent: CREDENTIALS NOT REFRESHED", err.Error())
+		}
+		checkCredentials(i)
+		return
+	}
+	if app.GetGuiProvider().Mocked() {
+		return
+	}
+	ok, _ := dlgs.Question("REFRESH CREDENTIALS",
+		"Some credentials can not be renewed automatically and must be reissued by the cluster administrator.\n"+
+			"Do you want to select a kubeconfig file with the new credentials?", false)
+	if !ok {
+		return
+	}
+	filePath, selected, _ := dlgs.File("Select kubeconfig file", "", false)
+	if !selected {
+		return
+	}
+	conf, valid := client.GetLocalConfig()
+	if !valid {
+		conf = client.NewLocalConfig()
+		conf.Valid = true
+	}
+	conf.SetKubeconfig(filePath)
+	if err := client.SaveLocalConfig(); err != nil {
+		i.ShowWarning("LIQO AGENT", "Liqo Agent could not save settings changes")
+		return
+	}
+	i.Notify("Liqo Agent: KUBECONFIG UPDATED", "the new credentials will be used at the next Agent start",
+		app.NotifyIconDefault, app.IconLiqoNil)
+}
+
+//credentialOwner returns a literal description of the owner of a credential, e.g. "user 'admin'" or "peer 'remote'".
+func credentialOwner(c *client.CredentialInfo) string {
+	if c.Kind == client.CredentialPeerToken {
+		return fmt.Sprintf("peer '%s'", c.User)
+	}
+	return fmt.Sprintf("user '%s'", c.User)
+}
+
+//expiryText returns a literal description of the expiry of a credential with respect to now,
+//e.g. "expires in 3d 4h".
+func expiryText(c *client.CredentialInfo, now time.Time) string {
+	left := c.Expiry.Sub(now)
+	if left <= 0 {
+		return "is EXPIRED"
+	}
+	return "expires in " + format.Duration(left)
+}
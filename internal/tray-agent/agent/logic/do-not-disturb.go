@@ -0,0 +1,86 @@
+package logic
+
+import (
+	"context"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+)
+
+/*This file contains the Do Not Disturb mode, silencing the desktop banners of the notifications below a severity
+threshold until it is disabled, while the tray icon and the STATUS MenuNode keep displaying the notified events. The
+same threshold applies to the quiet hours, which are the scheduled Do Not Disturb windows (e.g. every night from
+22:00 to 08:00).*/
+
+const (
+	//titleDoNotDisturb is the title of the QUICK toggling the Do Not Disturb mode.
+	titleDoNotDisturb = "Do Not Disturb"
+	//activitySourceDoNotDisturb is the activity.Feed source of the changes of the Do Not Disturb mode.
+	activitySourceDoNotDisturb = "doNotDisturb"
+)
+
+//startQuickDoNotDisturb is the wrapper function to register QUICK "Do Not Disturb".
+func startQuickDoNotDisturb(i *app.Indicator) {
+	i.AddQuick(titleDoNotDisturb, qDoNotDisturb, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		quickToggleDoNotDisturb(i)
+	}))
+	updateQuickDoNotDisturb(i)
+}
+
+//quickToggleDoNotDisturb is the callback for the QUICK "Do Not Disturb", enabling or disabling the mode and saving
+//the choice in the local configuration.
+func quickToggleDoNotDisturb(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	enabled := !conf.GetDoNotDisturb().Enabled
+	conf.SetDoNotDisturb(enabled)
+	if err := client.SaveLocalConfig(); err != nil {
+		logger.Warning("cannot save the Do Not Disturb mode", "err", err)
+	}
+	msg := "Do Not Disturb mode disabled"
+	if enabled {
+		msg = "Do Not Disturb mode enabled"
+	}
+	activity.GetFeed().Add(activitySourceDoNotDisturb, msg, activity.OutcomeSuccess)
+	configureDoNotDisturb(i)
+}
+
+//configureDoNotDisturb applies the Do Not Disturb mode and the severity threshold of the local configuration to the
+//Indicator. In case of an invalid threshold, the default one is kept.
+func configureDoNotDisturb(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	dnd := conf.GetDoNotDisturb()
+	threshold := app.SeverityError
+	if dnd.Threshold != "" {
+		var err error
+		if threshold, err = app.ParseSeverity(dnd.Threshold); err != nil {
+			i.Notify("Liqo Agent: INVALID DO NOT DISTURB THRESHOLD", err.Error(), app.NotifyIconWarning,
+				app.IconLiqoNil)
+			threshold = app.SeverityError
+		}
+	}
+	i.SetQuietThreshold(threshold)
+	i.SetDoNotDisturb(dnd.Enabled)
+	updateQuickDoNotDisturb(i)
+}
+
+//updateQuickDoNotDisturb refreshes the title of the QUICK "Do Not Disturb" and its tooltip, describing the
+//notifications still displayed as banners.
+func updateQuickDoNotDisturb(i *app.Indicator) {
+	quick, present := i.Quick(qDoNotDisturb)
+	if !present {
+		return
+	}
+	if i.DoNotDisturb() {
+		quick.SetTitle(titleDoNotDisturb + ": ON")
+	} else {
+		quick.SetTitle(titleDoNotDisturb + ": OFF")
+	}
+	switch threshold := i.QuietThreshold(); threshold {
+	case app.SeverityInfo:
+		quick.SetTooltip("All the notifications are displayed as banners")
+	case app.SeverityError:
+		quick.SetTooltip("Only the error notifications are displayed as banners")
+	default:
+		quick.SetTooltip("Only the " + threshold.String() + " and error notifications are displayed as banners")
+	}
+}
@@ -0,0 +1,169 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/format"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strings"
+	"sync"
+)
+
+//titleHealth is the title of the QUICK showing the health of the Liqo control plane.
+const titleHealth = "Cluster health"
+
+//notificationHealthPrefix precedes the name of a Liqo component in the ID of the app.Notification of its failure.
+const notificationHealthPrefix = "health/"
+
+//healthFailing contains the names of the Liqo components whose failure has already been notified.
+var healthFailing = make(map[string]bool)
+
+//healthMutex protects healthFailing.
+var healthMutex sync.Mutex
+
+//listenHealthChanged is the callback refreshing the health of the Liqo control plane when its components change.
+func listenHealthChanged(_ client.NotifyDataGeneric, _ ...interface{}) {
+	i := app.GetIndicator()
+	report, err := i.AgentCtrl().HealthReport()
+	if err != nil {
+		return
+	}
+	if quick, present := i.Quick(qHealth); present {
+		refreshHealth(i, quick, report)
+	}
+	refreshPendingHealth(i, report)
+	active := make(map[string]bool)
+	for _, c := range report.Components {
+		if componentFailure(c) != "" {
+			active[notificationHealthPrefix+c.Name] = true
+		}
+	}
+	dismissResolvedNotifications(i, notificationHealthPrefix, active)
+	for _, c := range newFailures(report) {
+		i.ShowNotification(app.Notification{
+			ID:       notificationHealthPrefix + c.Name,
+			Title:    "Liqo Agent: LIQO COMPONENT FAILING",
+			Message:  fmt.Sprintf("%s %s: peerings may not work properly", c.Name, componentFailure(c)),
+			Severity: app.SeverityError,
+			Category: app.CategoryResources,
+			Target:   app.NotificationTarget{Kind: c.Kind, Name: c.Name},
+		}.WithTrayIcon(app.IconLiqoRed))
+	}
+}
+
+//componentFailure returns the description of the failure of the pods of a component, i.e. crash-looping or not
+//Ready pods, or an empty string if none of them is failing.
+func componentFailure(c *client.ComponentHealth) string {
+	switch {
+	case len(c.CrashLooping) > 0:
+		return "is crash-looping (" + strings.Join(c.CrashLooping, ", ") + ")"
+	case len(c.NotReady) > 0:
+		return "has pods not Ready (" + strings.Join(c.NotReady, ", ") + ")"
+	default:
+		return ""
+	}
+}
+
+//newFailures returns the components of a HealthReport whose pods are failing (see componentFailure) since the
+//last check. The recovered components are forgotten, so that a new failure is notified again.
+func newFailures(report *client.HealthReport) []*client.ComponentHealth {
+	healthMutex.Lock()
+	defer healthMutex.Unlock()
+	current := make(map[string]bool)
+	var components []*client.ComponentHealth
+	for _, c := range report.Components {
+		if componentFailure(c) == "" {
+			continue
+		}
+		current[c.Name] = true
+		if !healthFailing[c.Name] {
+			components = append(components, c)
+		}
+	}
+	healthFailing = current
+	return components
+}
+
+/*refreshHealth updates the content of the health QUICK. The title summarizes the number of unhealthy components,
+while each LIST child shows the readiness of a component of the Liqo control plane, the key ones first, e.g.
+	✔ liqo-controller-manager 1/1
+	✖ liqo-gateway 0/1 (CrashLoopBackOff, 5 restarts)
+	✔ liqo-auth 1/1
+The QUICK and the failing components are marked with the red Liqo icon.
+*/
+func refreshHealth(i *app.Indicator, quick *app.MenuNode, report *client.HealthReport) {
+	quick.FreeListChildren()
+	var red []byte
+	if report.Unhealthy() > 0 {
+		red = i.IconData(app.IconLiqoRed)
+	}
+	quick.SetIcon(red)
+	switch unhealthy := report.Unhealthy(); {
+	case len(report.Components) == 0:
+		quick.SetTitle(titleHealth + ": not found in '" + report.Namespace + "'")
+	case unhealthy == 0:
+		quick.SetTitle(titleHealth + ": OK")
+	default:
+		quick.SetTitle(fmt.Sprintf("%s: %d/%d components failing", titleHealth, unhealthy, len(report.Components)))
+	}
+	quick.SetIsEnabled(len(report.Components) > 0)
+	for _, c := range report.Components {
+		child := quick.UseListChild(componentHealthTitle(c), c.Name)
+		child.SetIsEnabled(false)
+		if c.Healthy() {
+			child.SetIcon(nil)
+		} else {
+			child.SetIcon(red)
+		}
+	}
+}
+
+//componentHealthTitle returns the description of the readiness of a component of the Liqo control plane.
+func componentHealthTitle(c *client.ComponentHealth) string {
+	title := strings.Builder{}
+	if c.Healthy() {
+		title.WriteString("✔ ")
+	} else {
+		title.WriteString("✖ ")
+	}
+	title.WriteString(fmt.Sprintf("%s %d/%d", c.Name, c.Ready, c.Desired))
+	var details []string
+	if len(c.CrashLooping) > 0 {
+		details = append(details, "CrashLoopBackOff")
+	}
+	if len(c.NotReady) > 0 {
+		details = append(details, fmt.Sprintf("%d not Ready", len(c.NotReady)))
+	}
+	if c.Restarts > 0 {
+		details = append(details, format.Count(int(c.Restarts), "restart", "restarts"))
+	}
+	if len(details) > 0 {
+		title.WriteString(" (" + strings.Join(details, ", ") + ")")
+	}
+	return title.String()
+}
+
+//refreshPendingHealth registers the failing components of the Liqo control plane as a pending problem.
+//Selecting it displays the failing components.
+func refreshPendingHealth(i *app.Indicator, report *client.HealthReport) {
+	unhealthy := report.Unhealthy()
+	if unhealthy == 0 {
+		i.Pending().Remove(pendingHealth)
+		return
+	}
+	var lines []string
+	for _, c := range report.Components {
+		if !c.Healthy() {
+			lines = append(lines, componentHealthTitle(c))
+		}
+	}
+	i.Pending().Add(app.PendingItem{
+		ID:    pendingHealth,
+		Kind:  app.PendingProblem,
+		Title: fmt.Sprintf("%d Liqo component(s) failing", unhealthy),
+		Action: app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			e.Indicator.ShowWarning("LIQO AGENT: LIQO HEALTH", strings.Join(lines, "\n"))
+		}),
+	})
+}
@@ -0,0 +1,120 @@
+package logic
+
+import (
+	"context"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"github.com/skratchdot/open-golang/open"
+	"time"
+)
+
+const (
+	//tHeartbeat is the tag of the Timer probing the reachability of the API server.
+	tHeartbeat = "T_HEARTBEAT"
+	//activitySourceHeartbeat is the activity.Entry source of the changes of reachability of the API server.
+	activitySourceHeartbeat = "heartbeat"
+	//reconnectingIconInterval is the interval the tray icon blinks at while the API server is unreachable.
+	reconnectingIconInterval = time.Second
+)
+
+//startHeartbeat starts the Timer periodically probing the API server, so that the loss of connectivity is
+//detected within seconds, independently of the caches.
+func startHeartbeat(i *app.Indicator) {
+	i.Listen(client.TopicHeartbeat, listenHeartbeat)
+	interval := configuredInterval(intervals().Heartbeat, client.DefaultHeartbeatInterval)
+	_ = i.StartTimer(tHeartbeat, interval, func(args ...interface{}) {
+		_ = i.AgentCtrl().Heartbeat(i.Context())
+	})
+}
+
+//listenHeartbeat is the callback notifying the user when the API server becomes unreachable or reachable again.
+func listenHeartbeat(data client.NotifyDataGeneric, _ ...interface{}) {
+	hb, ok := data.(*client.NotifyDataHeartbeat)
+	if !ok {
+		return
+	}
+	i := app.GetIndicator()
+	if !hb.Reachable && client.IsLoginRequiredError(hb.Err) {
+		//the API server can not be contacted until the user signs in again
+		activity.GetFeed().Add(activitySourceHeartbeat, "The credentials of the cluster expired: "+hb.Err.Error(),
+			activity.OutcomeFailure)
+		notifyAuthenticationFailure(i, hb.Err)
+		return
+	}
+	if !hb.Reachable && !i.AgentCtrl().Mocked() {
+		//an intercepted HTTP traffic is signaled specifically, since the user can fix it by signing in
+		conf, _ := client.GetLocalConfig()
+		if portal := client.DetectCaptivePortal(i.Context(), conf.GetCaptivePortalProbeURL()); portal != nil {
+			showCaptivePortal(i, portal)
+			return
+		}
+	}
+	if hb.Reachable {
+		i.Pending().Remove(pendingCaptivePortal)
+		i.DismissNotification(notificationIDAuthentication)
+	}
+	title, message, outcome := heartbeatMessage(hb)
+	activity.GetFeed().Add(activitySourceHeartbeat, message, outcome)
+	n := app.Notification{
+		ID:       app.NotificationIDConnection,
+		Title:    title,
+		Message:  message,
+		Severity: app.SeverityInfo,
+		Category: app.CategoryConnection,
+	}
+	switch {
+	case !hb.Reachable:
+		n.Severity = app.SeverityWarning
+		n = n.WithTrayIcon(app.IconLiqoNoConn)
+	case i.Status().Running() == app.StatRunOff:
+		n = n.WithTrayIcon(app.IconLiqoOff)
+	default:
+		n = n.WithTrayIcon(app.IconLiqoMain)
+	}
+	i.ShowNotification(n)
+	if !hb.Reachable {
+		//the tray icon blinks while the heartbeat keeps probing the API server, until it is reachable again
+		i.SetIconAnimation([]app.Icon{app.IconLiqoNoConn, app.IconLiqoOff}, reconnectingIconInterval)
+	}
+}
+
+//showCaptivePortal signals that the network requires a sign-in on a captive portal, offering to open its page.
+func showCaptivePortal(i *app.Indicator, portal *client.CaptivePortal) {
+	activity.GetFeed().Add(activitySourceHeartbeat, "The network requires sign-in: "+portal.URL, activity.OutcomeFailure)
+	i.SetIcon(app.IconLiqoWarning)
+	i.Pending().Add(app.PendingItem{
+		ID:    pendingCaptivePortal,
+		Kind:  app.PendingProblem,
+		Title: "The network requires sign-in",
+		Action: app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			_ = open.Start(portal.URL)
+		}),
+	})
+	if app.GetGuiProvider().Mocked() {
+		return
+	}
+	//the dialog waits for the user: it must not block the heartbeat Listener
+	go func() {
+		ok, _ := dlgs.Question("Liqo Agent: NETWORK REQUIRES SIGN-IN", "The cluster is not reachable because the "+
+			"network requires to sign in (e.g. on a hotel or airport Wi-Fi).\n\nDo you want to open the sign-in page?",
+			true)
+		if ok {
+			_ = open.Start(portal.URL)
+		}
+	}()
+}
+
+//heartbeatMessage returns the title and the message notifying a change of reachability of the API server,
+//with the outcome recorded in the activity feed.
+func heartbeatMessage(hb *client.NotifyDataHeartbeat) (title string, message string, outcome activity.Outcome) {
+	if hb.Reachable {
+		return "Liqo Agent: CLUSTER REACHABLE", "The cluster API server is reachable again", activity.OutcomeSuccess
+	}
+	message = "The cluster API server is not responding"
+	if hb.Err != nil {
+		message += ": " + hb.Err.Error()
+	}
+	return "Liqo Agent: CLUSTER UNREACHABLE", message, activity.OutcomeFailure
+}
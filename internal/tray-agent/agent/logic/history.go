@@ -0,0 +1,150 @@
+package logic
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/history"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strings"
+	"time"
+)
+
+const (
+	//titleHistory is the title of the QUICK showing the peering history.
+	titleHistory = "Peering history"
+	//historyDays is the number of past days displayed in the peering history.
+	historyDays = 7
+	//tHistory is the tag of the Timer refreshing the peering history, so that the displayed days keep rolling.
+	tHistory = "T_HISTORY"
+	//historyRefreshInterval is the refresh interval of the peering history in absence of peering transitions.
+	historyRefreshInterval = 10 * time.Minute
+	//historyTimeLayout is the layout used to display the bounds of a connection interval.
+	historyTimeLayout = "15:04"
+	//historyDayLayout is the layout used to display the day of a set of connection intervals.
+	historyDayLayout = "Mon 02 Jan"
+)
+
+//recordPeering stores in the history Store the peering transitions of a peer, together with the shared resources.
+//If removed == true, all the peerings with the peer are recorded as torn down. The function returns whether any
+//new Record has been stored. The caller must hold the read lock of the PeerInfo.
+func recordPeering(store *history.Store, peer *app.PeerInfo, removed bool) bool {
+	changed := false
+	peerings := []history.Record{
+		{
+			ClusterID:   peer.ClusterID,
+			ClusterName: peer.ClusterName,
+			Direction:   history.DirectionOutgoing,
+			Connected:   peer.OutPeeringConnected && !removed,
+			CpuQuota:    peer.OutCpuQuota,
+			MemQuota:    peer.OutMemQuota,
+		},
+		{
+			ClusterID:   peer.ClusterID,
+			ClusterName: peer.ClusterName,
+			Direction:   history.DirectionIncoming,
+			Connected:   peer.InPeeringConnected && !removed,
+		},
+	}
+	for _, r := range peerings {
+		last, present := store.LastRecord(r.ClusterID, r.Direction)
+		if !present && !r.Connected {
+			continue
+		}
+		//while the peering is active, also the changes of the shared resources are recorded
+		if present && last.Connected == r.Connected &&
+			(!r.Connected || (last.CpuQuota == r.CpuQuota && last.MemQuota == r.MemQuota)) {
+			continue
+		}
+		if !r.Connected {
+			r.CpuQuota, r.MemQuota = "", ""
+		}
+		_ = store.Add(r)
+		changed = true
+	}
+	return changed
+}
+
+//startQuickShowHistory is the wrapper function to register QUICK "Peering history".
+func startQuickShowHistory(i *app.Indicator) {
+	node := i.AddQuick(titleHistory, qHistory, nil)
+	refreshHistory(node, history.GetStore(), time.Now())
+	_ = i.StartTimer(tHistory, historyRefreshInterval, func(args ...interface{}) {
+		refreshHistoryQuick(i)
+	})
+}
+
+//refreshHistoryQuick refreshes, if registered, the QUICK "Peering history".
+func refreshHistoryQuick(i *app.Indicator) {
+	if quick, present := i.Quick(qHistory); present {
+		refreshHistory(quick, history.GetStore(), time.Now())
+	}
+}
+
+/*refreshHistory updates the content of the peering history QUICK with the Records of the last historyDays days.
+The history has the following structure:
+	-	peer name
+	1-		day: list of the connection intervals of both the peerings, starting from the most recent day
+*/
+func refreshHistory(quick *app.MenuNode, store *history.Store, now time.Time) {
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	timelines := store.Timelines(today.AddDate(0, 0, -(historyDays-1)), now)
+	quick.FreeListChildren()
+	quick.SetIsEnabled(len(timelines) > 0)
+	for _, t := range timelines {
+		name := t.ClusterName
+		if name == "" {
+			name = t.ClusterID
+		}
+		peerNode := quick.UseListChild(name, t.ClusterID)
+		rows := 0
+		for day := 0; day < historyDays; day++ {
+			start := today.AddDate(0, 0, -day)
+			end := start.AddDate(0, 0, 1)
+			out := formatIntervals(t.Outgoing, start, end)
+			in := formatIntervals(t.Incoming, start, end)
+			if out == "" && in == "" {
+				continue
+			}
+			title := strings.Builder{}
+			title.WriteString(start.Format(historyDayLayout))
+			if out != "" {
+				title.WriteString("  OUT ")
+				title.WriteString(out)
+			}
+			if in != "" {
+				title.WriteString("  IN ")
+				title.WriteString(in)
+			}
+			row := peerNode.UseListChild(peerDataIndentation+title.String(), start.Format("2006-01-02"))
+			row.SetIsEnabled(false)
+			rows++
+		}
+		if rows == 0 {
+			row := peerNode.UseListChild(peerDataIndentation+"no connections in the last days", tagStatus)
+			row.SetIsEnabled(false)
+		}
+	}
+}
+
+//formatIntervals returns the literal representation of the connection intervals overlapping with the
+//[start, end) time span, e.g. "09:12-11:40, 14:00-now".
+func formatIntervals(intervals []history.Interval, start time.Time, end time.Time) string {
+	var spans []string
+	for _, in := range intervals {
+		if !in.End.After(start) || !in.Start.Before(end) {
+			continue
+		}
+		from, to := "00:00", "24:00"
+		if !in.Start.Before(start) {
+			from = in.Start.In(start.Location()).Format(historyTimeLayout)
+		}
+		if in.End.Before(end) {
+			if in.Open {
+				to = "now"
+			} else {
+				to = in.End.In(start.Location()).Format(historyTimeLayout)
+			}
+		}
+		spans = append(spans, from+"-"+to)
+	}
+	return strings.Join(spans, ", ")
+}
@@ -0,0 +1,81 @@
+package logic
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+)
+
+/*This file contains the global keyboard shortcuts of the Agent, configured in the 'hotkeys' field of the local
+configuration, e.g.:
+
+	hotkeys:
+	  openMenu: CTRL+ALT+L
+	  toggle: CTRL+ALT+SHIFT+L
+
+The shortcuts open the tray menu, turn Liqo on and off and refresh the displayed data without reaching the tray
+icon.*/
+
+const (
+	//hotkeyOpenMenu is the ID of the shortcut opening the tray menu.
+	hotkeyOpenMenu = "open-menu"
+	//hotkeyToggle is the ID of the shortcut turning Liqo on and off.
+	hotkeyToggle = "toggle"
+	//hotkeyRefresh is the ID of the shortcut refreshing the peers, the contexts and the status.
+	hotkeyRefresh = "refresh"
+)
+
+//configureHotkeys binds the global shortcuts of the 'hotkeys' setting of the local configuration, releasing the
+//previous ones.
+func configureHotkeys(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	keys := conf.GetHotkeys()
+	err := i.SetHotkeys([]app.Hotkey{
+		{
+			ID:          hotkeyOpenMenu,
+			Description: "Open the Liqo Agent menu",
+			Trigger:     keys.OpenMenu,
+			Handler:     func() { hotkeyOpenMenuHandler(i) },
+		},
+		{
+			ID:          hotkeyToggle,
+			Description: "Turn Liqo on and off",
+			Trigger:     keys.Toggle,
+			Handler: func() {
+				quickTurnOnOff(i)
+				recordRunning(i.Status().Running())
+			},
+		},
+		{
+			ID:          hotkeyRefresh,
+			Description: "Refresh the Liqo Agent",
+			Trigger:     keys.Refresh,
+			Handler:     func() { hotkeyRefreshHandler(i) },
+		},
+	})
+	if err != nil {
+		logger.Debug("global shortcuts not bound", "err", err)
+	}
+}
+
+//hotkeyOpenMenuHandler opens the tray menu or, if the GuiBackend displays it only on click, the Status window.
+func hotkeyOpenMenuHandler(i *app.Indicator) {
+	if i.OpenMenu() || app.GetGuiProvider().Mocked() {
+		return
+	}
+	showStatusWindow(i)
+}
+
+//hotkeyRefreshHandler reloads the peers from the cluster, then refreshes the contexts, the peering requests and
+//the status.
+func hotkeyRefreshHandler(i *app.Indicator) {
+	if i.AgentCtrl().Connected() {
+		forgetPeers(i)
+		if err := i.AgentCtrl().RestartCaches(); err != nil {
+			i.Notify("Liqo Agent: REFRESH FAILED", err.Error(), app.NotifyIconError, app.IconLiqoRed)
+		}
+	}
+	refreshContexts(i)
+	refreshPeeringRequests(i)
+	refreshResourceSharing(i)
+	i.RefreshStatus()
+}
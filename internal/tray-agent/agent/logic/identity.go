@@ -0,0 +1,195 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+)
+
+/*This file contains the verification of the identity of the peers (see client.TrustStore). The identity is checked
+before starting an outgoing peering, when an incoming peering is established and on demand from the peer entry:
+the user can pin the identity of a new peer, while the change of the identity of a pinned peer is reported loudly.*/
+
+const (
+	//titlePeerIdentity is the title of the peer entry verifying its identity.
+	titlePeerIdentity = "Verify identity…"
+	//activitySourceIdentity is the activity.Feed source of the identity verifications.
+	activitySourceIdentity = "identity"
+	//pendingIdentityPrefix precedes the ClusterID of a peer in the ID of the app.PendingItem asking to verify it.
+	pendingIdentityPrefix = "identity/"
+)
+
+//peerIdentityHandler is the app.ClickHandler displaying the identity of a peer and allowing to pin it.
+type peerIdentityHandler struct {
+	peer *app.PeerInfo
+}
+
+//HandleClick implements the app.ClickHandler interface.
+func (h *peerIdentityHandler) HandleClick(ctx context.Context, e *app.ClickEvent) {
+	h.peer.RLock()
+	fcName := h.peer.ForeignClusterResourceName
+	clusterID := h.peer.ClusterID
+	h.peer.RUnlock()
+	recordLastPeer(e.Indicator, clusterID)
+	verifyPeerIdentity(ctx, e.Indicator, fcName, "")
+}
+
+//verifyPeerIdentity verifies the identity of a peer against the pinned one, asking the user to pin it if new (or
+//changed). If action is not empty, the user is asked whether to go on with it (e.g. "start the peering"): the
+//returned bool is true if the action can be performed.
+func verifyPeerIdentity(ctx context.Context, i *app.Indicator, fcName string, action string) bool {
+	id, err := i.AgentCtrl().PeerIdentity(ctx, fcName)
+	if id.ClusterID == "" {
+		i.ShowClientError("Liqo Agent: IDENTITY VERIFICATION FAILED", err)
+		return false
+	}
+	verdict, pinned := client.GetTrustStore().Verify(id)
+	//the identity is displayed and compared with the fingerprint from the same source of the pinned one
+	id = id.From(pinned.Source)
+	i.Pending().Remove(pendingIdentityPrefix + id.ClusterID)
+	switch verdict {
+	case client.IdentityVerified:
+		if action == "" {
+			showIdentityInfo(fmt.Sprintf("The identity of the peer matches the one pinned on %s.\n\n%s",
+				pinned.PinnedAt.Format("2006-01-02 15:04"), id))
+		}
+		return true
+	case client.IdentityChanged:
+		reportIdentityChange(i, id, pinned)
+		question := "The identity of the peer DIFFERS from the pinned one: the cluster may be impersonated by " +
+			"someone else.\n\n" + identityComparison(id, pinned) + "\n\nDo you trust the new identity"
+		if action != "" {
+			question += " and want to " + action
+		}
+		if !askIdentity("WARNING: PEER IDENTITY CHANGED", question+"?", false) {
+			return false
+		}
+		return pinPeerIdentity(i, id)
+	case client.IdentityUnverifiable:
+		if err == nil {
+			err = errors.New("the certificate the identity has been pinned with is not available")
+		}
+		question := fmt.Sprintf("The identity of the peer could not be verified:\n%v\n\nPinned identity:\n%s", err,
+			pinned)
+		if action == "" {
+			showIdentityInfo(question)
+			return false
+		}
+		return askIdentity("PEER IDENTITY NOT VERIFIED", question+"\n\nDo you want to "+action+" anyway?", true)
+	default:
+		if id.CAFingerprint == "" {
+			question := fmt.Sprintf("The CA certificate of the peer could not be retrieved:\n%v\n\n%s", err, id)
+			if action == "" {
+				showIdentityInfo(question)
+				return false
+			}
+			return askIdentity("PEER IDENTITY NOT VERIFIED", question+"\n\nDo you want to "+action+" anyway?", true)
+		}
+		question := "This is the first time the identity of the peer is verified. Check it with the administrator " +
+			"of the remote cluster:\n\n" + id.String() + "\n\nDo you trust this identity and want to pin it"
+		if action != "" {
+			question += " and " + action
+		}
+		if !askIdentity("VERIFY PEER IDENTITY", question+"?", true) {
+			return false
+		}
+		return pinPeerIdentity(i, id)
+	}
+}
+
+//checkIncomingPeerIdentity verifies the identity of a peer whose incoming peering has been established, without
+//blocking: a new identity is registered as a pending request, while a changed one is reported loudly.
+func checkIncomingPeerIdentity(i *app.Indicator, fcName string) {
+	id, _ := i.AgentCtrl().PeerIdentity(i.Context(), fcName)
+	if id.ClusterID == "" {
+		return
+	}
+	switch verdict, pinned := client.GetTrustStore().Verify(id); verdict {
+	case client.IdentityChanged:
+		reportIdentityChange(i, id.From(pinned.Source), pinned)
+	case client.IdentityUnknown:
+		if id.CAFingerprint == "" {
+			return
+		}
+		i.Pending().Add(app.PendingItem{
+			ID:    pendingIdentityPrefix + id.ClusterID,
+			Kind:  app.PendingRequest,
+			Title: "Verify the identity of " + peerIdentityName(id),
+			Action: app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+				verifyPeerIdentity(ctx, e.Indicator, fcName, "")
+			}),
+		})
+	}
+}
+
+//reportIdentityChange reports the change of the identity of a pinned peer with a critical notification, an
+//activity failure and a pending problem, which is kept until the identity is verified again.
+func reportIdentityChange(i *app.Indicator, id client.PeerIdentity, pinned client.PeerIdentity) {
+	name := peerIdentityName(id)
+	title := "Identity of " + name + " changed"
+	i.ShowNotification(app.Notification{
+		ID:       pendingIdentityPrefix + id.ClusterID,
+		Title:    "Liqo Agent: PEER IDENTITY CHANGED",
+		Message:  fmt.Sprintf("The CA certificate of %s differs from the pinned one", name),
+		Severity: app.SeverityError,
+		Category: app.CategorySecurity,
+		Target:   app.NotificationTarget{Kind: "ForeignCluster", Name: id.ClusterID},
+	}.WithTrayIcon(app.IconLiqoRed))
+	activity.GetFeed().Add(activitySourceIdentity, title, activity.OutcomeFailure)
+	i.Pending().Add(app.PendingItem{
+		ID:    pendingIdentityPrefix + id.ClusterID,
+		Kind:  app.PendingProblem,
+		Title: title,
+		Action: app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			e.Indicator.ShowError("LIQO AGENT: PEER IDENTITY CHANGED", identityComparison(id, pinned))
+		}),
+	})
+}
+
+//pinPeerIdentity pins the identity of a peer, returning whether it succeeded.
+func pinPeerIdentity(i *app.Indicator, id client.PeerIdentity) bool {
+	if err := client.GetTrustStore().Pin(id); err != nil {
+		i.ShowWarning("LIQO AGENT", "The identity of the peer could not be pinned:\n"+err.Error())
+		return false
+	}
+	i.Pending().Remove(pendingIdentityPrefix + id.ClusterID)
+	i.DismissNotification(pendingIdentityPrefix + id.ClusterID)
+	activity.GetFeed().Add(activitySourceIdentity, "Identity of "+peerIdentityName(id)+" pinned",
+		activity.OutcomeSuccess)
+	return true
+}
+
+//identityComparison returns the description of the current and of the pinned identity of a peer.
+func identityComparison(id client.PeerIdentity, pinned client.PeerIdentity) string {
+	return fmt.Sprintf("CURRENT:\n%s\n\nPINNED on %s:\n%s", id, pinned.PinnedAt.Format("2006-01-02 15:04"), pinned)
+}
+
+//peerIdentityName returns the name of a peer displayed in the identity messages.
+func peerIdentityName(id client.PeerIdentity) string {
+	if id.ClusterName != "" {
+		return id.ClusterName
+	}
+	return id.ClusterID
+}
+
+//askIdentity asks the user a question about the identity of a peer. In the mocked mode, the question gets the
+//mocked answer.
+func askIdentity(title string, question string, mocked bool) bool {
+	if app.GetGuiProvider().Mocked() {
+		return mocked
+	}
+	ok, _ := dlgs.Question(title, question, true)
+	return ok
+}
+
+//showIdentityInfo displays information about the identity of a peer.
+func showIdentityInfo(text string) {
+	if app.GetGuiProvider().Mocked() {
+		return
+	}
+	_, _ = dlgs.Info("PEER IDENTITY", text)
+}
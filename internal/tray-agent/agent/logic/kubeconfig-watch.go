@@ -0,0 +1,138 @@
+package logic
+
+import (
+	"context"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"sync"
+)
+
+/*This file contains the reaction to the changes of the kubeconfig file of the main cluster (see
+client.AgentController.WatchKubeconfig), e.g. a rotated certificate or a refreshed token: the clients and the caches
+of the AgentController are rebuilt from the file, without restarting the Agent. While offline, a change triggers a
+reconnection attempt instead. When the cluster rejects the credentials, the user is notified with a "Retry" action.*/
+
+const (
+	//activitySourceKubeconfig is the activity.Feed source of the reloads of the kubeconfig file.
+	activitySourceKubeconfig = "kubeconfig"
+	//notificationIDAuthentication is the ID of the app.Notification of the credentials rejected by the cluster.
+	notificationIDAuthentication = "authentication"
+)
+
+//kubeconfigWatch contains the watch of the kubeconfig file.
+var kubeconfigWatch = struct {
+	sync.Mutex
+	//cancel stops the watch, if started.
+	cancel context.CancelFunc
+}{}
+
+//startKubeconfigWatch starts watching the kubeconfig file of the main cluster, unless the AgentController is
+//mocked. A running watch is restarted, e.g. after a switch to another kubeconfig file.
+func startKubeconfigWatch(i *app.Indicator) {
+	if i.AgentCtrl().Mocked() || i.AgentCtrl().Kubeconfig() == "" {
+		return
+	}
+	stopKubeconfigWatch()
+	kubeconfigWatch.Lock()
+	defer kubeconfigWatch.Unlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	err := i.AgentCtrl().WatchKubeconfig(ctx, client.DefaultKubeconfigWatchDelay, func() {
+		current := app.GetIndicator()
+		kubeconfigChanged(current.Context(), current)
+	}, func(err error) {
+		logger.Warning("kubeconfig watch failure", "err", err)
+	})
+	if err != nil {
+		cancel()
+		logger.Warning("cannot watch the kubeconfig file", "err", err)
+		return
+	}
+	kubeconfigWatch.cancel = cancel
+}
+
+//stopKubeconfigWatch stops watching the kubeconfig file, if started.
+func stopKubeconfigWatch() {
+	kubeconfigWatch.Lock()
+	defer kubeconfigWatch.Unlock()
+	if kubeconfigWatch.cancel != nil {
+		kubeconfigWatch.cancel()
+		kubeconfigWatch.cancel = nil
+	}
+}
+
+//kubeconfigChanged is the callback of the changes of the kubeconfig file. If connected, the AgentController is
+//rebuilt from the file, removing the peers listed from the previous caches; otherwise, the reconnection is
+//attempted immediately.
+func kubeconfigChanged(ctx context.Context, i *app.Indicator) {
+	logger.Info("kubeconfig file changed", "path", i.AgentCtrl().Kubeconfig())
+	if !i.AgentCtrl().Connected() {
+		if timer, present := i.Timer(tReconnect); present {
+			timer.Trigger()
+		}
+		return
+	}
+	reloadKubeconfig(ctx, i)
+}
+
+//reloadKubeconfig rebuilds the clients and the caches of the main cluster from its kubeconfig file. On failure, the
+//AgentController is reconnected with backoff.
+func reloadKubeconfig(ctx context.Context, i *app.Indicator) {
+	forgetPeers(i)
+	err := runOperation(ctx, i, opReloadKubeconfig, func(ctx context.Context) error {
+		return i.AgentCtrl().ReloadKubeconfig()
+	})
+	refreshContexts(i)
+	refreshPeeringRequests(i)
+	refreshResourceSharing(i)
+	updateReconnection(i)
+	checkCredentials(i)
+	if err != nil {
+		activity.GetFeed().Add(activitySourceKubeconfig, "Kubeconfig reload failed: "+err.Error(),
+			activity.OutcomeFailure)
+		if client.IsLoginRequiredError(err) {
+			notifyAuthenticationFailure(i, err)
+		} else {
+			i.ShowClientError("Liqo Agent: KUBECONFIG RELOAD FAILED", err)
+		}
+		return
+	}
+	i.DismissNotification(notificationIDAuthentication)
+	activity.GetFeed().Add(activitySourceKubeconfig, "Kubeconfig reloaded", activity.OutcomeSuccess)
+}
+
+//notifyAuthenticationFailure notifies that the cluster rejected the credentials of the kubeconfig file, offering
+//to retry the connection, e.g. after signing in again with an external tool. If the login command of the user is
+//known (see client.LoginMethod), the user is offered to run it.
+func notifyAuthenticationFailure(i *app.Indicator, err error) {
+	n := app.Notification{
+		ID:       notificationIDAuthentication,
+		Title:    "Liqo Agent: AUTHENTICATION FAILED",
+		Message:  "The cluster rejected the credentials of the kubeconfig file: " + err.Error(),
+		Severity: app.SeverityError,
+		Category: app.CategorySecurity,
+		Actions: []app.NotificationAction{
+			{Label: "Retry", Handler: app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+				retryAuthentication(ctx, e.Indicator)
+			})},
+			{Label: "Dismiss"},
+		},
+	}
+	if method, ok := loginMethod(i); ok {
+		n.Title = "Liqo Agent: LOGIN REQUIRED"
+		n.Message = "Token expired, re-login required: " + err.Error()
+		n.Actions = append([]app.NotificationAction{{Label: "Log in",
+			Handler: app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+				e.Indicator.DismissNotification(notificationIDAuthentication)
+				login(e.Indicator, method)
+			})}}, n.Actions...)
+	}
+	i.ShowNotification(n.WithTrayIcon(app.IconLiqoWarning))
+}
+
+//retryAuthentication is the "Retry" action of the authentication failures: the kubeconfig file is reloaded, or the
+//reconnection attempted immediately if offline.
+func retryAuthentication(ctx context.Context, i *app.Indicator) {
+	i.DismissNotification(notificationIDAuthentication)
+	kubeconfigChanged(ctx, i)
+}
@@ -0,0 +1,36 @@
+package logic
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+)
+
+//tUsageTrend is the tag of the Timer sampling the CPU acquired from the peers for the tray label.
+const tUsageTrend = "T_USAGE_TREND"
+
+//startUsageTrend applies the label mode and format of the local configuration. When the tray label displays the
+//CPU acquired from the peers, it is sampled with the period of the capacity overview.
+func startUsageTrend(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	i.SetLabelMode(app.ParseLabelMode(conf.GetLabelMode()))
+	i.SetLabelFormat(conf.GetLabelFormat(), conf.GetLabelAlways())
+	if !i.NeedsUsageTrend() {
+		return
+	}
+	sample := func(args ...interface{}) {
+		sampleUsageTrend(i)
+	}
+	sample()
+	_ = i.StartTimer(tUsageTrend, configuredInterval(intervals().Capacity, capacityRefreshInterval), sample)
+}
+
+//sampleUsageTrend records the CPU (in cores) currently acquired from the peers, i.e. requested by the pods
+//scheduled on the virtual nodes, and refreshes the tray label.
+func sampleUsageTrend(i *app.Indicator) {
+	report, err := i.AgentCtrl().CapacityReport()
+	if err != nil {
+		return
+	}
+	i.UsageTrend().Add(float64(report.Peers.CpuRequested) / 1000)
+	i.RefreshLabel()
+}
@@ -0,0 +1,77 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/i18n"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"sync"
+)
+
+//titleLanguage is the title of the QUICK changing the language of the menu and of the notifications.
+const titleLanguage = "Language…"
+
+//languageOnce prevents the registration of multiple callbacks of the language changes.
+var languageOnce sync.Once
+
+//configureLanguage applies the language of the local configuration, or the one detected from the environment if
+//not set. Each following change of the language is applied to the whole menu.
+func configureLanguage(i *app.Indicator) {
+	languageOnce.Do(func() {
+		i18n.OnChange(func() {
+			app.GetIndicator().Retranslate()
+		})
+	})
+	conf, _ := client.GetLocalConfig()
+	language := conf.GetLanguage()
+	if language == "" {
+		language = i18n.DetectLanguage()
+	}
+	if err := i18n.SetLanguage(language); err != nil {
+		logger.Warning("cannot apply the configured language", "err", err)
+	}
+}
+
+//startQuickSetLanguage is the wrapper function to register QUICK "Language…".
+func startQuickSetLanguage(i *app.Indicator) {
+	i.AddQuick(titleLanguage, qLanguage, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		quickChangeLanguage(e.Indicator)
+	}))
+}
+
+//quickChangeLanguage is the callback function for the QUICK "Language…". The selected language is applied
+//immediately and saved in the Agent configuration file.
+func quickChangeLanguage(i *app.Indicator) {
+	if app.GetGuiProvider().Mocked() {
+		return
+	}
+	var names []string
+	for _, language := range i18n.Languages() {
+		names = append(names, i18n.LanguageName(language))
+	}
+	choice, ok, _ := dlgs.List("LANGUAGE SETTINGS", fmt.Sprintf("Choose the language of the Liqo Agent menu and "+
+		"notifications.\nCURRENT: %s", i18n.LanguageName(i18n.Language())), names)
+	if !ok {
+		return
+	}
+	for _, language := range i18n.Languages() {
+		if i18n.LanguageName(language) == choice {
+			setLanguage(i, language)
+		}
+	}
+}
+
+//setLanguage applies a language to the menu and to the notifications, and saves it in the Agent configuration file.
+func setLanguage(i *app.Indicator, language string) {
+	if err := i18n.SetLanguage(language); err != nil {
+		i.ShowWarning("LIQO AGENT", "The language could not be changed:\n"+err.Error())
+		return
+	}
+	conf, _ := client.GetLocalConfig()
+	conf.SetLanguage(language)
+	if err := client.SaveLocalConfig(); err != nil {
+		i.ShowWarning("LIQO AGENT", "The language could not be saved:\n"+err.Error())
+	}
+}
@@ -0,0 +1,223 @@
+package logic
+
+import (
+	"fmt"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strings"
+)
+
+//menuSection is a group of QUICKs of the tray menu that can be hidden, pinned and reordered as a whole.
+type menuSection struct {
+	//name identifies the section in the configuration file.
+	name string
+	//title is the user-friendly description of the section.
+	title string
+	//quicks contains the wrapper functions registering the QUICKs of the section, in display order.
+	quicks []func(i *app.Indicator)
+}
+
+//Sections of the tray menu that can be customized.
+const (
+	sectionPeers       = "peers"
+	sectionResources   = "resources"
+	sectionOffloading  = "offloading"
+	sectionDiagnostics = "diagnostics"
+	sectionMaintenance = "maintenance"
+	sectionSettings    = "settings"
+)
+
+//menuSections contains the customizable sections of the tray menu, in their default order.
+var menuSections = []*menuSection{
+	{name: sectionPeers, title: "Peers", quicks: []func(i *app.Indicator){
+		startQuickShowPeers, startQuickSearchPeers, startQuickClusters, startQuickClipboard, startQuickOpenTerminal,
+		startQuickExportTopology, startQuickShowHistory}},
+	{name: sectionResources, title: "Resources", quicks: []func(i *app.Indicator){
+		startQuickShowStorage, startQuickShowCapacity}},
+	{name: sectionOffloading, title: "Offloading", quicks: []func(i *app.Indicator){
+		startQuickNamespaceOffloading}},
+	{name: sectionDiagnostics, title: "Diagnostics", quicks: []func(i *app.Indicator){
+		startQuickShowStatus, startQuickShowCredentials, startQuickShowHealth, startQuickShowActivity,
+		startQuickBackgroundTasks}},
+	{name: sectionMaintenance, title: "Maintenance", quicks: []func(i *app.Indicator){
+		startQuickUpgrade, startQuickAgentUpdate, startQuickUninstall, startQuickReset}},
+	{name: sectionSettings, title: "Settings", quicks: []func(i *app.Indicator){
+		startQuickSettingsPage, startQuickSetNotifications, startQuickQuietHours, startQuickDoNotDisturb, startQuickSetIconTheme,
+		startQuickSetLanguage, startQuickGroupPeers, startQuickReadOnly, startQuickStartup, startQuickLogs}},
+}
+
+/*buildMenu registers the QUICKs of the tray menu according to the layout of the local configuration:
+-	the Liqo controls (start/stop, mode, dashboard), the pending items, the reconnection entries while offline, the
+	kubeconfig contexts, the incoming peering requests, the start of an outgoing peering and its onboarding
+	checklist, the resource sharing percentage, always at the top
+-	the pinned sections
+-	the other visible sections, in the configured order
+-	the "Customize menu", "About Liqo" and "Quit" entries, always at the bottom
+Each section is displayed under its title, between separators.
+*/
+func buildMenu(i *app.Indicator) {
+	startQuickOnOff(i)
+	startQuickChangeMode(i)
+	startQuickDashboard(i)
+	startQuickPending(i)
+	startQuickReconnect(i)
+	startActionContexts(i)
+	startActionPeeringRequests(i)
+	startActionPeeringWizard(i)
+	startActionOnboarding(i)
+	startActionResourceSharing(i)
+	conf, _ := client.GetLocalConfig()
+	pinned, others := arrangeSections(conf.GetMenuLayout())
+	sections := append(pinned, others...)
+	for _, s := range sections {
+		startSection(i, s)
+	}
+	if len(sections) > 0 {
+		i.EndSection()
+	} else {
+		i.AddSeparator()
+	}
+	startQuickCustomizeMenu(i)
+	startQuickLiqoWebsite(i)
+	startQuickQuit(i)
+}
+
+//startSection registers all the QUICKs of a menuSection, grouped under its title.
+func startSection(i *app.Indicator, s *menuSection) {
+	i.AddSection(s.title, s.name)
+	for _, start := range s.quicks {
+		start(i)
+	}
+}
+
+//arrangeSections returns the visible menu sections in display order, separating the pinned ones.
+//Unknown section names are ignored.
+func arrangeSections(layout client.MenuLayoutConfig) (pinned []*menuSection, others []*menuSection) {
+	hidden := stringSet(layout.Hidden)
+	isPinned := stringSet(layout.Pinned)
+	ordered := make([]*menuSection, 0, len(menuSections))
+	added := make(map[string]bool)
+	for _, name := range append(layout.Order, sectionNames()...) {
+		if s := findSection(name); s != nil && !added[s.name] {
+			added[s.name] = true
+			ordered = append(ordered, s)
+		}
+	}
+	for _, s := range ordered {
+		switch {
+		case hidden[s.name]:
+		case isPinned[s.name]:
+			pinned = append(pinned, s)
+		default:
+			others = append(others, s)
+		}
+	}
+	return pinned, others
+}
+
+//findSection returns the menuSection with a specific name, or nil if not found.
+func findSection(name string) *menuSection {
+	for _, s := range menuSections {
+		if s.name == strings.ToLower(strings.TrimSpace(name)) {
+			return s
+		}
+	}
+	return nil
+}
+
+//sectionNames returns the names of all the menu sections, in default order.
+func sectionNames() []string {
+	names := make([]string, 0, len(menuSections))
+	for _, s := range menuSections {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+//appendUniqueString appends s to the slice only if not already present.
+func appendUniqueString(slice []string, s string) []string {
+	for _, e := range slice {
+		if e == s {
+			return slice
+		}
+	}
+	return append(slice, s)
+}
+
+//stringSet converts a slice of names into a set, ignoring case.
+func stringSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		set[strings.ToLower(strings.TrimSpace(n))] = true
+	}
+	return set
+}
+
+//quickCustomizeMenu is the callback for the QUICK "Customize menu…". The user chooses the sections to show and pin
+//and their order. The new layout is saved in the local configuration and applied at the next start.
+func quickCustomizeMenu(i *app.Indicator) {
+	if app.GetGuiProvider().Mocked() {
+		return
+	}
+	conf, _ := client.GetLocalConfig()
+	current := conf.GetMenuLayout()
+	pinned, others := arrangeSections(current)
+	titles := make([]string, 0, len(menuSections))
+	for _, s := range menuSections {
+		titles = append(titles, s.title)
+	}
+	shown, ok, _ := dlgs.ListMulti("CUSTOMIZE MENU", "Select the sections to show in the menu:", titles)
+	if !ok {
+		return
+	}
+	pinnedTitles, ok, _ := dlgs.ListMulti("CUSTOMIZE MENU", "Select the sections to pin at the top of the menu:",
+		shown)
+	if !ok {
+		return
+	}
+	currentOrder := make([]string, 0, len(menuSections))
+	for _, s := range append(pinned, others...) {
+		currentOrder = append(currentOrder, s.name)
+	}
+	order, ok, _ := dlgs.Entry("CUSTOMIZE MENU", fmt.Sprintf("Type the order of the sections, separated by commas "+
+		"(%s):", strings.Join(sectionNames(), ", ")), strings.Join(currentOrder, ", "))
+	if !ok {
+		return
+	}
+	layout := newMenuLayout(shown, pinnedTitles, strings.Split(order, ","))
+	conf.SetMenuLayout(layout)
+	if err := client.SaveLocalConfig(); err != nil {
+		i.ShowWarning("CUSTOMIZE MENU", "The menu layout could not be saved:\n"+err.Error())
+		return
+	}
+	i.Notify("Liqo Agent", "The new menu layout will be applied at the next start of Liqo Agent",
+		app.NotifyIconDefault, app.IconLiqoNil)
+}
+
+//newMenuLayout returns the MenuLayoutConfig for the titles of the shown and pinned sections and the names of the
+//sections in display order.
+func newMenuLayout(shownTitles []string, pinnedTitles []string, order []string) client.MenuLayoutConfig {
+	shown := make(map[string]bool)
+	for _, t := range shownTitles {
+		shown[t] = true
+	}
+	pinned := make(map[string]bool)
+	for _, t := range pinnedTitles {
+		pinned[t] = true
+	}
+	layout := client.MenuLayoutConfig{}
+	for _, name := range order {
+		if s := findSection(name); s != nil {
+			layout.Order = appendUniqueString(layout.Order, s.name)
+		}
+	}
+	for _, s := range menuSections {
+		if !shown[s.title] {
+			layout.Hidden = append(layout.Hidden, s.name)
+		} else if pinned[s.title] {
+			layout.Pinned = append(layout.Pinned, s.name)
+		}
+	}
+	return layout
+}
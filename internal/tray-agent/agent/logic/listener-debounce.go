@@ -0,0 +1,41 @@
+package logic
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"time"
+)
+
+/*This file contains the debouncing of the Listeners. A burst of cluster events (e.g. while the cluster starts up)
+would refresh the status and the tray menu once per event: the Listeners whose callback processes the whole content
+of a cache handle at most one event per interval, delivering the last one of a burst at its end (trailing edge).
+The debouncing of each Listener can be changed with the 'listenerDebounce' field of the local configuration.*/
+
+//listenerDebounceInterval is the default debouncing interval of the Listeners of the coalesced Topics.
+const listenerDebounceInterval = 500 * time.Millisecond
+
+//defaultListenerDebounce contains the default app.Debounce of the Listeners, by Topic. The Listeners of the Topics
+//carrying a payload (e.g. the peers) are not debounced, since coalescing their events would lose data.
+var defaultListenerDebounce = map[client.Topic]app.Debounce{
+	client.TopicStorageChanged:    {Interval: listenerDebounceInterval, Trailing: true},
+	client.TopicHealthChanged:     {Interval: listenerDebounceInterval, Trailing: true},
+	client.TopicWorkloadsChanged:  {Interval: listenerDebounceInterval, Trailing: true},
+	client.TopicNamespacesChanged: {Interval: listenerDebounceInterval, Trailing: true},
+}
+
+//configureListenerDebounce applies the configured (or default) app.Debounce to the registered Listeners.
+func configureListenerDebounce(i *app.Indicator) {
+	for _, l := range i.Listeners() {
+		l.SetDebounce(listenerDebounce(l.Tag))
+	}
+}
+
+//listenerDebounce returns the app.Debounce of the Listeners of a Topic.
+func listenerDebounce(topic client.Topic) app.Debounce {
+	conf, _ := client.GetLocalConfig()
+	configured, present := conf.GetListenerDebounce(topic)
+	if !present {
+		return defaultListenerDebounce[topic]
+	}
+	return app.Debounce{Interval: configured.Interval, Trailing: configured.Trailing == nil || *configured.Trailing}
+}
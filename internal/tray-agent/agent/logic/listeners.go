@@ -1,15 +1,31 @@
 package logic
 
 import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/api"
 	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/history"
 	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
-	"sync"
 )
 
 //this file contains the callback functions for the Indicator listeners
 
 //******* PEERS *******
 
+//listenPeerChanged is the callback of the peer events: the additions and updates are handled by
+//listenAddedOrUpdatedPeer, the removals by listenDeletedPeer. Both are received from a single Listener, which
+//preserves the order of the events.
+func listenPeerChanged(data client.NotifyDataGeneric, args ...interface{}) {
+	fcData, ok := data.(*client.NotifyDataForeignCluster)
+	if !ok {
+		panic("wrong NotifyData type for an event Listener")
+	}
+	if fcData.Deleted {
+		listenDeletedPeer(data, args...)
+	} else {
+		listenAddedOrUpdatedPeer(data, args...)
+	}
+}
+
 func listenAddedOrUpdatedPeer(data client.NotifyDataGeneric, _ ...interface{}) {
 	i := app.GetIndicator()
 	status := i.Status()
@@ -23,22 +39,32 @@ func listenAddedOrUpdatedPeer(data client.NotifyDataGeneric, _ ...interface{}) {
 	defer peer.RUnlock()
 	//update content of the Status MenuNode in the tray menu
 	i.RefreshStatus()
+	publishPeerEvent(api.EventPeerAddedOrUpdated, peer)
+	if recordPeering(history.GetStore(), peer, false) {
+		refreshHistoryQuick(i)
+	}
+	refreshPeeringRequests(i)
 
 	//2- update information on tray menu
 	quickNode, present := i.Quick(qPeers)
 	if !present {
 		return
 	}
-	peerNode, present := quickNode.ListChild(peer.ClusterID)
+	peerNode, parent, present := placePeerEntry(quickNode, fcData)
+	pinned := true
 	if !present {
-		peerNode = createPeerNode(quickNode, fcData, peer)
+		peerNode = createPeerNode(parent, fcData, peer)
+		pinned = pinFavoritePeer(parent, peerNode, fcData.ClusterID)
 	}
-	wg := &sync.WaitGroup{}
-	wg.Add(2)
-	go refreshPeerInfo(peerNode, peer, fcData, wg)
-	go refreshPeeringInfo(peerNode, peer, fcData, wg)
-	wg.Wait()
+	//only the parts of the entry affected by the update are refreshed, avoiding flickers of the menu
+	refreshPeerEntry(peerNode, peer, fcData, peerRenderChange(fcData, !present))
+	refreshPeerGroups(quickNode)
+	refreshPeerSearch(quickNode)
 	refreshPeerCount(quickNode)
+	if !pinned {
+		//the GuiBackend cannot move the entry of the favorite peer: the list is rebuilt with the favorite peers first
+		regroupPeers(i)
+	}
 
 	//3- notify selected events
 	if !present {
@@ -47,6 +73,7 @@ func listenAddedOrUpdatedPeer(data client.NotifyDataGeneric, _ ...interface{}) {
 		}
 		if peer.InPeeringConnected {
 			i.NotifyPeering(app.PeeringIncoming, app.NotifyEventPeeringOn, peer)
+			go checkIncomingPeerIdentity(i, peer.ForeignClusterResourceName)
 		}
 	} else {
 		if !fcData.OutPeering.Connected && peer.OutPeeringConnected {
@@ -56,6 +83,7 @@ func listenAddedOrUpdatedPeer(data client.NotifyDataGeneric, _ ...interface{}) {
 		}
 		if !fcData.InPeering.Connected && peer.InPeeringConnected {
 			i.NotifyPeering(app.PeeringIncoming, app.NotifyEventPeeringOn, peer)
+			go checkIncomingPeerIdentity(i, peer.ForeignClusterResourceName)
 		} else if fcData.InPeering.Connected && !peer.InPeeringConnected {
 			i.NotifyPeering(app.PeeringIncoming, app.NotifyEventPeeringOff, peer)
 		}
@@ -75,17 +103,21 @@ func listenDeletedPeer(data client.NotifyDataGeneric, _ ...interface{}) {
 	defer peer.RUnlock()
 	//update content of the Status MenuNode in the tray menu
 	i.RefreshStatus()
+	publishPeerEvent(api.EventPeerDeleted, peer)
+	if recordPeering(history.GetStore(), peer, true) {
+		refreshHistoryQuick(i)
+	}
+	refreshPeeringRequests(i)
 
 	//2- update information on tray menu
 	quickNode, present := i.Quick(qPeers)
 	if !present {
 		return
 	}
-	_, present = quickNode.ListChild(peer.ClusterID)
-	if present {
-		//remove peer node and all its sub elements
-		quickNode.FreeListChild(peer.ClusterID)
-	}
+	//remove peer node and all its sub elements
+	removePeerEntry(quickNode, peer.ClusterID)
+	forgetRenderedPeer(peer.ClusterID)
+	forgetPeerLatency(peer.ClusterID)
 	refreshPeerCount(quickNode)
 
 	//3- notify selected events
@@ -110,5 +142,8 @@ func listenClusterName(data client.NotifyDataGeneric, _ ...interface{}) {
 	i := app.GetIndicator()
 	status := i.Status()
 	status.SetClusterName(clusterName)
+	//the event is emitted on every change of the ClusterConfig
+	refreshResourceSharing(i)
 	i.RefreshStatus()
+	publishStatusChanged(i)
 }
@@ -0,0 +1,83 @@
+package logic
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/api"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/history"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/metrics"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+)
+
+//startLocalAPI starts, if enabled in the local configuration, the local API exposing the Agent status
+//and the stream of its events and, if enabled as well, the metrics of the Agent.
+func startLocalAPI(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	apiConf := conf.GetLocalAPI()
+	if !apiConf.Enabled {
+		return
+	}
+	server := api.GetServer()
+	server.SetStatusFunc(func() *api.StatusData {
+		return statusData(i.Status())
+	})
+	server.SetAllowedOrigins(apiConf.AllowedOrigins)
+	server.SetHistoryStore(history.GetStore())
+	server.SetCapacityFunc(i.AgentCtrl().CapacityReport)
+	if apiConf.Metrics {
+		registerMetrics(i)
+		server.SetMetricsRegistry(metrics.GetRegistry())
+	}
+	if err := server.Start(apiConf.Address); err != nil {
+		i.Notify("Liqo Agent: LOCAL API UNAVAILABLE", err.Error(), app.NotifyIconWarning, app.IconLiqoNil)
+	}
+}
+
+//stopLocalAPI stops the local API, if running.
+func stopLocalAPI() {
+	api.GetServer().Stop()
+}
+
+//publishStatusChanged streams to the local API clients the updated Agent status.
+func publishStatusChanged(i *app.Indicator) {
+	api.GetServer().Publish(api.NewEvent(api.EventStatusChanged, statusData(i.Status())))
+}
+
+//publishPeerEvent streams to the local API clients an event concerning a peer.
+//The caller must hold the read lock of the PeerInfo.
+func publishPeerEvent(eventType api.EventType, peer *app.PeerInfo) {
+	api.GetServer().Publish(api.NewEvent(eventType, peerData(peer)))
+}
+
+//statusData converts the Indicator Status into the api.StatusData served by the local API.
+func statusData(status app.StatusInterface) *api.StatusData {
+	data := &api.StatusData{
+		Running:          bool(status.Running()),
+		Mode:             status.Mode().String(),
+		ClusterName:      status.ClusterName(),
+		IncomingPeerings: status.Peerings(app.PeeringIncoming),
+		OutgoingPeerings: status.Peerings(app.PeeringOutgoing),
+	}
+	peers := status.PeerList()
+	data.Peers = make([]*api.PeerData, 0, len(peers))
+	for _, peer := range peers {
+		peer.RLock()
+		data.Peers = append(data.Peers, peerData(peer))
+		peer.RUnlock()
+	}
+	return data
+}
+
+//peerData converts a PeerInfo into the api.PeerData served by the local API.
+//The caller must hold the read lock of the PeerInfo.
+func peerData(peer *app.PeerInfo) *api.PeerData {
+	return &api.PeerData{
+		ClusterID:       peer.ClusterID,
+		ClusterName:     peer.ClusterName,
+		OutgoingPeering: peer.OutPeeringConnected,
+		IncomingPeering: peer.InPeeringConnected,
+		OutgoingPhase:   peer.OutPeeringPhase,
+		IncomingPhase:   peer.InPeeringPhase,
+		CpuQuota:        peer.OutCpuQuota,
+		MemQuota:        peer.OutMemQuota,
+	}
+}
@@ -1,11 +1,41 @@
 package logic
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
 	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/history"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/i18n"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/logging"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/metrics"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/settings"
 	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
 	"github.com/liqotech/liqo-agent/internal/tray-agent/test"
+	clusterConfig "github.com/liqotech/liqo/apis/config/v1alpha1"
+	sharing "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/liqotech/liqo/pkg/discovery"
+	objectReferences "github.com/liqotech/liqo/pkg/object-references"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 //test the routines OnReady that is called in the app-indicator/Run() loop and manages the Liqo Agent logic.
@@ -36,14 +66,46 @@ func TestOnReady(t *testing.T) {
 	assert.Truef(t, exist, "QUICK %s not registered", qNotify)
 	_, exist = i.Quick(qPeers)
 	assert.Truef(t, exist, "QUICK %s not registered", qPeers)
+	_, exist = i.Quick(qTopology)
+	assert.Truef(t, exist, "QUICK %s not registered", qTopology)
+	_, exist = i.Quick(qHistory)
+	assert.Truef(t, exist, "QUICK %s not registered", qHistory)
+	_, exist = i.Quick(qStorage)
+	assert.Truef(t, exist, "QUICK %s not registered", qStorage)
+	_, exist = i.Quick(qCapacity)
+	assert.Truef(t, exist, "QUICK %s not registered", qCapacity)
+	_, exist = i.Quick(qCredentials)
+	assert.Truef(t, exist, "QUICK %s not registered", qCredentials)
+	_, exist = i.Quick(qHealth)
+	assert.Truef(t, exist, "QUICK %s not registered", qHealth)
+	_, exist = i.Quick(qActivity)
+	assert.Truef(t, exist, "QUICK %s not registered", qActivity)
+	_, exist = i.Quick(qUpgrade)
+	assert.Truef(t, exist, "QUICK %s not registered", qUpgrade)
+	_, exist = i.Quick(qUninstall)
+	assert.Truef(t, exist, "QUICK %s not registered", qUninstall)
+	_, exist = i.Quick(qIconTheme)
+	assert.Truef(t, exist, "QUICK %s not registered", qIconTheme)
+	_, exist = i.Quick(qQuietHours)
+	assert.Truef(t, exist, "QUICK %s not registered", qQuietHours)
+	_, exist = i.Quick(qTerminal)
+	assert.Truef(t, exist, "QUICK %s not registered", qTerminal)
+	_, exist = i.Quick(qCustomize)
+	assert.Truef(t, exist, "QUICK %s not registered", qCustomize)
 
 	// test Listeners registrations
 
 	// test peers Listeners
-	_, exist = i.Listener(client.ChanPeerAddedOrUpdated)
-	assert.True(t, exist, "Listener for NotifyChanType ChanPeerAddedOrUpdated not registered")
-	_, exist = i.Listener(client.ChanPeerDeleted)
-	assert.True(t, exist, "Listener for NotifyChanType ChanPeerDeleted not registered")
+	_, exist = i.Listener(client.TopicPeerChanged)
+	assert.True(t, exist, "Listener for NotifyChanType TopicPeerChanged not registered")
+	_, exist = i.Listener(client.TopicStorageChanged)
+	assert.True(t, exist, "Listener for NotifyChanType TopicStorageChanged not registered")
+	_, exist = i.Listener(client.TopicHealthChanged)
+	assert.True(t, exist, "Listener for NotifyChanType TopicHealthChanged not registered")
+	_, exist = i.Listener(client.TopicWorkloadsChanged)
+	assert.True(t, exist, "Listener for NotifyChanType TopicWorkloadsChanged not registered")
+	_, exist = i.Listener(client.TopicOfferChanged)
+	assert.True(t, exist, "Listener for NotifyChanType TopicOfferChanged not registered")
 }
 
 func TestPeersListeners(t *testing.T) {
@@ -109,3 +171,2516 @@ func TestPeersListeners(t *testing.T) {
 	assert.Equal(t, 0, endCount, "peers list is not empty when 0 ForeignCluster(s) exist [init phase]")
 	assert.False(t, quickNode.IsEnabled(), "peers menu entry should be disabled when 0 ForeignCluster(s) exist")
 }
+
+//test the recording and the display of the peering history.
+func TestPeeringHistory(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	OnReady()
+	i := app.GetIndicator()
+	quickNode, present := i.Quick(qHistory)
+	if !present {
+		t.Fatal("Peering history QUICK not registered")
+	}
+	store, _ := history.NewStore("", 0, 0)
+	peer := &app.PeerInfo{ClusterID: "cl1", ClusterName: "test1"}
+	assert.False(t, recordPeering(store, peer, false), "a never connected peer has been recorded")
+	peer.OutPeeringConnected = true
+	peer.OutCpuQuota = "2"
+	assert.True(t, recordPeering(store, peer, false), "outgoing peering not recorded")
+	assert.False(t, recordPeering(store, peer, false), "unchanged peering recorded twice")
+	peer.OutCpuQuota = "4"
+	assert.True(t, recordPeering(store, peer, false), "change of the shared resources not recorded")
+	assert.True(t, recordPeering(store, peer, true), "removal of the peer not recorded")
+	last, _ := store.LastRecord("cl1", history.DirectionOutgoing)
+	assert.False(t, last.Connected, "removed peer still recorded as connected")
+	refreshHistory(quickNode, store, time.Now())
+	assert.True(t, quickNode.IsEnabled(), "peering history disabled with stored records")
+	peerNode, present := quickNode.ListChild("cl1")
+	if assert.True(t, present, "peer missing in the peering history") {
+		assert.Equal(t, "test1", peerNode.Title())
+		assert.Equal(t, 1, peerNode.ListChildrenLen(), "wrong number of days in the peering history")
+	}
+}
+
+func TestFormatIntervals(t *testing.T) {
+	start := time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 1)
+	intervals := []history.Interval{
+		{Start: start.Add(-time.Hour), End: start.Add(2 * time.Hour)},
+		{Start: start.Add(14 * time.Hour), End: start.Add(15*time.Hour + 30*time.Minute), Open: true},
+		{Start: end.Add(time.Hour), End: end.Add(2 * time.Hour)},
+	}
+	assert.Equal(t, "00:00-02:00, 14:00-now", formatIntervals(intervals, start, end))
+	assert.Equal(t, "", formatIntervals(nil, start, end))
+}
+
+func TestStorageWarnings(t *testing.T) {
+	w := &client.OffloadingWarning{Namespace: "ns", Pod: "pod", ClusterID: "cl1", Claims: []string{"data"}}
+	report := &client.StorageReport{Warnings: []*client.OffloadingWarning{w}}
+	assert.Equal(t, 1, len(newStorageWarnings(report)), "new offloading warning not detected")
+	assert.Equal(t, 0, len(newStorageWarnings(report)), "offloading warning notified twice")
+	assert.Equal(t, 0, len(newStorageWarnings(&client.StorageReport{})))
+	assert.Equal(t, 1, len(newStorageWarnings(report)), "reappeared offloading warning not detected")
+}
+
+func TestGauge(t *testing.T) {
+	assert.Equal(t, "▕░░░░▏ 0%", gauge(0, 0, 4))
+	assert.Equal(t, "▕██░░▏ 50%", gauge(1, 2, 4))
+	assert.Equal(t, "▕█▌░░▏ 38%", gauge(3, 8, 4))
+	//overcommitted resources do not overflow the gauge
+	assert.Equal(t, "▕████▏ 150%", gauge(3, 2, 4))
+}
+
+func TestExpiryText(t *testing.T) {
+	now := time.Now()
+	c := &client.CredentialInfo{Expiry: now.Add(3*24*time.Hour + 4*time.Hour + time.Minute)}
+	assert.Equal(t, "expires in 3d 4h", expiryText(c, now))
+	c.Expiry = now.Add(2*time.Hour + 30*time.Minute)
+	assert.Equal(t, "expires in 2h 30m", expiryText(c, now))
+	c.Expiry = now.Add(-time.Minute)
+	assert.Equal(t, "is EXPIRED", expiryText(c, now))
+}
+
+func TestHealth(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	OnReady()
+	quick, present := app.GetIndicator().Quick(qHealth)
+	if !present {
+		t.Fatal("Cluster health QUICK not registered")
+	}
+	failing := &client.ComponentHealth{Name: "liqo-gateway", Desired: 1, CrashLooping: []string{"liqo-gateway-1"}}
+	report := &client.HealthReport{Namespace: "liqo", Components: []*client.ComponentHealth{
+		{Name: "liqo-auth", Desired: 1, Ready: 1}, failing}}
+	i := app.GetIndicator()
+	refreshHealth(i, quick, report)
+	assert.Equal(t, titleHealth+": 1/2 components failing", quick.Title())
+	assert.Equal(t, 2, quick.ListChildrenLen())
+	node, present := quick.ListChild("liqo-gateway")
+	if assert.True(t, present) {
+		assert.Equal(t, "✖ liqo-gateway 0/1 (CrashLoopBackOff)", node.Title())
+	}
+	assert.Equal(t, 1, len(newFailures(report)), "new crash-loop not detected")
+	assert.Equal(t, 0, len(newFailures(report)), "crash-loop notified twice")
+	failing.CrashLooping = nil
+	assert.Equal(t, 0, len(newFailures(report)))
+	//the pods not Ready are reported as well
+	failing.NotReady = []string{"liqo-gateway-1"}
+	assert.Equal(t, "has pods not Ready (liqo-gateway-1)", componentFailure(failing))
+	assert.Equal(t, 1, len(newFailures(report)), "pod not Ready not detected")
+	refreshHealth(i, quick, report)
+	node, _ = quick.ListChild("liqo-gateway")
+	assert.Equal(t, "✖ liqo-gateway 0/1 (1 not Ready)", node.Title())
+	failing.NotReady, failing.Ready = nil, 1
+	refreshHealth(i, quick, report)
+	assert.Equal(t, titleHealth+": OK", quick.Title())
+}
+
+func TestReconnect(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	OnReady()
+	i := app.GetIndicator()
+	defer i.Quit()
+	reconnect, present := i.Quick(qReconnect)
+	if !present {
+		t.Fatal("Reconnect QUICK not registered")
+	}
+	offline, present := i.Quick(qOffline)
+	if !present {
+		t.Fatal("Offline QUICK not registered")
+	}
+	assert.False(t, reconnect.IsVisible(), "Reconnect QUICK visible while connected")
+	assert.False(t, offline.IsVisible(), "Offline QUICK visible while connected")
+	//the status of the connected cluster is saved, once the caches are synchronized
+	previous, hadPrevious := client.GetStatusCache().Status()
+	defer func() {
+		if hadPrevious {
+			_ = client.GetStatusCache().Save(previous)
+		} else {
+			_ = client.GetStatusCache().Reset()
+		}
+	}()
+	assert.NoError(t, client.GetStatusCache().Reset())
+	i.Status().SetCacheSync(client.CacheSyncProgress{Synced: 1, Total: 1})
+	saveStatusCache(i)
+	_, saved := client.GetStatusCache().Status()
+	assert.True(t, saved, "status of the connected cluster not saved")
+	//a reconnection in progress is stopped once connected
+	reconnection.Lock()
+	reconnection.backoff = client.DefaultBackoffPolicy.NewBackoff()
+	reconnection.Unlock()
+	updateReconnection(i)
+	reconnection.Lock()
+	assert.Nil(t, reconnection.backoff, "reconnection not stopped")
+	reconnection.Unlock()
+	//the last known status is displayed
+	refreshOffline(i, offline, client.CachedStatus{ClusterName: "home", Context: "kind-home",
+		Peers: []client.CachedPeer{{ClusterID: "cl1", ClusterName: "peer-1", Outgoing: true},
+			{ClusterID: "cl2"}}, SavedAt: i.Now().Add(-2 * time.Hour)})
+	assert.Contains(t, offline.Title(), titleOffline+": last known status, ")
+	assert.Equal(t, 4, offline.ListChildrenLen())
+	node, present := offline.ListChild("cluster")
+	if assert.True(t, present) {
+		assert.Equal(t, "Cluster: home (context kind-home)", node.Title())
+	}
+	node, present = offline.ListChild("peers")
+	if assert.True(t, present) {
+		assert.Equal(t, "Peers: 2, 1 active peering", node.Title())
+	}
+	node, present = offline.ListChild("peer/cl1")
+	if assert.True(t, present) {
+		assert.Equal(t, "• peer-1 (outgoing)", node.Title())
+	}
+	assert.Equal(t, "• cl2", cachedPeerTitle(client.CachedPeer{ClusterID: "cl2"}))
+}
+
+func TestKubeconfigReload(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	OnReady()
+	i := app.GetIndicator()
+	defer i.Quit()
+	authenticationNotified := func() (app.Notification, bool) {
+		for _, n := range i.Notifications() {
+			if n.ID == notificationIDAuthentication {
+				return n, true
+			}
+		}
+		return app.Notification{}, false
+	}
+	//the credentials rejected by the cluster are notified with a "Retry" action
+	notifyAuthenticationFailure(i, client.ClassifyError("reload kubeconfig", apierrors.NewUnauthorized("expired")))
+	n, present := authenticationNotified()
+	if assert.True(t, present, "authentication failure not notified") {
+		assert.Equal(t, app.SeverityError, n.Severity)
+		if assert.Len(t, n.Actions, 2) {
+			assert.Equal(t, "Retry", n.Actions[0].Label)
+			assert.NotNil(t, n.Actions[0].Handler)
+		}
+	}
+	//a successful reload keeps the AgentController connected and dismisses the notification
+	reloadKubeconfig(context.Background(), i)
+	assert.True(t, i.AgentCtrl().Connected(), "AgentController not connected after the reload")
+	_, present = authenticationNotified()
+	assert.False(t, present, "authentication failure not dismissed")
+	//other sources (e.g. the startup summary) may add entries to the feed meanwhile
+	var reload *activity.Entry
+	entries := activity.GetFeed().Entries()
+	for index := len(entries) - 1; index >= 0 && reload == nil; index-- {
+		if entries[index].Source == activitySourceKubeconfig {
+			reload = entries[index]
+		}
+	}
+	if assert.NotNil(t, reload, "kubeconfig reload not added to the activity feed") {
+		assert.Equal(t, activity.OutcomeSuccess, reload.Outcome)
+	}
+}
+
+func TestPeerTokenExpiry(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	OnReady()
+	i := app.GetIndicator()
+	defer i.Quit()
+	quick, present := i.Quick(qCredentials)
+	if !present {
+		t.Fatal("Credentials QUICK not registered")
+	}
+	cache := client.GetCredentialCache()
+	defer cache.Clear()
+	cache.Put("sso", "token-peer", client.PeerCredential{Token: "t", Expiry: time.Now().Add(25 * time.Hour)})
+	checkCredentials(i)
+	entry, present := quick.ListChild("0")
+	if assert.True(t, present, "peering token not listed") {
+		assert.Contains(t, entry.Title(), "peering token (token-peer): expires in")
+	}
+	item, present := i.Pending().Item(pendingCredentials)
+	if assert.True(t, present, "expiring peering token not reported") {
+		assert.Contains(t, item.Title, "The peering token of 'token-peer' expires in")
+	}
+	assert.Equal(t, "peer 'token-peer'", credentialOwner(&client.CredentialInfo{User: "token-peer",
+		Kind: client.CredentialPeerToken}))
+}
+
+func TestLogin(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	OnReady()
+	i := app.GetIndicator()
+	defer i.Quit()
+	quick, present := i.Quick(qCredentials)
+	if !present {
+		t.Fatal("Credentials QUICK not registered")
+	}
+	//with no login command, the failures only offer to retry
+	_, ok := loginMethod(i)
+	assert.False(t, ok, "login command available")
+	_, present = quick.ListChild(tagLogin)
+	assert.False(t, present, "login entry displayed with no login command")
+	conf, _ := client.GetLocalConfig()
+	defer conf.SetOrgDefaults(nil)
+	conf.SetOrgDefaults(&client.LocalConfig{LoginCommand: "aws sso login"})
+	checkCredentials(i)
+	entry, present := quick.ListChild(tagLogin)
+	if assert.True(t, present, "login entry not displayed") {
+		assert.Equal(t, titleLogin+" (aws sso login)", entry.Title())
+		assert.True(t, quick.IsEnabled(), "Credentials QUICK disabled")
+	}
+	//an expired token is notified with the login action
+	notifyAuthenticationFailure(i, apierrors.NewUnauthorized("token expired"))
+	var notification *app.Notification
+	for _, n := range i.Notifications() {
+		if n.ID == notificationIDAuthentication {
+			n := n
+			notification = &n
+		}
+	}
+	if assert.NotNil(t, notification, "expired token not notified") {
+		assert.Equal(t, "Liqo Agent: LOGIN REQUIRED", notification.Title)
+		if assert.Len(t, notification.Actions, 3) {
+			assert.Equal(t, "Log in", notification.Actions[0].Label)
+			assert.Equal(t, "Retry", notification.Actions[1].Label)
+		}
+	}
+	//the entry is removed with the login command
+	conf.SetOrgDefaults(nil)
+	checkCredentials(i)
+	_, present = quick.ListChild(tagLogin)
+	assert.False(t, present, "login entry displayed after the removal of the login command")
+}
+
+func TestActivity(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	OnReady()
+	quick, present := app.GetIndicator().Quick(qActivity)
+	if !present {
+		t.Fatal("Activity QUICK not registered")
+	}
+	start := len(activity.GetFeed().Entries())
+	activity.GetFeed().Add(activitySourceUpgrade, "test upgrade", activity.OutcomeSuccess)
+	assert.True(t, quick.IsEnabled(), "Activity QUICK disabled with feed entries")
+	expected := start + 1
+	if expected > activityMenuEntries {
+		expected = activityMenuEntries
+	}
+	assert.Equal(t, expected, quick.ListChildrenLen(), "activity feed not refreshed")
+	node, _ := quick.ListChild("0")
+	assert.Contains(t, node.Title(), "✔ ")
+	assert.Contains(t, node.Title(), "test upgrade")
+}
+
+func TestUpgradeHelpers(t *testing.T) {
+	assert.Equal(t, "Upgrade Liqo to v0.3.0…", upgradeTitle("v0.3.0"))
+	assert.Equal(t, titleUpgrade, upgradeTitle(""))
+	assert.Equal(t, "abcd…", truncate("abcdefgh", 5))
+	assert.Equal(t, "abc", truncate(" abc ", 5))
+	assert.Equal(t, "Error: failed", lastLine("Release \"liqo\"\nError: failed\n\n"))
+}
+
+//test that the helm and liqoctl commands target the cluster the Agent is connected to.
+func TestClusterCommand(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	OnReady()
+	i := app.GetIndicator()
+	defer i.Quit()
+	prev, present := os.LookupEnv(client.EnvLiqoKConfig)
+	defer func() {
+		if present {
+			_ = os.Setenv(client.EnvLiqoKConfig, prev)
+		} else {
+			_ = os.Unsetenv(client.EnvLiqoKConfig)
+		}
+	}()
+	//no command is run if the cluster cannot be resolved
+	assert.NoError(t, os.Unsetenv(client.EnvLiqoKConfig))
+	_, err := clusterCommand(i, "helm", "version")
+	assert.Error(t, err, "command created with no kubeconfig")
+	dir, err := ioutil.TempDir("", "liqo-upgrade")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	kubeconfig := filepath.Join(dir, "config")
+	assert.NoError(t, ioutil.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: home
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: home
+  context:
+    cluster: home
+current-context: home
+`), 0600))
+	assert.NoError(t, os.Setenv(client.EnvLiqoKConfig, kubeconfig))
+	//the default kubeconfig file of the user is overridden
+	prevDefault, defaultPresent := os.LookupEnv("KUBECONFIG")
+	defer func() {
+		if defaultPresent {
+			_ = os.Setenv("KUBECONFIG", prevDefault)
+		} else {
+			_ = os.Unsetenv("KUBECONFIG")
+		}
+	}()
+	assert.NoError(t, os.Setenv("KUBECONFIG", filepath.Join(dir, "other")))
+	cmd, err := clusterCommand(i, "helm", "version")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"helm", "version"}, cmd.Args)
+		//the last occurrence of a variable is the one used by the command
+		values := make(map[string]string)
+		for _, v := range cmd.Env {
+			kv := strings.SplitN(v, "=", 2)
+			values[kv[0]] = kv[1]
+		}
+		assert.Equal(t, kubeconfig, values["KUBECONFIG"], "wrong kubeconfig")
+		assert.Equal(t, "home", values[client.EnvShellContext], "wrong context")
+		assert.Empty(t, values[envHelmKubeContext], "helm context not reset")
+	}
+}
+
+func TestWorkloadFailures(t *testing.T) {
+	evicted := &client.WorkloadFailure{Namespace: "ns", Pod: "p1", ClusterID: "cl1", Reason: "Evicted"}
+	crashing := &client.WorkloadFailure{Namespace: "ns", Pod: "p2", ClusterID: "cl1", Reason: "CrashLoopBackOff"}
+	assert.Equal(t, 2, len(newWorkloadFailures([]*client.WorkloadFailure{evicted, crashing})))
+	assert.Equal(t, 0, len(newWorkloadFailures([]*client.WorkloadFailure{evicted, crashing})),
+		"failures notified twice")
+	assert.Equal(t, 0, len(newWorkloadFailures([]*client.WorkloadFailure{evicted})))
+	assert.Equal(t, 1, len(newWorkloadFailures([]*client.WorkloadFailure{evicted, crashing})),
+		"recurring failure not notified again")
+}
+
+func TestOfferChangeMessage(t *testing.T) {
+	changes := []*client.OfferChange{
+		{Field: "label zone", Old: "eu-1", New: "eu-2", Kind: client.OfferChanged},
+		{Field: "quota memory", Old: "8Gi", New: "4Gi", Kind: client.OfferDecreased},
+	}
+	assert.Equal(t, "label zone: eu-1 → eu-2 (changed)\nquota memory: 8Gi → 4Gi (decreased)",
+		offerChangeMessage(changes))
+	assert.True(t, offerShrunk(changes))
+	assert.False(t, offerShrunk(changes[:1]))
+}
+
+func TestQuietHoursTitle(t *testing.T) {
+	schedule, err := app.NewQuietSchedule([]client.QuietHoursRule{{From: "22:00", To: "08:00"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//Wednesday
+	now := time.Date(2021, time.April, 21, 23, 0, 0, 0, time.UTC)
+	assert.Equal(t, "Quiet hours: ON until Thu 08:00", quietHoursTitle(schedule, now))
+	now = time.Date(2021, time.April, 21, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, "Quiet hours: off until 22:00", quietHoursTitle(schedule, now))
+}
+
+func TestQuietHoursSchedule(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	defer i.SetQuietHours(nil)
+	defer i.SetQuietUntil(time.Time{})
+	s := quietHoursSchedule{i}
+	//Wednesday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2021, time.April, day, hour, minute, 0, 0, time.Local)
+	}
+	next, ok := s.Next(at(21, 12, 0))
+	assert.True(t, ok)
+	assert.Equal(t, at(22, 0, 0), next, "day in the title not refreshed at midnight")
+	schedule, _ := app.NewQuietSchedule([]client.QuietHoursRule{{From: "22:00", To: "08:00"}})
+	i.SetQuietHours(schedule)
+	next, _ = s.Next(at(21, 12, 0))
+	assert.Equal(t, at(21, 22, 0), next, "start of the quiet hours not scheduled")
+	i.SetQuietUntil(at(21, 13, 30))
+	next, _ = s.Next(at(21, 12, 0))
+	assert.Equal(t, at(21, 13, 30), next, "end of the quiet period not scheduled")
+	if timer, present := i.Timer(tQuietHours); assert.True(t, present) {
+		assert.Equal(t, "at the changes of the quiet hours", timer.Schedule().String())
+	}
+}
+
+func TestShellScript(t *testing.T) {
+	assert.Equal(t, `echo "kubectl context: ${LIQO_CONTEXT:-current}"; exec "${SHELL:-sh}"`, shellScript(""))
+	script := shellScript("cl1")
+	assert.Contains(t, script, "--field-selector spec.nodeName=$LIQO_VIRTUAL_NODE")
+	assert.True(t, strings.HasSuffix(script, `exec "${SHELL:-sh}"`))
+}
+
+func TestMenuLayout(t *testing.T) {
+	names := func(sections []*menuSection) []string {
+		var n []string
+		for _, s := range sections {
+			n = append(n, s.name)
+		}
+		return n
+	}
+	pinned, others := arrangeSections(client.MenuLayoutConfig{})
+	assert.Empty(t, pinned)
+	assert.Equal(t, sectionNames(), names(others), "wrong default layout")
+	pinned, others = arrangeSections(client.MenuLayoutConfig{
+		Order:  []string{"settings", "unknown", "Diagnostics"},
+		Pinned: []string{"diagnostics"},
+		Hidden: []string{"maintenance", "resources"},
+	})
+	assert.Equal(t, []string{sectionDiagnostics}, names(pinned))
+	assert.Equal(t, []string{sectionSettings, sectionPeers, sectionOffloading}, names(others))
+	layout := newMenuLayout([]string{"Peers", "Diagnostics"}, []string{"Diagnostics"},
+		[]string{" diagnostics", "peers "})
+	assert.Equal(t, []string{sectionDiagnostics, sectionPeers}, layout.Order)
+	assert.Equal(t, []string{sectionDiagnostics}, layout.Pinned)
+	assert.Equal(t, []string{sectionResources, sectionOffloading, sectionMaintenance, sectionSettings}, layout.Hidden)
+}
+
+func TestDiffPeer(t *testing.T) {
+	old := &client.NotifyDataForeignCluster{ClusterID: "id1", ClusterName: "peer1"}
+	assert.Equal(t, peerChangeAll, diffPeer(nil, old), "new peer not entirely rendered")
+	data := *old
+	assert.Equal(t, peerChange(0), diffPeer(old, &data), "unchanged peer refreshed")
+	data.ClusterName = "peer1-renamed"
+	assert.Equal(t, peerChangeName, diffPeer(old, &data))
+	data = *old
+	data.OutPeering.Connected = true
+	assert.Equal(t, peerChangePeering, diffPeer(old, &data))
+	data = *old
+	data.AuthStatus = discovery.AuthStatusAccepted
+	assert.Equal(t, peerChangeStatus|peerChangePeering, diffPeer(old, &data))
+	//the rendered data are tracked until the peer is removed
+	assert.Equal(t, peerChangeAll, peerRenderChange(old, false))
+	assert.Equal(t, peerChange(0), peerRenderChange(old, false))
+	assert.Equal(t, peerChangeAll, peerRenderChange(old, true), "rebuilt entry not entirely rendered")
+	forgetRenderedPeer(old.ClusterID)
+	assert.Equal(t, peerChangeAll, peerRenderChange(old, false))
+	forgetRenderedPeer(old.ClusterID)
+}
+
+func TestHeartbeatMessage(t *testing.T) {
+	title, message, outcome := heartbeatMessage(&client.NotifyDataHeartbeat{Reachable: false,
+		Err: client.ErrTimeout})
+	assert.Contains(t, title, "UNREACHABLE")
+	assert.Contains(t, message, client.ErrTimeout.Error())
+	assert.Equal(t, activity.OutcomeFailure, outcome)
+	title, _, outcome = heartbeatMessage(&client.NotifyDataHeartbeat{Reachable: true})
+	assert.NotContains(t, title, "UNREACHABLE")
+	assert.Equal(t, activity.OutcomeSuccess, outcome)
+}
+
+func TestRecentPeerTitle(t *testing.T) {
+	assert.Equal(t, "peer1 [LAN] "+labelPeerRecent, recentPeerTitle("peer1 [LAN]", true))
+	assert.Equal(t, "peer1 [LAN]", recentPeerTitle("peer1 [LAN] "+labelPeerRecent, false))
+	assert.Equal(t, "peer1 "+labelPeerRecent, recentPeerTitle("peer1 "+labelPeerRecent, true))
+}
+
+func TestUninstallConfirmationWord(t *testing.T) {
+	release := &client.LiqoRelease{Name: "liqo"}
+	assert.Equal(t, "home", uninstallConfirmationWord("home", release))
+	assert.Equal(t, "liqo", uninstallConfirmationWord("", release))
+	assert.Equal(t, "uninstall", uninstallConfirmationWord("", nil))
+}
+
+func TestClaimUninstall(t *testing.T) {
+	if !assert.True(t, claimUninstall()) {
+		return
+	}
+	assert.False(t, claimUninstall(), "uninstallation claimed twice")
+	releaseUninstall()
+	upgrade.Lock()
+	upgrade.running = true
+	upgrade.Unlock()
+	assert.False(t, claimUninstall(), "uninstallation claimed during an upgrade")
+	upgrade.Lock()
+	upgrade.running = false
+	upgrade.Unlock()
+	assert.True(t, claimUninstall())
+	releaseUninstall()
+}
+
+//test the stress-test developer mode with a small amount of synthetic peers.
+func TestStressTest(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	//the events are not tracked by the EventTester
+	app.GetGuiProvider().NewEventTester()
+	OnReady()
+	i := app.GetIndicator()
+	config := client.DefaultStressConfig
+	config.Peers, config.Rate, config.Duration = 30, 200, 200*time.Millisecond
+	//the handler latency depends on the load of the machine running the test: only the final menu is checked
+	config.MaxLatency, config.MaxSettling = 0, 30*time.Second
+	report, err := runStressTest(context.Background(), i, config)
+	if assert.NoError(t, err) {
+		assert.Empty(t, report.Failures, "stress test assertions failed")
+		assert.NotZero(t, report.Listeners["peerChanged"].Handled, "peer events not handled")
+		quick, _ := i.Quick(qPeers)
+		assert.Equal(t, report.Injected.Peers, quick.ListChildrenLen(), "peers menu not matching the injected peers")
+	}
+	i.Quit()
+}
+
+//test the tracking of the Agent startup and the contents of the Status window.
+func TestStartup(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	app.GetGuiProvider().NewEventTester()
+	OnReady()
+	i := app.GetIndicator()
+	_, present := i.Quick(qStatusWindow)
+	assert.True(t, present, "Status window QUICK not registered")
+	//the startup completes once the caches are synced
+	s := currentStartup()
+	deadline := time.Now().Add(3 * time.Second)
+	for !s.completed() && time.Now().Before(deadline) {
+		time.Sleep(cacheSyncInterval)
+		refreshCacheSync(i, s)
+	}
+	assert.True(t, s.completed(), "startup not completed")
+	details := statusDetails(i)
+	assert.Contains(t, details, "Cluster: connected")
+	for _, stage := range []string{stageConfig, stageConnect, stageMenu, stageCaches} {
+		assert.Contains(t, details, stage, "startup stage missing from the Status window")
+	}
+	assert.NotContains(t, details, "in progress", "startup stages not completed")
+	entries := activity.GetFeed().Entries()
+	if assert.NotEmpty(t, entries) {
+		found := false
+		for _, e := range entries {
+			if e.Source == activitySourceStartup {
+				found = true
+				assert.Equal(t, activity.OutcomeSuccess, e.Outcome)
+			}
+		}
+		assert.True(t, found, "startup summary not recorded in the activity feed")
+	}
+	i.Quit()
+}
+
+//test the QUICK summarizing the items awaiting an input of the user.
+func TestPendingQuick(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	app.GetGuiProvider().NewEventTester()
+	OnReady()
+	i := app.GetIndicator()
+	quick, present := i.Quick(qPending)
+	if !assert.True(t, present, "pending QUICK not registered") {
+		return
+	}
+	assert.False(t, quick.IsVisible(), "pending QUICK visible with no pending item")
+	addPendingFailure(i, "test", "Test failed", "details")
+	i.Pending().Add(app.PendingItem{ID: pendingUpgrade, Kind: app.PendingRequest, Title: "Liqo v1 available"})
+	assert.True(t, quick.IsVisible(), "pending QUICK not visible")
+	assert.Equal(t, "⚑ 1 pending request, 1 problem", quick.Title())
+	assert.Equal(t, 2, quick.ListChildrenLen())
+	if child, present := quick.ListChild(pendingFailurePrefix + "test"); assert.True(t, present) {
+		assert.Equal(t, "✖ Test failed", child.Title())
+	}
+	//selecting a failure dismisses it
+	item, _ := i.Pending().Item(pendingFailurePrefix + "test")
+	item.Action.HandleClick(context.Background(), &app.ClickEvent{Indicator: i})
+	assert.Equal(t, 1, quick.ListChildrenLen())
+	i.Pending().Remove(pendingUpgrade)
+	assert.False(t, quick.IsVisible(), "pending QUICK visible with no pending item")
+	i.Quit()
+}
+
+//test the grouping of the peers list by a label.
+func TestPeerGroups(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	conf, _ := client.GetLocalConfig()
+	conf.SetPeerGroupLabel("region")
+	defer conf.SetPeerGroupLabel("")
+	quick, _ := i.Quick(qPeers)
+	fcCtrl := i.AgentCtrl().Controller(client.CRForeignCluster)
+	fc1 := test.CreateForeignCluster("grp1", "test1")
+	fc1.Labels = map[string]string{"region": "eu-west"}
+	fc2 := test.CreateForeignCluster("grp2", "test2")
+	eventTester.Add(2)
+	assert.NoError(t, fcCtrl.Store.Add(fc1))
+	assert.NoError(t, fcCtrl.Store.Add(fc2))
+	eventTester.Wait()
+	assert.Equal(t, 2, quick.ListChildrenLen(), "wrong number of peer groups")
+	assert.Equal(t, 2, peerEntriesLen(quick))
+	group, present := quick.ListChild(tagPeerGroupPrefix + "eu-west")
+	if assert.True(t, present, "peer group not displayed") {
+		assert.Equal(t, "eu-west (1 peer, 0 peered)", group.Title())
+		_, present = group.ListChild("grp1")
+		assert.True(t, present, "peer not displayed in its group")
+	}
+	if group, present := quick.ListChild(tagPeerGroupPrefix); assert.True(t, present, "peer group not displayed") {
+		assert.Equal(t, "Other (1 peer, 0 peered)", group.Title())
+	}
+	//moving a peer to a different group removes the empty one
+	fc1 = fc1.DeepCopy()
+	fc1.Labels = map[string]string{"region": "us-east"}
+	eventTester.Add(1)
+	assert.NoError(t, fcCtrl.Store.Update(fc1))
+	eventTester.Wait()
+	_, present = quick.ListChild(tagPeerGroupPrefix + "eu-west")
+	assert.False(t, present, "empty peer group still displayed")
+	_, present = findPeerEntry(quick, "grp1")
+	assert.True(t, present, "moved peer not displayed")
+	//disabling the grouping lists the peers directly
+	conf.SetPeerGroupLabel("")
+	regroupPeers(i)
+	_, present = quick.ListChild("grp2")
+	assert.True(t, present, "peer not displayed after disabling the grouping")
+	assert.Equal(t, 2, quick.ListChildrenLen())
+	i.Quit()
+}
+
+func TestPeerSearch(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	defer i.Quit()
+	defer searchPeers(i, "")
+	conf, _ := client.GetLocalConfig()
+	conf.SetPeerGroupLabel("region")
+	defer conf.SetPeerGroupLabel("")
+	quick, _ := i.Quick(qPeers)
+	fcCtrl := i.AgentCtrl().Controller(client.CRForeignCluster)
+	fc1 := test.CreateForeignCluster("srch1", "Cluster-EU-1")
+	fc1.Labels = map[string]string{"region": "eu-west"}
+	fc2 := test.CreateForeignCluster("srch2", "cluster-us-1")
+	fc2.Labels = map[string]string{"region": "us-east"}
+	eventTester.Add(2)
+	assert.NoError(t, fcCtrl.Store.Add(fc1))
+	assert.NoError(t, fcCtrl.Store.Add(fc2))
+	eventTester.Wait()
+	//the peers not matching the search, and their groups, are hidden regardless of the case
+	searchPeers(i, " eu ")
+	search, _ := i.Quick(qPeerSearch)
+	assert.Equal(t, "Search Peers: \"eu\"", search.Title())
+	euGroup, _ := quick.ListChild(tagPeerGroupPrefix + "eu-west")
+	usGroup, _ := quick.ListChild(tagPeerGroupPrefix + "us-east")
+	assert.True(t, euGroup.IsListed())
+	assert.False(t, usGroup.IsListed(), "group without matching peers displayed")
+	//the new peers are searched as well
+	fc3 := test.CreateForeignCluster("srch3", "cluster-eu-2")
+	fc3.Labels = map[string]string{"region": "us-east"}
+	eventTester.Add(1)
+	assert.NoError(t, fcCtrl.Store.Add(fc3))
+	eventTester.Wait()
+	assert.True(t, usGroup.IsListed(), "group with a matching peer hidden")
+	peer, _ := usGroup.ListChild("srch2")
+	assert.False(t, peer.IsListed())
+	peer, _ = usGroup.ListChild("srch3")
+	assert.True(t, peer.IsListed())
+	//the search applies to the peers listed directly as well
+	conf.SetPeerGroupLabel("")
+	regroupPeers(i)
+	for id, listed := range map[string]bool{"srch1": true, "srch2": false, "srch3": true} {
+		peer, present := quick.ListChild(id)
+		if assert.True(t, present) {
+			assert.Equal(t, listed, peer.IsListed(), id)
+		}
+	}
+	searchPeers(i, "")
+	assert.Equal(t, titlePeerSearch, search.Title())
+	peer, _ = quick.ListChild("srch2")
+	assert.True(t, peer.IsListed(), "peer hidden after clearing the search")
+}
+
+//test the restoration of the peers search, grouping and expanded lists left at the previous run.
+func TestMenuStateRestore(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	store := client.GetMenuStateStore()
+	defer func() {
+		_ = store.Reset()
+	}()
+	label := "region"
+	assert.NoError(t, store.Update(func(state *client.MenuState) {
+		state.PeerSearch = "eu"
+		state.PeerGroupLabel = &label
+		state.ExpandedLists = map[string]int{qPeers: 2}
+	}))
+	OnReady()
+	i := app.GetIndicator()
+	defer i.Quit()
+	defer applyPeerSearch(i, "")
+	//the lists are paginated again, and their pages restored, when the settings change
+	conf, _ := client.GetLocalConfig()
+	conf.SetOrgDefaults(&client.LocalConfig{ListPageSize: 1})
+	defer conf.SetOrgDefaults(nil)
+	reapplySettings(i)
+	search, _ := i.Quick(qPeerSearch)
+	assert.Equal(t, "Search Peers: \"eu\"", search.Title(), "search not restored")
+	assert.Equal(t, "region", peerGroupLabel(), "grouping not restored")
+	//the restored pages display the first 2 groups
+	quick, _ := i.Quick(qPeers)
+	fcCtrl := i.AgentCtrl().Controller(client.CRForeignCluster)
+	for _, region := range []string{"eu-1", "eu-2", "eu-3"} {
+		fc := test.CreateForeignCluster("restore-"+region, "cluster-"+region)
+		fc.Labels = map[string]string{"region": region}
+		eventTester.Add(1)
+		assert.NoError(t, fcCtrl.Store.Add(fc))
+		eventTester.Wait()
+	}
+	listed := 0
+	for _, group := range quick.ListChildren() {
+		if group.IsListed() {
+			listed++
+		}
+	}
+	assert.Equal(t, 2, listed, "expanded pages not restored")
+	//a new search displays the first page again, and the choices are saved
+	searchPeers(i, "cluster")
+	setPeerGroupLabel(i, "")
+	state := store.State()
+	assert.Equal(t, "cluster", state.PeerSearch)
+	if assert.NotNil(t, state.PeerGroupLabel) {
+		assert.Equal(t, "", *state.PeerGroupLabel)
+	}
+	assert.NotContains(t, state.ExpandedLists, qPeers, "expanded pages kept after a new search")
+}
+
+func TestPeerPreferences(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	defer i.Quit()
+	conf, _ := client.GetLocalConfig()
+	defer func() {
+		conf.SetPeerAlias("pref2", "")
+		conf.SetPeerFavorite("pref2", false)
+		configurePeerPreferences(i)
+	}()
+	quick, _ := i.Quick(qPeers)
+	fcCtrl := i.AgentCtrl().Controller(client.CRForeignCluster)
+	eventTester.Add(2)
+	assert.NoError(t, fcCtrl.Store.Add(test.CreateForeignCluster("pref1", "cluster-a")))
+	assert.NoError(t, fcCtrl.Store.Add(test.CreateForeignCluster("pref2", "cluster-b")))
+	eventTester.Wait()
+	peer, present := quick.ListChild("pref2")
+	if assert.True(t, present) {
+		favorite, _ := peer.ListChild(tagPeerFavorite)
+		assert.Contains(t, favorite.Title(), titlePeerFavoriteAdd)
+	}
+	//the favorite peers are displayed first, with their alias
+	conf.SetPeerAlias("pref2", "staging")
+	conf.SetPeerFavorite("pref2", true)
+	configurePeerPreferences(i)
+	regroupPeers(i)
+	children := quick.ListChildren()
+	if assert.NotEmpty(t, children) {
+		assert.Equal(t, "pref2", children[0].Tag(), "favorite peer not displayed first")
+		assert.Equal(t, labelPeerFavorite+" staging", children[0].Title())
+		favorite, _ := children[0].ListChild(tagPeerFavorite)
+		assert.Contains(t, favorite.Title(), titlePeerFavoriteRemove)
+	}
+	assert.Contains(t, i.Status().GoString(), labelPeerFavorite+" staging")
+	//the peers are searched by alias as well
+	searchPeers(i, "stag")
+	defer searchPeers(i, "")
+	peer, _ = quick.ListChild("pref2")
+	assert.True(t, peer.IsListed(), "peer not found by alias")
+	peer, _ = quick.ListChild("pref1")
+	assert.False(t, peer.IsListed())
+}
+
+func TestClipboard(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	defer i.Quit()
+	quick, present := i.Quick(qClipboard)
+	if !assert.True(t, present, "clipboard QUICK not registered") {
+		return
+	}
+	config := &clusterConfig.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: "clipboard-config"}}
+	config.Spec.DiscoveryConfig.AuthServiceAddress = "auth.example.com"
+	config.Spec.DiscoveryConfig.AuthServicePort = "443"
+	eventTester.Add(1)
+	assert.NoError(t, i.AgentCtrl().Controller(client.CRClusterConfig).Store.Add(config))
+	eventTester.Wait()
+	join, _ := quick.ListChild(tagClipboardJoin)
+	eventTester.Add(1)
+	join.Channel() <- struct{}{}
+	eventTester.Wait()
+	assert.Contains(t, app.MockedClipboard(), "authUrl: https://auth.example.com:443")
+	//the clipboard is not changed if the data is not available
+	clusterID, _ := quick.ListChild(tagClipboardClusterID)
+	eventTester.Add(1)
+	clusterID.Channel() <- struct{}{}
+	eventTester.Wait()
+	assert.Contains(t, app.MockedClipboard(), "kubectl apply")
+	//each peer copies the kubeconfig of its virtual node
+	peers, _ := i.Quick(qPeers)
+	eventTester.Add(1)
+	assert.NoError(t, i.AgentCtrl().Controller(client.CRForeignCluster).Store.Add(
+		test.CreateForeignCluster("clip1", "cluster-clip")))
+	eventTester.Wait()
+	if peer, present := peers.ListChild("clip1"); assert.True(t, present) {
+		_, present = peer.ListChild(tagPeerKubeconfig)
+		assert.True(t, present, "kubeconfig entry missing")
+	}
+}
+
+//test the time limit of the operations and the report of the stuck ones.
+func TestRunOperation(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	app.GetGuiProvider().NewEventTester()
+	OnReady()
+	i := app.GetIndicator()
+	operationTimeouts["test"] = 10 * time.Millisecond
+	grace := stuckGrace
+	stuckGrace = 10 * time.Millisecond
+	defer func() {
+		delete(operationTimeouts, "test")
+		stuckGrace = grace
+	}()
+	//the operations aware of the context are not reported as stuck
+	err := runOperation(context.Background(), i, "test", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	_, present := i.Pending().Item(pendingStuckPrefix + "test")
+	assert.False(t, present, "operation aware of the context reported as stuck")
+	//an operation ignoring the context is reported as stuck until it returns
+	release := make(chan struct{})
+	err = runOperation(context.Background(), i, "test", func(context.Context) error {
+		<-release
+		return nil
+	})
+	assert.True(t, errors.Is(err, client.ErrTimeout), "stuck operation not timed out")
+	_, present = i.Pending().Item(pendingStuckPrefix + "test")
+	assert.True(t, present, "stuck operation not reported")
+	assert.Contains(t, operationDiagnostics(i, operations.next), "Time limit: 10ms")
+	close(release)
+	assert.Eventually(t, func() bool {
+		_, present := i.Pending().Item(pendingStuckPrefix + "test")
+		return !present
+	}, time.Second, 5*time.Millisecond, "completed operation still reported as stuck")
+	i.Quit()
+}
+
+//test the reset of the selected parts of the Agent state.
+func TestResetAgent(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	app.GetGuiProvider().NewEventTester()
+	OnReady()
+	i := app.GetIndicator()
+	conf, _ := client.GetLocalConfig()
+	conf.SetPeerGroupLabel("region")
+	assert.NoError(t, client.GetMenuStateStore().Update(func(state *client.MenuState) {
+		state.LastPeer = "reset1"
+	}))
+	assert.NoError(t, history.GetStore().Add(history.Record{Timestamp: time.Now(), ClusterID: "reset1",
+		Direction: history.DirectionOutgoing, Connected: true}))
+	activity.GetFeed().Add("test", "entry", activity.OutcomeSuccess)
+	addPendingFailure(i, "reset", "Reset failed", "details")
+	i.Pending().Add(app.PendingItem{ID: pendingUpgrade, Kind: app.PendingRequest, Title: "Liqo v1 available"})
+	//only the selected targets are cleared
+	assert.NoError(t, resetAgent(i, []resetTarget{resetMenuState, resetActivity}))
+	assert.Equal(t, "", client.GetMenuStateStore().State().LastPeer, "menu state not cleared")
+	assert.NotEmpty(t, history.GetStore().ClusterIDs(), "peering history cleared")
+	entries := activity.GetFeed().Entries()
+	if assert.Len(t, entries, 1, "activity feed not cleared") {
+		assert.Equal(t, activitySourceReset, entries[0].Source)
+	}
+	_, present := i.Pending().Item(pendingFailurePrefix + "reset")
+	assert.False(t, present, "reported failure not cleared")
+	_, present = i.Pending().Item(pendingUpgrade)
+	assert.True(t, present, "pending request cleared")
+	assert.Equal(t, "region", conf.GetPeerGroupLabel(), "configuration reset")
+	assert.NoError(t, resetAgent(i, []resetTarget{resetHistory, resetConfig}))
+	assert.Empty(t, history.GetStore().ClusterIDs(), "peering history not cleared")
+	conf, _ = client.GetLocalConfig()
+	assert.Equal(t, "", conf.GetPeerGroupLabel(), "configuration not reset")
+	i.Pending().Remove(pendingUpgrade)
+	i.Quit()
+}
+
+//test the verification of the identity of the peers.
+func TestPeerIdentityVerification(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	fcCtrl := i.AgentCtrl().Controller(client.CRForeignCluster)
+	fc := test.CreateForeignCluster("idp1", "identity")
+	fc.Spec.AuthUrl = server.URL
+	eventTester.Add(1)
+	assert.NoError(t, fcCtrl.Store.Add(fc))
+	eventTester.Wait()
+	defer func() {
+		_ = client.GetTrustStore().Unpin("idp1")
+	}()
+	//a new identity is pinned before starting the peering
+	assert.True(t, verifyPeerIdentity(context.Background(), i, "idp1", "start the peering"))
+	pinned, present := client.GetTrustStore().Pinned("idp1")
+	if assert.True(t, present, "identity not pinned") {
+		assert.Equal(t, client.Fingerprint(server.Certificate().Raw), pinned.CAFingerprint)
+	}
+	assert.True(t, verifyPeerIdentity(context.Background(), i, "idp1", "start the peering"))
+	//a changed identity is reported and blocks the peering
+	pinned.CAFingerprint = "AA:BB"
+	assert.NoError(t, client.GetTrustStore().Pin(pinned))
+	assert.False(t, verifyPeerIdentity(context.Background(), i, "idp1", "start the peering"))
+	item, present := i.Pending().Item(pendingIdentityPrefix + "idp1")
+	if assert.True(t, present, "identity change not reported") {
+		assert.Equal(t, app.PendingProblem, item.Kind)
+	}
+	entries := activity.GetFeed().Entries()
+	if assert.NotEmpty(t, entries) {
+		assert.Equal(t, activitySourceIdentity, entries[0].Source)
+		assert.Equal(t, activity.OutcomeFailure, entries[0].Outcome)
+	}
+	//the identity of a new peer with an incoming peering is to be verified
+	assert.NoError(t, client.GetTrustStore().Unpin("idp1"))
+	i.Pending().Remove(pendingIdentityPrefix + "idp1")
+	checkIncomingPeerIdentity(i, "idp1")
+	item, present = i.Pending().Item(pendingIdentityPrefix + "idp1")
+	if assert.True(t, present, "identity verification not requested") {
+		assert.Equal(t, app.PendingRequest, item.Kind)
+	}
+	i.Pending().Remove(pendingIdentityPrefix + "idp1")
+	i.Quit()
+}
+
+//test the read-only mode disabling the write actions.
+func TestReadOnlyMode(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	//the QUICK is clicked repeatedly
+	i.SetClickGuard(0)
+	conf, _ := client.GetLocalConfig()
+	quick, present := i.Quick(qReadOnly)
+	if !assert.True(t, present, "read-only QUICK not registered") {
+		return
+	}
+	uninstall, present := i.Quick(qUninstall)
+	assert.True(t, present)
+	assert.Equal(t, titleReadOnly+": OFF", quick.Title())
+	assert.True(t, uninstall.IsEnabled())
+	assert.True(t, writeAllowed(i, "test"))
+	//the mode is toggled from the menu
+	eventTester.Add(1)
+	quick.Channel() <- struct{}{}
+	eventTester.Wait()
+	assert.True(t, conf.GetReadOnly(), "read-only mode not saved")
+	assert.True(t, i.ReadOnly())
+	assert.Equal(t, titleReadOnly+": ON", quick.Title())
+	assert.False(t, uninstall.IsEnabled(), "write action enabled in read-only mode")
+	assert.False(t, writeAllowed(i, "test"))
+	assert.Equal(t, activitySourceReadOnly, activity.GetFeed().Entries()[0].Source)
+	eventTester.Add(1)
+	quick.Channel() <- struct{}{}
+	eventTester.Wait()
+	assert.False(t, i.ReadOnly())
+	assert.True(t, uninstall.IsEnabled())
+	//the mode enforced by the organization cannot be disabled
+	conf.SetOrgDefaults(&client.LocalConfig{ReadOnly: true})
+	defer conf.SetOrgDefaults(nil)
+	configureReadOnly(i)
+	assert.True(t, i.ReadOnly())
+	assert.False(t, quick.IsEnabled(), "enforced read-only mode can be toggled")
+	assert.Contains(t, quick.Title(), "enforced")
+	quickToggleReadOnly(i)
+	assert.True(t, i.ReadOnly(), "enforced read-only mode disabled")
+	conf.SetOrgDefaults(nil)
+	configureReadOnly(i)
+	assert.False(t, i.ReadOnly())
+	i.Quit()
+}
+
+//test the runtime control of the debug logging.
+func TestDebugLogging(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	i.SetClickGuard(0)
+	defer logging.SetDebug(false)
+	quick, present := i.Quick(qLogs)
+	if !assert.True(t, present, "logs QUICK not registered") {
+		return
+	}
+	option, present := quick.Option(oDebugLogging)
+	if !assert.True(t, present, "debug logging OPTION not registered") {
+		return
+	}
+	assert.False(t, option.IsChecked())
+	assert.False(t, logging.DebugEnabled())
+	eventTester.Add(1)
+	option.Channel() <- struct{}{}
+	eventTester.Wait()
+	conf, _ := client.GetLocalConfig()
+	assert.True(t, conf.GetDebugLogging(), "debug logging not saved")
+	assert.True(t, logging.DebugEnabled(), "debug logging not enabled")
+	assert.True(t, option.IsChecked())
+	assert.Equal(t, activitySourceLogs, activity.GetFeed().Entries()[0].Source)
+	eventTester.Add(1)
+	option.Channel() <- struct{}{}
+	eventTester.Wait()
+	assert.False(t, logging.DebugEnabled(), "debug logging not disabled")
+	assert.False(t, option.IsChecked())
+	i.Quit()
+}
+
+//test the export of the support bundle.
+func TestSupportBundle(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	dir, err := ioutil.TempDir("", "liqo-bundle")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	_ = os.Setenv("XDG_STATE_HOME", dir)
+	defer os.Unsetenv("XDG_STATE_HOME")
+	assert.NoError(t, os.MkdirAll(logging.Dir(), 0700))
+	assert.NoError(t, ioutil.WriteFile(logging.Path(), []byte("request with Bearer abc\n"), 0600))
+	assert.NoError(t, ioutil.WriteFile(logging.Path()+".1", []byte("older entry\n"), 0600))
+	OnReady()
+	i := app.GetIndicator()
+	defer i.Quit()
+	path, err := exportSupportBundle(i, dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+	r, err := zip.OpenReader(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer r.Close()
+	content := map[string]string{}
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+		rc, err := f.Open()
+		if !assert.NoError(t, err) {
+			return
+		}
+		data, _ := ioutil.ReadAll(rc)
+		_ = rc.Close()
+		content[f.Name] = string(data)
+	}
+	assert.Equal(t, []string{"status.txt", client.ConfigFileName, "logs/agent.log", "logs/agent.log.1"}, names)
+	assert.Equal(t, "request with Bearer [REDACTED]\n", content["logs/agent.log"], "logs not redacted")
+	assert.Contains(t, content["status.txt"], "Cluster: ")
+	assert.Equal(t, "older entry\n", content["logs/agent.log.1"])
+}
+
+//test the settings changed from the settings page.
+func TestSettingsPage(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	defer i.Quit()
+	defer stopSettingsPage()
+	defer logging.SetDebug(false)
+	quick, present := i.Quick(qSettingsPage)
+	if !assert.True(t, present, "settings page QUICK not registered") {
+		return
+	}
+	eventTester.Add(1)
+	quick.Channel() <- struct{}{}
+	eventTester.Wait()
+	pageURL, err := url.Parse(settings.GetServer().URL())
+	if !assert.NoError(t, err, "settings page not served") {
+		return
+	}
+	put := func(values settings.Values) *http.Response {
+		data, _ := json.Marshal(values)
+		req, _ := http.NewRequest(http.MethodPut, "http://"+pageURL.Host+settings.SettingsPath, bytes.NewReader(data))
+		req.Header.Set(settings.TokenHeader, pageURL.Query().Get(settings.TokenParam))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp
+	}
+	values := settingsForm().Values
+	values.IconTheme = string(app.IconThemeAccessible)
+	values.ReadOnly = true
+	values.DebugLogging = true
+	assert.Equal(t, http.StatusOK, put(values).StatusCode)
+	conf, _ := client.GetLocalConfig()
+	defer func() {
+		conf.SetOrgDefaults(nil)
+		conf.SetReadOnly(false)
+		conf.SetDebugLogging(false)
+		conf.SetIconTheme("")
+		conf.SetLanguage("")
+		_ = client.SaveLocalConfig()
+	}()
+	assert.Equal(t, string(app.IconThemeAccessible), conf.GetIconTheme())
+	assert.True(t, i.ReadOnly(), "read-only mode not applied")
+	assert.True(t, logging.DebugEnabled(), "debug logging not applied")
+	assert.Equal(t, activitySourceSettings, activity.GetFeed().Entries()[0].Source)
+	//invalid settings are rejected
+	values.LabelMode = "unknown"
+	values.ReadOnly = false
+	assert.Equal(t, http.StatusUnprocessableEntity, put(values).StatusCode)
+	assert.True(t, conf.GetReadOnly(), "invalid settings applied")
+	//the settings that cannot be saved are reported as failed
+	file, err := ioutil.TempFile("", "liqo-agent-settings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = file.Close()
+	defer func() { _ = os.Remove(file.Name()) }()
+	env, present := os.LookupEnv(client.EnvLiqoPath)
+	restoreEnv := func() {
+		if present {
+			_ = os.Setenv(client.EnvLiqoPath, env)
+		} else {
+			_ = os.Unsetenv(client.EnvLiqoPath)
+		}
+	}
+	defer restoreEnv()
+	//the config dir cannot be created where a file already exists
+	assert.NoError(t, os.Setenv(client.EnvLiqoPath, file.Name()))
+	values.LabelMode = string(app.LabelModeCounts)
+	values.ReadOnly = true
+	assert.Equal(t, http.StatusUnprocessableEntity, put(values).StatusCode)
+	assert.Equal(t, activity.OutcomeFailure, activity.GetFeed().Entries()[0].Outcome)
+	restoreEnv()
+	//the read-only mode enforced by the organization cannot be disabled
+	conf.SetOrgDefaults(&client.LocalConfig{ReadOnly: true})
+	assert.Equal(t, []string{"readOnly"}, settingsForm().Locked)
+	values.ReadOnly = false
+	assert.Equal(t, http.StatusUnprocessableEntity, put(values).StatusCode)
+}
+
+//test the cooldowns of the actions that must not be repeated.
+func TestActionCooldowns(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	app.GetGuiProvider().NewEventTester()
+	OnReady()
+	i := app.GetIndicator()
+	quick, present := i.Quick(qCredentials)
+	if !assert.True(t, present) {
+		return
+	}
+	credentials := []*client.CredentialInfo{
+		{User: "u1", Kind: client.CredentialClientCertificate, Expiry: time.Now().Add(time.Hour)},
+		{User: "u2", Kind: client.CredentialClientCertificate, Expiry: time.Now().Add(time.Hour)},
+	}
+	refreshCredentialsQuick(i, quick, credentials)
+	refresh, present := quick.ListChild(tagRefreshCredentials)
+	if !assert.True(t, present) {
+		return
+	}
+	assert.Equal(t, refreshCredentialsCooldown, refresh.Cooldown())
+	assert.True(t, refresh.IsWriteAction())
+	//the refresh entry is kept across the updates, so that its cooldown applies
+	refreshCredentialsQuick(i, quick, credentials[:1])
+	kept, _ := quick.ListChild(tagRefreshCredentials)
+	assert.Same(t, refresh, kept, "refresh entry replaced")
+	assert.Equal(t, 2, quick.ListChildrenLen())
+	refreshCredentialsQuick(i, quick, nil)
+	assert.Zero(t, quick.ListChildrenLen())
+	//the command of the peerings cannot be repeated
+	peerList, _ := i.Quick(qPeers)
+	peerNode := createPeerNode(peerList, &client.NotifyDataForeignCluster{ClusterID: "cooldown1"}, &app.PeerInfo{})
+	outgoing, _ := peerNode.ListChild(tagPeeringOutgoing)
+	cmd, _ := outgoing.ListChild(tagPeeringCmd)
+	assert.Equal(t, peeringCmdCooldown, cmd.Cooldown())
+	peerList.FreeListChild("cooldown1")
+	i.Quit()
+}
+
+func TestBackgroundTasksQuick(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	i.SetClickGuard(0)
+	quick, present := i.Quick(qBackground)
+	if !assert.True(t, present) {
+		return
+	}
+	refreshBackgroundTasks(i, quick)
+	//the timers and the listeners are followed by the refreshes entry
+	assert.Equal(t, len(i.Timers())+len(i.Listeners())+1, quick.ListChildrenLen())
+	if entry, present := quick.ListChild(tagRefresh); assert.True(t, present, "refreshes entry not listed") {
+		assert.Contains(t, entry.Title(), "merged")
+	}
+	//clicking an entry pauses and resumes the task
+	timer, _ := i.Timer(tBackground)
+	entry, present := quick.ListChild(tagTimerPrefix + tBackground)
+	if !assert.True(t, present) {
+		return
+	}
+	click := func() {
+		eventTester.Add(1)
+		entry.Channel() <- struct{}{}
+		eventTester.Wait()
+	}
+	click()
+	assert.False(t, timer.Active())
+	assert.Contains(t, entry.Title(), "paused")
+	click()
+	assert.True(t, timer.Active())
+	listener, _ := i.Listener(client.TopicPeerChanged)
+	entry, present = quick.ListChild(tagListenerPrefix + client.TopicPeerChanged.String())
+	if !assert.True(t, present) {
+		return
+	}
+	click()
+	assert.True(t, listener.Paused())
+	click()
+	assert.False(t, listener.Paused())
+	i.Quit()
+}
+
+//test the sections of the additional clusters.
+func TestClustersQuick(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	i.SetClickGuard(0)
+	quick, present := i.Quick(qClusters)
+	if !assert.True(t, present) {
+		return
+	}
+	assert.False(t, quick.IsVisible(), "Clusters QUICK visible without additional clusters")
+	conf := client.ClusterConfig{Name: "staging", Context: "staging-ctx"}
+	useClusterView(conf)
+	connectCluster(i, conf)
+	assert.True(t, quick.IsVisible())
+	assert.Equal(t, "Clusters (1)", quick.Title())
+	entry, present := quick.ListChild("staging")
+	if !assert.True(t, present, "cluster entry missing") {
+		return
+	}
+	assert.Equal(t, "○ staging: 0 peers", entry.Title())
+	reconnect, _ := entry.ListChild(tagClusterReconnect)
+	assert.False(t, reconnect.IsVisible(), "reconnect entry visible while connected")
+	_, listening := i.ClusterListener("staging", client.TopicPeerChanged)
+	assert.True(t, listening, "peers of the cluster not listened")
+	//the peers of the cluster are displayed in its section only
+	cluster, _ := i.AgentCtrl().Cluster("staging")
+	fc := test.CreateForeignCluster("staging-peer", "remote")
+	eventTester.Add(1)
+	assert.NoError(t, cluster.Controller(client.CRForeignCluster).Store.Add(fc))
+	eventTester.Wait()
+	peerNode, present := entry.ListChild("staging-peer")
+	if assert.True(t, present, "peer entry missing") {
+		assert.Equal(t, "remote", peerNode.Title())
+		cmd, _ := peerNode.ListChild(tagPeeringCmd)
+		assert.Equal(t, titlePeeringCmdStart, cmd.Title())
+	}
+	assert.Equal(t, "○ staging: 1 peer", entry.Title())
+	peers, _ := i.Quick(qPeers)
+	_, present = peers.ListChild("staging-peer")
+	assert.False(t, present, "peer of an additional cluster listed among the main ones")
+	//the peerings of the cluster are notified naming it
+	stagingPeer := &client.NotifyDataForeignCluster{Name: "staging-peer", ClusterID: "staging-peer",
+		ClusterName: "remote"}
+	stagingPeer.InPeering.Connected = true
+	notifyClusterPeerings(i, "staging", nil, stagingPeer)
+	n, present := i.Notification("cluster/staging/peering/incoming/staging-peer")
+	if assert.True(t, present, "peering of the cluster not notified") {
+		assert.Equal(t, "Liqo Agent [staging]: PEERING ACCEPTED", n.Title)
+		assert.Equal(t, "staging is now sharing resources to remote", n.Message)
+	}
+	eventTester.Add(1)
+	assert.NoError(t, cluster.Controller(client.CRForeignCluster).Store.Delete(fc))
+	eventTester.Wait()
+	_, present = entry.ListChild("staging-peer")
+	assert.False(t, present, "entry of a removed peer not freed")
+	//the clusters are disconnected when the Agent exits
+	disconnectClusters(i)
+	assert.Empty(t, i.AgentCtrl().Clusters())
+	i.Quit()
+}
+
+func TestClusterEntryTitle(t *testing.T) {
+	v := &clusterView{conf: client.ClusterConfig{Context: "edge"}, peers: map[string]*client.NotifyDataForeignCluster{}}
+	assert.Equal(t, "⊘ edge: connecting…", clusterEntryTitle(v))
+	v.attempted, v.err = true, errors.New("connection refused")
+	assert.Equal(t, "⊘ edge: unreachable", clusterEntryTitle(v))
+	assert.Equal(t, "✖ connection refused", clusterStatusText(v))
+	v.connected = true
+	peer := &client.NotifyDataForeignCluster{ClusterID: "p1"}
+	peer.OutPeering.Connected = true
+	v.peers["p1"] = peer
+	assert.Equal(t, app.IconLiqoPurple, v.icon())
+	assert.Equal(t, "● edge: 1 peer, ↓0 ↑1", clusterEntryTitle(v))
+	assert.Equal(t, "UNKNOWN p1", clusterPeerName(peer))
+}
+
+//test the bundle of the remote diagnostics of a peer.
+func TestPeerDiagnostics(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	eventTester.Add(1)
+	assert.NoError(t, i.AgentCtrl().Controller(client.CRForeignCluster).Store.Add(test.CreateForeignCluster("dp1",
+		"diagnostics")))
+	eventTester.Wait()
+	quickNode, _ := i.Quick(qPeers)
+	peerNode, present := quickNode.ListChild("dp1")
+	if assert.True(t, present, "peer entry missing") {
+		_, present = peerNode.ListChild(tagPeerDiagnostics)
+		assert.True(t, present, "diagnostics entry missing")
+	}
+	dir, err := ioutil.TempDir("", "liqo-diagnostics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path, err := collectPeerDiagnostics(context.Background(), i, "dp1", dir)
+	assert.NoError(t, err)
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	header, err := tr.Next()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "summary.txt", header.Name)
+	}
+	//the content of the bundle is redacted
+	d := &client.PeerDiagnostics{ClusterID: "dp1", Files: []client.DiagnosticsFile{
+		{Name: "logs/peer-lines.log", Data: []byte("Authorization: Bearer abc.def-123")},
+	}}
+	path = filepath.Join(dir, "redacted.tar.gz")
+	assert.NoError(t, writeDiagnosticsBundle(d, path, time.Now()))
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	gz, err = gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr = tar.NewReader(gz)
+	_, _ = tr.Next()
+	if _, err = tr.Next(); assert.NoError(t, err) {
+		content, _ := ioutil.ReadAll(tr)
+		assert.NotContains(t, string(content), "abc.def-123", "bundle content not redacted")
+	}
+	assert.Error(t, writeDiagnosticsBundle(d, path, time.Now()), "existing bundle overwritten")
+	i.Quit()
+}
+
+//test the ACTION listing the kubeconfig contexts.
+func TestContextsAction(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	OnReady()
+	i := app.GetIndicator()
+	action, present := i.Action(aContexts)
+	if !present {
+		t.Fatal("Kubeconfig context ACTION not registered")
+	}
+	//the mocked kubeconfig file cannot be read
+	assert.False(t, action.IsEnabled(), "contexts listed without a kubeconfig file")
+	assert.Equal(t, 0, action.ListChildrenLen())
+	i.Quit()
+}
+
+//test the ACTION approving the incoming peering requests.
+func TestPeeringRequestsAction(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	action, present := i.Action(aPeeringRequests)
+	if !present {
+		t.Fatal("peering requests ACTION not registered")
+	}
+	assert.False(t, action.IsVisible(), "peering requests ACTION visible without requests")
+	fcCtrl := i.AgentCtrl().Controller(client.CRForeignCluster)
+	for n, id := range []string{"req-1", "req-2"} {
+		fc := test.CreateForeignCluster(id, "cluster-"+id)
+		fc.CreationTimestamp = metav1.NewTime(time.Now().Add(time.Duration(n) * time.Minute))
+		fc.Status.Incoming.PeeringRequest = &corev1.ObjectReference{Name: "pr-" + id}
+		eventTester.Add(1)
+		assert.NoError(t, fcCtrl.Store.Add(fc))
+		eventTester.Wait()
+	}
+	assert.True(t, action.IsVisible(), "peering requests ACTION not visible")
+	assert.Equal(t, "Peering request from cluster-req-1 (+1 more)", action.Title())
+	_, present = action.Option(oAcceptPeeringRequest)
+	assert.True(t, present, "Accept OPTION not registered")
+	_, present = action.Option(oRejectPeeringRequest)
+	assert.True(t, present, "Reject OPTION not registered")
+	_, present = i.Pending().Item(pendingPeeringRequestPrefix + "req-2")
+	assert.True(t, present, "peering request not listed among the pending items")
+	n, present := i.Notification(pendingPeeringRequestPrefix + "req-2")
+	if assert.True(t, present, "peering request not notified") {
+		assert.Len(t, n.Actions, 3, "peering request notified without Accept, Reject and Dismiss actions")
+	}
+	//the oldest request is decided first
+	eventTester.Add(1)
+	decideOldestPeeringRequest(context.Background(), i, false)
+	eventTester.Wait()
+	fc, _ := i.AgentCtrl().ForeignClusters().Get("req-1")
+	assert.Equal(t, client.PeeringApprovalRejected, fc.Annotations[client.PeeringApprovalAnnotation])
+	assert.Equal(t, "Peering request from cluster-req-2", action.Title())
+	_, present = i.Pending().Item(pendingPeeringRequestPrefix + "req-1")
+	assert.False(t, present, "decided peering request still pending")
+	_, present = i.Notification(pendingPeeringRequestPrefix + "req-1")
+	assert.False(t, present, "notification of the decided peering request still active")
+	eventTester.Add(1)
+	decideOldestPeeringRequest(context.Background(), i, true)
+	eventTester.Wait()
+	fc, _ = i.AgentCtrl().ForeignClusters().Get("req-2")
+	assert.Equal(t, client.PeeringApprovalAccepted, fc.Annotations[client.PeeringApprovalAnnotation])
+	assert.False(t, action.IsVisible(), "peering requests ACTION visible without pending requests")
+	assert.Equal(t, 0, i.Pending().Len())
+	i.Quit()
+}
+
+//test the status reconstructed from the peering history.
+func TestStatusDetailsAt(t *testing.T) {
+	store, _ := history.NewStore("", 0, 0)
+	//the Records older than the retention of the Store are discarded
+	now := time.Now()
+	assert.Contains(t, statusDetailsAt(store, now.Add(-time.Hour), now), "No peering history recorded.")
+	assert.NoError(t, store.Add(history.Record{Timestamp: now.Add(-3 * time.Hour), ClusterID: "cl1",
+		ClusterName: "cluster-1", Direction: history.DirectionOutgoing, Connected: true, CpuQuota: "2",
+		MemQuota: "4Gi"}))
+	assert.NoError(t, store.Add(history.Record{Timestamp: now.Add(-2 * time.Hour), ClusterID: "cl2",
+		ClusterName: "cluster-2", Direction: history.DirectionOutgoing, Connected: true, CpuQuota: "500m",
+		MemQuota: "1Gi"}))
+	assert.NoError(t, store.Add(history.Record{Timestamp: now.Add(-2 * time.Hour), ClusterID: "cl2",
+		ClusterName: "cluster-2", Direction: history.DirectionIncoming, Connected: true}))
+	assert.NoError(t, store.Add(history.Record{Timestamp: now.Add(-30 * time.Minute), ClusterID: "cl1",
+		ClusterName: "cluster-1", Direction: history.DirectionOutgoing}))
+	details := statusDetailsAt(store, now.Add(-time.Hour), now)
+	assert.Contains(t, details, "Status as of "+now.Add(-time.Hour).Format("Mon 02 Jan 15:04")+" (1h ago)")
+	assert.Contains(t, details, "Peerings: 2 outgoing, 1 incoming")
+	assert.Contains(t, details, "Acquired resources: 2.5 CPU, 5.0Gi")
+	assert.Contains(t, details, "• cluster-1: OUT (2.0 CPU, 4.0Gi)")
+	assert.Contains(t, details, "• cluster-2: OUT (0.5 CPU, 1.0Gi), IN")
+	details = statusDetailsAt(store, now, now)
+	assert.Contains(t, details, "Peerings: 1 outgoing, 1 incoming")
+	assert.NotContains(t, details, "cluster-1")
+	details = statusDetailsAt(store, now.Add(-4*time.Hour), now)
+	assert.Contains(t, details, "The peering history starts on "+
+		now.Add(-3*time.Hour).Format("Mon 02 Jan 15:04"))
+	assert.Contains(t, details, "Peerings: 0 outgoing, 0 incoming")
+	assert.Contains(t, statusDetailsAt(store, now.Add(time.Hour), now), "The time is in the future.")
+}
+
+//test the change of the language of the menu and of the notifications.
+func TestLanguage(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	OnReady()
+	i := app.GetIndicator()
+	_, present := i.Quick(qLanguage)
+	assert.True(t, present, "Language QUICK not registered")
+	conf, _ := client.GetLocalConfig()
+	defer conf.SetLanguage("")
+	defer i18n.SetLanguage(i18n.Language())
+	setLanguage(i, "it")
+	assert.Equal(t, "it", i18n.Language())
+	assert.Equal(t, "it", conf.GetLanguage(), "language not stored in the configuration")
+	//an unsupported language is not applied
+	setLanguage(i, "xx")
+	assert.Equal(t, "it", i18n.Language())
+	assert.NoError(t, i18n.SetLanguage(i18n.DefaultLanguage))
+	//the configured language is applied again
+	configureLanguage(i)
+	assert.Equal(t, "it", i18n.Language())
+	i.Quit()
+}
+
+//test the ACTION starting an outgoing peering with a typed peer.
+func TestPeeringWizard(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	defer app.SetMockedInput("", false)
+	action, present := i.Action(aPeeringWizard)
+	if !present {
+		t.Fatal("peering wizard ACTION not registered")
+	}
+	assert.True(t, action.IsWriteAction(), "peering wizard ACTION not marked as write action")
+	//a cancelled dialog box starts nothing
+	app.SetMockedInput("https://10.0.0.1:30443", false)
+	peeringWizard(context.Background(), i)
+	assert.Empty(t, i.AgentCtrl().ForeignClusters().List())
+	//the ForeignCluster of a peer without a known identity triggers no listener
+	app.SetMockedInput(" https://10.0.0.1:30443 ", true)
+	peeringWizard(context.Background(), i)
+	fc, exists := i.AgentCtrl().ForeignClusters().Get("manual-10-0-0-1-30443")
+	if assert.True(t, exists, "ForeignCluster of the typed peer not created") {
+		assert.True(t, fc.Spec.Join)
+		assert.Equal(t, "https://10.0.0.1:30443", fc.Spec.AuthUrl)
+	}
+	i.Quit()
+}
+
+func TestOnboardingChecklistAction(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	action, present := i.Action(aOnboarding)
+	if !present {
+		t.Fatal("onboarding ACTION not registered")
+	}
+	assert.False(t, action.IsVisible(), "onboarding ACTION visible without a started peering")
+	fcCtrl := i.AgentCtrl().Controller(client.CRForeignCluster)
+	fc := test.CreateForeignCluster("onboard", "remote")
+	fc.Status.AuthStatus = discovery.AuthStatusAccepted
+	eventTester.Add(1)
+	assert.NoError(t, fcCtrl.Store.Add(fc))
+	eventTester.Wait()
+	startOnboarding(i, "onboard", "remote")
+	assert.True(t, action.IsVisible(), "onboarding ACTION not displayed for a started peering")
+	assert.Equal(t, "Onboarding remote: 1/5 steps", action.Title())
+	assert.Equal(t, 5, action.ListChildrenLen(), "wrong number of onboarding steps")
+	if step, present := action.ListChild(tagOnboardingStepPrefix + client.StepAuthentication); assert.True(t,
+		present) {
+		assert.Equal(t, "✔ Authentication: identity accepted by the peer", step.Title())
+	}
+	if timer, present := i.Timer(tOnboarding); assert.True(t, present) {
+		assert.True(t, timer.Active(), "onboarding checklist not refreshed")
+	}
+	//a failed step is notified once with its remediation hint
+	fc = fc.DeepCopy()
+	fc.Status.AuthStatus = discovery.AuthStatusRefused
+	eventTester.Add(1)
+	assert.NoError(t, fcCtrl.Store.Update(fc))
+	eventTester.Wait()
+	refreshOnboarding(i)
+	assert.Equal(t, "Onboarding remote: 0/5 steps, failing", action.Title())
+	if n, present := i.Notification(notificationOnboardingPrefix + "onboard"); assert.True(t, present,
+		"failed onboarding step not notified") {
+		assert.Contains(t, n.Message, "auth token")
+	}
+	i.DismissNotification(notificationOnboardingPrefix + "onboard")
+	refreshOnboarding(i)
+	_, present = i.Notification(notificationOnboardingPrefix + "onboard")
+	assert.False(t, present, "failed onboarding step notified twice")
+	//the checklist can be dismissed
+	if option, present := action.Option(oDismissOnboarding); assert.True(t, present) {
+		eventTester.Add(1)
+		option.Channel() <- struct{}{}
+		eventTester.Wait()
+		assert.False(t, action.IsVisible(), "onboarding checklist not dismissed")
+	}
+	if timer, present := i.Timer(tOnboarding); assert.True(t, present) {
+		assert.False(t, timer.Active(), "dismissed onboarding checklist still refreshed")
+	}
+	i.Quit()
+}
+
+func TestStartupActions(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	defer i.SetQuietUntil(time.Time{})
+	now := time.Date(2021, time.March, 1, 8, 0, 0, 0, time.Local)
+	actions := []client.StartupActionConfig{
+		{Type: client.StartupQuietUntil, Until: "09:00"},
+		{Type: client.StartupPeer, Peers: []string{"https://10.0.0.2:30443"}},
+		{Type: client.StartupEnableOffloading, Namespaces: []string{"missing"}},
+		{Type: "unknown"},
+	}
+	results := performStartupActions(context.Background(), i, actions, now)
+	if assert.Len(t, results, 4) {
+		assert.NoError(t, results[0].err)
+		assert.Equal(t, "notifications silenced until 09:00", results[0].detail)
+		assert.NoError(t, results[1].err)
+		assert.Error(t, results[2].err, "offloading enabled for a missing namespace")
+		assert.Error(t, results[3].err, "unknown startup action performed")
+	}
+	assert.Equal(t, now.Add(time.Hour), i.QuietUntil(), "quiet period not set")
+	if quick, present := i.Quick(qQuietHours); assert.True(t, present) {
+		assert.True(t, quick.IsVisible(), "quiet period not displayed")
+		assert.Equal(t, "Quiet hours: ON until 09:00", quick.Title())
+	}
+	_, exists := i.AgentCtrl().ForeignClusters().Get("manual-10-0-0-2-30443")
+	assert.True(t, exists, "peering of the startup actions not started")
+	assert.Equal(t, activitySourceStartupActions, activity.GetFeed().Entries()[0].Source)
+	notifyStartupActions(i, results)
+	if n, present := i.Notification(notificationStartupActions); assert.True(t, present,
+		"failed startup actions not notified") {
+		assert.Contains(t, n.Message, "2 of 4 startup actions failed")
+		assert.Contains(t, n.Message, "enableOffloading missing")
+	}
+	//a quiet period already ended is not set, and the actions on the cluster follow the read-only mode
+	i.SetQuietUntil(time.Time{})
+	i.SetReadOnly(true)
+	defer i.SetReadOnly(false)
+	results = performStartupActions(context.Background(), i, actions[:2], now.Add(2*time.Hour))
+	assert.NoError(t, results[0].err)
+	assert.True(t, i.QuietUntil().IsZero(), "ended quiet period set")
+	assert.Error(t, results[1].err, "peering started in read-only mode")
+	notifyStartupActions(i, results[:1])
+	_, present := i.Notification(notificationStartupActions)
+	assert.False(t, present, "successful startup actions notified as failed")
+	i.Quit()
+}
+
+func TestDoNotDisturb(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	i.SetClickGuard(0)
+	conf, _ := client.GetLocalConfig()
+	quick, present := i.Quick(qDoNotDisturb)
+	if !assert.True(t, present, "Do Not Disturb QUICK not registered") {
+		return
+	}
+	assert.Equal(t, titleDoNotDisturb+": OFF", quick.Title())
+	assert.False(t, i.DoNotDisturb())
+	assert.Equal(t, app.SeverityError, i.QuietThreshold())
+	//the mode is toggled from the menu
+	eventTester.Add(1)
+	quick.Channel() <- struct{}{}
+	eventTester.Wait()
+	assert.True(t, conf.GetDoNotDisturb().Enabled, "Do Not Disturb mode not saved")
+	assert.True(t, i.DoNotDisturb())
+	assert.Equal(t, titleDoNotDisturb+": ON", quick.Title())
+	assert.Equal(t, activitySourceDoNotDisturb, activity.GetFeed().Entries()[0].Source)
+	eventTester.Add(1)
+	quick.Channel() <- struct{}{}
+	eventTester.Wait()
+	assert.False(t, i.DoNotDisturb())
+	//the severity threshold is configurable, and an invalid one keeps the default
+	conf.SetOrgDefaults(&client.LocalConfig{DoNotDisturb: &client.DoNotDisturbConfig{Threshold: "warn"}})
+	defer conf.SetOrgDefaults(nil)
+	configureDoNotDisturb(i)
+	assert.Equal(t, app.SeverityWarning, i.QuietThreshold())
+	conf.SetOrgDefaults(&client.LocalConfig{DoNotDisturb: &client.DoNotDisturbConfig{Threshold: "critical"}})
+	configureDoNotDisturb(i)
+	assert.Equal(t, app.SeverityError, i.QuietThreshold())
+	conf.SetOrgDefaults(nil)
+	i.Quit()
+}
+
+func TestMetrics(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	registerMetrics(i)
+	eventTester.Add(1)
+	assert.NoError(t, i.AgentCtrl().Controller(client.CRForeignCluster).Store.Add(
+		test.CreateForeignCluster("metrics", "remote")))
+	eventTester.Wait()
+	buf := &bytes.Buffer{}
+	assert.NoError(t, metrics.GetRegistry().WriteText(buf))
+	text := buf.String()
+	for _, sample := range []string{
+		"liqo_agent_connected 1",
+		"liqo_agent_peers 1",
+		`liqo_agent_peerings{direction="outgoing"} 0`,
+		`liqo_agent_pending_events{listener="peerChanged"} 0`,
+		"liqo_agent_running_operations 0",
+		"liqo_agent_indicator_creation_seconds_count ",
+		`liqo_agent_event_handling_seconds_count{listener="peerChanged"} `,
+	} {
+		assert.Contains(t, text, sample)
+	}
+	i.Quit()
+}
+
+func TestTracing(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	exports := make(chan string, 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		exports <- r.URL.Path + " " + string(body)
+	}))
+	defer server.Close()
+	conf, _ := client.GetLocalConfig()
+	conf.SetOrgDefaults(&client.LocalConfig{Tracing: &client.TracingConfig{Endpoint: server.URL + "/",
+		Interval: 10 * time.Millisecond}})
+	defer conf.SetOrgDefaults(nil)
+	startTracing()
+	//the watch event and its handling by the Indicator are traced
+	eventTester.Add(1)
+	assert.NoError(t, i.AgentCtrl().Controller(client.CRForeignCluster).Store.Add(
+		test.CreateForeignCluster("traced", "remote")))
+	eventTester.Wait()
+	//the watch span can end after the handling of its event: the exports are collected until both are received
+	var spans []string
+	exported := ""
+	deadline := time.Now().Add(5 * time.Second)
+	for !(strings.Contains(exported, "watch foreignclusters") && strings.Contains(exported, "handle peerChanged")) &&
+		time.Now().Before(deadline) {
+		select {
+		case span := <-exports:
+			spans = append(spans, span)
+			exported = strings.Join(spans, "\n")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	stopTracing()
+	assert.Contains(t, exported, "/v1/traces ")
+	assert.Contains(t, exported, `"name":"watch foreignclusters"`)
+	assert.Contains(t, exported, `"name":"handle peerChanged"`)
+	i.Quit()
+	//the collector can be set by the OTLP env variables
+	assert.Equal(t, "", tracesEndpoint(client.TracingConfig{}))
+	assert.NoError(t, os.Setenv(envOtlpEndpoint, "http://collector:4318"))
+	defer os.Unsetenv(envOtlpEndpoint)
+	assert.Equal(t, "http://collector:4318/v1/traces", tracesEndpoint(client.TracingConfig{}))
+	assert.Equal(t, "http://local:4318/v1/traces",
+		tracesEndpoint(client.TracingConfig{Endpoint: "http://local:4318/v1/traces"}))
+}
+
+func TestRemoteWrite(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	pushes := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		pushes <- user + ":" + password
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "liqo-remote-write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	passwordFile := filepath.Join(dir, "password")
+	assert.NoError(t, ioutil.WriteFile(passwordFile, []byte("secret\n"), 0600))
+	conf, _ := client.GetLocalConfig()
+	conf.SetOrgDefaults(&client.LocalConfig{RemoteWrite: &client.RemoteWriteConfig{URL: server.URL,
+		Username: "agent", PasswordFile: passwordFile, Interval: 10 * time.Millisecond}})
+	defer conf.SetOrgDefaults(nil)
+	startRemoteWrite(i)
+	select {
+	case credentials := <-pushes:
+		assert.Equal(t, "agent:secret", credentials)
+	case <-time.After(5 * time.Second):
+		t.Error("metrics not pushed")
+	}
+	stopRemoteWrite()
+	//an unreadable password file prevents the push
+	conf.SetOrgDefaults(&client.LocalConfig{RemoteWrite: &client.RemoteWriteConfig{URL: server.URL,
+		Username: "agent", PasswordFile: filepath.Join(dir, "missing")}})
+	startRemoteWrite(i)
+	remoteWrite.Lock()
+	assert.Nil(t, remoteWrite.cancel, "metrics pushed without the password")
+	remoteWrite.Unlock()
+	i.Quit()
+}
+
+func TestHotkeys(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	assert.Empty(t, i.Hotkeys(), "global shortcuts bound without configuration")
+	conf, _ := client.GetLocalConfig()
+	conf.SetOrgDefaults(&client.LocalConfig{Hotkeys: &client.HotkeysConfig{OpenMenu: "CTRL+ALT+M",
+		Toggle: "CTRL+ALT+L"}})
+	defer conf.SetOrgDefaults(nil)
+	configureHotkeys(i)
+	bound := make(map[string]string)
+	for _, h := range i.Hotkeys() {
+		bound[h.ID] = h.Trigger
+	}
+	assert.Equal(t, map[string]string{hotkeyOpenMenu: "CTRL+ALT+M", hotkeyToggle: "CTRL+ALT+L"}, bound)
+	//the shortcut turns Liqo on and off
+	running := i.Status().Running()
+	assert.True(t, i.ActivateHotkey(hotkeyToggle))
+	assert.NotEqual(t, running, i.Status().Running(), "Liqo not toggled by the shortcut")
+	assert.True(t, i.ActivateHotkey(hotkeyToggle))
+	assert.Equal(t, running, i.Status().Running())
+	assert.True(t, i.ActivateHotkey(hotkeyOpenMenu))
+	assert.False(t, i.ActivateHotkey(hotkeyRefresh), "unbound shortcut activated")
+	conf.SetOrgDefaults(nil)
+	configureHotkeys(i)
+	assert.Empty(t, i.Hotkeys())
+	i.Quit()
+}
+
+func TestPeerDetails(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	quick, _ := i.Quick(qPeers)
+	fc := test.CreateForeignCluster("details1", "remote")
+	eventTester.Add(1)
+	assert.NoError(t, i.AgentCtrl().Controller(client.CRForeignCluster).Store.Add(fc))
+	eventTester.Wait()
+	peerNode, present := quick.ListChild("details1")
+	if !assert.True(t, present) {
+		return
+	}
+	details, present := peerNode.ListChild(tagPeerDetails)
+	if !assert.True(t, present, "PEERING DETAILS entry not created") {
+		return
+	}
+	assert.False(t, details.IsVisible(), "PEERING DETAILS displayed without active peerings")
+	row := func(tag string) *app.MenuNode {
+		node, _ := details.ListChild(tag)
+		return node
+	}
+	//the details are displayed once the outgoing peering is established
+	fc = fc.DeepCopy()
+	fc.Status.Outgoing.Joined = true
+	fc.Status.Outgoing.AdvertisementStatus = sharing.AdvertisementAccepted
+	eventTester.Add(1)
+	assert.NoError(t, i.AgentCtrl().Controller(client.CRForeignCluster).Store.Update(fc))
+	eventTester.Wait()
+	assert.True(t, details.IsVisible())
+	assert.Equal(t, peerDataIndentation+"Cluster ID: details1", row(tagDetailsClusterID).Title())
+	assert.Equal(t, peerDataIndentation+"Acquired: "+labelResourceQuotaUnavailable, row(tagDetailsAcquired).Title())
+	assert.False(t, row(tagDetailsShared).IsVisible(), "resources shared without incoming peering")
+	assert.True(t, strings.HasPrefix(row(tagDetailsLatency).Title(), peerDataIndentation+"Latency: "))
+	//the ResourceOffer of the peer provides the acquired resources and the virtual node
+	eventTester.Add(1)
+	assert.NoError(t, i.AgentCtrl().Controller(client.CRResourceOffer).Store.Add(&sharing.ResourceOffer{
+		ObjectMeta: metav1.ObjectMeta{Name: "offer-details1", Namespace: "tenant"},
+		Spec: sharing.ResourceOfferSpec{ClusterId: "details1", ResourceQuota: corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("4"),
+				corev1.ResourceMemory: resource.MustParse("8Gi"),
+			},
+		}},
+		Status: sharing.ResourceOfferStatus{VnodeReference: objectReferences.NodeReference{Name: "liqo-details1"}},
+	}))
+	eventTester.Wait()
+	assert.Equal(t, peerDataIndentation+"Acquired: 4.0 CPU, 8.0Gi RAM", row(tagDetailsAcquired).Title())
+	assert.Equal(t, peerDataIndentation+"Virtual node: liqo-details1", row(tagDetailsVirtualNode).Title())
+	i.Quit()
+}
+
+func TestResourceSharing(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	defer app.SetMockedInput("", false)
+	action, present := i.Action(aResourceSharing)
+	if !present {
+		t.Fatal("resource sharing ACTION not registered")
+	}
+	assert.False(t, action.IsEnabled(), "resource sharing ACTION enabled without ClusterConfig")
+	config := &clusterConfig.ClusterConfig{ObjectMeta: metav1.ObjectMeta{Name: "sharing-config"}}
+	config.Spec.AdvertisementConfig.OutgoingConfig.ResourceSharingPercentage = 50
+	eventTester.Add(1)
+	assert.NoError(t, i.AgentCtrl().Controller(client.CRClusterConfig).Store.Add(config))
+	eventTester.Wait()
+	assert.True(t, action.IsEnabled())
+	assert.Equal(t, "Resource sharing: 50%", action.Title())
+	option := func(tag string) *app.MenuNode {
+		node, _ := action.Option(tag)
+		return node
+	}
+	assert.True(t, option(sharingOptionTag(50)).IsChecked(), "current percentage not checked")
+	assert.False(t, option(oSharingCustom).IsChecked())
+	assert.True(t, option(sharingOptionTag(25)).IsWriteAction())
+	//a preset percentage; each update of the ClusterConfig is signaled to the listeners
+	eventTester.Add(1)
+	setResourceSharing(context.Background(), i, 25)
+	eventTester.Wait()
+	assert.Equal(t, "Resource sharing: 25%", action.Title())
+	assert.True(t, option(sharingOptionTag(25)).IsChecked())
+	assert.False(t, option(sharingOptionTag(50)).IsChecked())
+	//a custom percentage
+	app.SetMockedInput("30%", true)
+	eventTester.Add(1)
+	askResourceSharing(context.Background(), i)
+	eventTester.Wait()
+	percentage, _ := i.AgentCtrl().ResourceSharingPercentage()
+	assert.Equal(t, int32(30), percentage)
+	assert.True(t, option(oSharingCustom).IsChecked())
+	assert.Equal(t, "Custom (30%)…", option(oSharingCustom).Title())
+	//an invalid percentage changes nothing
+	app.SetMockedInput("130", true)
+	askResourceSharing(context.Background(), i)
+	percentage, _ = i.AgentCtrl().ResourceSharingPercentage()
+	assert.Equal(t, int32(30), percentage)
+	i.Quit()
+}
+
+func TestNamespaceOffloading(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	quick, present := i.Quick(qNamespaces)
+	if !present {
+		t.Fatal("namespaces QUICK not registered")
+	}
+	assert.False(t, quick.IsEnabled(), "namespaces QUICK enabled without namespaces")
+	assert.Equal(t, "web: Ready (2 pods offloaded)", namespaceOffloadingTitle(&client.NamespaceOffloading{
+		Name: "web", State: client.OffloadingReady, Message: "2 pods offloaded"}))
+	assert.Equal(t, "jobs: ❗ Error (the namespace is being deleted)",
+		namespaceOffloadingTitle(&client.NamespaceOffloading{Name: "jobs", State: client.OffloadingError,
+			Message: "the namespace is being deleted"}))
+	assert.Equal(t, "default", namespaceOffloadingTitle(&client.NamespaceOffloading{Name: "default",
+		State: client.OffloadingDisabled}))
+	//the offloading of a missing namespace cannot be enabled
+	toggleNamespaceOffloading(context.Background(), i, "missing")
+	var recorded *activity.Entry
+	for _, e := range activity.GetFeed().Entries() {
+		if e.Source == activitySourceOffloading {
+			recorded = e
+		}
+	}
+	if assert.NotNil(t, recorded, "namespace offloading change not recorded in the activity feed") {
+		assert.Equal(t, activity.OutcomeFailure, recorded.Outcome)
+	}
+	i.Quit()
+}
+
+func TestPeeringPhaseTransitions(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	notified := func() bool {
+		for _, n := range i.Notifications() {
+			if n.ID == notificationPeeringErrorPrefix+"outgoing/phases1" {
+				return true
+			}
+		}
+		return false
+	}
+	fc := test.CreateForeignCluster("phases1", "remote")
+	fc.Spec.Join = true
+	fc.Status.AuthStatus = discovery.AuthStatusRefused
+	eventTester.Add(1)
+	assert.NoError(t, i.AgentCtrl().Controller(client.CRForeignCluster).Store.Add(fc))
+	eventTester.Wait()
+	assert.Equal(t, client.PeeringError, i.Status().PeeringPhase("phases1", app.PeeringOutgoing))
+	assert.True(t, notified(), "failed peering not notified")
+	//the notification is dismissed once the peering recovers
+	fc = fc.DeepCopy()
+	fc.Status.AuthStatus = discovery.AuthStatusAccepted
+	fc.Status.Outgoing.Joined = true
+	fc.Status.Outgoing.AdvertisementStatus = sharing.AdvertisementAccepted
+	eventTester.Add(1)
+	assert.NoError(t, i.AgentCtrl().Controller(client.CRForeignCluster).Store.Update(fc))
+	eventTester.Wait()
+	assert.Equal(t, client.PeeringEstablished, i.Status().PeeringPhase("phases1", app.PeeringOutgoing))
+	assert.False(t, notified(), "notification of the recovered peering not dismissed")
+	var recorded []*activity.Entry
+	for _, e := range activity.GetFeed().Entries() {
+		if e.Source == activitySourcePeerings && strings.Contains(e.Message, "remote") {
+			recorded = append(recorded, e)
+		}
+	}
+	if assert.Len(t, recorded, 2) {
+		assert.Equal(t, "Outgoing peering with remote: Error → Established", recorded[0].Message)
+	}
+	i.Quit()
+}
+
+func TestPeeringProgress(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	OnReady()
+	i := app.GetIndicator()
+	defer i.Quit()
+	peer := &app.PeerInfo{ClusterID: "progress1", ClusterName: "remote"}
+	transition := func(from client.PeeringPhase, to client.PeeringPhase) {
+		onPeeringTransition(i, app.PeeringTransition{Peer: peer, Peering: app.PeeringOutgoing, From: from, To: to})
+	}
+	//the outgoing peering being established is displayed with its progress
+	transition(client.PeeringNone, client.PeeringPending)
+	p, present := i.Progress(progressPeeringPrefix + "progress1")
+	if !assert.True(t, present, "peering progress not displayed") {
+		return
+	}
+	assert.Equal(t, peeringProgress[client.PeeringPending], p.Percent())
+	assert.True(t, strings.HasPrefix(p.Title(), "Establishing peering with remote "))
+	transition(client.PeeringPending, client.PeeringAuthenticating)
+	assert.Equal(t, peeringProgress[client.PeeringAuthenticating], p.Percent())
+	//the incoming peerings are not displayed
+	onPeeringTransition(i, app.PeeringTransition{Peer: peer, Peering: app.PeeringIncoming,
+		From: client.PeeringAuthenticating, To: client.PeeringEstablished})
+	_, present = i.Progress(progressPeeringPrefix + "progress1")
+	assert.True(t, present, "peering progress completed by the incoming peering")
+	transition(client.PeeringAuthenticating, client.PeeringEstablished)
+	_, present = i.Progress(progressPeeringPrefix + "progress1")
+	assert.False(t, present, "established peering still in progress")
+	//a failed peering completes the progress as well
+	transition(client.PeeringNone, client.PeeringPending)
+	transition(client.PeeringPending, client.PeeringError)
+	_, present = i.Progress(progressPeeringPrefix + "progress1")
+	assert.False(t, present, "failed peering still in progress")
+}
+
+func TestTunnelHealth(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	notified := func() bool {
+		for _, n := range i.Notifications() {
+			if n.ID == notificationTunnelPrefix+"tunnel1" {
+				return true
+			}
+		}
+		return false
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+	fc := test.CreateForeignCluster("tunnel1", "remote")
+	fc.Spec.Join = true
+	fc.Spec.AuthUrl = "https://" + listener.Addr().String()
+	fc.Status.AuthStatus = discovery.AuthStatusAccepted
+	fc.Status.Outgoing.Joined = true
+	fc.Status.Outgoing.AdvertisementStatus = sharing.AdvertisementAccepted
+	eventTester.Add(1)
+	assert.NoError(t, i.AgentCtrl().Controller(client.CRForeignCluster).Store.Add(fc))
+	eventTester.Wait()
+	//no tunnel has been set up towards the peer
+	checkTunnelsHealth(i)
+	health, present := i.Status().TunnelHealth("tunnel1")
+	if assert.True(t, present, "tunnel health not stored") {
+		assert.True(t, health.Reachable)
+		assert.True(t, health.Degraded())
+	}
+	assert.True(t, notified(), "degraded tunnel not notified")
+	assert.Contains(t, i.Status().GoString(), "Tunnel remote: degraded")
+	//the notification is not repeated while the tunnel stays degraded
+	before := len(activity.GetFeed().Entries())
+	checkTunnelsHealth(i)
+	assert.Equal(t, before, len(activity.GetFeed().Entries()), "degraded tunnel recorded twice")
+	//the notification is dismissed once the peering is torn down
+	fc = fc.DeepCopy()
+	fc.Spec.Join = false
+	fc.Status.Outgoing.Joined = false
+	eventTester.Add(1)
+	assert.NoError(t, i.AgentCtrl().Controller(client.CRForeignCluster).Store.Update(fc))
+	eventTester.Wait()
+	checkTunnelsHealth(i)
+	assert.False(t, notified(), "notification of a peer no more peered not dismissed")
+	i.Quit()
+}
+
+func TestListenerDebounce(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	health, present := i.Listener(client.TopicHealthChanged)
+	if assert.True(t, present) {
+		assert.Equal(t, app.Debounce{Interval: listenerDebounceInterval, Trailing: true}, health.Debounce(),
+			"coalesced Topic not debounced")
+	}
+	peers, present := i.Listener(client.TopicPeerChanged)
+	if assert.True(t, present) {
+		assert.Zero(t, peers.Debounce().Interval, "peer events debounced")
+	}
+	i.Quit()
+}
+
+//test the application of the changes of the configuration file.
+func TestConfigReload(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	defer i.Quit()
+	dir, err := ioutil.TempDir("", "liqo-config-reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, present := os.LookupEnv(client.EnvLiqoPath)
+	conf, _ := client.GetLocalConfig()
+	defer func() {
+		_ = os.RemoveAll(dir)
+		if present {
+			_ = os.Setenv(client.EnvLiqoPath, env)
+		} else {
+			_ = os.Unsetenv(client.EnvLiqoPath)
+		}
+		_, _ = client.ReloadLocalConfig()
+		reapplySettings(i)
+	}()
+	assert.NoError(t, os.Setenv(client.EnvLiqoPath, dir))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, client.ConfigFileName),
+		[]byte("iconTheme: accessible\nintervals:\n  heartbeat: 7s\n  tunnel: 1m\n"), 0644))
+	changed, err := client.ReloadLocalConfig()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	applyConfigReload(i)
+	assert.Equal(t, string(app.IconThemeAccessible), conf.GetIconTheme())
+	assert.Equal(t, app.IconThemeAccessible, i.IconTheme(), "icon theme not applied")
+	timer, present := i.Timer(tHeartbeat)
+	if assert.True(t, present) {
+		assert.Equal(t, 7*time.Second, timer.Interval(), "heartbeat interval not applied")
+	}
+	timer, present = i.Timer(tTunnelHealth)
+	if assert.True(t, present) {
+		assert.Equal(t, time.Minute, timer.Interval(), "tunnel health interval not applied")
+	}
+	assert.Equal(t, activitySourceConfig, activity.GetFeed().Entries()[0].Source)
+	//the default intervals are restored
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, client.ConfigFileName), []byte("{}\n"), 0644))
+	_, _ = client.ReloadLocalConfig()
+	applyConfigReload(i)
+	timer, _ = i.Timer(tHeartbeat)
+	assert.Equal(t, client.DefaultHeartbeatInterval, timer.Interval())
+	//an invalid file is reported
+	showConfigError(i, errors.New("invalid yaml"))
+	entry := activity.GetFeed().Entries()[0]
+	assert.Equal(t, activitySourceConfig, entry.Source)
+	assert.Equal(t, activity.OutcomeFailure, entry.Outcome)
+}
+
+//test the "Start at login" OPTION.
+func TestAutostart(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	i.SetClickGuard(0)
+	defer i.Quit()
+	if _, supported := client.GetAutostartProvider(); !supported {
+		t.Skip("the start at login is not supported on this platform")
+	}
+	dir, err := ioutil.TempDir("", "liqo-autostart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, present := os.LookupEnv("XDG_CONFIG_HOME")
+	defer func() {
+		_ = os.RemoveAll(dir)
+		if present {
+			_ = os.Setenv("XDG_CONFIG_HOME", env)
+		} else {
+			_ = os.Unsetenv("XDG_CONFIG_HOME")
+		}
+	}()
+	assert.NoError(t, os.Setenv("XDG_CONFIG_HOME", dir))
+	assert.NoError(t, os.Setenv("XDG_CONFIG_DIRS", dir))
+	defer os.Unsetenv("XDG_CONFIG_DIRS")
+	quick, present := i.Quick(qStartup)
+	if !assert.True(t, present, "startup QUICK not registered") {
+		return
+	}
+	option, present := quick.Option(oAutostart)
+	if !assert.True(t, present, "start at login OPTION not registered") {
+		return
+	}
+	updateQuickStartup(i)
+	assert.False(t, option.IsChecked())
+	eventTester.Add(1)
+	option.Channel() <- struct{}{}
+	eventTester.Wait()
+	assert.True(t, option.IsChecked())
+	_, err = os.Stat(filepath.Join(dir, "autostart", client.DesktopEntryName))
+	assert.NoError(t, err, "autostart entry not created")
+	assert.Equal(t, activitySourceAutostart, activity.GetFeed().Entries()[0].Source)
+	eventTester.Add(1)
+	option.Channel() <- struct{}{}
+	eventTester.Wait()
+	assert.False(t, option.IsChecked())
+	_, err = os.Stat(filepath.Join(dir, "autostart", client.DesktopEntryName))
+	assert.True(t, os.IsNotExist(err), "autostart entry not removed")
+}
+
+//test the check for new versions of the Agent.
+func TestAgentUpdate(t *testing.T) {
+	app.UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	app.DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	app.DestroyStatus()
+	eventTester := app.GetGuiProvider().NewEventTester()
+	eventTester.Test()
+	OnReady()
+	i := app.GetIndicator()
+	defer i.Quit()
+	latest := `{"tag_name": "v0.3.0", "html_url": "https://example.com/v0.3.0"}`
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(latest))
+	}))
+	transport := http.DefaultTransport
+	http.DefaultTransport = server.Client().Transport
+	dir, err := ioutil.TempDir("", "liqo-agent-update")
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, present := os.LookupEnv(client.EnvLiqoPath)
+	version := client.AgentVersion
+	defer func() {
+		server.Close()
+		http.DefaultTransport = transport
+		_ = os.RemoveAll(dir)
+		if present {
+			_ = os.Setenv(client.EnvLiqoPath, env)
+		} else {
+			_ = os.Unsetenv(client.EnvLiqoPath)
+		}
+		_, _ = client.ReloadLocalConfig()
+		client.AgentVersion = version
+		agentUpdate.Lock()
+		agentUpdate.available = nil
+		agentUpdate.Unlock()
+	}()
+	assert.NoError(t, os.Setenv(client.EnvLiqoPath, dir))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, client.ConfigFileName),
+		[]byte("selfUpdate:\n  releasesUrl: "+server.URL+"\n"), 0644))
+	_, err = client.ReloadLocalConfig()
+	assert.NoError(t, err)
+	quick, present := i.Quick(qAgentUpdate)
+	if !assert.True(t, present, "agent update QUICK not registered") {
+		return
+	}
+	//the development builds are not updated
+	checkAgentUpdate(context.Background(), i)
+	assert.False(t, quick.IsVisible())
+	client.AgentVersion = "v0.2.0"
+	checkAgentUpdate(context.Background(), i)
+	assert.True(t, quick.IsVisible())
+	assert.Equal(t, "Update Liqo Agent to v0.3.0…", quick.Title())
+	//the QUICK is hidden once the Agent is up to date
+	client.AgentVersion = "v0.3.0"
+	checkAgentUpdate(context.Background(), i)
+	assert.False(t, quick.IsVisible())
+	//the check can be disabled
+	client.AgentVersion = "v0.2.0"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, client.ConfigFileName),
+		[]byte("selfUpdate:\n  disabled: true\n  releasesUrl: "+server.URL+"\n"), 0644))
+	_, _ = client.ReloadLocalConfig()
+	checkAgentUpdate(context.Background(), i)
+	assert.False(t, quick.IsVisible())
+}
@@ -0,0 +1,60 @@
+package logic
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"os/exec"
+	"strings"
+)
+
+/*This file contains the sign-in of the user when the credentials of the cluster, obtained at runtime by an exec
+plugin or an authentication provider (see client.LoginMethod), can not be renewed anymore. The login command runs
+in a terminal, so that it can prompt the user or open the sign-in page; once it exits, the connection is retried.*/
+
+const (
+	//titleLogin is the title of the menu entry signing in again to the cluster.
+	titleLogin = "• Log in again"
+	//tagLogin is the tag of the menu entry signing in again to the cluster.
+	tagLogin = "login"
+	//activitySourceLogin is the activity.Feed source of the sign-ins to the cluster.
+	activitySourceLogin = "login"
+	//loginScript is the script run in the terminal: it runs the login command, passed as its arguments, and waits
+	//for the user before closing.
+	loginScript = `"$@"; echo; printf "Press Enter to close"; read line`
+)
+
+//loginMethod returns the LoginMethod of the cluster user, if it provides a login command.
+func loginMethod(i *app.Indicator) (*client.LoginMethod, bool) {
+	method, err := i.AgentCtrl().KubeconfigLogin()
+	if err != nil || method == nil || len(method.Command) == 0 {
+		return nil, false
+	}
+	return method, true
+}
+
+//login runs the login command of the cluster user in a terminal. When the terminal exits, the connection to the
+//cluster is retried.
+func login(i *app.Indicator, method *client.LoginMethod) {
+	if app.GetGuiProvider().Mocked() {
+		return
+	}
+	terminal, err := terminalCommand()
+	if err == nil {
+		//the terminal is kept open after the command, so that its output can be read
+		args := append(terminal[1:], "sh", "-c", loginScript, "sh")
+		cmd := exec.Command(terminal[0], append(args, method.Command...)...)
+		cmd.Env = i.AgentCtrl().LoginEnv(method)
+		if err = cmd.Start(); err == nil {
+			activity.GetFeed().Add(activitySourceLogin, "Login started: "+strings.Join(method.Command, " "),
+				activity.OutcomeInfo)
+			go func() {
+				_ = cmd.Wait()
+				current := app.GetIndicator()
+				retryAuthentication(current.Context(), current)
+			}()
+			return
+		}
+	}
+	i.ShowWarning("LIQO AGENT", "Liqo Agent could not run the login command:\n"+err.Error())
+}
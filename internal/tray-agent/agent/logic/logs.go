@@ -0,0 +1,174 @@
+package logic
+
+import (
+	"archive/zip"
+	"context"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/logging"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/redact"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"github.com/skratchdot/open-golang/open"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/*This file contains the entries of the Settings section about the Agent logs (see package logging):
+-	"Open log file" opens the current log file with the default application;
+-	"Export support bundle…" saves into a zip archive, to be attached to the bug reports, the log files together
+	with the current Status and the effective configuration. Since the archive is meant to leave the machine, its
+	content is scrubbed by the redaction layer;
+-	"Debug logging" raises at runtime the verbosity of the Agent and of its libraries. The choice is saved in the
+	local configuration.*/
+
+const (
+	//oOpenLogs is the tag of the OPTION opening the log file.
+	oOpenLogs = "O_OPEN_LOGS"
+	//oSupportBundle is the tag of the OPTION exporting the support bundle.
+	oSupportBundle = "O_SUPPORT_BUNDLE"
+	//oDebugLogging is the tag of the OPTION toggling the debug logging.
+	oDebugLogging = "O_DEBUG_LOGGING"
+	//activitySourceLogs is the activity.Feed source of the changes of the logging settings.
+	activitySourceLogs = "logs"
+)
+
+//startQuickLogs is the wrapper function to register QUICK "Logs" and its OPTION "Debug logging".
+func startQuickLogs(i *app.Indicator) {
+	quick := i.AddQuick("Logs", qLogs, nil)
+	quick.SetTooltip("Log file: " + logging.Path())
+	quick.AddOption("Open log file", oOpenLogs, "Open the Agent log file", false,
+		app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			optionOpenLogs(i)
+		}))
+	quick.AddOption("Export support bundle…", oSupportBundle, "Save the logs, the status and the configuration "+
+		"of the Agent into an archive to be attached to the bug reports", false,
+		app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			optionExportSupportBundle(i)
+		}))
+	quick.AddOption("Debug logging", oDebugLogging, "Log the debug entries of the Agent and of its libraries",
+		true, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			optionToggleDebugLogging(i)
+		}))
+	updateQuickLogs(i)
+}
+
+//optionOpenLogs is the callback for the OPTION "Open log file".
+func optionOpenLogs(i *app.Indicator) {
+	if app.GetGuiProvider().Mocked() {
+		return
+	}
+	if err := open.Start(logging.Path()); err != nil {
+		i.ShowWarning("LIQO AGENT", "Liqo Agent could not open the log file "+logging.Path()+":\n"+err.Error())
+	}
+}
+
+//optionExportSupportBundle is the callback for the OPTION "Export support bundle…".
+func optionExportSupportBundle(i *app.Indicator) {
+	if app.GetGuiProvider().Mocked() {
+		return
+	}
+	dir, ok, _ := dlgs.File("Select the destination folder", "", true)
+	if !ok {
+		return
+	}
+	path, err := exportSupportBundle(i, dir)
+	if err != nil {
+		activity.GetFeed().Add(activitySourceLogs, "Support bundle export failed", activity.OutcomeFailure)
+		i.ShowWarning("LIQO AGENT", "Liqo Agent could not export the support bundle:\n"+err.Error())
+		return
+	}
+	activity.GetFeed().Add(activitySourceLogs, "Support bundle saved to "+path, activity.OutcomeSuccess)
+	i.Notify("Liqo Agent", "The support bundle was saved to "+path, app.NotifyIconDefault, app.IconLiqoNil)
+}
+
+//exportSupportBundle saves the support bundle into dir, returning its path. The bundle contains:
+//	-	status.txt: the details of the Status (see statusDetails);
+//	-	agent_conf.yaml: the effective configuration;
+//	-	logs/: the current and the rotated log files.
+func exportSupportBundle(i *app.Indicator, dir string) (string, error) {
+	config, err := client.MarshalLocalConfig()
+	if err != nil {
+		return "", err
+	}
+	files := []client.DiagnosticsFile{
+		{Name: "status.txt", Data: []byte(statusDetails(i))},
+		{Name: client.ConfigFileName, Data: config},
+	}
+	for _, logFile := range logging.Files() {
+		data, err := ioutil.ReadFile(logFile)
+		if err != nil {
+			return "", err
+		}
+		files = append(files, client.DiagnosticsFile{Name: "logs/" + filepath.Base(logFile), Data: data})
+	}
+	path := filepath.Join(dir, "liqo-agent-support-"+i.Now().Format("20060102-150405")+".zip")
+	return path, writeSupportBundle(files, path, i.Now())
+}
+
+//writeSupportBundle saves the files into a zip archive. The content of each file is redacted.
+func writeSupportBundle(files []client.DiagnosticsFile, path string, modTime time.Time) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(f)
+	for _, file := range files {
+		header := &zip.FileHeader{Name: file.Name, Method: zip.Deflate}
+		header.Modified = modTime
+		var w io.Writer
+		if w, err = zw.CreateHeader(header); err != nil {
+			break
+		}
+		if _, err = w.Write([]byte(redact.String(string(file.Data)))); err != nil {
+			break
+		}
+	}
+	for _, closer := range []interface{ Close() error }{zw, f} {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		_ = os.Remove(path)
+	}
+	return err
+}
+
+//optionToggleDebugLogging is the callback for the OPTION "Debug logging", enabling or disabling the debug logging
+//and saving the choice in the local configuration.
+func optionToggleDebugLogging(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	enabled := !conf.GetDebugLogging()
+	conf.SetDebugLogging(enabled)
+	if err := client.SaveLocalConfig(); err != nil {
+		logger.Warning("cannot save the debug logging setting", "err", err)
+	}
+	msg := "Debug logging disabled"
+	if enabled {
+		msg = "Debug logging enabled"
+	}
+	activity.GetFeed().Add(activitySourceLogs, msg, activity.OutcomeSuccess)
+	configureDebugLogging(i)
+}
+
+//configureDebugLogging applies the debug logging setting of the local configuration.
+func configureDebugLogging(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	logging.SetDebug(conf.GetDebugLogging())
+	updateQuickLogs(i)
+}
+
+//updateQuickLogs refreshes the checkbox of the OPTION "Debug logging".
+func updateQuickLogs(i *app.Indicator) {
+	quick, present := i.Quick(qLogs)
+	if !present {
+		return
+	}
+	if option, present := quick.Option(oDebugLogging); present {
+		option.SetIsChecked(logging.DebugEnabled())
+	}
+}
@@ -1,100 +1,305 @@
 package logic
 
 import (
+	"context"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
 	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/logging"
 	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
 	"github.com/skratchdot/open-golang/open"
+	"sync"
+	"time"
 )
 
+//logger writes the log entries of the Agent logic.
+var logger = logging.New("logic")
+
 //OnReady is the routine orchestrating Liqo Agent execution.
 func OnReady() {
+	s := beginStartup()
+	startTracing()
 	// Indicator configuration
+	s.stage(stageConnect)
 	i := app.GetIndicator()
+	s.stage(stageMenu)
+	loadOrgDefaults(i)
+	reportConfigError(i)
+	configureReadOnly(i)
+	configureRedaction(i)
+	configureDebugLogging(i)
+	configureQuietHours(i)
+	configureDoNotDisturb(i)
+	configureSessionLock(i)
+	configureHotkeys(i)
+	configureLanguage(i)
+	configureRefreshInterval(i)
+	configurePeerPreferences(i)
+	restoreMenuState(i)
 	i.RefreshStatus()
+	//the QUICKs are registered before the listeners and timers that look them up
+	buildMenu(i)
+	restoreExpandedLists(i)
+	startPeeringPhaseWatch(i)
 	startListenerClusterConfig(i)
 	startListenerPeersList(i)
-	startQuickOnOff(i)
-	startQuickChangeMode(i)
-	startQuickDashboard(i)
-	startQuickShowPeers(i)
-	i.AddSeparator()
-	startQuickSetNotifications(i)
-	startQuickLiqoWebsite(i)
-	startQuickQuit(i)
-	//try to start Liqo and main ACTION
-	quickTurnOnOff(i)
+	startListenerStorage(i)
+	startListenerHealth(i)
+	startListenerWorkloads(i)
+	startListenerOffers(i)
+	startListenerNamespaces(i)
+	startHeartbeat(i)
+	configureListenerDebounce(i)
+	startPeerLatencyProbe(i)
+	startTunnelHealthCheck(i)
+	startUsageTrend(i)
+	s.stage(stageCaches)
+	startCacheSyncProgress(i, s)
+	startLocalAPI(i)
+	startRemoteWrite(i)
+	startConfigWatch(i)
+	startKubeconfigWatch(i)
+	//try to start Liqo and main ACTION, unless the user left it stopped
+	if !client.GetMenuStateStore().State().Stopped {
+		quickTurnOnOff(i)
+	}
+	startStressTest(i)
 }
 
 //OnExit is the routine containing clean-up operations to be performed at Liqo Agent exit.
 func OnExit() {
-	app.GetIndicator().Disconnect()
+	stopLocalAPI()
+	stopSettingsPage()
+	stopConfigWatch()
+	stopKubeconfigWatch()
+	stopRemoteWrite()
+	stopTracing()
+	saveStatusCache(app.GetIndicator())
+	disconnectClusters(app.GetIndicator())
+	i := app.GetIndicator()
+	i.Disconnect()
+	if !i.Wait(app.QuitTimeout) {
+		logger.Warning("exiting while some goroutines are still running")
+	}
+	_ = logging.Close()
 }
 
 //startQuickOnOff is the wrapper function to register the QUICK "START/STOP LIQO".
 func startQuickOnOff(i *app.Indicator) {
-	i.AddQuick("", qOnOff, func(args ...interface{}) {
-		quickTurnOnOff(args[0].(*app.Indicator))
-	}, i)
+	i.AddQuick("", qOnOff, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		quickTurnOnOff(e.Indicator)
+		recordRunning(e.Indicator.Status().Running())
+	}))
 	//the Quick MenuNode title is refreshed
 	updateQuickTurnOnOff(i)
 }
 
 //startQuickChangeMode is the wrapper function to register the QUICK "CHANGE LIQO MODE"
 func startQuickChangeMode(i *app.Indicator) {
-	i.AddQuick("", qMode, func(args ...interface{}) {
+	i.AddQuick("", qMode, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
 		quickChangeMode(i)
-	}, i)
+	}))
 	//the Quick MenuNode title is refreshed
 	updateQuickChangeMode(i)
 }
 
 //startQuickLiqoWebsite is the wrapper function to register QUICK "About Liqo".
 func startQuickLiqoWebsite(i *app.Indicator) {
-	i.AddQuick("Help", qWeb, func(args ...interface{}) {
-		_ = open.Start("https://doc.liqo.io/")
-	})
+	i.AddQuick("Help", qWeb, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		_ = open.Start(helpURL())
+	}))
 }
 
 //startQuickDashboard is the wrapper function to register QUICK "LAUNCH Liqo Dash".
 func startQuickDashboard(i *app.Indicator) {
-	node := i.AddQuick("LiqoDash", qDash, func(args ...interface{}) {
-		quickConnectDashboard(i)
-	})
+	node := i.AddQuick("LiqoDash", qDash, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		quickConnectDashboard(ctx, i)
+	}))
 	node.SetIsEnabled(false)
 }
 
 //startQuickSetNotifications is the wrapper function to register QUICK "Change Notification settings".
 func startQuickSetNotifications(i *app.Indicator) {
-	i.AddQuick("Notifications Settings", qNotify, func(args ...interface{}) {
+	i.AddQuick("Notifications Settings", qNotify, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
 		quickChangeNotifyLevel()
+	}))
+}
+
+//startQuickQuietHours is the wrapper function to register QUICK "Quiet hours", refreshed when its state changes and
+//visible only if quiet hours are configured or a quiet period is in progress.
+func startQuickQuietHours(i *app.Indicator) {
+	node := i.AddQuick(titleQuietHours, qQuietHours, nil)
+	node.SetIsEnabled(false)
+	refreshQuietHours(node, i.QuietHours(), i.QuietUntil(), time.Now())
+	_ = i.StartScheduledTimer(tQuietHours, quietHoursSchedule{i}, func(args ...interface{}) {
+		refreshQuietHours(node, i.QuietHours(), i.QuietUntil(), time.Now())
 	})
 }
 
+//startQuickCustomizeMenu is the wrapper function to register QUICK "Customize menu…".
+func startQuickCustomizeMenu(i *app.Indicator) {
+	i.AddQuick("Customize menu…", qCustomize, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		quickCustomizeMenu(i)
+	}))
+}
+
+//startQuickSetIconTheme is the wrapper function to register QUICK "Icon Theme Settings".
+func startQuickSetIconTheme(i *app.Indicator) {
+	i.AddQuick("Icon Theme Settings", qIconTheme, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		quickChangeIconTheme(i)
+	}))
+}
+
 //startQuickQuit is the wrapper function to register QUICK "QUIT".
 func startQuickQuit(i *app.Indicator) {
-	i.AddQuick("Quit", qQuit, func(args ...interface{}) {
-		i := args[0].(*app.Indicator)
-		i.Quit()
-	}, i)
+	i.AddQuick("Quit", qQuit, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		e.Indicator.Quit()
+	}))
 }
 
 //startQuickShowPeers is the wrapper function to register QUICK "PEERS".
 func startQuickShowPeers(i *app.Indicator) {
 	node := i.AddQuick(titlePeers, qPeers, nil)
+	node.SetListPageSize(listPageSize())
 	refreshPeerCount(node)
 }
 
+//startQuickOpenTerminal is the wrapper function to register QUICK "Open terminal here".
+func startQuickOpenTerminal(i *app.Indicator) {
+	i.AddQuick(titleTerminal, qTerminal, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		openTerminal(i, "")
+	}))
+}
+
+//startQuickExportTopology is the wrapper function to register QUICK "Export topology".
+func startQuickExportTopology(i *app.Indicator) {
+	i.AddQuick("Export topology", qTopology, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		quickExportTopology(i)
+	}))
+}
+
+//startQuickShowStorage is the wrapper function to register QUICK "Storage".
+func startQuickShowStorage(i *app.Indicator) {
+	node := i.AddQuick(titleStorage, qStorage, nil)
+	if report, err := i.AgentCtrl().StorageReport(); err == nil {
+		refreshStorage(node, report, i.Status())
+	} else {
+		node.SetIsEnabled(false)
+	}
+}
+
+//startQuickShowCapacity is the wrapper function to register QUICK "Capacity", periodically refreshed.
+func startQuickShowCapacity(i *app.Indicator) {
+	node := i.AddQuick(titleCapacity, qCapacity, nil)
+	refresh := func(args ...interface{}) {
+		if report, err := i.AgentCtrl().CapacityReport(); err == nil {
+			refreshCapacity(node, report, i.Status())
+			node.SetIsEnabled(true)
+		} else {
+			node.SetIsEnabled(false)
+		}
+	}
+	refresh()
+	_ = i.StartTimer(tCapacity, configuredInterval(intervals().Capacity, capacityRefreshInterval), refresh)
+}
+
+//startQuickShowCredentials is the wrapper function to register QUICK "Credentials", periodically checking the
+//expiry of the cluster credentials.
+func startQuickShowCredentials(i *app.Indicator) {
+	i.AddQuick(titleCredentials, qCredentials, nil)
+	checkCredentials(i)
+	interval := configuredInterval(intervals().Credentials, credentialsCheckInterval)
+	_ = i.StartTimer(tCredentials, interval, func(args ...interface{}) {
+		checkCredentials(i)
+	})
+}
+
+//startQuickShowHealth is the wrapper function to register QUICK "Cluster health".
+func startQuickShowHealth(i *app.Indicator) {
+	node := i.AddQuick(titleHealth, qHealth, nil)
+	if report, err := i.AgentCtrl().HealthReport(); err == nil {
+		refreshHealth(i, node, report)
+	} else {
+		node.SetIsEnabled(false)
+	}
+}
+
+//startQuickShowActivity is the wrapper function to register QUICK "Activity".
+func startQuickShowActivity(i *app.Indicator) {
+	node := i.AddQuick(titleActivity, qActivity, nil)
+	refreshActivity(node, activity.GetFeed())
+	activityOnce.Do(func() {
+		activity.GetFeed().OnAdd(func(e *activity.Entry) {
+			if quick, present := app.GetIndicator().Quick(qActivity); present {
+				refreshActivity(quick, activity.GetFeed())
+			}
+			publishActivity(e)
+		})
+	})
+}
+
+//activityOnce prevents the registration of multiple activity feed callbacks.
+var activityOnce sync.Once
+
+//startQuickUpgrade is the wrapper function to register QUICK "Upgrade Liqo…", visible only when a new Liqo
+//version is available.
+func startQuickUpgrade(i *app.Indicator) {
+	node := i.AddQuick(titleUpgrade, qUpgrade, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		quickUpgradeLiqo(i)
+	}))
+	node.SetIsVisible(false)
+	node.SetWriteAction(true)
+	if !i.AgentCtrl().Mocked() {
+		go checkUpgrade(i)
+	}
+	interval := configuredInterval(intervals().Upgrade, upgradeCheckInterval)
+	_ = i.StartTimer(tUpgrade, interval, func(args ...interface{}) {
+		checkUpgrade(i)
+	})
+}
+
+//startQuickUninstall is the wrapper function to register QUICK "Uninstall Liqo…".
+func startQuickUninstall(i *app.Indicator) {
+	node := i.AddQuick(titleUninstall, qUninstall, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		quickUninstallLiqo(ctx, i)
+	}))
+	node.SetWriteAction(true)
+}
+
 //LISTENERS
 
 /*startListenerPeersList is a wrapper that starts the listeners regarding the dynamic listing of Liqo discovered Liqo peers.
   Since these listeners work on a specific QUICK MenuNode, the associated handlers works only if that QUICK
   is registered in the Indicator.*/
 func startListenerPeersList(i *app.Indicator) {
-	i.Listen(client.ChanPeerAddedOrUpdated, listenAddedOrUpdatedPeer)
-	i.Listen(client.ChanPeerDeleted, listenDeletedPeer)
+	i.Listen(client.TopicPeerChanged, listenPeerChanged)
+}
+
+//startListenerStorage is a wrapper that starts the listener regarding the storage resources of the home cluster.
+func startListenerStorage(i *app.Indicator) {
+	i.Listen(client.TopicStorageChanged, listenStorageChanged)
+}
+
+//startListenerHealth is a wrapper that starts the listener regarding the components of the Liqo control plane.
+func startListenerHealth(i *app.Indicator) {
+	i.Listen(client.TopicHealthChanged, listenHealthChanged)
+}
+
+//startListenerWorkloads is a wrapper that starts the listener regarding the pods offloaded to the peers.
+func startListenerWorkloads(i *app.Indicator) {
+	i.Listen(client.TopicWorkloadsChanged, listenWorkloadsChanged)
+}
+
+//startListenerNamespaces is a wrapper that starts the listener regarding the namespaces of the home cluster.
+func startListenerNamespaces(i *app.Indicator) {
+	i.Listen(client.TopicNamespacesChanged, listenNamespacesChanged)
+}
+
+//startListenerOffers is a wrapper that starts the listener regarding the resource offers of the peers.
+func startListenerOffers(i *app.Indicator) {
+	i.Listen(client.TopicOfferChanged, listenOfferChanged)
 }
 
 //startListenerClusterConfig is a wrapper that starts the listeners regarding Liqo configuration data.
 func startListenerClusterConfig(i *app.Indicator) {
-	i.Listen(client.ChanClusterName, listenClusterName)
+	i.Listen(client.TopicClusterName, listenClusterName)
 }
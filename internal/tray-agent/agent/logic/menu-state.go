@@ -0,0 +1,124 @@
+package logic
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strings"
+)
+
+//labelPeerRecent is the label marking in the peers list the last peer the user interacted with.
+const labelPeerRecent = "[RECENT]"
+
+//restoreMenuState applies the menu state left by the user at the previous run of the Agent. The pages of the lists
+//are restored once they are registered (see restoreExpandedLists).
+func restoreMenuState(i *app.Indicator) {
+	state := client.GetMenuStateStore().State()
+	applyPeerSearch(i, state.PeerSearch)
+	restoreExpandedLists(i)
+	if state.NotifyLevel != nil {
+		i.NotificationSetLevel(app.NotifyLevel(*state.NotifyLevel))
+		return
+	}
+	//the user never selected a level: the configured one (e.g. by the organization) applies
+	conf, _ := client.GetLocalConfig()
+	if level, valid := app.ParseNotifyLevel(conf.GetNotifyLevel()); valid {
+		i.NotificationSetLevel(level)
+	}
+}
+
+//recordNotifyLevel saves the notification level selected by the user.
+func recordNotifyLevel(level app.NotifyLevel) {
+	_ = client.GetMenuStateStore().Update(func(state *client.MenuState) {
+		l := int(level)
+		state.NotifyLevel = &l
+	})
+}
+
+//recordRunning saves whether the user left the Agent running.
+func recordRunning(running app.StatRun) {
+	_ = client.GetMenuStateStore().Update(func(state *client.MenuState) {
+		state.Stopped = running == app.StatRunOff
+	})
+}
+
+//recordLastPeer saves the last peer the user interacted with, moving the labelPeerRecent marker onto its entry.
+func recordLastPeer(i *app.Indicator, clusterID string) {
+	var previous string
+	_ = client.GetMenuStateStore().Update(func(state *client.MenuState) {
+		previous = state.LastPeer
+		state.LastPeer = clusterID
+	})
+	if previous == clusterID {
+		return
+	}
+	if quick, present := i.Quick(qPeers); present {
+		if node, present := findPeerEntry(quick, previous); present {
+			node.SetTitle(recentPeerTitle(node.Title(), false))
+		}
+		if node, present := findPeerEntry(quick, clusterID); present {
+			node.SetTitle(recentPeerTitle(node.Title(), true))
+		}
+	}
+}
+
+//restoreExpandedLists restores the pages the user displayed in the long lists of the menu (the peers and the
+//namespaces), tracking the ones displayed from now on. The groups of peers are tracked once created
+//(see peerGroupParent).
+func restoreExpandedLists(i *app.Indicator) {
+	for _, tag := range []string{qPeers, qNamespaces} {
+		if quick, present := i.Quick(tag); present {
+			trackListPages(quick, tag)
+		}
+	}
+}
+
+//trackListPages displays the pages of a paginated list (identified by tag) the user left displayed at the previous
+//run of the Agent, and saves the ones the user displays from now on.
+func trackListPages(node *app.MenuNode, tag string) {
+	if pages := client.GetMenuStateStore().State().ExpandedLists[tag]; pages > 1 {
+		node.SetListPages(pages)
+	}
+	node.OnListShowMore(func(pages int) {
+		_ = client.GetMenuStateStore().Update(func(state *client.MenuState) {
+			if state.ExpandedLists == nil {
+				state.ExpandedLists = make(map[string]int)
+			}
+			state.ExpandedLists[tag] = pages
+		})
+	})
+}
+
+//recordPeerSearch saves the text searched in the peers list. Since the search displays again the first page of the
+//list and of its groups, their expanded pages are forgotten.
+func recordPeerSearch(query string) {
+	_ = client.GetMenuStateStore().Update(func(state *client.MenuState) {
+		state.PeerSearch = query
+		for tag := range state.ExpandedLists {
+			if tag == qPeers || strings.HasPrefix(tag, tagPeerGroupPrefix) {
+				delete(state.ExpandedLists, tag)
+			}
+		}
+	})
+}
+
+//recordPeerGroupLabel saves the label the user grouped the peers list by (empty for no grouping). A nil label
+//restores the configured one.
+func recordPeerGroupLabel(label *string) {
+	_ = client.GetMenuStateStore().Update(func(state *client.MenuState) {
+		state.PeerGroupLabel = label
+	})
+}
+
+//isLastPeer returns whether clusterID identifies the last peer the user interacted with.
+func isLastPeer(clusterID string) bool {
+	return clusterID != "" && client.GetMenuStateStore().State().LastPeer == clusterID
+}
+
+//recentPeerTitle adds (or removes) the labelPeerRecent marker to the title of a peer entry.
+func recentPeerTitle(title string, recent bool) string {
+	title = strings.TrimSuffix(title, " "+labelPeerRecent)
+	if recent {
+		title += " " + labelPeerRecent
+	}
+	return title
+}
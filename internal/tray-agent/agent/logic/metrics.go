@@ -0,0 +1,136 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/metrics"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+/*This file contains the gauges describing the state of the Agent and of the home cluster, exported by the /metrics
+endpoint of the local API together with the timers measuring the latency of the Agent components (e.g. the handling
+of the cluster events, see package metrics). The same metrics can be pushed to a Prometheus remote-write endpoint
+(see client.RemoteWriteConfig).*/
+
+//remoteWrite contains the push of the metrics to the remote-write endpoint.
+var remoteWrite = struct {
+	sync.Mutex
+	//cancel stops the push, if started.
+	cancel context.CancelFunc
+}{}
+
+//registerMetrics registers the gauges of the Agent in the metrics.Registry singleton.
+func registerMetrics(i *app.Indicator) {
+	r := metrics.GetRegistry()
+	r.RegisterGauge("connected", "Whether the Agent is connected to the cluster", func() []metrics.Sample {
+		return []metrics.Sample{{Value: boolValue(i.AgentCtrl().Connected())}}
+	})
+	r.RegisterGauge("reachable", "Whether the API server of the cluster is reachable", func() []metrics.Sample {
+		return []metrics.Sample{{Value: boolValue(i.AgentCtrl().Reachable())}}
+	})
+	r.RegisterGauge("caches_synced", "Number of caches completing their initial synchronization",
+		func() []metrics.Sample {
+			progress := i.AgentCtrl().CacheSyncProgress()
+			return []metrics.Sample{
+				{Labels: metrics.Labels{"state": "synced"}, Value: float64(progress.Synced)},
+				{Labels: metrics.Labels{"state": "total"}, Value: float64(progress.Total)},
+			}
+		})
+	r.RegisterGauge("peers", "Number of discovered peers", func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(i.Status().Peers())}}
+	})
+	r.RegisterGauge("peerings", "Number of active peerings", func() []metrics.Sample {
+		return []metrics.Sample{
+			{Labels: metrics.Labels{"direction": "incoming"}, Value: float64(i.Status().Peerings(app.PeeringIncoming))},
+			{Labels: metrics.Labels{"direction": "outgoing"}, Value: float64(i.Status().Peerings(app.PeeringOutgoing))},
+		}
+	})
+	r.RegisterGauge("pending_events", "Number of cluster events waiting to be handled", func() []metrics.Sample {
+		listeners := i.Listeners()
+		samples := make([]metrics.Sample, 0, len(listeners))
+		for _, l := range listeners {
+			labels := metrics.Labels{"listener": l.Tag.String()}
+			if l.Cluster != "" {
+				labels["cluster"] = l.Cluster
+			}
+			samples = append(samples, metrics.Sample{Labels: labels, Value: float64(l.Stats().Pending)})
+		}
+		return samples
+	})
+	r.RegisterGauge("pending_items", "Number of items awaiting an input of the user", func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(i.Pending().Len())}}
+	})
+	r.RegisterGauge("running_operations", "Number of operations started from the tray menu in progress",
+		func() []metrics.Sample {
+			operations.Lock()
+			defer operations.Unlock()
+			return []metrics.Sample{{Value: float64(len(operations.running))}}
+		})
+}
+
+//startRemoteWrite starts pushing the metrics to the remote-write endpoint of the local configuration, if any,
+//replacing the previous push. The failures are logged when the push starts failing and when it recovers.
+func startRemoteWrite(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	rwConf := conf.GetRemoteWrite()
+	if rwConf.URL == "" {
+		return
+	}
+	password := rwConf.Password
+	if rwConf.PasswordFile != "" {
+		content, err := ioutil.ReadFile(rwConf.PasswordFile)
+		if err != nil {
+			i.Notify("Liqo Agent: METRICS REMOTE WRITE UNAVAILABLE",
+				fmt.Sprintf("cannot read the password file: %v", err), app.NotifyIconWarning, app.IconLiqoNil)
+			return
+		}
+		password = strings.TrimSpace(string(content))
+	}
+	registerMetrics(i)
+	writer := metrics.NewRemoteWriter(metrics.GetRegistry(), metrics.RemoteWriteOptions{
+		URL:      rwConf.URL,
+		Username: rwConf.Username,
+		Password: password,
+		Interval: rwConf.Interval,
+		Labels:   rwConf.Labels,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	remoteWrite.Lock()
+	if remoteWrite.cancel != nil {
+		remoteWrite.cancel()
+	}
+	remoteWrite.cancel = cancel
+	remoteWrite.Unlock()
+	failing := false
+	go writer.Run(ctx, func(err error) {
+		switch {
+		case err != nil && !failing:
+			logger.Warning("metrics remote write failing", "err", err)
+		case err == nil && failing:
+			logger.Info("metrics remote write recovered")
+		}
+		failing = err != nil
+	})
+}
+
+//stopRemoteWrite stops pushing the metrics to the remote-write endpoint, if started.
+func stopRemoteWrite() {
+	remoteWrite.Lock()
+	defer remoteWrite.Unlock()
+	if remoteWrite.cancel != nil {
+		remoteWrite.cancel()
+		remoteWrite.cancel = nil
+	}
+}
+
+//boolValue returns the value of a boolean gauge.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
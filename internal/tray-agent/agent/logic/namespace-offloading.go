@@ -0,0 +1,123 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"sync"
+)
+
+/*This file contains the QUICK "Namespaces" of the Offloading section, listing the namespaces of the home cluster.
+Clicking a namespace toggles its offloading towards the peers, i.e. the client.LabelOffloadingEnabled label. The
+state of the enabled namespaces (Ready/Error) is displayed in their entries and in the STATUS node.*/
+
+const (
+	//titleNamespaceOffloading is the title of the QUICK listing the namespaces of the home cluster.
+	titleNamespaceOffloading = "Namespaces"
+	//activitySourceOffloading is the activity.Feed source of the changes of the offloading of the namespaces.
+	activitySourceOffloading = "offloading"
+)
+
+//shownNamespaces contains the namespaces listed in the QUICK "Namespaces".
+var shownNamespaces = struct {
+	sync.Mutex
+	names map[string]bool
+}{names: make(map[string]bool)}
+
+//startQuickNamespaceOffloading is the wrapper function to register the QUICK "Namespaces".
+func startQuickNamespaceOffloading(i *app.Indicator) {
+	i.AddQuick(titleNamespaceOffloading, qNamespaces, nil).SetListPageSize(listPageSize())
+	refreshNamespaceOffloading(i)
+}
+
+//listenNamespacesChanged is the callback refreshing the offloading of the namespaces when they change.
+func listenNamespacesChanged(_ client.NotifyDataGeneric, _ ...interface{}) {
+	refreshNamespaceOffloading(app.GetIndicator())
+}
+
+//refreshNamespaceOffloading refreshes the LIST children of the QUICK "Namespaces" and the offloading state
+//displayed in the STATUS node. If the namespaces are not available, the QUICK is disabled.
+func refreshNamespaceOffloading(i *app.Indicator) {
+	namespaces, err := i.AgentCtrl().NamespaceOffloadings()
+	i.Status().SetOffloading(namespaces)
+	i.RefreshStatus()
+	quick, present := i.Quick(qNamespaces)
+	if !present {
+		return
+	}
+	shownNamespaces.Lock()
+	defer shownNamespaces.Unlock()
+	listed := make(map[string]bool, len(namespaces))
+	for _, ns := range namespaces {
+		listed[ns.Name] = true
+		child, present := quick.ListChild(ns.Name)
+		if !present {
+			child = quick.UseListChild(ns.Name, ns.Name)
+			child.SetWriteAction(true)
+			name := ns.Name
+			child.Connect(false, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+				toggleNamespaceOffloading(ctx, e.Indicator, name)
+			}))
+		}
+		child.SetTitle(namespaceOffloadingTitle(ns))
+		child.SetIsChecked(ns.Enabled())
+	}
+	for name := range shownNamespaces.names {
+		if !listed[name] {
+			quick.FreeListChild(name)
+		}
+	}
+	shownNamespaces.names = listed
+	quick.SetIsEnabled(err == nil && len(namespaces) > 0)
+}
+
+//namespaceOffloadingTitle returns the title of the entry of a namespace, e.g. "default: Ready (2 pods offloaded)".
+func namespaceOffloadingTitle(ns *client.NamespaceOffloading) string {
+	switch ns.State {
+	case client.OffloadingReady:
+		return fmt.Sprintf("%s: Ready (%s)", ns.Name, ns.Message)
+	case client.OffloadingError:
+		return fmt.Sprintf("%s: ❗ Error (%s)", ns.Name, ns.Message)
+	default:
+		return ns.Name
+	}
+}
+
+//toggleNamespaceOffloading enables or disables the offloading of a namespace, according to its current state.
+func toggleNamespaceOffloading(ctx context.Context, i *app.Indicator, namespace string) {
+	if !writeAllowed(i, "the change of the namespace offloading") {
+		refreshNamespaceOffloading(i)
+		return
+	}
+	if !i.AgentCtrl().Connected() {
+		i.ShowErrorNoConnection()
+		return
+	}
+	enable := true
+	for _, ns := range i.Status().Offloading() {
+		if ns.Name == namespace {
+			enable = false
+		}
+	}
+	op, verb := opEnableOffloading, "enabled"
+	if !enable {
+		op, verb = opDisableOffloading, "disabled"
+	}
+	err := runOperation(ctx, i, op, func(ctx context.Context) error {
+		return i.AgentCtrl().SetNamespaceOffloading(namespace, enable)
+	})
+	if err != nil {
+		//the check mark is restored on the current state
+		refreshNamespaceOffloading(i)
+		activity.GetFeed().Add(activitySourceOffloading, "Offloading of namespace "+namespace+" not "+verb,
+			activity.OutcomeFailure)
+		i.ShowClientError("Liqo Agent: NAMESPACE OFFLOADING NOT CHANGED", err)
+		return
+	}
+	activity.GetFeed().Add(activitySourceOffloading, "Offloading of namespace "+namespace+" "+verb,
+		activity.OutcomeSuccess)
+	i.Notify("Liqo Agent", "The offloading of namespace "+namespace+" has been "+verb, app.NotifyIconDefault,
+		app.IconLiqoNil)
+}
@@ -0,0 +1,60 @@
+package logic
+
+import (
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strings"
+)
+
+//activitySourceOffers is the activity.Entry source of the changes of the resource offers.
+const activitySourceOffers = "offers"
+
+//listenOfferChanged is the callback notifying the user when a peer changes the resources it offers,
+//with a readable description of the differences.
+func listenOfferChanged(data client.NotifyDataGeneric, _ ...interface{}) {
+	offer, ok := data.(*client.NotifyDataOfferChanged)
+	if !ok {
+		return
+	}
+	i := app.GetIndicator()
+	peer := peerName(i.Status(), offer.ClusterID)
+	msg := offerChangeMessage(offer.Changes)
+	activity.GetFeed().Add(activitySourceOffers, fmt.Sprintf("%s changed its resource offer: %s", peer,
+		strings.Replace(msg, "\n", "; ", -1)), activity.OutcomeInfo)
+	n := app.Notification{
+		ID:       "offer/" + offer.ClusterID,
+		Title:    "Liqo Agent: " + peer + " CHANGED ITS OFFER",
+		Message:  msg,
+		Severity: app.SeverityInfo,
+		Category: app.CategoryResources,
+		Target:   app.NotificationTarget{Kind: "ForeignCluster", Name: offer.ClusterID},
+	}
+	if offerShrunk(offer.Changes) {
+		n.Severity = app.SeverityWarning
+		n = n.WithTrayIcon(app.IconLiqoWarning)
+	}
+	i.ShowNotification(n)
+	//the offloading warnings depend on the StorageClasses offered by the peers
+	listenStorageChanged(nil)
+}
+
+//offerChangeMessage returns the notification text for a set of client.OfferChange, one per line.
+func offerChangeMessage(changes []*client.OfferChange) string {
+	lines := make([]string, 0, len(changes))
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+//offerShrunk returns whether any of the changes reduces the resources offered by a peer.
+func offerShrunk(changes []*client.OfferChange) bool {
+	for _, c := range changes {
+		if c.Kind == client.OfferDecreased || c.Kind == client.OfferRemoved {
+			return true
+		}
+	}
+	return false
+}
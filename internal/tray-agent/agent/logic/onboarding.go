@@ -0,0 +1,219 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"sync"
+	"time"
+)
+
+/*This file contains the live onboarding checklist of the last outgoing peering started from the tray menu. The
+ACTION "Onboarding <peer>" lists the steps of the peering (see client.OnboardingChecklist), refreshed until all of
+them are completed, e.g.
+	✔ Authentication: identity accepted by the peer
+	✔ Resources negotiated: Advertisement accepted
+	… Network established: network parameters exchanged, waiting for the tunnel
+	… Virtual node ready: waiting for the virtual node
+	… Test pod schedulable: waiting for the virtual node
+Clicking a step displays its remediation hint.*/
+
+const (
+	//aOnboarding is the tag of the ACTION displaying the onboarding checklist.
+	aOnboarding = "A_ONBOARDING"
+	//oDismissOnboarding is the tag of the OPTION hiding the onboarding checklist.
+	oDismissOnboarding = "O_DISMISS_ONBOARDING"
+	//tOnboarding is the tag of the Timer refreshing the onboarding checklist.
+	tOnboarding = "T_ONBOARDING"
+	//onboardingRefreshInterval is the interval between two refreshes of the onboarding checklist.
+	onboardingRefreshInterval = 3 * time.Second
+	//onboardingTimeout is the time after which an onboarding not completed is reported.
+	onboardingTimeout = 10 * time.Minute
+	//tagOnboardingStepPrefix precedes the name of a step in the tag of its entry.
+	tagOnboardingStepPrefix = "step/"
+	//notificationOnboardingPrefix precedes the name of the ForeignCluster in the ID of the onboarding Notifications.
+	notificationOnboardingPrefix = "onboarding/"
+	//activitySourceOnboarding is the activity.Feed source of the onboarding outcomes.
+	activitySourceOnboarding = "onboarding"
+)
+
+//onboarding contains the state of the tracked onboarding checklist.
+var onboarding = struct {
+	sync.Mutex
+	//fcName is the name of the ForeignCluster of the tracked peer, empty if none.
+	fcName string
+	//name is the name of the peer displayed to the user.
+	name    string
+	started time.Time
+	//done specifies whether the onboarding completed or timed out.
+	done bool
+	//steps contains the last evaluated steps, indexed by name.
+	steps map[string]client.OnboardingStep
+	//reported contains the failed steps already notified.
+	reported map[string]bool
+}{}
+
+//onboardingStepSymbols contains the symbols preceding the steps, by state.
+var onboardingStepSymbols = map[client.OnboardingState]string{
+	client.OnboardingPending: "…",
+	client.OnboardingPassed:  "✔",
+	client.OnboardingFailed:  "✘",
+}
+
+//startActionOnboarding is the wrapper function to register the ACTION "Onboarding <peer>", hidden until a
+//peering is started.
+func startActionOnboarding(i *app.Indicator) {
+	action := i.AddAction("", aOnboarding, nil)
+	action.AddOption("Dismiss", oDismissOnboarding, "Hide the onboarding checklist", false,
+		app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			stopOnboarding(e.Indicator)
+		}))
+	action.SetIsVisible(false)
+	_ = i.StartTimer(tOnboarding, onboardingRefreshInterval, func(args ...interface{}) {
+		refreshOnboarding(i)
+	})
+	if timer, present := i.Timer(tOnboarding); present {
+		timer.SetActive(false)
+	}
+}
+
+//startOnboarding starts tracking the onboarding checklist of the outgoing peering with a peer, replacing the
+//previous one.
+func startOnboarding(i *app.Indicator, fcName string, name string) {
+	action, present := i.Action(aOnboarding)
+	if !present {
+		return
+	}
+	onboarding.Lock()
+	for step := range onboarding.steps {
+		action.FreeListChild(tagOnboardingStepPrefix + step)
+	}
+	onboarding.fcName, onboarding.name, onboarding.started, onboarding.done = fcName, name, time.Now(), false
+	onboarding.steps = make(map[string]client.OnboardingStep)
+	onboarding.reported = make(map[string]bool)
+	onboarding.Unlock()
+	action.SetIsVisible(true)
+	if timer, present := i.Timer(tOnboarding); present {
+		timer.SetActive(true)
+	}
+	refreshOnboarding(i)
+}
+
+//stopOnboarding stops tracking the onboarding checklist and hides it.
+func stopOnboarding(i *app.Indicator) {
+	if timer, present := i.Timer(tOnboarding); present {
+		timer.SetActive(false)
+	}
+	onboarding.Lock()
+	onboarding.fcName, onboarding.done = "", true
+	onboarding.Unlock()
+	if action, present := i.Action(aOnboarding); present {
+		action.SetIsVisible(false)
+	}
+}
+
+//refreshOnboarding evaluates the steps of the tracked onboarding checklist and updates its ACTION, notifying the
+//completion, the failed steps and the timeout of the onboarding.
+func refreshOnboarding(i *app.Indicator) {
+	action, present := i.Action(aOnboarding)
+	if !present || !i.AgentCtrl().Connected() {
+		return
+	}
+	onboarding.Lock()
+	defer onboarding.Unlock()
+	if onboarding.fcName == "" || onboarding.done {
+		return
+	}
+	fcName, name := onboarding.fcName, onboarding.name
+	steps, err := i.AgentCtrl().OnboardingChecklist(i.Context(), fcName)
+	if err != nil {
+		//the peer is no longer available
+		action.SetTitle("Onboarding " + name + ": peer removed")
+		onboarding.done = true
+		return
+	}
+	passed := 0
+	for _, step := range steps {
+		onboarding.steps[step.Name] = step
+		if step.State == client.OnboardingPassed {
+			passed++
+		}
+		tag := tagOnboardingStepPrefix + step.Name
+		entry, present := action.ListChild(tag)
+		if !present {
+			entry = action.UseListChild("", tag)
+			stepName := step.Name
+			entry.Connect(false, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+				showOnboardingHint(stepName)
+			}))
+		}
+		entry.SetTitle(onboardingStepTitle(step))
+	}
+	completed, failed := client.OnboardingDone(steps)
+	notificationID := notificationOnboardingPrefix + fcName
+	switch {
+	case completed:
+		onboarding.done = true
+		action.SetTitle("Onboarding " + name + ": ready")
+		i.DismissNotification(notificationID)
+		activity.GetFeed().Add(activitySourceOnboarding, "Peering with "+name+" ready", activity.OutcomeSuccess)
+		i.Notify("Liqo Agent", "The peering with "+name+" is ready to host pods", app.NotifyIconDefault,
+			app.IconLiqoNil)
+	case failed:
+		action.SetTitle(fmt.Sprintf("Onboarding %s: %d/%d steps, failing", name, passed, len(steps)))
+		for _, step := range steps {
+			if step.State != client.OnboardingFailed || onboarding.reported[step.Name] {
+				continue
+			}
+			onboarding.reported[step.Name] = true
+			activity.GetFeed().Add(activitySourceOnboarding, "Peering with "+name+": "+step.Name+" failed",
+				activity.OutcomeFailure)
+			i.ShowNotification(app.Notification{
+				ID:       notificationID,
+				Title:    "Liqo Agent: PEERING ONBOARDING FAILED",
+				Message:  fmt.Sprintf("%s: %s. %s", step.Name, step.Detail, step.Hint),
+				Severity: app.SeverityWarning,
+				Category: app.CategoryPeering,
+				Target:   app.NotificationTarget{Kind: "ForeignCluster", Name: fcName},
+			})
+		}
+	case time.Since(onboarding.started) > onboardingTimeout:
+		onboarding.done = true
+		action.SetTitle(fmt.Sprintf("Onboarding %s: %d/%d steps, stalled", name, passed, len(steps)))
+		activity.GetFeed().Add(activitySourceOnboarding, "Peering with "+name+" not ready within "+
+			onboardingTimeout.String(), activity.OutcomeFailure)
+		i.ShowWarning("Liqo Agent: PEERING ONBOARDING STALLED", fmt.Sprintf("The peering with %s is not ready "+
+			"after %s: open its onboarding checklist for the remediation hints", name, onboardingTimeout))
+	default:
+		action.SetTitle(fmt.Sprintf("Onboarding %s: %d/%d steps", name, passed, len(steps)))
+	}
+	if onboarding.done {
+		if timer, present := i.Timer(tOnboarding); present {
+			timer.SetActive(false)
+		}
+	}
+}
+
+//onboardingStepTitle returns the title of the entry of a step of the onboarding checklist, e.g.
+//	✔ Authentication: identity accepted by the peer
+func onboardingStepTitle(step client.OnboardingStep) string {
+	return fmt.Sprintf("%s %s: %s", onboardingStepSymbols[step.State], step.Name, step.Detail)
+}
+
+//showOnboardingHint displays the state and the remediation hint of a step of the onboarding checklist.
+func showOnboardingHint(stepName string) {
+	onboarding.Lock()
+	step, present := onboarding.steps[stepName]
+	onboarding.Unlock()
+	if !present || app.GetGuiProvider().Mocked() {
+		return
+	}
+	text := onboardingStepTitle(step)
+	if step.Hint != "" {
+		text += "\n\n" + step.Hint
+	}
+	_, _ = dlgs.Info("Liqo Agent: PEERING ONBOARDING", text)
+}
@@ -0,0 +1,282 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/format"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/metrics"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Operations on the cluster started from the tray menu, executed with a time limit by runOperation.
+const (
+	opPeering            = "peering"
+	opRefreshCredentials = "credentials"
+	opDashboard          = "dashboard"
+	opUninstallReport    = "uninstallReport"
+	opStopPeerings       = "stopPeerings"
+	opDisableOffloading  = "disableOffloading"
+	opPeerDiagnostics    = "peerDiagnostics"
+	opSwitchContext      = "switchContext"
+	opSwitchKubeconfig   = "switchKubeconfig"
+	opPeerCredentials    = "peerCredentials"
+	opPeeringApproval    = "peeringApproval"
+	opEnableOffloading   = "enableOffloading"
+	opResourceSharing    = "resourceSharing"
+	opReloadKubeconfig   = "reloadKubeconfig"
+)
+
+const (
+	//activitySourceOperations is the activity.Feed source of the stuck operations.
+	activitySourceOperations = "operations"
+	//pendingStuckPrefix precedes the name of a stuck operation in the ID of its app.PendingItem.
+	pendingStuckPrefix = "stuck/"
+	//defaultOperationTimeout is the time limit of the operations without a specific one.
+	defaultOperationTimeout = 30 * time.Second
+)
+
+//operationTimeouts contains the default time limits of the operations taking longer than defaultOperationTimeout.
+var operationTimeouts = map[string]time.Duration{
+	//the peering command is retried with backoff
+	opPeering:           time.Minute,
+	opStopPeerings:      2 * time.Minute,
+	opDisableOffloading: 2 * time.Minute,
+	opEnableOffloading:  2 * time.Minute,
+	opPeerDiagnostics:   2 * time.Minute,
+	//the credential helpers may wait for the user to sign in
+	opPeerCredentials: 2 * time.Minute,
+}
+
+//stuckGrace is the time an operation is given to return after its context expired, before being considered stuck.
+//The operations aware of the context return in the meantime with a timeout error.
+var stuckGrace = time.Second
+
+//operationDescriptions contains the descriptions of the operations displayed to the user.
+var operationDescriptions = map[string]string{
+	opPeering:            "Peering command",
+	opRefreshCredentials: "Credentials refresh",
+	opDashboard:          "LiqoDash connection",
+	opUninstallReport:    "Uninstallation check",
+	opStopPeerings:       "Peerings teardown",
+	opDisableOffloading:  "Offloading teardown",
+	opPeerDiagnostics:    "Remote diagnostics collection",
+	opSwitchContext:      "Context switch",
+	opSwitchKubeconfig:   "Kubeconfig switch",
+	opPeerCredentials:    "Peer credentials retrieval",
+	opPeeringApproval:    "Peering request approval",
+	opEnableOffloading:   "Offloading activation",
+	opResourceSharing:    "Resource sharing change",
+	opReloadKubeconfig:   "Kubeconfig reload",
+}
+
+//runningOperation is an operation currently executed by runOperation.
+type runningOperation struct {
+	name    string
+	started time.Time
+	timeout time.Duration
+	//stuck specifies whether the operation exceeded its time limit.
+	stuck bool
+}
+
+//operations contains the operations currently executed by runOperation.
+var operations = struct {
+	running map[int]*runningOperation
+	next    int
+	sync.Mutex
+}{running: make(map[int]*runningOperation)}
+
+//operationTimeout returns the time limit of an operation: the configured one, if any, or its default one.
+func operationTimeout(name string) time.Duration {
+	conf, _ := client.GetLocalConfig()
+	if timeout := conf.GetOperationTimeout(name); timeout > 0 {
+		return timeout
+	}
+	if timeout, present := operationTimeouts[name]; present {
+		return timeout
+	}
+	return defaultOperationTimeout
+}
+
+//operationDescription returns the description of an operation displayed to the user.
+func operationDescription(name string) string {
+	if description, present := operationDescriptions[name]; present {
+		return description
+	}
+	return name
+}
+
+/*runOperation executes an operation on the cluster started from the tray menu, limiting its duration.
+The ctx passed to op expires after the time limit of the operation (see operationTimeout).
+
+If op does not return in time, runOperation returns a client.ErrTimeout error without waiting for it: the operation
+is then reported as stuck in the pending items and in the activity feed, with the diagnostics collected at the
+moment, until it eventually returns.*/
+func runOperation(ctx context.Context, i *app.Indicator, name string, op func(ctx context.Context) error) error {
+	timeout := operationTimeout(name)
+	opCtx, cancel := context.WithTimeout(ctx, timeout)
+	result := make(chan error, 1)
+	id := startOperation(name, timeout)
+	go func() {
+		result <- op(opCtx)
+	}()
+	select {
+	case err := <-result:
+		finishOperation(id)
+		cancel()
+		return err
+	case <-opCtx.Done():
+	}
+	select {
+	case err := <-result:
+		finishOperation(id)
+		cancel()
+		return err
+	case <-time.After(stuckGrace):
+	}
+	if ctx.Err() != nil {
+		//the caller gave up on the operation (e.g. the Indicator is quitting): it is not reported
+		go func() {
+			<-result
+			finishOperation(id)
+		}()
+		cancel()
+		return ctx.Err()
+	}
+	reportStuckOperation(i, id)
+	go func() {
+		err := <-result
+		cancel()
+		if elapsed, stuck := finishOperation(id); stuck {
+			recoverStuckOperation(i, name, elapsed, err)
+		}
+	}()
+	return &client.Error{Class: client.ErrTimeout, Op: operationDescription(name),
+		Err: fmt.Errorf("no reply within %s", timeout)}
+}
+
+//startOperation records the start of an operation, returning its identifier.
+func startOperation(name string, timeout time.Duration) int {
+	operations.Lock()
+	defer operations.Unlock()
+	operations.next++
+	operations.running[operations.next] = &runningOperation{name: name, started: time.Now(), timeout: timeout}
+	return operations.next
+}
+
+//finishOperation records the end of an operation, returning its duration and whether it was stuck.
+func finishOperation(id int) (elapsed time.Duration, stuck bool) {
+	operations.Lock()
+	defer operations.Unlock()
+	op, present := operations.running[id]
+	if !present {
+		return 0, false
+	}
+	delete(operations.running, id)
+	elapsed = time.Since(op.started)
+	metrics.GetRegistry().Timer("operation_seconds", "Duration of the operations started from the tray menu",
+		metrics.Labels{"operation": op.name}).Observe(elapsed)
+	return elapsed, op.stuck
+}
+
+//reportStuckOperation marks an operation as stuck, recording it in the activity feed and registering it as a
+//pending problem. Selecting the problem displays the diagnostics of the operation.
+func reportStuckOperation(i *app.Indicator, id int) {
+	operations.Lock()
+	op, present := operations.running[id]
+	if present {
+		op.stuck = true
+	}
+	operations.Unlock()
+	if !present {
+		return
+	}
+	description := operationDescription(op.name)
+	title := fmt.Sprintf("%s stuck for more than %s", description, op.timeout)
+	logger.Warning("operation stuck", "operation", op.name, "timeout", op.timeout)
+	activity.GetFeed().Add(activitySourceOperations, title, activity.OutcomeFailure)
+	pendingID := pendingStuckPrefix + op.name
+	i.Pending().Add(app.PendingItem{
+		ID:    pendingID,
+		Kind:  app.PendingProblem,
+		Title: title,
+		Action: app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			e.Indicator.ShowError(fmt.Sprintf("LIQO AGENT: %s", strings.ToUpper(description)+" STUCK"),
+				operationDiagnostics(e.Indicator, id))
+		}),
+	})
+}
+
+//recoverStuckOperation records the completion of an operation previously reported as stuck.
+func recoverStuckOperation(i *app.Indicator, name string, elapsed time.Duration, err error) {
+	if !stillStuck(name) {
+		i.Pending().Remove(pendingStuckPrefix + name)
+	}
+	msg := fmt.Sprintf("%s completed after %s", operationDescription(name), format.Duration(elapsed))
+	outcome := activity.OutcomeSuccess
+	if err != nil {
+		msg = fmt.Sprintf("%s failed after %s: %v", operationDescription(name), format.Duration(elapsed), err)
+		outcome = activity.OutcomeFailure
+	}
+	activity.GetFeed().Add(activitySourceOperations, msg, outcome)
+}
+
+//stillStuck returns whether another execution of an operation is stuck.
+func stillStuck(name string) bool {
+	operations.Lock()
+	defer operations.Unlock()
+	for _, op := range operations.running {
+		if op.name == name && op.stuck {
+			return true
+		}
+	}
+	return false
+}
+
+//operationDiagnostics returns the description of a stuck operation and of the state of the Agent, helping to
+//understand why the operation does not complete.
+func operationDiagnostics(i *app.Indicator, id int) string {
+	str := strings.Builder{}
+	operations.Lock()
+	if op, present := operations.running[id]; present {
+		str.WriteString(fmt.Sprintf("Operation: %s\n", operationDescription(op.name)))
+		str.WriteString(fmt.Sprintf("Started: %s (running for %s)\n", op.started.Format("15:04:05"),
+			format.Duration(time.Since(op.started))))
+		str.WriteString(fmt.Sprintf("Time limit: %s\n", op.timeout))
+	} else {
+		str.WriteString("The operation has completed in the meantime.\n")
+	}
+	var others []string
+	for otherID, op := range operations.running {
+		if otherID != id {
+			others = append(others, fmt.Sprintf("%s (%s)", operationDescription(op.name),
+				format.Duration(time.Since(op.started))))
+		}
+	}
+	operations.Unlock()
+	sort.Strings(others)
+	ctrl := i.AgentCtrl()
+	switch {
+	case !ctrl.Connected():
+		str.WriteString("Cluster: not connected\n")
+	case !ctrl.Reachable():
+		str.WriteString("Cluster: unreachable\n")
+	default:
+		str.WriteString("Cluster: connected\n")
+	}
+	str.WriteString(fmt.Sprintf("Caches: %s\n", ctrl.CacheSyncProgress()))
+	if polled := ctrl.PolledResources(); len(polled) > 0 {
+		str.WriteString("Polled (watch not permitted): " + strings.Join(polled, ", ") + "\n")
+	}
+	if len(others) > 0 {
+		str.WriteString("Other running operations: " + strings.Join(others, ", ") + "\n")
+	}
+	str.WriteString(fmt.Sprintf("Goroutines: %d", runtime.NumGoroutine()))
+	return str.String()
+}
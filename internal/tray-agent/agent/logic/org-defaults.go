@@ -0,0 +1,68 @@
+package logic
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"time"
+)
+
+const (
+	//activitySourceOrgDefaults is the activity.Feed source of the acquisition of the organization-wide defaults.
+	activitySourceOrgDefaults = "orgDefaults"
+	//defaultHelpURL is the page opened by the "Help" QUICK, unless branded.
+	defaultHelpURL = "https://doc.liqo.io/"
+)
+
+//loadOrgDefaults acquires the organization-wide defaults from the cluster, if enabled, so that they complete the
+//local configuration before the Indicator is configured. The settings already applied (e.g. the icon theme) are
+//refreshed.
+func loadOrgDefaults(i *app.Indicator) {
+	found, err := i.AgentCtrl().LoadOrgDefaults()
+	if err != nil {
+		logger.Warning("cannot acquire the organization defaults", "err", err)
+		activity.GetFeed().Add(activitySourceOrgDefaults, "Organization defaults not applied: "+err.Error(),
+			activity.OutcomeFailure)
+		return
+	}
+	conf, _ := client.GetLocalConfig()
+	if found {
+		activity.GetFeed().Add(activitySourceOrgDefaults, "Organization defaults applied", activity.OutcomeSuccess)
+		i.SetIconTheme(app.ParseIconTheme(conf.GetIconTheme()))
+		i.SetColorScheme(app.ParseColorScheme(conf.GetColorScheme()))
+	}
+	if title := conf.GetBranding().Title; title != "" {
+		i.SetMenuTitle(title)
+	}
+}
+
+//helpURL returns the page opened by the "Help" QUICK.
+func helpURL() string {
+	conf, _ := client.GetLocalConfig()
+	if url := conf.GetBranding().HelpURL; url != "" {
+		return url
+	}
+	return defaultHelpURL
+}
+
+//intervals returns the configured periods of the checks performed by the Agent.
+func intervals() client.IntervalsConfig {
+	conf, _ := client.GetLocalConfig()
+	return conf.GetIntervals()
+}
+
+//configureRefreshInterval applies the configured minimum interval between two refreshes of the status and of the
+//tray label, if any.
+func configureRefreshInterval(i *app.Indicator) {
+	if interval := intervals().Refresh; interval > 0 {
+		i.SetRefreshInterval(interval)
+	}
+}
+
+//configuredInterval returns the configured interval, or fallback if not set.
+func configuredInterval(configured time.Duration, fallback time.Duration) time.Duration {
+	if configured <= 0 {
+		return fallback
+	}
+	return configured
+}
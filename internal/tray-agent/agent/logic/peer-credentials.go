@@ -0,0 +1,32 @@
+package logic
+
+import (
+	"context"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+)
+
+//activitySourceCredentialHelpers is the activity.Feed source of the credentials provided by the credential helpers.
+const activitySourceCredentialHelpers = "credentialHelpers"
+
+//providePeerCredentials runs the credential helper matching a peer (see client.CredentialHelper), if any, before
+//starting an outgoing peering towards it. It returns whether the peering can be started.
+func providePeerCredentials(ctx context.Context, i *app.Indicator, fcName string) bool {
+	var helper string
+	err := runOperation(ctx, i, opPeerCredentials, func(ctx context.Context) error {
+		var err error
+		helper, err = i.AgentCtrl().ProvidePeerCredentials(ctx, fcName)
+		return err
+	})
+	if err != nil {
+		activity.GetFeed().Add(activitySourceCredentialHelpers, "Credential helper "+helper+" failed for "+fcName,
+			activity.OutcomeFailure)
+		i.ShowClientError("Liqo Agent: PEER AUTHENTICATION FAILED", err)
+		return false
+	}
+	if helper != "" {
+		activity.GetFeed().Add(activitySourceCredentialHelpers, "Credential of "+fcName+" provided by "+helper,
+			activity.OutcomeSuccess)
+	}
+	return true
+}
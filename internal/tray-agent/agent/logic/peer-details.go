@@ -0,0 +1,157 @@
+package logic
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/format"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"sync"
+	"time"
+)
+
+/*This file contains the PEERING DETAILS entry of each peer with an active peering, expanding into:
+-	the ClusterID of the peer
+-	the virtual node created for the outgoing peering
+-	the resources acquired from the peer and the ones shared with it
+-	the latency towards the peer, periodically measured
+*/
+
+const (
+	//titlePeerDetails is the title of the entry of a peer displaying the details of its peerings.
+	titlePeerDetails = "PEERING DETAILS"
+	//tagPeerDetails is the tag of the entry of a peer displaying the details of its peerings.
+	tagPeerDetails = "details"
+	//tPeerLatency is the tag of the Timer measuring the latency towards the peers.
+	tPeerLatency = "T_PEER_LATENCY"
+	//peerLatencyInterval is the default period of the measurement of the latency towards the peers.
+	peerLatencyInterval = time.Minute
+)
+
+//tags of the rows of the PEERING DETAILS entry.
+const (
+	tagDetailsClusterID   = "clusterID"
+	tagDetailsVirtualNode = "virtualNode"
+	tagDetailsAcquired    = "acquired"
+	tagDetailsShared      = "shared"
+	tagDetailsLatency     = "latency"
+)
+
+//peerLatencies contains the last latency measured towards each peer, already formatted, by ClusterID.
+var peerLatencies = struct {
+	data map[string]string
+	sync.Mutex
+}{data: make(map[string]string)}
+
+//createPeerDetails adds the PEERING DETAILS entry to the entry of a peer, hidden until a peering is active.
+func createPeerDetails(peerNode *app.MenuNode) {
+	details := peerNode.UseListChild(peerDataIndentation+titlePeerDetails, tagPeerDetails)
+	details.SetIsVisible(false)
+	for _, tag := range []string{tagDetailsClusterID, tagDetailsVirtualNode, tagDetailsAcquired, tagDetailsShared,
+		tagDetailsLatency} {
+		details.UseListChild("", tag).SetIsEnabled(false)
+	}
+}
+
+//refreshPeerDetails refreshes the content of the PEERING DETAILS entry of a peer.
+func refreshPeerDetails(peerNode *app.MenuNode, data *client.NotifyDataForeignCluster, wg *sync.WaitGroup) {
+	defer wg.Done()
+	details, present := peerNode.ListChild(tagPeerDetails)
+	if !present {
+		return
+	}
+	active := data.OutPeering.Connected || data.InPeering.Connected
+	details.SetIsVisible(active)
+	if !active {
+		return
+	}
+	setDetailsRow(details, tagDetailsClusterID, "Cluster ID: "+data.ClusterID, true)
+	virtualNode := data.OutPeering.VirtualNode
+	if virtualNode == "" {
+		virtualNode = labelResourceQuotaUnavailable
+	}
+	setDetailsRow(details, tagDetailsVirtualNode, "Virtual node: "+virtualNode, data.OutPeering.Connected)
+	setDetailsRow(details, tagDetailsAcquired, "Acquired: "+describeQuota(data.OutPeering.CpuQuota,
+		data.OutPeering.MemQuota), data.OutPeering.Connected)
+	setDetailsRow(details, tagDetailsShared, "Shared: "+describeQuota(data.InPeering.CpuQuota,
+		data.InPeering.MemQuota), data.InPeering.Connected)
+	peerLatencies.Lock()
+	latency, measured := peerLatencies.data[data.ClusterID]
+	peerLatencies.Unlock()
+	if !measured {
+		latency = "measuring…"
+		go measurePeerLatency(app.GetIndicator(), data.ClusterID, data.Name)
+	}
+	setDetailsRow(details, tagDetailsLatency, "Latency: "+latency, true)
+}
+
+//setDetailsRow sets the title and the visibility of a row of the PEERING DETAILS entry.
+func setDetailsRow(details *app.MenuNode, tag string, title string, visible bool) {
+	row, present := details.ListChild(tag)
+	if !present {
+		return
+	}
+	row.SetTitle(peerDataIndentation + title)
+	row.SetIsVisible(visible)
+}
+
+//describeQuota returns the description of the CPU and memory quotas shared in a peering, e.g. "2.0 CPU, 4.0Gi RAM".
+func describeQuota(cpu string, mem string) string {
+	if cpu == "" && mem == "" {
+		return labelResourceQuotaUnavailable
+	}
+	if cpu == "" {
+		cpu = labelResourceQuotaUnavailable
+	} else {
+		cpu = format.CPUQuantity(cpu)
+	}
+	if mem == "" {
+		mem = labelResourceQuotaUnavailable
+	} else {
+		mem = format.MemoryQuantity(mem)
+	}
+	return cpu + ", " + mem + " RAM"
+}
+
+//startPeerLatencyProbe periodically measures the latency towards the peers with an active peering.
+func startPeerLatencyProbe(i *app.Indicator) {
+	interval := configuredInterval(intervals().Latency, peerLatencyInterval)
+	_ = i.StartTimer(tPeerLatency, interval, func(args ...interface{}) {
+		renderedPeers.Lock()
+		peers := make([]client.NotifyDataForeignCluster, 0, len(renderedPeers.data))
+		for _, data := range renderedPeers.data {
+			if data.OutPeering.Connected || data.InPeering.Connected {
+				peers = append(peers, data)
+			}
+		}
+		renderedPeers.Unlock()
+		for _, data := range peers {
+			measurePeerLatency(i, data.ClusterID, data.Name)
+		}
+	})
+}
+
+//measurePeerLatency measures the latency towards a peer and displays it in its PEERING DETAILS entry.
+func measurePeerLatency(i *app.Indicator, clusterID string, foreignCluster string) {
+	latency := labelResourceQuotaUnavailable
+	if d, err := i.AgentCtrl().PeerLatency(i.Context(), foreignCluster); err == nil {
+		latency = format.Duration(d)
+	}
+	peerLatencies.Lock()
+	peerLatencies.data[clusterID] = latency
+	peerLatencies.Unlock()
+	quick, present := i.Quick(qPeers)
+	if !present {
+		return
+	}
+	if peerNode, present := findPeerEntry(quick, clusterID); present {
+		if details, present := peerNode.ListChild(tagPeerDetails); present {
+			setDetailsRow(details, tagDetailsLatency, "Latency: "+latency, true)
+		}
+	}
+}
+
+//forgetPeerLatency removes the latency measured towards a peer, after its entry has been removed.
+func forgetPeerLatency(clusterID string) {
+	peerLatencies.Lock()
+	defer peerLatencies.Unlock()
+	delete(peerLatencies.data, clusterID)
+}
@@ -0,0 +1,112 @@
+package logic
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/redact"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/*This file contains the "Collect remote diagnostics" action of the peer entries, bundling the client.PeerDiagnostics
+of a peer into a compressed archive to be attached to the reports of the peering issues. Since the archive is meant to
+leave the machine, its content is scrubbed by the redaction layer.*/
+
+const (
+	//titlePeerDiagnostics is the title of the peer entry collecting its remote diagnostics.
+	titlePeerDiagnostics = "Collect remote diagnostics…"
+	//activitySourceDiagnostics is the activity.Feed source of the diagnostics collections.
+	activitySourceDiagnostics = "diagnostics"
+)
+
+//peerDiagnosticsHandler is the app.ClickHandler collecting the remote diagnostics of a peer.
+type peerDiagnosticsHandler struct {
+	peer *app.PeerInfo
+}
+
+//HandleClick implements the app.ClickHandler interface.
+func (h *peerDiagnosticsHandler) HandleClick(ctx context.Context, e *app.ClickEvent) {
+	h.peer.RLock()
+	fcName := h.peer.ForeignClusterResourceName
+	clusterID := h.peer.ClusterID
+	h.peer.RUnlock()
+	recordLastPeer(e.Indicator, clusterID)
+	if app.GetGuiProvider().Mocked() {
+		return
+	}
+	dir, ok, _ := dlgs.File("Select the destination folder", "", true)
+	if !ok {
+		return
+	}
+	path, err := collectPeerDiagnostics(ctx, e.Indicator, fcName, dir)
+	if err != nil {
+		activity.GetFeed().Add(activitySourceDiagnostics, "Diagnostics collection of "+clusterID+" failed",
+			activity.OutcomeFailure)
+		e.Indicator.ShowClientError("Liqo Agent: DIAGNOSTICS COLLECTION FAILED", err)
+		return
+	}
+	activity.GetFeed().Add(activitySourceDiagnostics, "Diagnostics of "+clusterID+" saved to "+path,
+		activity.OutcomeSuccess)
+	e.Indicator.Notify("Liqo Agent", "The remote diagnostics of the peer were saved to "+path,
+		app.NotifyIconDefault, app.IconLiqoNil)
+}
+
+//collectPeerDiagnostics collects the remote diagnostics of the peer described by a ForeignCluster and saves them
+//into a bundle inside dir, returning its path.
+func collectPeerDiagnostics(ctx context.Context, i *app.Indicator, fcName string, dir string) (string, error) {
+	var diagnostics *client.PeerDiagnostics
+	err := runOperation(ctx, i, opPeerDiagnostics, func(ctx context.Context) error {
+		var err error
+		diagnostics, err = i.AgentCtrl().CollectPeerDiagnostics(ctx, fcName, client.DefaultDiagnosticsLogLines)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("liqo-diagnostics-%s-%s.tar.gz", diagnostics.ClusterID,
+		i.Now().Format("20060102-150405")))
+	return path, writeDiagnosticsBundle(diagnostics, path, i.Now())
+}
+
+//writeDiagnosticsBundle saves the PeerDiagnostics into a gzip compressed tar archive, together with their summary.
+//The content of each file is redacted.
+func writeDiagnosticsBundle(d *client.PeerDiagnostics, path string, modTime time.Time) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	files := append([]client.DiagnosticsFile{{Name: "summary.txt", Data: []byte(d.Summary())}}, d.Files...)
+	for _, file := range files {
+		data := []byte(redact.String(string(file.Data)))
+		header := &tar.Header{
+			Name:    file.Name,
+			Mode:    0600,
+			Size:    int64(len(data)),
+			ModTime: modTime,
+		}
+		if err = tw.WriteHeader(header); err != nil {
+			break
+		}
+		if _, err = tw.Write(data); err != nil {
+			break
+		}
+	}
+	for _, closer := range []interface{ Close() error }{tw, gz, f} {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		_ = os.Remove(path)
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package logic
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"sync"
+)
+
+//peerChange is a bitmask of the parts of a peer entry in the tray menu affected by a change of its ForeignCluster.
+type peerChange int
+
+//peerChange values.
+const (
+	//peerChangeName affects the main entry of the peer.
+	peerChangeName peerChange = 1 << iota
+	//peerChangeStatus affects the STATUS child of the peer.
+	peerChangeStatus
+	//peerChangePeering affects the children describing the outgoing and incoming peerings.
+	peerChangePeering
+	//peerChangeAll affects the whole peer entry.
+	peerChangeAll = peerChangeName | peerChangeStatus | peerChangePeering
+)
+
+//renderedPeers contains, for each peer displayed in the tray menu, the data it has been rendered with.
+var renderedPeers = struct {
+	data map[string]client.NotifyDataForeignCluster
+	sync.Mutex
+}{data: make(map[string]client.NotifyDataForeignCluster)}
+
+//diffPeer returns the parts of a peer entry to refresh when its data change from old (nil for a new peer) to data.
+func diffPeer(old *client.NotifyDataForeignCluster, data *client.NotifyDataForeignCluster) peerChange {
+	if old == nil {
+		return peerChangeAll
+	}
+	var change peerChange
+	if old.ClusterName != data.ClusterName || old.LocalDiscovered != data.LocalDiscovered {
+		change |= peerChangeName
+	}
+	if old.ClusterID != data.ClusterID || old.Trusted != data.Trusted || old.AuthStatus != data.AuthStatus {
+		change |= peerChangeStatus
+	}
+	//the authentication status also enables the peering commands
+	if old.AuthStatus != data.AuthStatus || old.OutPeering != data.OutPeering || old.InPeering != data.InPeering {
+		change |= peerChangePeering
+	}
+	return change
+}
+
+//peerRenderChange records the data a peer entry is going to be rendered with, returning the parts of the entry
+//to refresh. If rebuilt is true, the entry has just been created and it is entirely refreshed.
+func peerRenderChange(data *client.NotifyDataForeignCluster, rebuilt bool) peerChange {
+	renderedPeers.Lock()
+	defer renderedPeers.Unlock()
+	old, present := renderedPeers.data[data.ClusterID]
+	renderedPeers.data[data.ClusterID] = *data
+	if !present || rebuilt {
+		return peerChangeAll
+	}
+	return diffPeer(&old, data)
+}
+
+//forgetRenderedPeer removes the data a peer entry has been rendered with, after the entry has been removed.
+func forgetRenderedPeer(clusterID string) {
+	renderedPeers.Lock()
+	defer renderedPeers.Unlock()
+	delete(renderedPeers.data, clusterID)
+}
+
+//refreshPeerEntry refreshes the parts of a peer entry affected by change, leaving the other ones untouched.
+func refreshPeerEntry(peerNode *app.MenuNode, peer *app.PeerInfo, data *client.NotifyDataForeignCluster,
+	change peerChange) {
+	wg := &sync.WaitGroup{}
+	if change&peerChangeName != 0 {
+		wg.Add(1)
+		go refreshPeerName(peerNode, peer, data, wg)
+	}
+	if change&peerChangeStatus != 0 {
+		wg.Add(1)
+		go refreshPeerStatus(peerNode, data, wg)
+	}
+	if change&peerChangePeering != 0 {
+		wg.Add(1)
+		go refreshPeeringInfo(peerNode, peer, data, wg)
+		wg.Add(1)
+		go refreshPeerDetails(peerNode, data, wg)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,298 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"sort"
+	"sync"
+)
+
+/*This file contains the grouping of the peers list. When a grouping label is configured (e.g. the region of the
+clusters), the entries of the peers are not listed directly in the QUICK qPeers, but in the collapsed submenus of
+their groups, whose headers display aggregate counts:
+
+	Peers (5)
+	├── eu-west (3 peers, 1 peered)
+	│	├── cluster-a
+	│	└── ...
+	└── Other (2 peers, 0 peered)
+*/
+
+const (
+	//tagPeerGroupPrefix precedes the label value in the tag of a group entry of the peers list.
+	tagPeerGroupPrefix = "group/"
+	//titlePeerGroupOther is the title of the group of the peers missing the grouping label.
+	titlePeerGroupOther = "Other"
+	//titlePeerGrouping is the title of the QUICK selecting the grouping label.
+	titlePeerGrouping = "Group Peers By…"
+	//labelPeerGroupNone is the choice disabling the grouping of the peers list.
+	labelPeerGroupNone = "(no grouping)"
+)
+
+//peerGroups records, for each peer displayed in the peers list, the tag of the group entry containing it
+//(empty if the list is not grouped). It also serializes the changes to the structure of the list.
+var peerGroups = struct {
+	tags map[string]string
+	sync.Mutex
+}{tags: make(map[string]string)}
+
+//peerGroupLabel returns the ForeignCluster label the peers list is grouped by, empty if it is not grouped: the one
+//last selected by the user from the menu, if any, otherwise the configured one.
+func peerGroupLabel() string {
+	if label := client.GetMenuStateStore().State().PeerGroupLabel; label != nil {
+		return *label
+	}
+	conf, _ := client.GetLocalConfig()
+	return conf.GetPeerGroupLabel()
+}
+
+//peerGroupTag returns the tag of the group entry a peer belongs to, empty if the list is not grouped.
+func peerGroupTag(label string, data *client.NotifyDataForeignCluster) string {
+	if label == "" {
+		return ""
+	}
+	return tagPeerGroupPrefix + data.Labels[label]
+}
+
+//peerGroupParent returns the node containing the entries of the group identified by tag: the QUICK itself
+//if tag is empty, otherwise the group entry, created if needed.
+func peerGroupParent(quick *app.MenuNode, tag string) *app.MenuNode {
+	if tag == "" {
+		return quick
+	}
+	if node, present := quick.ListChild(tag); present {
+		return node
+	}
+	node := quick.UseListChild(tag, tag)
+	node.SetListPageSize(listPageSize())
+	node.SetListFilter(matchPeerSearch)
+	trackListPages(node, tag)
+	return node
+}
+
+//placePeerEntry returns the entry of a peer in the peers list, moving it if its group changed. If the returned
+//entry is not present, it has to be created (see createPeerNode) as a child of the returned parent.
+func placePeerEntry(quick *app.MenuNode, data *client.NotifyDataForeignCluster) (peerNode *app.MenuNode,
+	parent *app.MenuNode, present bool) {
+	tag := peerGroupTag(peerGroupLabel(), data)
+	peerGroups.Lock()
+	defer peerGroups.Unlock()
+	old, recorded := peerGroups.tags[data.ClusterID]
+	if recorded && old != tag {
+		//the peer changed group: its entry is rebuilt in the new one
+		peerGroupParent(quick, old).FreeListChild(data.ClusterID)
+		delete(peerGroups.tags, data.ClusterID)
+		refreshPeerGroup(quick, old)
+	}
+	parent = peerGroupParent(quick, tag)
+	peerGroups.tags[data.ClusterID] = tag
+	peerNode, present = parent.ListChild(data.ClusterID)
+	return
+}
+
+//findPeerEntry returns the entry of a peer, wherever it is in the peers list.
+func findPeerEntry(quick *app.MenuNode, clusterID string) (peerNode *app.MenuNode, present bool) {
+	peerGroups.Lock()
+	defer peerGroups.Unlock()
+	return peerEntry(quick, clusterID)
+}
+
+//peerEntry returns the entry of a peer, without creating its group entry. peerGroups must be locked.
+func peerEntry(quick *app.MenuNode, clusterID string) (peerNode *app.MenuNode, present bool) {
+	tag, recorded := peerGroups.tags[clusterID]
+	if !recorded {
+		return nil, false
+	}
+	if tag == "" {
+		return quick.ListChild(clusterID)
+	}
+	if group, present := quick.ListChild(tag); present {
+		return group.ListChild(clusterID)
+	}
+	return nil, false
+}
+
+//removePeerEntry removes the entry of a peer from the peers list, together with its group if left empty.
+func removePeerEntry(quick *app.MenuNode, clusterID string) {
+	peerGroups.Lock()
+	defer peerGroups.Unlock()
+	tag, recorded := peerGroups.tags[clusterID]
+	if !recorded {
+		return
+	}
+	delete(peerGroups.tags, clusterID)
+	if tag == "" {
+		quick.FreeListChild(clusterID)
+		return
+	}
+	if group, present := quick.ListChild(tag); present {
+		group.FreeListChild(clusterID)
+	}
+	refreshPeerGroup(quick, tag)
+}
+
+//peerEntriesLen returns the number of peer entries displayed in the peers list.
+func peerEntriesLen(quick *app.MenuNode) int {
+	peerGroups.Lock()
+	defer peerGroups.Unlock()
+	n := 0
+	for clusterID := range peerGroups.tags {
+		if _, present := peerEntry(quick, clusterID); present {
+			n++
+		}
+	}
+	return n
+}
+
+//peerGroupTags returns the sorted tags of the groups currently displayed. peerGroups must be locked.
+func peerGroupTags() []string {
+	set := make(map[string]bool)
+	for _, tag := range peerGroups.tags {
+		set[tag] = true
+	}
+	tags := make([]string, 0, len(set))
+	for tag := range set {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+//refreshPeerGroups refreshes the headers of all the groups of the peers list.
+func refreshPeerGroups(quick *app.MenuNode) {
+	peerGroups.Lock()
+	defer peerGroups.Unlock()
+	for _, tag := range peerGroupTags() {
+		refreshPeerGroup(quick, tag)
+	}
+}
+
+//refreshPeerGroup refreshes the header of a group of the peers list with the number of its peers and of the
+//peered ones, removing it once empty. peerGroups must be locked.
+func refreshPeerGroup(quick *app.MenuNode, tag string) {
+	if tag == "" {
+		return
+	}
+	group, present := quick.ListChild(tag)
+	if !present {
+		return
+	}
+	peers, peered := 0, 0
+	renderedPeers.Lock()
+	for clusterID, t := range peerGroups.tags {
+		if t != tag {
+			continue
+		}
+		peers++
+		if data, present := renderedPeers.data[clusterID]; present &&
+			(data.OutPeering.Connected || data.InPeering.Connected) {
+			peered++
+		}
+	}
+	renderedPeers.Unlock()
+	if peers == 0 {
+		quick.FreeListChild(tag)
+		return
+	}
+	group.SetTitle(peerGroupTitle(tag, peers, peered))
+}
+
+//peerGroupTitle returns the title of a group header, e.g. "eu-west (3 peers, 1 peered)".
+func peerGroupTitle(tag string, peers int, peered int) string {
+	name := tag[len(tagPeerGroupPrefix):]
+	if name == "" {
+		name = titlePeerGroupOther
+	}
+	noun := "peers"
+	if peers == 1 {
+		noun = "peer"
+	}
+	return fmt.Sprintf("%s (%d %s, %d peered)", name, peers, noun, peered)
+}
+
+//regroupPeers rebuilds the peers list according to the current grouping label.
+func regroupPeers(i *app.Indicator) {
+	quick, present := i.Quick(qPeers)
+	if !present {
+		return
+	}
+	renderedPeers.Lock()
+	rendered := make([]client.NotifyDataForeignCluster, 0, len(renderedPeers.data))
+	for _, data := range renderedPeers.data {
+		rendered = append(rendered, data)
+	}
+	renderedPeers.Unlock()
+	sortRenderedPeers(rendered)
+	peerGroups.Lock()
+	quick.FreeListChildren()
+	peerGroups.tags = make(map[string]string)
+	peerGroups.Unlock()
+	for n := range rendered {
+		data := &rendered[n]
+		peer, present := i.Status().Peer(data.ClusterID)
+		if !present {
+			continue
+		}
+		_, parent, _ := placePeerEntry(quick, data)
+		refreshPeerEntry(createPeerNode(parent, data, peer), peer, data, peerChangeAll)
+	}
+	refreshPeerGroups(quick)
+	refreshPeerSearch(quick)
+}
+
+//startQuickGroupPeers is the wrapper function to register QUICK "Group Peers By…".
+func startQuickGroupPeers(i *app.Indicator) {
+	i.AddQuick(titlePeerGrouping, qPeerGrouping, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		quickGroupPeers(i)
+	}))
+}
+
+//quickGroupPeers is the callback for the QUICK "Group Peers By…", choosing the grouping label among the ones
+//of the current peers.
+func quickGroupPeers(i *app.Indicator) {
+	if app.GetGuiProvider().Mocked() {
+		return
+	}
+	choices := append([]string{labelPeerGroupNone}, peerLabelKeys()...)
+	current := peerGroupLabel()
+	if current == "" {
+		current = labelPeerGroupNone
+	}
+	choice, ok, _ := dlgs.List("GROUP PEERS BY", fmt.Sprintf("Choose the label the peers are grouped by.\n"+
+		"CURRENT: %s", current), choices)
+	if !ok {
+		return
+	}
+	if choice == labelPeerGroupNone {
+		choice = ""
+	}
+	setPeerGroupLabel(i, choice)
+}
+
+//peerLabelKeys returns the sorted keys of the labels of the peers displayed in the peers list.
+func peerLabelKeys() []string {
+	renderedPeers.Lock()
+	defer renderedPeers.Unlock()
+	set := make(map[string]bool)
+	for _, data := range renderedPeers.data {
+		for key := range data.Labels {
+			set[key] = true
+		}
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//setPeerGroupLabel groups the peers list by a label (no grouping if empty), kept across the restarts of the Agent
+//in the menu state.
+func setPeerGroupLabel(i *app.Indicator, label string) {
+	recordPeerGroupLabel(&label)
+	regroupPeers(i)
+}
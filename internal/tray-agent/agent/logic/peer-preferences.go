@@ -0,0 +1,157 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"sort"
+	"strings"
+)
+
+/*This file contains the preferences of the user about the peers, stored in the 'peers' field of the configuration
+file by ClusterID:
+-	the alias, a friendly name displayed in place of the ClusterName in the peers list, in the notifications and in
+	the STATUS MenuNode;
+-	the favorite peers, pinned at the top of the peers list (or of their group) and summarized in the STATUS MenuNode.
+Both are set from the entry of each peer.*/
+
+const (
+	//tagPeerAlias is the tag of the entry of a peer assigning its alias.
+	tagPeerAlias = "alias"
+	//tagPeerFavorite is the tag of the entry of a peer adding it to (or removing it from) the favorite ones.
+	tagPeerFavorite = "favorite"
+	//titlePeerAlias is the title of the entry of a peer assigning its alias.
+	titlePeerAlias = "Rename…"
+	//titlePeerFavoriteAdd is the title of the entry of a peer adding it to the favorite ones.
+	titlePeerFavoriteAdd = "Add to Favorites"
+	//titlePeerFavoriteRemove is the title of the entry of a peer removing it from the favorite ones.
+	titlePeerFavoriteRemove = "Remove from Favorites"
+	//labelPeerFavorite is the label marking the favorite peers in the peers list.
+	labelPeerFavorite = "★"
+)
+
+//configurePeerPreferences applies the preferences about the peers of the local configuration to the Status.
+func configurePeerPreferences(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	i.Status().SetPeerPreferences(conf.GetPeerPreferences())
+}
+
+//peerPreferences returns the preferences of the user about a peer.
+func peerPreferences(clusterID string) client.PeerPreferences {
+	conf, _ := client.GetLocalConfig()
+	return conf.GetPeerPreferences()[clusterID]
+}
+
+//savePeerPreferences applies a change to the preferences about the peers, saving it in the configuration file, and
+//displays the peers again.
+func savePeerPreferences(i *app.Indicator, change func(conf *client.LocalConfiguration)) {
+	conf, _ := client.GetLocalConfig()
+	change(conf)
+	if err := client.SaveLocalConfig(); err != nil {
+		i.ShowWarning("PEER PREFERENCES", "The preferences about the peer could not be saved:\n"+err.Error())
+	}
+	configurePeerPreferences(i)
+	i.RefreshStatus()
+	//the peer entries are rebuilt, with the new names and the favorite ones first
+	regroupPeers(i)
+}
+
+//setPeerAlias sets the alias of a peer, removing it if empty.
+func setPeerAlias(i *app.Indicator, clusterID string, alias string) {
+	alias = strings.TrimSpace(alias)
+	savePeerPreferences(i, func(conf *client.LocalConfiguration) {
+		conf.SetPeerAlias(clusterID, alias)
+	})
+}
+
+//setPeerFavorite adds a peer to (or removes it from) the favorite ones.
+func setPeerFavorite(i *app.Indicator, clusterID string, favorite bool) {
+	savePeerPreferences(i, func(conf *client.LocalConfiguration) {
+		conf.SetPeerFavorite(clusterID, favorite)
+	})
+}
+
+//createPeerPreferences creates the entries of a peer assigning its alias and toggling its favorite state.
+func createPeerPreferences(peerNode *app.MenuNode, clusterID string) {
+	aliasNode := peerNode.UseListChild(peerDataIndentation+"• "+titlePeerAlias, tagPeerAlias)
+	aliasNode.Connect(false, &peerAliasHandler{clusterID: clusterID})
+	favoriteNode := peerNode.UseListChild("", tagPeerFavorite)
+	favoriteNode.Connect(false, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		setPeerFavorite(e.Indicator, clusterID, !peerPreferences(clusterID).Favorite)
+	}))
+}
+
+//refreshPeerFavorite refreshes the title of the entry toggling the favorite state of a peer.
+func refreshPeerFavorite(peerNode *app.MenuNode, favorite bool) {
+	favoriteNode, present := peerNode.ListChild(tagPeerFavorite)
+	if !present {
+		return
+	}
+	if favorite {
+		favoriteNode.SetTitle(peerDataIndentation + "• " + titlePeerFavoriteRemove)
+	} else {
+		favoriteNode.SetTitle(peerDataIndentation + "• " + titlePeerFavoriteAdd)
+	}
+}
+
+//peerAliasHandler is the ClickHandler of the entry of a peer assigning its alias.
+type peerAliasHandler struct {
+	clusterID string
+}
+
+//HandleClick implements the app.ClickHandler interface.
+func (h *peerAliasHandler) HandleClick(ctx context.Context, e *app.ClickEvent) {
+	if app.GetGuiProvider().Mocked() {
+		return
+	}
+	alias, ok, _ := dlgs.Entry("RENAME PEER", fmt.Sprintf("Type the name displayed for the peer %s "+
+		"(leave empty to display its cluster name):", h.clusterID), peerPreferences(h.clusterID).Alias)
+	if !ok {
+		return
+	}
+	setPeerAlias(e.Indicator, h.clusterID, alias)
+}
+
+//sortRenderedPeers sorts the peers of the peers list with the favorite ones first, then by displayed name.
+func sortRenderedPeers(peers []client.NotifyDataForeignCluster) {
+	conf, _ := client.GetLocalConfig()
+	prefs := conf.GetPeerPreferences()
+	name := func(data *client.NotifyDataForeignCluster) string {
+		if alias := prefs[data.ClusterID].Alias; alias != "" {
+			return alias
+		}
+		return data.ClusterName
+	}
+	sort.SliceStable(peers, func(a, b int) bool {
+		fa, fb := prefs[peers[a].ClusterID].Favorite, prefs[peers[b].ClusterID].Favorite
+		if fa != fb {
+			return fa
+		}
+		return name(&peers[a]) < name(&peers[b])
+	})
+}
+
+//pinFavoritePeer moves the entry of a favorite peer, just created, before the entries of the other peers. It returns
+//false if the entry cannot be moved by the GuiBackend, so that the peers list has to be rebuilt.
+func pinFavoritePeer(parent *app.MenuNode, peerNode *app.MenuNode, clusterID string) bool {
+	conf, _ := client.GetLocalConfig()
+	prefs := conf.GetPeerPreferences()
+	if !prefs[clusterID].Favorite {
+		return true
+	}
+	for _, entry := range parent.ListChildren() {
+		if entry == peerNode {
+			return true
+		}
+		if !prefs[entry.Tag()].Favorite {
+			if err := peerNode.MoveBefore(entry); err != nil {
+				logger.Debug("cannot pin the favorite peer", "peer", clusterID, "err", err)
+				return false
+			}
+			return true
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strings"
+	"sync"
+)
+
+/*This file contains the search of the peers list. Users federating with tens of clusters type a part of a cluster
+name in the dialog of the QUICK "Search Peers…": the peers list (and each of its groups) displays only the peers
+whose cluster name (or alias) contains it, regardless of the case, until the search is cleared.
+
+	Search Peers: "eu"
+	Peers (40)
+	├── cluster-eu-1
+	└── cluster-eu-2
+*/
+
+//titlePeerSearch is the title of the QUICK searching the peers list, while no search is active.
+const titlePeerSearch = "Search Peers…"
+
+//peerSearch contains the text the cluster names of the displayed peers contain, empty if no search is active.
+var peerSearch = struct {
+	query string
+	sync.RWMutex
+}{}
+
+//startQuickSearchPeers is the wrapper function to register the QUICK "Search Peers…".
+func startQuickSearchPeers(i *app.Indicator) {
+	i.AddQuick(titlePeerSearch, qPeerSearch, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		quickSearchPeers(i)
+	}))
+	applyPeerSearch(i, peerSearchQuery())
+}
+
+//quickSearchPeers is the callback for the QUICK "Search Peers…", asking the text to search.
+func quickSearchPeers(i *app.Indicator) {
+	if app.GetGuiProvider().Mocked() {
+		return
+	}
+	query, ok, _ := dlgs.Entry("SEARCH PEERS", "Type a part of the name of the clusters to display "+
+		"(leave empty to display all the peers):", peerSearchQuery())
+	if !ok {
+		return
+	}
+	searchPeers(i, query)
+}
+
+//peerSearchQuery returns the text searched in the peers list, empty if no search is active.
+func peerSearchQuery() string {
+	peerSearch.RLock()
+	defer peerSearch.RUnlock()
+	return peerSearch.query
+}
+
+//searchPeers displays only the peers whose cluster name contains query, or all of them if empty. The search is kept
+//across the restarts of the Agent.
+func searchPeers(i *app.Indicator, query string) {
+	query = strings.TrimSpace(query)
+	if query != client.GetMenuStateStore().State().PeerSearch {
+		recordPeerSearch(query)
+	}
+	applyPeerSearch(i, query)
+}
+
+//applyPeerSearch displays only the peers whose cluster name contains query, or all of them if empty.
+func applyPeerSearch(i *app.Indicator, query string) {
+	query = strings.TrimSpace(query)
+	peerSearch.Lock()
+	peerSearch.query = query
+	peerSearch.Unlock()
+	if quick, present := i.Quick(qPeerSearch); present {
+		if query == "" {
+			quick.SetTitle(titlePeerSearch)
+		} else {
+			quick.SetTitle(fmt.Sprintf("Search Peers: \"%s\"", query))
+		}
+	}
+	quick, present := i.Quick(qPeers)
+	if !present {
+		return
+	}
+	quick.SetListFilter(matchPeerSearch)
+	peerGroups.Lock()
+	defer peerGroups.Unlock()
+	for _, tag := range peerGroupTags() {
+		if group, present := quick.ListChild(tag); present && tag != "" {
+			group.SetListFilter(matchPeerSearch)
+		}
+	}
+}
+
+//refreshPeerSearch applies again the search to the peers list, after the peers changed.
+func refreshPeerSearch(quick *app.MenuNode) {
+	peerGroups.Lock()
+	defer peerGroups.Unlock()
+	for _, tag := range peerGroupTags() {
+		if group, present := quick.ListChild(tag); present && tag != "" {
+			group.RefreshListFilter()
+		}
+	}
+	quick.RefreshListFilter()
+}
+
+//matchPeerSearch returns whether an entry of the peers list is displayed by the current search: a peer whose
+//cluster name or alias contains the text searched, or a group containing one. The peers not rendered yet are
+//displayed until their name is known.
+func matchPeerSearch(entry *app.MenuNode) bool {
+	query := strings.ToLower(peerSearchQuery())
+	if query == "" {
+		return true
+	}
+	tag := entry.Tag()
+	conf, _ := client.GetLocalConfig()
+	prefs := conf.GetPeerPreferences()
+	matches := func(data *client.NotifyDataForeignCluster) bool {
+		return strings.Contains(strings.ToLower(data.ClusterName), query) ||
+			strings.Contains(strings.ToLower(prefs[data.ClusterID].Alias), query)
+	}
+	renderedPeers.Lock()
+	defer renderedPeers.Unlock()
+	if strings.HasPrefix(tag, tagPeerGroupPrefix) {
+		label := peerGroupLabel()
+		for _, data := range renderedPeers.data {
+			if peerGroupTag(label, &data) == tag && matches(&data) {
+				return true
+			}
+		}
+		return false
+	}
+	data, present := renderedPeers.data[tag]
+	return !present || matches(&data)
+}
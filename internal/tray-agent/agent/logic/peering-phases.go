@@ -0,0 +1,95 @@
+package logic
+
+import (
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strings"
+)
+
+const (
+	//activitySourcePeerings is the activity.Feed source of the transitions of the phases of the peerings.
+	activitySourcePeerings = "peerings"
+	//notificationPeeringErrorPrefix precedes the direction and the ClusterID of a failed peering in the ID of its
+	//app.Notification.
+	notificationPeeringErrorPrefix = "peeringError/"
+	//progressPeeringPrefix precedes the ClusterID of a peer in the tag of the app.Progress of the outgoing peering
+	//being established.
+	progressPeeringPrefix = "peering/"
+)
+
+//peeringProgress contains the completed percentage displayed for the phases of an outgoing peering being established.
+var peeringProgress = map[client.PeeringPhase]int{
+	client.PeeringPending:        30,
+	client.PeeringAuthenticating: 60,
+}
+
+//startPeeringPhaseWatch registers the callback reacting to the transitions of the phases of the peerings.
+func startPeeringPhaseWatch(i *app.Indicator) {
+	i.Status().OnPeeringPhaseChange(func(t app.PeeringTransition) {
+		onPeeringTransition(i, t)
+	})
+}
+
+//onPeeringTransition records the peerings being established or failing in the activity feed, notifying the
+//failures. The notification of a failure is dismissed once the peering leaves the client.PeeringError phase.
+func onPeeringTransition(i *app.Indicator, t app.PeeringTransition) {
+	direction, id := "Outgoing", notificationPeeringErrorPrefix+"outgoing/"
+	if t.Peering == app.PeeringIncoming {
+		direction, id = "Incoming", notificationPeeringErrorPrefix+"incoming/"
+	}
+	t.Peer.RLock()
+	id += t.Peer.ClusterID
+	name := t.Peer.DisplayName()
+	if t.Peer.Unknown && t.Peer.Alias == "" {
+		name = t.Peer.ClusterID
+	}
+	fcName := t.Peer.ForeignClusterResourceName
+	t.Peer.RUnlock()
+	msg := fmt.Sprintf("%s peering with %s: %s → %s", direction, name, t.From, t.To)
+	switch t.To {
+	case client.PeeringEstablished:
+		activity.GetFeed().Add(activitySourcePeerings, msg, activity.OutcomeSuccess)
+	case client.PeeringError:
+		activity.GetFeed().Add(activitySourcePeerings, msg, activity.OutcomeFailure)
+		i.ShowNotification(app.Notification{
+			ID:    id,
+			Title: "Liqo Agent: PEERING FAILED",
+			Message: fmt.Sprintf("The %s peering with %s cannot be established",
+				strings.ToLower(direction), name),
+			Severity: app.SeverityError,
+			Category: app.CategoryPeering,
+			Target:   app.NotificationTarget{Kind: "ForeignCluster", Name: fcName},
+		}.WithTrayIcon(app.IconLiqoRed))
+	}
+	if t.From == client.PeeringError {
+		i.DismissNotification(id)
+	}
+	if t.Peering == app.PeeringOutgoing {
+		refreshPeeringProgress(i, t, name)
+	}
+}
+
+//showPeeringProgress displays the outgoing peering with a peer being established, e.g. once started by the user.
+func showPeeringProgress(i *app.Indicator, clusterID string, name string) *app.Progress {
+	return i.ShowProgress(progressPeeringPrefix+clusterID, "Establishing peering with "+name)
+}
+
+//refreshPeeringProgress updates the progress of an outgoing peering being established according to its phase: the
+//progress is displayed until the peering is established, or fails.
+func refreshPeeringProgress(i *app.Indicator, t app.PeeringTransition, name string) {
+	t.Peer.RLock()
+	clusterID := t.Peer.ClusterID
+	t.Peer.RUnlock()
+	p, present := i.Progress(progressPeeringPrefix + clusterID)
+	percent, establishing := peeringProgress[t.To]
+	switch {
+	case establishing && !present:
+		showPeeringProgress(i, clusterID, name).Update(percent)
+	case establishing:
+		p.Update(percent)
+	case present:
+		p.Done()
+	}
+}
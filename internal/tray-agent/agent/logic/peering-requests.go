@@ -0,0 +1,169 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strings"
+)
+
+/*This file contains the ACTION approving the incoming peering requests from the tray menu. The ACTION is visible only
+while some request awaits the decision of the user: its "Accept" and "Reject" OPTIONs act on the oldest one, while
+each pending request is also listed among the pending items and notified with a banner offering the same choices.*/
+
+const (
+	//aPeeringRequests is the tag of the ACTION approving the incoming peering requests.
+	aPeeringRequests = "A_PEERING_REQUESTS"
+	//oAcceptPeeringRequest is the tag of the OPTION accepting the oldest peering request.
+	oAcceptPeeringRequest = "O_ACCEPT_PEERING_REQUEST"
+	//oRejectPeeringRequest is the tag of the OPTION rejecting the oldest peering request.
+	oRejectPeeringRequest = "O_REJECT_PEERING_REQUEST"
+	//activitySourcePeeringRequests is the activity.Feed source of the decisions about the peering requests.
+	activitySourcePeeringRequests = "peeringRequests"
+)
+
+//startActionPeeringRequests is the wrapper function to register the ACTION "Peering request from …".
+func startActionPeeringRequests(i *app.Indicator) {
+	action := i.AddAction("", aPeeringRequests, nil)
+	accept := action.AddOption("Accept", oAcceptPeeringRequest, "Accept the incoming peering", false,
+		app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			decideOldestPeeringRequest(ctx, e.Indicator, true)
+		}))
+	accept.SetWriteAction(true)
+	reject := action.AddOption("Reject", oRejectPeeringRequest, "Reject the incoming peering, tearing it down", false,
+		app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			decideOldestPeeringRequest(ctx, e.Indicator, false)
+		}))
+	reject.SetWriteAction(true)
+	refreshPeeringRequests(i)
+}
+
+//refreshPeeringRequests updates the aPeeringRequests ACTION and the pending items with the incoming peering
+//requests awaiting a decision, e.g.
+//	⬢ Peering request from cluster-2 (+1 more)
+func refreshPeeringRequests(i *app.Indicator) {
+	var requests []client.PeeringRequest
+	if i.AgentCtrl().Connected() {
+		requests = i.AgentCtrl().PendingPeeringRequests()
+	}
+	pending := make(map[string]bool, len(requests))
+	for _, req := range requests {
+		id := pendingPeeringRequestPrefix + req.ForeignCluster
+		pending[id] = true
+		if _, present := i.Pending().Item(id); present {
+			continue
+		}
+		fcName, name := req.ForeignCluster, peeringRequestName(req)
+		i.Pending().Add(app.PendingItem{
+			ID:    id,
+			Kind:  app.PendingRequest,
+			Title: "Peering request from " + name,
+			Action: app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+				if app.GetGuiProvider().Mocked() {
+					return
+				}
+				accept, err := dlgs.Question("Liqo Agent: PEERING REQUEST",
+					fmt.Sprintf("Do you want to accept the incoming peering from %s?", name), true)
+				if err == nil && accept {
+					decidePeeringRequest(ctx, e.Indicator, fcName, name, true)
+				}
+			}),
+		})
+		notifyPeeringRequest(i, id, fcName, name)
+	}
+	for _, it := range i.Pending().Items() {
+		if strings.HasPrefix(it.ID, pendingPeeringRequestPrefix) && !pending[it.ID] {
+			i.Pending().Remove(it.ID)
+			i.DismissNotification(it.ID)
+		}
+	}
+	action, present := i.Action(aPeeringRequests)
+	if !present {
+		return
+	}
+	if len(requests) == 0 {
+		action.SetIsVisible(false)
+		return
+	}
+	title := "Peering request from " + peeringRequestName(requests[0])
+	if len(requests) > 1 {
+		title += fmt.Sprintf(" (+%d more)", len(requests)-1)
+	}
+	action.SetTitle(title)
+	action.SetIsVisible(true)
+}
+
+//notifyPeeringRequest notifies a new incoming peering request, offering to accept or reject it from the banner.
+func notifyPeeringRequest(i *app.Indicator, id string, fcName string, name string) {
+	decide := func(accept bool) app.ClickHandler {
+		return app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			decidePeeringRequest(ctx, e.Indicator, fcName, name, accept)
+		})
+	}
+	i.ShowNotification(app.Notification{
+		ID:       id,
+		Title:    "Liqo Agent: PEERING REQUEST",
+		Message:  name + " requests an incoming peering",
+		Severity: app.SeverityInfo,
+		Category: app.CategoryPeering,
+		Target:   app.NotificationTarget{Kind: "ForeignCluster", Name: fcName},
+		Actions: []app.NotificationAction{
+			{Label: "Accept peering", Handler: decide(true)},
+			{Label: "Reject", Handler: decide(false)},
+			{Label: "Dismiss"},
+		},
+	})
+}
+
+//peeringRequestName returns the name of the peer displayed for a peering request.
+func peeringRequestName(req client.PeeringRequest) string {
+	if req.ClusterName != "" {
+		return req.ClusterName
+	}
+	return req.ClusterID
+}
+
+//decideOldestPeeringRequest accepts or rejects the oldest incoming peering request awaiting a decision.
+func decideOldestPeeringRequest(ctx context.Context, i *app.Indicator, accept bool) {
+	requests := i.AgentCtrl().PendingPeeringRequests()
+	if len(requests) == 0 {
+		refreshPeeringRequests(i)
+		return
+	}
+	decidePeeringRequest(ctx, i, requests[0].ForeignCluster, peeringRequestName(requests[0]), accept)
+}
+
+//decidePeeringRequest accepts or rejects the incoming peering request of the peer described by a ForeignCluster.
+func decidePeeringRequest(ctx context.Context, i *app.Indicator, fcName string, name string, accept bool) {
+	if !writeAllowed(i, "the approval of a peering request") {
+		return
+	}
+	decision := "Rejection"
+	if accept {
+		decision = "Acceptance"
+	}
+	err := runOperation(ctx, i, opPeeringApproval, func(ctx context.Context) error {
+		if accept {
+			return i.AgentCtrl().AcceptPeeringRequest(fcName)
+		}
+		return i.AgentCtrl().RejectPeeringRequest(fcName)
+	})
+	refreshPeeringRequests(i)
+	if err != nil {
+		activity.GetFeed().Add(activitySourcePeeringRequests, decision+" of the peering request from "+name+
+			" failed", activity.OutcomeFailure)
+		i.ShowClientError("Liqo Agent: PEERING REQUEST NOT UPDATED", err)
+		return
+	}
+	activity.GetFeed().Add(activitySourcePeeringRequests, decision+" of the peering request from "+name,
+		activity.OutcomeSuccess)
+	verb := "rejected"
+	if accept {
+		verb = "accepted"
+	}
+	i.Notify("Liqo Agent", fmt.Sprintf("The peering request from %s has been %s", name, verb),
+		app.NotifyIconDefault, app.IconLiqoNil)
+}
@@ -0,0 +1,90 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strings"
+)
+
+/*This file contains the ACTION starting an outgoing peering with a peer typed by the user, either one of the
+discovered peers (by its cluster ID) or a peer out of reach of the automatic discovery (by the URL of its
+authentication service, e.g. a cluster in another network).*/
+
+const (
+	//aPeeringWizard is the tag of the ACTION starting an outgoing peering with a typed peer.
+	aPeeringWizard = "A_PEERING_WIZARD"
+	//activitySourcePeeringWizard is the activity.Feed source of the peerings started with the aPeeringWizard ACTION.
+	activitySourcePeeringWizard = "peeringWizard"
+)
+
+//errPeeringNotVerified is returned by startPeeringWith when the identity or the credentials of the peer are not
+//verified.
+var errPeeringNotVerified = errors.New("peer identity or credentials not verified")
+
+//startActionPeeringWizard is the wrapper function to register the ACTION "Peer with a cluster…".
+func startActionPeeringWizard(i *app.Indicator) {
+	action := i.AddAction("Peer with a cluster…", aPeeringWizard,
+		app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			peeringWizard(ctx, e.Indicator)
+		}))
+	action.SetWriteAction(true)
+}
+
+//peeringWizard asks the user for the cluster ID or the authentication service URL of a peer, then starts an
+//outgoing peering towards it.
+func peeringWizard(ctx context.Context, i *app.Indicator) {
+	if !writeAllowed(i, "the start of a peering") {
+		return
+	}
+	if !i.AgentCtrl().Connected() {
+		i.ShowErrorNoConnection()
+		return
+	}
+	peer, ok := app.GetGuiProvider().InputDialog("Liqo Agent: PEER WITH A CLUSTER",
+		"Type the cluster ID of a discovered peer or the URL of the authentication service of the remote cluster "+
+			"(e.g. https://10.0.0.1:30443):", "")
+	peer = strings.TrimSpace(peer)
+	if !ok || peer == "" {
+		return
+	}
+	fcName, err := startPeeringWith(ctx, i, peer)
+	if err == errPeeringNotVerified {
+		return
+	}
+	if err != nil {
+		activity.GetFeed().Add(activitySourcePeeringWizard, "Peering with "+peer+" not started",
+			activity.OutcomeFailure)
+		i.ShowClientError("Liqo Agent: PEERING COMMAND FAILED", err)
+		return
+	}
+	activity.GetFeed().Add(activitySourcePeeringWizard, "Peering with "+peer+" started ("+fcName+")",
+		activity.OutcomeSuccess)
+	i.Notify("Liqo Agent", "The peering with "+peer+" has been started", app.NotifyIconDefault, app.IconLiqoNil)
+	startOnboarding(i, fcName, peer)
+}
+
+//startPeeringWith starts an outgoing peering with a peer, by cluster ID or authentication service URL (see
+//client.StartPeeringWith), returning the name of its ForeignCluster. The discovered peers follow the same checks of
+//the peerings started from their menu entry: if they fail, errPeeringNotVerified is returned.
+func startPeeringWith(ctx context.Context, i *app.Indicator, peer string) (string, error) {
+	for _, fc := range i.AgentCtrl().ForeignClusters().List() {
+		if fc.Spec.ClusterIdentity.ClusterID == peer && !fc.Spec.Join {
+			if !verifyPeerIdentity(ctx, i, fc.Name, "start the peering") || !providePeerCredentials(ctx, i, fc.Name) {
+				return "", errPeeringNotVerified
+			}
+		}
+	}
+	conf, _ := client.GetLocalConfig()
+	var fcName string
+	err := runOperation(ctx, i, opPeering, func(ctx context.Context) error {
+		return client.Retry(ctx, conf.GetBackoffPolicy(), func() error {
+			var err error
+			fcName, err = i.AgentCtrl().StartPeeringWith(peer)
+			return err
+		})
+	})
+	return fcName, err
+}
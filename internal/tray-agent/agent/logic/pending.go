@@ -0,0 +1,71 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+)
+
+//IDs of the app.PendingItem registered by the Agent features.
+const (
+	pendingCredentials   = "credentials"
+	pendingHealth        = "health"
+	pendingUpgrade       = "upgrade"
+	pendingCaptivePortal = "captivePortal"
+	//pendingPeeringRequestPrefix precedes the name of a ForeignCluster in the ID of the app.PendingItem of its
+	//incoming peering request.
+	pendingPeeringRequestPrefix = "peeringRequest/"
+	//pendingFailurePrefix precedes the name of a failed operation in the ID of its app.PendingItem.
+	pendingFailurePrefix = "failed/"
+)
+
+//startQuickPending is the wrapper function to register QUICK "Pending", summarizing the items awaiting an input
+//of the user (e.g. "2 pending requests, 1 problem"). It is visible only when some item is pending.
+func startQuickPending(i *app.Indicator) {
+	node := i.AddQuick("", qPending, nil)
+	refreshPending(node, i.Pending())
+	i.Pending().OnChange(func() {
+		refreshPending(node, i.Pending())
+	})
+}
+
+//refreshPending updates the content of the pending QUICK: its title summarizes the pending items, while each
+//LIST child leads to the place where an item can be handled, e.g.
+//	✖ Credentials of 'admin' expired
+//	• Liqo v0.3.0 available
+func refreshPending(quick *app.MenuNode, registry *app.PendingRegistry) {
+	quick.FreeListChildren()
+	summary := registry.Summary()
+	quick.SetIsVisible(summary != "")
+	if summary == "" {
+		return
+	}
+	quick.SetTitle("⚑ " + summary)
+	for _, it := range registry.Items() {
+		title := "• " + it.Title
+		if it.Kind == app.PendingProblem {
+			title = "✖ " + it.Title
+		}
+		child := quick.UseListChild(title, it.ID)
+		if it.Action == nil {
+			child.SetIsEnabled(false)
+			continue
+		}
+		child.Connect(false, it.Action)
+	}
+}
+
+//addPendingFailure registers a failed operation as a pending problem. Selecting it displays the details
+//of the failure and dismisses it.
+func addPendingFailure(i *app.Indicator, operation string, title string, details string) {
+	id := pendingFailurePrefix + operation
+	i.Pending().Add(app.PendingItem{
+		ID:    id,
+		Kind:  app.PendingProblem,
+		Title: title,
+		Action: app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			e.Indicator.Pending().Remove(id)
+			e.Indicator.ShowError(fmt.Sprintf("LIQO AGENT: %s", title), details)
+		}),
+	})
+}
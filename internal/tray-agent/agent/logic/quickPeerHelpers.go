@@ -1,13 +1,16 @@
 package logic
 
 import (
+	"context"
 	"fmt"
 	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/format"
 	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
 	discovery2 "github.com/liqotech/liqo/pkg/discovery"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 /*This file contains internal variables and helper functions for the QUICK qPeers in charge of displaying
@@ -20,6 +23,9 @@ const (
 	tagPeeringIncoming = "inPeering"
 	tagPeeringOutgoing = "outPeering"
 	tagPeeringCmd      = "cmd"
+	tagPeerTerminal    = "terminal"
+	tagPeerIdentity    = "identity"
+	tagPeerDiagnostics = "diagnostics"
 )
 
 // set of frequently used title strings for menu entries regarding peers management
@@ -57,6 +63,9 @@ const (
 const (
 	//peerDataIndentation is the text prefix to prepend in the submenu of each peer entry in the tray menu.
 	peerDataIndentation = "   "
+	//peeringCmdCooldown is the minimum interval between two clicks on the command starting or stopping a peering,
+	//preventing duplicate peering requests.
+	peeringCmdCooldown = 5 * time.Second
 )
 
 //refreshPeerCount updates the visual counter of the discovered peers (even not peered).
@@ -92,6 +101,13 @@ Each peer entry has the following structure:
 	3.2-	PEERING STATUS: details on the active peering (e.g. consumed resources)
 	4-		INCOMING PEERING: display information and commands for an incoming peering from this peer
 	4.1-	STOP PEERING
+	5-		OPEN TERMINAL: open a terminal pointing at the virtual node representing this peer
+	6-		VERIFY IDENTITY: display the identity of this peer, allowing to pin it
+	7-		COLLECT REMOTE DIAGNOSTICS: collect the diagnostics of the peering with this peer
+	8-		RENAME: assign an alias to this peer
+	9-		ADD TO/REMOVE FROM FAVORITES: pin this peer at the top of the peers list
+	10-		COPY VIRTUAL NODE KUBECONFIG: copy to the clipboard the kubeconfig the virtual node of this peer uses
+	11-		PEERING DETAILS: display the shared resources, the virtual node and the latency of the active peerings
 */
 func createPeerNode(peerList *app.MenuNode, data *client.NotifyDataForeignCluster, peer *app.PeerInfo) *app.MenuNode {
 	//create the structure for a single peer
@@ -102,14 +118,17 @@ func createPeerNode(peerList *app.MenuNode, data *client.NotifyDataForeignCluste
 	//2- AUTHN TOKEN MANUAL INSERTION
 	insertAuthToken := peerNode.UseListChild(peerDataIndentation+titlePeerAuthToken, tagPeerAuthToken)
 	insertAuthToken.SetIsEnabled(false)
+	insertAuthToken.SetWriteAction(true)
 	//todo further connection of the "insert auth token" entry with a callback
 	//3- OUTGOING PEERING
 	outgoingNode := peerNode.UseListChild(peerDataIndentation+titlePeeringOutgoing, tagPeeringOutgoing)
 	//3.1- START/STOP PEERING
 	outgoingPeeringNode := outgoingNode.UseListChild(peerDataIndentation+titlePeeringCmdStart, tagPeeringCmd)
-	outgoingPeeringNode.Connect(false, peerHelperOutgoingPeering, peer)
+	outgoingPeeringNode.Connect(false, &peerOutgoingPeeringHandler{peer: peer})
 	//the command can not be available unless the authn token is accepted by the foreign cluster.
 	outgoingPeeringNode.SetIsEnabled(false)
+	outgoingPeeringNode.SetWriteAction(true)
+	outgoingPeeringNode.SetCooldown(peeringCmdCooldown)
 	//3.2- STATUS
 	outgoingStatus := outgoingNode.UseListChild("", tagStatus)
 	outgoingStatus.SetIsVisible(false)
@@ -122,6 +141,22 @@ func createPeerNode(peerList *app.MenuNode, data *client.NotifyDataForeignCluste
 	//the "stop peering" entry is by default disabled since its callback can be executed only in presence
 	//of an active incoming peering
 	incomingCmd.SetIsEnabled(false)
+	incomingCmd.SetWriteAction(true)
+	//5- OPEN TERMINAL
+	terminalNode := peerNode.UseListChild(peerDataIndentation+"• "+titleTerminal, tagPeerTerminal)
+	terminalNode.Connect(false, &peerTerminalHandler{clusterID: data.ClusterID})
+	//6- VERIFY IDENTITY
+	identityNode := peerNode.UseListChild(peerDataIndentation+"• "+titlePeerIdentity, tagPeerIdentity)
+	identityNode.Connect(false, &peerIdentityHandler{peer: peer})
+	//7- COLLECT REMOTE DIAGNOSTICS
+	diagnosticsNode := peerNode.UseListChild(peerDataIndentation+"• "+titlePeerDiagnostics, tagPeerDiagnostics)
+	diagnosticsNode.Connect(false, &peerDiagnosticsHandler{peer: peer})
+	//8- RENAME and 9- ADD TO/REMOVE FROM FAVORITES
+	createPeerPreferences(peerNode, data.ClusterID)
+	//10- COPY VIRTUAL NODE KUBECONFIG
+	createPeerKubeconfig(peerNode, data.ClusterID)
+	//11- PEERING DETAILS
+	createPeerDetails(peerNode)
 	return peerNode
 }
 
@@ -130,20 +165,34 @@ func createPeerNode(peerList *app.MenuNode, data *client.NotifyDataForeignCluste
 //1- The ClusterName of the correspondent ForeignCluster (or a text replacement labelPeerUnknown indicating its name is unknown
 //
 //2- A tag labelPeerLAN indicating whether the peer is located in the same LAN of the home cluster
+//
+//The alias assigned by the user replaces the ClusterName, and the favorite peers are marked by labelPeerFavorite.
 func refreshPeerName(peerNode *app.MenuNode, peer *app.PeerInfo, data *client.NotifyDataForeignCluster, wg *sync.WaitGroup) {
 	defer wg.Done()
 	var title []string
+	prefs := peerPreferences(data.ClusterID)
+	if prefs.Favorite {
+		title = append(title, labelPeerFavorite)
+	}
 	//- check unknown identity
-	if peer.Unknown {
+	switch {
+	case prefs.Alias != "":
+		title = append(title, prefs.Alias)
+	case peer.Unknown:
 		title = append(title, labelPeerUnknown, strconv.Itoa(peer.UnknownId))
-	} else {
+	default:
 		title = append(title, data.ClusterName)
 	}
 	//check if the cluster is located inside the LAN
 	if data.LocalDiscovered {
 		title = append(title, labelPeerLAN)
 	}
+	//mark the last peer the user interacted with
+	if isLastPeer(data.ClusterID) {
+		title = append(title, labelPeerRecent)
+	}
 	peerNode.SetTitle(strings.Join(title, " "))
+	refreshPeerFavorite(peerNode, prefs.Favorite)
 }
 
 //refreshPeerStatus refreshes the content of the peer status entry.
@@ -182,18 +231,6 @@ func refreshPeerStatus(peerNode *app.MenuNode, data *client.NotifyDataForeignClu
 	statusNode.SetTitle(content.String())
 }
 
-//refreshPeerInfo reloads into the tray menu details on identity and status of a specific peer.
-func refreshPeerInfo(peerNode *app.MenuNode, peer *app.PeerInfo, data *client.NotifyDataForeignCluster, wg *sync.WaitGroup) {
-	peerWg := &sync.WaitGroup{}
-	peerWg.Add(2)
-	defer wg.Done()
-	//set content of the NAME entry
-	go refreshPeerName(peerNode, peer, data, peerWg)
-	//set content of the STATUS entry
-	go refreshPeerStatus(peerNode, data, peerWg)
-	peerWg.Wait()
-}
-
 //refreshPeerInfo reloads into the tray menu details on peering status of a specific peer.
 func refreshPeeringInfo(peerNode *app.MenuNode, peer *app.PeerInfo, data *client.NotifyDataForeignCluster, wg *sync.WaitGroup) {
 	defer wg.Done()
@@ -253,13 +290,13 @@ func describeOutResources(data *client.NotifyDataForeignCluster) string {
 	content := strings.Builder{}
 	content.WriteString(peerDataIndentation + "CPU: ")
 	if data.OutPeering.CpuQuota != "" {
-		content.WriteString(data.OutPeering.CpuQuota)
+		content.WriteString(format.CPUQuantity(data.OutPeering.CpuQuota))
 	} else {
 		content.WriteString(labelResourceQuotaUnavailable)
 	}
 	content.WriteString("\n" + peerDataIndentation + "RAM: ")
 	if data.OutPeering.MemQuota != "" {
-		content.WriteString(data.OutPeering.MemQuota)
+		content.WriteString(format.MemoryQuantity(data.OutPeering.MemQuota))
 	} else {
 		content.WriteString(labelResourceQuotaUnavailable)
 	}
@@ -268,23 +305,68 @@ func describeOutResources(data *client.NotifyDataForeignCluster) string {
 
 //The following functions are the callbacks associated to the entries of the tray menu "PEERS" sub-section.
 
-//helperOutgoingPeering is a callback to perform the start/stop of an outgoing peering towards a foreign cluster.
-//It takes the *app-indicator/PeerInfo data of the correspondent peer.
-func peerHelperOutgoingPeering(args ...interface{}) {
-	if len(args) < 1 {
-		panic("wrong function arity: missing app-indicator.*PeerInfo parameter")
-	}
-	peer, ok := args[0].(*app.PeerInfo)
-	if !ok {
-		panic("argument is not *app-Indicator.PeerInfo")
-	}
-	agentCtrl := app.GetIndicator().AgentCtrl()
+//peerOutgoingPeeringHandler is the app.ClickHandler performing the start/stop of an outgoing peering towards
+//a foreign cluster.
+type peerOutgoingPeeringHandler struct {
+	//peer is the *app-indicator/PeerInfo data of the correspondent peer.
+	peer *app.PeerInfo
+}
+
+//HandleClick implements the app.ClickHandler interface.
+func (h *peerOutgoingPeeringHandler) HandleClick(ctx context.Context, e *app.ClickEvent) {
+	peer := h.peer
+	agentCtrl := e.Indicator.AgentCtrl()
 	peer.RLock()
 	fcName := peer.ForeignClusterResourceName
 	outPeered := peer.OutPeeringConnected
+	clusterID := peer.ClusterID
+	name := peer.ClusterName
 	peer.RUnlock()
+	recordLastPeer(e.Indicator, clusterID)
+	if !writeAllowed(e.Indicator, "the change of a peering") {
+		return
+	}
 	if agentCtrl.Connected() {
+		//the identity of the peer is verified before starting a peering
+		if !outPeered && !verifyPeerIdentity(ctx, e.Indicator, fcName, "start the peering") {
+			return
+		}
+		//the peers requiring additional authentication material get it from the credential helpers
+		if !outPeered && !providePeerCredentials(ctx, e.Indicator, fcName) {
+			return
+		}
+		if name == "" {
+			name = fcName
+		}
+		//the peering being started is displayed until established (see refreshPeeringProgress)
+		var progress *app.Progress
+		if !outPeered {
+			progress = showPeeringProgress(e.Indicator, clusterID, name).WithPulsingIcon()
+		}
 		//the operation to be performed is opposite to the actual peering status
-		_ = agentCtrl.StartStopOutPeering(fcName, !outPeered)
+		conf, _ := client.GetLocalConfig()
+		err := runOperation(ctx, e.Indicator, opPeering, func(ctx context.Context) error {
+			return client.Retry(ctx, conf.GetBackoffPolicy(), func() error {
+				return agentCtrl.StartStopOutPeering(fcName, !outPeered)
+			})
+		})
+		e.Indicator.ShowClientError("Liqo Agent: PEERING COMMAND FAILED", err)
+		if err != nil && progress != nil {
+			progress.Done()
+		}
+		if err == nil && !outPeered {
+			startOnboarding(e.Indicator, fcName, name)
+		}
 	}
 }
+
+//peerTerminalHandler is the app.ClickHandler opening a terminal pointing at the virtual node of a peer.
+type peerTerminalHandler struct {
+	clusterID string
+}
+
+//HandleClick implements the app.ClickHandler interface.
+func (h *peerTerminalHandler) HandleClick(ctx context.Context, e *app.ClickEvent) {
+	recordLastPeer(e.Indicator, h.clusterID)
+	openTerminal(e.Indicator, h.clusterID)
+}
@@ -1,13 +1,15 @@
 package logic
 
 import (
+	"context"
 	"fmt"
-	"github.com/atotto/clipboard"
 	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/api"
 	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
 	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
 	"github.com/skratchdot/open-golang/open"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -20,8 +22,87 @@ const (
 	qNotify = "Q_NOTIFY"
 	qQuit   = "Q_QUIT"
 	qPeers  = "Q_PEERS"
+	//qClusters is the tag of the QUICK listing the additional clusters.
+	qClusters = "Q_CLUSTERS"
+	//qTopology is the tag of the QUICK exporting the peering topology.
+	qTopology = "Q_TOPOLOGY"
+	//qHistory is the tag of the QUICK showing the peering history.
+	qHistory = "Q_HISTORY"
+	//qStorage is the tag of the QUICK showing the storage resources and their usability across peers.
+	qStorage = "Q_STORAGE"
+	//qCapacity is the tag of the QUICK showing the capacity overview of the home cluster.
+	qCapacity = "Q_CAPACITY"
+	//qCredentials is the tag of the QUICK showing the expiry of the cluster credentials.
+	qCredentials = "Q_CREDENTIALS"
+	//qHealth is the tag of the QUICK showing the health of the Liqo control plane.
+	qHealth = "Q_HEALTH"
+	//qActivity is the tag of the QUICK showing the activity feed.
+	qActivity = "Q_ACTIVITY"
+	//qUpgrade is the tag of the QUICK starting the upgrade of Liqo.
+	qUpgrade = "Q_UPGRADE"
+	//qUninstall is the tag of the QUICK starting the uninstallation of Liqo.
+	qUninstall = "Q_UNINSTALL"
+	//qAgentUpdate is the tag of the QUICK updating the Agent.
+	qAgentUpdate = "Q_AGENT_UPDATE"
+	//qIconTheme is the tag of the QUICK changing the tray icon theme.
+	qIconTheme = "Q_ICON_THEME"
+	//qLanguage is the tag of the QUICK changing the language of the menu and of the notifications.
+	qLanguage = "Q_LANGUAGE"
+	//qQuietHours is the tag of the QUICK showing the state of the quiet hours.
+	qQuietHours = "Q_QUIET_HOURS"
+	//qDoNotDisturb is the tag of the QUICK toggling the Do Not Disturb mode.
+	qDoNotDisturb = "Q_DO_NOT_DISTURB"
+	//qTerminal is the tag of the QUICK opening a terminal pointing at the home cluster.
+	qTerminal = "Q_TERMINAL"
+	//qCustomize is the tag of the QUICK customizing the menu layout.
+	qCustomize = "Q_CUSTOMIZE"
+	//qStatusWindow is the tag of the QUICK opening the Status window.
+	qStatusWindow = "Q_STATUS_WINDOW"
+	//qPending is the tag of the QUICK summarizing the items awaiting an input of the user.
+	qPending = "Q_PENDING"
+	//qPeerGrouping is the tag of the QUICK selecting the label the peers list is grouped by.
+	qPeerGrouping = "Q_PEER_GROUPING"
+	//qReset is the tag of the QUICK clearing the Agent state.
+	qReset = "Q_RESET"
+	//qReadOnly is the tag of the QUICK toggling the read-only mode.
+	qReadOnly = "Q_READ_ONLY"
+	//qLogs is the tag of the QUICK collecting the settings and the entries about the Agent logs.
+	qLogs = "Q_LOGS"
+	//qStartup is the tag of the QUICK collecting the settings about the start of the Agent.
+	qStartup = "Q_STARTUP"
+	//qSettingsPage is the tag of the QUICK opening the settings page.
+	qSettingsPage = "Q_SETTINGS_PAGE"
+	//qBackground is the tag of the QUICK listing the background tasks.
+	qBackground = "Q_BACKGROUND"
+	//qNamespaces is the tag of the QUICK listing the namespaces, toggling their offloading.
+	qNamespaces = "Q_NAMESPACES"
+	//qPeerSearch is the tag of the QUICK searching the peers list.
+	qPeerSearch = "Q_PEER_SEARCH"
+	//qReconnect is the tag of the QUICK attempting the connection to the cluster.
+	qReconnect = "Q_RECONNECT"
+	//qOffline is the tag of the QUICK displaying the last known status of the cluster while offline.
+	qOffline = "Q_OFFLINE"
+	//qClipboard is the tag of the QUICK copying the data about the home cluster to the clipboard.
+	qClipboard = "Q_CLIPBOARD"
 )
 
+//configureListPages displays the long lists of the menu (the peers and the namespaces) by pages of the configured
+//size. The entries of the peer groups are paginated once created (see peerGroupParent).
+func configureListPages(i *app.Indicator) {
+	size := listPageSize()
+	for _, tag := range []string{qPeers, qNamespaces} {
+		if quick, present := i.Quick(tag); present {
+			quick.SetListPageSize(size)
+		}
+	}
+}
+
+//listPageSize returns the configured number of entries of the long lists displayed at once.
+func listPageSize() int {
+	conf, _ := client.GetLocalConfig()
+	return conf.GetListPageSize()
+}
+
 //quickTurnOnOff is the callback for the QUICK "START/STOP LIQO".
 func quickTurnOnOff(i *app.Indicator) {
 	runSt := i.Status().Running()
@@ -41,6 +122,7 @@ func quickTurnOnOff(i *app.Indicator) {
 			if peersPresent {
 				refreshPeerCount(peersQuick)
 			}
+			publishStatusChanged(i)
 		}
 	case app.StatRunOn:
 		//turning OFF LiqoAgent
@@ -54,6 +136,7 @@ func quickTurnOnOff(i *app.Indicator) {
 		if peersPresent {
 			peersQuick.SetIsEnabled(false)
 		}
+		publishStatusChanged(i)
 	}
 }
 
@@ -84,6 +167,7 @@ func quickChangeMode(i *app.Indicator) {
 			//todo transition logic
 			updateQuickChangeMode(i)
 			i.RefreshStatus()
+			publishStatusChanged(i)
 		} else {
 			i.ShowWarningForbiddenTethered()
 		}
@@ -93,6 +177,7 @@ func quickChangeMode(i *app.Indicator) {
 			//todo transition logic
 			updateQuickChangeMode(i)
 			i.RefreshStatus()
+			publishStatusChanged(i)
 		} else {
 			i.ShowWarning("LIQO AGENT", "Mode change not allowed.")
 		}
@@ -132,10 +217,43 @@ func quickChangeNotifyLevel() {
 			"CURRENT: %s", i.Config().NotifyTranslate(i.Config().NotifyLevel())), notifyDescription)
 		if ok {
 			i.NotificationSetLevel(i.Config().NotifyTranslateReverse(level))
+			recordNotifyLevel(i.Config().NotifyLevel())
 		}
 	}
 }
 
+//quickChangeIconTheme is the callback function for the QUICK "Icon Theme Settings". The selected theme is
+//applied immediately and saved in the Agent configuration file.
+func quickChangeIconTheme(i *app.Indicator) {
+	if app.GetGuiProvider().Mocked() {
+		return
+	}
+	var descriptions []string
+	for _, theme := range []app.IconTheme{app.IconThemeDefault, app.IconThemeAccessible} {
+		descriptions = append(descriptions, app.IconThemeDescriptions[theme])
+	}
+	choice, ok, _ := dlgs.List("ICON THEME SETTINGS", fmt.Sprintf("Choose the theme of the Liqo tray icon.\n"+
+		"CURRENT: %s", app.IconThemeDescriptions[i.IconTheme()]), descriptions)
+	if !ok {
+		return
+	}
+	for theme, description := range app.IconThemeDescriptions {
+		if description == choice {
+			setIconTheme(i, theme)
+		}
+	}
+}
+
+//setIconTheme applies an IconTheme to the tray icon and saves it in the Agent configuration file.
+func setIconTheme(i *app.Indicator, theme app.IconTheme) {
+	i.SetIconTheme(theme)
+	conf, _ := client.GetLocalConfig()
+	conf.SetIconTheme(string(theme))
+	if err := client.SaveLocalConfig(); err != nil {
+		i.ShowWarning("LIQO AGENT", "The icon theme could not be saved:\n"+err.Error())
+	}
+}
+
 //quickConnectDashboard is the callback function for the QUICK "Launch LiqoDash".
 //
 //- If LiqoDash connection parameters are set (or can be retrieved), it opens the LiqoDash address
@@ -143,13 +261,16 @@ func quickChangeNotifyLevel() {
 //
 //- Then, it searches for an access token in the cluster and provides it to the user directly in the
 //clipboard, ready to be pasted.
-func quickConnectDashboard(i *app.Indicator) {
+func quickConnectDashboard(ctx context.Context, i *app.Indicator) {
 	ctrl := i.AgentCtrl()
 	//Check if connection parameters are already set in the env vars to speed execution up.
 	host, ok1 := os.LookupEnv(client.EnvLiqoDashHost)
 	port, ok2 := os.LookupEnv(client.EnvLiqoDashPort)
 	if !ok1 || !ok2 {
-		if err := ctrl.AcquireDashboardConfig(); err != nil {
+		err := runOperation(ctx, i, opDashboard, func(context.Context) error {
+			return ctrl.AcquireDashboardConfig()
+		})
+		if err != nil {
 			i.Notify("Liqo Agent: SERVICE UNAVAILABLE", err.Error(),
 				app.NotifyIconDefault, app.IconLiqoNil)
 			return
@@ -165,8 +286,13 @@ func quickConnectDashboard(i *app.Indicator) {
 	dashUrl := dashUrlBuilder.String()
 	if err := open.Run(dashUrl); err == nil {
 		//try to recover access token
-		if token, errNFound := ctrl.GetLiqoDashSecret(); errNFound == nil {
-			if err = clipboard.WriteAll(*token); err == nil {
+		var token *string
+		errNFound := runOperation(ctx, i, opDashboard, func(context.Context) (err error) {
+			token, err = ctrl.GetLiqoDashSecret()
+			return
+		})
+		if errNFound == nil {
+			if err = i.CopyToClipboard(*token); err == nil {
 				i.Notify("Liqo Agent", "The LiqoDash access token was copied in your clipboard",
 					app.NotifyIconDefault, app.IconLiqoNil)
 			} else {
@@ -179,3 +305,45 @@ func quickConnectDashboard(i *app.Indicator) {
 		}
 	}
 }
+
+//quickExportTopology is the callback function for the QUICK "Export topology". It lets the user choose an
+//export format and a destination directory, then saves there the graph of the peering topology.
+func quickExportTopology(i *app.Indicator) {
+	if app.GetGuiProvider().Mocked() {
+		return
+	}
+	formats := make([]string, 0, len(api.TopologyFormats))
+	for _, f := range api.TopologyFormats {
+		formats = append(formats, string(f))
+	}
+	format, ok, _ := dlgs.List("EXPORT TOPOLOGY", "Choose the export format of the peering topology.", formats)
+	if !ok {
+		return
+	}
+	dir, ok, _ := dlgs.File("Select the destination folder", "", true)
+	if !ok {
+		return
+	}
+	path := filepath.Join(dir, "liqo-topology."+format)
+	if err := exportTopology(i, api.TopologyFormat(format), path); err != nil {
+		i.ShowWarning("LIQO AGENT", "Liqo Agent could not export the peering topology:\n"+err.Error())
+		return
+	}
+	i.Notify("Liqo Agent", "The peering topology was exported to "+path, app.NotifyIconDefault, app.IconLiqoNil)
+}
+
+//exportTopology saves to path the current peering topology in the requested format.
+func exportTopology(i *app.Indicator, format api.TopologyFormat, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = api.BuildTopology(statusData(i.Status())).Export(format, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package logic
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"time"
+)
+
+const (
+	//titleQuietHours is the title of the QUICK showing the state of the quiet hours.
+	titleQuietHours = "Quiet hours"
+	//tQuietHours is the tag of the Timer refreshing the state of the quiet hours.
+	tQuietHours = "T_QUIET_HOURS"
+)
+
+//quietHoursSchedule is the app.Schedule of the Timer refreshing the state of the quiet hours. It triggers when the
+//quiet hours or the quiet period start or end, and at midnight to update the day in the title of the QUICK.
+type quietHoursSchedule struct {
+	i *app.Indicator
+}
+
+//Next returns the first change of the state of the quiet hours strictly after the given instant.
+func (s quietHoursSchedule) Next(after time.Time) (time.Time, bool) {
+	y, m, d := after.Date()
+	next := time.Date(y, m, d+1, 0, 0, 0, 0, after.Location())
+	if change, ok := s.i.QuietHours().NextChange(after); ok && change.Before(next) {
+		next = change
+	}
+	if until := s.i.QuietUntil(); until.After(after) && until.Before(next) {
+		next = until
+	}
+	return next, true
+}
+
+//String returns the description of the quietHoursSchedule.
+func (s quietHoursSchedule) String() string {
+	return "at the changes of the quiet hours"
+}
+
+//configureQuietHours applies the quiet hours of the local configuration. In case of invalid settings,
+//no quiet hours are set.
+func configureQuietHours(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	schedule, err := app.NewQuietSchedule(conf.GetQuietHours())
+	if err != nil {
+		i.Notify("Liqo Agent: INVALID QUIET HOURS", err.Error(), app.NotifyIconWarning, app.IconLiqoNil)
+		return
+	}
+	i.SetQuietHours(schedule)
+	rescheduleQuietHours(i, time.Now())
+}
+
+//rescheduleQuietHours refreshes the state of the quiet hours at now, after a change of the schedule or the quiet
+//period, and computes again the next refresh.
+func rescheduleQuietHours(i *app.Indicator, now time.Time) {
+	if quick, present := i.Quick(qQuietHours); present {
+		refreshQuietHours(quick, i.QuietHours(), i.QuietUntil(), now)
+	}
+	if timer, present := i.Timer(tQuietHours); present {
+		timer.SetSchedule(quietHoursSchedule{i})
+	}
+}
+
+//refreshQuietHours updates the QUICK showing whether the quiet hours, or the quiet period ending at until, are active
+//and until when.
+func refreshQuietHours(quick *app.MenuNode, schedule *app.QuietSchedule, until time.Time, now time.Time) {
+	if until.After(now) {
+		quick.SetIsVisible(true)
+		quick.SetTitle(titleQuietHours + ": ON until " + clockLabel(until, now))
+		return
+	}
+	quick.SetIsVisible(!schedule.Empty())
+	if schedule.Empty() {
+		return
+	}
+	quick.SetTitle(quietHoursTitle(schedule, now))
+}
+
+//quietHoursTitle returns the title describing the state of the quiet hours, e.g.
+//"Quiet hours: ON until 08:00" or "Quiet hours: off until Sat 00:00".
+func quietHoursTitle(schedule *app.QuietSchedule, now time.Time) string {
+	state := "off"
+	if schedule.Active(now) {
+		state = "ON"
+	}
+	title := titleQuietHours + ": " + state
+	if next, ok := schedule.NextChange(now); ok {
+		title += " until " + clockLabel(next, now)
+	}
+	return title
+}
+
+//clockLabel returns the time of the day of t, preceded by its day if different from the one of now, e.g. "08:00"
+//or "Sat 00:00".
+func clockLabel(t time.Time, now time.Time) string {
+	layout := "15:04"
+	if t.YearDay() != now.YearDay() || t.Year() != now.Year() {
+		layout = "Mon 15:04"
+	}
+	return t.Format(layout)
+}
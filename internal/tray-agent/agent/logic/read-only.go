@@ -0,0 +1,84 @@
+package logic
+
+import (
+	"context"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+)
+
+/*This file contains the read-only mode of the Agent, for environments where it must be strictly observational. In
+read-only mode the menu entries performing write actions (peerings, credentials refresh, upgrade, uninstallation and
+reset) are disabled, and the same actions started in other ways (e.g. from the pending items) are refused. The mode
+is toggled from the Settings section, unless it is enforced by the organization-wide defaults.*/
+
+const (
+	//titleReadOnly is the title of the QUICK toggling the read-only mode.
+	titleReadOnly = "Read-only Mode"
+	//activitySourceReadOnly is the activity.Feed source of the changes of the read-only mode.
+	activitySourceReadOnly = "read-only"
+)
+
+//startQuickReadOnly is the wrapper function to register QUICK "Read-only Mode".
+func startQuickReadOnly(i *app.Indicator) {
+	i.AddQuick(titleReadOnly, qReadOnly, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		quickToggleReadOnly(i)
+	}))
+	updateQuickReadOnly(i)
+}
+
+//quickToggleReadOnly is the callback for the QUICK "Read-only Mode", enabling or disabling the mode and saving the
+//choice in the local configuration.
+func quickToggleReadOnly(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	if conf.ReadOnlyEnforced() {
+		return
+	}
+	readOnly := !conf.GetReadOnly()
+	conf.SetReadOnly(readOnly)
+	if err := client.SaveLocalConfig(); err != nil {
+		logger.Warning("cannot save the read-only mode", "err", err)
+	}
+	msg := "Read-only mode disabled"
+	if readOnly {
+		msg = "Read-only mode enabled"
+	}
+	activity.GetFeed().Add(activitySourceReadOnly, msg, activity.OutcomeSuccess)
+	configureReadOnly(i)
+}
+
+//configureReadOnly applies the read-only mode of the local configuration to the Indicator.
+func configureReadOnly(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	i.SetReadOnly(conf.GetReadOnly())
+	updateQuickReadOnly(i)
+}
+
+//updateQuickReadOnly refreshes the title of the QUICK "Read-only Mode", which is disabled if the mode is enforced
+//by the organization-wide defaults.
+func updateQuickReadOnly(i *app.Indicator) {
+	quick, present := i.Quick(qReadOnly)
+	if !present {
+		return
+	}
+	conf, _ := client.GetLocalConfig()
+	switch {
+	case conf.ReadOnlyEnforced():
+		quick.SetTitle(titleReadOnly + ": ON (enforced by the organization)")
+	case conf.GetReadOnly():
+		quick.SetTitle(titleReadOnly + ": ON")
+	default:
+		quick.SetTitle(titleReadOnly + ": OFF")
+	}
+	quick.SetIsEnabled(!conf.ReadOnlyEnforced())
+}
+
+//writeAllowed returns whether a write action can be performed, warning the user if it is refused because of the
+//read-only mode.
+func writeAllowed(i *app.Indicator, action string) bool {
+	if !i.ReadOnly() {
+		return true
+	}
+	i.ShowWarning("LIQO AGENT: READ-ONLY MODE", "The Agent is in read-only mode: "+action+" is not allowed.")
+	return false
+}
@@ -0,0 +1,252 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/format"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*This file contains the reconnection of the Agent to the main cluster. While the AgentController is not connected
+(e.g. the cluster was not reachable when the Agent started), the connection is attempted again by the tReconnect
+Timer, at intervals growing exponentially according to the configured BackoffPolicy, or immediately by the
+"Reconnect now" QUICK.
+
+Meanwhile, the offline QUICK displays the last known status of the cluster, periodically saved in the cache
+directory while connected (see client.StatusCache), labeled with its age:
+	Offline: last known status, 2h ago
+	    Cluster: home-cluster
+	    Peers: 2, 1 active peering
+	    • peer-1 (outgoing)
+	    • peer-2
+*/
+
+const (
+	//titleReconnect is the title of the QUICK attempting the connection to the cluster.
+	titleReconnect = "Reconnect now"
+	//titleOffline is the title of the QUICK displaying the last known status of the cluster.
+	titleOffline = "Offline"
+	//tReconnect is the tag of the Timer attempting the connection to the cluster.
+	tReconnect = "T_RECONNECT"
+	//tStatusCache is the tag of the Timer saving the last known status of the cluster.
+	tStatusCache = "T_STATUS_CACHE"
+	//statusCacheInterval is the interval between two savings of the last known status of the cluster.
+	statusCacheInterval = time.Minute
+	//activitySourceReconnect is the activity.Feed source of the reconnections to the cluster.
+	activitySourceReconnect = "reconnect"
+)
+
+//reconnection contains the state of the reconnection to the cluster.
+var reconnection = struct {
+	//backoff computes the delays between the attempts. If nil, no reconnection is in progress.
+	backoff *client.Backoff
+	//attempts is the number of failed attempts since the reconnection started.
+	attempts int
+	sync.Mutex
+}{}
+
+//startQuickReconnect is the wrapper function to register the QUICKs "Reconnect now" and "Offline", visible only
+//while the Agent is not connected to the cluster. It also starts the Timers attempting the reconnection and saving
+//the last known status of the cluster.
+func startQuickReconnect(i *app.Indicator) {
+	i.AddQuick(titleReconnect, qReconnect, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		if timer, present := e.Indicator.Timer(tReconnect); present {
+			timer.Trigger()
+		}
+	}))
+	i.AddQuick(titleOffline, qOffline, nil)
+	_ = i.StartScheduledTimer(tReconnect, app.At(time.Time{}), func(args ...interface{}) {
+		attemptReconnect(i)
+	})
+	_ = i.StartTimer(tStatusCache, statusCacheInterval, func(args ...interface{}) {
+		saveStatusCache(i)
+	})
+	updateReconnection(i)
+}
+
+//updateReconnection starts the reconnection to the cluster if the AgentController is not connected, or stops it
+//otherwise (e.g. after a successful context switch), refreshing the related QUICKs.
+func updateReconnection(i *app.Indicator) {
+	if i.AgentCtrl().Connected() {
+		reconnection.Lock()
+		reconnection.backoff, reconnection.attempts = nil, 0
+		reconnection.Unlock()
+		refreshReconnect(i)
+		return
+	}
+	reconnection.Lock()
+	started := reconnection.backoff != nil
+	if !started {
+		conf, _ := client.GetLocalConfig()
+		reconnection.backoff = conf.GetBackoffPolicy().NewBackoff()
+	}
+	reconnection.Unlock()
+	if !started {
+		scheduleReconnect(i)
+	}
+}
+
+//scheduleReconnect schedules the next attempt of reconnection, after the delay of the backoff.
+func scheduleReconnect(i *app.Indicator) {
+	reconnection.Lock()
+	if reconnection.backoff == nil {
+		reconnection.Unlock()
+		return
+	}
+	delay := reconnection.backoff.Next()
+	reconnection.Unlock()
+	if timer, present := i.Timer(tReconnect); present {
+		timer.SetSchedule(app.At(time.Now().Add(delay)))
+	}
+	refreshReconnect(i)
+}
+
+//attemptReconnect is the callback of the tReconnect Timer, connecting the AgentController to the cluster. On
+//failure, the next attempt is scheduled.
+func attemptReconnect(i *app.Indicator) {
+	ctrl := i.AgentCtrl()
+	if ctrl.Connected() {
+		updateReconnection(i)
+		return
+	}
+	err := ctrl.Reconnect()
+	reconnection.Lock()
+	if err != nil {
+		reconnection.attempts++
+		attempts := reconnection.attempts
+		reconnection.Unlock()
+		logger.Debug("cannot reconnect to the cluster", "attempts", attempts, "err", err)
+		//the user is notified once, since the credentials are not expected to be fixed by retrying
+		if attempts == 1 && client.IsLoginRequiredError(err) {
+			notifyAuthenticationFailure(i, err)
+		}
+		scheduleReconnect(i)
+		return
+	}
+	attempts := reconnection.attempts
+	reconnection.backoff, reconnection.attempts = nil, 0
+	reconnection.Unlock()
+	i.DismissNotification(notificationIDAuthentication)
+	activity.GetFeed().Add(activitySourceReconnect, fmt.Sprintf("Reconnected to the cluster after %s",
+		format.Count(attempts+1, "attempt", "attempts")), activity.OutcomeSuccess)
+	refreshReconnect(i)
+	refreshContexts(i)
+	refreshPeeringRequests(i)
+	refreshResourceSharing(i)
+	//Liqo is started as at the start of the Agent, unless the user left it stopped
+	if i.Status().Running() == app.StatRunOff && !client.GetMenuStateStore().State().Stopped {
+		quickTurnOnOff(i)
+	} else if i.Status().Running() == app.StatRunOff {
+		i.SetIcon(app.IconLiqoOff)
+	}
+}
+
+//refreshReconnect updates the QUICKs "Reconnect now" and "Offline": they are visible only while the Agent is not
+//connected, the latter only if a status of the cluster has been saved.
+func refreshReconnect(i *app.Indicator) {
+	connected := i.AgentCtrl().Connected()
+	if quick, present := i.Quick(qReconnect); present {
+		title := titleReconnect
+		if timer, present := i.Timer(tReconnect); present && !connected {
+			if next := timer.NextFire(); !next.IsZero() {
+				title = fmt.Sprintf("%s (next attempt at %s)", titleReconnect, next.Format("15:04:05"))
+			}
+		}
+		quick.SetTitle(title)
+		quick.SetIsVisible(!connected)
+	}
+	quick, present := i.Quick(qOffline)
+	if !present {
+		return
+	}
+	status, saved := client.GetStatusCache().Status()
+	if connected || !saved {
+		quick.SetIsVisible(false)
+		quick.FreeListChildren()
+		return
+	}
+	refreshOffline(i, quick, status)
+	quick.SetIsVisible(true)
+}
+
+//refreshOffline updates the content of the offline QUICK with the last known status of the cluster.
+func refreshOffline(i *app.Indicator, quick *app.MenuNode, status client.CachedStatus) {
+	quick.SetTitle(fmt.Sprintf("%s: last known status, %s", titleOffline, format.Ago(status.SavedAt, i.Now())))
+	quick.FreeListChildren()
+	cluster := status.ClusterName
+	if status.Context != "" {
+		cluster += " (context " + status.Context + ")"
+	}
+	quick.UseListChild("Cluster: "+cluster, "cluster").SetIsEnabled(false)
+	active := 0
+	for _, peer := range status.Peers {
+		if peer.Outgoing || peer.Incoming {
+			active++
+		}
+	}
+	quick.UseListChild(fmt.Sprintf("Peers: %d, %s", len(status.Peers), format.Count(active, "active peering",
+		"active peerings")), "peers").SetIsEnabled(false)
+	for _, peer := range status.Peers {
+		quick.UseListChild(cachedPeerTitle(peer), "peer/"+peer.ClusterID).SetIsEnabled(false)
+	}
+}
+
+//cachedPeerTitle returns the description of a peer in the last known status of the cluster, e.g.
+//"• peer-1 (outgoing, incoming)".
+func cachedPeerTitle(peer client.CachedPeer) string {
+	name := peer.ClusterName
+	if name == "" {
+		name = peer.ClusterID
+	}
+	var peerings []string
+	if peer.Outgoing {
+		peerings = append(peerings, "outgoing")
+	}
+	if peer.Incoming {
+		peerings = append(peerings, "incoming")
+	}
+	if len(peerings) == 0 {
+		return "• " + name
+	}
+	return "• " + name + " (" + strings.Join(peerings, ", ") + ")"
+}
+
+//saveStatusCache saves the current status of the cluster in the StatusCache, to be displayed while the Agent is
+//offline. The status is saved only if connected and once the caches are synchronized, so that it is complete.
+func saveStatusCache(i *app.Indicator) {
+	ctrl := i.AgentCtrl()
+	status := i.Status()
+	if !ctrl.Connected() || !ctrl.Reachable() || !status.CacheSync().Done() {
+		return
+	}
+	cached := client.CachedStatus{ClusterName: status.ClusterName(), SavedAt: i.Now()}
+	if _, active, err := ctrl.Contexts(); err == nil {
+		cached.Context = active
+	}
+	for _, peer := range status.PeerList() {
+		peer.RLock()
+		cp := client.CachedPeer{ClusterID: peer.ClusterID, Outgoing: peer.OutPeeringConnected,
+			Incoming: peer.InPeeringConnected}
+		if !peer.Unknown {
+			cp.ClusterName = peer.ClusterName
+		}
+		peer.RUnlock()
+		cached.Peers = append(cached.Peers, cp)
+	}
+	sort.Slice(cached.Peers, func(a, b int) bool {
+		pa, pb := cached.Peers[a], cached.Peers[b]
+		if pa.ClusterName != pb.ClusterName {
+			return pa.ClusterName < pb.ClusterName
+		}
+		return pa.ClusterID < pb.ClusterID
+	})
+	if err := client.GetStatusCache().Save(cached); err != nil {
+		logger.Debug("cannot save the status cache", "err", err)
+	}
+}
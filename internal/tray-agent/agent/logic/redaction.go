@@ -0,0 +1,17 @@
+package logic
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/redact"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+)
+
+//configureRedaction applies the redaction settings of the local configuration. In case of invalid settings,
+//the default ones (redacting both secrets and server URLs) are kept.
+func configureRedaction(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	redaction := conf.GetRedaction()
+	if err := redact.GetRedactor().Configure(redaction.KeepServerURLs, redaction.Patterns); err != nil {
+		i.Notify("Liqo Agent: INVALID REDACTION SETTINGS", err.Error(), app.NotifyIconWarning, app.IconLiqoNil)
+	}
+}
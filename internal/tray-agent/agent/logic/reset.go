@@ -0,0 +1,205 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/history"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strings"
+)
+
+const (
+	//titleReset is the title of the QUICK resetting the Agent state.
+	titleReset = "Reset Agent…"
+	//activitySourceReset is the activity.Feed source of the Agent resets.
+	activitySourceReset = "reset"
+)
+
+//resetTarget is a part of the Agent state that can be cleared by a reset.
+type resetTarget string
+
+//resetTarget values, in the order they are cleared.
+const (
+	//resetCaches discards the cached cluster resources, listed again from the cluster.
+	resetCaches resetTarget = "caches"
+	//resetMenuState clears the menu state persisted across runs (notification level, last peer, stopped Agent, peers
+	//search and grouping, expanded lists).
+	resetMenuState resetTarget = "menuState"
+	//resetHistory clears the peering history.
+	resetHistory resetTarget = "history"
+	//resetActivity clears the activity feed and the failures in the pending items.
+	resetActivity resetTarget = "activity"
+	//resetConfig removes the configuration file, restoring the default settings.
+	resetConfig resetTarget = "config"
+)
+
+//resetTargets contains the resetTarget values, in the order they are cleared.
+var resetTargets = []resetTarget{resetCaches, resetMenuState, resetHistory, resetActivity, resetConfig}
+
+//resetTargetDescriptions contains the descriptions of the resetTarget values displayed to the user.
+var resetTargetDescriptions = map[resetTarget]string{
+	resetCaches:    "Cluster caches (reloaded from the cluster)",
+	resetMenuState: "Menu state (notification level, last peer, search, grouping, expanded lists)",
+	resetHistory:   "Peering history",
+	resetActivity:  "Activity feed and reported failures",
+	resetConfig:    "Configuration file (" + client.ConfigFileName + ")",
+}
+
+//startQuickReset is the wrapper function to register QUICK "Reset Agent…".
+func startQuickReset(i *app.Indicator) {
+	node := i.AddQuick(titleReset, qReset, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		quickResetAgent(i)
+	}))
+	node.SetWriteAction(true)
+}
+
+//quickResetAgent is the callback for the QUICK "Reset Agent…": the user selects the parts of the Agent state
+//to clear and confirms the reset.
+func quickResetAgent(i *app.Indicator) {
+	if app.GetGuiProvider().Mocked() || !writeAllowed(i, "the reset of the Agent") {
+		return
+	}
+	items := make([]string, 0, len(resetTargets))
+	for _, t := range resetTargets {
+		items = append(items, resetTargetDescriptions[t])
+	}
+	selected, ok, _ := dlgs.ListMulti("RESET AGENT", "Select the parts of the Liqo Agent state to clear:", items)
+	if !ok || len(selected) == 0 {
+		return
+	}
+	var targets []resetTarget
+	for _, t := range resetTargets {
+		for _, s := range selected {
+			if s == resetTargetDescriptions[t] {
+				targets = append(targets, t)
+			}
+		}
+	}
+	ok, _ = dlgs.Question("RESET AGENT", "The following data will be cleared:\n\n- "+strings.Join(selected, "\n- ")+
+		"\n\nThis operation cannot be undone. Do you want to continue?", true)
+	if !ok {
+		return
+	}
+	if err := resetAgent(i, targets); err != nil {
+		i.ShowWarning("RESET AGENT", "The Agent state has been partially reset:\n"+err.Error())
+		return
+	}
+	i.Notify("Liqo Agent: RESET COMPLETED", "The selected data have been cleared",
+		app.NotifyIconDefault, app.IconLiqoNil)
+}
+
+//resetAgent clears the selected parts of the Agent state and reinitializes the affected features.
+//The reset goes on in case of failures, which are returned together.
+func resetAgent(i *app.Indicator, targets []resetTarget) error {
+	var failures []string
+	fail := func(t resetTarget, err error) {
+		failures = append(failures, fmt.Sprintf("%s: %v", resetTargetDescriptions[t], err))
+	}
+	selected := make(map[resetTarget]bool)
+	for _, t := range targets {
+		selected[t] = true
+	}
+	if selected[resetCaches] {
+		forgetPeers(i)
+		if err := i.AgentCtrl().RestartCaches(); err != nil {
+			fail(resetCaches, err)
+		}
+		if err := client.GetStatusCache().Reset(); err != nil {
+			fail(resetCaches, err)
+		}
+		refreshReconnect(i)
+	}
+	if selected[resetMenuState] {
+		if err := client.GetMenuStateStore().Reset(); err != nil {
+			fail(resetMenuState, err)
+		}
+		i.NotificationSetLevel(app.NotifyLevelMax)
+	}
+	if selected[resetHistory] {
+		if err := history.GetStore().Clear(); err != nil {
+			fail(resetHistory, err)
+		}
+		refreshHistoryQuick(i)
+	}
+	if selected[resetActivity] {
+		activity.GetFeed().Clear()
+		if quick, present := i.Quick(qActivity); present {
+			refreshActivity(quick, activity.GetFeed())
+		}
+		for _, it := range i.Pending().Items() {
+			if strings.HasPrefix(it.ID, pendingFailurePrefix) {
+				i.Pending().Remove(it.ID)
+			}
+		}
+	}
+	if selected[resetConfig] {
+		if err := client.ResetLocalConfig(); err != nil {
+			fail(resetConfig, err)
+		}
+	}
+	reapplySettings(i)
+	summary := "Agent state reset: " + strings.Join(resetTargetNames(targets), ", ")
+	if len(failures) > 0 {
+		activity.GetFeed().Add(activitySourceReset, summary+" (with failures)", activity.OutcomeFailure)
+		return fmt.Errorf("%s", strings.Join(failures, "\n"))
+	}
+	activity.GetFeed().Add(activitySourceReset, summary, activity.OutcomeSuccess)
+	return nil
+}
+
+//resetTargetNames returns the names of the reset targets.
+func resetTargetNames(targets []resetTarget) []string {
+	names := make([]string, 0, len(targets))
+	for _, t := range targets {
+		names = append(names, string(t))
+	}
+	return names
+}
+
+//forgetPeers removes all the peers from the Indicator Status and from the peers list, e.g. before their
+//ForeignClusters are listed again from the cluster.
+func forgetPeers(i *app.Indicator) {
+	quick, present := i.Quick(qPeers)
+	for _, peer := range i.Status().PeerList() {
+		peer.RLock()
+		clusterID := peer.ClusterID
+		peer.RUnlock()
+		i.Status().RemovePeer(&client.NotifyDataForeignCluster{ClusterID: clusterID})
+		if present {
+			removePeerEntry(quick, clusterID)
+		}
+		forgetRenderedPeer(clusterID)
+		forgetPeerLatency(clusterID)
+	}
+	i.RefreshStatus()
+	if present {
+		refreshPeerCount(quick)
+	}
+}
+
+//reapplySettings applies again the settings of the local configuration and of the menu state, e.g. after a reset.
+func reapplySettings(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	i.SetIconTheme(app.ParseIconTheme(conf.GetIconTheme()))
+	i.SetColorScheme(app.ParseColorScheme(conf.GetColorScheme()))
+	i.SetIconBadge(conf.GetIconBadge())
+	configureReadOnly(i)
+	configureRedaction(i)
+	configureDebugLogging(i)
+	configureQuietHours(i)
+	configureDoNotDisturb(i)
+	configureSessionLock(i)
+	configureHotkeys(i)
+	configureLanguage(i)
+	configureRefreshInterval(i)
+	configureListPages(i)
+	configurePeerPreferences(i)
+	restoreMenuState(i)
+	i.SetLabelMode(app.ParseLabelMode(conf.GetLabelMode()))
+	i.SetLabelFormat(conf.GetLabelFormat(), conf.GetLabelAlways())
+	//the peer entries are rebuilt, e.g. dropping the marker of the last peer
+	regroupPeers(i)
+}
@@ -0,0 +1,156 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strconv"
+	"strings"
+)
+
+/*This file contains the ACTION "Resource sharing", changing the percentage of the resources of the home cluster
+offered to the peers (the ResourceSharingPercentage of the ClusterConfig). Its OPTIONs are the preset percentages
+and a custom one typed by the user, the current value being checked.*/
+
+const (
+	//aResourceSharing is the tag of the ACTION changing the resources offered to the peers.
+	aResourceSharing = "A_RESOURCE_SHARING"
+	//titleResourceSharing is the title of the ACTION changing the resources offered to the peers.
+	titleResourceSharing = "Resource sharing"
+	//oSharingCustom is the tag of the OPTION setting a custom resource sharing percentage.
+	oSharingCustom = "O_SHARING_CUSTOM"
+	//titleSharingCustom is the title of the OPTION setting a custom resource sharing percentage.
+	titleSharingCustom = "Custom…"
+	//activitySourceResourceSharing is the activity.Feed source of the changes of the resource sharing percentage.
+	activitySourceResourceSharing = "resourceSharing"
+)
+
+//sharingPresets contains the preset resource sharing percentages.
+var sharingPresets = []int32{25, 50, 75}
+
+//sharingOptionTag returns the tag of the OPTION setting a preset resource sharing percentage.
+func sharingOptionTag(percentage int32) string {
+	return fmt.Sprintf("O_SHARING_%d", percentage)
+}
+
+//startActionResourceSharing is the wrapper function to register the ACTION "Resource sharing".
+func startActionResourceSharing(i *app.Indicator) {
+	action := i.AddAction(titleResourceSharing, aResourceSharing, nil)
+	for _, p := range sharingPresets {
+		percentage := p
+		option := action.AddOption(fmt.Sprintf("%d%%", percentage), sharingOptionTag(percentage),
+			fmt.Sprintf("Offer %d%% of the cluster resources to the peers", percentage), true,
+			app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+				setResourceSharing(ctx, e.Indicator, percentage)
+			}))
+		option.SetIsRadio(true)
+		option.SetWriteAction(true)
+	}
+	custom := action.AddOption(titleSharingCustom, oSharingCustom, "Type the percentage of the cluster resources "+
+		"offered to the peers", true, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		askResourceSharing(ctx, e.Indicator)
+	}))
+	custom.SetIsRadio(true)
+	custom.SetWriteAction(true)
+	refreshResourceSharing(i)
+}
+
+//refreshResourceSharing updates the aResourceSharing ACTION with the current resource sharing percentage,
+//checking the matching OPTION. If the ClusterConfig is not available, the ACTION is disabled.
+func refreshResourceSharing(i *app.Indicator) {
+	action, present := i.Action(aResourceSharing)
+	if !present {
+		return
+	}
+	percentage, err := i.AgentCtrl().ResourceSharingPercentage()
+	if err != nil {
+		action.SetTitle(titleResourceSharing)
+		action.SetIsEnabled(false)
+		return
+	}
+	action.SetTitle(fmt.Sprintf("%s: %d%%", titleResourceSharing, percentage))
+	action.SetIsEnabled(true)
+	preset := false
+	for _, p := range sharingPresets {
+		if option, present := action.Option(sharingOptionTag(p)); present {
+			option.SetIsChecked(p == percentage)
+		}
+		preset = preset || p == percentage
+	}
+	if custom, present := action.Option(oSharingCustom); present {
+		custom.SetIsChecked(!preset)
+		if preset {
+			custom.SetTitle(titleSharingCustom)
+		} else {
+			custom.SetTitle(fmt.Sprintf("Custom (%d%%)…", percentage))
+		}
+	}
+}
+
+//askResourceSharing asks the user for a custom resource sharing percentage, then applies it.
+func askResourceSharing(ctx context.Context, i *app.Indicator) {
+	if !writeAllowed(i, "the change of the shared resources") {
+		return
+	}
+	if !i.AgentCtrl().Connected() {
+		i.ShowErrorNoConnection()
+		return
+	}
+	current, err := i.AgentCtrl().ResourceSharingPercentage()
+	if err != nil {
+		i.ShowClientError("Liqo Agent: RESOURCE SHARING NOT CHANGED", err)
+		return
+	}
+	value, ok := app.GetGuiProvider().InputDialog("Liqo Agent: RESOURCE SHARING",
+		"Type the percentage (0-100) of the cluster resources offered to the peers:", strconv.Itoa(int(current)))
+	if !ok {
+		//the check mark is restored on the current value
+		refreshResourceSharing(i)
+		return
+	}
+	percentage, err := parseSharingPercentage(value)
+	if err != nil {
+		refreshResourceSharing(i)
+		i.ShowError("Liqo Agent: INVALID PERCENTAGE", err.Error())
+		return
+	}
+	setResourceSharing(ctx, i, percentage)
+}
+
+//parseSharingPercentage parses a resource sharing percentage typed by the user, e.g. "30" or "30%".
+func parseSharingPercentage(value string) (int32, error) {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "%")
+	percentage, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || percentage < 0 || percentage > 100 {
+		return 0, fmt.Errorf("%q is not a percentage between 0 and 100", value)
+	}
+	return int32(percentage), nil
+}
+
+//setResourceSharing changes the percentage of the resources of the home cluster offered to the peers.
+func setResourceSharing(ctx context.Context, i *app.Indicator, percentage int32) {
+	if !writeAllowed(i, "the change of the shared resources") {
+		refreshResourceSharing(i)
+		return
+	}
+	if !i.AgentCtrl().Connected() {
+		refreshResourceSharing(i)
+		i.ShowErrorNoConnection()
+		return
+	}
+	err := runOperation(ctx, i, opResourceSharing, func(ctx context.Context) error {
+		return i.AgentCtrl().SetResourceSharingPercentage(percentage)
+	})
+	refreshResourceSharing(i)
+	if err != nil {
+		activity.GetFeed().Add(activitySourceResourceSharing, fmt.Sprintf("Change of the shared resources to %d%% "+
+			"failed", percentage), activity.OutcomeFailure)
+		i.ShowClientError("Liqo Agent: RESOURCE SHARING NOT CHANGED", err)
+		return
+	}
+	activity.GetFeed().Add(activitySourceResourceSharing, fmt.Sprintf("Shared resources set to %d%%", percentage),
+		activity.OutcomeSuccess)
+	i.Notify("Liqo Agent", fmt.Sprintf("The cluster now offers %d%% of its resources to the peers", percentage),
+		app.NotifyIconDefault, app.IconLiqoNil)
+}
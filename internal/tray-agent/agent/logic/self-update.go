@@ -0,0 +1,186 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"github.com/skratchdot/open-golang/open"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*This file contains the self-update of the Agent. Its latest release is periodically checked on GitHub (see
+client.LatestAgentRelease): when a newer version is available, the user is notified and the "Update Liqo Agent…"
+QUICK is shown. By default, the QUICK opens the page of the release; with 'selfUpdate.install: true' in the local
+configuration, it downloads and installs the new executable instead, and then proposes to restart the Agent.*/
+
+const (
+	//titleAgentUpdate is the title of the QUICK updating the Agent.
+	titleAgentUpdate = "Update Liqo Agent…"
+	//titleAgentRestart is the title of the QUICK restarting the Agent after an update.
+	titleAgentRestart = "Restart Liqo Agent to complete the update"
+	//tAgentUpdate is the tag of the Timer checking the availability of a new Agent version.
+	tAgentUpdate = "T_AGENT_UPDATE"
+	//agentUpdateCheckInterval is the interval between two checks of the availability of a new Agent version.
+	agentUpdateCheckInterval = 24 * time.Hour
+	//activitySourceAgentUpdate is the activity.Feed source of the Agent updates.
+	activitySourceAgentUpdate = "agentUpdate"
+)
+
+//agentUpdateState contains the state of the Agent self-update.
+type agentUpdateState struct {
+	//available is the newer release of the Agent. If nil, no update is available.
+	available *client.AgentRelease
+	//installing specifies whether an update is being installed.
+	installing bool
+	//installed is the version installed by the last update, which is applied at the restart of the Agent.
+	installed string
+	sync.Mutex
+}
+
+//agentUpdate contains the state of the Agent self-update.
+var agentUpdate = &agentUpdateState{}
+
+//startQuickAgentUpdate is the wrapper function to register QUICK "Update Liqo Agent…", visible only when a new
+//version of the Agent is available.
+func startQuickAgentUpdate(i *app.Indicator) {
+	node := i.AddQuick(titleAgentUpdate, qAgentUpdate, app.ClickHandlerFunc(func(ctx context.Context,
+		e *app.ClickEvent) {
+		quickUpdateAgent(ctx, i)
+	}))
+	node.SetIsVisible(false)
+	if !i.AgentCtrl().Mocked() {
+		go checkAgentUpdate(i.Context(), i)
+	}
+	interval := configuredInterval(intervals().AgentUpdate, agentUpdateCheckInterval)
+	_ = i.StartTimer(tAgentUpdate, interval, func(args ...interface{}) {
+		checkAgentUpdate(i.Context(), i)
+	})
+}
+
+//checkAgentUpdate checks whether a newer version of the Agent is available. In that case, the user is notified and
+//the update QUICK is shown.
+func checkAgentUpdate(ctx context.Context, i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	settings := conf.GetSelfUpdate()
+	if settings.Disabled {
+		return
+	}
+	release, err := client.LatestAgentRelease(ctx, settings.ReleasesURL)
+	if err != nil {
+		logger.Debug("cannot check the Agent updates", "err", err)
+		return
+	}
+	agentUpdate.Lock()
+	defer agentUpdate.Unlock()
+	if agentUpdate.installing || agentUpdate.installed != "" {
+		return
+	}
+	if !release.NewerThan(client.AgentVersion) {
+		release = nil
+	} else if agentUpdate.available == nil || agentUpdate.available.Version != release.Version {
+		i.Notify("Liqo Agent: NEW AGENT VERSION",
+			fmt.Sprintf("Liqo Agent %s is available (running: %s)", release.Version, client.AgentVersion),
+			app.NotifyIconDefault, app.IconLiqoNil)
+	}
+	agentUpdate.available = release
+	if quick, present := i.Quick(qAgentUpdate); present {
+		quick.SetTitle(agentUpdateTitle(release))
+		quick.SetIsVisible(release != nil)
+	}
+}
+
+//agentUpdateTitle returns the title of the update QUICK for an available release.
+func agentUpdateTitle(release *client.AgentRelease) string {
+	if release == nil {
+		return titleAgentUpdate
+	}
+	return strings.TrimSuffix(titleAgentUpdate, "…") + " to " + release.Version + "…"
+}
+
+//quickUpdateAgent is the callback for the QUICK "Update Liqo Agent…". Unless the installation of the updates is
+//enabled in the local configuration, it opens the page of the new release. Otherwise, after the user
+//confirmation, it installs the new release and proposes to restart the Agent. Once the update is installed, the
+//QUICK restarts the Agent.
+func quickUpdateAgent(ctx context.Context, i *app.Indicator) {
+	agentUpdate.Lock()
+	release, installing, installed := agentUpdate.available, agentUpdate.installing, agentUpdate.installed
+	agentUpdate.Unlock()
+	if installed != "" {
+		restartAgent(i)
+		return
+	}
+	if installing || release == nil || app.GetGuiProvider().Mocked() {
+		return
+	}
+	conf, _ := client.GetLocalConfig()
+	if !conf.GetSelfUpdate().Install {
+		if err := open.Start(release.PageURL); err != nil {
+			i.ShowWarning("LIQO AGENT", "Liqo Agent could not open the release page:\n"+err.Error())
+		}
+		return
+	}
+	ok, _ := dlgs.Question("UPDATE LIQO AGENT", fmt.Sprintf("Do you want to update Liqo Agent from %s to %s?",
+		client.AgentVersion, release.Version), false)
+	if !ok {
+		return
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		i.ShowError("LIQO AGENT UPDATE FAILED", err.Error())
+		return
+	}
+	go installAgentUpdate(ctx, i, release, executable)
+}
+
+//installAgentUpdate installs a release of the Agent in place of executable, and then proposes to restart the Agent.
+func installAgentUpdate(ctx context.Context, i *app.Indicator, release *client.AgentRelease, executable string) {
+	agentUpdate.Lock()
+	if agentUpdate.installing {
+		agentUpdate.Unlock()
+		return
+	}
+	agentUpdate.installing = true
+	agentUpdate.Unlock()
+	quick, _ := i.Quick(qAgentUpdate)
+	quick.SetIsEnabled(false)
+	quick.SetTitle("Updating Liqo Agent to " + release.Version + "…")
+	feed := activity.GetFeed()
+	feed.Add(activitySourceAgentUpdate, fmt.Sprintf("Agent update to %s started", release.Version),
+		activity.OutcomeInfo)
+	err := client.InstallAgentRelease(ctx, release, executable)
+	agentUpdate.Lock()
+	agentUpdate.installing = false
+	if err == nil {
+		agentUpdate.installed = release.Version
+	}
+	agentUpdate.Unlock()
+	quick.SetIsEnabled(true)
+	if err != nil {
+		quick.SetTitle(agentUpdateTitle(release))
+		feed.Add(activitySourceAgentUpdate, fmt.Sprintf("Agent update to %s failed: %v", release.Version, err),
+			activity.OutcomeFailure)
+		i.ShowError("LIQO AGENT UPDATE FAILED", err.Error())
+		return
+	}
+	quick.SetTitle(titleAgentRestart)
+	feed.Add(activitySourceAgentUpdate, fmt.Sprintf("Agent updated to %s", release.Version), activity.OutcomeSuccess)
+	if app.GetGuiProvider().Mocked() {
+		return
+	}
+	if ok, _ := dlgs.Question("LIQO AGENT UPDATED", fmt.Sprintf("Liqo Agent %s has been installed.\n"+
+		"Do you want to restart Liqo Agent now?", release.Version), true); ok {
+		restartAgent(i)
+	}
+}
+
+//restartAgent quits the Agent, requesting to start it again (see client.RequestRestart).
+func restartAgent(i *app.Indicator) {
+	client.RequestRestart()
+	i.Quit()
+}
@@ -0,0 +1,20 @@
+package logic
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+)
+
+//configureSessionLock applies the 'notifyWhileLocked' setting of the local configuration: unless set, the
+//notifications raised while the desktop session is locked are deferred until it is unlocked
+//(see app.Indicator.WatchSessionLock).
+func configureSessionLock(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	if conf.GetNotifyWhileLocked() {
+		i.StopWatchingSessionLock()
+		return
+	}
+	if err := i.WatchSessionLock(); err != nil {
+		logger.Debug("notifications not deferred while the session is locked", "err", err)
+	}
+}
@@ -0,0 +1,182 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/i18n"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/settings"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"github.com/skratchdot/open-golang/open"
+	"sort"
+)
+
+/*This file contains the "Settings…" entry of the Settings section, opening in the browser the settings page served
+by the Agent (see package settings). The page is served, on a random local port, from the first click until the
+Agent exits. The submitted settings are validated, saved in the local configuration and applied at once, as after a
+reset of the Agent.*/
+
+const (
+	//titleSettingsPage is the title of the QUICK opening the settings page.
+	titleSettingsPage = "Settings…"
+	//activitySourceSettings is the activity.Feed source of the changes made from the settings page.
+	activitySourceSettings = "settings"
+)
+
+//colorSchemeDescriptions maps each app.ColorScheme into its user-friendly description.
+var colorSchemeDescriptions = map[app.ColorScheme]string{
+	app.ColorSchemeAuto:  "Follow the desktop",
+	app.ColorSchemeLight: "Light panels",
+	app.ColorSchemeDark:  "Dark panels",
+}
+
+//labelModeDescriptions maps each app.LabelMode into its user-friendly description.
+var labelModeDescriptions = map[app.LabelMode]string{
+	app.LabelModeCounts: "Active peerings",
+	app.LabelModeTrend:  "CPU acquired from the peers",
+}
+
+//startQuickSettingsPage is the wrapper function to register QUICK "Settings…".
+func startQuickSettingsPage(i *app.Indicator) {
+	i.AddQuick(titleSettingsPage, qSettingsPage, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		quickOpenSettingsPage(i)
+	}))
+}
+
+//quickOpenSettingsPage is the callback for the QUICK "Settings…", starting to serve the settings page if needed and
+//opening it in the default browser.
+func quickOpenSettingsPage(i *app.Indicator) {
+	if err := startSettingsPage(i); err != nil {
+		i.ShowWarning("LIQO AGENT", "Liqo Agent could not serve the settings page:\n"+err.Error())
+		return
+	}
+	if app.GetGuiProvider().Mocked() {
+		return
+	}
+	if err := open.Start(settings.GetServer().URL()); err != nil {
+		i.ShowWarning("LIQO AGENT", "Liqo Agent could not open the settings page:\n"+err.Error())
+	}
+}
+
+//startSettingsPage starts, if not running, the server of the settings page.
+func startSettingsPage(i *app.Indicator) error {
+	server := settings.GetServer()
+	if server.Running() {
+		return nil
+	}
+	server.SetFormFunc(func() *settings.Form {
+		return settingsForm()
+	})
+	server.SetApplyFunc(func(values *settings.Values) error {
+		return applySettings(i, values)
+	})
+	return server.Start(settings.DefaultAddress)
+}
+
+//stopSettingsPage stops the server of the settings page, if running.
+func stopSettingsPage() {
+	settings.GetServer().Stop()
+}
+
+//settingsForm returns the settings.Form of the local configuration.
+func settingsForm() *settings.Form {
+	conf, _ := client.GetLocalConfig()
+	language := conf.GetLanguage()
+	if language == "" {
+		language = i18n.Language()
+	}
+	form := &settings.Form{
+		Values: settings.Values{
+			Language:       language,
+			IconTheme:      string(app.ParseIconTheme(conf.GetIconTheme())),
+			ColorScheme:    string(app.ParseColorScheme(conf.GetColorScheme())),
+			IconBadge:      conf.GetIconBadge(),
+			LabelMode:      string(app.ParseLabelMode(conf.GetLabelMode())),
+			PeerGroupLabel: peerGroupLabel(),
+			DoNotDisturb:   conf.GetDoNotDisturb().Enabled,
+			ReadOnly:       conf.GetReadOnly(),
+			DebugLogging:   conf.GetDebugLogging(),
+		},
+		Choices: map[string][]settings.Choice{},
+	}
+	for _, l := range i18n.Languages() {
+		form.Choices["language"] = append(form.Choices["language"], settings.Choice{Value: l,
+			Description: i18n.LanguageName(l)})
+	}
+	for theme, description := range app.IconThemeDescriptions {
+		form.Choices["iconTheme"] = append(form.Choices["iconTheme"], settings.Choice{Value: string(theme),
+			Description: description})
+	}
+	for scheme, description := range colorSchemeDescriptions {
+		form.Choices["colorScheme"] = append(form.Choices["colorScheme"], settings.Choice{Value: string(scheme),
+			Description: description})
+	}
+	for mode, description := range labelModeDescriptions {
+		form.Choices["labelMode"] = append(form.Choices["labelMode"], settings.Choice{Value: string(mode),
+			Description: description})
+	}
+	for _, choices := range form.Choices {
+		sort.SliceStable(choices, func(a, b int) bool {
+			return choices[a].Value < choices[b].Value
+		})
+	}
+	if conf.ReadOnlyEnforced() {
+		form.Locked = append(form.Locked, "readOnly")
+	}
+	return form
+}
+
+//applySettings validates the settings.Values submitted by the settings page, saves them in the local configuration
+//and applies them to the Indicator.
+func applySettings(i *app.Indicator, values *settings.Values) error {
+	form := settingsForm()
+	for name, value := range map[string]string{
+		"language":    values.Language,
+		"iconTheme":   values.IconTheme,
+		"colorScheme": values.ColorScheme,
+		"labelMode":   values.LabelMode,
+	} {
+		if !validChoice(form.Choices[name], value) {
+			return fmt.Errorf("invalid %s '%s'", name, value)
+		}
+	}
+	conf, _ := client.GetLocalConfig()
+	if conf.ReadOnlyEnforced() {
+		if !values.ReadOnly {
+			return errors.New("the read-only mode is enforced by the organization")
+		}
+	} else {
+		conf.SetReadOnly(values.ReadOnly)
+	}
+	conf.SetLanguage(values.Language)
+	conf.SetIconTheme(values.IconTheme)
+	conf.SetColorScheme(values.ColorScheme)
+	conf.SetIconBadge(values.IconBadge)
+	conf.SetLabelMode(values.LabelMode)
+	conf.SetPeerGroupLabel(values.PeerGroupLabel)
+	//the saved label replaces the one selected from the menu
+	recordPeerGroupLabel(nil)
+	conf.SetDoNotDisturb(values.DoNotDisturb)
+	conf.SetDebugLogging(values.DebugLogging)
+	//the settings are applied to the current session even if they cannot be saved
+	reapplySettings(i)
+	if err := client.SaveLocalConfig(); err != nil {
+		activity.GetFeed().Add(activitySourceSettings, "Settings changed from the settings page could not be saved",
+			activity.OutcomeFailure)
+		return fmt.Errorf("cannot save the settings: %w", err)
+	}
+	activity.GetFeed().Add(activitySourceSettings, "Settings changed from the settings page", activity.OutcomeSuccess)
+	return nil
+}
+
+//validChoice returns whether a value is one of the choices.
+func validChoice(choices []settings.Choice, value string) bool {
+	for _, c := range choices {
+		if c.Value == value {
+			return true
+		}
+	}
+	return false
+}
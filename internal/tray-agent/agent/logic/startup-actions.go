@@ -0,0 +1,144 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strings"
+	"time"
+)
+
+/*This file contains the actions performed automatically once the Agent started, listed in the 'startupActions'
+field of the configuration file (see client.StartupActionConfig). The actions on the cluster are executed as
+operations (see runOperation), and their outcome is summarized with a single notification.*/
+
+const (
+	//activitySourceStartupActions is the activity.Feed source of the outcomes of the startup actions.
+	activitySourceStartupActions = "startupActions"
+	//notificationStartupActions is the ID of the Notification summarizing the failed startup actions.
+	notificationStartupActions = "startupActions"
+)
+
+//startupActionResult is the outcome of a startup action.
+type startupActionResult struct {
+	action client.StartupActionConfig
+	//detail describes the effects of a successful action.
+	detail string
+	err    error
+}
+
+//runStartupActions starts the startup actions of the local configuration, if any.
+func runStartupActions(i *app.Indicator) {
+	conf, _ := client.GetLocalConfig()
+	actions := conf.GetStartupActions()
+	if len(actions) == 0 {
+		return
+	}
+	go func() {
+		notifyStartupActions(i, performStartupActions(i.Context(), i, actions, time.Now()))
+	}()
+}
+
+//performStartupActions performs a sequence of startup actions, recording their outcome in the activity feed.
+func performStartupActions(ctx context.Context, i *app.Indicator, actions []client.StartupActionConfig,
+	now time.Time) []startupActionResult {
+	results := make([]startupActionResult, 0, len(actions))
+	for _, a := range actions {
+		r := startupActionResult{action: a}
+		r.detail, r.err = performStartupAction(ctx, i, a, now)
+		if r.err != nil {
+			activity.GetFeed().Add(activitySourceStartupActions, a.String()+": "+r.err.Error(),
+				activity.OutcomeFailure)
+		} else {
+			activity.GetFeed().Add(activitySourceStartupActions, a.String()+": "+r.detail, activity.OutcomeSuccess)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+//performStartupAction performs a startup action, returning a description of its effects.
+func performStartupAction(ctx context.Context, i *app.Indicator, a client.StartupActionConfig,
+	now time.Time) (string, error) {
+	ctrl := i.AgentCtrl()
+	//the actions on the cluster are subject to the read-only mode and require a connection
+	if a.Type == client.StartupEnableOffloading || a.Type == client.StartupPeer {
+		if i.ReadOnly() {
+			return "", fmt.Errorf("not allowed in read-only mode")
+		}
+		if !ctrl.Connected() {
+			return "", fmt.Errorf("not connected to the cluster")
+		}
+	}
+	switch a.Type {
+	case client.StartupEnableOffloading:
+		var enabled []string
+		err := runOperation(ctx, i, opEnableOffloading, func(context.Context) (err error) {
+			enabled, err = ctrl.EnableOffloading(a.Namespaces)
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("offloading enabled for %d namespaces", len(enabled)), nil
+	case client.StartupPeer:
+		var started []string
+		for _, peer := range a.Peers {
+			fcName, err := startPeeringWith(ctx, i, peer)
+			if err != nil {
+				return "", fmt.Errorf("cannot start the peering with %s: %w", peer, err)
+			}
+			started = append(started, fcName)
+		}
+		return "peerings started with " + strings.Join(started, ", "), nil
+	case client.StartupQuietUntil:
+		until, err := todayClock(a.Until, now)
+		if err != nil {
+			return "", err
+		}
+		//the Agent started after the end of the quiet period
+		if !until.After(now) {
+			return a.Until + " already passed, notifications not silenced", nil
+		}
+		i.SetQuietUntil(until)
+		rescheduleQuietHours(i, now)
+		return "notifications silenced until " + clockLabel(until, now), nil
+	default:
+		return "", fmt.Errorf("unknown action type '%s'", a.Type)
+	}
+}
+
+//todayClock returns the instant of the day of now at a "HH:MM" time of the day.
+func todayClock(clock string, now time.Time) (time.Time, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time '%s', expected HH:MM", clock)
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location()), nil
+}
+
+//notifyStartupActions summarizes the outcome of the startup actions with a notification, listing the failed ones.
+func notifyStartupActions(i *app.Indicator, results []startupActionResult) {
+	var failed []string
+	for _, r := range results {
+		if r.err != nil {
+			failed = append(failed, r.action.String()+": "+r.err.Error())
+		}
+	}
+	if len(failed) == 0 {
+		i.DismissNotification(notificationStartupActions)
+		i.Notify("Liqo Agent: STARTUP ACTIONS", fmt.Sprintf("%d startup actions completed", len(results)),
+			app.NotifyIconDefault, app.IconLiqoNil)
+		return
+	}
+	i.ShowNotification(app.Notification{
+		ID:    notificationStartupActions,
+		Title: "Liqo Agent: STARTUP ACTIONS FAILED",
+		Message: fmt.Sprintf("%d of %d startup actions failed:\n%s", len(failed), len(results),
+			strings.Join(failed, "\n")),
+		Severity: app.SeverityWarning,
+		Category: app.CategoryOperation,
+	})
+}
@@ -0,0 +1,227 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	//activitySourceStartup is the activity.Feed source of the startup summary.
+	activitySourceStartup = "startup"
+	//titleStatusWindow is the title of the QUICK opening the Status window.
+	titleStatusWindow = "Status…"
+)
+
+//Stages of the Agent startup, with the progress percentage reached when they begin.
+const (
+	stageConfig  = "Loading configuration"
+	stageConnect = "Connecting to the cluster"
+	stageMenu    = "Building the menu"
+	stageCaches  = "Syncing caches"
+)
+
+//startupStagePercent is the progress percentage reached at the beginning of each stage. The cache
+//synchronization fills the remaining part.
+var startupStagePercent = map[string]int{
+	stageConfig:  0,
+	stageConnect: 10,
+	stageMenu:    40,
+	stageCaches:  50,
+}
+
+//startupStage is a completed (or in progress) stage of the Agent startup.
+type startupStage struct {
+	name    string
+	started time.Time
+	//elapsed is the duration of the stage, set when the next one begins.
+	elapsed time.Duration
+}
+
+//startupTracker records the stages of the Agent startup, displaying their progress on a splash ProgressWindow
+//if enabled. The final summary is kept for the Status window.
+type startupTracker struct {
+	mutex   sync.Mutex
+	started time.Time
+	stages  []*startupStage
+	//window is the splash ProgressWindow, nil if disabled.
+	window  app.ProgressWindow
+	done    bool
+	summary string
+}
+
+//startup contains the tracker of the current Agent startup.
+var startup = struct {
+	tracker *startupTracker
+	sync.Mutex
+}{tracker: &startupTracker{}}
+
+//currentStartup returns the tracker of the current Agent startup.
+func currentStartup() *startupTracker {
+	startup.Lock()
+	defer startup.Unlock()
+	return startup.tracker
+}
+
+//beginStartup starts tracking a new Agent startup, opening the splash window if enabled in the local configuration,
+//which is loaded to this purpose.
+func beginStartup() *startupTracker {
+	t := &startupTracker{started: time.Now()}
+	t.stage(stageConfig)
+	client.LoadLocalConfig()
+	if conf, _ := client.GetLocalConfig(); conf.GetStartupSplash() {
+		t.window = app.NewProgressWindow("Liqo Agent")
+		t.window.Update(startupStagePercent[stageConfig], stageConfig+"…")
+	}
+	startup.Lock()
+	startup.tracker = t
+	startup.Unlock()
+	return t
+}
+
+//stage begins a new stage of the startup, completing the previous one.
+func (t *startupTracker) stage(name string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.done {
+		return
+	}
+	now := time.Now()
+	if n := len(t.stages); n > 0 {
+		t.stages[n-1].elapsed = now.Sub(t.stages[n-1].started)
+	}
+	t.stages = append(t.stages, &startupStage{name: name, started: now})
+	if t.window != nil {
+		t.window.Update(startupStagePercent[name], name+"…")
+	}
+}
+
+//cacheSync updates the progress of the cache synchronization, completing the startup once done.
+//It returns whether the startup has been completed by this call.
+func (t *startupTracker) cacheSync(i *app.Indicator, progress client.CacheSyncProgress) bool {
+	t.mutex.Lock()
+	if t.done {
+		t.mutex.Unlock()
+		return false
+	}
+	if !progress.Done() {
+		if t.window != nil {
+			base := startupStagePercent[stageCaches]
+			t.window.Update(base+(100-base)*progress.Synced/progress.Total, stageCaches+": "+progress.String())
+		}
+		t.mutex.Unlock()
+		return false
+	}
+	t.mutex.Unlock()
+	t.finish(i)
+	return true
+}
+
+//finish completes the startup, recording its summary in the activity feed and starting the startup actions. If the
+//splash window is enabled, the summary is notified in place of the window.
+func (t *startupTracker) finish(i *app.Indicator) {
+	t.mutex.Lock()
+	if t.done {
+		t.mutex.Unlock()
+		return
+	}
+	now := time.Now()
+	if n := len(t.stages); n > 0 {
+		t.stages[n-1].elapsed = now.Sub(t.stages[n-1].started)
+	}
+	t.done = true
+	summary, outcome := startupSummary(i, now.Sub(t.started))
+	t.summary = summary
+	window := t.window
+	t.mutex.Unlock()
+	activity.GetFeed().Add(activitySourceStartup, summary, outcome)
+	runStartupActions(i)
+	if window == nil {
+		return
+	}
+	window.Close()
+	if outcome == activity.OutcomeSuccess {
+		i.Notify("Liqo Agent: READY", summary, app.NotifyIconDefault, app.IconLiqoNil)
+	}
+}
+
+//startupSummary returns the summary of a completed startup.
+func startupSummary(i *app.Indicator, elapsed time.Duration) (string, activity.Outcome) {
+	elapsed = elapsed.Round(time.Millisecond)
+	if !i.AgentCtrl().Connected() {
+		return fmt.Sprintf("Liqo Agent started in %s, not connected to the cluster", elapsed), activity.OutcomeFailure
+	}
+	clusterName := i.Status().ClusterName()
+	if clusterName == "" {
+		clusterName = "the cluster"
+	}
+	return fmt.Sprintf("Liqo Agent connected to %s in %s: %s, %d peers", clusterName, elapsed,
+		i.AgentCtrl().CacheSyncProgress(), i.Status().Peers()), activity.OutcomeSuccess
+}
+
+//completed returns whether the startup has been completed.
+func (t *startupTracker) completed() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.done
+}
+
+//report returns a textual description of the stages of the startup and their duration.
+func (t *startupTracker) report() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	str := strings.Builder{}
+	if t.done {
+		str.WriteString(t.summary + "\n")
+	} else {
+		str.WriteString("Liqo Agent is starting\n")
+	}
+	for _, s := range t.stages {
+		elapsed := s.elapsed
+		if elapsed == 0 && !t.done {
+			str.WriteString(fmt.Sprintf("  %s: in progress\n", s.name))
+			continue
+		}
+		str.WriteString(fmt.Sprintf("  %s: %s\n", s.name, elapsed.Round(time.Millisecond)))
+	}
+	return strings.TrimSuffix(str.String(), "\n")
+}
+
+//startQuickShowStatus is the wrapper function to register QUICK "Status…", opening the Status window.
+func startQuickShowStatus(i *app.Indicator) {
+	i.AddQuick(titleStatusWindow, qStatusWindow, app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+		if app.GetGuiProvider().Mocked() {
+			return
+		}
+		showStatusWindow(i)
+	}))
+}
+
+//statusDetails returns the detailed description of the Agent status displayed in the Status window.
+func statusDetails(i *app.Indicator) string {
+	st := i.Status()
+	ctrl := i.AgentCtrl()
+	str := strings.Builder{}
+	str.WriteString(st.GoString() + "\n\n")
+	switch {
+	case !ctrl.Connected():
+		str.WriteString("Cluster: not connected\n")
+	case !ctrl.Reachable():
+		str.WriteString("Cluster: unreachable\n")
+	default:
+		str.WriteString("Cluster: connected\n")
+	}
+	str.WriteString(fmt.Sprintf("Caches: %s\n", ctrl.CacheSyncProgress()))
+	if polled := ctrl.PolledResources(); len(polled) > 0 {
+		str.WriteString("Polled (watch not permitted): " + strings.Join(polled, ", ") + "\n")
+	}
+	str.WriteString(fmt.Sprintf("Peers: %d (%d outgoing peerings, %d incoming peerings)\n\n", st.Peers(),
+		st.Peerings(app.PeeringOutgoing), st.Peerings(app.PeeringIncoming)))
+	str.WriteString(currentStartup().report())
+	return str.String()
+}
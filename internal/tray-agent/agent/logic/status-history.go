@@ -0,0 +1,141 @@
+package logic
+
+import (
+	"fmt"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/format"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/history"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"strings"
+	"time"
+)
+
+/*This file contains the "View status as of" selector of the Status window, reconstructing from the peering history
+the peerings and the acquired resources at a past time, e.g. to find out what the Agent saw when an outage began.*/
+
+const (
+	//statusChoiceNow is the choice of the Status window displaying the current status.
+	statusChoiceNow = "Now"
+	//statusChoiceCustom is the choice of the Status window asking for the time of the displayed status.
+	statusChoiceCustom = "Custom time…"
+	//statusTimeLayout is the layout of the time typed for the statusChoiceCustom choice.
+	statusTimeLayout = "2006-01-02 15:04"
+)
+
+//statusPastChoices contains the choices of the Status window displaying a past status, with their distance
+//from the current time.
+var statusPastChoices = []struct {
+	title string
+	ago   time.Duration
+}{
+	{"15 minutes ago", 15 * time.Minute},
+	{"1 hour ago", time.Hour},
+	{"6 hours ago", 6 * time.Hour},
+	{"1 day ago", 24 * time.Hour},
+}
+
+//showStatusWindow displays the Status window, which can be switched to the status reconstructed at a past time
+//until it is closed.
+func showStatusWindow(i *app.Indicator) {
+	choices := []string{statusChoiceNow}
+	for _, c := range statusPastChoices {
+		choices = append(choices, c.title)
+	}
+	choices = append(choices, statusChoiceCustom)
+	details := statusDetails(i)
+	for {
+		choice, ok, _ := dlgs.List("LIQO AGENT STATUS", details+"\nView status as of:", choices)
+		if !ok {
+			return
+		}
+		now := i.Now()
+		at := now
+		switch choice {
+		case statusChoiceNow:
+			details = statusDetails(i)
+			continue
+		case statusChoiceCustom:
+			typed, ok, _ := dlgs.Entry("LIQO AGENT STATUS", "Type the time of the status to display (YYYY-MM-DD hh:mm):",
+				now.Add(-time.Hour).Format(statusTimeLayout))
+			if !ok {
+				continue
+			}
+			var err error
+			if at, err = time.ParseInLocation(statusTimeLayout, strings.TrimSpace(typed), now.Location()); err != nil {
+				_, _ = dlgs.Warning("LIQO AGENT STATUS", "The time is not valid: use the YYYY-MM-DD hh:mm format.")
+				continue
+			}
+		default:
+			for _, c := range statusPastChoices {
+				if c.title == choice {
+					at = now.Add(-c.ago)
+				}
+			}
+		}
+		details = statusDetailsAt(history.GetStore(), at, now)
+	}
+}
+
+//statusDetailsAt returns the description of the peerings at a past time, reconstructed from the peering history, e.g.
+//	Status as of Mon 02 Jan 14:30 (2h ago)
+//
+//	Peerings: 1 outgoing, 1 incoming
+//	Acquired resources: 2.0 CPU, 4.0Gi
+//
+//	• cluster-1: OUT (2.0 CPU, 4.0Gi)
+//	• cluster-2: IN
+func statusDetailsAt(store *history.Store, at time.Time, now time.Time) string {
+	str := strings.Builder{}
+	str.WriteString(fmt.Sprintf("Status as of %s %s (%s)\n\n", at.Format(historyDayLayout),
+		at.Format(historyTimeLayout), format.Ago(at, now)))
+	if at.After(now) {
+		str.WriteString("The time is in the future.\n")
+		return str.String()
+	}
+	peers, oldest, present := store.StatusAt(at)
+	if !present {
+		str.WriteString("No peering history recorded.\n")
+		return str.String()
+	}
+	if at.Before(oldest) {
+		str.WriteString(fmt.Sprintf("The peering history starts on %s %s: the peerings established before are not "+
+			"known.\n\n", oldest.Format(historyDayLayout), oldest.Format(historyTimeLayout)))
+	}
+	var out, in int
+	cpu, mem := resource.Quantity{}, resource.Quantity{}
+	lines := strings.Builder{}
+	for _, p := range peers {
+		name := p.ClusterName
+		if name == "" {
+			name = p.ClusterID
+		}
+		var peerings []string
+		if p.OutConnected {
+			out++
+			peering := "OUT"
+			if p.CpuQuota != "" || p.MemQuota != "" {
+				peering += fmt.Sprintf(" (%s, %s)", format.CPUQuantity(p.CpuQuota), format.MemoryQuantity(p.MemQuota))
+			}
+			peerings = append(peerings, peering)
+			if q, err := resource.ParseQuantity(p.CpuQuota); err == nil {
+				cpu.Add(q)
+			}
+			if q, err := resource.ParseQuantity(p.MemQuota); err == nil {
+				mem.Add(q)
+			}
+		}
+		if p.InConnected {
+			in++
+			peerings = append(peerings, "IN")
+		}
+		lines.WriteString(fmt.Sprintf("• %s: %s\n", name, strings.Join(peerings, ", ")))
+	}
+	str.WriteString(fmt.Sprintf("Peerings: %d outgoing, %d incoming\n", out, in))
+	str.WriteString(fmt.Sprintf("Acquired resources: %s, %s\n", format.Cores(cpu.MilliValue()),
+		format.Bytes(mem.Value())))
+	if lines.Len() > 0 {
+		str.WriteString("\n" + lines.String())
+	}
+	return str.String()
+}
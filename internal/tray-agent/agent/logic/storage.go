@@ -0,0 +1,138 @@
+package logic
+
+import (
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// set of tags and titles for menu entries regarding the storage resources
+const (
+	titleStorage         = "Storage"
+	titleStorageClasses  = "STORAGE CLASSES"
+	titleVolumeClaims    = "VOLUME CLAIMS"
+	titleStorageWarnings = "OFFLOADING WARNINGS"
+	tagStorageClasses    = "storageClasses"
+	tagVolumeClaims      = "volumeClaims"
+	tagStorageWarnings   = "storageWarnings"
+)
+
+//storageWarned contains the keys of the client.OffloadingWarning already notified to the user.
+var storageWarned = make(map[string]bool)
+
+//storageWarnedMutex protects storageWarned.
+var storageWarnedMutex sync.Mutex
+
+//listenStorageChanged is the callback refreshing the storage information when the storage resources of the
+//home cluster change.
+func listenStorageChanged(_ client.NotifyDataGeneric, _ ...interface{}) {
+	i := app.GetIndicator()
+	report, err := i.AgentCtrl().StorageReport()
+	if err != nil {
+		return
+	}
+	if quick, present := i.Quick(qStorage); present {
+		refreshStorage(quick, report, i.Status())
+	}
+	for _, w := range newStorageWarnings(report) {
+		i.Notify("Liqo Agent: OFFLOADED WORKLOAD WITHOUT STORAGE",
+			fmt.Sprintf("pod %s/%s has been offloaded to %s, which cannot satisfy its volume claims (%s)",
+				w.Namespace, w.Pod, peerName(i.Status(), w.ClusterID), strings.Join(w.Claims, ", ")),
+			app.NotifyIconWarning, app.IconLiqoWarning)
+	}
+}
+
+//newStorageWarnings returns the OffloadingWarning of a StorageReport not yet notified to the user.
+//The warnings resolved in the meantime are forgotten, so that they are notified again if they reappear.
+func newStorageWarnings(report *client.StorageReport) []*client.OffloadingWarning {
+	storageWarnedMutex.Lock()
+	defer storageWarnedMutex.Unlock()
+	current := make(map[string]bool)
+	var warnings []*client.OffloadingWarning
+	for _, w := range report.Warnings {
+		current[w.Key()] = true
+		if !storageWarned[w.Key()] {
+			warnings = append(warnings, w)
+		}
+	}
+	storageWarned = current
+	return warnings
+}
+
+/*refreshStorage updates the content of the storage QUICK. The storage menu has the following structure:
+1-	STORAGE CLASSES: the StorageClasses of the home cluster
+2-	VOLUME CLAIMS: the PersistentVolumeClaims of the home cluster, with their phase and the peers using them
+3-	OFFLOADING WARNINGS: the pods offloaded to a peer that cannot satisfy their volume claims
+*/
+func refreshStorage(quick *app.MenuNode, report *client.StorageReport, status app.StatusInterface) {
+	quick.FreeListChildren()
+	//1- STORAGE CLASSES
+	classesNode := quick.UseListChild(countTitle(titleStorageClasses, len(report.StorageClasses)), tagStorageClasses)
+	for _, sc := range report.StorageClasses {
+		title := sc.Name + " (" + sc.Provisioner + ")"
+		if sc.Default {
+			title += " [default]"
+		}
+		classesNode.UseListChild(peerDataIndentation+title, sc.Name).SetIsEnabled(false)
+	}
+	classesNode.SetIsEnabled(len(report.StorageClasses) > 0)
+	//2- VOLUME CLAIMS
+	bound := 0
+	for _, vc := range report.Claims {
+		if vc.Bound() {
+			bound++
+		}
+	}
+	claimsTitle := titleVolumeClaims + " (" + strconv.Itoa(bound) + "/" + strconv.Itoa(len(report.Claims)) + " bound)"
+	claimsNode := quick.UseListChild(claimsTitle, tagVolumeClaims)
+	for _, vc := range report.Claims {
+		title := strings.Builder{}
+		title.WriteString(peerDataIndentation + vc.Namespace + "/" + vc.Name + ": " + vc.Phase)
+		if vc.Capacity != "" {
+			title.WriteString(" " + vc.Capacity)
+		}
+		if vc.StorageClass != "" {
+			title.WriteString(" [" + vc.StorageClass + "]")
+		}
+		if len(vc.ClusterIDs) > 0 {
+			names := make([]string, 0, len(vc.ClusterIDs))
+			for _, id := range vc.ClusterIDs {
+				names = append(names, peerName(status, id))
+			}
+			title.WriteString(" ⚠ used from " + strings.Join(names, ", "))
+		}
+		claimsNode.UseListChild(title.String(), vc.Namespace+"/"+vc.Name).SetIsEnabled(false)
+	}
+	claimsNode.SetIsEnabled(len(report.Claims) > 0)
+	//3- OFFLOADING WARNINGS
+	warningsNode := quick.UseListChild(countTitle(titleStorageWarnings, len(report.Warnings)), tagStorageWarnings)
+	for _, w := range report.Warnings {
+		title := fmt.Sprintf("%s%s/%s on %s: %s", peerDataIndentation, w.Namespace, w.Pod,
+			peerName(status, w.ClusterID), strings.Join(w.Claims, ", "))
+		warningsNode.UseListChild(title, w.Key()).SetIsEnabled(false)
+	}
+	warningsNode.SetIsEnabled(len(report.Warnings) > 0)
+}
+
+//countTitle returns a title followed by a counter, e.g. "TITLE (3)".
+func countTitle(title string, count int) string {
+	return title + " (" + strconv.Itoa(count) + ")"
+}
+
+//peerName returns the ClusterName of a peer, falling back to its ClusterID if unknown.
+func peerName(status app.StatusInterface, clusterID string) string {
+	if clusterID == "" {
+		return "an unknown peer"
+	}
+	if peer, present := status.Peer(clusterID); present {
+		peer.RLock()
+		defer peer.RUnlock()
+		if peer.ClusterName != "" {
+			return peer.ClusterName
+		}
+	}
+	return clusterID
+}
@@ -0,0 +1,132 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"io/ioutil"
+	"time"
+)
+
+const (
+	//activitySourceStress is the activity.Feed source of the stress-test developer mode.
+	activitySourceStress = "stress"
+	//stressPollInterval is the interval the menu is checked at, while waiting for it to settle.
+	stressPollInterval = 50 * time.Millisecond
+)
+
+//stressConfig is the configuration of the stress-test developer mode, if enabled.
+var stressConfig *client.StressConfig
+
+//EnableStressTest enables the stress-test developer mode: once the Agent is ready, the synthetic peers and
+//changes described by config are injected and the responsiveness of the menu is measured.
+//
+//It requires the mocked AgentController (see client.UseMockedAgentController) and it must be called before OnReady.
+func EnableStressTest(config client.StressConfig) {
+	stressConfig = &config
+}
+
+//stressReport contains the metrics captured during a stress test.
+type stressReport struct {
+	Injected client.StressStats `json:"injected"`
+	//Settling is the time the peers menu took to display the final set of peers after the end of the injection.
+	Settling time.Duration `json:"settling"`
+	//Listeners contains the execution metrics of the peers event handlers.
+	Listeners map[string]app.ListenerStats `json:"listeners"`
+	//Refresh contains the execution metrics of the refreshes of the status and of the tray label.
+	Refresh app.RefreshStats `json:"refresh"`
+	//Failures lists the timing assertions that did not hold.
+	Failures []string `json:"failures"`
+}
+
+//startStressTest runs the stress test, if enabled, in background.
+func startStressTest(i *app.Indicator) {
+	if stressConfig == nil {
+		return
+	}
+	config := *stressConfig
+	go func() {
+		report, err := runStressTest(i.Context(), i, config)
+		if err != nil {
+			logger.Error(err, "stress test failed")
+			activity.GetFeed().Add(activitySourceStress, "Stress test failed: "+err.Error(), activity.OutcomeFailure)
+			return
+		}
+		publishStressReport(i, config, report)
+	}()
+}
+
+//runStressTest injects the synthetic peers, waits for the peers menu to settle and checks the timing assertions
+//of config.
+func runStressTest(ctx context.Context, i *app.Indicator, config client.StressConfig) (*stressReport, error) {
+	stats, err := i.AgentCtrl().RunStress(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	report := &stressReport{Injected: stats, Listeners: make(map[string]app.ListenerStats)}
+	quick, present := i.Quick(qPeers)
+	if !present {
+		return nil, fmt.Errorf("QUICK %s not registered", qPeers)
+	}
+	start := time.Now()
+	deadline := start.Add(config.MaxSettling)
+	for !stressSettled(i, quick, stats.Peers) {
+		if time.Now().After(deadline) {
+			report.Failures = append(report.Failures, fmt.Sprintf("the peers menu did not settle within %s "+
+				"(%d/%d peers displayed)", config.MaxSettling, peerEntriesLen(quick), stats.Peers))
+			break
+		}
+		time.Sleep(stressPollInterval)
+	}
+	report.Settling = time.Since(start)
+	report.Refresh = i.RefreshStats()
+	if l, present := i.Listener(client.TopicPeerChanged); present {
+		s := l.Stats()
+		report.Listeners[client.TopicPeerChanged.String()] = s
+		if config.MaxLatency > 0 && s.MaxTime > config.MaxLatency {
+			report.Failures = append(report.Failures, fmt.Sprintf("the %s handler took up to %s (limit %s)",
+				client.TopicPeerChanged, s.MaxTime, config.MaxLatency))
+		}
+	}
+	return report, nil
+}
+
+//stressSettled returns whether the peers menu displays all the peers and no peer event is pending.
+func stressSettled(i *app.Indicator, quick *app.MenuNode, peers int) bool {
+	if l, present := i.Listener(client.TopicPeerChanged); present && l.Stats().Pending > 0 {
+		return false
+	}
+	return peerEntriesLen(quick) == peers
+}
+
+//publishStressReport logs the outcome of a stress test, records it in the activity feed and, if requested,
+//writes the full report to file.
+func publishStressReport(i *app.Indicator, config client.StressConfig, report *stressReport) {
+	summary := fmt.Sprintf("Stress test: %d peers, %d updates, %d removals in %s; menu settled in %s",
+		report.Injected.Peers, report.Injected.Updated, report.Injected.Deleted,
+		report.Injected.Elapsed.Round(time.Millisecond), report.Settling.Round(time.Millisecond))
+	outcome := activity.OutcomeSuccess
+	if len(report.Failures) > 0 {
+		outcome = activity.OutcomeFailure
+		for _, f := range report.Failures {
+			logger.Warning("stress test assertion failed", "assertion", f)
+		}
+	}
+	logger.Info(summary)
+	activity.GetFeed().Add(activitySourceStress, summary, outcome)
+	if config.Report != "" {
+		if data, err := json.MarshalIndent(report, "", "  "); err == nil {
+			if err = ioutil.WriteFile(config.Report, data, 0644); err != nil {
+				logger.Error(err, "cannot write the stress test report")
+			}
+		}
+	}
+	if outcome == activity.OutcomeFailure {
+		i.Notify("Stress test failed", report.Failures[0], app.NotifyIconError, app.IconLiqoRed)
+	} else {
+		i.Notify("Stress test passed", summary, app.NotifyIconDefault, app.IconLiqoNil)
+	}
+}
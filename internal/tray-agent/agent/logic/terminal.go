@@ -0,0 +1,81 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const (
+	//titleTerminal is the title of the menu entries opening a terminal.
+	titleTerminal = "Open terminal here"
+	//envTerminal is the env variable conventionally containing the preferred terminal emulator.
+	envTerminal = "TERMINAL"
+)
+
+//knownTerminals contains the terminal emulators searched when none is configured, each followed by the flag
+//introducing the command to run.
+var knownTerminals = [][]string{
+	{"x-terminal-emulator", "-e"},
+	{"gnome-terminal", "--"},
+	{"konsole", "-e"},
+	{"xfce4-terminal", "-x"},
+	{"tilix", "-e"},
+	{"xterm", "-e"},
+}
+
+//openTerminal launches the terminal emulator with kubectl pointing at the cluster the Agent is connected to.
+//If clusterID is not empty, the terminal also points at the virtual node representing that peer, showing its
+//offloaded pods.
+func openTerminal(i *app.Indicator, clusterID string) {
+	env, err := i.AgentCtrl().ShellEnv(clusterID)
+	if err == nil {
+		var terminal []string
+		if terminal, err = terminalCommand(); err == nil {
+			cmd := exec.Command(terminal[0], append(terminal[1:], "sh", "-c", shellScript(clusterID))...)
+			cmd.Env = append(os.Environ(), env...)
+			if err = cmd.Start(); err == nil {
+				//the terminal outlives the Agent operations: its exit status is not relevant
+				go func() {
+					_ = cmd.Wait()
+				}()
+				return
+			}
+		}
+	}
+	i.ShowWarning("LIQO AGENT", "Liqo Agent could not open a terminal:\n"+err.Error())
+}
+
+//terminalCommand returns the command (with its arguments) launching the terminal emulator: the one configured in
+//the local configuration, the one in the TERMINAL env variable or the first known one available.
+func terminalCommand() ([]string, error) {
+	conf, _ := client.GetLocalConfig()
+	if terminal := strings.Fields(conf.GetTerminal()); len(terminal) > 0 {
+		return terminal, nil
+	}
+	if terminal, present := os.LookupEnv(envTerminal); present && terminal != "" {
+		return []string{terminal, "-e"}, nil
+	}
+	for _, t := range knownTerminals {
+		if path, err := exec.LookPath(t[0]); err == nil {
+			return []string{path, t[1]}, nil
+		}
+	}
+	return nil, errors.New("no terminal emulator found: set the 'terminal' field in the configuration file")
+}
+
+//shellScript returns the script run in the terminal: it shows the target of kubectl and then starts the user shell.
+func shellScript(clusterID string) string {
+	script := []string{fmt.Sprintf(`echo "kubectl context: ${%s:-current}"`, client.EnvShellContext)}
+	if clusterID != "" {
+		node := "$" + client.EnvShellVirtualNode
+		script = append(script,
+			fmt.Sprintf(`echo "peer: $%s (virtual node %s)"`, client.EnvShellPeerClusterID, node),
+			fmt.Sprintf(`kubectl get pods --all-namespaces -o wide --field-selector spec.nodeName=%s`, node))
+	}
+	return strings.Join(append(script, `exec "${SHELL:-sh}"`), "; ")
+}
@@ -0,0 +1,95 @@
+package logic
+
+import (
+	"context"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/tracing"
+	"os"
+	"strings"
+	"sync"
+)
+
+/*This file contains the export of the Agent traces (see package tracing) to the OpenTelemetry collector of the local
+configuration or of the standard OTEL_EXPORTER_OTLP_* env variables. Tracing is started before the connection to the
+cluster, in order to record the startup of the caches.*/
+
+const (
+	//envOtlpTracesEndpoint is the env variable containing the URL of the traces endpoint of the collector.
+	envOtlpTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	//envOtlpEndpoint is the env variable containing the base URL of the collector.
+	envOtlpEndpoint = "OTEL_EXPORTER_OTLP_ENDPOINT"
+)
+
+//traces contains the export of the traces.
+var traces = struct {
+	sync.Mutex
+	//cancel stops the export, if started.
+	cancel context.CancelFunc
+	//done is closed when the last traces have been exported.
+	done chan struct{}
+}{}
+
+//tracesEndpoint returns the URL of the traces endpoint of the collector, empty if none is configured.
+func tracesEndpoint(conf client.TracingConfig) string {
+	endpoint := conf.Endpoint
+	if endpoint == "" {
+		if endpoint = os.Getenv(envOtlpTracesEndpoint); endpoint != "" {
+			return endpoint
+		}
+		endpoint = os.Getenv(envOtlpEndpoint)
+	}
+	if endpoint == "" || strings.HasSuffix(endpoint, tracing.TracesPath) {
+		return endpoint
+	}
+	return strings.TrimSuffix(endpoint, "/") + tracing.TracesPath
+}
+
+//startTracing starts recording the traces of the Agent, if a collector is configured.
+func startTracing() {
+	conf, _ := client.GetLocalConfig()
+	tracingConf := conf.GetTracing()
+	endpoint := tracesEndpoint(tracingConf)
+	if endpoint == "" {
+		return
+	}
+	stopTracing()
+	exporter := tracing.NewOTLPExporter(tracing.OTLPOptions{
+		Endpoint: endpoint,
+		Headers:  tracingConf.Headers,
+		Interval: tracingConf.Interval,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	traces.Lock()
+	traces.cancel, traces.done = cancel, done
+	traces.Unlock()
+	tracing.SetExporter(exporter)
+	failing := false
+	go func() {
+		defer close(done)
+		exporter.Run(ctx, func(err error) {
+			switch {
+			case err != nil && !failing:
+				logger.Warning("traces export failing", "err", err)
+			case err == nil && failing:
+				logger.Info("traces export recovered")
+			}
+			failing = err != nil
+		})
+	}()
+	logger.Debug("exporting the traces", "endpoint", endpoint)
+}
+
+//stopTracing stops recording the traces, exporting the last ones.
+func stopTracing() {
+	traces.Lock()
+	cancel, done := traces.cancel, traces.done
+	traces.cancel, traces.done = nil, nil
+	traces.Unlock()
+	if cancel == nil {
+		return
+	}
+	tracing.SetExporter(nil)
+	cancel()
+	<-done
+}
@@ -0,0 +1,80 @@
+package logic
+
+import (
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/format"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"time"
+)
+
+const (
+	//tTunnelHealth is the tag of the Timer checking the network connectivity towards the peers.
+	tTunnelHealth = "T_TUNNEL_HEALTH"
+	//tunnelHealthInterval is the default period of the check of the network connectivity towards the peers.
+	tunnelHealthInterval = 30 * time.Second
+	//activitySourceTunnels is the activity.Feed source of the changes of the health of the tunnels.
+	activitySourceTunnels = "tunnels"
+	//notificationTunnelPrefix precedes the ClusterID of a peer in the ID of the app.Notification of its
+	//degraded tunnel.
+	notificationTunnelPrefix = "tunnel/"
+)
+
+//startTunnelHealthCheck periodically checks the network connectivity towards the peers with an active peering.
+func startTunnelHealthCheck(i *app.Indicator) {
+	interval := configuredInterval(intervals().Tunnel, tunnelHealthInterval)
+	_ = i.StartTimer(tTunnelHealth, interval, func(args ...interface{}) {
+		checkTunnelsHealth(i)
+	})
+}
+
+//checkTunnelsHealth checks the network connectivity towards the peers with an active peering, dismissing the
+//notifications of the peers no more peered.
+func checkTunnelsHealth(i *app.Indicator) {
+	renderedPeers.Lock()
+	peers := make([]client.NotifyDataForeignCluster, 0, len(renderedPeers.data))
+	for _, data := range renderedPeers.data {
+		if data.OutPeering.Connected || data.InPeering.Connected {
+			peers = append(peers, data)
+		}
+	}
+	renderedPeers.Unlock()
+	active := make(map[string]bool)
+	for _, data := range peers {
+		active[notificationTunnelPrefix+data.ClusterID] = true
+		checkTunnelHealth(i, data.ClusterID, data.Name)
+	}
+	dismissResolvedNotifications(i, notificationTunnelPrefix, active)
+}
+
+//checkTunnelHealth checks the network connectivity towards a peer and stores the result in the Status. The user is
+//notified when the connectivity becomes degraded, and the notification is dismissed once it recovers.
+func checkTunnelHealth(i *app.Indicator, clusterID string, foreignCluster string) {
+	health, err := i.AgentCtrl().CheckTunnelHealth(i.Context(), foreignCluster)
+	if err != nil {
+		return
+	}
+	previous, checked := i.Status().TunnelHealth(clusterID)
+	i.Status().SetTunnelHealth(health)
+	wasDegraded := checked && previous.Degraded()
+	peer := peerName(i.Status(), clusterID)
+	id := notificationTunnelPrefix + clusterID
+	switch {
+	case health.Degraded() && !wasDegraded:
+		msg := fmt.Sprintf("The connection with %s is degraded: %s", peer, health.Reason())
+		activity.GetFeed().Add(activitySourceTunnels, msg, activity.OutcomeFailure)
+		i.ShowNotification(app.Notification{
+			ID:       id,
+			Title:    "Liqo Agent: TUNNEL DEGRADED",
+			Message:  msg,
+			Severity: app.SeverityWarning,
+			Category: app.CategoryPeering,
+			Target:   app.NotificationTarget{Kind: "ForeignCluster", Name: foreignCluster},
+		}.WithTrayIcon(app.IconLiqoWarning))
+	case !health.Degraded() && wasDegraded:
+		activity.GetFeed().Add(activitySourceTunnels, fmt.Sprintf("The connection with %s recovered (latency %s)",
+			peer, format.Duration(health.Latency)), activity.OutcomeSuccess)
+		i.DismissNotification(id)
+	}
+}
@@ -0,0 +1,195 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+const (
+	//titleUninstall is the title of the QUICK starting the uninstallation of Liqo.
+	titleUninstall = "Uninstall Liqo…"
+	//activitySourceUninstall is the activity.Entry source of the uninstall operations.
+	activitySourceUninstall = "uninstall"
+)
+
+//uninstalling specifies whether an uninstallation of Liqo is in progress.
+var uninstalling = struct {
+	running bool
+	sync.Mutex
+}{}
+
+//quickUninstallLiqo is the callback for the QUICK "Uninstall Liqo…". It shows the pre-flight report of everything
+//that will be removed and requires two confirmations: an explicit consent and the name of the cluster typed
+//by the user. Then it tears down the peerings, disables the offloading and uninstalls Liqo.
+func quickUninstallLiqo(ctx context.Context, i *app.Indicator) {
+	if app.GetGuiProvider().Mocked() || !writeAllowed(i, "the uninstallation of Liqo") {
+		return
+	}
+	if !claimUninstall() {
+		i.ShowWarning("UNINSTALL LIQO", "Another Liqo operation is in progress, please retry when it completes.")
+		return
+	}
+	//the uninstallation is released if not started, e.g. when the user does not confirm it
+	started := false
+	defer func() {
+		if !started {
+			releaseUninstall()
+		}
+	}()
+	var report *client.UninstallReport
+	err := runOperation(ctx, i, opUninstallReport, func(context.Context) (err error) {
+		report, err = i.AgentCtrl().UninstallReport()
+		return
+	})
+	if err != nil {
+		i.ShowClientError("UNINSTALL LIQO", err)
+		return
+	}
+	if report.Empty() {
+		i.ShowWarning("UNINSTALL LIQO", "Liqo does not seem to be installed in the connected cluster.")
+		return
+	}
+	command, args, err := uninstallCommand(report.Release)
+	if err != nil {
+		i.ShowWarning("UNINSTALL LIQO", err.Error())
+		return
+	}
+	//the command is bound to the cluster the pre-flight report has been built for
+	cmd, err := clusterCommand(i, command, args...)
+	if err != nil {
+		i.ShowClientError("UNINSTALL LIQO", err)
+		return
+	}
+	ok, _ := dlgs.Question("UNINSTALL LIQO", "The following resources will be removed from the cluster:\n\n"+
+		report.String()+"\nThe offloaded pods will be evicted. Do you want to continue?", false)
+	if !ok {
+		return
+	}
+	word := uninstallConfirmationWord(i.Status().ClusterName(), report.Release)
+	typed, ok, _ := dlgs.Entry("UNINSTALL LIQO", fmt.Sprintf("This operation cannot be undone.\n"+
+		"Type '%s' to confirm the uninstallation of Liqo:", word), "")
+	if !ok {
+		return
+	}
+	if strings.TrimSpace(typed) != word {
+		i.ShowWarning("UNINSTALL LIQO", "The confirmation does not match: Liqo has not been uninstalled.")
+		return
+	}
+	started = true
+	go runUninstall(i, cmd)
+}
+
+//claimUninstall marks an uninstallation of Liqo as in progress, unless one (or an upgrade) is running already. The
+//check and the claim are atomic, so that two clicks cannot start two uninstallations. It returns whether the
+//uninstallation has been claimed: in that case, releaseUninstall must be called once it ends.
+func claimUninstall() bool {
+	upgrade.Lock()
+	upgrading := upgrade.running
+	upgrade.Unlock()
+	uninstalling.Lock()
+	defer uninstalling.Unlock()
+	if upgrading || uninstalling.running {
+		return false
+	}
+	uninstalling.running = true
+	return true
+}
+
+//releaseUninstall marks the uninstallation of Liqo as ended.
+func releaseUninstall() {
+	uninstalling.Lock()
+	defer uninstalling.Unlock()
+	uninstalling.running = false
+}
+
+//uninstallCommand returns the command (and its arguments) uninstalling Liqo. liqoctl is preferred when available,
+//otherwise the helm release is uninstalled by means of helm.
+func uninstallCommand(release *client.LiqoRelease) (string, []string, error) {
+	if liqoctl, err := exec.LookPath("liqoctl"); err == nil {
+		return liqoctl, []string{"uninstall"}, nil
+	}
+	if release == nil {
+		return "", nil, fmt.Errorf("no helm release of Liqo found, and the 'liqoctl' command is not available")
+	}
+	helm, err := exec.LookPath("helm")
+	if err != nil {
+		return "", nil, fmt.Errorf("the uninstallation requires the 'liqoctl' or the 'helm' command, " +
+			"which are not available")
+	}
+	return helm, []string{"uninstall", release.Name, "--namespace", release.Namespace, "--wait"}, nil
+}
+
+//uninstallConfirmationWord returns the word the user has to type to confirm the uninstallation: the name of the
+//home cluster or, if unknown, the name of the Liqo release.
+func uninstallConfirmationWord(clusterName string, release *client.LiqoRelease) string {
+	if clusterName != "" {
+		return clusterName
+	}
+	if release != nil && release.Name != "" {
+		return release.Name
+	}
+	return "uninstall"
+}
+
+//runUninstall performs the uninstallation of Liqo by means of cmd, recording each step in the activity feed.
+func runUninstall(i *app.Indicator, cmd *exec.Cmd) {
+	quick, _ := i.Quick(qUninstall)
+	defer func() {
+		releaseUninstall()
+		quick.SetTitle(titleUninstall)
+		quick.SetIsEnabled(true)
+	}()
+	quick.SetIsEnabled(false)
+	feed := activity.GetFeed()
+	feed.Add(activitySourceUninstall, "Liqo uninstallation started", activity.OutcomeInfo)
+	//1: peerings teardown
+	quick.SetTitle("Uninstalling Liqo: stopping peerings…")
+	var stopped int
+	err := runOperation(i.Context(), i, opStopPeerings, func(context.Context) (err error) {
+		stopped, err = i.AgentCtrl().StopAllPeerings()
+		return
+	})
+	if err != nil {
+		failUninstall(i, err.Error())
+		return
+	}
+	feed.Add(activitySourceUninstall, fmt.Sprintf("%d peering(s) stopped", stopped), activity.OutcomeSuccess)
+	//2: offloading
+	quick.SetTitle("Uninstalling Liqo: disabling offloading…")
+	var disabled []string
+	err = runOperation(i.Context(), i, opDisableOffloading, func(context.Context) (err error) {
+		disabled, err = i.AgentCtrl().DisableOffloading()
+		return
+	})
+	if err != nil {
+		failUninstall(i, err.Error())
+		return
+	}
+	feed.Add(activitySourceUninstall, fmt.Sprintf("Offloading disabled on %d namespace(s)", len(disabled)),
+		activity.OutcomeSuccess)
+	//3: uninstallation
+	output, err := runWithProgress(cmd, func(line string) {
+		quick.SetTitle("Uninstalling Liqo: " + truncate(line, upgradeProgressLength))
+	})
+	if err != nil {
+		failUninstall(i, lastLine(output))
+		return
+	}
+	feed.Add(activitySourceUninstall, "Liqo uninstalled", activity.OutcomeSuccess)
+	i.Notify("Liqo Agent: LIQO UNINSTALLED", "Liqo has been removed from the cluster",
+		app.NotifyIconDefault, app.IconLiqoNil)
+}
+
+//failUninstall records and shows the failure of the uninstallation.
+func failUninstall(i *app.Indicator, reason string) {
+	activity.GetFeed().Add(activitySourceUninstall, "Liqo uninstallation failed: "+reason, activity.OutcomeFailure)
+	addPendingFailure(i, activitySourceUninstall, "Liqo uninstallation failed", reason)
+	i.ShowError("LIQO UNINSTALLATION FAILED", reason)
+}
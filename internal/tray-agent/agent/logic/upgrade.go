@@ -0,0 +1,259 @@
+package logic
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/activity"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	//titleUpgrade is the title of the QUICK starting the upgrade of Liqo.
+	titleUpgrade = "Upgrade Liqo…"
+	//tUpgrade is the tag of the Timer checking the availability of a new Liqo version.
+	tUpgrade = "T_UPGRADE"
+	//upgradeCheckInterval is the interval between two checks of the availability of a new Liqo version.
+	upgradeCheckInterval = 24 * time.Hour
+	//upgradeHealthTimeout is the maximum time waited for the Liqo components to become healthy after an upgrade.
+	upgradeHealthTimeout = 5 * time.Minute
+	//upgradeHealthInterval is the interval between two checks of the Liqo components health after an upgrade.
+	upgradeHealthInterval = 5 * time.Second
+	//upgradeProgressLength is the maximum length of the progress message displayed in the QUICK title.
+	upgradeProgressLength = 40
+	//activitySourceUpgrade is the activity.Entry source of the upgrade operations.
+	activitySourceUpgrade = "upgrade"
+	//envHelmKubeContext is the env variable selecting the kubeconfig context used by helm.
+	envHelmKubeContext = "HELM_KUBECONTEXT"
+)
+
+//upgradeState contains the state of the Liqo upgrade assistant.
+type upgradeState struct {
+	//release is the currently installed Liqo release.
+	release *client.LiqoRelease
+	//available is the newer version of the Liqo chart available for the upgrade. If empty, no upgrade is available.
+	available string
+	//running specifies whether an upgrade is in progress.
+	running bool
+	sync.Mutex
+}
+
+//upgrade contains the state of the Liqo upgrade assistant.
+var upgrade = &upgradeState{}
+
+//checkUpgrade checks whether a newer version of the Liqo chart is available for the connected cluster. In that case,
+//the user is notified and the upgrade QUICK is shown.
+func checkUpgrade(i *app.Indicator) {
+	release, err := i.AgentCtrl().InstalledLiqoRelease()
+	if err != nil {
+		return
+	}
+	conf, _ := client.GetLocalConfig()
+	latest, err := client.LatestChartVersion(conf.GetChartRepository())
+	if err != nil {
+		return
+	}
+	upgrade.Lock()
+	defer upgrade.Unlock()
+	if upgrade.running {
+		return
+	}
+	upgrade.release = release
+	newVersion := client.CompareVersions(latest, release.ChartVersion) > 0
+	if newVersion && latest != upgrade.available {
+		i.Notify("Liqo Agent: NEW LIQO VERSION",
+			fmt.Sprintf("Liqo %s is available (installed: %s)", latest, release.ChartVersion),
+			app.NotifyIconDefault, app.IconLiqoNil)
+	}
+	if !newVersion {
+		latest = ""
+	}
+	upgrade.available = latest
+	if quick, present := i.Quick(qUpgrade); present {
+		quick.SetTitle(upgradeTitle(latest))
+		quick.SetIsVisible(latest != "")
+	}
+	if latest == "" {
+		i.Pending().Remove(pendingUpgrade)
+		return
+	}
+	i.Pending().Add(app.PendingItem{
+		ID:    pendingUpgrade,
+		Kind:  app.PendingRequest,
+		Title: fmt.Sprintf("Liqo %s available", latest),
+		Action: app.ClickHandlerFunc(func(ctx context.Context, e *app.ClickEvent) {
+			quickUpgradeLiqo(e.Indicator)
+		}),
+	})
+}
+
+//upgradeTitle returns the title of the upgrade QUICK for an available version.
+func upgradeTitle(version string) string {
+	if version == "" {
+		return titleUpgrade
+	}
+	return strings.TrimSuffix(titleUpgrade, "…") + " to " + version + "…"
+}
+
+//quickUpgradeLiqo is the callback for the QUICK "Upgrade Liqo…". After the user confirmation, it delegates the
+//upgrade to helm, showing its progress, and then waits for the Liqo components to become healthy.
+//The outcome is recorded in the activity feed.
+func quickUpgradeLiqo(i *app.Indicator) {
+	upgrade.Lock()
+	if upgrade.running || upgrade.available == "" || upgrade.release == nil {
+		upgrade.Unlock()
+		return
+	}
+	release, version := *upgrade.release, upgrade.available
+	upgrade.Unlock()
+	if app.GetGuiProvider().Mocked() || !writeAllowed(i, "the upgrade of Liqo") {
+		return
+	}
+	helm, err := exec.LookPath("helm")
+	if err != nil {
+		i.ShowWarning("UPGRADE LIQO", "The upgrade requires the 'helm' command, which is not available.")
+		return
+	}
+	ok, _ := dlgs.Question("UPGRADE LIQO", fmt.Sprintf("Do you want to upgrade Liqo from %s to %s?\n"+
+		"The current configuration of the release '%s' is kept.", release.ChartVersion, version, release.Name), false)
+	if !ok {
+		return
+	}
+	upgrade.Lock()
+	upgrade.running = true
+	upgrade.Unlock()
+	go runUpgrade(i, helm, release, version)
+}
+
+//runUpgrade performs the upgrade of a Liqo release to a chart version.
+func runUpgrade(i *app.Indicator, helm string, release client.LiqoRelease, version string) {
+	quick, _ := i.Quick(qUpgrade)
+	defer func() {
+		//the QUICK is shown again only if a newer version is still available
+		upgrade.Lock()
+		upgrade.running = false
+		upgrade.available = ""
+		upgrade.Unlock()
+		quick.SetTitle(titleUpgrade)
+		quick.SetIsVisible(false)
+		quick.SetIsEnabled(true)
+		checkUpgrade(i)
+	}()
+	quick.SetIsEnabled(false)
+	feed := activity.GetFeed()
+	feed.Add(activitySourceUpgrade, fmt.Sprintf("Liqo upgrade to %s started", version), activity.OutcomeInfo)
+	conf, _ := client.GetLocalConfig()
+	//the upgrade is not run if the cluster of the Agent cannot be resolved
+	var output string
+	cmd, err := clusterCommand(i, helm, "upgrade", release.Name, client.LiqoChartName,
+		"--repo", conf.GetChartRepository(), "--namespace", release.Namespace, "--version", version,
+		"--reuse-values", "--wait")
+	if err != nil {
+		output = err.Error()
+	} else {
+		output, err = runWithProgress(cmd, func(line string) {
+			quick.SetTitle("Upgrading Liqo: " + truncate(line, upgradeProgressLength))
+		})
+	}
+	if err != nil {
+		feed.Add(activitySourceUpgrade, fmt.Sprintf("Liqo upgrade to %s failed: %s", version, lastLine(output)),
+			activity.OutcomeFailure)
+		addPendingFailure(i, activitySourceUpgrade, fmt.Sprintf("Liqo upgrade to %s failed", version),
+			lastLine(output))
+		i.ShowError("LIQO UPGRADE FAILED", lastLine(output))
+		return
+	}
+	quick.SetTitle("Upgrading Liqo: checking health…")
+	report := waitForHealth(i, upgradeHealthTimeout)
+	if report != nil && report.Unhealthy() == 0 {
+		feed.Add(activitySourceUpgrade, fmt.Sprintf("Liqo upgraded to %s, all the components are healthy", version),
+			activity.OutcomeSuccess)
+		i.Notify("Liqo Agent: LIQO UPGRADED", fmt.Sprintf("Liqo has been upgraded to %s", version),
+			app.NotifyIconDefault, app.IconLiqoNil)
+		return
+	}
+	msg := fmt.Sprintf("Liqo upgraded to %s, but the control plane is not healthy", version)
+	if report != nil {
+		msg = fmt.Sprintf("Liqo upgraded to %s, but %d component(s) are failing", version, report.Unhealthy())
+	}
+	feed.Add(activitySourceUpgrade, msg, activity.OutcomeFailure)
+	i.Notify("Liqo Agent: LIQO UPGRADED WITH ERRORS", msg, app.NotifyIconWarning, app.IconLiqoWarning)
+}
+
+//clusterCommand returns a command run against the cluster the Agent is connected to: its environment points
+//kubectl, helm and liqoctl at the kubeconfig file and context of the Agent, instead of the default ones of the user.
+//An error is returned if the cluster cannot be resolved.
+func clusterCommand(i *app.Indicator, name string, args ...string) (*exec.Cmd, error) {
+	env, err := i.AgentCtrl().ShellEnv("")
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(name, args...)
+	//a context selected by HELM_KUBECONTEXT would override the current one of the kubeconfig file
+	cmd.Env = append(append(os.Environ(), env...), envHelmKubeContext+"=")
+	return cmd, nil
+}
+
+//runWithProgress runs a command, calling progress for each line of its combined output.
+//It returns the whole output once the command has exited.
+func runWithProgress(cmd *exec.Cmd, progress func(line string)) (string, error) {
+	pipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	cmd.Stderr = cmd.Stdout
+	if err = cmd.Start(); err != nil {
+		return err.Error(), err
+	}
+	output := strings.Builder{}
+	scanner := bufio.NewScanner(pipe)
+	for scanner.Scan() {
+		line := scanner.Text()
+		output.WriteString(line + "\n")
+		if strings.TrimSpace(line) != "" {
+			progress(line)
+		}
+	}
+	return output.String(), cmd.Wait()
+}
+
+//waitForHealth waits, up to timeout, for all the Liqo components to become healthy.
+//It returns the last retrieved HealthReport, if any.
+func waitForHealth(i *app.Indicator, timeout time.Duration) *client.HealthReport {
+	var report *client.HealthReport
+	deadline := time.Now().Add(timeout)
+	for {
+		if r, err := i.AgentCtrl().HealthReport(); err == nil {
+			report = r
+			if r.Unhealthy() == 0 && len(r.Components) > 0 {
+				return report
+			}
+		}
+		if time.Now().After(deadline) {
+			return report
+		}
+		time.Sleep(upgradeHealthInterval)
+	}
+}
+
+//truncate shortens s to at most length characters, adding an ellipsis if needed.
+func truncate(s string, length int) string {
+	runes := []rune(strings.TrimSpace(s))
+	if len(runes) <= length {
+		return string(runes)
+	}
+	return string(runes[:length-1]) + "…"
+}
+
+//lastLine returns the last non empty line of a text.
+func lastLine(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
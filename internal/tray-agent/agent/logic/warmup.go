@@ -0,0 +1,40 @@
+package logic
+
+import (
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"time"
+)
+
+const (
+	//tCacheSync is the tag of the Timer displaying the progress of the initial synchronization of the Agent caches.
+	tCacheSync = "T_CACHE_SYNC"
+	//cacheSyncInterval is the refresh interval of the progress of the initial synchronization of the Agent caches.
+	cacheSyncInterval = 500 * time.Millisecond
+)
+
+//startCacheSyncProgress displays in the STATUS MenuNode the progress of the initial synchronization of the Agent
+//caches, refreshed until it completes. The completion of the synchronization completes the startup tracked by t.
+func startCacheSyncProgress(i *app.Indicator, t *startupTracker) {
+	if !refreshCacheSync(i, t) {
+		return
+	}
+	_ = i.StartTimer(tCacheSync, cacheSyncInterval, func(args ...interface{}) {
+		if !refreshCacheSync(i, t) {
+			if timer, present := i.Timer(tCacheSync); present {
+				timer.SetActive(false)
+			}
+		}
+	})
+}
+
+//refreshCacheSync updates the progress of the cache synchronization in the STATUS MenuNode and in the startup
+//tracked by t. It returns whether the synchronization is still in progress.
+func refreshCacheSync(i *app.Indicator, t *startupTracker) bool {
+	progress := i.AgentCtrl().CacheSyncProgress()
+	if progress != i.Status().CacheSync() {
+		i.Status().SetCacheSync(progress)
+		i.RefreshStatus()
+	}
+	t.cacheSync(i, progress)
+	return !progress.Done()
+}
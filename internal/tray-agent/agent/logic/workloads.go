@@ -0,0 +1,75 @@
+package logic
+
+import (
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	app "github.com/liqotech/liqo-agent/internal/tray-agent/app-indicator"
+	"strings"
+	"sync"
+)
+
+//notificationWorkloadPrefix precedes the key of a client.WorkloadFailure in the ID of its app.Notification.
+const notificationWorkloadPrefix = "workload/"
+
+//workloadsFailed contains the keys of the client.WorkloadFailure already notified to the user.
+var workloadsFailed = make(map[string]bool)
+
+//workloadsFailedMutex protects workloadsFailed.
+var workloadsFailedMutex sync.Mutex
+
+//listenWorkloadsChanged is the callback notifying the failures of the pods offloaded to the peers, which
+//would otherwise be visible only by inspecting the provider clusters.
+func listenWorkloadsChanged(_ client.NotifyDataGeneric, _ ...interface{}) {
+	i := app.GetIndicator()
+	failures, err := i.AgentCtrl().WorkloadFailures()
+	if err != nil {
+		return
+	}
+	active := make(map[string]bool)
+	for _, f := range failures {
+		active[notificationWorkloadPrefix+f.Key()] = true
+	}
+	dismissResolvedNotifications(i, notificationWorkloadPrefix, active)
+	for _, f := range newWorkloadFailures(failures) {
+		msg := fmt.Sprintf("pod %s/%s offloaded to %s: %s", f.Namespace, f.Pod, peerName(i.Status(), f.ClusterID),
+			f.Reason)
+		if f.Message != "" {
+			msg += " (" + f.Message + ")"
+		}
+		i.ShowNotification(app.Notification{
+			ID:       notificationWorkloadPrefix + f.Key(),
+			Title:    "Liqo Agent: OFFLOADED WORKLOAD FAILING",
+			Message:  msg,
+			Severity: app.SeverityError,
+			Category: app.CategoryResources,
+			Target:   app.NotificationTarget{Kind: "Pod", Name: f.Namespace + "/" + f.Pod},
+		}.WithTrayIcon(app.IconLiqoRed))
+	}
+}
+
+//dismissResolvedNotifications dismisses the active app.Notification with an ID starting with prefix and not in
+//active, so that they are displayed again if the problem they report reappears.
+func dismissResolvedNotifications(i *app.Indicator, prefix string, active map[string]bool) {
+	for _, n := range i.Notifications() {
+		if strings.HasPrefix(n.ID, prefix) && !active[n.ID] {
+			i.DismissNotification(n.ID)
+		}
+	}
+}
+
+//newWorkloadFailures returns the WorkloadFailure not yet notified to the user. The failures resolved in the
+//meantime are forgotten, so that they are notified again if they reappear.
+func newWorkloadFailures(failures []*client.WorkloadFailure) []*client.WorkloadFailure {
+	workloadsFailedMutex.Lock()
+	defer workloadsFailedMutex.Unlock()
+	current := make(map[string]bool)
+	var notified []*client.WorkloadFailure
+	for _, f := range failures {
+		current[f.Key()] = true
+		if !workloadsFailed[f.Key()] {
+			notified = append(notified, f)
+		}
+	}
+	workloadsFailed = current
+	return notified
+}
@@ -0,0 +1,13 @@
+/*
+Package metrics collects the internal metrics of Liqo Agent and exports them in the Prometheus text exposition format,
+so that the health of the Agent can be scraped from the local API (see the /metrics endpoint of package api), or
+pushes them to a Prometheus remote-write endpoint (see RemoteWriter).
+
+The metrics are of two kinds:
+
+* Timers, accumulating the durations of an operation (e.g. the handling of the cluster events), exported as summaries
+together with their maximum
+
+* Gauges, whose samples are collected on demand (e.g. the number of peers) when the metrics are exported.
+*/
+package metrics
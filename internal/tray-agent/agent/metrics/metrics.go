@@ -0,0 +1,289 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Namespace precedes the names of all the metrics of the Agent.
+const Namespace = "liqo_agent_"
+
+//Labels are the labels of a metric sample, e.g. {"listener": "peerDeleted"}.
+type Labels map[string]string
+
+//key returns the labels formatted as in the exposition format, e.g. {listener="peerDeleted"}, sorted by name.
+func (l Labels) key() string {
+	if len(l) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(l))
+	for name := range l {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+"="+strconv.Quote(l[name]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+//Sample is a value of a Gauge.
+type Sample struct {
+	Labels Labels
+	Value  float64
+}
+
+//TimerStats are the accumulated durations of a Timer.
+type TimerStats struct {
+	Count int
+	Total time.Duration
+	Max   time.Duration
+}
+
+//Timer accumulates the durations of an operation.
+type Timer struct {
+	labels Labels
+	mutex  sync.Mutex
+	stats  TimerStats
+}
+
+//Observe records a duration of the operation.
+func (t *Timer) Observe(d time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.stats.Count++
+	t.stats.Total += d
+	if d > t.stats.Max {
+		t.stats.Max = d
+	}
+}
+
+//ObserveSince records the duration of the operation started at start.
+func (t *Timer) ObserveSince(start time.Time) {
+	t.Observe(time.Since(start))
+}
+
+//Stats returns the accumulated durations of the Timer.
+func (t *Timer) Stats() TimerStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.stats
+}
+
+//timerFamily contains the Timers sharing a name, by labels.
+type timerFamily struct {
+	help string
+	//timers are indexed by their formatted labels.
+	timers map[string]*Timer
+}
+
+//gauge is a registered Gauge, whose samples are returned by collect.
+type gauge struct {
+	help    string
+	collect func() []Sample
+}
+
+//Registry contains the metrics exported by the Agent.
+type Registry struct {
+	mutex  sync.RWMutex
+	timers map[string]*timerFamily
+	gauges map[string]*gauge
+}
+
+//registry is the Registry singleton.
+var registry = NewRegistry()
+
+//NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{timers: make(map[string]*timerFamily), gauges: make(map[string]*gauge)}
+}
+
+//GetRegistry returns the Registry singleton, shared by the Agent components.
+func GetRegistry() *Registry {
+	return registry
+}
+
+//Timer returns the Timer with a given name (without the Namespace prefix, e.g. "event_handling_seconds") and
+//labels, creating it on first use.
+func (r *Registry) Timer(name string, help string, labels Labels) *Timer {
+	key := labels.key()
+	r.mutex.RLock()
+	if f, present := r.timers[name]; present {
+		if t, present := f.timers[key]; present {
+			r.mutex.RUnlock()
+			return t
+		}
+	}
+	r.mutex.RUnlock()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	f, present := r.timers[name]
+	if !present {
+		f = &timerFamily{help: help, timers: make(map[string]*Timer)}
+		r.timers[name] = f
+	}
+	t, present := f.timers[key]
+	if !present {
+		t = &Timer{labels: labels}
+		f.timers[key] = t
+	}
+	return t
+}
+
+//RegisterGauge registers the Gauge with a given name (without the Namespace prefix), whose samples are returned by
+//collect when the metrics are exported. A Gauge with the same name is replaced.
+func (r *Registry) RegisterGauge(name string, help string, collect func() []Sample) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.gauges[name] = &gauge{help: help, collect: collect}
+}
+
+//Reset removes all the metrics of the Registry.
+func (r *Registry) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.timers = make(map[string]*timerFamily)
+	r.gauges = make(map[string]*gauge)
+}
+
+//WriteText writes the metrics in the Prometheus text exposition format, sorted by name. Each Timer is exported as a
+//summary (the _count and _sum series, in seconds) and a gauge with the _max suffix.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mutex.RLock()
+	timers := make(map[string]*timerFamily, len(r.timers))
+	for name, f := range r.timers {
+		timers[name] = f
+	}
+	gauges := make(map[string]*gauge, len(r.gauges))
+	for name, g := range r.gauges {
+		gauges[name] = g
+	}
+	r.mutex.RUnlock()
+	names := make([]string, 0, len(timers)+len(gauges))
+	for name := range timers {
+		names = append(names, name)
+	}
+	for name := range gauges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := bufio.NewWriter(w)
+	for _, name := range names {
+		full := Namespace + name
+		if f, present := timers[name]; present {
+			r.writeTimers(out, full, f)
+			continue
+		}
+		g := gauges[name]
+		samples := g.collect()
+		writeHeader(out, full, g.help, "gauge")
+		for _, s := range samples {
+			writeSample(out, full, s.Labels.key(), s.Value)
+		}
+	}
+	return out.Flush()
+}
+
+//Series is a sample of a metric, with the full name of its series.
+type Series struct {
+	//Name is the name of the series, e.g. "liqo_agent_event_handling_seconds_count".
+	Name   string
+	Labels Labels
+	Value  float64
+}
+
+//Collect returns the current samples of all the metrics, sorted by name, as exported by WriteText.
+func (r *Registry) Collect() []Series {
+	r.mutex.RLock()
+	var timers []*Timer
+	var timerNames []string
+	for name, f := range r.timers {
+		for _, t := range f.timers {
+			timers = append(timers, t)
+			timerNames = append(timerNames, Namespace+name)
+		}
+	}
+	gauges := make(map[string]*gauge, len(r.gauges))
+	for name, g := range r.gauges {
+		gauges[Namespace+name] = g
+	}
+	r.mutex.RUnlock()
+	var series []Series
+	for n, t := range timers {
+		stats := t.Stats()
+		series = append(series,
+			Series{Name: timerNames[n] + "_sum", Labels: t.labels, Value: stats.Total.Seconds()},
+			Series{Name: timerNames[n] + "_count", Labels: t.labels, Value: float64(stats.Count)},
+			Series{Name: timerNames[n] + "_max", Labels: t.labels, Value: stats.Max.Seconds()})
+	}
+	for name, g := range gauges {
+		for _, s := range g.collect() {
+			series = append(series, Series{Name: name, Labels: s.Labels, Value: s.Value})
+		}
+	}
+	sort.Slice(series, func(a, b int) bool {
+		if series[a].Name != series[b].Name {
+			return series[a].Name < series[b].Name
+		}
+		return series[a].Labels.key() < series[b].Labels.key()
+	})
+	return series
+}
+
+//writeTimers writes a family of Timers.
+func (r *Registry) writeTimers(out *bufio.Writer, name string, f *timerFamily) {
+	r.mutex.RLock()
+	keys := make([]string, 0, len(f.timers))
+	for key := range f.timers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	timers := make([]*Timer, len(keys))
+	for n, key := range keys {
+		timers[n] = f.timers[key]
+	}
+	r.mutex.RUnlock()
+	stats := make([]TimerStats, len(keys))
+	for n, t := range timers {
+		stats[n] = t.Stats()
+	}
+	writeHeader(out, name, f.help, "summary")
+	for n, key := range keys {
+		writeSample(out, name+"_sum", key, stats[n].Total.Seconds())
+		writeSample(out, name+"_count", key, float64(stats[n].Count))
+	}
+	writeHeader(out, name+"_max", "Maximum of "+lowerFirst(f.help), "gauge")
+	for n, key := range keys {
+		writeSample(out, name+"_max", key, stats[n].Max.Seconds())
+	}
+}
+
+//writeHeader writes the HELP and TYPE lines of a metric.
+func writeHeader(out *bufio.Writer, name string, help string, kind string) {
+	_, _ = fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, kind)
+}
+
+//writeSample writes a sample of a metric.
+func writeSample(out *bufio.Writer, name string, labels string, value float64) {
+	_, _ = fmt.Fprintf(out, "%s%s %s\n", name, labels, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+//escapeHelp escapes the backslashes and the line feeds of a HELP text.
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+//lowerFirst returns a string with its first letter in lower case, e.g. "Duration of..." -> "duration of...".
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
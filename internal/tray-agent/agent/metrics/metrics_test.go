@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	r := NewRegistry()
+	timer := r.Timer("event_handling_seconds", "Duration of the handling of the events", Labels{"listener": "peer"})
+	assert.Same(t, timer, r.Timer("event_handling_seconds", "", Labels{"listener": "peer"}),
+		"Timer with the same labels not reused")
+	timer.Observe(500 * time.Millisecond)
+	timer.Observe(time.Second)
+	r.Timer("event_handling_seconds", "", Labels{"listener": "node"}).Observe(250 * time.Millisecond)
+	assert.Equal(t, TimerStats{Count: 2, Total: 1500 * time.Millisecond, Max: time.Second}, timer.Stats())
+	r.RegisterGauge("peers", "Number of peers", func() []Sample {
+		return []Sample{{Value: 3}}
+	})
+	buf := &bytes.Buffer{}
+	assert.NoError(t, r.WriteText(buf))
+	assert.Equal(t, `# HELP liqo_agent_event_handling_seconds Duration of the handling of the events
+# TYPE liqo_agent_event_handling_seconds summary
+liqo_agent_event_handling_seconds_sum{listener="node"} 0.25
+liqo_agent_event_handling_seconds_count{listener="node"} 1
+liqo_agent_event_handling_seconds_sum{listener="peer"} 1.5
+liqo_agent_event_handling_seconds_count{listener="peer"} 2
+# HELP liqo_agent_event_handling_seconds_max Maximum of duration of the handling of the events
+# TYPE liqo_agent_event_handling_seconds_max gauge
+liqo_agent_event_handling_seconds_max{listener="node"} 0.25
+liqo_agent_event_handling_seconds_max{listener="peer"} 1
+# HELP liqo_agent_peers Number of peers
+# TYPE liqo_agent_peers gauge
+liqo_agent_peers 3
+`, buf.String())
+	//the label values are escaped
+	assert.Equal(t, `{a="x\"y",b="z"}`, Labels{"b": "z", "a": `x"y`}.key())
+	r.Reset()
+	buf.Reset()
+	assert.NoError(t, r.WriteText(buf))
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,199 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*This file contains the push of the metrics to a Prometheus remote-write endpoint (e.g. Prometheus with the
+remote-write receiver enabled, Cortex, Thanos or Mimir), so that the metrics observed by an Agent running on a desktop
+or on a jump host can be charted alongside the ones of the clusters. Each push sends the current samples of all the
+metrics as a WriteRequest message of the remote-write protocol 1.0: the message is encoded by hand, since only a
+few fields are needed, and compressed as a snappy block.*/
+
+const (
+	//DefaultRemoteWriteInterval is the default interval between two pushes of the metrics.
+	DefaultRemoteWriteInterval = time.Minute
+	//remoteWriteTimeout is the time the endpoint has to accept a push.
+	remoteWriteTimeout = 30 * time.Second
+	//remoteWriteVersion is the version of the remote-write protocol.
+	remoteWriteVersion = "0.1.0"
+	//metricNameLabel is the label carrying the name of a series.
+	metricNameLabel = "__name__"
+)
+
+//RemoteWriteOptions contains the settings of a RemoteWriter.
+type RemoteWriteOptions struct {
+	//URL is the address of the remote-write endpoint, e.g. "https://prometheus.example.com/api/v1/write".
+	URL string
+	//Username and Password are the credentials of the basic authentication, if Username is set.
+	Username string
+	Password string
+	//Interval is the interval between two pushes. It defaults to DefaultRemoteWriteInterval.
+	Interval time.Duration
+	//Labels are added to all the series, e.g. {"instance": "jump-host"}. They do not override the labels of the
+	//series.
+	Labels Labels
+	//Client performs the requests. It defaults to an http.Client with a timeout.
+	Client *http.Client
+}
+
+//RemoteWriter periodically pushes the metrics of a Registry to a remote-write endpoint.
+type RemoteWriter struct {
+	registry *Registry
+	options  RemoteWriteOptions
+}
+
+//NewRemoteWriter returns a RemoteWriter pushing the metrics of a Registry.
+func NewRemoteWriter(r *Registry, options RemoteWriteOptions) *RemoteWriter {
+	if options.Interval <= 0 {
+		options.Interval = DefaultRemoteWriteInterval
+	}
+	if options.Client == nil {
+		options.Client = &http.Client{Timeout: remoteWriteTimeout}
+	}
+	return &RemoteWriter{registry: r, options: options}
+}
+
+//Run pushes the metrics at each interval until ctx is canceled. onResult is called with the outcome of each push.
+func (w *RemoteWriter) Run(ctx context.Context, onResult func(err error)) {
+	ticker := time.NewTicker(w.options.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.Push(ctx)
+			if onResult != nil {
+				onResult(err)
+			}
+		}
+	}
+}
+
+//Push sends the current samples of the metrics to the remote-write endpoint.
+func (w *RemoteWriter) Push(ctx context.Context) error {
+	body := snappyBlock(encodeWriteRequest(w.registry.Collect(), w.options.Labels, time.Now()))
+	req, err := http.NewRequest(http.MethodPost, w.options.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid remote-write URL: %w", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("User-Agent", "liqo-agent")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", remoteWriteVersion)
+	if w.options.Username != "" {
+		req.SetBasicAuth(w.options.Username, w.options.Password)
+	}
+	resp, err := w.options.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot push the metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		reply, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("cannot push the metrics: %s: %s", resp.Status, strings.TrimSpace(string(reply)))
+	}
+	return nil
+}
+
+//encodeWriteRequest encodes the series as a WriteRequest message, with a sample at a given instant:
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+//The labels of each TimeSeries are sorted by name, as required by the protocol.
+func encodeWriteRequest(series []Series, external Labels, at time.Time) []byte {
+	var request []byte
+	timestamp := at.UnixNano() / int64(time.Millisecond)
+	for _, s := range series {
+		labels := map[string]string{metricNameLabel: s.Name}
+		for name, value := range external {
+			labels[name] = value
+		}
+		for name, value := range s.Labels {
+			labels[name] = value
+		}
+		names := make([]string, 0, len(labels))
+		for name := range labels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var ts []byte
+		for _, name := range names {
+			var label []byte
+			label = appendBytesField(label, 1, []byte(name))
+			label = appendBytesField(label, 2, []byte(labels[name]))
+			ts = appendBytesField(ts, 1, label)
+		}
+		var sample []byte
+		sample = appendTag(sample, 1, 1)
+		sample = appendFixed64(sample, math.Float64bits(s.Value))
+		sample = appendTag(sample, 2, 0)
+		sample = appendVarint(sample, uint64(timestamp))
+		ts = appendBytesField(ts, 2, sample)
+		request = appendBytesField(request, 1, ts)
+	}
+	return request
+}
+
+//appendTag appends the key of a protobuf field, given its number and wire type.
+func appendTag(b []byte, field int, wireType int) []byte {
+	return appendVarint(b, uint64(field<<3|wireType))
+}
+
+//appendBytesField appends a length-delimited protobuf field.
+func appendBytesField(b []byte, field int, value []byte) []byte {
+	b = appendTag(b, field, 2)
+	b = appendVarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+//appendVarint appends a base 128 varint.
+func appendVarint(b []byte, v uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return append(b, buf[:binary.PutUvarint(buf, v)]...)
+}
+
+//appendFixed64 appends a little-endian 64-bit value.
+func appendFixed64(b []byte, v uint64) []byte {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, v)
+	return append(b, buf...)
+}
+
+//snappyMaxLiteral is the maximum length of a literal element of the snappy blocks built by snappyBlock.
+const snappyMaxLiteral = 1 << 16
+
+//snappyBlock returns data as a snappy block made of literal elements only. It is valid for any snappy decoder,
+//while not compressing data: the metrics of the Agent are a few kilobytes.
+func snappyBlock(data []byte) []byte {
+	block := appendVarint(make([]byte, 0, len(data)+len(data)/snappyMaxLiteral*3+binary.MaxVarintLen64+3),
+		uint64(len(data)))
+	for len(data) > 0 {
+		n := len(data)
+		if n > snappyMaxLiteral {
+			n = snappyMaxLiteral
+		}
+		if n <= 60 {
+			block = append(block, byte(n-1)<<2)
+		} else {
+			//tag 61: the length minus one follows in two bytes
+			block = append(block, 61<<2, byte(n-1), byte((n-1)>>8))
+		}
+		block = append(block, data[:n]...)
+		data = data[n:]
+	}
+	return block
+}
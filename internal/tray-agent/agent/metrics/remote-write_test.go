@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+//decodeSnappyLiterals decodes a snappy block made of literal elements.
+func decodeSnappyLiterals(t *testing.T, block []byte) []byte {
+	length, n := binary.Uvarint(block)
+	block = block[n:]
+	var data []byte
+	for len(block) > 0 {
+		if block[0]&3 != 0 {
+			t.Fatalf("element type %d, expected a literal", block[0]&3)
+		}
+		tag := block[0] >> 2
+		size := int(tag) + 1
+		block = block[1:]
+		if tag == 61 {
+			size = int(block[0]) + int(block[1])<<8 + 1
+			block = block[2:]
+		}
+		if size > len(block) {
+			t.Fatalf("literal of %d bytes exceeding the block", size)
+		}
+		data = append(data, block[:size]...)
+		block = block[size:]
+	}
+	assert.Equal(t, int(length), len(data), "length mismatch")
+	return data
+}
+
+//protoField is a field of a protobuf message: Bytes for the length-delimited ones, Value for the others.
+type protoField struct {
+	Number int
+	Bytes  []byte
+	Value  uint64
+}
+
+//decodeProto splits a protobuf message into its fields.
+func decodeProto(t *testing.T, b []byte) []protoField {
+	var fields []protoField
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		b = b[n:]
+		f := protoField{Number: int(key >> 3)}
+		switch key & 7 {
+		case 0:
+			f.Value, n = binary.Uvarint(b)
+			b = b[n:]
+		case 1:
+			f.Value = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case 2:
+			length, n := binary.Uvarint(b)
+			f.Bytes = b[n : n+int(length)]
+			b = b[n+int(length):]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func TestRemoteWriter_Push(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterGauge("peers", "Number of peers", func() []Sample {
+		return []Sample{{Labels: Labels{"cluster": "home"}, Value: 3}}
+	})
+	r.Timer("probe_seconds", "Duration of the probes", nil).Observe(250 * time.Millisecond)
+	var body []byte
+	var header http.Header
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		header = req.Header
+		user, password, ok := req.BasicAuth()
+		if !ok || user != "agent" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ = ioutil.ReadAll(req.Body)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("out of order sample"))
+	}))
+	defer server.Close()
+	w := NewRemoteWriter(r, RemoteWriteOptions{URL: server.URL, Username: "agent", Password: "secret",
+		Labels: Labels{"instance": "jump-host", "cluster": "ignored"}})
+	start := time.Now()
+	if !assert.NoError(t, w.Push(context.Background())) {
+		return
+	}
+	assert.Equal(t, "snappy", header.Get("Content-Encoding"))
+	assert.Equal(t, "application/x-protobuf", header.Get("Content-Type"))
+	assert.Equal(t, remoteWriteVersion, header.Get("X-Prometheus-Remote-Write-Version"))
+	series := decodeProto(t, decodeSnappyLiterals(t, body))
+	//the gauge and the _sum, _count and _max series of the Timer
+	if !assert.Len(t, series, 4) {
+		return
+	}
+	var names []string
+	for _, s := range series {
+		fields := decodeProto(t, s.Bytes)
+		labels := map[string]string{}
+		var order []string
+		var sample []protoField
+		for _, f := range fields {
+			if f.Number == 2 {
+				sample = decodeProto(t, f.Bytes)
+				continue
+			}
+			label := decodeProto(t, f.Bytes)
+			labels[string(label[0].Bytes)] = string(label[1].Bytes)
+			order = append(order, string(label[0].Bytes))
+		}
+		assert.IsIncreasing(t, order, "labels not sorted")
+		names = append(names, labels[metricNameLabel])
+		assert.Equal(t, "jump-host", labels["instance"])
+		if labels[metricNameLabel] == "liqo_agent_peers" {
+			assert.Equal(t, "home", labels["cluster"], "label of the series overridden")
+			assert.Equal(t, float64(3), math.Float64frombits(sample[0].Value))
+		}
+		assert.InDelta(t, start.UnixNano()/int64(time.Millisecond), int64(sample[1].Value), 5000)
+	}
+	assert.Equal(t, []string{"liqo_agent_peers", "liqo_agent_probe_seconds_count", "liqo_agent_probe_seconds_max",
+		"liqo_agent_probe_seconds_sum"}, names)
+	//the failures report the reply of the endpoint
+	status = http.StatusBadRequest
+	err := w.Push(context.Background())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "out of order sample")
+	}
+	w.options.Password = "wrong"
+	assert.Error(t, w.Push(context.Background()))
+}
+
+func TestSnappyBlock(t *testing.T) {
+	data := bytes.Repeat([]byte("liqo"), 40000)
+	assert.Equal(t, data, decodeSnappyLiterals(t, snappyBlock(data)))
+	assert.Equal(t, []byte{3, 2 << 2, 'a', 'b', 'c'}, snappyBlock([]byte("abc")))
+	assert.Equal(t, []byte{0}, snappyBlock(nil))
+}
@@ -0,0 +1,9 @@
+/*
+Package redact provides the central redaction layer of Liqo Agent, scrubbing secrets (tokens, certificates,
+private keys) and optionally the cluster server URLs from any text before it leaves the Agent.
+
+Install() routes the standard library and klog outputs through the layer, so that the logs produced by the Agent
+and its dependencies are redacted without any change at the call sites. Any other output meant to leave the
+machine (e.g. diagnostics bundles or metrics dumps) must be written through a Writer.
+*/
+package redact
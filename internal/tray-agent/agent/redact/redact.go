@@ -0,0 +1,134 @@
+package redact
+
+import (
+	"flag"
+	"io"
+	"io/ioutil"
+	klogv1 "k8s.io/klog"
+	"k8s.io/klog/v2"
+	"log"
+	"regexp"
+	"sync"
+)
+
+//Placeholder is the text replacing the redacted content.
+const Placeholder = "[REDACTED]"
+
+//rule is a single redaction rule: each match of the pattern is replaced by the replacement template.
+type rule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+//secretRules contains the rules always applied, ordered so that the wider matches come first.
+var secretRules = []rule{
+	//PEM encoded certificates and keys
+	{regexp.MustCompile(`-----BEGIN [A-Z ]+-----[\s\S]*?-----END [A-Z ]+-----`), Placeholder},
+	//kubeconfig and JSON/YAML fields containing credentials
+	{regexp.MustCompile(`(?i)((?:certificate-authority|client-certificate|client-key)-data|(?:id-|refresh-|access-)?token` +
+		`|password|secret)(["']?\s*[:=]\s*["']?)[^\s"',}]+`), "${1}${2}" + Placeholder},
+	//HTTP authorization headers
+	{regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`), "${1}" + Placeholder},
+	//JWT tokens
+	{regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`), Placeholder},
+}
+
+//serverURLRule redacts the host of HTTP(S) URLs, e.g. the address of the cluster API server.
+var serverURLRule = rule{regexp.MustCompile(`(?i)(https?://)[^\s/"'<>]+`), "${1}" + Placeholder}
+
+//Redactor singleton.
+var redactor = &Redactor{rules: append(append([]rule{}, secretRules...), serverURLRule)}
+
+//Redactor scrubs sensitive content from text. By default, it redacts secrets and server URLs.
+type Redactor struct {
+	rules []rule
+	sync.RWMutex
+}
+
+//GetRedactor returns the Redactor singleton.
+func GetRedactor() *Redactor {
+	return redactor
+}
+
+//Configure sets the Redactor rules. Secrets are always redacted, while server URLs are kept if keepServerURLs
+//is true. The additional patterns are regular expressions whose matches are entirely redacted.
+//In case of an invalid pattern, the Redactor is not modified.
+func (r *Redactor) Configure(keepServerURLs bool, patterns []string) error {
+	rules := append([]rule{}, secretRules...)
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, rule{re, Placeholder})
+	}
+	if !keepServerURLs {
+		rules = append(rules, serverURLRule)
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.rules = rules
+	return nil
+}
+
+//Redact returns s without its sensitive content.
+func (r *Redactor) Redact(s string) string {
+	r.RLock()
+	defer r.RUnlock()
+	for _, ru := range r.rules {
+		s = ru.pattern.ReplaceAllString(s, ru.replacement)
+	}
+	return s
+}
+
+//String returns s without its sensitive content, using the Redactor singleton.
+func String(s string) string {
+	return redactor.Redact(s)
+}
+
+//writer is an io.Writer redacting the written content.
+type writer struct {
+	w io.Writer
+}
+
+//Writer returns an io.Writer redacting, using the Redactor singleton, the content written to w.
+//Each Write call is redacted on its own: writes should contain whole lines or documents.
+func Writer(w io.Writer) io.Writer {
+	return &writer{w: w}
+}
+
+//Write redacts p and writes it to the underlying io.Writer. The returned length refers to p, so that
+//redaction is transparent to the callers.
+func (rw *writer) Write(p []byte) (int, error) {
+	if _, err := rw.w.Write([]byte(String(string(p)))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+//Install routes the outputs of the standard library logger and of klog (used by the kubernetes and Liqo
+//libraries) to w, passing them through the redaction layer.
+func Install(w io.Writer) {
+	out := Writer(w)
+	log.SetOutput(out)
+	//klog writes each entry to the output of its severity and of all the lower ones: only the INFO output,
+	//receiving all the entries, is used. The direct writes to stderr are disabled.
+	fsV1 := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klogv1.InitFlags(fsV1)
+	_ = fsV1.Set("logtostderr", "false")
+	_ = fsV1.Set("alsologtostderr", "false")
+	_ = fsV1.Set("stderrthreshold", "4")
+	for _, s := range []string{"FATAL", "ERROR", "WARNING"} {
+		klogv1.SetOutputBySeverity(s, ioutil.Discard)
+	}
+	klogv1.SetOutputBySeverity("INFO", out)
+	fsV2 := flag.NewFlagSet("klog/v2", flag.ContinueOnError)
+	klog.InitFlags(fsV2)
+	_ = fsV2.Set("logtostderr", "false")
+	_ = fsV2.Set("alsologtostderr", "false")
+	_ = fsV2.Set("stderrthreshold", "4")
+	for _, s := range []string{"FATAL", "ERROR", "WARNING"} {
+		klog.SetOutputBySeverity(s, ioutil.Discard)
+	}
+	klog.SetOutputBySeverity("INFO", out)
+}
@@ -0,0 +1,14 @@
+/*
+Package settings provides the settings page of Liqo Agent, a small web UI editing the user-facing settings of the
+local configuration.
+
+The page is served by an embedded HTTP server listening on the loopback interface, on a random port. Each start of
+the Server generates a new random token, which is required by every request: the page URL (see Server.URL) carries
+it as a query parameter, and the page sends it back in the TokenHeader header of the calls to the settings endpoint
+(SettingsPath):
+
+* GET returns the Form, i.e. the current Values together with the allowed choices
+
+* PUT applies and persists new Values, returning the updated Form.
+*/
+package settings
@@ -0,0 +1,91 @@
+package settings
+
+//page is the settings page. Its script reads the token from the page URL and uses it to load and save the Form: the
+//inputs are named after the json names of the Values fields, and the selects are filled with the Choices.
+const page = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Liqo Agent settings</title>
+<style>
+body { font-family: sans-serif; max-width: 36em; margin: 2em auto; color: #222; }
+h1 { font-size: 1.4em; }
+label { display: flex; justify-content: space-between; align-items: center; margin: .8em 0; }
+select, input[type=text] { width: 16em; }
+#status { min-height: 1.5em; margin-top: 1em; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>Liqo Agent settings</h1>
+<form id="settings">
+<label>Language <select name="language"></select></label>
+<label>Icon theme <select name="iconTheme"></select></label>
+<label>Color scheme <select name="colorScheme"></select></label>
+<label>Peers count badge on the icon <input type="checkbox" name="iconBadge"></label>
+<label>Tray label <select name="labelMode"></select></label>
+<label>Group peers by label <input type="text" name="peerGroupLabel" placeholder="no grouping"></label>
+<label>Do Not Disturb <input type="checkbox" name="doNotDisturb"></label>
+<label>Read-only mode <input type="checkbox" name="readOnly"></label>
+<label>Debug logging <input type="checkbox" name="debugLogging"></label>
+<button type="submit">Save</button>
+</form>
+<div id="status"></div>
+<script>
+"use strict";
+const token = new URLSearchParams(window.location.search).get("token");
+const form = document.getElementById("settings");
+const status = document.getElementById("status");
+
+function show(message, error) {
+	status.textContent = message;
+	status.className = error ? "error" : "";
+}
+
+function render(data) {
+	for (const input of form.elements) {
+		if (!input.name) {
+			continue;
+		}
+		const choices = data.choices[input.name];
+		if (input.tagName === "SELECT" && choices) {
+			input.replaceChildren(...choices.map(c => new Option(c.description, c.value)));
+		}
+		const value = data.values[input.name];
+		if (input.type === "checkbox") {
+			input.checked = value;
+		} else {
+			input.value = value;
+		}
+		input.disabled = (data.locked || []).includes(input.name);
+	}
+}
+
+async function call(method, body) {
+	const response = await fetch("` + SettingsPath + `", {
+		method: method,
+		headers: {"` + TokenHeader + `": token, "Content-Type": "application/json"},
+		body: body,
+	});
+	if (!response.ok) {
+		throw new Error(await response.text() || response.statusText);
+	}
+	render(await response.json());
+}
+
+form.addEventListener("submit", event => {
+	event.preventDefault();
+	const values = {};
+	for (const input of form.elements) {
+		if (input.name) {
+			values[input.name] = input.type === "checkbox" ? input.checked : input.value;
+		}
+	}
+	call("PUT", JSON.stringify(values)).then(() => show("Settings saved.", false), e => show(e.message, true));
+});
+
+call("GET").catch(e => show(e.message, true));
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,239 @@
+package settings
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	//DefaultAddress is the listening address of the Server: the loopback interface, on a random port.
+	DefaultAddress = "127.0.0.1:0"
+	//PagePath is the path of the settings page.
+	PagePath = "/"
+	//SettingsPath is the path of the endpoint reading (GET) and updating (PUT) the settings.
+	SettingsPath = "/api/v1/settings"
+	//TokenParam is the query parameter carrying the token in the URL of the settings page.
+	TokenParam = "token"
+	//TokenHeader is the header carrying the token in the requests to SettingsPath.
+	TokenHeader = "X-Liqo-Settings-Token"
+	//tokenLength is the number of random bytes of a token.
+	tokenLength = 32
+	//maxRequestSize is the maximum size of the body of a request.
+	maxRequestSize = 64 << 10
+	//shutdownTimeout is the maximum amount of time waited for the server graceful shutdown.
+	shutdownTimeout = 5 * time.Second
+)
+
+//Values contains the settings edited by the settings page.
+type Values struct {
+	Language       string `json:"language"`
+	IconTheme      string `json:"iconTheme"`
+	ColorScheme    string `json:"colorScheme"`
+	IconBadge      bool   `json:"iconBadge"`
+	LabelMode      string `json:"labelMode"`
+	PeerGroupLabel string `json:"peerGroupLabel"`
+	DoNotDisturb   bool   `json:"doNotDisturb"`
+	ReadOnly       bool   `json:"readOnly"`
+	DebugLogging   bool   `json:"debugLogging"`
+}
+
+//Choice is an allowed value of a setting, with its description.
+type Choice struct {
+	Value       string `json:"value"`
+	Description string `json:"description"`
+}
+
+//Form contains the current Values and the constraints of the settings page.
+type Form struct {
+	Values Values `json:"values"`
+	//Choices contains, by json name of the Values field (e.g. "iconTheme"), the allowed values of the settings
+	//with a fixed set of values.
+	Choices map[string][]Choice `json:"choices"`
+	//Locked contains the json names of the Values fields which cannot be changed, e.g. since they are enforced by
+	//the organization-wide defaults.
+	Locked []string `json:"locked,omitempty"`
+}
+
+//Server singleton.
+var server *Server
+
+//Server is the embedded HTTP server of the settings page.
+type Server struct {
+	//formFunc returns the Form served by the Server.
+	formFunc func() *Form
+	//applyFunc applies and persists the Values submitted by the settings page. An error rejects them.
+	applyFunc func(*Values) error
+	//token is the secret required by each request. It changes at each Start.
+	token string
+	//address is the actual listening address of the running Server.
+	address string
+	//httpServer is the underlying HTTP server. It is nil when the Server is not running.
+	httpServer *http.Server
+	sync.RWMutex
+}
+
+//GetServer returns the Server singleton. The Server is not started until Start() is called.
+func GetServer() *Server {
+	if server == nil {
+		server = &Server{
+			formFunc: func() *Form {
+				return &Form{Choices: map[string][]Choice{}}
+			},
+			applyFunc: func(*Values) error {
+				return errors.New("settings not editable")
+			},
+		}
+	}
+	return server
+}
+
+//SetFormFunc sets the function providing the Form served by the Server.
+func (s *Server) SetFormFunc(formFunc func() *Form) {
+	s.Lock()
+	defer s.Unlock()
+	if formFunc != nil {
+		s.formFunc = formFunc
+	}
+}
+
+//SetApplyFunc sets the function applying and persisting the Values submitted by the settings page.
+func (s *Server) SetApplyFunc(applyFunc func(*Values) error) {
+	s.Lock()
+	defer s.Unlock()
+	if applyFunc != nil {
+		s.applyFunc = applyFunc
+	}
+}
+
+//Running returns whether the Server is currently serving requests.
+func (s *Server) Running() bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.httpServer != nil
+}
+
+//Start starts serving the settings page on the provided address (see DefaultAddress), with a new token.
+func (s *Server) Start(address string) error {
+	s.Lock()
+	defer s.Unlock()
+	if s.httpServer != nil {
+		return errors.New("the settings page is already served")
+	}
+	token := make([]byte, tokenLength)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	s.token = hex.EncodeToString(token)
+	s.address = listener.Addr().String()
+	s.httpServer = &http.Server{Handler: s.handler()}
+	go func(srv *http.Server) {
+		_ = srv.Serve(listener)
+	}(s.httpServer)
+	return nil
+}
+
+//Stop gracefully stops the Server. Its token is no longer valid.
+func (s *Server) Stop() {
+	s.Lock()
+	defer s.Unlock()
+	if s.httpServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	_ = s.httpServer.Shutdown(ctx)
+	s.httpServer = nil
+	s.token, s.address = "", ""
+}
+
+//URL returns the URL of the settings page, including the token, or an empty string if the Server is not running.
+func (s *Server) URL() string {
+	s.RLock()
+	defer s.RUnlock()
+	if s.httpServer == nil {
+		return ""
+	}
+	return "http://" + s.address + PagePath + "?" + TokenParam + "=" + s.token
+}
+
+//handler returns the http.Handler routing the settings page and endpoint.
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(PagePath, s.servePage)
+	mux.HandleFunc(SettingsPath, s.serveSettings)
+	return mux
+}
+
+//authorized returns whether the request carries the token of the Server.
+func (s *Server) authorized(token string) bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+//servePage is the handler of the PagePath endpoint.
+func (s *Server) servePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != PagePath {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorized(r.URL.Query().Get(TokenParam)) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	h := w.Header()
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	h.Set("Cache-Control", "no-store")
+	h.Set("X-Frame-Options", "DENY")
+	h.Set("Content-Security-Policy", "default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; "+
+		"connect-src 'self'")
+	_, _ = w.Write([]byte(page))
+}
+
+//serveSettings is the handler of the SettingsPath endpoint.
+func (s *Server) serveSettings(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r.Header.Get(TokenHeader)) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	s.RLock()
+	formFunc, applyFunc := s.formFunc, s.applyFunc
+	s.RUnlock()
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		values := &Values{}
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(values); err != nil {
+			http.Error(w, "invalid settings: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := applyFunc(values); err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(formFunc())
+}
@@ -0,0 +1,92 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	s := GetServer()
+	current := Values{Language: "en", IconTheme: "default"}
+	s.SetFormFunc(func() *Form {
+		return &Form{Values: current, Choices: map[string][]Choice{
+			"iconTheme": {{Value: "default", Description: "Default"}}}}
+	})
+	s.SetApplyFunc(func(v *Values) error {
+		if v.IconTheme != "default" {
+			return errors.New("invalid icon theme")
+		}
+		current = *v
+		return nil
+	})
+	assert.Empty(t, s.URL())
+	if !assert.NoError(t, s.Start(DefaultAddress)) {
+		return
+	}
+	defer s.Stop()
+	assert.True(t, s.Running())
+	assert.Error(t, s.Start(DefaultAddress), "server started twice")
+	pageURL, err := url.Parse(s.URL())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "127.0.0.1", pageURL.Hostname())
+	token := pageURL.Query().Get(TokenParam)
+	assert.Len(t, token, 2*tokenLength)
+	request := func(method string, path string, token string, body string) *http.Response {
+		req, _ := http.NewRequest(method, "http://"+pageURL.Host+path, strings.NewReader(body))
+		req.Header.Set(TokenHeader, token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	//the page
+	resp, err := http.Get(s.URL())
+	if !assert.NoError(t, err) {
+		return
+	}
+	page, _ := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(page), SettingsPath)
+	resp = request(http.MethodGet, PagePath+"?"+TokenParam+"=wrong", "", "")
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "page served with a wrong token")
+	//the settings endpoint
+	resp = request(http.MethodGet, SettingsPath, "", "")
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "settings served without a token")
+	resp = request(http.MethodGet, SettingsPath, token, "")
+	form := &Form{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(form))
+	_ = resp.Body.Close()
+	assert.Equal(t, "en", form.Values.Language)
+	assert.Len(t, form.Choices["iconTheme"], 1)
+	resp = request(http.MethodPut, SettingsPath, token, `{"language":"it","iconTheme":"default","readOnly":true}`)
+	form = &Form{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(form))
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "it", form.Values.Language, "settings not applied")
+	assert.True(t, current.ReadOnly)
+	resp = request(http.MethodPut, SettingsPath, token, `{"iconTheme":"other"}`)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, "rejected settings applied")
+	resp = request(http.MethodPut, SettingsPath, token, `{"unknown":true}`)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "it", current.Language)
+	//a restart invalidates the token
+	s.Stop()
+	assert.False(t, s.Running())
+	assert.NoError(t, s.Start(DefaultAddress))
+	assert.NotContains(t, s.URL(), token)
+}
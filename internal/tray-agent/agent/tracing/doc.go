@@ -0,0 +1,10 @@
+/*
+Package tracing records the spans of the Agent operations (e.g. the startup of the caches, the events of the watched
+resources and their handling by the Indicator) and exports them to an OpenTelemetry collector with the OTLP/HTTP
+protocol, in its JSON encoding, so that the delays between a change in the cluster and its display in the tray menu
+can be inspected on a tracing backend (e.g. Jaeger).
+
+The spans are recorded only while an Exporter is set (see SetExporter): otherwise, Start returns a nil *Span, whose
+methods do nothing, so that the instrumented code does not need to check whether tracing is enabled.
+*/
+package tracing
@@ -0,0 +1,274 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*This file contains the Exporter sending the Spans to an OpenTelemetry collector with the OTLP/HTTP protocol, in its
+JSON encoding. The Spans are queued when they end, and sent in batches at each export interval: if the collector is
+unreachable, the oldest queued Spans are dropped.*/
+
+const (
+	//DefaultExportInterval is the default interval between two exports of the queued Spans.
+	DefaultExportInterval = 5 * time.Second
+	//DefaultServiceName is the default name of the service emitting the Spans.
+	DefaultServiceName = "liqo-agent"
+	//TracesPath is the path of the traces endpoint of an OTLP/HTTP collector.
+	TracesPath = "/v1/traces"
+	//maxQueuedSpans is the maximum number of Spans waiting to be exported.
+	maxQueuedSpans = 2048
+	//maxBatchSpans is the maximum number of Spans sent by a single request.
+	maxBatchSpans = 512
+	//exportTimeout is the time the collector has to accept a batch.
+	exportTimeout = 10 * time.Second
+	//OTLP span kind and status codes.
+	spanKindInternal = 1
+	statusCodeError  = 2
+)
+
+//OTLPOptions contains the settings of an OTLPExporter.
+type OTLPOptions struct {
+	//Endpoint is the URL of the traces endpoint of the collector, e.g. "http://localhost:4318/v1/traces".
+	Endpoint string
+	//Headers are added to the export requests, e.g. the authentication ones.
+	Headers map[string]string
+	//ServiceName is the service.name attribute of the exported resource. It defaults to DefaultServiceName.
+	ServiceName string
+	//Interval is the interval between two exports. It defaults to DefaultExportInterval.
+	Interval time.Duration
+	//Client performs the requests. It defaults to an http.Client with a timeout.
+	Client *http.Client
+}
+
+//OTLPExporter is the Exporter sending the Spans to an OpenTelemetry collector.
+type OTLPExporter struct {
+	options OTLPOptions
+	//mutex protects queue and dropped.
+	mutex sync.Mutex
+	queue []*Span
+	//dropped is the number of Spans dropped since the queue was full.
+	dropped int
+}
+
+//NewOTLPExporter returns an OTLPExporter.
+func NewOTLPExporter(options OTLPOptions) *OTLPExporter {
+	if options.ServiceName == "" {
+		options.ServiceName = DefaultServiceName
+	}
+	if options.Interval <= 0 {
+		options.Interval = DefaultExportInterval
+	}
+	if options.Client == nil {
+		options.Client = &http.Client{Timeout: exportTimeout}
+	}
+	return &OTLPExporter{options: options}
+}
+
+//Export implements the Exporter interface, queuing the Span until the next export.
+func (e *OTLPExporter) Export(s *Span) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if len(e.queue) == maxQueuedSpans {
+		e.queue = e.queue[1:]
+		e.dropped++
+	}
+	e.queue = append(e.queue, s)
+}
+
+//Dropped returns the number of Spans dropped because the queue was full.
+func (e *OTLPExporter) Dropped() int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.dropped
+}
+
+//Run exports the queued Spans at each interval until ctx is canceled, when the last ones are exported. onResult is
+//called with the outcome of each export.
+func (e *OTLPExporter) Run(ctx context.Context, onResult func(err error)) {
+	ticker := time.NewTicker(e.options.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+			_ = e.Flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			err := e.Flush(ctx)
+			if onResult != nil {
+				onResult(err)
+			}
+		}
+	}
+}
+
+//Flush exports the queued Spans. The Spans of a failed batch are discarded.
+func (e *OTLPExporter) Flush(ctx context.Context) error {
+	for {
+		e.mutex.Lock()
+		n := len(e.queue)
+		if n > maxBatchSpans {
+			n = maxBatchSpans
+		}
+		batch := e.queue[:n]
+		e.queue = e.queue[n:]
+		e.mutex.Unlock()
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := e.send(ctx, batch); err != nil {
+			return err
+		}
+	}
+}
+
+//send sends a batch of Spans to the collector.
+func (e *OTLPExporter) send(ctx context.Context, batch []*Span) error {
+	body, err := json.Marshal(e.encode(batch))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.options.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid OTLP endpoint: %w", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "liqo-agent")
+	for name, value := range e.options.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := e.options.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot export the spans: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		reply, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("cannot export the spans: %s: %s", resp.Status, strings.TrimSpace(string(reply)))
+	}
+	return nil
+}
+
+//The following types are the subset of the OTLP JSON encoding of an ExportTraceServiceRequest written by the
+//OTLPExporter.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            *otlpStatus     `json:"status,omitempty"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	//otlpValue is an AnyValue: only one of its fields is set. The 64-bit integers are encoded as strings.
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+	}
+)
+
+//encode returns the ExportTraceServiceRequest of a batch of Spans.
+func (e *OTLPExporter) encode(batch []*Span) otlpRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, s := range batch {
+		s.mutex.Lock()
+		span := otlpSpan{
+			TraceID:           s.TraceID(),
+			SpanID:            s.ID(),
+			ParentSpanID:      s.ParentID(),
+			Name:              s.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        encodeAttributes(s.attributes),
+		}
+		if s.err != nil {
+			span.Status = &otlpStatus{Code: statusCodeError, Message: s.err.Error()}
+		}
+		s.mutex.Unlock()
+		spans = append(spans, span)
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: encodeAttributes(Attributes{"service.name": e.options.ServiceName})},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: DefaultServiceName},
+			Spans: spans,
+		}},
+	}}}
+}
+
+//encodeAttributes returns the OTLP encoding of some Attributes, sorted by key. The values of unsupported types are
+//formatted as strings.
+func encodeAttributes(attributes Attributes) []otlpAttribute {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	encoded := make([]otlpAttribute, 0, len(keys))
+	for _, key := range keys {
+		var value otlpValue
+		switch v := attributes[key].(type) {
+		case string:
+			value.StringValue = &v
+		case int:
+			i := strconv.Itoa(v)
+			value.IntValue = &i
+		case int64:
+			i := strconv.FormatInt(v, 10)
+			value.IntValue = &i
+		case float64:
+			value.DoubleValue = &v
+		case bool:
+			value.BoolValue = &v
+		default:
+			s := fmt.Sprint(v)
+			value.StringValue = &s
+		}
+		encoded = append(encoded, otlpAttribute{Key: key, Value: value})
+	}
+	return encoded
+}
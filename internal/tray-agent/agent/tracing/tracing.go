@@ -0,0 +1,178 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+//Attributes are the attributes of a Span, e.g. {"resource": "foreignclusters"}. The values are strings, integers,
+//floats or booleans.
+type Attributes map[string]interface{}
+
+//Exporter receives the ended Spans.
+type Exporter interface {
+	//Export hands an ended Span to the Exporter. It must not block.
+	Export(s *Span)
+}
+
+//Span is a timed operation of the Agent, part of a trace. A nil *Span is valid and records nothing.
+type Span struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	start    time.Time
+	exporter Exporter
+	//mutex protects the following fields.
+	mutex      sync.Mutex
+	end        time.Time
+	attributes Attributes
+	err        error
+	ended      bool
+}
+
+//spanKey is the key of the current Span in a context.Context.
+type spanKey struct{}
+
+var (
+	//exporter is the Exporter of the ended Spans, nil if tracing is disabled.
+	exporter Exporter
+	//exporterMutex protects exporter.
+	exporterMutex sync.RWMutex
+)
+
+//SetExporter sets the Exporter receiving the ended Spans, enabling tracing. A nil Exporter disables it.
+func SetExporter(e Exporter) {
+	exporterMutex.Lock()
+	defer exporterMutex.Unlock()
+	exporter = e
+}
+
+//Enabled returns whether the Spans are recorded.
+func Enabled() bool {
+	exporterMutex.RLock()
+	defer exporterMutex.RUnlock()
+	return exporter != nil
+}
+
+//Start starts a Span, child of the current one of ctx if any, and returns it together with a context carrying it.
+//If tracing is disabled, the Span is nil and ctx is returned unchanged.
+func Start(ctx context.Context, name string, attributes Attributes) (context.Context, *Span) {
+	exporterMutex.RLock()
+	e := exporter
+	exporterMutex.RUnlock()
+	if e == nil {
+		return ctx, nil
+	}
+	s := &Span{name: name, start: time.Now(), exporter: e, attributes: Attributes{}}
+	for key, value := range attributes {
+		s.attributes[key] = value
+	}
+	if parent := FromContext(ctx); parent != nil {
+		s.traceID, s.parentID = parent.traceID, parent.spanID
+	} else {
+		_, _ = rand.Read(s.traceID[:])
+	}
+	_, _ = rand.Read(s.spanID[:])
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+//FromContext returns the current Span of ctx, if any.
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+//SetAttribute sets an attribute of the Span.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.attributes[key] = value
+}
+
+//SetError marks the Span as failed, if err is not nil.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.err = err
+}
+
+//End ends the Span, handing it to the Exporter. Only the first call is effective.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended, s.end = true, time.Now()
+	s.mutex.Unlock()
+	s.exporter.Export(s)
+}
+
+//Name returns the name of the Span.
+func (s *Span) Name() string {
+	if s == nil {
+		return ""
+	}
+	return s.name
+}
+
+//TraceID returns the hex encoded ID of the trace of the Span.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+//ParentID returns the hex encoded ID of the parent of the Span, empty for the root Spans.
+func (s *Span) ParentID() string {
+	if s == nil || s.parentID == [8]byte{} {
+		return ""
+	}
+	return hex.EncodeToString(s.parentID[:])
+}
+
+//ID returns the hex encoded ID of the Span.
+func (s *Span) ID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.spanID[:])
+}
+
+//Attribute returns an attribute of the Span.
+func (s *Span) Attribute(key string) (interface{}, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	value, present := s.attributes[key]
+	return value, present
+}
+
+//Err returns the error of a failed Span.
+func (s *Span) Err() error {
+	if s == nil {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
+}
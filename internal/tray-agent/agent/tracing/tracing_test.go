@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+//spanRecorder is an Exporter recording the ended Spans.
+type spanRecorder struct {
+	spans []*Span
+}
+
+func (r *spanRecorder) Export(s *Span) {
+	r.spans = append(r.spans, s)
+}
+
+func TestStart(t *testing.T) {
+	SetExporter(nil)
+	ctx, s := Start(context.Background(), "disabled", nil)
+	assert.Nil(t, s, "span recorded with tracing disabled")
+	assert.Nil(t, FromContext(ctx))
+	//the methods of a nil Span do nothing
+	s.SetAttribute("key", "value")
+	s.SetError(errors.New("failure"))
+	s.End()
+	recorder := &spanRecorder{}
+	SetExporter(recorder)
+	defer SetExporter(nil)
+	assert.True(t, Enabled())
+	ctx, parent := Start(context.Background(), "parent", Attributes{"resource": "foreignclusters"})
+	assert.Same(t, parent, FromContext(ctx))
+	_, child := Start(ctx, "child", nil)
+	child.SetError(errors.New("failure"))
+	child.End()
+	child.End()
+	parent.End()
+	if assert.Len(t, recorder.spans, 2, "span not exported once") {
+		assert.Equal(t, child, recorder.spans[0])
+	}
+	assert.Equal(t, parent.TraceID(), child.TraceID(), "child in a different trace")
+	assert.Equal(t, parent.ID(), child.ParentID())
+	assert.Empty(t, parent.ParentID())
+	assert.Len(t, parent.TraceID(), 32)
+	assert.Len(t, parent.ID(), 16)
+	value, _ := parent.Attribute("resource")
+	assert.Equal(t, "foreignclusters", value)
+	assert.EqualError(t, child.Err(), "failure")
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var request otlpRequest
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.URL.Path != TracesPath || json.Unmarshal(body, &request) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	e := NewOTLPExporter(OTLPOptions{Endpoint: server.URL + TracesPath,
+		Headers: map[string]string{"Authorization": "Bearer token"}})
+	SetExporter(e)
+	defer SetExporter(nil)
+	ctx, parent := Start(context.Background(), "startCaches", Attributes{"caches": 11, "mocked": true})
+	_, child := Start(ctx, "syncCache", Attributes{"resource": "foreignclusters"})
+	child.SetError(errors.New("timeout"))
+	child.End()
+	parent.End()
+	assert.NoError(t, e.Flush(context.Background()))
+	assert.Equal(t, "Bearer token", header.Get("Authorization"))
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	if !assert.Len(t, request.ResourceSpans, 1) || !assert.Len(t, request.ResourceSpans[0].ScopeSpans, 1) {
+		return
+	}
+	assert.Equal(t, DefaultServiceName, *request.ResourceSpans[0].Resource.Attributes[0].Value.StringValue)
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "syncCache", spans[0].Name)
+		assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+		if assert.NotNil(t, spans[0].Status) {
+			assert.Equal(t, statusCodeError, spans[0].Status.Code)
+			assert.Equal(t, "timeout", spans[0].Status.Message)
+		}
+		assert.Nil(t, spans[1].Status)
+		assert.Equal(t, "caches", spans[1].Attributes[0].Key)
+		assert.Equal(t, "11", *spans[1].Attributes[0].Value.IntValue)
+		assert.True(t, *spans[1].Attributes[1].Value.BoolValue)
+		assert.NotEqual(t, "0", spans[1].EndTimeUnixNano)
+	}
+	//an empty queue sends nothing
+	request = otlpRequest{}
+	assert.NoError(t, e.Flush(context.Background()))
+	assert.Empty(t, request.ResourceSpans)
+	//the queue drops the oldest Spans when full
+	for n := 0; n < maxQueuedSpans+3; n++ {
+		_, s := Start(context.Background(), "event", nil)
+		s.End()
+	}
+	assert.Equal(t, 3, e.Dropped())
+	e.options.Endpoint = server.URL + "/wrong"
+	assert.Error(t, e.Flush(context.Background()))
+}
@@ -0,0 +1,11 @@
+/*
+Package xdg resolves the directories of the Liqo Agent files, following the XDG Base Directory specification
+(https://specifications.freedesktop.org/basedir-spec/): each kind of file is stored in the liqo subdirectory of the
+corresponding base directory, e.g. the configuration in $XDG_CONFIG_HOME/liqo (by default ~/.config/liqo).
+
+Setting the LIQO_PATH env variable selects a single directory for all the files, e.g. for portable setups and tests.
+
+The package does not depend on the other packages of the Agent, so that all of them (including the logging one)
+resolve the directories in the same way.
+*/
+package xdg
@@ -0,0 +1,101 @@
+package xdg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+//EnvLiqoPath defines the env var containing the path of the root directory of the Liqo Agent on the local file system.
+const EnvLiqoPath = "LIQO_PATH"
+
+//Dir is a kind of directory of the Agent files.
+type Dir string
+
+//Dir values.
+const (
+	//DirConfig contains the configuration edited by the user.
+	DirConfig Dir = "config"
+	//DirState contains the state persisted across runs of the Agent, e.g. the menu state, the peering history and
+	//the log files.
+	DirState Dir = "state"
+	//DirCache contains the files the Agent can recreate, e.g. the kubeconfig files selecting a context.
+	DirCache Dir = "cache"
+	//DirData contains the resources installed with the Agent, e.g. the icons of the notifications.
+	DirData Dir = "data"
+	//DirRuntime contains the files bound to the user session, e.g. the socket of the headless GuiBackend. It has no
+	//default location: it is available only when the session provides $XDG_RUNTIME_DIR.
+	DirRuntime Dir = "runtime"
+)
+
+//appDirName is the name of the Agent subdirectory of the XDG base directories.
+const appDirName = "liqo"
+
+//xdgBaseDirs contains, for each Dir, the env variable of its XDG base directory and its default location, relative
+//to the home directory (empty if it has no default location).
+var xdgBaseDirs = map[Dir]struct {
+	env      string
+	fallback string
+}{
+	DirConfig:  {env: "XDG_CONFIG_HOME", fallback: ".config"},
+	DirState:   {env: "XDG_STATE_HOME", fallback: filepath.Join(".local", "state")},
+	DirCache:   {env: "XDG_CACHE_HOME", fallback: ".cache"},
+	DirData:    {env: "XDG_DATA_HOME", fallback: filepath.Join(".local", "share")},
+	DirRuntime: {env: "XDG_RUNTIME_DIR"},
+}
+
+//Path returns the path of the directory, or an empty string if it cannot be determined (e.g. the home directory is
+//unknown): in that case, the files are kept in memory only.
+func (d Dir) Path() string {
+	if liqoDir, present := os.LookupEnv(EnvLiqoPath); present {
+		return liqoDir
+	}
+	base := d.BasePath()
+	if base == "" {
+		return ""
+	}
+	return filepath.Join(base, appDirName)
+}
+
+//BasePath returns the path of the XDG base directory containing the directory (e.g. ~/.config for DirConfig),
+//shared with the other applications, or an empty string if it cannot be determined. Unlike Path, it is not
+//affected by EnvLiqoPath.
+func (d Dir) BasePath() string {
+	base, ok := xdgBaseDirs[d]
+	if !ok {
+		return ""
+	}
+	//relative paths are invalid according to the specification, and ignored
+	if dir := os.Getenv(base.env); filepath.IsAbs(dir) {
+		return dir
+	}
+	if base.fallback == "" {
+		return ""
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, base.fallback)
+}
+
+//File returns the path of a file inside the directory, or an empty string if the directory is not available.
+func (d Dir) File(name string) string {
+	dir := d.Path()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, name)
+}
+
+//Ensure creates the directory, accessible by the current user only, if it does not exist, and returns its path.
+func (d Dir) Ensure() (string, error) {
+	dir := d.Path()
+	if dir == "" {
+		return "", fmt.Errorf("the %s directory is not available", d)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
@@ -0,0 +1,75 @@
+package xdg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//setTestEnv sets the env variables of a test, returning a function restoring their previous values.
+func setTestEnv(t *testing.T, vars map[string]string) func() {
+	previous := map[string]*string{}
+	for name, value := range vars {
+		if old, present := os.LookupEnv(name); present {
+			previous[name] = &old
+		} else {
+			previous[name] = nil
+		}
+		var err error
+		if value == "" {
+			err = os.Unsetenv(name)
+		} else {
+			err = os.Setenv(name, value)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return func() {
+		for name, old := range previous {
+			if old != nil {
+				_ = os.Setenv(name, *old)
+			} else {
+				_ = os.Unsetenv(name)
+			}
+		}
+	}
+}
+
+func TestDirPath(t *testing.T) {
+	home, err := ioutil.TempDir("", "liqo-agent-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer setTestEnv(t, map[string]string{EnvLiqoPath: "", "HOME": home, "XDG_CONFIG_HOME": "",
+		"XDG_STATE_HOME": "relative/state", "XDG_CACHE_HOME": filepath.Join(home, "cache"), "XDG_DATA_HOME": "",
+		"XDG_RUNTIME_DIR": ""})()
+	//the unset and the relative base directories fall back to their default location
+	assert.Equal(t, filepath.Join(home, ".config", "liqo"), DirConfig.Path())
+	assert.Equal(t, filepath.Join(home, ".local", "state", "liqo"), DirState.Path())
+	assert.Equal(t, filepath.Join(home, "cache", "liqo"), DirCache.Path())
+	assert.Equal(t, filepath.Join(home, ".local", "share", "liqo"), DirData.Path())
+	assert.Equal(t, filepath.Join(home, ".config"), DirConfig.BasePath())
+	//the runtime directory has no default location
+	assert.Empty(t, DirRuntime.Path())
+	assert.Empty(t, DirRuntime.File("agent.sock"))
+	_, err = DirRuntime.Ensure()
+	assert.Error(t, err)
+	path, err := DirConfig.Ensure()
+	assert.NoError(t, err)
+	info, err := os.Stat(path)
+	if assert.NoError(t, err) {
+		assert.True(t, info.IsDir())
+		assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+	}
+	assert.Equal(t, filepath.Join(path, "config.yaml"), DirConfig.File("config.yaml"))
+	//EnvLiqoPath selects a single directory for all the files, but not the base directories
+	defer setTestEnv(t, map[string]string{EnvLiqoPath: filepath.Join(home, "portable")})()
+	for _, d := range []Dir{DirConfig, DirState, DirCache, DirData, DirRuntime} {
+		assert.Equal(t, filepath.Join(home, "portable"), d.Path(), string(d))
+	}
+	assert.Equal(t, filepath.Join(home, ".config"), DirConfig.BasePath())
+}
@@ -0,0 +1,74 @@
+package app_indicator
+
+import "time"
+
+/*This file contains the protection of the MenuNodes against repeated clicks. A click is ignored if it is received:
+-	within the click guard of the Indicator since the previous click on the same node has been handled, e.g. a
+	double click or the clicks queued while a dialog was open;
+-	within the cooldown of the node since the previous handled click, e.g. to refresh something at most every 5s.
+This prevents, for instance, accidental duplicate peering requests.*/
+
+//DefaultClickGuard is the default time window after the handling of a click during which the further clicks on the
+//same MenuNode are ignored.
+const DefaultClickGuard = 500 * time.Millisecond
+
+//SetClickGuard sets the time window after the handling of a click during which the further clicks on the same
+//MenuNode are ignored. A zero window disables the protection against double clicks.
+func (i *Indicator) SetClickGuard(window time.Duration) {
+	i.clickGuardMutex.Lock()
+	defer i.clickGuardMutex.Unlock()
+	i.clickGuard = window
+}
+
+//ClickGuard returns the time window after the handling of a click during which the further clicks on the same
+//MenuNode are ignored.
+func (i *Indicator) ClickGuard() time.Duration {
+	i.clickGuardMutex.RLock()
+	defer i.clickGuardMutex.RUnlock()
+	return i.clickGuard
+}
+
+//SetCooldown sets the minimum interval between two handled clicks on the MenuNode. A zero cooldown removes the limit.
+func (n *MenuNode) SetCooldown(cooldown time.Duration) {
+	n.Lock()
+	defer n.Unlock()
+	n.cooldown = cooldown
+}
+
+//Cooldown returns the minimum interval between two handled clicks on the MenuNode.
+func (n *MenuNode) Cooldown() time.Duration {
+	n.RLock()
+	defer n.RUnlock()
+	return n.cooldown
+}
+
+//acceptClick returns whether a click received at instant t has to be handled, recording it if so.
+func (n *MenuNode) acceptClick(t time.Time) bool {
+	guard := n.indicator.ClickGuard()
+	n.Lock()
+	defer n.Unlock()
+	if !n.lastClick.IsZero() && t.Sub(n.lastClick) < n.cooldown {
+		return false
+	}
+	if !n.lastHandled.IsZero() && t.Sub(n.lastHandled) < guard {
+		return false
+	}
+	n.lastClick = t
+	return true
+}
+
+//clickHandled records the instant the handling of a click on the MenuNode has been completed.
+func (n *MenuNode) clickHandled(t time.Time) {
+	n.Lock()
+	defer n.Unlock()
+	n.lastHandled = t
+}
+
+//resetClicks removes the cooldown of the MenuNode and forgets its previous clicks.
+func (n *MenuNode) resetClicks() {
+	n.Lock()
+	defer n.Unlock()
+	n.cooldown = 0
+	n.lastClick = time.Time{}
+	n.lastHandled = time.Time{}
+}
@@ -0,0 +1,32 @@
+package app_indicator
+
+import (
+	"context"
+	"time"
+)
+
+//ClickEvent contains the data of a 'clicked' event of a MenuNode.
+type ClickEvent struct {
+	//Indicator is the Indicator owning the clicked MenuNode.
+	Indicator *Indicator
+	//Node is the clicked MenuNode.
+	Node *MenuNode
+	//Time is the moment the event has been received.
+	Time time.Time
+}
+
+/*ClickHandler is the interface implemented by the handlers of the 'clicked' events of a MenuNode.
+
+HandleClick is executed once per event. The ctx Context is cancelled when the handler is disconnected from the
+MenuNode or the Indicator quits: long running handlers should use it to stop their operations.*/
+type ClickHandler interface {
+	HandleClick(ctx context.Context, e *ClickEvent)
+}
+
+//ClickHandlerFunc is an adapter allowing the use of ordinary functions as ClickHandler.
+type ClickHandlerFunc func(ctx context.Context, e *ClickEvent)
+
+//HandleClick calls f(ctx, e).
+func (f ClickHandlerFunc) HandleClick(ctx context.Context, e *ClickEvent) {
+	f(ctx, e)
+}
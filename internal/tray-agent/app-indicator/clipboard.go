@@ -0,0 +1,47 @@
+package app_indicator
+
+import (
+	"context"
+	"fmt"
+)
+
+/*This file contains the copy of texts to the clipboard of the desktop session, e.g. the identifiers, commands and
+credentials the users hand to the administrators of other clusters. The clipboard of the platform is written by
+means of its utilities (xclip, xsel or wl-copy on Linux, pbcopy on macOS), unless the GuiBackend handles it on its
+own (see clipboardWriter). MenuNode.ConnectCopy makes a menu entry copy a text at each click.*/
+
+//ClipboardContent returns the text to copy to the clipboard, retrieved when the copy is requested.
+type ClipboardContent func(ctx context.Context) (string, error)
+
+//CopyToClipboard copies text to the clipboard of the desktop session. It returns an error if the clipboard is not
+//available on this platform.
+func (i *Indicator) CopyToClipboard(text string) error {
+	return i.gProvider.CopyToClipboard(text)
+}
+
+//CopyHandler returns a ClickHandler copying to the clipboard the text returned by content. The user is notified of
+//the copy of the text, named by what (e.g. "local cluster ID"), or of the failure.
+func CopyHandler(what string, content ClipboardContent) ClickHandler {
+	return ClickHandlerFunc(func(ctx context.Context, e *ClickEvent) {
+		i := e.Indicator
+		text, err := content(ctx)
+		if err != nil {
+			logger.Debug("cannot retrieve the text to copy", "content", what, "err", err)
+			i.ShowClientError(fmt.Sprintf("Liqo Agent: cannot copy the %s", what), err)
+			return
+		}
+		if err = i.CopyToClipboard(text); err != nil {
+			logger.Warning("cannot copy to the clipboard", "content", what, "err", err)
+			i.ShowWarning("LIQO AGENT", "Liqo Agent could not copy to the clipboard:\n"+err.Error())
+			return
+		}
+		i.Notify("Liqo Agent", fmt.Sprintf("The %s was copied to your clipboard", what), NotifyIconDefault,
+			IconLiqoNil)
+	})
+}
+
+//ConnectCopy connects the MenuNode to a CopyHandler, copying to the clipboard the text returned by content at each
+//click.
+func (n *MenuNode) ConnectCopy(what string, content ClipboardContent) {
+	n.Connect(false, CopyHandler(what, content))
+}
@@ -0,0 +1,33 @@
+package app_indicator
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCopyHandler(t *testing.T) {
+	i := newTestIndicator(t, NewMockedGuiProvider())
+	defer i.Quit()
+	backend := i.gProvider.(*guiProvider).backend.(*mockBackend)
+	e := &ClickEvent{Indicator: i}
+	CopyHandler("cluster ID", func(ctx context.Context) (string, error) {
+		return "home-id", nil
+	}).HandleClick(context.TODO(), e)
+	assert.Equal(t, "home-id", backend.clipboard)
+	//the clipboard is not changed if the text cannot be retrieved
+	CopyHandler("cluster ID", func(ctx context.Context) (string, error) {
+		return "", errors.New("not connected")
+	}).HandleClick(context.TODO(), e)
+	assert.Equal(t, "home-id", backend.clipboard)
+	//the clipboard of the platform is not written by the mocked guiProvider
+	provider := NewMockedGuiProvider().(*guiProvider)
+	provider.backend = plainBackend{provider.backend}
+	assert.Error(t, provider.CopyToClipboard("text"))
+}
+
+//plainBackend is a GuiBackend implementing none of the optional interfaces of the wrapped one.
+type plainBackend struct {
+	GuiBackend
+}
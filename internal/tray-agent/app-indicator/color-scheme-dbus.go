@@ -0,0 +1,87 @@
+// +build linux
+
+package app_indicator
+
+import (
+	"context"
+	"github.com/godbus/dbus/v5"
+	"time"
+)
+
+//The D-Bus color scheme watcher reads the color-scheme setting of the XDG desktop portal and follows its
+//SettingChanged signal on the session bus.
+func init() {
+	colorSchemeWatcherFactory = newPortalColorSchemeWatcher
+}
+
+const (
+	portalName          = "org.freedesktop.portal.Desktop"
+	portalPath          = "/org/freedesktop/portal/desktop"
+	portalSettingsIface = "org.freedesktop.portal.Settings"
+	//appearanceNamespace and colorSchemeKey identify the color scheme preferred by the desktop among the portal
+	//settings: 0 means no preference, 1 dark and 2 light.
+	appearanceNamespace = "org.freedesktop.appearance"
+	colorSchemeKey      = "color-scheme"
+	//portalReadTimeout bounds the wait for the portal, which may be activated on demand, at the Agent startup.
+	portalReadTimeout = 2 * time.Second
+)
+
+func newPortalColorSchemeWatcher(onChange func(scheme ColorScheme)) (ColorScheme, func(), error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return "", nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), portalReadTimeout)
+	defer cancel()
+	var value dbus.Variant
+	if err := conn.Object(portalName, portalPath).CallWithContext(ctx, portalSettingsIface+".Read", 0,
+		appearanceNamespace, colorSchemeKey).Store(&value); err != nil {
+		return "", nil, err
+	}
+	options := []dbus.MatchOption{dbus.WithMatchObjectPath(portalPath), dbus.WithMatchInterface(portalSettingsIface),
+		dbus.WithMatchMember("SettingChanged")}
+	if err := conn.AddMatchSignal(options...); err != nil {
+		return "", nil, err
+	}
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case s := <-signals:
+				if s.Name != portalSettingsIface+".SettingChanged" || len(s.Body) < 3 ||
+					s.Body[0] != appearanceNamespace || s.Body[1] != colorSchemeKey {
+					continue
+				}
+				if changed, ok := s.Body[2].(dbus.Variant); ok {
+					onChange(portalColorScheme(changed))
+				}
+			}
+		}
+	}()
+	return portalColorScheme(value), func() {
+		_ = conn.RemoveMatchSignal(options...)
+		conn.RemoveSignal(signals)
+		close(done)
+	}, nil
+}
+
+//portalColorScheme returns the ColorScheme of a color-scheme setting, empty if the desktop has no preference.
+func portalColorScheme(v dbus.Variant) ColorScheme {
+	value := v.Value()
+	//the Read method wraps the setting in a further variant
+	if inner, ok := value.(dbus.Variant); ok {
+		value = inner.Value()
+	}
+	switch scheme, _ := value.(uint32); scheme {
+	case 1:
+		return ColorSchemeDark
+	case 2:
+		return ColorSchemeLight
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,173 @@
+package app_indicator
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*This file contains the selection of the tray icon variant matching the desktop theme. The Liqo icons have a dark
+outline, barely visible on the dark panels: each Icon has a variant with a light outline, used when the desktop
+prefers a dark color scheme. The preference is read from the org.freedesktop.appearance color-scheme setting of the
+XDG desktop portal, followed while the Agent runs, falling back to the GTK settings.*/
+
+//ColorScheme identifies the variant of the tray icons matching the color scheme of the desktop.
+type ColorScheme string
+
+//ColorScheme identifiers.
+const (
+	//ColorSchemeAuto follows the color scheme preferred by the desktop.
+	ColorSchemeAuto ColorScheme = "auto"
+	//ColorSchemeLight draws the icons for light panels.
+	ColorSchemeLight ColorScheme = "light"
+	//ColorSchemeDark draws the icons for dark panels.
+	ColorSchemeDark ColorScheme = "dark"
+)
+
+//ParseColorScheme returns the ColorScheme identified by name. It falls back to ColorSchemeAuto for an unknown name.
+func ParseColorScheme(name string) ColorScheme {
+	switch scheme := ColorScheme(strings.ToLower(name)); scheme {
+	case ColorSchemeLight, ColorSchemeDark:
+		return scheme
+	default:
+		return ColorSchemeAuto
+	}
+}
+
+//colorSchemeWatcherFactory starts following the color scheme preferred by the desktop on the current platform,
+//calling onChange at each change, and returns the current one together with the function stopping it. An empty
+//ColorScheme means that the desktop has no preference. It returns an error if the preference cannot be observed.
+var colorSchemeWatcherFactory func(onChange func(scheme ColorScheme)) (current ColorScheme, stop func(), err error)
+
+//ColorScheme returns the ColorScheme the tray icon is currently drawn for, either ColorSchemeLight or
+//ColorSchemeDark.
+func (i *Indicator) ColorScheme() ColorScheme {
+	gr := i.graphicResource[resourceIcon]
+	gr.RLock()
+	defer gr.RUnlock()
+	if i.colorScheme == "" {
+		return ColorSchemeLight
+	}
+	return i.colorScheme
+}
+
+//SetColorScheme sets the ColorScheme used to draw the tray icon, redrawing the current one. With ColorSchemeAuto,
+//the icon follows the color scheme preferred by the desktop.
+func (i *Indicator) SetColorScheme(scheme ColorScheme) {
+	gr := i.graphicResource[resourceIcon]
+	gr.Lock()
+	stop := i.colorSchemeStop
+	i.colorSchemeSetting, i.colorSchemeStop = scheme, nil
+	gr.Unlock()
+	if stop != nil {
+		stop()
+	}
+	if scheme == ColorSchemeAuto {
+		scheme = i.followDesktopColorScheme()
+	}
+	i.drawColorScheme(scheme)
+}
+
+//followDesktopColorScheme starts following the color scheme preferred by the desktop, returning the current one.
+func (i *Indicator) followDesktopColorScheme() ColorScheme {
+	if i.gProvider.Mocked() {
+		return ColorSchemeLight
+	}
+	if colorSchemeWatcherFactory != nil {
+		current, stop, err := colorSchemeWatcherFactory(i.onDesktopColorScheme)
+		if err == nil {
+			gr := i.graphicResource[resourceIcon]
+			gr.Lock()
+			i.colorSchemeStop = stop
+			gr.Unlock()
+			if current != "" {
+				return current
+			}
+		}
+	}
+	return gtkColorScheme()
+}
+
+//onDesktopColorScheme redraws the tray icon when the desktop changes its preferred color scheme.
+func (i *Indicator) onDesktopColorScheme(scheme ColorScheme) {
+	gr := i.graphicResource[resourceIcon]
+	gr.RLock()
+	auto := i.colorSchemeSetting == ColorSchemeAuto
+	gr.RUnlock()
+	if !auto {
+		return
+	}
+	if scheme == "" {
+		scheme = gtkColorScheme()
+	}
+	i.drawColorScheme(scheme)
+}
+
+//drawColorScheme redraws the current tray icon for a ColorScheme, either ColorSchemeLight or ColorSchemeDark.
+func (i *Indicator) drawColorScheme(scheme ColorScheme) {
+	gr := i.graphicResource[resourceIcon]
+	gr.Lock()
+	changed := i.colorScheme != scheme
+	i.colorScheme = scheme
+	gr.Unlock()
+	if changed {
+		i.redrawIcon()
+	}
+}
+
+//gtkColorScheme returns the color scheme of the GTK theme, read from the GTK_THEME environment variable (e.g.
+//"Adwaita:dark") or from the settings.ini files of GTK 4 and 3. It defaults to ColorSchemeLight.
+func gtkColorScheme() ColorScheme {
+	if theme := os.Getenv("GTK_THEME"); theme != "" {
+		return themeColorScheme(theme)
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ColorSchemeLight
+	}
+	for _, version := range []string{"gtk-4.0", "gtk-3.0"} {
+		if scheme := gtkSettingsColorScheme(filepath.Join(configDir, version, "settings.ini")); scheme != "" {
+			return scheme
+		}
+	}
+	return ColorSchemeLight
+}
+
+//gtkSettingsColorScheme returns the color scheme set by a GTK settings.ini file, empty if the file sets none, e.g.
+//	[Settings]
+//	gtk-theme-name=Adwaita-dark
+//	gtk-application-prefer-dark-theme=1
+func gtkSettingsColorScheme(path string) ColorScheme {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	var scheme ColorScheme
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "=", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(fields[1])
+		switch strings.TrimSpace(fields[0]) {
+		case "gtk-application-prefer-dark-theme":
+			if value == "1" || strings.EqualFold(value, "true") {
+				return ColorSchemeDark
+			}
+		case "gtk-theme-name":
+			scheme = themeColorScheme(value)
+		}
+	}
+	return scheme
+}
+
+//themeColorScheme returns the color scheme of a GTK theme, dark if its name (or variant) contains "dark".
+func themeColorScheme(theme string) ColorScheme {
+	if strings.Contains(strings.ToLower(theme), "dark") {
+		return ColorSchemeDark
+	}
+	return ColorSchemeLight
+}
@@ -0,0 +1,63 @@
+package app_indicator
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestColorScheme(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	i := GetIndicator()
+	assert.Equal(t, ColorSchemeAuto, ParseColorScheme("unknown"))
+	assert.Equal(t, ColorSchemeDark, ParseColorScheme("Dark"))
+	//the mocked Indicator does not follow the desktop
+	assert.Equal(t, ColorSchemeLight, i.ColorScheme())
+	i.SetIcon(IconLiqoGreen)
+	i.SetColorScheme(ColorSchemeDark)
+	assert.Equal(t, ColorSchemeDark, i.ColorScheme())
+	assert.Equal(t, IconLiqoGreen, i.Icon(), "icon changed when switching color scheme")
+	dark, _ := iconData(IconThemeDefault, ColorSchemeDark, IconLiqoGreen)
+	light, _ := iconData(IconThemeDefault, ColorSchemeLight, IconLiqoGreen)
+	assert.NotEqual(t, light, dark, "same image for both color schemes")
+	//the changes of the desktop preference are ignored unless following it
+	i.onDesktopColorScheme(ColorSchemeLight)
+	assert.Equal(t, ColorSchemeDark, i.ColorScheme())
+	i.SetColorScheme(ColorSchemeAuto)
+	i.onDesktopColorScheme(ColorSchemeDark)
+	assert.Equal(t, ColorSchemeDark, i.ColorScheme())
+	i.SetColorScheme(ColorSchemeLight)
+	assert.Equal(t, ColorSchemeLight, i.ColorScheme())
+}
+
+func TestGtkColorScheme(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liqo-agent-gtk")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	defer os.Setenv("GTK_THEME", os.Getenv("GTK_THEME"))
+	_ = os.Setenv("XDG_CONFIG_HOME", dir)
+	_ = os.Unsetenv("GTK_THEME")
+	writeSettings := func(version, content string) {
+		_ = os.MkdirAll(filepath.Join(dir, version), 0755)
+		_ = ioutil.WriteFile(filepath.Join(dir, version, "settings.ini"), []byte(content), 0644)
+	}
+	assert.Equal(t, ColorSchemeLight, gtkColorScheme(), "no settings")
+	writeSettings("gtk-3.0", "[Settings]\ngtk-theme-name = Adwaita-dark\n")
+	assert.Equal(t, ColorSchemeDark, gtkColorScheme())
+	writeSettings("gtk-3.0", "[Settings]\ngtk-theme-name=Adwaita\ngtk-application-prefer-dark-theme=true\n")
+	assert.Equal(t, ColorSchemeDark, gtkColorScheme())
+	//the GTK 4 settings take precedence
+	writeSettings("gtk-4.0", "[Settings]\ngtk-theme-name=Breeze\n")
+	assert.Equal(t, ColorSchemeLight, gtkColorScheme())
+	_ = os.Setenv("GTK_THEME", "Adwaita:dark")
+	assert.Equal(t, ColorSchemeDark, gtkColorScheme())
+}
@@ -1,9 +1,8 @@
 package app_indicator
 
 import (
-	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
-	"os"
-	"path/filepath"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/xdg"
+	"time"
 )
 
 // data structure containing Indicator configuration
@@ -16,23 +15,24 @@ type config struct {
 	notifyTranslateMap map[NotifyLevel]string
 	// map that performs the reverse translation of the notifyTranslateMap map
 	notifyTranslateReverseMap map[string]NotifyLevel
+	// recurring quiet hours during which only critical notifications are displayed as banners
+	quietHours *QuietSchedule
+	// end of a one-off quiet period, e.g. set at startup, during which only critical notifications are displayed
+	quietUntil time.Time
+	// Do Not Disturb mode, silencing the notifications as during the quiet hours until disabled
+	doNotDisturb bool
+	// minimum Severity of the notifications displayed as banners during the quiet hours, the quiet period and the
+	// Do Not Disturb mode
+	quietThreshold Severity
 }
 
 // newConfig assigns a startup configuration to the Indicator
 func newConfig() *config {
-	/*According to Liqo Agent installation process, first check if
-	user has defined XDG_DATA_HOME env variable. Otherwise, use the
-	fallback directory according to XDG specifications
-	(www.freedesktop.com)*/
-	XDGBaseDir, present := os.LookupEnv("XDG_DATA_HOME")
-	if !present {
-		XDGBaseDir = filepath.Join(os.Getenv("HOME"), ".local/share")
-	}
-	liqoPath := filepath.Join(XDGBaseDir, "liqo")
-	if err := os.Setenv(client.EnvLiqoPath, liqoPath); err != nil {
-		os.Exit(1)
-	}
-	conf := &config{notifyLevel: NotifyLevelMax, notifyIconPath: filepath.Join(liqoPath, "icons")}
+	/*According to Liqo Agent installation process, the icons of the
+	notifications are installed in the data directory of the Agent
+	($XDG_DATA_HOME/liqo, see xdg.DirData)*/
+	conf := &config{notifyLevel: NotifyLevelMax, notifyIconPath: xdg.DirData.File("icons"),
+		quietThreshold: SeverityError}
 	conf.notifyTranslateMap = make(map[NotifyLevel]string)
 	conf.notifyTranslateReverseMap = make(map[string]NotifyLevel)
 	conf.notifyTranslateMap[NotifyLevelOff] = NotifyLevelOffDescription
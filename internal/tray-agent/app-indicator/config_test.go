@@ -30,11 +30,24 @@ func TestNotifyTranslations(t *testing.T) {
 
 // test creation of a new config obj
 func TestNewConfig(t *testing.T) {
-	if err := os.Setenv("XDG_DATA_HOME", "test"); err != nil {
+	dataHome, present := os.LookupEnv("XDG_DATA_HOME")
+	liqoPath, liqoPathPresent := os.LookupEnv(client.EnvLiqoPath)
+	defer func() {
+		if present {
+			_ = os.Setenv("XDG_DATA_HOME", dataHome)
+		} else {
+			_ = os.Unsetenv("XDG_DATA_HOME")
+		}
+		if liqoPathPresent {
+			_ = os.Setenv(client.EnvLiqoPath, liqoPath)
+		}
+	}()
+	if err := os.Setenv("XDG_DATA_HOME", "/test"); err != nil {
 		t.Skip("it was not possible to set OS env variable")
 	}
+	_ = os.Unsetenv(client.EnvLiqoPath)
 	conf := newConfig()
-	assert.Equal(t, "test/liqo", os.Getenv(client.EnvLiqoPath))
+	assert.Equal(t, "/test/liqo/icons", conf.notifyIconPath)
 	assert.Equal(t, 3, len(conf.notifyTranslateMap))
 	assert.Equal(t, 3, len(conf.notifyTranslateReverseMap))
 	// test config startup content
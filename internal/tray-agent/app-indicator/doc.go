@@ -1,9 +1,14 @@
 /*
 package app_indicator provides API to install a system tray Indicator and bind it to a menu.
-It relies on the github.com/getlantern/systray to display the indicator (icon+label) and perform
-a basic management of each menu entry (MenuNode).
+It relies on a GuiBackend (by default github.com/getlantern/systray) to display the indicator (icon+label)
+and perform a basic management of each menu entry (MenuNode). The backends are selected at build time with
+build tags, see GuiBackend.
 
-The GetIndicator() function returns the Indicator singleton.
+The GetIndicator() function returns the Indicator singleton, and Reset() replaces it with a new one. NewIndicator()
+creates instead an independent Indicator whose dependencies (GuiProvider, AgentController, Status, local
+configuration and Clock) are provided with IndicatorOption values (e.g. WithGuiProvider, WithConfigPath), e.g. to run
+isolated Indicators in the tests or to wire a different GuiProvider. It returns an error, instead of an Indicator,
+if a dependency cannot be provided.
 
 The Indicator can:
 
@@ -15,12 +20,19 @@ The Indicator can:
 
 * communicate with the user through a notification system that exploits changes of the Indicator icon and desktop banners.
 
+* present a Notification (severity, category, target resource, actions and a stable ID for updates) with
+ShowNotification, as a desktop banner or a dialog box.
+
+The goroutines of the Indicator (Listeners, Timers, event handlers) share its Context, cancelled by Quit: the GUI
+runtime exits once they finished, or after QuitTimeout. Their changes to the menu are serialized by the Indicator
+(see RunOnGui), so that they never race against each other.
+
 USAGE EXAMPLE:
 
 		//define execution logic
 		func onReady(){
 			indicator := app_indicator.GetIndicator()
-    		indicator.AddQuick("HOME", "Q_HOME", myFunction)
+    		indicator.AddQuick("HOME", "Q_HOME", app_indicator.ClickHandlerFunc(myFunction))
 			...
 		}
 
@@ -1,13 +1,22 @@
 package app_indicator
 
 import (
+	"context"
+	"fmt"
 	"github.com/oleiade/lane"
+	"sort"
 	"sync"
 )
 
 /*nodeList manages a dynamic list of MenuNode elements OF list NodeType that present themselves as nested items
 of a parent MenuNode. It overcomes the GuiProviderInterface main limitation, i.e.
 the lack of 'pop' operation from the graphic tray menu stack.
+
+A long list can be displayed by pages (see SetListPageSize): only the first entries in use, in display order (see
+MoveBefore), are displayed, followed by
+a "Show more… (N)" entry displaying the next page when clicked. The entries not displayed are given an item only once
+displayed, together with their nested entries. The list can also be filtered (see SetListFilter): the entries not
+matching the filter are hidden, and the pages contain the matching ones only.
 */
 type nodeList struct {
 	//parent is the MenuNode owning the nodeList.
@@ -20,6 +29,22 @@ type nodeList struct {
 	totFree int
 	//withCheckbox defines if LIST MenuNode elements are provided with a graphic checkbox.
 	withCheckbox bool
+	//order contains the LIST MenuNodes in use, in the order they have been used.
+	order []*MenuNode
+	//pageSize is the number of entries of each page, 0 if the list is not paginated.
+	pageSize int
+	//shown is the number of entries currently displayed by the paginated list.
+	shown int
+	//filter selects the entries displayed, if set.
+	filter func(node *MenuNode) bool
+	//more is the "Show more…" entry of the paginated list, if created.
+	more *MenuNode
+	//onShowMore is executed, if set, when the "Show more…" entry is clicked, with the number of pages displayed.
+	onShowMore func(pages int)
+	//items counts the items created for the entries, while moreItems is the count when more has been created:
+	//more is created again when outdated, so that it is displayed after all the entries.
+	items     int
+	moreItems int
 	//Mutex used to protect operations on the nodeList.
 	sync.RWMutex
 	//WaitGroup used to parallelize LIST nodes cleaning.
@@ -43,19 +68,245 @@ func (nl *nodeList) useNode(title string, tag string) *MenuNode {
 	nl.Lock()
 	defer nl.Unlock()
 	var node *MenuNode
-	if nl.totFree > 0 {
+	unlisted := nl.pageSize > 0 && len(nl.order) >= nl.shown
+	reused := nl.totFree > 0
+	if reused {
 		node = nl.freeNodes.Dequeue().(*MenuNode)
 		nl.totFree--
 	} else {
-		node = newMenuNode(NodeTypeList, nl.withCheckbox, nl.parent)
+		//the entries beyond the displayed page are given an item once displayed
+		node = newListNode(nl.parent.indicator, nl.withCheckbox, nl.parent, unlisted)
+		if node.item != nil {
+			nl.items++
+		}
 	}
+	node.setUnlisted(unlisted)
 	node.SetTitle(title)
 	node.SetTag(tag)
 	node.SetIsVisible(true)
 	nl.usedNodes[tag] = node
+	if reused {
+		nl.placeLast(node)
+	}
+	nl.order = append(nl.order, node)
+	nl.paginate()
 	return node
 }
 
+//placeLast moves a LIST MenuNode taken from the freeNodes pool, which keeps the position of its previous use, after
+//the entries in use, as the new ones are. It must be called holding the lock, before node is added to the order.
+func (nl *nodeList) placeLast(node *MenuNode) {
+	entries := nl.displayOrder()
+	if len(entries) == 0 {
+		return
+	}
+	last := entries[len(entries)-1]
+	i := nl.parent.indicator
+	i.nodesMutex.Lock()
+	before := indexOfNode(nl.parent.children, node) < indexOfNode(nl.parent.children, last)
+	i.nodesMutex.Unlock()
+	if !before {
+		return
+	}
+	//without CapabilityReorder, the entries with a submenu cannot be moved: the node keeps its position
+	if err := node.MoveAfter(last); err != nil {
+		logger.Debug("cannot move the reused list entry", "tag", node.Tag(), "err", err)
+	}
+}
+
+//setPageSize displays the list by pages of size entries, starting from the first one. A size <= 0 displays all the
+//entries.
+func (nl *nodeList) setPageSize(size int) {
+	nl.Lock()
+	defer nl.Unlock()
+	if size < 0 {
+		size = 0
+	}
+	nl.pageSize, nl.shown = size, size
+	nl.paginate()
+}
+
+//setFilter displays only the entries matching filter (all of them if nil), starting from the first page.
+func (nl *nodeList) setFilter(filter func(node *MenuNode) bool) {
+	nl.Lock()
+	defer nl.Unlock()
+	nl.filter, nl.shown = filter, nl.pageSize
+	nl.paginate()
+}
+
+//refilter applies again the filter of the list, e.g. after the entries changed.
+func (nl *nodeList) refilter() {
+	nl.Lock()
+	defer nl.Unlock()
+	nl.paginate()
+}
+
+//showMore displays the next page of the list.
+func (nl *nodeList) showMore() {
+	nl.Lock()
+	nl.shown += nl.pageSize
+	nl.paginate()
+	handler := nl.onShowMore
+	pages := 1
+	if nl.pageSize > 0 {
+		pages = nl.shown / nl.pageSize
+	}
+	nl.Unlock()
+	if handler != nil {
+		handler(pages)
+	}
+}
+
+//setPages displays the first pages of the paginated list. It is a no-op if the list is not paginated.
+func (nl *nodeList) setPages(pages int) {
+	nl.Lock()
+	defer nl.Unlock()
+	if nl.pageSize == 0 {
+		return
+	}
+	if pages < 1 {
+		pages = 1
+	}
+	nl.shown = pages * nl.pageSize
+	nl.paginate()
+}
+
+//paginate displays the first shown entries of the list matching the filter, in display order, followed by the
+//"Show more…" entry if some are hidden. It must be called holding the lock.
+func (nl *nodeList) paginate() {
+	listed := 0
+	for _, node := range nl.displayOrder() {
+		if nl.filter != nil && !nl.filter(node) {
+			node.setUnlisted(true)
+			continue
+		}
+		if node.setUnlisted(nl.pageSize > 0 && listed >= nl.shown) {
+			nl.items++
+		}
+		listed++
+	}
+	hidden := 0
+	if nl.pageSize > 0 && listed > nl.shown {
+		hidden = listed - nl.shown
+	}
+	if hidden == 0 {
+		if nl.more != nil {
+			nl.more.SetIsVisible(false)
+		}
+		return
+	}
+	if nl.more == nil || nl.moreItems != nl.items {
+		nl.newMore()
+	}
+	nl.more.SetTitle(fmt.Sprintf("Show more… (%d)", hidden))
+	nl.more.SetIsVisible(true)
+}
+
+//displayOrder returns the LIST MenuNodes in use, in the order they are displayed. It must be called holding the lock.
+func (nl *nodeList) displayOrder() []*MenuNode {
+	i := nl.parent.indicator
+	i.nodesMutex.Lock()
+	position := make(map[*MenuNode]int, len(nl.parent.children))
+	for k, c := range nl.parent.children {
+		position[c] = k
+	}
+	i.nodesMutex.Unlock()
+	entries := append([]*MenuNode(nil), nl.order...)
+	sort.SliceStable(entries, func(a, b int) bool {
+		return position[entries[a]] < position[entries[b]]
+	})
+	return entries
+}
+
+//newMore creates the "Show more…" entry after all the entries of the list. The outdated one, if any, joins the
+//freeNodes pool. It must be called holding the lock.
+func (nl *nodeList) newMore() {
+	if old := nl.more; old != nil {
+		old.reset()
+		nl.freeNodes.Enqueue(old)
+		nl.totFree++
+	}
+	nl.more = newListNode(nl.parent.indicator, false, nl.parent, false)
+	nl.moreItems = nl.items
+	nl.more.Connect(false, ClickHandlerFunc(func(ctx context.Context, e *ClickEvent) {
+		nl.showMore()
+	}))
+}
+
+//setUnlisted sets whether the LIST MenuNode is hidden by the pagination or by the filter of its parent, giving it
+//an item once displayed. It returns whether the item has been created.
+func (n *MenuNode) setUnlisted(unlisted bool) bool {
+	n.Lock()
+	if n.unlisted == unlisted {
+		n.Unlock()
+		return false
+	}
+	n.unlisted = unlisted
+	if unlisted || n.item != nil {
+		n.showItem()
+		n.Unlock()
+		return false
+	}
+	n.Unlock()
+	return n.attach()
+}
+
+//attach creates the item of a MenuNode created without one (see newListNode), unless hidden by the pagination,
+//drawing its state. Then, the items of its nested MenuNodes are created. It returns whether the item has been
+//created.
+func (n *MenuNode) attach() bool {
+	n.Lock()
+	parentItem := n.parent.item
+	if n.item != nil || n.unlisted || parentItem == nil {
+		n.Unlock()
+		return false
+	}
+	i := n.indicator
+	var item Item
+	i.RunOnGui(func() {
+		item = i.gProvider.AddSubMenuItem(parentItem, n.hasCheckbox)
+	})
+	n.draw(item, n.hasCheckbox, n.isChecked)
+	n.Unlock()
+	if i.gProvider.Supports(CapabilityReorder) {
+		n.placeItem(item)
+	}
+	i.nodesMutex.Lock()
+	children := append([]*MenuNode(nil), n.children...)
+	i.nodesMutex.Unlock()
+	for _, c := range children {
+		c.attach()
+	}
+	return true
+}
+
+//placeItem moves the item of the MenuNode, just created at the bottom of the menu, before the item of the following
+//sibling displayed, if any. It requires a GuiBackend with CapabilityReorder.
+func (n *MenuNode) placeItem(item Item) {
+	i := n.indicator
+	i.moveMutex.Lock()
+	defer i.moveMutex.Unlock()
+	i.nodesMutex.Lock()
+	var next Item
+	siblings := n.parent.children
+	for k := indexOfNode(siblings, n) + 1; k < len(siblings) && next == nil; k++ {
+		next = siblings[k].item
+	}
+	i.nodesMutex.Unlock()
+	if next != nil {
+		i.RunOnGui(func() {
+			i.gProvider.MoveItem(item, next, false)
+		})
+	}
+}
+
+//usedNodesInOrder returns the LIST MenuNodes in use, in display order.
+func (nl *nodeList) usedNodesInOrder() []*MenuNode {
+	nl.Lock()
+	defer nl.Unlock()
+	return nl.displayOrder()
+}
+
 //usedNode retrieves, if present, a tagged LIST child in use.
 func (nl *nodeList) usedNode(tag string) (node *MenuNode, present bool) {
 	nl.RLock()
@@ -68,30 +319,56 @@ func (nl *nodeList) usedNode(tag string) (node *MenuNode, present bool) {
 func (nl *nodeList) freeNode(tag string) {
 	node, ok := nl.usedNodes[tag]
 	if ok {
-		node.SetTitle("")
-		node.SetTag("")
-		node.SetIsVisible(false)
-		node.SetIsEnabled(true)
-		node.SetIsChecked(false)
-		node.Disconnect()
+		node.reset()
 		delete(nl.usedNodes, tag)
+		for k, used := range nl.order {
+			if used == node {
+				nl.order = append(nl.order[:k], nl.order[k+1:]...)
+				break
+			}
+		}
 		nl.freeNodes.Enqueue(node)
 		nl.totFree++
+		nl.paginate()
 	}
 }
 
-//freeAllNodes iteratively applies freeNode() to all used LIST MenuNode.
+//reset clears the LIST MenuNode, before joining the freeNodes pool.
+func (n *MenuNode) reset() {
+	n.SetTitle("")
+	n.SetTag("")
+	n.SetIsVisible(false)
+	n.SetIsEnabled(true)
+	n.SetWriteAction(false)
+	n.resetClicks()
+	n.SetIsChecked(false)
+	n.SetIsRadio(false)
+	n.SetIcon(nil)
+	n.Disconnect()
+	n.RLock()
+	nl := n.nodeList
+	n.RUnlock()
+	if nl != nil {
+		nl.setFilter(nil)
+		nl.setPageSize(0)
+	}
+}
+
+//freeAllNodes iteratively applies freeNode() to all used LIST MenuNode. The nested LIST MenuNodes are freed in
+//parallel.
 func (nl *nodeList) freeAllNodes() {
 	nl.Lock()
 	defer nl.Unlock()
-	defer nl.Wait()
-	for tag, node := range nl.usedNodes {
+	for _, node := range nl.usedNodes {
 		nl.Add(1)
-		go func(n *MenuNode, str string) {
+		go func(n *MenuNode) {
 			defer nl.Done()
 			n.FreeListChildren()
-			nl.freeNode(str)
-		}(node, tag)
+		}(node)
+	}
+	nl.Wait()
+	for tag := range nl.usedNodes {
+		nl.freeNode(tag)
 	}
 }
 
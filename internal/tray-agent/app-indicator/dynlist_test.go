@@ -0,0 +1,117 @@
+package app_indicator
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestListPagination(t *testing.T) {
+	i := newTestIndicator(t, NewMockedGuiProvider())
+	defer i.Quit()
+	backend := i.gProvider.(*guiProvider).backend.(*mockBackend)
+	quick := i.AddQuick("Quick", "Q_TEST", nil)
+	quick.SetListPageSize(10)
+	entries := make([]*MenuNode, 25)
+	for k := range entries {
+		entries[k] = quick.UseListChild(fmt.Sprintf("entry %d", k), fmt.Sprint(k))
+	}
+	//the nested entries of the hidden ones are created without an item as well
+	nested := entries[22].UseListChild("nested", "nested")
+	assert.Nil(t, nested.item)
+	//only the first page is given an item, followed by the "Show more…" entry
+	for k, entry := range entries {
+		assert.Equal(t, k >= 10, entry.unlisted, "entry %d", k)
+		assert.Equal(t, k < 10, entry.item != nil, "entry %d", k)
+	}
+	nl := quick.nodeList
+	assert.Equal(t, "Show more… (15)", nl.more.Title())
+	assert.True(t, nl.more.IsVisible())
+	assert.Len(t, backend.mockedOrder(quick.item.(*mockItem)), 11)
+	//the next page is displayed after the first one
+	nl.showMore()
+	assert.Equal(t, "Show more… (5)", nl.more.Title())
+	order := backend.mockedOrder(quick.item.(*mockItem))
+	//the outdated "Show more…" entry is hidden and cleared
+	assert.Empty(t, order[11])
+	assert.Equal(t, "entry 19", order[20])
+	assert.Equal(t, "Show more… (5)", order[len(order)-1])
+	nl.showMore()
+	assert.False(t, nl.more.IsVisible(), "Show more displayed with no hidden entries")
+	if assert.NotNil(t, nested.item, "nested entry not displayed") {
+		assert.Equal(t, "nested", nested.item.(*mockItem).title)
+	}
+	//the entries freed leave room for the hidden ones
+	quick.SetListPageSize(10)
+	for k := 0; k < 5; k++ {
+		quick.FreeListChild(fmt.Sprint(k))
+	}
+	for k, entry := range entries[5:] {
+		assert.Equal(t, k >= 10, entry.unlisted, "entry %d", k+5)
+	}
+	assert.Equal(t, "Show more… (10)", nl.more.Title())
+	//the entries taken from the freed ones are displayed after the others
+	reused := quick.UseListChild("entry 25", "25")
+	children := quick.ListChildren()
+	assert.Equal(t, reused, children[len(children)-1])
+	assert.True(t, reused.unlisted)
+	//the pages displayed can be restored, and their expansion is signaled
+	quick.SetListPages(3)
+	assert.False(t, reused.unlisted)
+	assert.False(t, nl.more.IsVisible())
+	quick.SetListPageSize(10)
+	pages := 0
+	quick.OnListShowMore(func(p int) {
+		pages = p
+	})
+	nl.showMore()
+	assert.Equal(t, 2, pages)
+	//the pagination can be disabled
+	quick.SetListPageSize(0)
+	for _, entry := range entries[5:] {
+		assert.False(t, entry.unlisted)
+		assert.True(t, entry.item.(*mockItem).Visible())
+	}
+	assert.False(t, nl.more.IsVisible())
+	quick.FreeListChildren()
+	assert.Zero(t, quick.ListChildrenLen())
+}
+
+func TestListFilter(t *testing.T) {
+	i := newTestIndicator(t, NewMockedGuiProvider())
+	defer i.Quit()
+	quick := i.AddQuick("Quick", "Q_TEST", nil)
+	quick.SetListPageSize(3)
+	entries := make([]*MenuNode, 10)
+	for k := range entries {
+		entries[k] = quick.UseListChild(fmt.Sprintf("entry %d", k), fmt.Sprint(k))
+	}
+	odd := func(child *MenuNode) bool {
+		var k int
+		_, _ = fmt.Sscan(child.Tag(), &k)
+		return k%2 == 1
+	}
+	quick.SetListFilter(odd)
+	//the pages contain the matching entries only
+	for k, entry := range entries {
+		assert.Equal(t, k%2 == 0 || k > 5, entry.unlisted, "entry %d", k)
+	}
+	nl := quick.nodeList
+	assert.Equal(t, "Show more… (2)", nl.more.Title())
+	//the new entries are filtered as well
+	added := quick.UseListChild("entry 11", "11")
+	assert.True(t, added.unlisted)
+	assert.Equal(t, "Show more… (3)", nl.more.Title())
+	nl.showMore()
+	assert.False(t, added.unlisted)
+	assert.True(t, entries[8].unlisted)
+	//the filter is applied again once the entries changed
+	entries[8].SetTag("13")
+	quick.RefreshListFilter()
+	assert.False(t, entries[8].unlisted)
+	quick.SetListFilter(nil)
+	assert.Equal(t, "Show more… (8)", nl.more.Title())
+	for k, entry := range entries[:3] {
+		assert.False(t, entry.unlisted, "entry %d", k)
+	}
+}
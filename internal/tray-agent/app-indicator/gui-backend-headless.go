@@ -0,0 +1,229 @@
+// +build !noheadless
+
+package app_indicator
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/xdg"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//The headless GuiBackend runs the Agent without any tray icon, e.g. as a background service or where no graphic
+//server and no terminal are available. The status of the Agent and the notifications are logged on stdout, while
+//the menu can be consulted and clicked through a local unix socket (see EnvAgentSocket):
+//
+//	$ echo menu | nc -U $XDG_RUNTIME_DIR/liqo/agent.sock
+//	$ echo "click 3" | nc -U $XDG_RUNTIME_DIR/liqo/agent.sock
+//
+//Being the last resort, it is used when no other backend can run. Use the "noheadless" build tag to exclude it.
+func init() {
+	RegisterGuiBackend(GuiBackendHeadless, 0, newHeadlessBackend)
+}
+
+//EnvAgentSocket is the name of the env var containing the path of the unix socket of the headless GuiBackend.
+//It defaults to the AgentSocketFileName file in the xdg.DirRuntime directory (or in the temporary directory, if the
+//session provides no runtime directory).
+const EnvAgentSocket = "LIQO_AGENT_SOCKET"
+
+//AgentSocketFileName is the default basename of the unix socket of the headless GuiBackend.
+const AgentSocketFileName = "agent.sock"
+
+//headlessHelp describes the commands accepted on the socket of the headless GuiBackend.
+const headlessHelp = `commands:
+  status    print the tray label
+  menu      print the menu, numbering its clickable entries
+  click N   click the entry number N of the menu
+  help      print this help
+`
+
+func newHeadlessBackend() (GuiBackend, error) {
+	return newHeadlessBackendWithIO(agentSocketPath(), os.Stdout), nil
+}
+
+func newHeadlessBackendWithIO(socketPath string, out io.Writer) *headlessBackend {
+	return &headlessBackend{
+		socketPath: socketPath,
+		out:        out,
+		menu:       newItemTree(nil),
+		quit:       make(chan struct{}),
+	}
+}
+
+//agentSocketPath returns the path of the unix socket of the headless GuiBackend.
+func agentSocketPath() string {
+	if path, present := os.LookupEnv(EnvAgentSocket); present {
+		return path
+	}
+	if path := xdg.DirRuntime.File(AgentSocketFileName); path != "" {
+		return path
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("liqo-agent-%d.sock", os.Getuid()))
+}
+
+//headlessBackend is a GuiBackend logging the status on out and serving the menu on a unix socket.
+type headlessBackend struct {
+	socketPath string
+	//out is the destination of the logs.
+	out io.Writer
+	//outMutex serializes the logs.
+	outMutex sync.Mutex
+	menu     *itemTree
+	//title is protected by the menu mutex.
+	title    string
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+func (b *headlessBackend) Run(onReady func(), onExit func()) {
+	listener, err := b.listen()
+	if err != nil {
+		b.logf("Liqo Agent: running headless, the menu is not available: %v", err)
+	} else {
+		b.logf("Liqo Agent: running headless, send 'help' to the %s socket", b.socketPath)
+		go b.serve(listener)
+	}
+	go onReady()
+	<-b.quit
+	if listener != nil {
+		_ = listener.Close()
+	}
+	if onExit != nil {
+		onExit()
+	}
+}
+
+//listen opens the unix socket, replacing the stale one left by a previous execution. The socket is accessible by
+//the current user only.
+func (b *headlessBackend) listen() (net.Listener, error) {
+	if b.socketPath == "" {
+		return nil, fmt.Errorf("no socket path")
+	}
+	if conn, err := net.Dial("unix", b.socketPath); err == nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("%s is in use by another Agent", b.socketPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(b.socketPath), 0700); err != nil {
+		return nil, err
+	}
+	_ = os.Remove(b.socketPath)
+	listener, err := net.Listen("unix", b.socketPath)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.Chmod(b.socketPath, 0600); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+//serve accepts the connections to the socket until it is closed.
+func (b *headlessBackend) serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go b.serveConn(conn)
+	}
+}
+
+//serveConn replies to the commands received on a connection, one per line.
+func (b *headlessBackend) serveConn(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		if _, err := io.WriteString(conn, b.handleCommand(strings.TrimSpace(scanner.Text()))); err != nil {
+			return
+		}
+	}
+}
+
+//handleCommand executes a command received on the socket, returning the reply.
+func (b *headlessBackend) handleCommand(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return headlessHelp
+	}
+	b.menu.mu.Lock()
+	defer b.menu.mu.Unlock()
+	menu, clickable := b.menu.render(b.title)
+	switch {
+	case fields[0] == "status" && len(fields) == 1:
+		return b.title + "\n"
+	case fields[0] == "menu" && len(fields) == 1:
+		return menu
+	case fields[0] == "click" && len(fields) == 2:
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n <= 0 || n > len(clickable) {
+			return fmt.Sprintf("invalid entry %q\n", fields[1])
+		}
+		clickable[n-1].click()
+		return "clicked " + clickable[n-1].title + "\n"
+	case fields[0] == "help":
+		return headlessHelp
+	default:
+		return fmt.Sprintf("unknown command %q\n%s", command, headlessHelp)
+	}
+}
+
+//logf writes a line on the log of the backend.
+func (b *headlessBackend) logf(format string, args ...interface{}) {
+	b.outMutex.Lock()
+	defer b.outMutex.Unlock()
+	_, _ = fmt.Fprintf(b.out, format+"\n", args...)
+}
+
+//notify implements the notificationLogger interface.
+func (b *headlessBackend) notify(n Notification) {
+	b.logf("[%s] %s: %s", n.Severity, n.Title, strings.ReplaceAll(strings.TrimSpace(n.Message), "\n", " "))
+}
+
+func (b *headlessBackend) Quit() {
+	b.quitOnce.Do(func() {
+		close(b.quit)
+	})
+}
+
+func (b *headlessBackend) AddSeparator() {
+	b.menu.add(nil, false, true)
+}
+
+//SetIcon is a no-op, since there is no icon to draw.
+func (b *headlessBackend) SetIcon(iconBytes []byte) {}
+
+//SetTitle logs the changes of the tray label, which summarizes the status of the Agent.
+func (b *headlessBackend) SetTitle(title string) {
+	b.menu.mu.Lock()
+	changed := b.title != title
+	b.title = title
+	b.menu.mu.Unlock()
+	if changed && title != "" {
+		b.logf("» %s", title)
+	}
+}
+
+//Capabilities implements the GuiBackend interface: the label is logged at each change, while the Notifications are
+//logged without their Actions (see notificationLogger).
+func (b *headlessBackend) Capabilities() Capability {
+	return CapabilityLabel | CapabilityCheckmarks | CapabilityReorder
+}
+
+func (b *headlessBackend) AddMenuItem(withCheckbox bool) Item {
+	return b.menu.add(nil, withCheckbox, false)
+}
+
+func (b *headlessBackend) AddSubMenuItem(parent Item, withCheckbox bool) Item {
+	return b.menu.add(parent.(*treeItem), withCheckbox, false)
+}
+
+func (b *headlessBackend) MoveItem(item Item, sibling Item, after bool) {
+	b.menu.move(item.(*treeItem), sibling.(*treeItem), after)
+}
@@ -0,0 +1,83 @@
+// +build !noheadless
+
+package app_indicator
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+//syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	buf bytes.Buffer
+	sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.String()
+}
+
+func TestHeadlessBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liqo-headless")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out := &syncBuffer{}
+	b := newHeadlessBackendWithIO(filepath.Join(dir, AgentSocketFileName), out)
+	action := b.AddMenuItem(false)
+	action.SetTitle("action")
+	b.SetTitle("(IN:1/OUT:0)")
+	assert.Contains(t, out.String(), "» (IN:1/OUT:0)", "label change not logged")
+	assert.Equal(t, "(IN:1/OUT:0)\n", b.handleCommand("status"))
+	assert.Contains(t, b.handleCommand("menu"), "  1 action")
+	assert.Contains(t, b.handleCommand("click 2"), "invalid entry")
+	assert.Contains(t, b.handleCommand("unknown"), "unknown command")
+	//the menu is served on the socket
+	exited := make(chan struct{})
+	go b.Run(func() {}, func() { close(exited) })
+	var conn net.Conn
+	assert.Eventually(t, func() bool {
+		conn, err = net.Dial("unix", filepath.Join(dir, AgentSocketFileName))
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	if conn != nil {
+		_, err = conn.Write([]byte("click 1\n"))
+		assert.NoError(t, err)
+		reply, _ := bufio.NewReader(conn).ReadString('\n')
+		assert.Equal(t, "clicked action\n", reply)
+		_ = conn.Close()
+		select {
+		case <-action.ClickedCh():
+		case <-time.After(time.Second):
+			t.Fatal("entry not clicked")
+		}
+	}
+	b.Quit()
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after Quit")
+	}
+	//the Notifications are logged
+	i := newTestIndicator(t, &guiProvider{eventTester: &EventTester{}, backend: b, backendName: GuiBackendHeadless})
+	defer i.Quit()
+	i.ShowNotification(Notification{Title: "LIQO AGENT", Message: "peering\nfailed", Severity: SeverityError})
+	assert.Contains(t, out.String(), "[error] LIQO AGENT: peering failed")
+}
@@ -0,0 +1,322 @@
+// +build !release
+
+package app_indicator
+
+import (
+	"sync"
+)
+
+//The mock GuiBackend does not interact with any graphic server. It is used by the tests and it is excluded
+//from the release builds.
+func init() {
+	RegisterGuiBackend(GuiBackendMock, -1, func() (GuiBackend, error) {
+		return &mockBackend{}, nil
+	})
+}
+
+//mockOnce prevents mockedGui to be modified at runtime.
+var mockOnce sync.Once
+
+//UseMockedGuiProvider enables a mocked guiProvider that does not interact with the OS graphic server.
+//The real guiProvider exploits one of the other registered GuiBackend to orchestrate GUI execution.
+//
+//Function MUST be called before GetGuiProvider in order to be effective.
+func UseMockedGuiProvider() {
+	mockOnce.Do(func() {
+		mockedGui = true
+	})
+}
+
+//NewMockedGuiProvider returns a new mocked GuiProviderInterface, independent of the one returned by
+//GetGuiProvider, to be injected in an Indicator created by NewIndicator.
+func NewMockedGuiProvider() GuiProviderInterface {
+	return &guiProvider{
+		mocked:      true,
+		eventTester: &EventTester{},
+		backend:     &mockBackend{},
+		backendName: GuiBackendMock,
+	}
+}
+
+//DestroyMockedIndicator destroys the Indicator singleton for
+//testing purposes. It works only after calling UseMockedGuiProvider
+func DestroyMockedIndicator() {
+	if mockedGui {
+		rootMutex.Lock()
+		root = nil
+		rootMutex.Unlock()
+		if b, isMock := GetGuiProvider().(*guiProvider).backend.(*mockBackend); isMock {
+			b.inputMutex.Lock()
+			defer b.inputMutex.Unlock()
+			b.items = nil
+		}
+	}
+}
+
+//SetMockedInput sets the answer to the InputDialog calls of the mocked guiProvider returned by GetGuiProvider:
+//value is returned as typed by the user if ok is true, otherwise the dialog box is cancelled.
+func SetMockedInput(value string, ok bool) {
+	if b, isMock := GetGuiProvider().(*guiProvider).backend.(*mockBackend); isMock {
+		b.inputMutex.Lock()
+		defer b.inputMutex.Unlock()
+		b.input, b.inputOK = value, ok
+	}
+}
+
+//MockedClipboard returns the text last copied to the clipboard of the mocked guiProvider returned by
+//GetGuiProvider.
+func MockedClipboard() string {
+	if b, isMock := GetGuiProvider().(*guiProvider).backend.(*mockBackend); isMock {
+		b.inputMutex.Lock()
+		defer b.inputMutex.Unlock()
+		return b.clipboard
+	}
+	return ""
+}
+
+//SetMockedCapabilities sets the Capabilities supported by the mocked guiProvider returned by GetGuiProvider, all of
+//them by default.
+func SetMockedCapabilities(c Capability) {
+	if b, isMock := GetGuiProvider().(*guiProvider).backend.(*mockBackend); isMock {
+		b.inputMutex.Lock()
+		defer b.inputMutex.Unlock()
+		b.unsupported = ^c
+	}
+}
+
+//mockBackend is a GuiBackend whose menu entries are mockItem.
+type mockBackend struct {
+	//input and inputOK are the answer to the InputDialog calls, set by SetMockedInput.
+	input   string
+	inputOK bool
+	//unsupported contains the Capabilities not supported by the backend, set by SetMockedCapabilities.
+	unsupported Capability
+	//title is the content of the label.
+	title string
+	//clipboard is the text last copied to the clipboard.
+	clipboard string
+	//menuOpened counts the requests to display the menu.
+	menuOpened int
+	//items contains the top level entries of the menu in display order, while the nested ones are kept by their
+	//parent.
+	items []*mockItem
+	//inputMutex protects the fields of the mockBackend.
+	inputMutex sync.Mutex
+}
+
+//prompt implements the inputPrompter interface.
+func (b *mockBackend) prompt(title string, text string, defaultText string) (string, bool) {
+	b.inputMutex.Lock()
+	defer b.inputMutex.Unlock()
+	return b.input, b.inputOK
+}
+
+func (b *mockBackend) Run(onReady func(), onExit func()) {}
+
+func (b *mockBackend) Quit() {}
+
+func (b *mockBackend) AddSeparator() {
+	b.inputMutex.Lock()
+	defer b.inputMutex.Unlock()
+	b.items = append(b.items, &mockItem{separator: true, clickChan: make(chan struct{}, 2)})
+}
+
+func (b *mockBackend) SetIcon(iconBytes []byte) {}
+
+func (b *mockBackend) SetTitle(title string) {
+	b.inputMutex.Lock()
+	defer b.inputMutex.Unlock()
+	b.title = title
+}
+
+//writeClipboard implements the clipboardWriter interface, keeping the copied text.
+func (b *mockBackend) writeClipboard(text string) error {
+	b.inputMutex.Lock()
+	defer b.inputMutex.Unlock()
+	b.clipboard = text
+	return nil
+}
+
+//openMenu implements the menuOpener interface, counting the requests.
+func (b *mockBackend) openMenu() {
+	b.inputMutex.Lock()
+	defer b.inputMutex.Unlock()
+	b.menuOpened++
+}
+
+//Capabilities implements the GuiBackend interface.
+func (b *mockBackend) Capabilities() Capability {
+	b.inputMutex.Lock()
+	defer b.inputMutex.Unlock()
+	return ^b.unsupported
+}
+
+func (b *mockBackend) AddMenuItem(withCheckbox bool) Item {
+	item := &mockItem{
+		clickChan: make(chan struct{}, 2),
+	}
+	b.inputMutex.Lock()
+	defer b.inputMutex.Unlock()
+	b.items = append(b.items, item)
+	return item
+}
+
+func (b *mockBackend) AddSubMenuItem(parent Item, withCheckbox bool) Item {
+	parentItem := parent.(*mockItem)
+	item := parentItem.AddSubMenuItemCheckbox("", "", false).(*mockItem)
+	b.inputMutex.Lock()
+	defer b.inputMutex.Unlock()
+	parentItem.children = append(parentItem.children, item)
+	return item
+}
+
+//MoveItem implements the GuiBackend interface.
+func (b *mockBackend) MoveItem(item Item, sibling Item, after bool) {
+	b.inputMutex.Lock()
+	defer b.inputMutex.Unlock()
+	moved, next := item.(*mockItem), sibling.(*mockItem)
+	siblings := &b.items
+	if moved.parent != nil {
+		siblings = &moved.parent.children
+	}
+	if moved == next || moved.parent != next.parent {
+		return
+	}
+	items := make([]*mockItem, 0, len(*siblings))
+	for _, s := range *siblings {
+		if s == moved {
+			continue
+		}
+		if s == next && after {
+			items = append(items, s, moved)
+		} else if s == next {
+			items = append(items, moved, s)
+		} else {
+			items = append(items, s)
+		}
+	}
+	*siblings = items
+}
+
+//mockedSeparator is the title of the separators returned by mockedOrder.
+const mockedSeparator = "─────"
+
+//mockedOrder returns the titles of the entries nested into parent (the top level ones if nil), in display order.
+func (b *mockBackend) mockedOrder(parent *mockItem) []string {
+	b.inputMutex.Lock()
+	defer b.inputMutex.Unlock()
+	items := b.items
+	if parent != nil {
+		items = parent.children
+	}
+	titles := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.separator {
+			titles = append(titles, mockedSeparator)
+		} else {
+			titles = append(titles, item.title)
+		}
+	}
+	return titles
+}
+
+//mockItem implements a mock github.com/getlantern/systray/MenuItem
+type mockItem struct {
+	visible   bool
+	checked   bool
+	disabled  bool
+	title     string
+	tooltip   string
+	clickChan chan struct{}
+	//parent is the mockItem containing the nested ones
+	parent *mockItem
+	//separator specifies whether the mockItem is a separator, added by AddSeparator.
+	separator bool
+	//children contains the nested mockItems in display order, protected by the inputMutex of the mockBackend.
+	children   []*mockItem
+	icon       []byte
+	toggleType ToggleType
+}
+
+func (i *mockItem) SetTooltip(tooltip string) {
+	i.tooltip = tooltip
+}
+
+//AddSubMenuItem adds an Item as a nested menu entry.
+//This method is the mocked counterpart of systray.MenuItem 's method.
+func (i *mockItem) AddSubMenuItem(title string, tooltip string) Item {
+	return &mockItem{
+		title:     title,
+		tooltip:   tooltip,
+		clickChan: make(chan struct{}, 2),
+		parent:    i,
+	}
+}
+
+//AddSubMenuItemCheckbox adds an Item as a nested menu entry with a graphic checkbox.
+//This method is the mocked counterpart of systray.MenuItem 's method.
+func (i *mockItem) AddSubMenuItemCheckbox(title string, tooltip string, checked bool) Item {
+	return &mockItem{
+		checked:   checked,
+		title:     title,
+		tooltip:   tooltip,
+		clickChan: make(chan struct{}, 2),
+		parent:    i,
+	}
+}
+
+func (i *mockItem) Check() {
+	i.checked = true
+}
+
+func (i *mockItem) Uncheck() {
+	i.checked = false
+}
+
+func (i *mockItem) Checked() bool {
+	return i.checked
+}
+
+func (i *mockItem) Enable() {
+	i.disabled = false
+}
+
+func (i *mockItem) Disable() {
+	i.disabled = true
+}
+
+func (i *mockItem) Disabled() bool {
+	return i.disabled
+}
+
+func (i *mockItem) Show() {
+	i.visible = true
+}
+
+func (i *mockItem) Hide() {
+	i.visible = false
+}
+
+func (i *mockItem) Visible() bool {
+	return i.visible
+}
+
+func (i *mockItem) SetTitle(title string) {
+	i.title = title
+}
+
+func (i *mockItem) Title() string {
+	return i.title
+}
+
+func (i *mockItem) SetIcon(iconBytes []byte) {
+	i.icon = iconBytes
+}
+
+func (i *mockItem) SetToggleType(toggleType ToggleType) {
+	i.toggleType = toggleType
+}
+
+func (i *mockItem) ClickedCh() chan struct{} {
+	return i.clickChan
+}
@@ -0,0 +1,433 @@
+// +build linux,!nosni
+
+package app_indicator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+	"image"
+	"image/color"
+	_ "image/png"
+	"os"
+	"sync"
+	"time"
+)
+
+//The sni GuiBackend exports the tray icon as a StatusNotifierItem on the D-Bus session bus, with its menu
+//exported with the com.canonical.dbusmenu protocol. It is written in pure Go, so it does not need cgo nor the
+//GTK libraries. Use the "nosni" build tag to exclude it.
+func init() {
+	RegisterGuiBackend(GuiBackendSNI, 20, newSniBackend)
+}
+
+const (
+	sniWatcherName  = "org.kde.StatusNotifierWatcher"
+	sniWatcherPath  = "/StatusNotifierWatcher"
+	sniItemIface    = "org.kde.StatusNotifierItem"
+	sniItemPath     = "/StatusNotifierItem"
+	dbusMenuIface   = "com.canonical.dbusmenu"
+	dbusMenuPath    = "/MenuBar"
+	dbusMenuVersion = 3
+	//sniLayoutDelay is the time the changes of the menu are collected for, before signaling a new layout.
+	sniLayoutDelay = 50 * time.Millisecond
+)
+
+func newSniBackend() (GuiBackend, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return nil, err
+	}
+	var hasWatcher bool
+	if err := conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, sniWatcherName).
+		Store(&hasWatcher); err != nil {
+		return nil, err
+	}
+	if !hasWatcher {
+		return nil, errors.New("no StatusNotifierWatcher on the session bus")
+	}
+	b := &sniBackend{
+		conn: conn,
+		quit: make(chan struct{}),
+	}
+	b.menu = newItemTree(b.scheduleLayoutUpdate)
+	return b, nil
+}
+
+//sniBackend is a GuiBackend implementing the StatusNotifierItem specification.
+type sniBackend struct {
+	conn *dbus.Conn
+	//busName is the name the StatusNotifierItem is registered with.
+	busName   string
+	itemProps *prop.Properties
+	menu      *itemTree
+	//layoutMutex protects revision and layoutTimer.
+	layoutMutex sync.Mutex
+	//revision is the version of the menu layout, incremented at each change.
+	revision    uint32
+	layoutTimer *time.Timer
+	quit        chan struct{}
+	quitOnce    sync.Once
+}
+
+func (b *sniBackend) Run(onReady func(), onExit func()) {
+	if err := b.export(); err != nil {
+		panic(fmt.Sprintf("cannot export the StatusNotifierItem: %v", err))
+	}
+	go onReady()
+	<-b.quit
+	_, _ = b.conn.ReleaseName(b.busName)
+	if onExit != nil {
+		onExit()
+	}
+}
+
+//export exports the StatusNotifierItem and its menu, then registers the item to the StatusNotifierWatcher.
+func (b *sniBackend) export() error {
+	b.busName = fmt.Sprintf("org.kde.StatusNotifierItem-%d-1", os.Getpid())
+	if _, err := b.conn.RequestName(b.busName, dbus.NameFlagDoNotQueue); err != nil {
+		return err
+	}
+	item := &sniItemObject{}
+	if err := b.conn.Export(item, sniItemPath, sniItemIface); err != nil {
+		return err
+	}
+	var err error
+	b.itemProps, err = prop.Export(b.conn, sniItemPath, map[string]map[string]*prop.Prop{
+		sniItemIface: {
+			"Category":      {Value: "ApplicationStatus"},
+			"Id":            {Value: "liqo-agent"},
+			"Title":         {Value: "Liqo Agent"},
+			"Status":        {Value: "Active"},
+			"IconName":      {Value: ""},
+			"IconPixmap":    {Value: []sniPixmap{}},
+			"ToolTip":       {Value: sniToolTip{Title: "Liqo Agent", Pixmaps: []sniPixmap{}}},
+			"ItemIsMenu":    {Value: true},
+			"Menu":          {Value: dbus.ObjectPath(dbusMenuPath)},
+			"XAyatanaLabel": {Value: ""},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	menu := &sniMenuObject{backend: b}
+	if err := b.conn.Export(menu, dbusMenuPath, dbusMenuIface); err != nil {
+		return err
+	}
+	menuProps, err := prop.Export(b.conn, dbusMenuPath, map[string]map[string]*prop.Prop{
+		dbusMenuIface: {
+			"Version":       {Value: uint32(dbusMenuVersion)},
+			"TextDirection": {Value: "ltr"},
+			"Status":        {Value: "normal"},
+			"IconThemePath": {Value: []string{}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if err := exportIntrospection(b.conn, sniItemPath, sniItemIface, item, b.itemProps); err != nil {
+		return err
+	}
+	if err := exportIntrospection(b.conn, dbusMenuPath, dbusMenuIface, menu, menuProps); err != nil {
+		return err
+	}
+	return b.conn.Object(sniWatcherName, sniWatcherPath).
+		Call(sniWatcherName+".RegisterStatusNotifierItem", 0, b.busName).Err
+}
+
+//exportIntrospection exports the org.freedesktop.DBus.Introspectable interface for an exported object.
+func exportIntrospection(conn *dbus.Conn, path dbus.ObjectPath, iface string, obj interface{},
+	props *prop.Properties) error {
+	node := &introspect.Node{
+		Name: string(path),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{
+				Name:       iface,
+				Methods:    introspect.Methods(obj),
+				Properties: props.Introspection(iface),
+			},
+		},
+	}
+	return conn.Export(introspect.NewIntrospectable(node), path, "org.freedesktop.DBus.Introspectable")
+}
+
+func (b *sniBackend) Quit() {
+	b.quitOnce.Do(func() {
+		close(b.quit)
+	})
+}
+
+func (b *sniBackend) AddSeparator() {
+	b.menu.add(nil, false, true)
+}
+
+func (b *sniBackend) SetIcon(iconBytes []byte) {
+	if b.itemProps == nil {
+		return
+	}
+	pixmap, err := newSniPixmap(iconBytes)
+	if err != nil {
+		return
+	}
+	b.itemProps.SetMust(sniItemIface, "IconPixmap", []sniPixmap{pixmap})
+	_ = b.conn.Emit(sniItemPath, sniItemIface+".NewIcon")
+}
+
+func (b *sniBackend) SetTitle(title string) {
+	if b.itemProps == nil {
+		return
+	}
+	//the label is an extension of the specification supported by the Ayatana hosts (e.g. GNOME, Unity)
+	b.itemProps.SetMust(sniItemIface, "XAyatanaLabel", title)
+	_ = b.conn.Emit(sniItemPath, sniItemIface+".XAyatanaNewLabel", title, "")
+}
+
+//Capabilities implements the GuiBackend interface: the com.canonical.dbusmenu entries have no tooltips.
+func (b *sniBackend) Capabilities() Capability {
+	return CapabilityLabel | CapabilityNotificationsWithActions | CapabilityItemIcons | CapabilityCheckmarks |
+		CapabilityReorder
+}
+
+func (b *sniBackend) AddMenuItem(withCheckbox bool) Item {
+	return b.menu.add(nil, withCheckbox, false)
+}
+
+func (b *sniBackend) AddSubMenuItem(parent Item, withCheckbox bool) Item {
+	return b.menu.add(parent.(*treeItem), withCheckbox, false)
+}
+
+func (b *sniBackend) MoveItem(item Item, sibling Item, after bool) {
+	b.menu.move(item.(*treeItem), sibling.(*treeItem), after)
+}
+
+//scheduleLayoutUpdate signals the new layout of the menu to the host, collecting the changes performed
+//in a short time (e.g. while the whole menu is being refreshed) into a single signal.
+func (b *sniBackend) scheduleLayoutUpdate() {
+	b.layoutMutex.Lock()
+	defer b.layoutMutex.Unlock()
+	b.revision++
+	if b.layoutTimer != nil {
+		return
+	}
+	b.layoutTimer = time.AfterFunc(sniLayoutDelay, func() {
+		b.layoutMutex.Lock()
+		revision := b.revision
+		b.layoutTimer = nil
+		b.layoutMutex.Unlock()
+		_ = b.conn.Emit(dbusMenuPath, dbusMenuIface+".LayoutUpdated", revision, int32(0))
+	})
+}
+
+//currentRevision returns the version of the menu layout.
+func (b *sniBackend) currentRevision() uint32 {
+	b.layoutMutex.Lock()
+	defer b.layoutMutex.Unlock()
+	return b.revision
+}
+
+//sniPixmap is an icon in the ARGB32 (network byte order) format required by the specification.
+type sniPixmap struct {
+	Width  int32
+	Height int32
+	Data   []byte
+}
+
+//newSniPixmap converts a PNG icon into a sniPixmap.
+func newSniPixmap(iconBytes []byte) (sniPixmap, error) {
+	img, _, err := image.Decode(bytes.NewReader(iconBytes))
+	if err != nil {
+		return sniPixmap{}, err
+	}
+	bounds := img.Bounds()
+	pixmap := sniPixmap{
+		Width:  int32(bounds.Dx()),
+		Height: int32(bounds.Dy()),
+		Data:   make([]byte, 0, 4*bounds.Dx()*bounds.Dy()),
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			pixmap.Data = append(pixmap.Data, c.A, c.R, c.G, c.B)
+		}
+	}
+	return pixmap, nil
+}
+
+//sniToolTip is the tooltip of a StatusNotifierItem.
+type sniToolTip struct {
+	IconName string
+	Pixmaps  []sniPixmap
+	Title    string
+	Text     string
+}
+
+//sniItemObject implements the methods of the org.kde.StatusNotifierItem interface. Since the item
+//is a menu (ItemIsMenu), the host displays the menu on click and the methods are no-ops.
+type sniItemObject struct{}
+
+func (o *sniItemObject) Activate(x, y int32) *dbus.Error {
+	return nil
+}
+
+func (o *sniItemObject) SecondaryActivate(x, y int32) *dbus.Error {
+	return nil
+}
+
+func (o *sniItemObject) ContextMenu(x, y int32) *dbus.Error {
+	return nil
+}
+
+func (o *sniItemObject) Scroll(delta int32, orientation string) *dbus.Error {
+	return nil
+}
+
+//dbusMenuLayout is a node of the menu layout, with signature (ia{sv}av).
+type dbusMenuLayout struct {
+	ID         int32
+	Properties map[string]dbus.Variant
+	Children   []dbus.Variant
+}
+
+//dbusMenuItemProperties are the properties of a menu entry, with signature (ia{sv}).
+type dbusMenuItemProperties struct {
+	ID         int32
+	Properties map[string]dbus.Variant
+}
+
+//dbusMenuEvent is an event of a menu entry, with signature (isvu).
+type dbusMenuEvent struct {
+	ID        int32
+	EventID   string
+	Data      dbus.Variant
+	Timestamp uint32
+}
+
+//sniMenuObject implements the methods of the com.canonical.dbusmenu interface.
+type sniMenuObject struct {
+	backend *sniBackend
+}
+
+func (o *sniMenuObject) GetLayout(parentID int32, recursionDepth int32, propertyNames []string) (uint32,
+	dbusMenuLayout, *dbus.Error) {
+	tree := o.backend.menu
+	parent, present := tree.item(parentID)
+	if !present {
+		return 0, dbusMenuLayout{}, dbus.MakeFailedError(fmt.Errorf("unknown menu entry %d", parentID))
+	}
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+	return o.backend.currentRevision(), layoutOf(parent, recursionDepth), nil
+}
+
+//layoutOf returns the layout of the subtree of item, descending up to depth levels (all if depth < 0).
+//The caller must hold the tree mutex.
+func layoutOf(item *treeItem, depth int32) dbusMenuLayout {
+	layout := dbusMenuLayout{
+		ID:         item.id,
+		Properties: propertiesOf(item),
+		Children:   []dbus.Variant{},
+	}
+	if depth == 0 {
+		return layout
+	}
+	for _, child := range item.children {
+		layout.Children = append(layout.Children, dbus.MakeVariant(layoutOf(child, depth-1)))
+	}
+	return layout
+}
+
+//propertiesOf returns the dbusmenu properties of an entry. The caller must hold the tree mutex.
+func propertiesOf(item *treeItem) map[string]dbus.Variant {
+	props := map[string]dbus.Variant{
+		"visible": dbus.MakeVariant(item.visible),
+	}
+	if item.id == 0 {
+		props["children-display"] = dbus.MakeVariant("submenu")
+		return props
+	}
+	if item.separator {
+		props["type"] = dbus.MakeVariant("separator")
+		return props
+	}
+	props["label"] = dbus.MakeVariant(item.title)
+	props["enabled"] = dbus.MakeVariant(!item.disabled)
+	if len(item.icon) > 0 {
+		props["icon-data"] = dbus.MakeVariant(item.icon)
+	}
+	if toggle := item.toggle(); toggle != "" {
+		props["toggle-type"] = dbus.MakeVariant(toggle)
+		state := int32(0)
+		if item.checked {
+			state = 1
+		}
+		props["toggle-state"] = dbus.MakeVariant(state)
+	}
+	if item.hasVisibleChildren() {
+		props["children-display"] = dbus.MakeVariant("submenu")
+	}
+	return props
+}
+
+func (o *sniMenuObject) GetGroupProperties(ids []int32, propertyNames []string) ([]dbusMenuItemProperties,
+	*dbus.Error) {
+	tree := o.backend.menu
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+	props := make([]dbusMenuItemProperties, 0, len(ids))
+	for _, id := range ids {
+		if item, present := tree.byID[id]; present {
+			props = append(props, dbusMenuItemProperties{ID: id, Properties: propertiesOf(item)})
+		}
+	}
+	return props, nil
+}
+
+func (o *sniMenuObject) GetProperty(id int32, name string) (dbus.Variant, *dbus.Error) {
+	tree := o.backend.menu
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+	item, present := tree.byID[id]
+	if !present {
+		return dbus.Variant{}, dbus.MakeFailedError(fmt.Errorf("unknown menu entry %d", id))
+	}
+	value, present := propertiesOf(item)[name]
+	if !present {
+		return dbus.Variant{}, dbus.MakeFailedError(fmt.Errorf("unknown property %s", name))
+	}
+	return value, nil
+}
+
+func (o *sniMenuObject) Event(id int32, eventID string, data dbus.Variant, timestamp uint32) *dbus.Error {
+	if eventID != "clicked" {
+		return nil
+	}
+	if item, present := o.backend.menu.item(id); present {
+		item.click()
+	}
+	return nil
+}
+
+func (o *sniMenuObject) EventGroup(events []dbusMenuEvent) ([]int32, *dbus.Error) {
+	var notFound []int32
+	for _, e := range events {
+		if _, present := o.backend.menu.item(e.ID); !present {
+			notFound = append(notFound, e.ID)
+			continue
+		}
+		_ = o.Event(e.ID, e.EventID, e.Data, e.Timestamp)
+	}
+	return notFound, nil
+}
+
+func (o *sniMenuObject) AboutToShow(id int32) (bool, *dbus.Error) {
+	return false, nil
+}
+
+func (o *sniMenuObject) AboutToShowGroup(ids []int32) ([]int32, []int32, *dbus.Error) {
+	return []int32{}, []int32{}, nil
+}
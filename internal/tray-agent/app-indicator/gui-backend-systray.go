@@ -0,0 +1,89 @@
+// +build !nosystray
+
+package app_indicator
+
+import (
+	"errors"
+	"github.com/getlantern/systray"
+	"os"
+	"runtime"
+)
+
+//The systray GuiBackend relies on github.com/getlantern/systray, that requires cgo and, on Linux,
+//the GTK and libappindicator libraries. Use the "nosystray" build tag to exclude it.
+func init() {
+	RegisterGuiBackend(GuiBackendSystray, 30, newSystrayBackend)
+}
+
+func newSystrayBackend() (GuiBackend, error) {
+	if runtime.GOOS == "linux" && os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		return nil, errors.New("no graphic session available")
+	}
+	return &systrayBackend{}, nil
+}
+
+//systrayBackend is a GuiBackend whose menu entries are systrayItem.
+type systrayBackend struct{}
+
+func (b *systrayBackend) Run(onReady func(), onExit func()) {
+	systray.Run(onReady, onExit)
+}
+
+func (b *systrayBackend) Quit() {
+	systray.Quit()
+}
+
+func (b *systrayBackend) AddSeparator() {
+	systray.AddSeparator()
+}
+
+func (b *systrayBackend) SetIcon(iconBytes []byte) {
+	systray.SetIcon(iconBytes)
+}
+
+func (b *systrayBackend) SetTitle(title string) {
+	systray.SetTitle(title)
+}
+
+//Capabilities implements the GuiBackend interface: the label is not displayed on Windows, while the tooltips, the
+//icons and the check marks of the entries without a checkbox are not displayed on Linux.
+func (b *systrayBackend) Capabilities() Capability {
+	c := CapabilityNotificationsWithActions
+	if runtime.GOOS != "windows" {
+		c |= CapabilityLabel
+	}
+	if runtime.GOOS != "linux" {
+		c |= CapabilityTooltips | CapabilityItemIcons | CapabilityCheckmarks
+	}
+	return c
+}
+
+func (b *systrayBackend) AddMenuItem(withCheckbox bool) Item {
+	if withCheckbox {
+		return systrayItem{systray.AddMenuItemCheckbox("", "", false)}
+	}
+	return systrayItem{systray.AddMenuItem("", "")}
+}
+
+func (b *systrayBackend) AddSubMenuItem(parent Item, withCheckbox bool) Item {
+	parentItem := parent.(systrayItem)
+	if withCheckbox {
+		return systrayItem{parentItem.AddSubMenuItemCheckbox("", "", false)}
+	}
+	return systrayItem{parentItem.AddSubMenuItem("", "")}
+}
+
+//MoveItem implements the GuiBackend interface: systray only appends the menu entries, so it is a no-op.
+func (b *systrayBackend) MoveItem(item Item, sibling Item, after bool) {}
+
+//systrayItem adapts a systray.MenuItem to the Item interface.
+type systrayItem struct {
+	*systray.MenuItem
+}
+
+func (i systrayItem) ClickedCh() chan struct{} {
+	return i.MenuItem.ClickedCh
+}
+
+//SetToggleType implements the Item interface: systray has no radio buttons, so the Items always display a check mark.
+func (i systrayItem) SetToggleType(toggleType ToggleType) {}
@@ -0,0 +1,266 @@
+package app_indicator
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+//itemTree is an in-memory menu, used by the GuiBackend implementations that render the menu by themselves
+//instead of delegating it to a native toolkit.
+type itemTree struct {
+	//mu protects the whole tree.
+	mu sync.Mutex
+	//root is the (invisible) parent of the top level entries, with id 0.
+	root   *treeItem
+	byID   map[int32]*treeItem
+	nextID int32
+	//onChange, if not nil, is called after each change of the tree, without holding mu.
+	onChange func()
+}
+
+//newItemTree returns an empty itemTree.
+func newItemTree(onChange func()) *itemTree {
+	t := &itemTree{
+		byID:     make(map[int32]*treeItem),
+		onChange: onChange,
+	}
+	t.root = &treeItem{tree: t, visible: true}
+	t.byID[0] = t.root
+	return t
+}
+
+//add appends a new entry to the children of parent (the root if nil).
+func (t *itemTree) add(parent *treeItem, withCheckbox bool, separator bool) *treeItem {
+	t.mu.Lock()
+	if parent == nil {
+		parent = t.root
+	}
+	t.nextID++
+	item := &treeItem{
+		tree:      t,
+		id:        t.nextID,
+		parent:    parent,
+		checkbox:  withCheckbox,
+		separator: separator,
+		visible:   true,
+		clickCh:   make(chan struct{}, 2),
+	}
+	parent.children = append(parent.children, item)
+	t.byID[item.id] = item
+	t.mu.Unlock()
+	t.changed()
+	return item
+}
+
+//move moves item next to sibling, an entry with the same parent: after it if after == true, before it otherwise.
+func (t *itemTree) move(item *treeItem, sibling *treeItem, after bool) {
+	t.mu.Lock()
+	parent := item.parent
+	if item == sibling || sibling.parent != parent {
+		t.mu.Unlock()
+		return
+	}
+	children := make([]*treeItem, 0, len(parent.children))
+	for _, c := range parent.children {
+		if c == item {
+			continue
+		}
+		if c == sibling && after {
+			children = append(children, c, item)
+		} else if c == sibling {
+			children = append(children, item, c)
+		} else {
+			children = append(children, c)
+		}
+	}
+	parent.children = children
+	t.mu.Unlock()
+	t.changed()
+}
+
+//render returns the textual representation of the visible entries of the menu, headed by title, numbering the
+//clickable ones: the returned slice contains the clickable entries, the first one having number 1. The caller must
+//hold the tree mutex.
+func (t *itemTree) render(title string) (string, []*treeItem) {
+	var sb strings.Builder
+	sb.WriteString("── Liqo Agent")
+	if title != "" {
+		sb.WriteString(": " + title)
+	}
+	sb.WriteString(" ──\n")
+	var clickable []*treeItem
+	t.renderChildren(&sb, t.root, 0, &clickable)
+	return sb.String(), clickable
+}
+
+func (t *itemTree) renderChildren(sb *strings.Builder, parent *treeItem, depth int, clickable *[]*treeItem) {
+	indent := strings.Repeat("    ", depth)
+	for _, item := range parent.children {
+		if !item.visible {
+			continue
+		}
+		if item.separator {
+			sb.WriteString(indent + "   ─────\n")
+			continue
+		}
+		label := item.title
+		switch item.toggle() {
+		case "checkmark":
+			if item.checked {
+				label = "[x] " + label
+			} else {
+				label = "[ ] " + label
+			}
+		case "radio":
+			if item.checked {
+				label = "(•) " + label
+			} else {
+				label = "( ) " + label
+			}
+		}
+		switch {
+		case item.disabled:
+			sb.WriteString(fmt.Sprintf("%s     %s\n", indent, label))
+		case item.hasVisibleChildren():
+			sb.WriteString(fmt.Sprintf("%s   > %s\n", indent, label))
+		default:
+			*clickable = append(*clickable, item)
+			sb.WriteString(fmt.Sprintf("%s%3d %s\n", indent, len(*clickable), label))
+		}
+		t.renderChildren(sb, item, depth+1, clickable)
+	}
+}
+
+//item returns the entry with the given id.
+func (t *itemTree) item(id int32) (*treeItem, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	item, present := t.byID[id]
+	return item, present
+}
+
+func (t *itemTree) changed() {
+	if t.onChange != nil {
+		t.onChange()
+	}
+}
+
+//treeItem is an entry of an itemTree. Its fields are protected by the tree mutex.
+type treeItem struct {
+	tree      *itemTree
+	id        int32
+	parent    *treeItem
+	children  []*treeItem
+	separator bool
+	checkbox  bool
+	visible   bool
+	checked   bool
+	disabled  bool
+	title     string
+	tooltip   string
+	clickCh   chan struct{}
+	//icon is the PNG image displayed next to the title, by the backends supporting CapabilityItemIcons.
+	icon       []byte
+	toggleType ToggleType
+}
+
+//toggle returns the dbusmenu toggle-type of the item: "radio" for the radio buttons, "checkmark" for the items
+//created with a checkbox and the checked ones, empty for the others. The caller must hold the tree mutex.
+func (i *treeItem) toggle() string {
+	switch {
+	case i.toggleType == ToggleRadio:
+		return "radio"
+	case i.checkbox || i.checked:
+		return "checkmark"
+	default:
+		return ""
+	}
+}
+
+//hasVisibleChildren returns whether the item has a submenu to be displayed. The caller must hold the tree mutex.
+func (i *treeItem) hasVisibleChildren() bool {
+	for _, c := range i.children {
+		if c.visible {
+			return true
+		}
+	}
+	return false
+}
+
+//set applies f to the item holding the tree mutex, then signals the change.
+func (i *treeItem) set(f func()) {
+	i.tree.mu.Lock()
+	f()
+	i.tree.mu.Unlock()
+	i.tree.changed()
+}
+
+//get applies f to the item holding the tree mutex.
+func (i *treeItem) get(f func()) {
+	i.tree.mu.Lock()
+	defer i.tree.mu.Unlock()
+	f()
+}
+
+//click delivers a 'clicked' event, unless the previous ones are still pending.
+func (i *treeItem) click() {
+	select {
+	case i.clickCh <- struct{}{}:
+	default:
+	}
+}
+
+func (i *treeItem) Check() {
+	i.set(func() { i.checked = true })
+}
+
+func (i *treeItem) Uncheck() {
+	i.set(func() { i.checked = false })
+}
+
+func (i *treeItem) Checked() (checked bool) {
+	i.get(func() { checked = i.checked })
+	return
+}
+
+func (i *treeItem) Enable() {
+	i.set(func() { i.disabled = false })
+}
+
+func (i *treeItem) Disable() {
+	i.set(func() { i.disabled = true })
+}
+
+func (i *treeItem) Disabled() (disabled bool) {
+	i.get(func() { disabled = i.disabled })
+	return
+}
+
+func (i *treeItem) Show() {
+	i.set(func() { i.visible = true })
+}
+
+func (i *treeItem) Hide() {
+	i.set(func() { i.visible = false })
+}
+
+func (i *treeItem) SetTitle(title string) {
+	i.set(func() { i.title = title })
+}
+
+func (i *treeItem) SetTooltip(tooltip string) {
+	i.set(func() { i.tooltip = tooltip })
+}
+
+func (i *treeItem) ClickedCh() chan struct{} {
+	return i.clickCh
+}
+
+func (i *treeItem) SetIcon(iconBytes []byte) {
+	i.set(func() { i.icon = iconBytes })
+}
+
+func (i *treeItem) SetToggleType(toggleType ToggleType) {
+	i.set(func() { i.toggleType = toggleType })
+}
@@ -0,0 +1,158 @@
+// +build !notui
+
+package app_indicator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//The tui GuiBackend renders the menu on the terminal the Agent has been started from, for the environments
+//without a graphic session (e.g. servers reached via ssh). Use the "notui" build tag to exclude it.
+func init() {
+	RegisterGuiBackend(GuiBackendTUI, 10, newTuiBackend)
+}
+
+func newTuiBackend() (GuiBackend, error) {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Mode()&os.ModeCharDevice == 0 {
+		return nil, errors.New("stdin is not a terminal")
+	}
+	return newTuiBackendWithIO(os.Stdin, os.Stdout), nil
+}
+
+func newTuiBackendWithIO(in io.Reader, out io.Writer) *tuiBackend {
+	return &tuiBackend{
+		in:   in,
+		out:  out,
+		menu: newItemTree(nil),
+		quit: make(chan struct{}),
+	}
+}
+
+//tuiBackend is a GuiBackend whose menu is printed on request: an empty line prints the menu,
+//the number of an entry clicks it.
+type tuiBackend struct {
+	in   io.Reader
+	out  io.Writer
+	menu *itemTree
+	//title and clickable are protected by the menu mutex.
+	title string
+	//clickable maps the numbers of the entries in the last printed menu to the entries.
+	clickable []*treeItem
+	quit      chan struct{}
+	quitOnce  sync.Once
+}
+
+func (b *tuiBackend) Run(onReady func(), onExit func()) {
+	go onReady()
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(b.in)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	_, _ = fmt.Fprintln(b.out, "Liqo Agent: press Enter to show the menu, then type the number of an entry.")
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				//no more input (e.g. stdin closed): keep running until Quit
+				lines = nil
+				continue
+			}
+			b.handleInput(strings.TrimSpace(line))
+		case <-b.quit:
+			if onExit != nil {
+				onExit()
+			}
+			return
+		}
+	}
+}
+
+//handleInput clicks the entry selected by the user or prints the menu.
+func (b *tuiBackend) handleInput(line string) {
+	if line != "" {
+		if n, err := strconv.Atoi(line); err == nil {
+			b.menu.mu.Lock()
+			var item *treeItem
+			if n > 0 && n <= len(b.clickable) {
+				item = b.clickable[n-1]
+			}
+			b.menu.mu.Unlock()
+			if item != nil {
+				item.click()
+				return
+			}
+		}
+		_, _ = fmt.Fprintf(b.out, "invalid entry %q\n", line)
+	}
+	_, _ = fmt.Fprint(b.out, b.render())
+}
+
+//openMenu implements the menuOpener interface, printing the menu.
+func (b *tuiBackend) openMenu() {
+	_, _ = fmt.Fprint(b.out, b.render())
+}
+
+//render prints the visible entries of the menu, numbering the clickable ones.
+func (b *tuiBackend) render() string {
+	b.menu.mu.Lock()
+	defer b.menu.mu.Unlock()
+	var menu string
+	menu, b.clickable = b.menu.render(b.title)
+	return menu
+}
+
+func (b *tuiBackend) Quit() {
+	b.quitOnce.Do(func() {
+		close(b.quit)
+	})
+}
+
+func (b *tuiBackend) AddSeparator() {
+	b.menu.add(nil, false, true)
+}
+
+//SetIcon is a no-op, since the icon can not be displayed on a terminal.
+func (b *tuiBackend) SetIcon(iconBytes []byte) {}
+
+func (b *tuiBackend) SetTitle(title string) {
+	b.menu.mu.Lock()
+	changed := b.title != title
+	b.title = title
+	b.menu.mu.Unlock()
+	if changed && title != "" {
+		_, _ = fmt.Fprintf(b.out, "» %s\n", title)
+	}
+}
+
+//Capabilities implements the GuiBackend interface: the label is printed at each change, while the desktop banners
+//are displayed if a graphic session is available.
+func (b *tuiBackend) Capabilities() Capability {
+	return CapabilityLabel | CapabilityNotificationsWithActions | CapabilityCheckmarks | CapabilityReorder
+}
+
+func (b *tuiBackend) AddMenuItem(withCheckbox bool) Item {
+	return b.menu.add(nil, withCheckbox, false)
+}
+
+func (b *tuiBackend) AddSubMenuItem(parent Item, withCheckbox bool) Item {
+	return b.menu.add(parent.(*treeItem), withCheckbox, false)
+}
+
+func (b *tuiBackend) MoveItem(item Item, sibling Item, after bool) {
+	b.menu.move(item.(*treeItem), sibling.(*treeItem), after)
+}
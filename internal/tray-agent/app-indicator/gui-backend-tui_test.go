@@ -0,0 +1,73 @@
+// +build !notui
+
+package app_indicator
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTuiBackend(t *testing.T) {
+	assert.Contains(t, GuiBackends(), GuiBackendTUI)
+	out := &bytes.Buffer{}
+	b := newTuiBackendWithIO(strings.NewReader(""), out)
+	b.SetTitle("home")
+	action := b.AddMenuItem(false)
+	action.SetTitle("action")
+	option := b.AddSubMenuItem(action, true)
+	option.SetTitle("option")
+	option.Check()
+	hidden := b.AddMenuItem(false)
+	hidden.SetTitle("hidden")
+	hidden.Hide()
+	b.AddSeparator()
+	quit := b.AddMenuItem(false)
+	quit.SetTitle("quit")
+	menu := b.render()
+	assert.Contains(t, menu, "Liqo Agent: home")
+	assert.Contains(t, menu, "> action", "entry with a submenu not rendered as such")
+	assert.Contains(t, menu, "  1 [x] option")
+	assert.Contains(t, menu, "  2 quit")
+	assert.NotContains(t, menu, "hidden", "hidden entry rendered")
+	//select an entry by its number
+	b.handleInput("2")
+	select {
+	case <-quit.ClickedCh():
+	case <-time.After(time.Second):
+		t.Fatal("entry not clicked")
+	}
+	b.handleInput("42")
+	assert.Contains(t, out.String(), "invalid entry")
+	//Run returns (executing onExit) after Quit
+	exited := make(chan struct{})
+	go b.Run(func() { b.Quit() }, func() { close(exited) })
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after Quit")
+	}
+}
+
+func TestItemTreeToggles(t *testing.T) {
+	b := newTuiBackendWithIO(strings.NewReader(""), &bytes.Buffer{})
+	action := b.AddMenuItem(false)
+	action.SetTitle("action")
+	//the entries without a checkbox display the check mark only once checked
+	plain := b.AddSubMenuItem(action, false)
+	plain.SetTitle("plain")
+	assert.NotContains(t, b.render(), "[ ] plain")
+	plain.Check()
+	assert.Contains(t, b.render(), "[x] plain", "check mark of an entry without a checkbox not rendered")
+	//the radio buttons are always displayed
+	radio := b.AddSubMenuItem(action, false)
+	radio.SetTitle("radio")
+	radio.SetToggleType(ToggleRadio)
+	assert.Contains(t, b.render(), "( ) radio")
+	radio.Check()
+	assert.Contains(t, b.render(), "(•) radio")
+	radio.SetIcon([]byte{1})
+	assert.Equal(t, []byte{1}, radio.(*treeItem).icon)
+}
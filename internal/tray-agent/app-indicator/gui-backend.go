@@ -0,0 +1,268 @@
+package app_indicator
+
+import (
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//EnvGuiBackend is the name of the env var that forces the GuiBackend used by the Agent, e.g. "tui". It takes
+//precedence over the 'guiBackend' field of the Agent configuration file.
+const EnvGuiBackend = "LIQO_AGENT_GUI"
+
+/*GuiBackend is the implementation of a graphic server (or of a replacement for it) the guiProvider relies on
+to display the tray icon, its label and its menu.
+
+Each backend lives in its own file, compiled only when its build tag is not excluded, and registers itself
+with RegisterGuiBackend() in an init() function. This way packagers can build minimal binaries, e.g.
+
+	go build -tags "release nosystray nosni" ./cmd/tray-agent
+
+produces a binary with the terminal and headless backends only, without cgo and GTK dependencies.
+
+	| backend  | build tag to exclude it | notes                                           |
+	|----------|-------------------------|-------------------------------------------------|
+	| systray  | nosystray               | github.com/getlantern/systray (cgo)             |
+	| sni      | nosni                   | StatusNotifierItem over D-Bus, Linux only       |
+	| tui      | notui                   | menu rendered on the terminal                   |
+	| headless | noheadless              | status on stdout, menu on a local unix socket   |
+	| mock     | release                 | used by tests, it does not display anything     |
+*/
+type GuiBackend interface {
+	//Run initializes the backend and starts the event loop, invoking onReady once the backend is ready.
+	//It blocks until Quit() is called, then it runs onExit.
+	Run(onReady func(), onExit func())
+	//Quit stops the event loop started by Run.
+	Quit()
+	//AddSeparator adds a separator bar to the menu.
+	AddSeparator()
+	//SetIcon sets the tray icon, provided as a PNG image.
+	SetIcon(iconBytes []byte)
+	//SetTitle sets the content of the label next to the tray icon.
+	SetTitle(title string)
+	//AddMenuItem creates a new entry at the bottom of the menu.
+	AddMenuItem(withCheckbox bool) Item
+	//AddSubMenuItem creates a new entry at the bottom of the submenu of parent,
+	//that is an Item created by the same backend.
+	AddSubMenuItem(parent Item, withCheckbox bool) Item
+	//MoveItem moves item next to sibling, an Item with the same parent: after it if after == true, before it
+	//otherwise. It is ineffective without CapabilityReorder.
+	MoveItem(item Item, sibling Item, after bool)
+	//Capabilities returns the optional features supported by the backend. The Indicator does not call the
+	//operations of the unsupported ones, degrading them instead (see Capability).
+	Capabilities() Capability
+}
+
+//Capability is an optional feature of a GuiBackend. The Capabilities of a backend are combined as a bit mask, e.g.
+//CapabilityLabel | CapabilityTooltips.
+type Capability uint
+
+//Capability identifiers.
+const (
+	//CapabilityLabel is the display of a label next to the tray icon (see GuiBackend.SetTitle). Without it, the
+	//content of the label is appended to the menu title.
+	CapabilityLabel Capability = 1 << iota
+	//CapabilityItemIcons is the display of an icon next to the menu entries.
+	CapabilityItemIcons
+	//CapabilityTooltips is the display of a tooltip when hovering a menu entry (see Item.SetTooltip).
+	CapabilityTooltips
+	//CapabilityNotificationsWithActions is the display of desktop banners with action buttons. Without it, the
+	//banners are displayed without buttons.
+	CapabilityNotificationsWithActions
+	//CapabilityCheckmarks is the display of a native check mark next to any checked entry (see Item.Check), not only
+	//the ones created with a checkbox. Without it, the check mark of the other entries is appended to their content.
+	CapabilityCheckmarks
+	//CapabilityReorder is the move of the menu entries after their creation (see GuiBackend.MoveItem). Without it,
+	//the MenuNodes are moved by exchanging their entries, which is possible only for the ones without a submenu.
+	CapabilityReorder
+)
+
+//capabilityNames maps each Capability into its name.
+var capabilityNames = []struct {
+	capability Capability
+	name       string
+}{
+	{CapabilityLabel, "label"},
+	{CapabilityItemIcons, "itemIcons"},
+	{CapabilityTooltips, "tooltips"},
+	{CapabilityNotificationsWithActions, "notificationsWithActions"},
+	{CapabilityCheckmarks, "checkmarks"},
+	{CapabilityReorder, "reorder"},
+}
+
+//String returns the names of the Capabilities, e.g. "label,tooltips".
+func (c Capability) String() string {
+	var names []string
+	for _, n := range capabilityNames {
+		if c&n.capability != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+//notificationLogger is implemented by the GuiBackends that cannot display desktop banners and dialog boxes (e.g.
+//the headless one): the Notifications are handed to them instead.
+type notificationLogger interface {
+	notify(n Notification)
+}
+
+//inputPrompter is implemented by the GuiBackends that ask the user for a value on their own, instead of displaying
+//a dialog box (e.g. the mock one, answering with a scripted value).
+type inputPrompter interface {
+	prompt(title string, text string, defaultText string) (string, bool)
+}
+
+//clipboardWriter is implemented by the GuiBackends that handle the clipboard on their own (e.g. the mock one,
+//keeping the copied text), instead of writing the clipboard of the platform.
+type clipboardWriter interface {
+	writeClipboard(text string) error
+}
+
+//menuOpener is implemented by the GuiBackends that can display the menu on request of the Agent (e.g. the
+//terminal one, printing it), instead of only when the user clicks the tray icon.
+type menuOpener interface {
+	openMenu()
+}
+
+//GuiBackendFactory creates a GuiBackend. It returns an error if the backend can not run in the current
+//environment (e.g. no D-Bus session or no terminal available).
+type GuiBackendFactory func() (GuiBackend, error)
+
+//guiBackendEntry is a GuiBackendFactory registered in the guiBackends registry.
+type guiBackendEntry struct {
+	name    string
+	factory GuiBackendFactory
+	//priority determines the order in which the backends are tried when no backend is forced.
+	//Backends with a negative priority are used only when explicitly requested.
+	priority int
+}
+
+var (
+	//guiBackends is the registry of the backends compiled in the binary.
+	guiBackends = make(map[string]guiBackendEntry)
+	//guiBackendsMutex protects guiBackends.
+	guiBackendsMutex sync.RWMutex
+)
+
+//Names of the GuiBackend implementations provided by this package.
+const (
+	GuiBackendSystray  = "systray"
+	GuiBackendSNI      = "sni"
+	GuiBackendTUI      = "tui"
+	GuiBackendHeadless = "headless"
+	GuiBackendMock     = "mock"
+)
+
+//RegisterGuiBackend registers a GuiBackendFactory with a name. When no backend is forced by means of the
+//EnvGuiBackend env var, the registered backends are tried in descending priority order until one of them
+//can be created. It is meant to be called in init() functions.
+func RegisterGuiBackend(name string, priority int, factory GuiBackendFactory) {
+	guiBackendsMutex.Lock()
+	defer guiBackendsMutex.Unlock()
+	if _, present := guiBackends[name]; present {
+		panic(fmt.Sprintf("GuiBackend %s registered twice", name))
+	}
+	guiBackends[name] = guiBackendEntry{name: name, factory: factory, priority: priority}
+}
+
+//GuiBackends returns the names of the backends compiled in the binary, in descending priority order.
+func GuiBackends() []string {
+	entries := sortedGuiBackends()
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.name)
+	}
+	return names
+}
+
+//sortedGuiBackends returns the registered backends in descending priority order.
+func sortedGuiBackends() []guiBackendEntry {
+	guiBackendsMutex.RLock()
+	defer guiBackendsMutex.RUnlock()
+	entries := make([]guiBackendEntry, 0, len(guiBackends))
+	for _, e := range guiBackends {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].priority != entries[j].priority {
+			return entries[i].priority > entries[j].priority
+		}
+		return entries[i].name < entries[j].name
+	})
+	return entries
+}
+
+//selectGuiBackend creates the GuiBackend used by the guiProvider:
+//
+//1. the mock one, if UseMockedGuiProvider() has been called;
+//
+//2. the one forced by the EnvGuiBackend env var;
+//
+//3. the one selected by the 'guiBackend' field of the Agent configuration file, if it can be created;
+//
+//4. the first one that can be created, in descending priority order.
+//
+//It panics if no backend is available, or the forced one can not be created, since the Agent can not run
+//without a GUI.
+func selectGuiBackend() (string, GuiBackend) {
+	var name string
+	var forced bool
+	if mockedGui {
+		name, forced = GuiBackendMock, true
+	} else {
+		name, forced = os.LookupEnv(EnvGuiBackend)
+	}
+	if forced && name != "" {
+		backend, err := createGuiBackend(name)
+		if err != nil {
+			panic(err.Error())
+		}
+		return name, backend
+	}
+	//the configuration file is read before the Indicator exists, since the backend has to be running first
+	client.LoadLocalConfig()
+	conf, _ := client.GetLocalConfig()
+	if name = conf.GetGuiBackend(); name != "" {
+		backend, err := createGuiBackend(name)
+		if err == nil {
+			return name, backend
+		}
+		guiLogger.Warning("falling back to the first available GuiBackend", "err", err)
+	}
+	return firstGuiBackend()
+}
+
+//createGuiBackend creates the registered GuiBackend with a specific name.
+func createGuiBackend(name string) (GuiBackend, error) {
+	guiBackendsMutex.RLock()
+	entry, present := guiBackends[name]
+	guiBackendsMutex.RUnlock()
+	if !present {
+		return nil, fmt.Errorf("GuiBackend %s not available in this build (available: %v)", name, GuiBackends())
+	}
+	backend, err := entry.factory()
+	if err != nil {
+		return nil, fmt.Errorf("cannot start GuiBackend %s: %v", name, err)
+	}
+	return backend, nil
+}
+
+//firstGuiBackend creates the first registered GuiBackend that can be created, in descending priority order.
+func firstGuiBackend() (string, GuiBackend) {
+	var errs []string
+	for _, e := range sortedGuiBackends() {
+		if e.priority < 0 {
+			continue
+		}
+		backend, err := e.factory()
+		if err == nil {
+			return e.name, backend
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", e.name, err))
+	}
+	panic(fmt.Sprintf("no GuiBackend available %v", errs))
+}
@@ -0,0 +1,61 @@
+package app_indicator
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGuiBackends(t *testing.T) {
+	UseMockedGuiProvider()
+	assert.Equal(t, GuiBackendMock, GetGuiProvider().Backend(), "mocked provider not using the mock backend")
+	backends := GuiBackends()
+	assert.Contains(t, backends, GuiBackendMock)
+	assert.Panics(t, func() {
+		RegisterGuiBackend(GuiBackendMock, 0, nil)
+	}, "backend registered twice")
+	//the backends are created by name
+	backend, err := createGuiBackend(GuiBackendMock)
+	assert.NoError(t, err)
+	assert.IsType(t, &mockBackend{}, backend)
+	_, err = createGuiBackend("missing")
+	assert.Error(t, err)
+}
+
+func TestCapabilities(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	i := GetIndicator()
+	defer SetMockedCapabilities(^Capability(0))
+	assert.Equal(t, "label,tooltips", (CapabilityLabel | CapabilityTooltips).String())
+	p := GetGuiProvider()
+	backend := p.(*guiProvider).backend.(*mockBackend)
+	//without the label, its content is appended to the menu title
+	SetMockedCapabilities(CapabilityTooltips)
+	assert.True(t, p.Supports(CapabilityTooltips))
+	assert.False(t, p.Supports(CapabilityLabel|CapabilityTooltips))
+	i.SetMenuTitle("Liqo Agent")
+	backend.SetTitle("")
+	i.SetLabel("2 peers")
+	assert.Equal(t, "Liqo Agent · 2 peers", i.menuTitleNode.Title())
+	assert.Empty(t, backend.title, "label set without the Capability")
+	i.SetLabel("")
+	assert.Equal(t, "Liqo Agent", i.menuTitleNode.Title())
+	//the tooltips are not set without the Capability
+	SetMockedCapabilities(CapabilityLabel)
+	o := i.AddQuick("test", "QUICK_CAPABILITIES", nil).AddOption("option", "OPTION_CAPABILITIES", "tooltip",
+		false, nil)
+	assert.Equal(t, "tooltip", o.tooltip)
+	assert.Empty(t, o.item.(*mockItem).tooltip, "tooltip set without the Capability")
+	i.SetLabel("3 peers")
+	assert.Equal(t, "3 peers", backend.title)
+	assert.Equal(t, "Liqo Agent", i.menuTitleNode.Title())
+	//the banners are displayed without buttons
+	notifier := &bannerRecorder{}
+	i.actionNotifier = notifier
+	i.actionNotifierOnce.Do(func() {})
+	assert.False(t, i.showActionBanner(Notification{Title: "title", Actions: []NotificationAction{{Label: "OK"}}}, ""))
+	assert.Empty(t, notifier.titles)
+}
@@ -1,49 +1,39 @@
 package app_indicator
 
 import (
-	"github.com/getlantern/systray"
+	"errors"
+	"github.com/atotto/clipboard"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/i18n"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/logging"
 	"sync"
 )
 
+//guiLogger writes the log entries of the guiProvider and of the GuiBackends.
+var guiLogger = logging.New("gui")
+
 //mockedGui controls if Indicator graphic component is mocked (true).
 var mockedGui bool
 
-//mockOnce prevents mockedGui to be modified at runtime.
-var mockOnce sync.Once
-
 //guiProviderInstance is the guiProvider singleton.
 var guiProviderInstance *guiProvider
 
 //guiProviderOnce protects guiProviderInstance.
 var guiProviderOnce sync.Once
 
-//UseMockedGuiProvider enables a mocked guiProvider that does not interact with the OS graphic server.
-//The real guiProvider internally exploits github.com/getlantern/systray to orchestrate GUI execution.
-//
-//Function MUST be called before GetGuiProvider in order to be effective.
-func UseMockedGuiProvider() {
-	mockOnce.Do(func() {
-		mockedGui = true
-	})
-}
-
-//DestroyMockedIndicator destroys the Indicator singleton for
-//testing purposes. It works only after calling UseMockedGuiProvider
-func DestroyMockedIndicator() {
-	if mockedGui {
-		root = nil
-	}
-}
-
 //GetGuiProvider returns the guiProvider singleton that provides the functions to interact with the graphic server.
 //
-//If UseMockedGuiProvider() has been previously called, it returns a mocked guiProvider.
+//The guiProvider drives the GuiBackend selected by selectGuiBackend(). If UseMockedGuiProvider() has been
+//previously called, it returns a mocked guiProvider.
 func GetGuiProvider() GuiProviderInterface {
 	guiProviderOnce.Do(func() {
 		guiProviderInstance = &guiProvider{
 			mocked:      mockedGui,
 			eventTester: &EventTester{},
 		}
+		guiProviderInstance.backendName, guiProviderInstance.backend = selectGuiBackend()
+		guiLogger.Debug("GuiBackend selected", "backend", guiProviderInstance.backendName,
+			"capabilities", guiProviderInstance.backend.Capabilities())
 	})
 	return guiProviderInstance
 }
@@ -79,8 +69,25 @@ type GuiProviderInterface interface {
 			Otherwise the graphical behavior of Item.Check() is demanded to internal implementation.
 	*/
 	AddSubMenuItem(parent Item, withCheckbox bool) Item
+	//MoveItem moves item next to sibling, an Item with the same parent: after it if after == true, before it
+	//otherwise. It requires CapabilityReorder.
+	MoveItem(item Item, sibling Item, after bool)
+	//InputDialog displays a dialog box asking the user to type a value, prefilled with defaultText. It returns
+	//the typed value and whether the user confirmed it. Without a dialog box to display (e.g. with the headless
+	//GuiBackend), ok is false.
+	InputDialog(title string, text string, defaultText string) (value string, ok bool)
+	//CopyToClipboard copies text to the clipboard of the desktop session. It returns an error if no clipboard is
+	//available (e.g. the clipboard utilities of the platform are not installed).
+	CopyToClipboard(text string) error
+	//OpenMenu displays the menu, if the GuiBackend can open it on request. It returns whether the menu is displayed.
+	OpenMenu() bool
 	//Mocked returns whether the interaction with the OS graphic server is mocked.
 	Mocked() bool
+	//Backend returns the name of the GuiBackend in use, e.g. "systray".
+	Backend() string
+	//Supports returns whether the GuiBackend in use supports all the Capabilities c, e.g.
+	//Supports(CapabilityLabel). The operations of the unsupported Capabilities should not be called.
+	Supports(c Capability) bool
 	//NewEventTester resets and return the EventTester. You can then call EventTester.Test() to start the testing
 	//mechanism for the events handled by the current Indicator instance. Read more on EventTester documentation.
 	NewEventTester() *EventTester
@@ -113,94 +120,131 @@ Inside a test, use EventTester.Add() and EventTester.Wait() to synchronize the e
 type EventTester struct {
 	sync.WaitGroup
 	testing bool
+	//testingMutex protects testing.
+	testingMutex sync.RWMutex
 }
 
 func (e *EventTester) Test() {
+	e.testingMutex.Lock()
+	defer e.testingMutex.Unlock()
 	e.testing = true
 }
 
-//A guiProvider provides the function to interact with the OS graphic server.
+//isTesting returns whether Test() has been called.
+func (e *EventTester) isTesting() bool {
+	e.testingMutex.RLock()
+	defer e.testingMutex.RUnlock()
+	return e.testing
+}
+
+//A guiProvider provides the function to interact with the OS graphic server, by means of a GuiBackend.
 //It can act as a mocked provider if UseMockedGuiProvider() is previously called.
 type guiProvider struct {
 	//if mocked == true, guiProvider acts a mocked provider
 	mocked      bool
 	eventTester *EventTester
+	//eventTesterMutex protects eventTester.
+	eventTesterMutex sync.RWMutex
+	//backend is the GuiBackend the calls are forwarded to.
+	backend GuiBackend
+	//backendName is the name backend has been registered with.
+	backendName string
 }
 
 func (g *guiProvider) Run(onReady func(), onExit func()) {
-	if !g.mocked {
-		systray.Run(onReady, onExit)
-	}
+	g.backend.Run(onReady, onExit)
 }
 
 func (g *guiProvider) AddSeparator() {
-	if !g.mocked {
-		systray.AddSeparator()
-	}
+	g.backend.AddSeparator()
 }
 
 func (g *guiProvider) Quit() {
-	if !g.mocked {
-		systray.Quit()
-	}
+	g.backend.Quit()
 }
 
 func (g *guiProvider) SetIcon(iconBytes []byte) {
-	if !g.mocked {
-		systray.SetIcon(iconBytes)
-	}
+	g.backend.SetIcon(iconBytes)
 }
 
 func (g *guiProvider) SetTitle(title string) {
-	if !g.mocked {
-		systray.SetTitle(title)
-	}
+	g.backend.SetTitle(title)
 }
 
 func (g *guiProvider) AddMenuItem(withCheckbox bool) Item {
-	if !g.mocked {
-		if withCheckbox {
-			return systray.AddMenuItemCheckbox("", "", false)
-		} else {
-			return systray.AddMenuItem("", "")
-		}
-	} else {
-		return &mockItem{
-			clickChan: make(chan struct{}, 2),
-		}
-	}
+	return g.backend.AddMenuItem(withCheckbox)
 }
 
 func (g *guiProvider) AddSubMenuItem(parent Item, withCheckbox bool) Item {
 	if parent == nil {
 		panic("invalid creation of child Item with nil parent")
 	}
-	if !g.mocked {
-		parentItem := parent.(*systray.MenuItem)
-		if withCheckbox {
-			return parentItem.AddSubMenuItemCheckbox("", "", false)
-		}
-		return parentItem.AddSubMenuItem("", "")
-	} else {
-		parentItem := parent.(*mockItem)
-		return parentItem.AddSubMenuItemCheckbox("", "", false)
+	return g.backend.AddSubMenuItem(parent, withCheckbox)
+}
+
+func (g *guiProvider) MoveItem(item Item, sibling Item, after bool) {
+	g.backend.MoveItem(item, sibling, after)
+}
+
+func (g *guiProvider) InputDialog(title string, text string, defaultText string) (string, bool) {
+	title, text = i18n.T(title), i18n.T(text)
+	if prompter, ok := g.backend.(inputPrompter); ok {
+		return prompter.prompt(title, text, defaultText)
+	}
+	if _, ok := g.backend.(notificationLogger); ok || g.mocked {
+		return "", false
+	}
+	value, ok, err := dlgs.Entry(title, text, defaultText)
+	if err != nil {
+		return "", false
 	}
+	return value, ok
+}
+
+func (g *guiProvider) CopyToClipboard(text string) error {
+	if writer, ok := g.backend.(clipboardWriter); ok {
+		return writer.writeClipboard(text)
+	}
+	if g.mocked {
+		return errors.New("clipboard not available")
+	}
+	return clipboard.WriteAll(text)
 }
 
 func (g *guiProvider) Mocked() bool {
 	return g.mocked
 }
 
+func (g *guiProvider) OpenMenu() bool {
+	if opener, ok := g.backend.(menuOpener); ok {
+		opener.openMenu()
+		return true
+	}
+	return false
+}
+
+func (g *guiProvider) Backend() string {
+	return g.backendName
+}
+
+func (g *guiProvider) Supports(c Capability) bool {
+	return g.backend.Capabilities()&c == c
+}
+
 func (g *guiProvider) NewEventTester() *EventTester {
+	g.eventTesterMutex.Lock()
+	defer g.eventTesterMutex.Unlock()
 	g.eventTester = &EventTester{}
 	return g.eventTester
 }
 
 func (g *guiProvider) GetEventTester() (*EventTester, bool) {
+	g.eventTesterMutex.RLock()
+	defer g.eventTesterMutex.RUnlock()
 	if !g.mocked {
 		return g.eventTester, false
 	}
-	return g.eventTester, g.eventTester.testing
+	return g.eventTester, g.eventTester.isTesting()
 }
 
 //Item is an interface representing the actual item that gets pushed (and displayed) in the stack of the tray menu.
@@ -226,87 +270,23 @@ type Item interface {
 	//SetTooltip sets a tooltip for the Item displayed after a 'mouse hover' event.
 	//Currently, this is ineffective on Linux builds.
 	SetTooltip(tooltip string)
+	//ClickedCh returns the channel receiving an event each time the Item is clicked.
+	ClickedCh() chan struct{}
+	//SetIcon sets the icon displayed next to the content of the Item, provided as a PNG image. A nil icon removes it.
+	//It is ineffective without CapabilityItemIcons.
+	SetIcon(iconBytes []byte)
+	//SetToggleType sets the kind of mark displayed next to the checked Item.
+	SetToggleType(toggleType ToggleType)
 }
 
-//mockItem implements a mock github.com/getlantern/systray/MenuItem
-type mockItem struct {
-	visible   bool
-	checked   bool
-	disabled  bool
-	title     string
-	tooltip   string
-	clickChan chan struct{}
-}
-
-func (i *mockItem) SetTooltip(tooltip string) {
-	i.tooltip = tooltip
-}
-
-//AddSubMenuItem adds an Item as a nested menu entry.
-//This method is the mocked counterpart of systray.MenuItem 's method.
-func (i *mockItem) AddSubMenuItem(title string, tooltip string) Item {
-	return &mockItem{
-		title:     title,
-		tooltip:   tooltip,
-		clickChan: make(chan struct{}, 2),
-	}
-}
-
-//AddSubMenuItemCheckbox adds an Item as a nested menu entry with a graphic checkbox.
-//This method is the mocked counterpart of systray.MenuItem 's method.
-func (i *mockItem) AddSubMenuItemCheckbox(title string, tooltip string, checked bool) Item {
-	return &mockItem{
-		checked:   checked,
-		title:     title,
-		tooltip:   tooltip,
-		clickChan: make(chan struct{}, 2),
-	}
-}
-
-func (i *mockItem) Check() {
-	i.checked = true
-}
-
-func (i *mockItem) Uncheck() {
-	i.checked = false
-}
-
-func (i *mockItem) Checked() bool {
-	return i.checked
-}
-
-func (i *mockItem) Enable() {
-	i.disabled = false
-}
-
-func (i *mockItem) Disable() {
-	i.disabled = true
-}
-
-func (i *mockItem) Disabled() bool {
-	return i.disabled
-}
-
-func (i *mockItem) Show() {
-	i.visible = true
-}
-
-func (i *mockItem) Hide() {
-	i.visible = false
-}
-
-func (i *mockItem) Visible() bool {
-	return i.visible
-}
-
-func (i *mockItem) SetTitle(title string) {
-	i.title = title
-}
-
-func (i *mockItem) Title() string {
-	return i.title
-}
+//ToggleType is the kind of mark displayed next to a checked Item.
+type ToggleType int
 
-func (i *mockItem) ClickedCh() chan struct{} {
-	return i.clickChan
-}
+//ToggleType identifiers.
+const (
+	//ToggleCheckmark displays a check mark, for the Items that are switched on and off independently.
+	ToggleCheckmark ToggleType = iota
+	//ToggleRadio displays a radio button, for the Items that are mutually exclusive choices. The backends without
+	//radio buttons display a check mark instead.
+	ToggleRadio
+)
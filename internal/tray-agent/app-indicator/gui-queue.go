@@ -0,0 +1,54 @@
+package app_indicator
+
+import "sync"
+
+/*This file contains the serialized dispatch of the changes to the GUI. The Items of the MenuNodes, the tray icon and
+its label are changed by many goroutines (the Listeners, the Timers, the event handlers): each change is funneled
+through the queue of the Indicator (see RunOnGui), so that the changes run one at a time, in the order they have
+been requested, and never race against each other.*/
+
+//guiQueue serializes the operations on the GUI. Each operation is run by the goroutine requesting it, as soon as the
+//ones requested before completed.
+type guiQueue struct {
+	mutex sync.Mutex
+	//busy specifies whether an operation is running.
+	busy bool
+	//waiting contains the turns of the operations waiting to run, in the order they have been requested.
+	waiting []chan struct{}
+}
+
+//run runs f once the operations requested before completed.
+func (q *guiQueue) run(f func()) {
+	q.mutex.Lock()
+	if q.busy {
+		turn := make(chan struct{})
+		q.waiting = append(q.waiting, turn)
+		q.mutex.Unlock()
+		<-turn
+	} else {
+		q.busy = true
+		q.mutex.Unlock()
+	}
+	defer q.next()
+	f()
+}
+
+//next hands the GUI to the first operation waiting, if any.
+func (q *guiQueue) next() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.waiting) == 0 {
+		q.busy = false
+		return
+	}
+	turn := q.waiting[0]
+	q.waiting = q.waiting[1:]
+	close(turn)
+}
+
+//RunOnGui runs f with exclusive access to the GUI: after the changes requested before, and before the ones requested
+//later. It returns once f completed. The MenuNode methods already funnel their changes through RunOnGui, so f is
+//meant for direct interactions with the GuiProvider and must not call RunOnGui (nor the MenuNode methods) itself.
+func (i *Indicator) RunOnGui(f func()) {
+	i.gui.run(f)
+}
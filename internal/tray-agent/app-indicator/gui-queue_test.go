@@ -0,0 +1,91 @@
+package app_indicator
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//waitQueued waits for n operations to be waiting in the GUI queue of i.
+func waitQueued(t *testing.T, i *Indicator, n int) {
+	deadline := time.Now().Add(time.Second)
+	for {
+		i.gui.mutex.Lock()
+		queued := len(i.gui.waiting)
+		i.gui.mutex.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d operations queued, expected %d", queued, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRunOnGui(t *testing.T) {
+	i := newTestIndicator(t, NewMockedGuiProvider())
+	defer i.Quit()
+	quick := i.AddQuick("Quick", "Q_TEST", nil)
+	item := quick.item.(*mockItem)
+	//hold the GUI until the other operations have been requested
+	held, release := make(chan struct{}), make(chan struct{})
+	go i.RunOnGui(func() {
+		close(held)
+		<-release
+	})
+	<-held
+	var order []int
+	var wg sync.WaitGroup
+	for k := 0; k < 5; k++ {
+		k := k
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			i.RunOnGui(func() {
+				order = append(order, k)
+			})
+		}()
+		waitQueued(t, i, k+1)
+	}
+	//the changes of the MenuNodes wait for their turn as well
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		quick.SetTitle("Changed")
+	}()
+	waitQueued(t, i, 6)
+	assert.False(t, strings.HasSuffix(item.title, "Changed"), "MenuNode changed out of turn")
+	close(release)
+	wg.Wait()
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, order, "operations run out of order")
+	assert.True(t, strings.HasSuffix(item.title, "Changed"))
+	waitQueued(t, i, 0)
+}
+
+func TestRunOnGuiExclusive(t *testing.T) {
+	i := newTestIndicator(t, NewMockedGuiProvider())
+	defer i.Quit()
+	var running, overlaps, runs int
+	var wg sync.WaitGroup
+	for k := 0; k < 50; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			i.RunOnGui(func() {
+				running++
+				if running > 1 {
+					overlaps++
+				}
+				time.Sleep(100 * time.Microsecond)
+				runs++
+				running--
+			})
+		}()
+	}
+	wg.Wait()
+	assert.Zero(t, overlaps, "concurrent operations on the GUI")
+	assert.Equal(t, 50, runs)
+}
@@ -0,0 +1,147 @@
+// +build linux
+
+package app_indicator
+
+import (
+	"errors"
+	"fmt"
+	"github.com/godbus/dbus/v5"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/i18n"
+	"strings"
+	"time"
+)
+
+//The portal hotkey grabber binds the Hotkeys in a session of the GlobalShortcuts interface of the XDG desktop
+//portal, following its Activated signal on the session bus.
+func init() {
+	hotkeyGrabberFactory = newPortalHotkeyGrabber
+}
+
+const (
+	portalShortcutsIface = "org.freedesktop.portal.GlobalShortcuts"
+	portalRequestIface   = "org.freedesktop.portal.Request"
+	portalSessionIface   = "org.freedesktop.portal.Session"
+	//portalRequestTimeout bounds the wait for the response of the portal, which may ask the user to confirm the
+	//shortcuts.
+	portalRequestTimeout = time.Minute
+)
+
+//portalShortcut is a shortcut bound by the GlobalShortcuts portal, marshalled as (sa{sv}).
+type portalShortcut struct {
+	ID      string
+	Options map[string]dbus.Variant
+}
+
+func newPortalHotkeyGrabber(hotkeys []Hotkey, onActivate func(id string)) (func(), error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return nil, err
+	}
+	token := fmt.Sprintf("liqo_agent_%d", time.Now().UnixNano())
+	results, err := portalRequest(conn, token, portalShortcutsIface+".CreateSession",
+		map[string]dbus.Variant{
+			"handle_token":         dbus.MakeVariant(token),
+			"session_handle_token": dbus.MakeVariant(token),
+		})
+	if err != nil {
+		return nil, err
+	}
+	handle, ok := results["session_handle"]
+	if !ok {
+		return nil, errors.New("no GlobalShortcuts session created")
+	}
+	var session dbus.ObjectPath
+	switch value := handle.Value().(type) {
+	case string:
+		session = dbus.ObjectPath(value)
+	case dbus.ObjectPath:
+		session = value
+	}
+	closeSession := func() {
+		_ = conn.Object(portalName, session).Call(portalSessionIface+".Close", 0).Err
+	}
+	shortcuts := make([]portalShortcut, 0, len(hotkeys))
+	for _, h := range hotkeys {
+		shortcuts = append(shortcuts, portalShortcut{ID: h.ID, Options: map[string]dbus.Variant{
+			"description":       dbus.MakeVariant(i18n.T(h.Description)),
+			"preferred_trigger": dbus.MakeVariant(h.Trigger),
+		}})
+	}
+	bindToken := token + "_bind"
+	if _, err := portalRequest(conn, bindToken, portalShortcutsIface+".BindShortcuts", session, shortcuts, "",
+		map[string]dbus.Variant{"handle_token": dbus.MakeVariant(bindToken)}); err != nil {
+		closeSession()
+		return nil, err
+	}
+	options := []dbus.MatchOption{dbus.WithMatchObjectPath(portalPath), dbus.WithMatchInterface(portalShortcutsIface),
+		dbus.WithMatchMember("Activated")}
+	if err := conn.AddMatchSignal(options...); err != nil {
+		closeSession()
+		return nil, err
+	}
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case s := <-signals:
+				if s.Name != portalShortcutsIface+".Activated" || len(s.Body) < 2 || s.Body[0] != session {
+					continue
+				}
+				if id, ok := s.Body[1].(string); ok {
+					onActivate(id)
+				}
+			}
+		}
+	}()
+	return func() {
+		_ = conn.RemoveMatchSignal(options...)
+		conn.RemoveSignal(signals)
+		close(done)
+		closeSession()
+	}, nil
+}
+
+//portalRequest calls a method of the XDG desktop portal that answers with a Request, and returns the results of
+//its Response. The path of the Request is derived from token, so that the Response is not missed.
+func portalRequest(conn *dbus.Conn, token string, method string, args ...interface{}) (map[string]dbus.Variant,
+	error) {
+	names := conn.Names()
+	if len(names) == 0 {
+		return nil, errors.New("no unique name on the session bus")
+	}
+	sender := strings.Replace(strings.TrimPrefix(names[0], ":"), ".", "_", -1)
+	request := dbus.ObjectPath(portalPath + "/request/" + sender + "/" + token)
+	options := []dbus.MatchOption{dbus.WithMatchObjectPath(request), dbus.WithMatchInterface(portalRequestIface),
+		dbus.WithMatchMember("Response")}
+	if err := conn.AddMatchSignal(options...); err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.RemoveMatchSignal(options...) }()
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+	if err := conn.Object(portalName, portalPath).Call(method, 0, args...).Err; err != nil {
+		return nil, err
+	}
+	timeout := time.After(portalRequestTimeout)
+	for {
+		select {
+		case s := <-signals:
+			if s.Path != request || len(s.Body) < 2 {
+				continue
+			}
+			//the response code is 0 on success, 1 if cancelled by the user and 2 otherwise
+			if code, _ := s.Body[0].(uint32); code != 0 {
+				return nil, fmt.Errorf("%s not completed (response %d)", method, code)
+			}
+			results, _ := s.Body[1].(map[string]dbus.Variant)
+			return results, nil
+		case <-timeout:
+			return nil, fmt.Errorf("no response to %s", method)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package app_indicator
+
+import (
+	"errors"
+)
+
+/*This file contains the global hotkeys of the Agent, i.e. keyboard shortcuts working while the menu is closed and
+the focus is on another application (e.g. to turn Liqo on and off). Grabbing a shortcut for the whole desktop
+requires a platform hook: on Linux, the shortcuts are registered with the GlobalShortcuts interface of the XDG
+desktop portal, that may ask the user to confirm them and lets them change the key combination in the desktop
+settings.*/
+
+//Hotkey is a global keyboard shortcut of the Agent.
+type Hotkey struct {
+	//ID identifies the Hotkey, e.g. "toggle".
+	ID string
+	//Description is the purpose of the Hotkey, displayed by the desktop settings listing the shortcuts.
+	Description string
+	//Trigger is the preferred key combination, in the format of the XDG shortcuts specification (e.g.
+	//"CTRL+ALT+L"). The desktop may assign a different one.
+	Trigger string
+	//Handler is executed at each activation of the Hotkey.
+	Handler func()
+}
+
+//hotkeyGrabberFactory starts listening to the Hotkeys on the current platform, calling onActivate with the ID of
+//the activated one, and returns the function releasing them. It returns an error if the Hotkeys cannot be grabbed.
+var hotkeyGrabberFactory func(hotkeys []Hotkey, onActivate func(id string)) (stop func(), err error)
+
+//SetHotkeys binds the global Hotkeys of the Agent, releasing the previous ones. The Hotkeys without a Trigger are
+//ignored. It returns an error if the Hotkeys cannot be grabbed on this platform: they can still be activated by
+//ActivateHotkey.
+func (i *Indicator) SetHotkeys(hotkeys []Hotkey) error {
+	bound := make([]Hotkey, 0, len(hotkeys))
+	for _, h := range hotkeys {
+		if h.ID != "" && h.Trigger != "" && h.Handler != nil {
+			bound = append(bound, h)
+		}
+	}
+	gr := i.graphicResource[resourceDesktop]
+	gr.Lock()
+	stop := i.hotkeysStop
+	i.hotkeys, i.hotkeysStop = bound, nil
+	gr.Unlock()
+	if stop != nil {
+		stop()
+	}
+	if len(bound) == 0 || i.gProvider.Mocked() {
+		return nil
+	}
+	if hotkeyGrabberFactory == nil {
+		return errors.New("global hotkeys not supported")
+	}
+	stop, err := hotkeyGrabberFactory(bound, func(id string) {
+		go i.ActivateHotkey(id)
+	})
+	if err != nil {
+		return err
+	}
+	gr.Lock()
+	i.hotkeysStop = stop
+	gr.Unlock()
+	return nil
+}
+
+//Hotkeys returns the global Hotkeys bound by SetHotkeys.
+func (i *Indicator) Hotkeys() []Hotkey {
+	gr := i.graphicResource[resourceDesktop]
+	gr.RLock()
+	defer gr.RUnlock()
+	return append([]Hotkey(nil), i.hotkeys...)
+}
+
+//ActivateHotkey executes the Handler of the bound Hotkey with this specific ID, returning false if not bound.
+func (i *Indicator) ActivateHotkey(id string) bool {
+	for _, h := range i.Hotkeys() {
+		if h.ID == id {
+			logger.Debug("hotkey activated", "id", id)
+			h.Handler()
+			return true
+		}
+	}
+	return false
+}
+
+//OpenMenu displays the tray menu on request of the Agent, e.g. at the activation of a Hotkey. It returns false if
+//the GuiBackend displays the menu only when the user clicks the tray icon.
+func (i *Indicator) OpenMenu() bool {
+	return i.gProvider.OpenMenu()
+}
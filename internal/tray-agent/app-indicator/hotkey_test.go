@@ -0,0 +1,44 @@
+package app_indicator
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHotkeys(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	i := GetIndicator()
+	backend := GetGuiProvider().(*guiProvider).backend.(*mockBackend)
+	activated := make(map[string]int)
+	handler := func(id string) func() {
+		return func() { activated[id]++ }
+	}
+	//the Hotkeys without a key combination are not bound
+	assert.NoError(t, i.SetHotkeys([]Hotkey{
+		{ID: "toggle", Trigger: "CTRL+ALT+L", Handler: handler("toggle")},
+		{ID: "refresh", Handler: handler("refresh")},
+	}))
+	assert.Len(t, i.Hotkeys(), 1)
+	assert.True(t, i.ActivateHotkey("toggle"))
+	assert.False(t, i.ActivateHotkey("refresh"), "Hotkey without key combination activated")
+	assert.Equal(t, map[string]int{"toggle": 1}, activated)
+	//the new bindings replace the previous ones
+	assert.NoError(t, i.SetHotkeys([]Hotkey{{ID: "refresh", Trigger: "CTRL+ALT+R", Handler: handler("refresh")}}))
+	assert.False(t, i.ActivateHotkey("toggle"), "released Hotkey activated")
+	assert.True(t, i.ActivateHotkey("refresh"))
+	assert.Equal(t, map[string]int{"toggle": 1, "refresh": 1}, activated)
+	assert.NoError(t, i.SetHotkeys(nil))
+	assert.Empty(t, i.Hotkeys())
+	//the menu is opened on request by the GuiBackends supporting it
+	backend.inputMutex.Lock()
+	opened := backend.menuOpened
+	backend.inputMutex.Unlock()
+	assert.True(t, i.OpenMenu())
+	backend.inputMutex.Lock()
+	assert.Equal(t, opened+1, backend.menuOpened)
+	backend.inputMutex.Unlock()
+}
@@ -0,0 +1,272 @@
+package app_indicator
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"time"
+)
+
+/*This file contains the animations of the tray icon. When the icon changes state, the Indicator looks up the
+IconTransition between the two icons and draws its frames through the GuiProvider before the new icon, so that the
+important changes are noticeable without a popup:
+
+	| from                 | to                          | transition                            |
+	|----------------------|-----------------------------|---------------------------------------|
+	| any other state      | IconLiqoRed/IconLiqoWarning | pulse: the new icon blinks            |
+	| IconLiqoNoConn/Off   | any other state but errors  | fade: the old icon fades into the new |
+
+A newer icon interrupts the running animation. Besides, the transient states (e.g. a reconnection in progress) can
+cycle the tray icon through some Icons with SetIconAnimation, until the next icon is set. The animations can be
+disabled with SetIconAnimations.*/
+
+//IconTransition is the animation drawn when the tray icon changes.
+type IconTransition int
+
+const (
+	//TransitionNone replaces the icon without any animation.
+	TransitionNone IconTransition = iota
+	//TransitionPulse makes the new icon blink, signaling a failure.
+	TransitionPulse
+	//TransitionFade fades the old icon into the new one, signaling a recovery (e.g. a reconnection).
+	TransitionFade
+)
+
+const (
+	//pulseBlinks is the number of times the new icon blinks in a TransitionPulse.
+	pulseBlinks = 2
+	//pulseFrameDelay is the duration of each frame of a TransitionPulse.
+	pulseFrameDelay = 200 * time.Millisecond
+	//pulseDimming is the opacity of the new icon while blinking.
+	pulseDimming = 0.3
+	//fadeFrames is the number of intermediate frames of a TransitionFade.
+	fadeFrames = 3
+	//fadeFrameDelay is the duration of each frame of a TransitionFade.
+	fadeFrameDelay = 100 * time.Millisecond
+)
+
+//iconFrame is a frame of an icon animation.
+type iconFrame struct {
+	//data is the PNG image of the frame.
+	data []byte
+	//delay is the time the frame is displayed for.
+	delay time.Duration
+}
+
+//isErrorIcon returns whether an Icon signals a failure.
+func isErrorIcon(ico Icon) bool {
+	return ico == IconLiqoRed || ico == IconLiqoWarning
+}
+
+//isDisconnectedIcon returns whether an Icon signals that the Agent is not working.
+func isDisconnectedIcon(ico Icon) bool {
+	return ico == IconLiqoNoConn || ico == IconLiqoOff
+}
+
+//iconTransition returns the IconTransition drawn when the tray icon changes from an Icon to another.
+func iconTransition(from Icon, to Icon) IconTransition {
+	switch {
+	case from == to:
+		return TransitionNone
+	case isErrorIcon(to) && !isErrorIcon(from):
+		return TransitionPulse
+	case isDisconnectedIcon(from) && !isDisconnectedIcon(to) && !isErrorIcon(to):
+		return TransitionFade
+	default:
+		return TransitionNone
+	}
+}
+
+//transitionFrames returns the frames drawn before the new icon by an IconTransition. If the frames cannot be
+//computed (e.g. an image cannot be decoded), no frame is returned.
+func transitionFrames(t IconTransition, from []byte, to []byte) []iconFrame {
+	var frames []iconFrame
+	switch t {
+	case TransitionPulse:
+		dimmed, err := blendIcons(nil, to, pulseDimming)
+		if err != nil {
+			return nil
+		}
+		for n := 0; n < pulseBlinks; n++ {
+			frames = append(frames, iconFrame{data: to, delay: pulseFrameDelay},
+				iconFrame{data: dimmed, delay: pulseFrameDelay})
+		}
+	case TransitionFade:
+		for n := 1; n <= fadeFrames; n++ {
+			frame, err := blendIcons(from, to, float64(n)/float64(fadeFrames+1))
+			if err != nil {
+				return nil
+			}
+			frames = append(frames, iconFrame{data: frame, delay: fadeFrameDelay})
+		}
+	}
+	return frames
+}
+
+//blendIcons returns the PNG image mixing two PNG images, where ratio is the weight of the second one (from 0 to
+//1). A nil first image is considered transparent.
+func blendIcons(from []byte, to []byte, ratio float64) ([]byte, error) {
+	dst, err := png.Decode(bytes.NewReader(to))
+	if err != nil {
+		return nil, err
+	}
+	var src image.Image = image.Transparent
+	if from != nil {
+		if src, err = png.Decode(bytes.NewReader(from)); err != nil {
+			return nil, err
+		}
+	}
+	bounds := dst.Bounds()
+	out := image.NewNRGBA(bounds)
+	mix := func(a uint8, b uint8) uint8 {
+		return uint8(float64(a)*(1-ratio) + float64(b)*ratio + 0.5)
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			a := color.NRGBAModel.Convert(src.At(x, y)).(color.NRGBA)
+			b := color.NRGBAModel.Convert(dst.At(x, y)).(color.NRGBA)
+			out.SetNRGBA(x, y, color.NRGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: mix(a.A, b.A)})
+		}
+	}
+	buf := &bytes.Buffer{}
+	if err = png.Encode(buf, out); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+//animateIcon draws the frames of an icon animation followed by the final icon, unless a newer icon is set in the
+//meantime (i.e. the generation of the tray icon changes) or the Indicator quits.
+func (i *Indicator) animateIcon(generation int, frames []iconFrame, final []byte) {
+	gr := i.graphicResource[resourceIcon]
+	for _, f := range append(frames, iconFrame{data: final}) {
+		gr.Lock()
+		if generation != i.iconGeneration {
+			gr.Unlock()
+			return
+		}
+		i.drawIcon(f.data)
+		gr.Unlock()
+		if f.delay == 0 {
+			continue
+		}
+		select {
+		case <-time.After(f.delay):
+		case <-i.ctx.Done():
+			return
+		}
+	}
+}
+
+//SetIconAnimation cycles the tray icon through some Icons, each one displayed for interval, signaling a transient
+//state (e.g. a reconnection in progress). The cycle lasts until the next SetIcon call, while a call with the same
+//Icons and interval leaves the running cycle untouched. If the icon animations are disabled, only the first Icon is
+//displayed. The invalid Icons are skipped.
+func (i *Indicator) SetIconAnimation(frames []Icon, interval time.Duration) {
+	gr := i.graphicResource[resourceIcon]
+	gr.Lock()
+	defer gr.Unlock()
+	if i.iconCycle != nil && i.iconCycleInterval == interval && sameIcons(i.iconCycle, frames) {
+		return
+	}
+	icons := make([]Icon, 0, len(frames))
+	images := make([][]byte, 0, len(frames))
+	for _, ico := range frames {
+		if data, valid := iconData(i.iconTheme, i.colorScheme, ico); valid {
+			icons = append(icons, ico)
+			images = append(images, data)
+		}
+	}
+	if len(icons) == 0 {
+		return
+	}
+	i.iconGeneration++
+	i.icon, i.iconCycle = icons[0], nil
+	if !i.iconAnimations || len(icons) == 1 || interval <= 0 {
+		i.drawIcon(images[0])
+		return
+	}
+	i.iconCycle, i.iconCycleInterval = icons, interval
+	generation := i.iconGeneration
+	i.goTracked(func() {
+		i.cycleIcon(generation, images, interval)
+	})
+}
+
+//IconAnimation returns the Icons cycled by the running SetIconAnimation, if any, and the interval each one is
+//displayed for.
+func (i *Indicator) IconAnimation() ([]Icon, time.Duration) {
+	gr := i.graphicResource[resourceIcon]
+	gr.RLock()
+	defer gr.RUnlock()
+	if i.iconCycle == nil {
+		return nil, 0
+	}
+	return append([]Icon(nil), i.iconCycle...), i.iconCycleInterval
+}
+
+//cycleIcon draws the images of an Icon cycle, one at each interval, until a newer icon is set (i.e. the generation
+//of the tray icon changes) or the Indicator quits.
+func (i *Indicator) cycleIcon(generation int, images [][]byte, interval time.Duration) {
+	gr := i.graphicResource[resourceIcon]
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for frame := 0; ; frame = (frame + 1) % len(images) {
+		gr.Lock()
+		if generation != i.iconGeneration {
+			gr.Unlock()
+			return
+		}
+		i.drawIcon(images[frame])
+		gr.Unlock()
+		select {
+		case <-ticker.C:
+		case <-i.ctx.Done():
+			return
+		}
+	}
+}
+
+//redrawIcon draws again the current tray icon, or restarts its cycle, e.g. after a change of IconTheme.
+func (i *Indicator) redrawIcon() {
+	gr := i.graphicResource[resourceIcon]
+	gr.Lock()
+	cycle, interval, current := i.iconCycle, i.iconCycleInterval, i.icon
+	i.iconCycle = nil
+	gr.Unlock()
+	if cycle != nil {
+		i.SetIconAnimation(cycle, interval)
+		return
+	}
+	i.SetIcon(current)
+}
+
+//sameIcons returns whether two lists contain the same Icons in the same order.
+func sameIcons(a []Icon, b []Icon) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for index := range a {
+		if a[index] != b[index] {
+			return false
+		}
+	}
+	return true
+}
+
+//SetIconAnimations enables or disables the animations of the tray icon.
+func (i *Indicator) SetIconAnimations(enabled bool) {
+	gr := i.graphicResource[resourceIcon]
+	gr.Lock()
+	defer gr.Unlock()
+	i.iconAnimations = enabled
+}
+
+//IconAnimations returns whether the animations of the tray icon are enabled.
+func (i *Indicator) IconAnimations() bool {
+	gr := i.graphicResource[resourceIcon]
+	gr.RLock()
+	defer gr.RUnlock()
+	return i.iconAnimations
+}
@@ -0,0 +1,161 @@
+package app_indicator
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"image/png"
+	"sync"
+	"testing"
+	"time"
+)
+
+//iconRecorder is a mocked GuiProviderInterface recording the drawn icons.
+type iconRecorder struct {
+	GuiProviderInterface
+	icons [][]byte
+	sync.Mutex
+}
+
+func (r *iconRecorder) SetIcon(iconBytes []byte) {
+	r.Lock()
+	defer r.Unlock()
+	r.icons = append(r.icons, iconBytes)
+}
+
+func (r *iconRecorder) drawn() [][]byte {
+	r.Lock()
+	defer r.Unlock()
+	return append([][]byte(nil), r.icons...)
+}
+
+func TestIconTransition(t *testing.T) {
+	assert.Equal(t, TransitionPulse, iconTransition(IconLiqoGreen, IconLiqoRed))
+	assert.Equal(t, TransitionPulse, iconTransition(IconLiqoMain, IconLiqoWarning))
+	assert.Equal(t, TransitionNone, iconTransition(IconLiqoWarning, IconLiqoRed))
+	assert.Equal(t, TransitionFade, iconTransition(IconLiqoNoConn, IconLiqoMain))
+	assert.Equal(t, TransitionNone, iconTransition(IconLiqoNoConn, IconLiqoOff))
+	assert.Equal(t, TransitionNone, iconTransition(IconLiqoMain, IconLiqoGreen))
+	assert.Equal(t, TransitionNone, iconTransition(IconLiqoRed, IconLiqoRed))
+	//the frames are valid images, halfway between the two icons
+	from, _ := iconData(IconThemeDefault, ColorSchemeLight, IconLiqoNoConn)
+	to, _ := iconData(IconThemeDefault, ColorSchemeLight, IconLiqoMain)
+	frames := transitionFrames(TransitionFade, from, to)
+	if assert.Len(t, frames, fadeFrames) {
+		_, err := png.Decode(bytes.NewReader(frames[0].data))
+		assert.NoError(t, err)
+	}
+	assert.Len(t, transitionFrames(TransitionPulse, from, to), 2*pulseBlinks)
+	assert.Empty(t, transitionFrames(TransitionPulse, from, []byte("not an image")))
+}
+
+func TestIconAnimations(t *testing.T) {
+	recorder := &iconRecorder{GuiProviderInterface: NewMockedGuiProvider()}
+	i := newTestIndicator(t, recorder)
+	defer i.Quit()
+	assert.True(t, i.IconAnimations())
+	green, _ := iconData(IconThemeDefault, ColorSchemeLight, IconLiqoGreen)
+	red, _ := iconData(IconThemeDefault, ColorSchemeLight, IconLiqoRed)
+	i.SetIcon(IconLiqoGreen)
+	start := len(recorder.drawn())
+	//a failure makes the icon pulse, ending with the new icon
+	i.SetIcon(IconLiqoRed)
+	assert.Equal(t, IconLiqoRed, i.Icon())
+	assert.Eventually(t, func() bool {
+		return len(recorder.drawn()) == start+2*pulseBlinks+1
+	}, 2*time.Second, 10*time.Millisecond)
+	drawn := recorder.drawn()
+	assert.Equal(t, red, drawn[len(drawn)-1])
+	//a newer icon interrupts the animation
+	i.SetIcon(IconLiqoGreen)
+	i.SetIcon(IconLiqoWarning)
+	i.SetIcon(IconLiqoGreen)
+	time.Sleep(3 * pulseFrameDelay)
+	drawn = recorder.drawn()
+	assert.Equal(t, green, drawn[len(drawn)-1], "interrupted animation drawn over the new icon")
+	//without animations, the icon is replaced at once
+	i.SetIconAnimations(false)
+	start = len(recorder.drawn())
+	i.SetIcon(IconLiqoRed)
+	assert.Len(t, recorder.drawn(), start+1)
+}
+
+func TestIconCycle(t *testing.T) {
+	recorder := &iconRecorder{GuiProviderInterface: NewMockedGuiProvider()}
+	i := newTestIndicator(t, recorder)
+	defer i.Quit()
+	noConn, _ := iconData(IconThemeDefault, ColorSchemeLight, IconLiqoNoConn)
+	off, _ := iconData(IconThemeDefault, ColorSchemeLight, IconLiqoOff)
+	interval := 20 * time.Millisecond
+	start := len(recorder.drawn())
+	i.SetIconAnimation([]Icon{IconLiqoNoConn, IconLiqoNil, IconLiqoOff}, interval)
+	assert.Equal(t, IconLiqoNoConn, i.Icon())
+	frames, cycleInterval := i.IconAnimation()
+	assert.Equal(t, []Icon{IconLiqoNoConn, IconLiqoOff}, frames, "invalid Icon cycled")
+	assert.Equal(t, interval, cycleInterval)
+	assert.Eventually(t, func() bool {
+		return len(recorder.drawn()) >= start+4
+	}, 2*time.Second, 10*time.Millisecond)
+	drawn := recorder.drawn()[start:]
+	assert.Equal(t, [][]byte{noConn, off, noConn, off}, drawn[:4])
+	//the same cycle is not restarted
+	i.SetIconAnimation([]Icon{IconLiqoNoConn, IconLiqoOff}, interval)
+	//a switch of IconTheme restarts the cycle with the new images
+	i.SetIconTheme(IconThemeAccessible)
+	frames, _ = i.IconAnimation()
+	assert.Len(t, frames, 2, "cycle stopped by the IconTheme")
+	i.SetIconTheme(IconThemeDefault)
+	//SetIcon stops the cycle
+	i.SetIconAnimations(false)
+	i.SetIcon(IconLiqoMain)
+	frames, _ = i.IconAnimation()
+	assert.Nil(t, frames)
+	time.Sleep(3 * interval)
+	start = len(recorder.drawn())
+	time.Sleep(3 * interval)
+	assert.Len(t, recorder.drawn(), start, "cycle not stopped by SetIcon")
+	//without animations, only the first Icon is displayed
+	i.SetIconAnimation([]Icon{IconLiqoNoConn, IconLiqoOff}, interval)
+	assert.Len(t, recorder.drawn(), start+1)
+	frames, _ = i.IconAnimation()
+	assert.Nil(t, frames)
+	assert.Equal(t, IconLiqoNoConn, i.Icon())
+}
+
+func TestIconBadge(t *testing.T) {
+	recorder := &iconRecorder{GuiProviderInterface: NewMockedGuiProvider()}
+	i := newTestIndicator(t, recorder)
+	defer i.Quit()
+	i.SetIconAnimations(false)
+	main, _ := iconData(IconThemeDefault, ColorSchemeLight, IconLiqoMain)
+	i.SetIcon(IconLiqoMain)
+	assert.Equal(t, []string{"7", "42", "9+"}, []string{badgeText(7), badgeText(42), badgeText(150)})
+	//the count is not drawn until the badge is enabled
+	start := len(recorder.drawn())
+	i.setBadgeCount(3)
+	assert.Len(t, recorder.drawn(), start)
+	i.SetIconBadge(true)
+	drawn := recorder.drawn()
+	badged := drawn[len(drawn)-1]
+	assert.NotEqual(t, main, badged)
+	img, err := png.Decode(bytes.NewReader(badged))
+	if assert.NoError(t, err) {
+		//the disc is in the bottom right corner, while the top left one is untouched
+		bounds := img.Bounds()
+		radius := bounds.Dx() * 7 / 32
+		_, _, _, a := img.At(bounds.Max.X-radius-1, bounds.Max.Y-2).RGBA()
+		assert.NotZero(t, a, "badge missing")
+		original, _ := png.Decode(bytes.NewReader(main))
+		assert.Equal(t, original.At(0, 0), img.At(0, 0))
+	}
+	//the icons drawn afterwards get the badge too
+	i.SetIcon(IconLiqoGreen)
+	green, _ := iconData(IconThemeDefault, ColorSchemeLight, IconLiqoGreen)
+	drawn = recorder.drawn()
+	assert.NotEqual(t, green, drawn[len(drawn)-1])
+	//the badge is hidden without active peerings
+	i.setBadgeCount(0)
+	drawn = recorder.drawn()
+	assert.Equal(t, green, drawn[len(drawn)-1])
+	i.SetIconBadge(false)
+	assert.False(t, i.IconBadge())
+}
@@ -0,0 +1,153 @@
+package app_indicator
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+)
+
+/*This file contains the badge drawn on the tray icon, counting the active peerings. Some desktop environments do not
+display the label next to the tray icon (e.g. the StatusNotifierItem hosts ignoring the Ayatana label), so the
+number of active peerings can be composited onto the icon itself: the badge is a disc in the bottom right corner,
+rendered at runtime on each image drawn (including the frames of the animations) with a tiny bitmap font.*/
+
+var (
+	//badgeFill is the color of the badge disc.
+	badgeFill = color.NRGBA{R: 0x15, G: 0x65, B: 0xc0, A: 0xff}
+	//badgeInk is the color of the badge border and digits.
+	badgeInk = color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+//badgeGlyphs contains the 3x5 bitmaps of the characters of the badge, a row for each string.
+var badgeGlyphs = map[rune][5]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", ".##", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", ".#.", ".#.", ".#."},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+	'+': {"...", ".#.", "###", ".#.", "..."},
+}
+
+//SetIconBadge enables or disables the badge counting the active peerings on the tray icon. The badge is drawn also
+//when disabled if the GuiBackend cannot display the label (see CapabilityLabel).
+func (i *Indicator) SetIconBadge(enabled bool) {
+	gr := i.graphicResource[resourceIcon]
+	gr.Lock()
+	changed := i.iconBadge != enabled
+	i.iconBadge = enabled
+	gr.Unlock()
+	if changed {
+		i.redrawIcon()
+	}
+}
+
+//IconBadge returns whether the badge counting the active peerings is enabled.
+func (i *Indicator) IconBadge() bool {
+	gr := i.graphicResource[resourceIcon]
+	gr.RLock()
+	defer gr.RUnlock()
+	return i.iconBadge
+}
+
+//setBadgeCount sets the number displayed by the badge of the tray icon, redrawing it if needed. The badge is hidden
+//when count is 0.
+func (i *Indicator) setBadgeCount(count int) {
+	gr := i.graphicResource[resourceIcon]
+	gr.Lock()
+	changed := i.badgeCount != count
+	i.badgeCount = count
+	shown := i.badgeShown()
+	gr.Unlock()
+	if changed && shown {
+		i.redrawIcon()
+	}
+}
+
+//badgeShown returns whether the badge is drawn on the tray icon. The caller holds the resourceIcon lock.
+func (i *Indicator) badgeShown() bool {
+	return i.iconBadge || !i.gProvider.Supports(CapabilityLabel)
+}
+
+//drawIcon draws an image as the tray icon, adding the badge if shown. The caller holds the resourceIcon lock.
+func (i *Indicator) drawIcon(data []byte) {
+	if i.badgeCount > 0 && i.badgeShown() {
+		if badged, err := badgeIcon(data, i.badgeCount); err == nil {
+			data = badged
+		}
+	}
+	i.RunOnGui(func() {
+		i.gProvider.SetIcon(data)
+	})
+}
+
+//badgeText returns the text of the badge counting n items: the numbers above 99 are displayed as "9+".
+func badgeText(n int) string {
+	if n > 99 {
+		return "9+"
+	}
+	return strconv.Itoa(n)
+}
+
+//badgeIcon returns a PNG image with a badge displaying count in the bottom right corner.
+func badgeIcon(data []byte, count int) ([]byte, error) {
+	src, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	bounds := src.Bounds()
+	out := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			out.Set(x, y, src.At(x, y))
+		}
+	}
+	//the disc covers a bit less than half the icon side
+	radius := bounds.Dx() * 7 / 32
+	cx, cy := bounds.Max.X-radius-1, bounds.Max.Y-radius-1
+	for y := cy - radius; y <= cy+radius; y++ {
+		for x := cx - radius; x <= cx+radius; x++ {
+			switch d := (x-cx)*(x-cx) + (y-cy)*(y-cy); {
+			case d <= (radius-1)*(radius-1):
+				out.SetNRGBA(x, y, badgeFill)
+			case d <= radius*radius:
+				out.SetNRGBA(x, y, badgeInk)
+			}
+		}
+	}
+	text := []rune(badgeText(count))
+	//a single digit is drawn larger, while two characters fit the disc at the original size
+	scale := (2*radius - 2) / (4*len(text) + 1)
+	if scale < 1 {
+		scale = 1
+	}
+	width, height := (4*len(text)-1)*scale, 5*scale
+	left, top := cx-width/2, cy-height/2
+	for index, r := range text {
+		glyph := badgeGlyphs[r]
+		for row, line := range glyph {
+			for column, pixel := range line {
+				if pixel != '#' {
+					continue
+				}
+				x0, y0 := left+(4*index+column)*scale, top+row*scale
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						out.SetNRGBA(x0+dx, y0+dy, badgeInk)
+					}
+				}
+			}
+		}
+	}
+	buf := &bytes.Buffer{}
+	if err = png.Encode(buf, out); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
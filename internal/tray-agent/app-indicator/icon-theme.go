@@ -0,0 +1,131 @@
+package app_indicator
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/icon"
+)
+
+//IconTheme identifies a set of tray icons.
+type IconTheme string
+
+//IconTheme identifiers.
+const (
+	//IconThemeDefault is the default Liqo iconset, encoding the Agent state with colors.
+	IconThemeDefault IconTheme = "default"
+	//IconThemeAccessible is a colorblind-friendly iconset, encoding the Agent state with shapes
+	//(e.g. a check mark, a cross, a triangle) in addition to colors.
+	IconThemeAccessible IconTheme = "accessible"
+)
+
+//IconThemeDescriptions maps each IconTheme into its user-friendly description.
+var IconThemeDescriptions = map[IconTheme]string{
+	IconThemeDefault:    "Default (colors)",
+	IconThemeAccessible: "Colorblind-friendly (shapes and colors)",
+}
+
+//ParseIconTheme returns the IconTheme identified by name. It falls back to IconThemeDefault for an unknown name.
+func ParseIconTheme(name string) IconTheme {
+	if _, valid := IconThemeDescriptions[IconTheme(name)]; valid {
+		return IconTheme(name)
+	}
+	return IconThemeDefault
+}
+
+//iconThemes contains, for each IconTheme, the image of each Icon.
+var iconThemes = map[IconTheme]map[Icon][]byte{
+	IconThemeDefault: {
+		IconLiqoMain:    icon.LiqoMain,
+		IconLiqoNoConn:  icon.LiqoNoConn,
+		IconLiqoOff:     icon.LiqoOff,
+		IconLiqoWarning: icon.LiqoWarning,
+		IconLiqoOrange:  icon.LiqoOrange,
+		IconLiqoGreen:   icon.LiqoGreen,
+		IconLiqoPurple:  icon.LiqoPurple,
+		IconLiqoRed:     icon.LiqoRed,
+		IconLiqoYellow:  icon.LiqoYellow,
+		IconLiqoCyan:    icon.LiqoCyan,
+	},
+	IconThemeAccessible: {
+		IconLiqoMain:    icon.LiqoMainAccessible,
+		IconLiqoNoConn:  icon.LiqoNoConnAccessible,
+		IconLiqoOff:     icon.LiqoOffAccessible,
+		IconLiqoWarning: icon.LiqoWarningAccessible,
+		IconLiqoOrange:  icon.LiqoOrangeAccessible,
+		IconLiqoGreen:   icon.LiqoGreenAccessible,
+		IconLiqoPurple:  icon.LiqoPurpleAccessible,
+		IconLiqoRed:     icon.LiqoRedAccessible,
+		IconLiqoYellow:  icon.LiqoYellowAccessible,
+		IconLiqoCyan:    icon.LiqoCyanAccessible,
+	},
+}
+
+//darkIconThemes contains, for each IconTheme, the image of each Icon drawn for the dark desktop themes, with a
+//light outline (see ColorScheme).
+var darkIconThemes = map[IconTheme]map[Icon][]byte{
+	IconThemeDefault: {
+		IconLiqoMain:    icon.LiqoMainDark,
+		IconLiqoNoConn:  icon.LiqoNoConnDark,
+		IconLiqoOff:     icon.LiqoOffDark,
+		IconLiqoWarning: icon.LiqoWarningDark,
+		IconLiqoOrange:  icon.LiqoOrangeDark,
+		IconLiqoGreen:   icon.LiqoGreenDark,
+		IconLiqoPurple:  icon.LiqoPurpleDark,
+		IconLiqoRed:     icon.LiqoRedDark,
+		IconLiqoYellow:  icon.LiqoYellowDark,
+		IconLiqoCyan:    icon.LiqoCyanDark,
+	},
+	IconThemeAccessible: {
+		IconLiqoMain:    icon.LiqoMainAccessibleDark,
+		IconLiqoNoConn:  icon.LiqoNoConnAccessibleDark,
+		IconLiqoOff:     icon.LiqoOffAccessibleDark,
+		IconLiqoWarning: icon.LiqoWarningAccessibleDark,
+		IconLiqoOrange:  icon.LiqoOrangeAccessibleDark,
+		IconLiqoGreen:   icon.LiqoGreenAccessibleDark,
+		IconLiqoPurple:  icon.LiqoPurpleAccessibleDark,
+		IconLiqoRed:     icon.LiqoRedAccessibleDark,
+		IconLiqoYellow:  icon.LiqoYellowAccessibleDark,
+		IconLiqoCyan:    icon.LiqoCyanAccessibleDark,
+	},
+}
+
+//iconData returns the image of an Icon in a specific IconTheme, drawn for a ColorScheme. If the theme is unknown,
+//IconThemeDefault is used.
+func iconData(theme IconTheme, scheme ColorScheme, ico Icon) (data []byte, valid bool) {
+	themes := iconThemes
+	if scheme == ColorSchemeDark {
+		themes = darkIconThemes
+	}
+	set, present := themes[theme]
+	if !present {
+		set = themes[IconThemeDefault]
+	}
+	data, valid = set[ico]
+	return
+}
+
+//IconTheme returns the IconTheme currently used to draw the tray icon.
+func (i *Indicator) IconTheme() IconTheme {
+	gr := i.graphicResource[resourceIcon]
+	gr.RLock()
+	defer gr.RUnlock()
+	return i.iconTheme
+}
+
+//IconData returns the image of an Icon drawn with the current IconTheme and ColorScheme, e.g. to decorate a
+//MenuNode (see MenuNode.SetIcon). It returns nil for an unknown Icon.
+func (i *Indicator) IconData(ico Icon) []byte {
+	scheme := i.ColorScheme()
+	data, valid := iconData(i.IconTheme(), scheme, ico)
+	if !valid {
+		return nil
+	}
+	return data
+}
+
+//SetIconTheme sets the IconTheme used to draw the tray icon, redrawing the current one.
+func (i *Indicator) SetIconTheme(theme IconTheme) {
+	gr := i.graphicResource[resourceIcon]
+	gr.Lock()
+	i.iconTheme = theme
+	gr.Unlock()
+	i.redrawIcon()
+}
@@ -1,10 +1,15 @@
 package app_indicator
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
-	"github.com/liqotech/liqo-agent/internal/tray-agent/icon"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/logging"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/metrics"
+	"strings"
 	"sync"
+	"time"
 )
 
 //standard width of an item in the tray menu
@@ -45,6 +50,9 @@ func Run(onReady func(), onExit func()) {
 	GetGuiProvider().Run(onReady, onExit)
 }
 
+//logger writes the log entries of the Indicator.
+var logger = logging.New("indicator")
+
 //Indicator singleton
 var root *Indicator
 
@@ -57,6 +65,25 @@ type Indicator struct {
 	label string
 	//indicator icon-id
 	icon Icon
+	//iconTheme is the IconTheme used to draw the tray icon.
+	iconTheme IconTheme
+	//colorSchemeSetting is the configured ColorScheme, possibly ColorSchemeAuto.
+	colorSchemeSetting ColorScheme
+	//colorScheme is the ColorScheme the tray icon is drawn for, either ColorSchemeLight or ColorSchemeDark.
+	colorScheme ColorScheme
+	//colorSchemeStop stops following the color scheme of the desktop, if followed (see SetColorScheme).
+	colorSchemeStop func()
+	//iconAnimations specifies whether the changes of the tray icon are animated (see IconTransition).
+	iconAnimations bool
+	//iconGeneration is incremented at each change of the tray icon, interrupting the running animation.
+	iconGeneration int
+	//iconCycle contains the Icons cycled by the running SetIconAnimation, displayed for iconCycleInterval each.
+	iconCycle         []Icon
+	iconCycleInterval time.Duration
+	//iconBadge specifies whether the badge counting the active peerings is drawn on the tray icon.
+	iconBadge bool
+	//badgeCount is the number displayed by the badge.
+	badgeCount int
 	//TITLE MenuNode used by the indicator to show the menu header
 	menuTitleNode *MenuNode
 	//title text currently in use
@@ -65,63 +92,369 @@ type Indicator struct {
 	menuStatusNode *MenuNode
 	//map that stores QUICK MenuNodes, associating them with their tag
 	quickMap map[string]*MenuNode
+	//quickMapMutex protects quickMap.
+	quickMapMutex sync.RWMutex
 	//reference to the node of the ACTION currently selected. If none, it defaults to the ROOT node
 	activeNode *MenuNode
 	//data struct containing indicator config
 	config *config
 	//guiProvider to interact with the graphic server
 	gProvider GuiProviderInterface
+	//gui serializes the changes to the GUI (see RunOnGui).
+	gui guiQueue
 	//data struct containing Liqo Status, used to control the menuStatusNode
 	status StatusInterface
-	//controller of all the application goroutines
-	quitChan chan struct{}
-	//if true, quitChan is closed and Indicator can gracefully exit
-	quitClosed bool
+	//ctx is the context of all the goroutines of the Indicator, cancelled by Disconnect.
+	ctx    context.Context
+	cancel context.CancelFunc
+	//routines tracks the goroutines of the Indicator (see goTracked), awaited by Wait.
+	routines sync.WaitGroup
+	//closing specifies whether ctx has been cancelled: no further goroutine is started.
+	closing bool
+	//keepCaches specifies whether the caches of the AgentController are left running at exit, since handed to the
+	//Indicator replacing this one (see Reset).
+	keepCaches bool
+	//routinesMutex protects closing and keepCaches.
+	routinesMutex sync.Mutex
+	//quitOnce ensures the GUI runtime is quit once.
+	quitOnce sync.Once
 	//data struct that controls Agent interaction with the cluster
 	agentCtrl *client.AgentController
 	//map of all the instantiated Listeners
-	listeners map[client.NotifyChannel]*Listener
+	listeners map[listenerKey]*Listener
+	//listenersMutex protects listeners.
+	listenersMutex sync.RWMutex
 	//map of all the instantiated Timers
 	timers map[string]*Timer
+	//timersMutex protects timers.
+	timersMutex sync.RWMutex
+	//pending collects the items awaiting an input of the user.
+	pending *PendingRegistry
+	//labelMode is the LabelMode of the tray label.
+	labelMode LabelMode
+	//labelFormat is the custom format of the tray label, overriding labelMode if set.
+	labelFormat labelFormat
+	//usageTrend contains the samples of the CPU acquired from the peers, displayed in LabelModeTrend.
+	usageTrend *TrendBuffer
+	//readOnly specifies whether the write actions are disabled (see SetReadOnly).
+	readOnly bool
+	//nodes contains all the MenuNodes of the Indicator, rendered again when the language changes.
+	nodes []*MenuNode
+	//topNodes contains the level-0 MenuNodes in display order (see (*MenuNode).MoveBefore).
+	topNodes []*MenuNode
+	//nodesMutex protects nodes, topNodes and the children of the MenuNodes.
+	nodesMutex sync.Mutex
+	//moveMutex serializes the moves of the MenuNodes.
+	moveMutex sync.Mutex
+	//sectionMap contains the SECTIONs of the menu, associated with their tag.
+	sectionMap map[string]*MenuNode
+	//openSection is the SECTION grouping the level-0 MenuNodes being added, if any (see AddSection).
+	openSection *MenuNode
+	//separated specifies whether the last entry of the menu is a separator.
+	separated bool
+	//writeNodes contains the MenuNodes performing write actions.
+	writeNodes map[*MenuNode]bool
+	//readOnlyMutex protects readOnly and writeNodes.
+	readOnlyMutex sync.RWMutex
+	//clickGuard is the time window during which the repeated clicks on a MenuNode are ignored (see SetClickGuard).
+	clickGuard time.Duration
+	//clickGuardMutex protects clickGuard.
+	clickGuardMutex sync.RWMutex
+	//notifications contains the active Notifications with an ID, indexed by ID.
+	notifications map[string]Notification
+	//bannerIDs contains the IDs assigned by the actionNotifier to the banners of the active Notifications,
+	//indexed by Notification ID.
+	bannerIDs map[string]uint32
+	//notificationsMutex protects notifications and bannerIDs.
+	notificationsMutex sync.RWMutex
+	//actionNotifier displays the banners with action buttons, if available (see getActionNotifier).
+	actionNotifier     actionNotifier
+	actionNotifierOnce sync.Once
+	//sessionLocked specifies whether the desktop session is locked (see SetSessionLocked).
+	sessionLocked bool
+	//deferredBanners contains the Notifications raised while the session is locked, delivered when it is unlocked.
+	deferredBanners []Notification
+	//droppedBanners is the number of deferred Notifications exceeding maxDeferredBanners.
+	droppedBanners int
+	//sessionLockStop stops observing the lock state of the session, if observed (see WatchSessionLock).
+	//sessionLocked, deferredBanners, droppedBanners and sessionLockStop are protected by the resourceDesktop lock.
+	sessionLockStop func()
+	//hotkeys contains the global Hotkeys bound by SetHotkeys.
+	hotkeys []Hotkey
+	//hotkeysStop releases the grabbed Hotkeys, if any. hotkeys and hotkeysStop are protected by the resourceDesktop
+	//lock.
+	hotkeysStop func()
+	//clock provides the current time.
+	clock Clock
+	//progress contains the operations displayed by ShowProgress, indexed by tag.
+	progress map[string]*Progress
+	//progressFree contains the entries of the completed operations, reused by the next ones.
+	progressFree []*MenuNode
+	//progressMutex protects progress and progressFree.
+	progressMutex sync.Mutex
+	//refresher collects the refresh requests of the STATUS MenuNode and of the label.
+	refresher refresher
 	//graphicResource is the map containing the mutex to protect access to the graphic resources handled by the Indicator
 	//(e.g. tray icon, tray label and desktop notifications).
 	graphicResource map[graphicResource]*sync.RWMutex
 }
 
-//GetIndicator initializes and returns the Indicator singleton. This function should not be called before Run().
+//Clock provides the current time to an Indicator, e.g. to timestamp the clicks and the notifications.
+type Clock interface {
+	Now() time.Time
+}
+
+//systemClock is the Clock reading the system time.
+type systemClock struct{}
+
+//Now implements the Clock interface.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+//SystemClock is the Clock reading the system time, used by default.
+var SystemClock Clock = systemClock{}
+
+//indicatorOptions contains the dependencies of an Indicator created by NewIndicator. The unset ones default to
+//the singletons used by the Agent.
+type indicatorOptions struct {
+	//guiProvider displays the tray icon and its menu. It defaults to GetGuiProvider().
+	guiProvider GuiProviderInterface
+	//agentController interacts with the cluster. It defaults to client.GetAgentController().
+	agentController *client.AgentController
+	//status contains the status of Liqo displayed by the menu. It defaults to GetStatus().
+	status StatusInterface
+	//localConfig contains the settings of the Agent. It defaults to the ones loaded from the
+	//client.ConfigFileName file.
+	localConfig *client.LocalConfiguration
+	//clock provides the current time. It defaults to SystemClock.
+	clock Clock
+}
+
+//IndicatorOption sets a dependency of an Indicator created by NewIndicator. It returns an error if the
+//dependency cannot be provided.
+type IndicatorOption func(opts *indicatorOptions) error
+
+//WithGuiProvider sets the GuiProvider displaying the tray icon and its menu, e.g. a NewMockedGuiProvider.
+func WithGuiProvider(provider GuiProviderInterface) IndicatorOption {
+	return func(opts *indicatorOptions) error {
+		if provider == nil {
+			return errors.New("nil GuiProvider")
+		}
+		opts.guiProvider = provider
+		return nil
+	}
+}
+
+//WithAgentController sets the AgentController interacting with the cluster.
+func WithAgentController(ctrl *client.AgentController) IndicatorOption {
+	return func(opts *indicatorOptions) error {
+		if ctrl == nil {
+			return errors.New("nil AgentController")
+		}
+		opts.agentController = ctrl
+		return nil
+	}
+}
+
+//WithStatus sets the status of Liqo displayed by the menu, e.g. a NewStatus.
+func WithStatus(status StatusInterface) IndicatorOption {
+	return func(opts *indicatorOptions) error {
+		if status == nil {
+			return errors.New("nil Status")
+		}
+		opts.status = status
+		return nil
+	}
+}
+
+//WithLocalConfig sets the settings of the Agent.
+func WithLocalConfig(conf *client.LocalConfiguration) IndicatorOption {
+	return func(opts *indicatorOptions) error {
+		if conf == nil {
+			return errors.New("nil LocalConfiguration")
+		}
+		opts.localConfig = conf
+		return nil
+	}
+}
+
+//WithConfigPath sets the settings of the Agent to the ones read from the client.ConfigFileName file in dir
+//(see client.ReadLocalConfig). It fails if the file cannot be read or decoded, while the invalid settings are
+//dropped and logged.
+func WithConfigPath(dir string) IndicatorOption {
+	return func(opts *indicatorOptions) error {
+		conf, err := client.ReadLocalConfig(dir)
+		if conf == nil {
+			return fmt.Errorf("cannot load the configuration from %s: %w", dir, err)
+		}
+		if err != nil {
+			logger.Warning("invalid settings dropped", "dir", dir, "err", err)
+		}
+		opts.localConfig = conf
+		return nil
+	}
+}
+
+//WithClock sets the Clock providing the current time.
+func WithClock(clock Clock) IndicatorOption {
+	return func(opts *indicatorOptions) error {
+		if clock == nil {
+			return errors.New("nil Clock")
+		}
+		opts.clock = clock
+		return nil
+	}
+}
+
+//rootMutex protects the Indicator singleton.
+var rootMutex sync.Mutex
+
+//GetIndicator initializes and returns the Indicator singleton, wired to the singletons of the Agent (see
+//NewIndicator). This function should not be called before Run().
 func GetIndicator() *Indicator {
+	rootMutex.Lock()
+	defer rootMutex.Unlock()
 	if root == nil {
-		root = &Indicator{
-			quickMap:        make(map[string]*MenuNode),
-			quitChan:        make(chan struct{}),
-			listeners:       make(map[client.NotifyChannel]*Listener),
-			timers:          make(map[string]*Timer),
-			graphicResource: make(map[graphicResource]*sync.RWMutex),
+		i, err := NewIndicator()
+		if err != nil {
+			//the default dependencies are always available
+			panic(err.Error())
 		}
-		root.graphicResource[resourceIcon] = &sync.RWMutex{}
-		root.graphicResource[resourceLabel] = &sync.RWMutex{}
-		root.graphicResource[resourceDesktop] = &sync.RWMutex{}
-		root.gProvider = GetGuiProvider()
-		root.SetIcon(IconLiqoNoConn)
-		root.SetLabel("")
-		root.menuTitleNode = newMenuNode(NodeTypeTitle, false, nil)
-		root.menu = newMenuNode(NodeTypeRoot, false, nil)
-		root.activeNode = root.menu
-		root.menuStatusNode = newMenuNode(NodeTypeStatus, false, nil)
-		root.config = newConfig()
-		root.status = GetStatus()
-		root.RefreshStatus()
+		root = i
+	}
+	return root
+}
+
+//Reset replaces the Indicator singleton with a new one created with the given options (see NewIndicator), e.g. to
+//wire a different GuiProvider or configuration. If the creation fails, the current singleton is kept and the error
+//is returned. Otherwise, the event handlers of the replaced Indicator are stopped and its entries are removed from
+//the menu.
+func Reset(opts ...IndicatorOption) (*Indicator, error) {
+	i, err := NewIndicator(opts...)
+	if err != nil {
+		return nil, err
+	}
+	rootMutex.Lock()
+	old := root
+	root = i
+	rootMutex.Unlock()
+	if old != nil {
+		old.retire(i)
+	}
+	return i, nil
+}
+
+//retire stops the event handlers of an Indicator replaced by Reset with next and hides its entries, leaving the GUI
+//runtime running for next.
+func (i *Indicator) retire(next *Indicator) {
+	i.routinesMutex.Lock()
+	i.keepCaches = i.agentCtrl == next.agentCtrl
+	i.routinesMutex.Unlock()
+	i.Disconnect()
+	i.FlushRefresh()
+	i.nodesMutex.Lock()
+	nodes := append([]*MenuNode{i.menuTitleNode, i.menuStatusNode}, i.topNodes...)
+	i.nodesMutex.Unlock()
+	for _, n := range nodes {
+		n.SetIsVisible(false)
+	}
+}
+
+//NewIndicator creates an Indicator with the dependencies set by the given options, defaulting to the singletons
+//used by the Agent. Unlike GetIndicator, it returns an independent Indicator at each call: e.g. the tests can run
+//isolated Indicators in parallel, each one with its own mocked GuiProvider (see NewMockedGuiProvider), Status
+//(see NewStatus) and Clock. It fails if an option cannot provide its dependency.
+func NewIndicator(options ...IndicatorOption) (*Indicator, error) {
+	defer metrics.GetRegistry().Timer("indicator_creation_seconds", "Duration of the creation of the Indicator",
+		nil).ObserveSince(time.Now())
+	opts := indicatorOptions{}
+	for _, option := range options {
+		if err := option(&opts); err != nil {
+			return nil, err
+		}
+	}
+	if opts.guiProvider == nil {
+		opts.guiProvider = GetGuiProvider()
+	}
+	if opts.status == nil {
+		opts.status = GetStatus()
+	}
+	if opts.localConfig == nil {
 		client.LoadLocalConfig()
-		root.agentCtrl = client.GetAgentController()
-		if !root.agentCtrl.Connected() {
-			root.ShowErrorNoConnection()
-		} else if !root.agentCtrl.ValidConfiguration() {
-			root.ShowError("LIQO AGENT - FATAL", "Agent could not retrieve configuration data.")
-		} else {
-			root.SetIcon(IconLiqoMain)
+		opts.localConfig, _ = client.GetLocalConfig()
+	}
+	if opts.clock == nil {
+		opts.clock = SystemClock
+	}
+	i := &Indicator{
+		quickMap:        make(map[string]*MenuNode),
+		sectionMap:      make(map[string]*MenuNode),
+		listeners:       make(map[listenerKey]*Listener),
+		timers:          make(map[string]*Timer),
+		pending:         newPendingRegistry(),
+		usageTrend:      NewTrendBuffer(DefaultTrendWindow),
+		writeNodes:      make(map[*MenuNode]bool),
+		clickGuard:      DefaultClickGuard,
+		notifications:   make(map[string]Notification),
+		bannerIDs:       make(map[string]uint32),
+		progress:        make(map[string]*Progress),
+		clock:           opts.clock,
+		graphicResource: make(map[graphicResource]*sync.RWMutex),
+	}
+	i.ctx, i.cancel = context.WithCancel(context.Background())
+	i.graphicResource[resourceIcon] = &sync.RWMutex{}
+	i.graphicResource[resourceLabel] = &sync.RWMutex{}
+	i.graphicResource[resourceDesktop] = &sync.RWMutex{}
+	i.gProvider = opts.guiProvider
+	//the mocked Indicators refresh immediately, unless a refresh interval is set
+	if !i.gProvider.Mocked() {
+		i.refresher.interval = DefaultRefreshInterval
+	}
+	i.SetIcon(IconLiqoNoConn)
+	i.SetLabel("")
+	i.menuTitleNode = newMenuNode(i, NodeTypeTitle, false, nil)
+	i.menu = newMenuNode(i, NodeTypeRoot, false, nil)
+	i.activeNode = i.menu
+	i.menuStatusNode = newMenuNode(i, NodeTypeStatus, false, nil)
+	i.config = newConfig()
+	i.status = opts.status
+	i.pending.OnChange(i.RefreshLabel)
+	i.RefreshStatus()
+	conf := opts.localConfig
+	i.SetIconTheme(ParseIconTheme(conf.GetIconTheme()))
+	i.SetColorScheme(ParseColorScheme(conf.GetColorScheme()))
+	i.SetIconBadge(conf.GetIconBadge())
+	i.SetIconAnimations(!conf.GetDisableIconAnimations())
+	i.labelMode = ParseLabelMode(conf.GetLabelMode())
+	i.labelFormat = labelFormat{format: conf.GetLabelFormat(), always: conf.GetLabelAlways()}
+	if opts.agentController == nil {
+		opts.agentController = client.GetAgentController()
+	}
+	i.agentCtrl = opts.agentController
+	//the caches of the AgentController are stopped with the Indicator, unless handed to the one replacing it
+	i.goTracked(func() {
+		<-i.ctx.Done()
+		i.routinesMutex.Lock()
+		keep := i.keepCaches
+		i.routinesMutex.Unlock()
+		if !keep && i.agentCtrl.Connected() {
+			i.agentCtrl.StopCaches()
 		}
+	})
+	if !i.agentCtrl.Connected() {
+		i.ShowErrorNoConnection()
+	} else if !i.agentCtrl.ValidConfiguration() {
+		i.ShowError("LIQO AGENT - FATAL", "Agent could not retrieve configuration data.")
+	} else {
+		i.SetIcon(IconLiqoMain)
 	}
-	return root
+	return i, nil
+}
+
+//Now returns the current time according to the Clock of the Indicator.
+func (i *Indicator) Now() time.Time {
+	return i.clock.Now()
 }
 
 //-----ACTIONS-----
@@ -132,21 +465,32 @@ func GetIndicator() *Indicator {
 //
 //	tag : unique tag for the ACTION
 //
-//	callback : callback function to be executed at each 'clicked' event. If callback == nil, the function can be set
-//	afterwards using (*Indicator).Connect() .
-func (i *Indicator) AddAction(title string, tag string, callback func(args ...interface{}), args ...interface{}) *MenuNode {
-	a := newMenuNode(NodeTypeAction, false, nil)
+//	handler : handler of the 'clicked' events. If handler == nil, it can be set
+//	afterwards using (*MenuNode).Connect() .
+func (i *Indicator) AddAction(title string, tag string, handler ClickHandler) *MenuNode {
+	a := newMenuNode(i, NodeTypeAction, false, nil)
 	a.parent = i.menu
 	a.SetTitle(title)
 	a.SetTag(tag)
-	if callback != nil {
-		a.Connect(false, callback, args...)
+	if handler != nil {
+		a.Connect(false, handler)
 	}
 	a.SetIsVisible(true)
 	i.menu.actionMap[tag] = a
 	return a
 }
 
+//AddSubmenu adds a SUBMENU to the top level of the indicator menu, expanding into a nested menu (see
+//(*MenuNode).AddSubmenu). It is visible by default.
+func (i *Indicator) AddSubmenu(title string, tag string) *MenuNode {
+	return i.menu.AddSubmenu(title, tag)
+}
+
+//Submenu returns the *MenuNode of the top level SUBMENU with this specific tag. If not present, present = false.
+func (i *Indicator) Submenu(tag string) (sub *MenuNode, present bool) {
+	return i.menu.Submenu(tag)
+}
+
 //Action returns the *MenuNode of the ACTION with this specific tag. If not present, present = false
 func (i *Indicator) Action(tag string) (act *MenuNode, present bool) {
 	act, present = i.menu.actionMap[tag]
@@ -159,18 +503,20 @@ func (i *Indicator) Action(tag string) (act *MenuNode, present bool) {
 //
 //	tag : unique tag for the QUICK
 //
-//	callback : callback function to be executed at each 'clicked' event. If callback == nil, the function can be set
+//	handler : handler of the 'clicked' events. If handler == nil, it can be set
 //	afterwards using (*MenuNode).Connect() .
-func (i *Indicator) AddQuick(title string, tag string, callback func(args ...interface{}), args ...interface{}) *MenuNode {
-	q := newMenuNode(NodeTypeQuick, false, nil)
+func (i *Indicator) AddQuick(title string, tag string, handler ClickHandler) *MenuNode {
+	q := newMenuNode(i, NodeTypeQuick, false, nil)
 	q.parent = q
 	q.SetTitle(title)
 	q.SetTag(tag)
-	if callback != nil {
-		q.Connect(false, callback, args...)
+	if handler != nil {
+		q.Connect(false, handler)
 	}
 	q.SetIsVisible(true)
+	i.quickMapMutex.Lock()
 	i.quickMap[tag] = q
+	i.quickMapMutex.Unlock()
 	return q
 }
 
@@ -178,6 +524,8 @@ func (i *Indicator) AddQuick(title string, tag string, callback func(args ...int
 
 //Quick returns the *MenuNode of the QUICK with this specific tag. If such QUICK does not exist, present == false.
 func (i *Indicator) Quick(tag string) (quick *MenuNode, present bool) {
+	i.quickMapMutex.RLock()
+	defer i.quickMapMutex.RUnlock()
 	quick, present = i.quickMap[tag]
 	return
 }
@@ -186,14 +534,31 @@ func (i *Indicator) Quick(tag string) (quick *MenuNode, present bool) {
 
 //AddSeparator adds a separator line to the indicator menu
 func (i *Indicator) AddSeparator() {
-	i.gProvider.AddSeparator()
+	i.nodesMutex.Lock()
+	i.separated = true
+	i.nodesMutex.Unlock()
+	i.RunOnGui(i.gProvider.AddSeparator)
 }
 
 //SetMenuTitle sets the text content of the TITLE MenuNode, displayed as the menu header.
 func (i *Indicator) SetMenuTitle(title string) {
-	i.menuTitleNode.SetTitle(title)
-	i.menuTitleNode.SetIsVisible(true)
 	i.menuTitleText = title
+	i.renderMenuTitle(i.Label())
+	i.menuTitleNode.SetIsVisible(true)
+}
+
+//renderMenuTitle displays the menu title. If the GuiBackend cannot display the label next to the tray icon, its
+//content is appended to the title.
+func (i *Indicator) renderMenuTitle(label string) {
+	title := i.menuTitleText
+	if label != "" && !i.gProvider.Supports(CapabilityLabel) {
+		if title != "" {
+			title += " · "
+		}
+		title += label
+		i.menuTitleNode.SetIsVisible(true)
+	}
+	i.menuTitleNode.SetTitle(title)
 }
 
 //Icon returns the icon-id of the Indicator tray icon currently set.
@@ -204,41 +569,33 @@ func (i *Indicator) Icon() Icon {
 	return i.icon
 }
 
-//SetIcon sets the Indicator tray icon. If 'ico' is not a valid argument or ico == IconLiqoNil,
-//SetIcon does nothing.
+//SetIcon sets the Indicator tray icon, drawn according to the current IconTheme and ColorScheme. If the icon animations are
+//enabled, the change is animated according to its IconTransition. It stops the cycle started by SetIconAnimation.
+//If 'ico' is not a valid argument or ico == IconLiqoNil, SetIcon does nothing.
 func (i *Indicator) SetIcon(ico Icon) {
 	gr := i.graphicResource[resourceIcon]
-	var newIcon []byte
-	switch ico {
-	case IconLiqoNil:
-		return
-	case IconLiqoMain:
-		newIcon = icon.LiqoMain
-	case IconLiqoOff:
-		newIcon = icon.LiqoOff
-	case IconLiqoNoConn:
-		newIcon = icon.LiqoNoConn
-	case IconLiqoWarning:
-		newIcon = icon.LiqoWarning
-	case IconLiqoOrange:
-		newIcon = icon.LiqoOrange
-	case IconLiqoGreen:
-		newIcon = icon.LiqoGreen
-	case IconLiqoPurple:
-		newIcon = icon.LiqoPurple
-	case IconLiqoRed:
-		newIcon = icon.LiqoRed
-	case IconLiqoYellow:
-		newIcon = icon.LiqoYellow
-	case IconLiqoCyan:
-		newIcon = icon.LiqoCyan
-	default:
-		return
-	}
 	gr.Lock()
 	defer gr.Unlock()
-	i.gProvider.SetIcon(newIcon)
+	newIcon, valid := iconData(i.iconTheme, i.colorScheme, ico)
+	if !valid {
+		return
+	}
+	i.iconGeneration++
+	i.iconCycle = nil
+	var frames []iconFrame
+	if i.iconAnimations {
+		oldIcon, _ := iconData(i.iconTheme, i.colorScheme, i.icon)
+		frames = transitionFrames(iconTransition(i.icon, ico), oldIcon, newIcon)
+	}
 	i.icon = ico
+	if len(frames) == 0 {
+		i.drawIcon(newIcon)
+		return
+	}
+	generation := i.iconGeneration
+	i.goTracked(func() {
+		i.animateIcon(generation, frames, newIcon)
+	})
 }
 
 //Label returns the text content of Indicator tray label.
@@ -255,43 +612,120 @@ func (i *Indicator) SetLabel(label string) {
 	gr.Lock()
 	defer gr.Unlock()
 	i.label = label
-	i.gProvider.SetTitle(label)
+	if i.gProvider.Supports(CapabilityLabel) {
+		i.RunOnGui(func() {
+			i.gProvider.SetTitle(label)
+		})
+	} else if i.menuTitleNode != nil {
+		i.renderMenuTitle(label)
+	}
 }
 
-//RefreshLabel updates the content of the Indicator label
-//with the total number of both incoming and outgoing peerings currently active.
-func (i *Indicator) RefreshLabel() {
+//renderLabel updates the content of the Indicator label (see RefreshLabel).
+func (i *Indicator) renderLabel() {
 	st := i.Status()
 	in := st.Peerings(PeeringIncoming)
 	out := st.Peerings(PeeringOutgoing)
 	//since the label is graphically invasive, its content is displayed only when
-	//there is at least one active peering
-	if st.Running() && (in > 0 || out > 0) {
-		i.SetLabel(fmt.Sprintf("(IN:%d/OUT:%d)", in, out))
-		return
+	//there is at least one active peering or pending item, unless differently configured
+	var parts []string
+	formatted, custom := i.formattedLabel()
+	trend := ""
+	if !custom && i.LabelMode() == LabelModeTrend {
+		trend = i.trendLabel()
 	}
-	i.SetLabel("")
+	if custom {
+		if formatted != "" {
+			parts = append(parts, formatted)
+		}
+	} else if trend != "" && st.Running() {
+		parts = append(parts, trend)
+	} else if st.Running() && (in > 0 || out > 0) {
+		parts = append(parts, fmt.Sprintf("(IN:%d/OUT:%d)", in, out))
+	}
+	//the badge of the tray icon counts the same peerings, if drawn
+	badge := 0
+	if st.Running() {
+		badge = in + out
+	}
+	i.setBadgeCount(badge)
+	if pending := i.pending.Len(); pending > 0 {
+		parts = append(parts, fmt.Sprintf("%s%d", pendingBadge, pending))
+	}
+	i.SetLabel(strings.Join(parts, " "))
 }
 
+//pendingBadge precedes, in the Indicator label, the number of items awaiting an input of the user.
+const pendingBadge = "⚑"
+
 //--------------
 
-//Quit stops the indicator execution.
+//QuitTimeout is the maximum time Quit waits for the goroutines of the Indicator to finish before exiting the GUI
+//runtime.
+const QuitTimeout = 5 * time.Second
+
+//Quit stops the indicator execution: the context of the Indicator is cancelled, stopping its Listeners, Timers,
+//event handlers and the caches of its AgentController. Then, once they finished (or after QuitTimeout), the GUI
+//runtime exits. Quit does not wait for them itself, so that it can be called by an event handler.
 func (i *Indicator) Quit() {
-	if i != nil {
-		i.Disconnect()
-		if i.agentCtrl.Connected() {
-			i.agentCtrl.StopCaches()
-		}
-	}
-	i.gProvider.Quit()
+	i.Disconnect()
+	i.FlushRefresh()
+	i.quitOnce.Do(func() {
+		go func() {
+			if !i.Wait(QuitTimeout) {
+				logger.Warning("quitting while some goroutines are still running", "timeout", QuitTimeout)
+			}
+			i.gProvider.Quit()
+		}()
+	})
 }
 
-//Disconnect exits all the event handlers associated with any Indicator MenuNode via the Connect() method.
+//Disconnect cancels the context of the Indicator (see Context), exiting all its goroutines: the Listeners, the
+//Timers and the event handlers associated with any Indicator MenuNode via the Connect() method.
 func (i *Indicator) Disconnect() {
-	if !i.quitClosed {
-		close(i.quitChan)
-		i.quitClosed = true
+	i.routinesMutex.Lock()
+	i.closing = true
+	i.routinesMutex.Unlock()
+	i.cancel()
+}
+
+//Context returns the context of the Indicator, done once it quits: the operations started by the event handlers
+//should be bound to it, so that they are cancelled at exit.
+func (i *Indicator) Context() context.Context {
+	return i.ctx
+}
+
+//Wait waits, up to timeout, for the goroutines of the Indicator to finish after Disconnect (or Quit) has been
+//called. It returns whether they finished.
+func (i *Indicator) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		i.routines.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+//goTracked runs f in a goroutine awaited by Wait, unless the Indicator is quitting. It returns whether f has been
+//started.
+func (i *Indicator) goTracked(f func()) bool {
+	i.routinesMutex.Lock()
+	if i.closing {
+		i.routinesMutex.Unlock()
+		return false
 	}
+	i.routines.Add(1)
+	i.routinesMutex.Unlock()
+	go func() {
+		defer i.routines.Done()
+		f()
+	}()
+	return true
 }
 
 //AgentCtrl returns the Indicator AgentController that interacts with the cluster.
@@ -1,9 +1,16 @@
 package app_indicator
 
 import (
+	"context"
+	"fmt"
 	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/i18n"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 // test Indicator startup configuration and basic methods
@@ -17,7 +24,7 @@ func TestGetIndicator(t *testing.T) {
 	assert.NotNil(t, i.menu, "root MenuNode not instantiated")
 	assert.NotNil(t, i.quickMap, "root quickMap not instantiated")
 	assert.NotNil(t, i.Config(), "root config obj not instantiated")
-	assert.NotNil(t, i.quitChan, "root quitChan not instantiated")
+	assert.NotNil(t, i.Context(), "root context not instantiated")
 	assert.NotNil(t, i.listeners, "root listeners not instantiated")
 	if assert.NotNil(t, i.AgentCtrl(), "root agentCtrl obj not instantiated") {
 		if i.agentCtrl.Connected() {
@@ -34,6 +41,138 @@ func TestGetIndicator(t *testing.T) {
 	i.Quit()
 }
 
+//fixedClock is a Clock always returning the same instant.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+//newTestIndicator creates an isolated Indicator displayed by provider, with a disconnected AgentController, its own
+//Status and an empty configuration, unless overridden by opts.
+func newTestIndicator(t *testing.T, provider GuiProviderInterface, opts ...IndicatorOption) *Indicator {
+	i, err := NewIndicator(append([]IndicatorOption{WithGuiProvider(provider),
+		WithAgentController(&client.AgentController{}), WithStatus(NewStatus()),
+		WithLocalConfig(&client.LocalConfiguration{})}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return i
+}
+
+func TestNewIndicator(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	//the Indicators are independent of each other, so that they can be tested in parallel
+	for _, name := range []string{"first", "second"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			i := newTestIndicator(t, NewMockedGuiProvider(), WithClock(fixedClock(now)))
+			defer i.Quit()
+			assert.NotSame(t, GetStatus(), i.Status())
+			assert.Equal(t, now, i.Now())
+			assert.Equal(t, IconLiqoNoConn, i.Icon())
+			quick := i.AddQuick(name, name, nil)
+			quick.SetWriteAction(true)
+			i.SetReadOnly(true)
+			assert.False(t, quick.IsEnabled())
+			assert.Len(t, i.quickMap, 1, "QUICK registered in another Indicator")
+			i.Status().SetRunning(StatRunOn)
+			assert.Equal(t, StatRunOn, i.Status().Running())
+			i.ShowNotification(Notification{ID: name, Title: name})
+			n, present := i.Notification(name)
+			if assert.True(t, present) {
+				assert.Equal(t, now, n.Time, "notification not timestamped by the Clock")
+			}
+		})
+	}
+}
+
+func TestIndicatorOptions(t *testing.T) {
+	//the missing dependencies are reported
+	for name, option := range map[string]IndicatorOption{
+		"GuiProvider":        WithGuiProvider(nil),
+		"AgentController":    WithAgentController(nil),
+		"Status":             WithStatus(nil),
+		"LocalConfiguration": WithLocalConfig(nil),
+		"Clock":              WithClock(nil),
+	} {
+		i, err := NewIndicator(WithGuiProvider(NewMockedGuiProvider()), option)
+		assert.Nil(t, i)
+		assert.EqualError(t, err, "nil "+name)
+	}
+	//the configuration is read from the given directory
+	dir, err := ioutil.TempDir("", "liqo-indicator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, client.ConfigFileName)
+	data := fmt.Sprintf("version: %d\nlabelMode: trend\n", client.CurrentConfigVersion)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(data), 0644))
+	i := newTestIndicator(t, NewMockedGuiProvider(), WithConfigPath(dir))
+	assert.Equal(t, LabelModeTrend, i.LabelMode())
+	i.Quit()
+	assert.NoError(t, ioutil.WriteFile(path, []byte("labelMode: [trend"), 0644))
+	_, err = NewIndicator(WithGuiProvider(NewMockedGuiProvider()), WithConfigPath(dir))
+	assert.Error(t, err, "undecodable configuration loaded")
+}
+
+func TestReset(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	old := GetIndicator()
+	quick := old.AddQuick("Quick", "Q_TEST", nil)
+	//a failed re-initialization keeps the current Indicator
+	_, err := Reset(WithStatus(nil))
+	assert.Error(t, err)
+	assert.Same(t, old, GetIndicator())
+	assert.True(t, quick.IsVisible())
+	i, err := Reset(WithStatus(NewStatus()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer i.Quit()
+	assert.Same(t, i, GetIndicator())
+	assert.NotSame(t, old, i)
+	assert.NotSame(t, old.Status(), i.Status())
+	//the replaced Indicator is stopped and its entries hidden
+	select {
+	case <-old.Context().Done():
+	default:
+		t.Error("replaced Indicator still running")
+	}
+	assert.False(t, quick.IsVisible(), "entry of the replaced Indicator displayed")
+	_, present := i.Quick("Q_TEST")
+	assert.False(t, present)
+}
+
+func TestIconTheme(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	i := GetIndicator()
+	assert.Equal(t, IconThemeDefault, ParseIconTheme("unknown"))
+	assert.Equal(t, IconThemeAccessible, ParseIconTheme("accessible"))
+	//each theme must provide all the icons
+	for theme := range IconThemeDescriptions {
+		for _, scheme := range []ColorScheme{ColorSchemeLight, ColorSchemeDark} {
+			for ico := IconLiqoMain; ico < IconLiqoNil; ico++ {
+				data, valid := iconData(theme, scheme, ico)
+				assert.Truef(t, valid && len(data) > 0, "icon %d missing in theme %s (%s)", ico, theme, scheme)
+			}
+		}
+	}
+	i.SetIcon(IconLiqoRed)
+	i.SetIconTheme(IconThemeAccessible)
+	assert.Equal(t, IconThemeAccessible, i.IconTheme())
+	assert.Equal(t, IconLiqoRed, i.Icon(), "icon changed when switching theme")
+	i.SetIconTheme(IconThemeDefault)
+}
+
 // simulation of an Indicator routine that allows to test functions of Indicator and MenuNode
 func TestIndicatorRoutine(t *testing.T) {
 	UseMockedGuiProvider()
@@ -60,7 +199,7 @@ func TestIndicatorRoutine(t *testing.T) {
 	assert.NotNil(t, a, "ACTION node is nil")
 	assert.True(t, a.IsVisible(), "ACTION node is not visible")
 	// test OPTION registration
-	a.AddOption("option test", "OPTION_TAG", "", false, nil, nil)
+	a.AddOption("option test", "OPTION_TAG", "", false, nil)
 	o, ok2 := a.Option("OPTION_TAG")
 	assert.True(t, ok2, "OPTION not registered")
 	assert.NotNil(t, a, "OPTION node is nil")
@@ -116,7 +255,35 @@ func TestIndicatorRoutine(t *testing.T) {
 	o.SetIsVisible(false)
 	//test Quit() and Disconnect
 	i.Quit()
-	assert.True(t, i.quitClosed, "Indicator quitChan not closed at Quit()")
+	assert.Error(t, i.Context().Err(), "Indicator context not cancelled at Quit()")
+}
+
+func TestQuit(t *testing.T) {
+	i := newTestIndicator(t, NewMockedGuiProvider())
+	assert.NoError(t, i.StartTimer("timer", time.Hour, func(args ...interface{}) {}))
+	//a running event handler is cancelled, and awaited
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	quick := i.AddQuick("Quick", "Q_TEST", ClickHandlerFunc(func(ctx context.Context, e *ClickEvent) {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		close(cancelled)
+	}))
+	quick.Channel() <- struct{}{}
+	<-started
+	assert.False(t, i.Wait(10*time.Millisecond), "goroutines finished while running")
+	i.Quit()
+	assert.Error(t, i.Context().Err())
+	assert.True(t, i.Wait(time.Second), "goroutines running after Quit")
+	select {
+	case <-cancelled:
+	default:
+		t.Error("event handler not awaited")
+	}
+	//no goroutine is started after Quit
+	assert.False(t, i.goTracked(func() {}))
+	assert.NoError(t, i.StartTimer("late", time.Hour, func(args ...interface{}) {}))
+	assert.True(t, i.Wait(time.Second))
 }
 
 func TestMenuNode_Connect(t *testing.T) {
@@ -128,15 +295,320 @@ func TestMenuNode_Connect(t *testing.T) {
 	et := GetGuiProvider().NewEventTester()
 	et.Test()
 	flagTest := false
-	o := i.AddQuick("test flag", "test", func(args ...interface{}) {
-		fp := args[0].(*bool)
-		*fp = true
-	}, &flagTest)
+	var event *ClickEvent
+	var handlerCtx context.Context
+	o := i.AddQuick("test flag", "test", ClickHandlerFunc(func(ctx context.Context, e *ClickEvent) {
+		flagTest = true
+		event = e
+		handlerCtx = ctx
+	}))
 	ch := o.Channel()
 	assert.NotNil(t, ch)
 	et.Add(1)
 	ch <- struct{}{}
 	et.Wait()
 	assert.True(t, flagTest, "Connect() callback not executed")
+	if assert.NotNil(t, event) {
+		assert.Equal(t, o, event.Node, "wrong ClickEvent node")
+		assert.Equal(t, i, event.Indicator, "wrong ClickEvent indicator")
+	}
+	//the handler context is cancelled when the node is disconnected
+	o.Disconnect()
+	select {
+	case <-handlerCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("handler context not cancelled at Disconnect()")
+	}
+	i.Quit()
+}
+
+func TestSubmenu(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	i := GetIndicator()
+	peers := i.AddSubmenu("Peers", "peers")
+	if s, present := i.Submenu("peers"); assert.True(t, present) {
+		assert.Equal(t, peers, s)
+	}
+	assert.Equal(t, NodeTypeSubmenu, peers.nodeType)
+	assert.True(t, peers.IsVisible(), "SUBMENU not visible by default")
+	assert.Nil(t, peers.item.(*mockItem).parent, "top level SUBMENU nested")
+	//SUBMENUs nest at any depth, each one with its own OPTIONs
+	peer := peers.AddSubmenu("cluster-a", "cluster-a")
+	assert.Equal(t, peers, peer.Parent())
+	assert.Equal(t, peers.item, peer.item.(*mockItem).parent, "SUBMENU not nested")
+	et := GetGuiProvider().NewEventTester()
+	et.Test()
+	flag := false
+	opt := peer.AddOption("Start peering", "start", "", false, ClickHandlerFunc(func(ctx context.Context, e *ClickEvent) {
+		flag = true
+	}))
+	assert.Equal(t, peer.item, opt.item.(*mockItem).parent, "OPTION not nested into the SUBMENU")
+	if o, present := peer.Option("start"); assert.True(t, present) {
+		assert.Equal(t, opt, o)
+	}
+	resources := peer.AddSubmenu("Resources", "resources")
+	assert.Equal(t, peer.item, resources.item.(*mockItem).parent)
+	_, present := peers.Submenu("resources")
+	assert.False(t, present, "SUBMENU registered at the wrong depth")
+	//SUBMENUs can be added to ACTIONs too
+	action := i.AddAction("Peerings", "peerings", nil)
+	nested := action.AddSubmenu("cluster-b", "cluster-b")
+	assert.Equal(t, action.item, nested.item.(*mockItem).parent)
+	et.Add(1)
+	opt.Channel() <- struct{}{}
+	et.Wait()
+	assert.True(t, flag, "nested OPTION callback not executed")
+	i.Quit()
+}
+
+func TestMenuNodeToggles(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	defer SetMockedCapabilities(^Capability(0))
+	i := GetIndicator()
+	a := i.AddAction("action", "ACTION_TOGGLES", nil)
+	//the check mark is displayed by the GuiBackend, without changing the title
+	o := a.AddOption("option", "OPTION_TOGGLES", "", false, nil)
+	item := o.item.(*mockItem)
+	o.SetIsChecked(true)
+	assert.True(t, item.Checked())
+	assert.Equal(t, "option", item.Title(), "title changed with CapabilityCheckmarks")
+	//otherwise, it is appended to the title
+	SetMockedCapabilities(^CapabilityCheckmarks)
+	o.SetIsChecked(false)
+	o.SetIsChecked(true)
+	assert.Equal(t, "option"+nodeIconChecked, item.Title())
+	o.SetIsChecked(false)
+	assert.Equal(t, "option", item.Title())
+	//radio buttons
+	assert.False(t, o.IsRadio())
+	o.SetIsRadio(true)
+	assert.True(t, o.IsRadio())
+	assert.Equal(t, ToggleRadio, item.toggleType)
+	o.SetIsRadio(false)
+	assert.Equal(t, ToggleCheckmark, item.toggleType)
+	//the icon replaces the text prefix
+	SetMockedCapabilities(^Capability(0))
+	q := i.AddQuick("quick", "QUICK_TOGGLES", nil)
+	qItem := q.item.(*mockItem)
+	icon := []byte{1, 2, 3}
+	q.SetIcon(icon)
+	assert.Equal(t, icon, qItem.icon)
+	assert.Equal(t, "quick", qItem.Title())
+	q.SetIcon(nil)
+	assert.Nil(t, qItem.icon)
+	assert.Equal(t, nodeIconQuick+"quick", qItem.Title())
+	//the icon is not set without CapabilityItemIcons
+	SetMockedCapabilities(^CapabilityItemIcons)
+	q.SetIcon(icon)
+	assert.Nil(t, qItem.icon)
+	assert.Equal(t, nodeIconQuick+"quick", qItem.Title())
+	i.Quit()
+}
+
+func TestReadOnly(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	i := GetIndicator()
+	et := GetGuiProvider().NewEventTester()
+	et.Test()
+	clicks := 0
+	write := i.AddQuick("write", "write", ClickHandlerFunc(func(ctx context.Context, e *ClickEvent) {
+		clicks++
+	}))
+	read := i.AddQuick("read", "read", nil)
+	write.SetWriteAction(true)
+	assert.True(t, write.IsEnabled())
+	i.SetReadOnly(true)
+	assert.True(t, i.ReadOnly())
+	assert.False(t, write.IsEnabled(), "write action enabled in read-only mode")
+	assert.True(t, read.IsEnabled(), "read action disabled in read-only mode")
+	//the clicks on the write actions are ignored
+	et.Add(1)
+	write.Channel() <- struct{}{}
+	et.Wait()
+	assert.Zero(t, clicks)
+	//the requested state is kept while in read-only mode
+	write.SetIsEnabled(true)
+	assert.False(t, write.IsEnabled())
+	i.SetReadOnly(false)
+	assert.True(t, write.IsEnabled())
+	et.Add(1)
+	write.Channel() <- struct{}{}
+	et.Wait()
+	assert.Equal(t, 1, clicks)
+	write.SetIsEnabled(false)
+	i.SetReadOnly(true)
+	i.SetReadOnly(false)
+	assert.False(t, write.IsEnabled(), "disabled write action enabled")
+	i.Quit()
+}
+
+func TestClickGuard(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	i := GetIndicator()
+	et := GetGuiProvider().NewEventTester()
+	et.Test()
+	assert.Equal(t, DefaultClickGuard, i.ClickGuard())
+	clicks := 0
+	o := i.AddQuick("test guard", "guard", ClickHandlerFunc(func(ctx context.Context, e *ClickEvent) {
+		clicks++
+	}))
+	click := func() {
+		et.Add(1)
+		o.Channel() <- struct{}{}
+		et.Wait()
+	}
+	//the double clicks are ignored
+	click()
+	click()
+	assert.Equal(t, 1, clicks, "double click handled")
+	i.SetClickGuard(10 * time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	click()
+	assert.Equal(t, 2, clicks)
+	//the clicks are handled at most once per cooldown
+	o.SetCooldown(100 * time.Millisecond)
+	assert.Equal(t, 100*time.Millisecond, o.Cooldown())
+	time.Sleep(20 * time.Millisecond)
+	click()
+	assert.Equal(t, 2, clicks, "click handled during the cooldown")
+	time.Sleep(100 * time.Millisecond)
+	click()
+	assert.Equal(t, 3, clicks)
+	i.Quit()
+}
+
+func TestBackgroundTasks(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	DestroyStatus()
+	i := GetIndicator()
+	i.Status().SetRunning(StatRunOn)
+	et := GetGuiProvider().NewEventTester()
+	et.Test()
+	//the Timers are listed by tag, with their next trigger
+	assert.NoError(t, i.StartTimer("T_B", time.Hour, func(args ...interface{}) {}))
+	assert.NoError(t, i.StartTimer("T_A", time.Hour, func(args ...interface{}) {}))
+	timers := i.Timers()
+	if assert.Len(t, timers, 2) {
+		assert.Equal(t, "T_A", timers[0].Tag())
+		assert.Equal(t, time.Hour, timers[0].Interval())
+		assert.Eventually(t, func() bool {
+			return timers[0].NextFire().After(time.Now().Add(time.Hour - time.Minute))
+		}, time.Second, 10*time.Millisecond)
+		timers[0].SetActive(false)
+		assert.False(t, timers[0].Active())
+	}
+	//the Listeners account the handled and the skipped events
+	i.Listen(client.TopicPeerChanged, func(data client.NotifyDataGeneric, args ...interface{}) {})
+	l, present := i.Listener(client.TopicPeerChanged)
+	if !assert.True(t, present) {
+		return
+	}
+	assert.Len(t, i.Listeners(), 1)
+	et.Add(1)
+	i.AgentCtrl().Events().Publish(client.TopicPeerChanged, struct{}{})
+	et.Wait()
+	stats := l.Stats()
+	assert.Equal(t, 1, stats.Handled)
+	assert.False(t, stats.LastEvent.IsZero())
+	l.SetPaused(true)
+	assert.True(t, l.Paused())
+	i.AgentCtrl().Events().Publish(client.TopicPeerChanged, struct{}{})
+	assert.Eventually(t, func() bool {
+		return l.Stats().Skipped == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, l.Stats().Handled, "event handled while paused")
+	i.Quit()
+}
+
+func TestListenerDebounce(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	DestroyStatus()
+	i := GetIndicator()
+	i.Status().SetRunning(StatRunOn)
+	et := GetGuiProvider().NewEventTester()
+	et.Test()
+	handled := 0
+	i.Listen(client.TopicHealthChanged, func(data client.NotifyDataGeneric, args ...interface{}) {
+		handled++
+	})
+	l, present := i.Listener(client.TopicHealthChanged)
+	if !assert.True(t, present) {
+		return
+	}
+	//a burst is handled at its start and, once, at the end of the interval
+	l.SetDebounce(Debounce{Interval: 200 * time.Millisecond, Trailing: true})
+	et.Add(3)
+	for n := 0; n < 3; n++ {
+		i.AgentCtrl().Events().Publish(client.TopicHealthChanged, struct{}{})
+	}
+	et.Wait()
+	assert.Equal(t, 2, handled, "burst not coalesced")
+	assert.Equal(t, 2, l.Stats().Handled)
+	assert.Equal(t, 2, l.Stats().Coalesced)
+	//without trailing-edge delivery the coalesced events are discarded
+	l.SetDebounce(Debounce{Interval: time.Hour})
+	et.Add(1)
+	i.AgentCtrl().Events().Publish(client.TopicHealthChanged, struct{}{})
+	et.Wait()
+	assert.Equal(t, 2, handled, "coalesced event handled")
+	assert.Equal(t, 3, l.Stats().Coalesced)
+	//a zero interval disables the debouncing
+	l.SetDebounce(Debounce{})
+	et.Add(2)
+	i.AgentCtrl().Events().Publish(client.TopicHealthChanged, struct{}{})
+	i.AgentCtrl().Events().Publish(client.TopicHealthChanged, struct{}{})
+	et.Wait()
+	assert.Equal(t, 4, handled)
+	i.Quit()
+}
+
+func TestRetranslate(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	defer i18n.SetLanguage(i18n.Language())
+	assert.NoError(t, i18n.SetLanguage(i18n.DefaultLanguage))
+	//the check tick is appended to the titles without CapabilityCheckmarks
+	SetMockedCapabilities(^CapabilityCheckmarks)
+	defer SetMockedCapabilities(^Capability(0))
+	i := GetIndicator()
+	quit := i.AddQuick("Quit", "quit", nil)
+	quitItem := quit.item.(*mockItem)
+	a := i.AddAction("Peering request from cluster-1", "request", nil)
+	accept := a.AddOption("Accept", "accept", "", false, nil)
+	accept.SetIsChecked(true)
+	assert.Equal(t, nodeIconQuick+"Quit", quitItem.Title())
+	assert.NoError(t, i18n.SetLanguage("it"))
+	i.Retranslate()
+	//the English title is kept
+	assert.Equal(t, "Quit", quit.Title())
+	assert.Equal(t, nodeIconQuick+"Esci", quitItem.Title(), "MenuNode not translated")
+	assert.Equal(t, nodeIconAction+"Richiesta di peering da cluster-1", a.item.(*mockItem).Title())
+	assert.Equal(t, "Accetta"+nodeIconChecked, accept.item.(*mockItem).Title(), "check tick lost")
+	//the titles set afterwards are translated too
+	quit.SetTitle("Help")
+	assert.Equal(t, nodeIconQuick+"Aiuto", quitItem.Title())
+	assert.NoError(t, i18n.SetLanguage(i18n.DefaultLanguage))
+	i.Retranslate()
+	assert.Equal(t, nodeIconQuick+"Help", quitItem.Title())
 	i.Quit()
 }
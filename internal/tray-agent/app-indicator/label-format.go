@@ -0,0 +1,100 @@
+package app_indicator
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/format"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Placeholders of the format strings of the Indicator label.
+const (
+	//LabelIn is replaced by the number of active incoming peerings.
+	LabelIn = "{{in}}"
+	//LabelOut is replaced by the number of active outgoing peerings.
+	LabelOut = "{{out}}"
+	//LabelCpuAcquired is replaced by the CPU (in cores) currently acquired from the peers.
+	LabelCpuAcquired = "{{cpuAcquired}}"
+	//LabelCpuTrend is replaced by the sparkline of the CPU acquired from the peers over the last hour.
+	LabelCpuTrend = "{{cpuTrend}}"
+	//LabelClusterAlias is replaced by the name of the home cluster.
+	LabelClusterAlias = "{{clusterAlias}}"
+)
+
+//LabelPresets contains the predefined format strings of the Indicator label, selectable by name.
+var LabelPresets = map[string]string{
+	string(LabelModeCounts): "(IN:" + LabelIn + "/OUT:" + LabelOut + ")",
+	string(LabelModeTrend):  "CPU " + LabelCpuTrend + " " + LabelCpuAcquired,
+	"compact":               "↓" + LabelIn + " ↑" + LabelOut,
+	"cluster":               LabelClusterAlias + " " + LabelIn + "/" + LabelOut,
+}
+
+//labelFormat is a custom format of the Indicator label.
+type labelFormat struct {
+	//format is the format string, with the placeholders replaced by the current values.
+	format string
+	//always specifies whether the label is displayed also when no peering is active.
+	always bool
+}
+
+//SetLabelFormat sets a custom format of the Indicator label, overriding its LabelMode: format is either the name of
+//one of the LabelPresets or a format string containing the label placeholders (e.g. LabelIn). If always is true,
+//the label is displayed also when no peering is active. An empty format restores the LabelMode.
+func (i *Indicator) SetLabelFormat(format string, always bool) {
+	if preset, present := LabelPresets[format]; present {
+		format = preset
+	}
+	gr := i.graphicResource[resourceLabel]
+	gr.Lock()
+	i.labelFormat = labelFormat{format: format, always: always}
+	gr.Unlock()
+	i.RefreshLabel()
+}
+
+//NeedsUsageTrend returns whether the Indicator label displays the CPU acquired from the peers, which must then
+//be sampled in the UsageTrend.
+func (i *Indicator) NeedsUsageTrend() bool {
+	gr := i.graphicResource[resourceLabel]
+	gr.RLock()
+	defer gr.RUnlock()
+	if i.labelFormat.format != "" {
+		return strings.Contains(i.labelFormat.format, LabelCpuAcquired) ||
+			strings.Contains(i.labelFormat.format, LabelCpuTrend)
+	}
+	return i.labelMode == LabelModeTrend
+}
+
+//formattedLabel returns the content of the Indicator label for its custom format, if any. The returned bool is
+//false if no custom format is set.
+func (i *Indicator) formattedLabel() (string, bool) {
+	gr := i.graphicResource[resourceLabel]
+	gr.RLock()
+	f := i.labelFormat
+	gr.RUnlock()
+	if f.format == "" {
+		return "", false
+	}
+	st := i.Status()
+	in := st.Peerings(PeeringIncoming)
+	out := st.Peerings(PeeringOutgoing)
+	if !f.always && (st.Running() == StatRunOff || (in == 0 && out == 0)) {
+		return "", true
+	}
+	return formatLabel(f.format, in, out, st.ClusterName(), i.usageTrend), true
+}
+
+//formatLabel replaces the placeholders of a label format string with the given values.
+func formatLabel(text string, in int, out int, clusterName string, trend *TrendBuffer) string {
+	cpu, trendText := "-", ""
+	if last, present := trend.Last(); present {
+		cpu = format.Number(last, 1)
+		trendText = Sparkline(trend.Buckets(trendCells, time.Now()))
+	}
+	return strings.TrimSpace(strings.NewReplacer(
+		LabelIn, strconv.Itoa(in),
+		LabelOut, strconv.Itoa(out),
+		LabelCpuAcquired, cpu,
+		LabelCpuTrend, trendText,
+		LabelClusterAlias, clusterName,
+	).Replace(text))
+}
@@ -0,0 +1,36 @@
+package app_indicator
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFormatLabel(t *testing.T) {
+	trend := NewTrendBuffer(time.Hour)
+	assert.Equal(t, "home 1/2 CPU -", formatLabel("{{clusterAlias}} {{in}}/{{out}} CPU {{cpuAcquired}}", 1, 2,
+		"home", trend))
+	trend.Add(1.25)
+	assert.Equal(t, "█ 1.2 {{unknown}}", formatLabel("{{cpuTrend}} {{cpuAcquired}} {{unknown}}", 0, 0, "", trend))
+}
+
+func TestSetLabelFormat(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	DestroyStatus()
+	i := GetIndicator()
+	i.SetLabelFormat("compact", false)
+	//with no active peering the label is hidden
+	assert.Empty(t, i.Label())
+	assert.False(t, i.NeedsUsageTrend())
+	i.SetLabelFormat("compact", true)
+	assert.Equal(t, "↓0 ↑0", i.Label())
+	i.SetLabelFormat("IN {{in}} CPU {{cpuAcquired}}", true)
+	assert.Equal(t, "IN 0 CPU -", i.Label())
+	assert.True(t, i.NeedsUsageTrend())
+	i.SetLabelFormat("", false)
+	assert.Empty(t, i.Label())
+}
@@ -0,0 +1,174 @@
+package app_indicator
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+//LabelMode defines the content of the Indicator label.
+type LabelMode string
+
+const (
+	//LabelModeCounts displays the number of the active incoming and outgoing peerings, e.g. "(IN:1/OUT:2)".
+	LabelModeCounts LabelMode = "counts"
+	//LabelModeTrend displays the trend of the CPU acquired from the peers over the TrendBuffer window,
+	//e.g. "CPU ▁▂▃▅▇ 2.5".
+	LabelModeTrend LabelMode = "trend"
+)
+
+//ParseLabelMode returns the LabelMode identified by name. It falls back to LabelModeCounts for an unknown name.
+func ParseLabelMode(name string) LabelMode {
+	if LabelMode(strings.ToLower(strings.TrimSpace(name))) == LabelModeTrend {
+		return LabelModeTrend
+	}
+	return LabelModeCounts
+}
+
+const (
+	//DefaultTrendWindow is the time span of the samples kept by the TrendBuffer of the Indicator.
+	DefaultTrendWindow = time.Hour
+	//trendCells is the number of characters of the sparkline displayed in the Indicator label.
+	trendCells = 12
+)
+
+//sparkLevels are the characters drawing the values of a sparkline, from the lowest to the highest.
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+//trendSample is a value recorded by a TrendBuffer.
+type trendSample struct {
+	at    time.Time
+	value float64
+}
+
+//TrendBuffer keeps the samples of a value recorded over a time window, e.g. the CPU acquired from the peers
+//over the last hour. It is safe for concurrent use.
+type TrendBuffer struct {
+	window  time.Duration
+	samples []trendSample
+	sync.RWMutex
+}
+
+//NewTrendBuffer returns an empty TrendBuffer keeping the samples of the last window.
+func NewTrendBuffer(window time.Duration) *TrendBuffer {
+	return &TrendBuffer{window: window}
+}
+
+//Add records a sample of the value at the current time.
+func (b *TrendBuffer) Add(value float64) {
+	b.add(time.Now(), value)
+}
+
+//add records a sample of the value at a given time, discarding the samples older than the window.
+func (b *TrendBuffer) add(at time.Time, value float64) {
+	b.Lock()
+	defer b.Unlock()
+	b.samples = append(b.samples, trendSample{at: at, value: value})
+	expired := 0
+	for expired < len(b.samples) && at.Sub(b.samples[expired].at) > b.window {
+		expired++
+	}
+	b.samples = append(b.samples[:0], b.samples[expired:]...)
+}
+
+//Last returns the most recent sample, if any.
+func (b *TrendBuffer) Last() (value float64, present bool) {
+	b.RLock()
+	defer b.RUnlock()
+	if len(b.samples) == 0 {
+		return 0, false
+	}
+	return b.samples[len(b.samples)-1].value, true
+}
+
+//Len returns the number of samples in the buffer.
+func (b *TrendBuffer) Len() int {
+	b.RLock()
+	defer b.RUnlock()
+	return len(b.samples)
+}
+
+//Buckets splits the window ending at now into cells intervals, returning the average of the samples of each one.
+//The intervals before the first sample are omitted, while the empty ones after it repeat the previous value.
+func (b *TrendBuffer) Buckets(cells int, now time.Time) []float64 {
+	b.RLock()
+	defer b.RUnlock()
+	if len(b.samples) == 0 || cells <= 0 {
+		return nil
+	}
+	sums := make([]float64, cells)
+	counts := make([]int, cells)
+	start := now.Add(-b.window)
+	for _, s := range b.samples {
+		cell := int(float64(s.at.Sub(start)) / float64(b.window) * float64(cells))
+		if cell < 0 {
+			continue
+		}
+		if cell >= cells {
+			cell = cells - 1
+		}
+		sums[cell] += s.value
+		counts[cell]++
+	}
+	var buckets []float64
+	for cell := range sums {
+		switch {
+		case counts[cell] > 0:
+			buckets = append(buckets, sums[cell]/float64(counts[cell]))
+		case len(buckets) > 0:
+			buckets = append(buckets, buckets[len(buckets)-1])
+		}
+	}
+	return buckets
+}
+
+//Sparkline returns a unicode sparkline of the values, e.g. "▁▂▃▅▇", scaled from 0 to the highest value.
+func Sparkline(values []float64) string {
+	max := 0.0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	b := strings.Builder{}
+	for _, v := range values {
+		level := 0
+		if max > 0 && v > 0 {
+			level = int(v/max*float64(len(sparkLevels)-1) + 0.5)
+		}
+		b.WriteRune(sparkLevels[level])
+	}
+	return b.String()
+}
+
+//LabelMode returns the LabelMode of the Indicator label.
+func (i *Indicator) LabelMode() LabelMode {
+	gr := i.graphicResource[resourceLabel]
+	gr.RLock()
+	defer gr.RUnlock()
+	return i.labelMode
+}
+
+//SetLabelMode sets the LabelMode of the Indicator label, refreshing its content.
+func (i *Indicator) SetLabelMode(mode LabelMode) {
+	gr := i.graphicResource[resourceLabel]
+	gr.Lock()
+	i.labelMode = mode
+	gr.Unlock()
+	i.RefreshLabel()
+}
+
+//UsageTrend returns the TrendBuffer of the CPU (in cores) acquired from the peers, displayed by the Indicator
+//label in LabelModeTrend.
+func (i *Indicator) UsageTrend() *TrendBuffer {
+	return i.usageTrend
+}
+
+//trendLabel returns the content of the Indicator label in LabelModeTrend, e.g. "CPU ▁▂▃▅▇ 2.5".
+//It is empty if no sample has been recorded.
+func (i *Indicator) trendLabel() string {
+	if i.usageTrend.Len() == 0 {
+		return ""
+	}
+	return formatLabel(LabelPresets[string(LabelModeTrend)], 0, 0, "", i.usageTrend)
+}
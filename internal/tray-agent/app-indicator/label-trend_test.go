@@ -0,0 +1,52 @@
+package app_indicator
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁▃▅█", Sparkline([]float64{0, 1, 2, 3.5}))
+	assert.Equal(t, "▁▁", Sparkline([]float64{0, 0}))
+	assert.Empty(t, Sparkline(nil))
+}
+
+func TestTrendBuffer(t *testing.T) {
+	b := NewTrendBuffer(time.Hour)
+	now := time.Now()
+	assert.Nil(t, b.Buckets(4, now))
+	//a sample older than the window is discarded once a newer one is recorded
+	b.add(now.Add(-2*time.Hour), 10)
+	b.add(now.Add(-40*time.Minute), 1)
+	b.add(now.Add(-35*time.Minute), 3)
+	b.add(now.Add(-1*time.Minute), 4)
+	assert.Equal(t, 3, b.Len())
+	//the empty interval before the last sample repeats the previous value
+	assert.Equal(t, []float64{2, 2, 4}, b.Buckets(4, now))
+	last, present := b.Last()
+	assert.True(t, present)
+	assert.Equal(t, 4.0, last)
+}
+
+func TestTrendLabel(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	DestroyStatus()
+	i := GetIndicator()
+	assert.Equal(t, LabelModeCounts, i.LabelMode())
+	assert.Equal(t, LabelModeTrend, ParseLabelMode(" Trend"))
+	assert.Equal(t, LabelModeCounts, ParseLabelMode("unknown"))
+	i.Status().SetRunning(StatRunOn)
+	i.SetLabelMode(LabelModeTrend)
+	//with no sample the label is empty
+	assert.Empty(t, i.Label())
+	i.UsageTrend().Add(2.5)
+	i.RefreshLabel()
+	assert.Equal(t, "CPU █ 2.5", i.Label())
+	i.SetLabelMode(LabelModeCounts)
+	assert.Empty(t, i.Label())
+}
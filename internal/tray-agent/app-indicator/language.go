@@ -0,0 +1,27 @@
+package app_indicator
+
+import "github.com/liqotech/liqo-agent/internal/tray-agent/agent/i18n"
+
+/*This file contains the switch of the language of the Indicator at runtime. The MenuNodes keep their title and
+tooltip in English, translating them each time they are displayed (see the i18n package): when the language changes,
+all the MenuNodes are displayed again, while the Notifications are translated when shown.*/
+
+//Retranslate displays again the titles and tooltips of all the MenuNodes, translated in the current language.
+func (i *Indicator) Retranslate() {
+	i.nodesMutex.Lock()
+	nodes := append([]*MenuNode{}, i.nodes...)
+	i.nodesMutex.Unlock()
+	for _, n := range nodes {
+		n.Lock()
+		if n.titleSet {
+			n.renderTitle(!n.nativeCheck() && n.itemChecked())
+		}
+		if n.tooltip != "" && i.gProvider.Supports(CapabilityTooltips) {
+			tooltip := i18n.T(n.tooltip)
+			n.onGui(func(item Item) {
+				item.SetTooltip(tooltip)
+			})
+		}
+		n.Unlock()
+	}
+}
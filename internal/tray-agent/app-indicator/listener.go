@@ -2,61 +2,310 @@ package app_indicator
 
 import (
 	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/metrics"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/tracing"
+	"sort"
+	"sync"
+	"time"
 )
 
 //Listener is an event listener that can react calling a specific callback.
 type Listener struct {
-	//Tag specifies the type of notification channel on which it listens to
-	Tag client.NotifyChannel
+	//Tag specifies the Topic of the events it listens to
+	Tag client.Topic
+	//Cluster is the name of the additional cluster whose AgentController is listened to, empty for the main one.
+	Cluster string
 	//StopChan lets control the Listener event loop
 	StopChan chan struct{}
-	//NotifyChan is the client.NotifyChannel on which it listens to
-	NotifyChan chan client.NotifyDataGeneric
+	//Subscription delivers the events of the Topic
+	Subscription *client.Subscription
+	//statsMutex protects stats, paused and debounce.
+	statsMutex sync.Mutex
+	stats      ListenerStats
+	//paused specifies whether the notifications are discarded without executing the callback.
+	paused bool
+	//debounce limits the rate of execution of the callback.
+	debounce Debounce
+	//timer exports the execution time of the callback (see package metrics).
+	timer *metrics.Timer
 }
 
-//newListener returns a new Listener.
-func newListener(tag client.NotifyChannel) *Listener {
-	ch := client.GetAgentController().NotifyChannel(tag)
-	if ch == nil {
-		panic("Indicator tried to listen to non existing NotifyChannel")
+//Debounce limits the rate of execution of the callback of a Listener, e.g. to handle a burst of events with a
+//single refresh. The events received within Interval from the last execution of the callback are coalesced: if
+//Trailing, the callback is executed once more at the end of the Interval for the last of them, otherwise they are
+//discarded. A zero Interval disables the debouncing.
+type Debounce struct {
+	Interval time.Duration
+	Trailing bool
+}
+
+//ListenerStats are the execution metrics of the callback of a Listener.
+type ListenerStats struct {
+	//Handled is the number of executions of the callback.
+	Handled int
+	//TotalTime is the overall execution time of the callback.
+	TotalTime time.Duration
+	//MaxTime is the longest execution time of the callback.
+	MaxTime time.Duration
+	//Pending is the number of notifications waiting to be handled.
+	Pending int
+	//Skipped is the number of notifications discarded while the Listener was paused.
+	Skipped int
+	//Coalesced is the number of notifications merged or discarded by the Debounce of the Listener.
+	Coalesced int
+	//LastEvent is the instant of the last handled notification.
+	LastEvent time.Time
+}
+
+//AverageTime returns the average execution time of the callback.
+func (s ListenerStats) AverageTime() time.Duration {
+	if s.Handled == 0 {
+		return 0
+	}
+	return s.TotalTime / time.Duration(s.Handled)
+}
+
+//Stats returns the execution metrics of the Listener callback.
+func (l *Listener) Stats() ListenerStats {
+	l.statsMutex.Lock()
+	stats := l.stats
+	l.statsMutex.Unlock()
+	stats.Pending = l.Subscription.Len()
+	return stats
+}
+
+//SetPaused pauses or resumes the Listener. While paused, the notifications are discarded without executing the
+//callback.
+func (l *Listener) SetPaused(paused bool) {
+	l.statsMutex.Lock()
+	defer l.statsMutex.Unlock()
+	l.paused = paused
+}
+
+//SetDebounce sets the Debounce of the Listener, applied to the notifications received from now on.
+func (l *Listener) SetDebounce(debounce Debounce) {
+	l.statsMutex.Lock()
+	defer l.statsMutex.Unlock()
+	l.debounce = debounce
+}
+
+//Debounce returns the Debounce of the Listener.
+func (l *Listener) Debounce() Debounce {
+	l.statsMutex.Lock()
+	defer l.statsMutex.Unlock()
+	return l.debounce
+}
+
+//coalesce accounts a notification merged or discarded by the Debounce of the Listener.
+func (l *Listener) coalesce() {
+	l.statsMutex.Lock()
+	defer l.statsMutex.Unlock()
+	l.stats.Coalesced++
+}
+
+//Paused returns whether the Listener is paused.
+func (l *Listener) Paused() bool {
+	l.statsMutex.Lock()
+	defer l.statsMutex.Unlock()
+	return l.paused
+}
+
+//skip accounts a notification discarded while the Listener is paused, returning false if it is not paused.
+func (l *Listener) skip() bool {
+	l.statsMutex.Lock()
+	defer l.statsMutex.Unlock()
+	if l.paused {
+		l.stats.Skipped++
+	}
+	return l.paused
+}
+
+//record accounts an execution of the callback started at start that lasted elapsed.
+func (l *Listener) record(start time.Time, elapsed time.Duration) {
+	l.statsMutex.Lock()
+	defer l.statsMutex.Unlock()
+	l.timer.Observe(elapsed)
+	l.stats.LastEvent = start
+	l.stats.Handled++
+	l.stats.TotalTime += elapsed
+	if elapsed > l.stats.MaxTime {
+		l.stats.MaxTime = elapsed
 	}
-	l := Listener{StopChan: make(chan struct{}, 1), Tag: tag, NotifyChan: ch}
+}
+
+//listenerKey identifies a registered Listener.
+type listenerKey struct {
+	cluster string
+	tag     client.Topic
+}
+
+//newListener returns a new Listener subscribed to a Topic of the EventBus of the AgentController.
+func newListener(ctrl *client.AgentController, tag client.Topic) *Listener {
+	l := Listener{StopChan: make(chan struct{}, 1), Tag: tag, Cluster: ctrl.Name(),
+		Subscription: ctrl.Events().Subscribe(tag, 0)}
+	labels := metrics.Labels{"listener": tag.String()}
+	if l.Cluster != "" {
+		labels["cluster"] = l.Cluster
+	}
+	l.timer = metrics.GetRegistry().Timer("event_handling_seconds", "Duration of the handling of the cluster events",
+		labels)
 	return &l
 }
 
-//Listener returns the registered Listener for the specified NotifyChannel. If such Listener does not exist,
+//Listener returns the registered Listener for the specified Topic. If such Listener does not exist,
 //present == false.
-func (i *Indicator) Listener(tag client.NotifyChannel) (listener *Listener, present bool) {
-	listener, present = i.listeners[tag]
+func (i *Indicator) Listener(tag client.Topic) (listener *Listener, present bool) {
+	i.listenersMutex.RLock()
+	defer i.listenersMutex.RUnlock()
+	listener, present = i.listeners[listenerKey{tag: tag}]
 	return
 }
 
-//Listen starts a Listener for a specific channel, executing callback when a notification arrives.
-func (i *Indicator) Listen(tag client.NotifyChannel, callback func(data client.NotifyDataGeneric, args ...interface{}), args ...interface{}) {
-	l := newListener(tag)
-	i.listeners[tag] = l
-	go func() {
+//ClusterListener returns the registered Listener for the specified Topic of an additional cluster (see
+//ListenCluster). If such Listener does not exist, present == false.
+func (i *Indicator) ClusterListener(cluster string, tag client.Topic) (listener *Listener, present bool) {
+	i.listenersMutex.RLock()
+	defer i.listenersMutex.RUnlock()
+	listener, present = i.listeners[listenerKey{cluster: cluster, tag: tag}]
+	return
+}
+
+//Listeners returns all the registered Listeners, sorted by cluster (the main one first) and Topic.
+func (i *Indicator) Listeners() []*Listener {
+	i.listenersMutex.RLock()
+	defer i.listenersMutex.RUnlock()
+	listeners := make([]*Listener, 0, len(i.listeners))
+	for _, l := range i.listeners {
+		listeners = append(listeners, l)
+	}
+	sort.Slice(listeners, func(a, b int) bool {
+		if listeners[a].Cluster != listeners[b].Cluster {
+			return listeners[a].Cluster < listeners[b].Cluster
+		}
+		return listeners[a].Tag < listeners[b].Tag
+	})
+	return listeners
+}
+
+//Listen starts a Listener for a specific Topic, executing callback when a notification arrives. Several components
+//can subscribe to the same Topic on the EventBus of the AgentController, each receiving all its events.
+func (i *Indicator) Listen(tag client.Topic, callback func(data client.NotifyDataGeneric, args ...interface{}), args ...interface{}) {
+	i.listen(i.agentCtrl, tag, callback, args...)
+}
+
+//ListenCluster starts a Listener for a specific Topic of the AgentController of an additional cluster (see
+//client.AgentController.ConnectCluster), executing callback when a notification arrives. The Listeners of a
+//cluster are stopped by StopClusterListeners.
+func (i *Indicator) ListenCluster(ctrl *client.AgentController, tag client.Topic, callback func(data client.NotifyDataGeneric, args ...interface{}), args ...interface{}) {
+	i.listen(ctrl, tag, callback, args...)
+}
+
+//StopClusterListeners stops all the Listeners of an additional cluster.
+func (i *Indicator) StopClusterListeners(cluster string) {
+	i.listenersMutex.RLock()
+	defer i.listenersMutex.RUnlock()
+	for key, l := range i.listeners {
+		if key.cluster == cluster {
+			select {
+			case l.StopChan <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+//signalEventHandled signals, in test mode, that an event has been handled by a Listener.
+func (i *Indicator) signalEventHandled() {
+	if et, testing := i.gProvider.GetEventTester(); testing {
+		et.Done()
+	}
+}
+
+//listen starts a Listener for a Topic of ctrl.
+func (i *Indicator) listen(ctrl *client.AgentController, tag client.Topic, callback func(data client.NotifyDataGeneric, args ...interface{}), args ...interface{}) {
+	l := newListener(ctrl, tag)
+	key := listenerKey{cluster: l.Cluster, tag: tag}
+	i.listenersMutex.Lock()
+	i.listeners[key] = l
+	i.listenersMutex.Unlock()
+	//handle executes the callback, unless the Agent is OFF or the Listener is paused, in order not to update
+	//information on status and tray menu or trigger notifications.
+	handle := func(data client.NotifyDataGeneric) {
+		if i.Status().Running() != StatRunOn || l.skip() {
+			return
+		}
+		start := time.Now()
+		_, span := tracing.Start(i.ctx, "handle "+tag.String(), tracing.Attributes{
+			"listener": tag.String(),
+			"cluster":  l.Cluster,
+			//the events still waiting to be handled
+			"pending": l.Subscription.Len(),
+		})
+		callback(data, args...)
+		span.End()
+		l.record(start, time.Since(start))
+		i.signalEventHandled()
+	}
+	started := i.goTracked(func() {
+		//the state of the debouncing: the last execution of the callback and the event waiting for the
+		//trailing-edge delivery, if any.
+		var (
+			lastRun    time.Time
+			pending    client.NotifyDataGeneric
+			hasPending bool
+			trailing   <-chan time.Time
+		)
 		for {
 			select {
 			//exec handler
-			case data, open := <-l.NotifyChan:
-				/*While the Agent is OFF, the callback is not executed, in order not to update information
-				on status and tray menu or trigger notifications.*/
-				if open && i.Status().Running() == StatRunOn {
-					callback(data, args...)
-					//signal callback execution in test mode
-					if et, testing := GetGuiProvider().GetEventTester(); testing {
-						et.Done()
+			case data, open := <-l.Subscription.C():
+				if !open {
+					continue
+				}
+				debounce := l.Debounce()
+				if wait := debounce.Interval - time.Since(lastRun); debounce.Interval > 0 && wait > 0 {
+					l.coalesce()
+					if !debounce.Trailing {
+						i.signalEventHandled()
+						continue
+					}
+					if hasPending {
+						//the event waiting for the delivery is superseded
+						i.signalEventHandled()
+					}
+					pending, hasPending = data, true
+					if trailing == nil {
+						trailing = time.After(wait)
 					}
+					continue
+				}
+				lastRun = time.Now()
+				handle(data)
+				//trailing-edge delivery of the coalesced events
+			case <-trailing:
+				trailing = nil
+				if hasPending {
+					lastRun = time.Now()
+					handle(pending)
+					pending, hasPending = nil, false
 				}
 				//closing application
-			case <-i.quitChan:
+			case <-i.ctx.Done():
+				l.Subscription.Unsubscribe()
 				return
 				//closing single listener. Channel controlled by Indicator
 			case <-l.StopChan:
-				delete(i.listeners, tag)
+				i.listenersMutex.Lock()
+				if i.listeners[key] == l {
+					delete(i.listeners, key)
+				}
+				i.listenersMutex.Unlock()
+				l.Subscription.Unsubscribe()
 				return
 			}
 		}
-	}()
+	})
+	if !started {
+		l.Subscription.Unsubscribe()
+	}
 }
@@ -1,9 +1,11 @@
 package app_indicator
 
 import (
-	"github.com/getlantern/systray"
+	"context"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/i18n"
 	"github.com/ozgio/strutil"
 	"sync"
+	"time"
 )
 
 /*NodeType defines the kind of a MenuNode, each one with specific features.
@@ -23,6 +25,10 @@ NodeType distinguishes different kinds of MenuNodes:
 		TITLE:	node with special text formatting used to display menu header.
 
 		STATUS:	non clickable node that displays status information.
+
+		SUBMENU: non clickable node expanding into a nested menu, containing OPTIONs and other SUBMENUs.
+
+		SECTION: non clickable header of a group of QUICKs and ACTIONs, between separators.
 */
 type NodeType int
 
@@ -47,6 +53,12 @@ const (
 	//NodeTypeStatus represents a NodeType of a STATUS MenuNode: non clickable node that displays status information
 	//about Liqo.
 	NodeTypeStatus
+	//NodeTypeSubmenu represents a NodeType of a SUBMENU MenuNode: non clickable node expanding into a nested menu,
+	//that contains OPTIONs and other SUBMENUs at any depth.
+	NodeTypeSubmenu
+	//NodeTypeSection represents a NodeType of a SECTION MenuNode: non clickable header of a group of level-0
+	//MenuNodes (e.g. QUICKs and ACTIONs), displayed between separators.
+	NodeTypeSection
 )
 
 //NodeIcon represents a string prefix helping to graphically distinguish different kinds of Menu entries (NodeType).
@@ -69,6 +81,8 @@ type MenuNode struct {
 	item Item
 	// the type of the MenuNode
 	nodeType NodeType
+	//indicator is the Indicator the MenuNode belongs to.
+	indicator *Indicator
 	// unique tag of the MenuNode that can be used as a key to get access to it, e.g. using (*Indicator)
 	tag string
 	// the kill switch to disconnect any event handler connected to the node.
@@ -91,8 +105,26 @@ type MenuNode struct {
 	actionMap map[string]*MenuNode
 	//map that stores OPTION MenuNodes, associating them with their tag. These nodes are used to create submenu choices
 	optionMap map[string]*MenuNode
+	//map that stores SUBMENU MenuNodes, associating them with their tag. These nodes nest a menu into the node.
+	submenuMap map[string]*MenuNode
+	//children contains the MenuNodes nested into the node in display order, protected by the nodesMutex of the
+	//Indicator (see MoveBefore).
+	children []*MenuNode
+	//nested specifies whether the item of the MenuNode is nested into the one of its parent.
+	nested bool
+	//rebound is closed when the MenuNode is bound to the item of a sibling, in order to move it (see MoveBefore).
+	rebound chan struct{}
+	//members contains the MenuNodes grouped by a SECTION, protected by the nodesMutex of the Indicator.
+	members []*MenuNode
+	//section is the SECTION grouping the MenuNode, if any.
+	section *MenuNode
 	//if isVisible==true, the MenuItem of the node is shown in the menu to the user
 	isVisible bool
+	//if unlisted==true, the LIST node is hidden by the pagination or by the filter of its parent (see
+	//SetListPageSize and SetListFilter), regardless of isVisible.
+	unlisted bool
+	//isChecked is the checked state of the node, kept while it has no item (see newListNode).
+	isChecked bool
 	//if isInvalid==true, the content of the LIST MenuNode is no more up to date and has to be refreshed by application
 	//logic
 	isInvalid bool
@@ -101,30 +133,81 @@ type MenuNode struct {
 	hasCheckbox bool
 	//text prefix that is prepended to the MenuNode title when it is shown in the menu
 	icon string
+	//iconData is the PNG image displayed next to the title, replacing the text prefix, by the backends supporting
+	//CapabilityItemIcons.
+	iconData []byte
+	//if isRadio==true, the node is checked as a radio button, being a choice exclusive of its siblings.
+	isRadio bool
 	//text content of the menu item. This redundancy of information is due to the fact Item does not provide getters
 	//for the data.
 	title string
+	//titleSet specifies whether the title has been set at least once.
+	titleSet bool
+	//tooltip of the menu item.
+	tooltip string
+	//if isWrite==true, the node performs a write action on the cluster or on the Agent settings: it is disabled
+	//and its clicks are ignored while the Indicator is in read-only mode.
+	isWrite bool
+	//isEnabled is the enabled state requested for the node, actually applied only if the node is not disabled by
+	//the read-only mode.
+	isEnabled bool
+	//cooldown is the minimum interval between two handled clicks on the node (see SetCooldown).
+	cooldown time.Duration
+	//lastClick is the instant of the last handled click on the node.
+	lastClick time.Time
+	//lastHandled is the instant the handling of the last click on the node has been completed.
+	lastHandled time.Time
 	//protection for concurrent access to MenuNode attributes.
 	sync.RWMutex
 }
 
-//newMenuNode creates a MenuNode of type NodeType
-func newMenuNode(nodeType NodeType, withCheckbox bool, parent *MenuNode) *MenuNode {
+//newMenuNode creates a MenuNode of type NodeType belonging to the Indicator i.
+func newMenuNode(i *Indicator, nodeType NodeType, withCheckbox bool, parent *MenuNode) *MenuNode {
+	return newNode(i, nodeType, withCheckbox, parent, false)
+}
+
+//newListNode creates a LIST MenuNode nested into parent. If detached == true, or parent has no item itself, the
+//node is created without an item: it is given one only once displayed (see attach), so that the long lists do not
+//fill the menu with hidden entries.
+func newListNode(i *Indicator, withCheckbox bool, parent *MenuNode, detached bool) *MenuNode {
+	return newNode(i, NodeTypeList, withCheckbox, parent, detached || parent.item == nil)
+}
+
+//newNode creates a MenuNode of type NodeType belonging to the Indicator i, without an item if detached == true or
+//if it is nested into a parent without an item.
+func newNode(i *Indicator, nodeType NodeType, withCheckbox bool, parent *MenuNode, detached bool) *MenuNode {
 	n := MenuNode{nodeType: nodeType,
-		hasCheckbox: withCheckbox}
+		indicator:   i,
+		hasCheckbox: withCheckbox,
+		isEnabled:   true}
 	n.actionMap = make(map[string]*MenuNode)
 	n.optionMap = make(map[string]*MenuNode)
+	n.submenuMap = make(map[string]*MenuNode)
 	n.stopChan = make(chan struct{})
+	n.rebound = make(chan struct{})
 	/* Calls to the GuiProviderInterface differ according to hierarchy level constraints of each nodeType.
 	ROOT, QUICK, ACTION and TITLE types are level-0 graphic elements, while OPTION and LIST ones are always nested.
+	SUBMENU ones are nested, unless their parent is the ROOT.
 	*/
-	if nodeType == NodeTypeOption || nodeType == NodeTypeList {
+	if nodeType == NodeTypeSubmenu && parent != nil && parent.nodeType == NodeTypeRoot {
+		i.RunOnGui(func() {
+			n.item = i.gProvider.AddMenuItem(withCheckbox)
+		})
+	} else if nodeType == NodeTypeOption || nodeType == NodeTypeList || nodeType == NodeTypeSubmenu {
 		if parent == nil {
 			panic("attempted creation of nested MenuNode with nil parent")
 		}
-		n.item = GetGuiProvider().AddSubMenuItem(parent.item, withCheckbox)
+		parentItem := parent.item
+		if !detached && parentItem != nil {
+			i.RunOnGui(func() {
+				n.item = i.gProvider.AddSubMenuItem(parentItem, withCheckbox)
+			})
+		}
+		n.nested = true
 	} else {
-		n.item = GetGuiProvider().AddMenuItem(withCheckbox)
+		i.RunOnGui(func() {
+			n.item = i.gProvider.AddMenuItem(withCheckbox)
+		})
 	}
 	n.parent = &n
 	switch nodeType {
@@ -146,10 +229,29 @@ func newMenuNode(nodeType NodeType, withCheckbox bool, parent *MenuNode) *MenuNo
 	case NodeTypeStatus:
 		n.icon = nodeIconDefault
 		n.SetIsEnabled(false)
+	case NodeTypeSubmenu:
+		n.parent = parent
+		n.icon = nodeIconDefault
+	case NodeTypeSection:
+		n.icon = nodeIconDefault
+		n.SetIsEnabled(false)
 	default:
 		panic("attempted creation of MenuNode with unknown NodeType")
 	}
 	n.SetIsVisible(false)
+	i.nodesMutex.Lock()
+	i.nodes = append(i.nodes, &n)
+	if n.nested {
+		parent.children = append(parent.children, &n)
+	} else {
+		i.topNodes = append(i.topNodes, &n)
+		i.separated = false
+		if s := i.openSection; s != nil && nodeType != NodeTypeSection {
+			s.members = append(s.members, &n)
+			n.section = s
+		}
+	}
+	i.nodesMutex.Unlock()
 	return &n
 }
 
@@ -157,52 +259,81 @@ func newMenuNode(nodeType NodeType, withCheckbox bool, parent *MenuNode) *MenuNo
 
 //Channel returns the ClickedChan chan of the MenuNode which reacts to the 'clicked' event
 func (n *MenuNode) Channel() chan struct{} {
-	switch n.item.(type) {
-	case *systray.MenuItem:
-		return n.item.(*systray.MenuItem).ClickedCh
-	case *mockItem:
-		return n.item.(*mockItem).ClickedCh()
-	default:
+	n.RLock()
+	defer n.RUnlock()
+	if n.item == nil {
 		return nil
 	}
+	return n.item.ClickedCh()
 }
 
-//Connect instantiates a listener for the 'clicked' event of the node.
+//Connect instantiates a listener for the 'clicked' event of the node, executing handler at each event.
 //If once == true, the event handler is at most executed once.
-func (n *MenuNode) Connect(once bool, callback func(args ...interface{}), args ...interface{}) {
+func (n *MenuNode) Connect(once bool, handler ClickHandler) {
 	n.Lock()
 	if n.stopped {
 		n.stopChan = make(chan struct{})
 		n.stopped = false
 	}
-	n.Unlock()
+	stopChan := n.stopChan
+	rebound := n.rebound
 	var clickCh chan struct{}
-	switch n.item.(type) {
-	case *systray.MenuItem:
-		clickCh = n.item.(*systray.MenuItem).ClickedCh
-	case *mockItem:
-		clickCh = n.item.(*mockItem).ClickedCh()
-	default:
+	if n.item != nil {
+		clickCh = n.item.ClickedCh()
+	}
+	n.Unlock()
+	if clickCh == nil {
 		clickCh = make(chan struct{}, 2)
 	}
-	go func() {
+	ctx, cancel := context.WithCancel(n.indicator.ctx)
+	//follow listens to the clicks of the item the MenuNode has been bound to (see MoveBefore)
+	follow := func() {
+		n.RLock()
+		clickCh, rebound = n.item.ClickedCh(), n.rebound
+		n.RUnlock()
+	}
+	started := n.indicator.goTracked(func() {
+		defer cancel()
 		for {
 			select {
 			case <-clickCh:
-				callback(args...)
-				if et, testing := GetGuiProvider().GetEventTester(); testing {
+				n.RLock()
+				moved := n.rebound != rebound
+				n.RUnlock()
+				if moved {
+					//the click is handed to the MenuNode now bound to the item
+					select {
+					case clickCh <- struct{}{}:
+					default:
+					}
+					follow()
+					continue
+				}
+				//the clicks delivered while the item was being disabled by the read-only mode are ignored, as well as
+				//the repeated ones (see acceptClick)
+				i := n.indicator
+				if now := i.Now(); (!n.IsWriteAction() || !i.ReadOnly()) && n.acceptClick(now) {
+					handler.HandleClick(ctx, &ClickEvent{Indicator: i, Node: n, Time: now})
+					n.clickHandled(i.Now())
+				}
+				if et, testing := i.gProvider.GetEventTester(); testing {
 					et.Done()
 				}
 				if once {
 					return
 				}
-			case <-n.stopChan:
+			case <-rebound:
+				follow()
+			case <-stopChan:
 				return
-			case <-root.quitChan:
+			case <-ctx.Done():
 				return
 			}
 		}
-	}()
+	})
+	if !started {
+		cancel()
+	}
 }
 
 //Disconnect removes the event handler (if any) from the MenuNode.
@@ -239,6 +370,63 @@ func (n *MenuNode) UseListChild(title string, tag string) *MenuNode {
 	return n.nodeList.useNode(title, tag)
 }
 
+//SetListPageSize displays the LIST children of the MenuNode by pages of size entries, in the order they have been
+//used: the entries beyond the first page are displayed by clicking the "Show more…" entry following the list, and
+//their items are created only then. A size <= 0 displays all the entries.
+func (n *MenuNode) SetListPageSize(size int) {
+	n.Lock()
+	defer n.Unlock()
+	if n.nodeList == nil {
+		n.nodeList = newNodeList(n)
+	}
+	n.nodeList.setPageSize(size)
+}
+
+//SetListPages displays the first pages of the LIST children of the MenuNode, as if the "Show more…" entry had been
+//clicked pages-1 times (see SetListPageSize). It is a no-op if the LIST children are not paginated.
+func (n *MenuNode) SetListPages(pages int) {
+	n.Lock()
+	defer n.Unlock()
+	if n.nodeList == nil {
+		n.nodeList = newNodeList(n)
+	}
+	n.nodeList.setPages(pages)
+}
+
+//OnListShowMore sets the callback executed when the "Show more…" entry following the LIST children of the MenuNode
+//is clicked, receiving the number of pages displayed (see SetListPageSize). A nil handler removes it.
+func (n *MenuNode) OnListShowMore(handler func(pages int)) {
+	n.Lock()
+	defer n.Unlock()
+	if n.nodeList == nil {
+		n.nodeList = newNodeList(n)
+	}
+	n.nodeList.Lock()
+	n.nodeList.onShowMore = handler
+	n.nodeList.Unlock()
+}
+
+//SetListFilter displays only the LIST children of the MenuNode for which filter returns true, starting from the
+//first page (see SetListPageSize). A nil filter displays all of them. The filter is applied again as the children are
+//used and freed, or by RefreshListFilter: it must not call the methods of the MenuNode itself.
+func (n *MenuNode) SetListFilter(filter func(child *MenuNode) bool) {
+	n.Lock()
+	defer n.Unlock()
+	if n.nodeList == nil {
+		n.nodeList = newNodeList(n)
+	}
+	n.nodeList.setFilter(filter)
+}
+
+//RefreshListFilter applies again the filter of the LIST children of the MenuNode, e.g. after their content changed.
+func (n *MenuNode) RefreshListFilter() {
+	n.RLock()
+	defer n.RUnlock()
+	if n.nodeList != nil {
+		n.nodeList.refilter()
+	}
+}
+
 //FreeListChild marks a LIST MenuNode and its nested children as unused, graphically removing them
 //from the submenu of MenuNode n in the tray menu. This is a no-op in case of tagged child missing.
 func (n *MenuNode) FreeListChild(tag string) {
@@ -271,6 +459,16 @@ func (n *MenuNode) FreeListChildren() {
 	n.nodeList.freeAllNodes()
 }
 
+//ListChildren returns the LIST MenuNodes currently in use, in the order they are displayed.
+func (n *MenuNode) ListChildren() []*MenuNode {
+	n.RLock()
+	defer n.RUnlock()
+	if n.nodeList == nil {
+		return nil
+	}
+	return n.nodeList.usedNodesInOrder()
+}
+
 //ListChildrenLen returns the number of LIST MenuNode currently in use.
 func (n *MenuNode) ListChildrenLen() int {
 	n.RLock()
@@ -289,18 +487,18 @@ func (n *MenuNode) ListChildrenLen() int {
 //
 //		tag : unique tag for the OPTION
 //
-//		callback : callback function to be executed at each 'clicked' event. If callback == nil,
-//		the function can be set afterwards using n.Connect() .
+//		handler : handler of the 'clicked' events. If handler == nil,
+//		it can be set afterwards using n.Connect() .
 //
 //		withCheckbox : if true, add a graphic checkbox on the menu element.
-func (n *MenuNode) AddOption(title string, tag string, tooltip string, withCheckbox bool, callback func(args ...interface{}), args ...interface{}) *MenuNode {
-	o := newMenuNode(NodeTypeOption, withCheckbox, n)
+func (n *MenuNode) AddOption(title string, tag string, tooltip string, withCheckbox bool, handler ClickHandler) *MenuNode {
+	o := newMenuNode(n.indicator, NodeTypeOption, withCheckbox, n)
 	o.SetTitle(title)
 	o.SetTag(tag)
 	o.SetTooltip(tooltip)
 	o.SetIsVisible(true)
-	if callback != nil {
-		o.Connect(false, callback, args...)
+	if handler != nil {
+		o.Connect(false, handler)
 	}
 	n.Lock()
 	defer n.Unlock()
@@ -316,20 +514,112 @@ func (n *MenuNode) Option(tag string) (opt *MenuNode, present bool) {
 	return
 }
 
+//------ SUBMENU ------
+
+//AddSubmenu adds a SUBMENU to the MenuNode, expanding into a nested menu: its OPTIONs and SUBMENUs are added with
+//AddOption and AddSubmenu, at any depth, e.g.
+//
+//	peer := peers.AddSubmenu("cluster-a", "cluster-a")
+//	peer.AddOption("Start peering", "start", "", false, handler)
+//	peer.AddSubmenu("Resources", "resources").AddOption(...)
+//
+//The SUBMENU is visible by default and, unlike an ACTION, its entry does not handle clicks.
+func (n *MenuNode) AddSubmenu(title string, tag string) *MenuNode {
+	s := newMenuNode(n.indicator, NodeTypeSubmenu, false, n)
+	s.SetTitle(title)
+	s.SetTag(tag)
+	s.SetIsVisible(true)
+	n.Lock()
+	defer n.Unlock()
+	n.submenuMap[tag] = s
+	return s
+}
+
+//Submenu returns the *MenuNode of the SUBMENU with this specific tag. If such SUBMENU does not exist,
+//present = false.
+func (n *MenuNode) Submenu(tag string) (sub *MenuNode, present bool) {
+	n.RLock()
+	defer n.RUnlock()
+	sub, present = n.submenuMap[tag]
+	return
+}
+
+//Parent returns the MenuNode containing n in the menu tree, or n itself for the level-0 MenuNodes without a parent
+//(e.g. QUICKs).
+func (n *MenuNode) Parent() *MenuNode {
+	n.RLock()
+	defer n.RUnlock()
+	return n.parent
+}
+
 //------ GETTERS/SETTERS ------
 
 //SetTitle sets the text content of the MenuNode label.
 func (n *MenuNode) SetTitle(title string) {
 	n.Lock()
 	defer n.Unlock()
-	if n.nodeType == NodeTypeTitle {
+	//an unchanged title is not set again, so that the menu entry does not flicker
+	if n.titleSet && n.title == title {
+		return
+	}
+	n.titleSet = true
+	n.title = title
+	n.renderTitle(false)
+}
+
+//renderTitle displays the title of the MenuNode, translated in the current language. If checked == true, the title
+//is displayed with the check tick of the MenuNodes without a graphic checkbox.
+func (n *MenuNode) renderTitle(checked bool) {
+	title := i18n.T(n.title)
+	switch {
+	case n.nodeType == NodeTypeTitle:
 		//the TITLE MenuNode is also used to set the width of the entire menu window
-		n.item.SetTitle(strutil.CenterText(title, menuWidth))
+		title = strutil.CenterText(title, menuWidth)
+	case checked:
+		title += nodeIconChecked
+	case n.iconData != nil && n.indicator.gProvider.Supports(CapabilityItemIcons):
+	default:
+		title = n.icon + title
+	}
+	n.onGui(func(item Item) {
+		item.SetTitle(title)
+	})
+}
 
-	} else {
-		n.item.SetTitle(n.icon + title)
+//onGui runs f on the Item of the MenuNode through the queue of the Indicator (see RunOnGui), if the MenuNode has
+//one. It must be called with the lock held.
+func (n *MenuNode) onGui(f func(item Item)) {
+	item := n.item
+	if item == nil {
+		return
 	}
-	n.title = title
+	n.indicator.RunOnGui(func() {
+		f(item)
+	})
+}
+
+//itemChecked returns whether the Item of the MenuNode is checked. It must be called with the lock held.
+func (n *MenuNode) itemChecked() bool {
+	checked := n.isChecked
+	n.onGui(func(item Item) {
+		checked = item.Checked()
+	})
+	return checked
+}
+
+//itemDisabled returns whether the Item of the MenuNode is disabled. It must be called with the lock held.
+func (n *MenuNode) itemDisabled() bool {
+	disabled := !n.isEnabled || n.isWrite && n.indicator.ReadOnly()
+	n.onGui(func(item Item) {
+		disabled = item.Disabled()
+	})
+	return disabled
+}
+
+//nativeCheck returns whether the check mark of the MenuNode is displayed by the GuiBackend, instead of being
+//appended to its title.
+func (n *MenuNode) nativeCheck() bool {
+	return n.hasCheckbox || n.indicator.gProvider.Supports(CapabilityCheckmarks)
 }
 
 //Title returns the text content of the menu entry. Eventual check tick for checked MenuNode is not included.
@@ -353,11 +643,18 @@ func (n *MenuNode) SetTag(tag string) {
 	n.tag = tag
 }
 
-//SetTooltip sets a 'mouse hover' tooltip for the MenuNode. This is a no-op for Linux builds.
+//SetTooltip sets a 'mouse hover' tooltip for the MenuNode. It is not displayed if the GuiBackend does not support
+//CapabilityTooltips (e.g. on Linux builds).
 func (n *MenuNode) SetTooltip(tooltip string) {
 	n.Lock()
 	defer n.Unlock()
-	n.item.SetTooltip(tooltip)
+	n.tooltip = tooltip
+	if n.indicator.gProvider.Supports(CapabilityTooltips) {
+		text := i18n.T(tooltip)
+		n.onGui(func(item Item) {
+			item.SetTooltip(text)
+		})
+	}
 }
 
 //IsInvalid returns if the content of the LIST MenuNode is no more up to date and has to be refreshed by application
@@ -383,57 +680,133 @@ func (n *MenuNode) IsVisible() bool {
 	return n.isVisible
 }
 
+//IsListed returns false if the LIST MenuNode is hidden by the pagination or by the filter of its parent (see
+//SetListPageSize and SetListFilter).
+func (n *MenuNode) IsListed() bool {
+	n.RLock()
+	defer n.RUnlock()
+	return !n.unlisted
+}
+
 //SetIsVisible change the MenuNode visibility in the menu.
 func (n *MenuNode) SetIsVisible(isVisible bool) {
 	n.Lock()
 	defer n.Unlock()
-	if isVisible {
-		n.item.Show()
-		n.isVisible = true
-	} else {
-		n.item.Hide()
-		n.isVisible = false
-	}
+	n.isVisible = isVisible
+	n.showItem()
+}
+
+//showItem shows the item of the MenuNode if visible and not hidden by the pagination, hides it otherwise. It must be
+//called with the lock held.
+func (n *MenuNode) showItem() {
+	shown := n.isVisible && !n.unlisted
+	n.onGui(func(item Item) {
+		if shown {
+			item.Show()
+		} else {
+			item.Hide()
+		}
+	})
 }
 
 //IsEnabled returns if the MenuNode label is clickable by the user (if displayed).
 func (n *MenuNode) IsEnabled() bool {
 	n.RLock()
 	defer n.RUnlock()
-	return !n.item.Disabled()
+	return !n.itemDisabled()
 }
 
 //SetIsEnabled change MenuNode possibility to be clickable.
 func (n *MenuNode) SetIsEnabled(isEnabled bool) {
 	n.Lock()
 	defer n.Unlock()
-	if isEnabled {
-		n.item.Enable()
-	} else {
-		n.item.Disable()
-	}
+	n.isEnabled = isEnabled
+	n.applyEnabled()
+}
+
+//applyEnabled enables or disables the item of the MenuNode according to its requested state and to the read-only
+//mode of the Indicator. It must be called with the lock held.
+func (n *MenuNode) applyEnabled() {
+	isEnabled := n.isEnabled && !(n.isWrite && n.indicator.ReadOnly())
+	n.onGui(func(item Item) {
+		if isEnabled && item.Disabled() {
+			item.Enable()
+		} else if !isEnabled && !item.Disabled() {
+			item.Disable()
+		}
+	})
 }
 
 //IsChecked returns if MenuNode has been checked.
 func (n *MenuNode) IsChecked() bool {
 	n.RLock()
 	defer n.RUnlock()
-	return n.item.Checked()
+	return n.itemChecked()
 }
 
 //SetIsChecked (un)check the MenuNode.
 func (n *MenuNode) SetIsChecked(isChecked bool) {
 	n.Lock()
 	defer n.Unlock()
-	if isChecked && !n.item.Checked() {
-		if !n.hasCheckbox {
-			n.item.SetTitle(n.title + nodeIconChecked)
+	if isChecked == n.itemChecked() {
+		return
+	}
+	n.isChecked = isChecked
+	if !n.nativeCheck() {
+		n.renderTitle(isChecked)
+	}
+	n.onGui(func(item Item) {
+		if isChecked {
+			item.Check()
+		} else {
+			item.Uncheck()
 		}
-		n.item.Check()
-	} else if !isChecked && n.item.Checked() {
-		if !n.hasCheckbox {
-			n.item.SetTitle(n.title)
+	})
+}
+
+//IsRadio returns whether the MenuNode is checked as a radio button.
+func (n *MenuNode) IsRadio() bool {
+	n.RLock()
+	defer n.RUnlock()
+	return n.isRadio
+}
+
+//SetIsRadio sets whether the MenuNode is checked as a radio button, being a choice exclusive of its siblings (e.g.
+//the active one among the kubeconfig contexts), instead of a check mark. The exclusivity is up to the application
+//logic, which checks a single sibling at a time.
+func (n *MenuNode) SetIsRadio(isRadio bool) {
+	n.Lock()
+	defer n.Unlock()
+	if n.isRadio == isRadio {
+		return
+	}
+	n.isRadio = isRadio
+	n.onGui(func(item Item) {
+		if isRadio {
+			item.SetToggleType(ToggleRadio)
+		} else {
+			item.SetToggleType(ToggleCheckmark)
 		}
-		n.item.Uncheck()
+	})
+}
+
+//SetIcon sets the icon displayed next to the MenuNode title, provided as a PNG image, replacing its text prefix
+//(e.g. the one of the QUICKs). A nil icon restores the text prefix. The icon is not displayed by the GuiBackends
+//without CapabilityItemIcons.
+func (n *MenuNode) SetIcon(icon []byte) {
+	n.Lock()
+	defer n.Unlock()
+	if n.iconData == nil && icon == nil {
+		return
+	}
+	n.iconData = icon
+	if !n.indicator.gProvider.Supports(CapabilityItemIcons) {
+		return
+	}
+	n.onGui(func(item Item) {
+		item.SetIcon(icon)
+	})
+	if n.titleSet {
+		n.renderTitle(!n.nativeCheck() && n.itemChecked())
 	}
 }
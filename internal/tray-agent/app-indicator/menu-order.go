@@ -0,0 +1,191 @@
+package app_indicator
+
+import (
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/i18n"
+)
+
+/*This file contains the reordering of the MenuNodes at runtime. The GuiBackends supporting CapabilityReorder move
+their entries directly, while systray only appends the new entries at the bottom of a menu: there, a MenuNode is
+moved by binding each MenuNode between its old and its new position to the entry of its neighbour, drawing again its
+state (title, check mark, visibility, ...) and listening to the clicks of the new entry. The entries with a submenu
+cannot be exchanged, since their nested entries would not follow them.*/
+
+//MoveBefore moves the MenuNode right before sibling, a MenuNode displayed in the same menu (e.g. two QUICKs, or two
+//LIST children of the same ACTION). It returns an error if the MenuNodes are not siblings or if, with a GuiBackend
+//without CapabilityReorder, a MenuNode to be exchanged has nested MenuNodes.
+func (n *MenuNode) MoveBefore(sibling *MenuNode) error {
+	return n.indicator.moveNode(n, sibling, false)
+}
+
+//MoveAfter moves the MenuNode right after sibling, a MenuNode displayed in the same menu (see MoveBefore).
+func (n *MenuNode) MoveAfter(sibling *MenuNode) error {
+	return n.indicator.moveNode(n, sibling, true)
+}
+
+//ReorderQuicks moves the QUICKs with the given tags, in this order, in place of the first QUICK of the menu, while
+//the other ones keep their relative order, e.g. ReorderQuicks(qPeerB, qPeerA). It returns an error if a tag is
+//unknown or a QUICK cannot be moved (see (*MenuNode).MoveBefore).
+func (i *Indicator) ReorderQuicks(tags ...string) error {
+	first := make([]*MenuNode, 0, len(tags))
+	for _, tag := range tags {
+		q, present := i.Quick(tag)
+		if !present {
+			return fmt.Errorf("unknown QUICK %s", tag)
+		}
+		first = append(first, q)
+	}
+	var head *MenuNode
+	i.nodesMutex.Lock()
+	for _, n := range i.topNodes {
+		if n.nodeType == NodeTypeQuick {
+			head = n
+			break
+		}
+	}
+	i.nodesMutex.Unlock()
+	var previous *MenuNode
+	for _, q := range first {
+		var err error
+		if previous == nil {
+			err = q.MoveBefore(head)
+		} else {
+			err = q.MoveAfter(previous)
+		}
+		if err != nil {
+			return err
+		}
+		previous = q
+	}
+	return nil
+}
+
+//moveNode moves n next to sibling: after it if after == true, before it otherwise.
+func (i *Indicator) moveNode(n *MenuNode, sibling *MenuNode, after bool) error {
+	if n == sibling {
+		return nil
+	}
+	i.moveMutex.Lock()
+	defer i.moveMutex.Unlock()
+	i.nodesMutex.Lock()
+	siblings := &i.topNodes
+	if n.nested {
+		siblings = &n.parent.children
+	}
+	from := indexOfNode(*siblings, n)
+	if from < 0 || indexOfNode(*siblings, sibling) < 0 {
+		i.nodesMutex.Unlock()
+		//the tags are read without holding the nodesMutex, since the MenuNodes lock it while adding a child
+		return fmt.Errorf("MenuNodes %s and %s are not displayed in the same menu", n.Tag(), sibling.Tag())
+	}
+	order := make([]*MenuNode, 0, len(*siblings))
+	for _, s := range *siblings {
+		switch {
+		case s == n:
+		case s == sibling && after:
+			order = append(order, s, n)
+		case s == sibling:
+			order = append(order, n, s)
+		default:
+			order = append(order, s)
+		}
+	}
+	low, high := from, indexOfNode(order, n)
+	if low > high {
+		low, high = high, low
+	}
+	if low == high {
+		i.nodesMutex.Unlock()
+		return nil
+	}
+	native := i.gProvider.Supports(CapabilityReorder)
+	if !native {
+		for _, s := range (*siblings)[low : high+1] {
+			if len(s.children) > 0 {
+				i.nodesMutex.Unlock()
+				return fmt.Errorf("the %s GuiBackend cannot move the menu entries with a submenu",
+					i.gProvider.Backend())
+			}
+		}
+	}
+	previous := append([]*MenuNode(nil), (*siblings)[low:high+1]...)
+	*siblings = order
+	i.nodesMutex.Unlock()
+	if native {
+		item, siblingItem := n.item, sibling.item
+		if item == nil || siblingItem == nil {
+			return nil
+		}
+		i.RunOnGui(func() {
+			i.gProvider.MoveItem(item, siblingItem, after)
+		})
+		return nil
+	}
+	//each MenuNode takes the entry previously displayed at its new position
+	items := make([]Item, len(previous))
+	hasCheckbox := make([]bool, len(previous))
+	checked := make(map[*MenuNode]bool, len(previous))
+	for k, p := range previous {
+		p.RLock()
+		items[k], hasCheckbox[k], checked[p] = p.item, p.hasCheckbox, p.itemChecked()
+		p.RUnlock()
+	}
+	for k, m := range order[low : high+1] {
+		m.bind(items[k], hasCheckbox[k], checked[m])
+	}
+	return nil
+}
+
+//bind binds the MenuNode to the entry previously displaying a sibling, drawing the state of the node on it.
+func (n *MenuNode) bind(item Item, hasCheckbox bool, checked bool) {
+	n.Lock()
+	defer n.Unlock()
+	n.draw(item, hasCheckbox, checked)
+}
+
+//draw binds the MenuNode to item, drawing the state of the node on it. It must be called with the lock held.
+func (n *MenuNode) draw(item Item, hasCheckbox bool, checked bool) {
+	n.item, n.hasCheckbox, n.isChecked = item, hasCheckbox, checked
+	if n.titleSet {
+		n.renderTitle(checked && !n.nativeCheck())
+	}
+	tooltip := i18n.T(n.tooltip)
+	tooltips := n.indicator.gProvider.Supports(CapabilityTooltips)
+	icons := n.indicator.gProvider.Supports(CapabilityItemIcons)
+	titleSet, iconData, isRadio := n.titleSet, n.iconData, n.isRadio
+	n.onGui(func(item Item) {
+		if !titleSet {
+			item.SetTitle("")
+		}
+		if tooltips {
+			item.SetTooltip(tooltip)
+		}
+		if icons {
+			item.SetIcon(iconData)
+		}
+		if isRadio {
+			item.SetToggleType(ToggleRadio)
+		} else {
+			item.SetToggleType(ToggleCheckmark)
+		}
+		if checked {
+			item.Check()
+		} else {
+			item.Uncheck()
+		}
+	})
+	n.applyEnabled()
+	n.showItem()
+	close(n.rebound)
+	n.rebound = make(chan struct{})
+}
+
+//indexOfNode returns the index of n in nodes, -1 if not present.
+func indexOfNode(nodes []*MenuNode, n *MenuNode) int {
+	for k, node := range nodes {
+		if node == n {
+			return k
+		}
+	}
+	return -1
+}
@@ -0,0 +1,82 @@
+package app_indicator
+
+import (
+	"context"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMoveMenuNodes(t *testing.T) {
+	defer SetMockedCapabilities(^Capability(0))
+	//the MenuNodes are moved by the GuiBackend, or by exchanging their entries without CapabilityReorder
+	for _, exchange := range []bool{false, true} {
+		UseMockedGuiProvider()
+		client.UseMockedAgentController()
+		DestroyMockedIndicator()
+		client.DestroyMockedAgentController()
+		if exchange {
+			SetMockedCapabilities(^CapabilityReorder)
+		} else {
+			SetMockedCapabilities(^Capability(0))
+		}
+		i := GetIndicator()
+		backend := GetGuiProvider().(*guiProvider).backend.(*mockBackend)
+		et := GetGuiProvider().NewEventTester()
+		et.Test()
+		var clicked []string
+		var mutex sync.Mutex
+		handler := func(tag string) ClickHandler {
+			return ClickHandlerFunc(func(ctx context.Context, e *ClickEvent) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				clicked = append(clicked, tag)
+			})
+		}
+		a := i.AddQuick("A", "A", handler("A"))
+		b := i.AddQuick("B", "B", handler("B"))
+		c := i.AddQuick("C", "C", handler("C"))
+		quicks := func() string {
+			var titles []string
+			for _, title := range backend.mockedOrder(nil) {
+				if strings.HasPrefix(title, nodeIconQuick) {
+					titles = append(titles, strings.TrimPrefix(title, nodeIconQuick))
+				}
+			}
+			return strings.Join(titles, "")
+		}
+		msg := fmt.Sprintf("exchange = %v", exchange)
+		assert.Equal(t, "ABC", quicks(), msg)
+		c.SetIsChecked(true)
+		b.SetIsVisible(false)
+		assert.NoError(t, c.MoveBefore(a), msg)
+		assert.Equal(t, "CAB", quicks(), msg)
+		assert.NoError(t, a.MoveAfter(b), msg)
+		assert.Equal(t, "CBA", quicks(), msg)
+		assert.NoError(t, i.ReorderQuicks("B"), msg)
+		assert.Equal(t, "BCA", quicks(), msg)
+		assert.Error(t, i.ReorderQuicks("D"), msg)
+		//the state and the clicks follow the moved MenuNodes
+		assert.True(t, c.IsChecked(), msg)
+		assert.False(t, b.item.(*mockItem).Visible(), msg)
+		assert.True(t, a.item.(*mockItem).Visible(), msg)
+		et.Add(1)
+		c.Channel() <- struct{}{}
+		et.Wait()
+		assert.Equal(t, []string{"C"}, clicked, msg)
+		//the MenuNodes with a submenu can be exchanged only by moving their entries
+		o := a.AddOption("option", "OPTION_MOVE", "", false, nil)
+		if exchange {
+			assert.Error(t, a.MoveBefore(c), msg)
+		} else {
+			assert.NoError(t, a.MoveBefore(c), msg)
+			assert.Equal(t, "BAC", quicks(), msg)
+		}
+		//only siblings can be moved
+		assert.Error(t, o.MoveBefore(b), msg)
+		i.Quit()
+	}
+}
@@ -0,0 +1,70 @@
+package app_indicator
+
+/*This file contains the SECTIONs of the tray menu. A SECTION groups the level-0 MenuNodes added after it (QUICKs,
+ACTIONs and top level SUBMENUs) under a non clickable header, until the next SECTION or EndSection: the separators
+above and below the group are added automatically, without doubling the adjacent ones.*/
+
+//AddSection adds a SECTION to the indicator menu, closing the previous one: the QUICKs, ACTIONs and SUBMENUs added
+//afterwards are grouped under its header, until the next SECTION or a call to EndSection. It is visible by default.
+//
+//	title : header displayed in the menu
+//
+//	tag : unique tag for the SECTION
+func (i *Indicator) AddSection(title string, tag string) *MenuNode {
+	i.EndSection()
+	i.separate()
+	s := newMenuNode(i, NodeTypeSection, false, nil)
+	s.parent = i.menu
+	s.SetTitle(title)
+	s.SetTag(tag)
+	s.SetIsVisible(true)
+	i.nodesMutex.Lock()
+	defer i.nodesMutex.Unlock()
+	i.openSection = s
+	i.sectionMap[tag] = s
+	return s
+}
+
+//EndSection closes the SECTION grouping the level-0 MenuNodes being added, adding a separator below it. It is a
+//no-op if no SECTION is open.
+func (i *Indicator) EndSection() {
+	i.nodesMutex.Lock()
+	open := i.openSection != nil
+	i.openSection = nil
+	i.nodesMutex.Unlock()
+	if open {
+		i.separate()
+	}
+}
+
+//Section returns the *MenuNode of the SECTION with this specific tag. If not present, present = false.
+func (i *Indicator) Section(tag string) (section *MenuNode, present bool) {
+	i.nodesMutex.Lock()
+	defer i.nodesMutex.Unlock()
+	section, present = i.sectionMap[tag]
+	return
+}
+
+//separate adds a separator to the menu, unless its last entry is a separator already.
+func (i *Indicator) separate() {
+	i.nodesMutex.Lock()
+	separated := i.separated
+	i.nodesMutex.Unlock()
+	if !separated {
+		i.AddSeparator()
+	}
+}
+
+//Members returns the MenuNodes grouped by the SECTION, in the order they have been added.
+func (n *MenuNode) Members() []*MenuNode {
+	n.indicator.nodesMutex.Lock()
+	defer n.indicator.nodesMutex.Unlock()
+	return append([]*MenuNode(nil), n.members...)
+}
+
+//Section returns the SECTION grouping the MenuNode, nil if none.
+func (n *MenuNode) Section() *MenuNode {
+	n.indicator.nodesMutex.Lock()
+	defer n.indicator.nodesMutex.Unlock()
+	return n.section
+}
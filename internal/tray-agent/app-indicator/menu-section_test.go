@@ -0,0 +1,51 @@
+package app_indicator
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSections(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	i := GetIndicator()
+	backend := GetGuiProvider().(*guiProvider).backend.(*mockBackend)
+	top := i.AddQuick("top", "QUICK_TOP", nil)
+	peers := i.AddSection("Peers", "peers")
+	a := i.AddQuick("a", "QUICK_A", nil)
+	act := i.AddAction("action", "ACTION_SECTION", nil)
+	//the separators are not doubled
+	i.AddSeparator()
+	settings := i.AddSection("Settings", "settings")
+	b := i.AddQuick("b", "QUICK_B", nil)
+	i.EndSection()
+	i.EndSection()
+	quit := i.AddQuick("quit", "QUICK_QUIT", nil)
+	order := backend.mockedOrder(nil)
+	assert.Equal(t, []string{nodeIconQuick + "top", mockedSeparator, "Peers", nodeIconQuick + "a",
+		nodeIconAction + "action", mockedSeparator, "Settings", nodeIconQuick + "b", mockedSeparator,
+		nodeIconQuick + "quit"}, order[len(order)-10:])
+	//the headers are not clickable
+	assert.Equal(t, NodeTypeSection, peers.nodeType)
+	assert.False(t, peers.IsEnabled())
+	assert.True(t, peers.IsVisible())
+	if s, present := i.Section("settings"); assert.True(t, present) {
+		assert.Equal(t, settings, s)
+	}
+	_, present := i.Section("unknown")
+	assert.False(t, present)
+	//the level-0 MenuNodes added after a SECTION are grouped by it
+	assert.Equal(t, []*MenuNode{a, act}, peers.Members())
+	assert.Equal(t, []*MenuNode{b}, settings.Members())
+	assert.Equal(t, peers, act.Section())
+	assert.Nil(t, top.Section())
+	assert.Nil(t, quit.Section())
+	//the nested ones belong to their parent
+	o := act.AddOption("option", "OPTION_SECTION", "", false, nil)
+	assert.Nil(t, o.Section())
+	assert.Len(t, peers.Members(), 2)
+	i.Quit()
+}
@@ -0,0 +1,115 @@
+// +build linux
+
+package app_indicator
+
+import (
+	"errors"
+	"github.com/godbus/dbus/v5"
+	"strconv"
+	"sync"
+)
+
+//The fdo actionNotifier sends the banners to the notification server of the D-Bus session bus, following the
+//freedesktop Desktop Notifications specification.
+func init() {
+	actionNotifierFactory = newFdoNotifier
+}
+
+const (
+	fdoNotificationsName  = "org.freedesktop.Notifications"
+	fdoNotificationsPath  = "/org/freedesktop/Notifications"
+	fdoNotificationsIface = "org.freedesktop.Notifications"
+	//fdoCapabilityActions is the capability of the notification servers displaying the action buttons.
+	fdoCapabilityActions = "actions"
+	//fdoExpireDefault lets the notification server decide when the banner expires.
+	fdoExpireDefault = int32(-1)
+)
+
+func newFdoNotifier() (actionNotifier, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return nil, err
+	}
+	var capabilities []string
+	if err := conn.Object(fdoNotificationsName, fdoNotificationsPath).
+		Call(fdoNotificationsIface+".GetCapabilities", 0).Store(&capabilities); err != nil {
+		return nil, err
+	}
+	supported := false
+	for _, c := range capabilities {
+		supported = supported || c == fdoCapabilityActions
+	}
+	if !supported {
+		return nil, errors.New("the notification server does not support the action buttons")
+	}
+	if err := conn.AddMatchSignal(dbus.WithMatchObjectPath(fdoNotificationsPath),
+		dbus.WithMatchInterface(fdoNotificationsIface)); err != nil {
+		return nil, err
+	}
+	n := &fdoNotifier{
+		conn:      conn,
+		callbacks: make(map[uint32]func(index int)),
+	}
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
+	go n.dispatch(signals)
+	return n, nil
+}
+
+//fdoNotifier is the actionNotifier implementing the freedesktop Desktop Notifications specification.
+type fdoNotifier struct {
+	conn *dbus.Conn
+	//callbacks contains the callbacks of the displayed banners, indexed by the ID assigned by the server.
+	callbacks map[uint32]func(index int)
+	//mutex protects callbacks.
+	mutex sync.Mutex
+}
+
+//notify implements the actionNotifier interface. The key of each action is the index of its label.
+func (n *fdoNotifier) notify(id uint32, title string, message string, iconPath string, labels []string,
+	onAction func(index int)) (uint32, error) {
+	actions := make([]string, 0, 2*len(labels))
+	for index, label := range labels {
+		actions = append(actions, strconv.Itoa(index), label)
+	}
+	var newID uint32
+	err := n.conn.Object(fdoNotificationsName, fdoNotificationsPath).Call(fdoNotificationsIface+".Notify", 0,
+		"Liqo Agent", id, iconPath, title, message, actions, map[string]dbus.Variant{}, fdoExpireDefault).
+		Store(&newID)
+	if err != nil {
+		return 0, err
+	}
+	n.mutex.Lock()
+	delete(n.callbacks, id)
+	n.callbacks[newID] = onAction
+	n.mutex.Unlock()
+	return newID, nil
+}
+
+//dispatch routes the clicks on the action buttons to the callbacks of their banners, until the connection
+//is closed.
+func (n *fdoNotifier) dispatch(signals <-chan *dbus.Signal) {
+	for s := range signals {
+		if len(s.Body) < 2 {
+			continue
+		}
+		id, ok := s.Body[0].(uint32)
+		if !ok {
+			continue
+		}
+		switch s.Name {
+		case fdoNotificationsIface + ".ActionInvoked":
+			key, _ := s.Body[1].(string)
+			n.mutex.Lock()
+			callback, present := n.callbacks[id]
+			n.mutex.Unlock()
+			if index, err := strconv.Atoi(key); present && err == nil {
+				go callback(index)
+			}
+		case fdoNotificationsIface + ".NotificationClosed":
+			n.mutex.Lock()
+			delete(n.callbacks, id)
+			n.mutex.Unlock()
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package app_indicator
+
+import (
+	"github.com/agrison/go-commons-lang/stringUtils"
+)
+
+/*This file contains the desktop banners offering the Actions of a Notification as buttons. They are displayed by a
+notification server supporting the actions of the freedesktop Desktop Notifications specification: when the user
+clicks a button, the Handler of the corresponding NotificationAction is performed as if a menu entry was clicked.
+Without such a server, the banners are displayed without buttons.*/
+
+//actionNotifier displays desktop banners with action buttons.
+type actionNotifier interface {
+	//notify displays a banner with a button for each label. When the user clicks one of them, onAction is called
+	//with its index. id is the value returned for a previous banner to be replaced by the new one, or 0.
+	notify(id uint32, title string, message string, iconPath string, labels []string,
+		onAction func(index int)) (uint32, error)
+}
+
+//actionNotifierFactory creates the actionNotifier of the current platform, if any. It returns an error if no
+//notification server supporting the action buttons is available.
+var actionNotifierFactory func() (actionNotifier, error)
+
+//getActionNotifier returns the actionNotifier displaying the banners of the Indicator, connecting to the
+//notification server at the first call. It returns nil if the banners cannot have action buttons.
+func (i *Indicator) getActionNotifier() actionNotifier {
+	i.actionNotifierOnce.Do(func() {
+		if i.actionNotifier != nil || i.gProvider.Mocked() || actionNotifierFactory == nil {
+			return
+		}
+		notifier, err := actionNotifierFactory()
+		if err != nil {
+			guiLogger.Debug("notification banners without action buttons", "err", err)
+			return
+		}
+		i.actionNotifier = notifier
+	})
+	return i.actionNotifier
+}
+
+//showActionBanner displays a Notification with some Actions as a desktop banner with a button for each Action,
+//returning whether it did. A banner replaces the one of the active Notification with the same ID. The Actions
+//without a Handler only close the banner (e.g. "Dismiss").
+func (i *Indicator) showActionBanner(n Notification, iconPath string) bool {
+	if len(n.Actions) == 0 || !i.gProvider.Supports(CapabilityNotificationsWithActions) {
+		return false
+	}
+	notifier := i.getActionNotifier()
+	if notifier == nil {
+		return false
+	}
+	labels := make([]string, len(n.Actions))
+	for index, a := range n.Actions {
+		labels[index] = a.Label
+	}
+	actions := n.Actions
+	var replaced uint32
+	i.notificationsMutex.RLock()
+	if n.ID != "" {
+		replaced = i.bannerIDs[n.ID]
+	}
+	i.notificationsMutex.RUnlock()
+	message := stringUtils.Capitalize(n.Message)
+	bannerID, err := notifier.notify(replaced, n.Title, message, iconPath, labels, func(index int) {
+		if index < 0 || index >= len(actions) || actions[index].Handler == nil {
+			return
+		}
+		actions[index].Handler.HandleClick(i.ctx, &ClickEvent{Indicator: i, Time: i.Now()})
+	})
+	if err != nil {
+		guiLogger.Error(err, "cannot display the notification banner")
+		return false
+	}
+	if n.ID != "" {
+		i.notificationsMutex.Lock()
+		i.bannerIDs[n.ID] = bannerID
+		i.notificationsMutex.Unlock()
+	}
+	return true
+}
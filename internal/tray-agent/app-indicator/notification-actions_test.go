@@ -0,0 +1,72 @@
+package app_indicator
+
+import (
+	"context"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+//bannerRecorder is an actionNotifier recording the displayed banners.
+type bannerRecorder struct {
+	lastID   uint32
+	replaced []uint32
+	labels   [][]string
+	titles   []string
+	messages []string
+	onAction func(index int)
+}
+
+func (r *bannerRecorder) notify(id uint32, title string, message string, iconPath string, labels []string,
+	onAction func(index int)) (uint32, error) {
+	r.lastID++
+	r.replaced = append(r.replaced, id)
+	r.labels = append(r.labels, labels)
+	r.titles = append(r.titles, title)
+	r.messages = append(r.messages, message)
+	r.onAction = onAction
+	return r.lastID, nil
+}
+
+func TestShowActionBanner(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	i := GetIndicator()
+	recorder := &bannerRecorder{}
+	i.actionNotifier = recorder
+	i.config.notifyLevel = NotifyLevelMax
+	accepted := 0
+	n := Notification{ID: "request", Title: "title", Message: "message", Actions: []NotificationAction{
+		{Label: "Accept peering", Handler: ClickHandlerFunc(func(ctx context.Context, e *ClickEvent) {
+			accepted++
+		})},
+		{Label: "Dismiss"},
+	}}
+	//the notifications without actions are displayed as plain banners
+	i.ShowNotification(Notification{Title: "plain"})
+	assert.Empty(t, recorder.labels, "banner without actions displayed with buttons")
+	i.ShowNotification(n)
+	if assert.Len(t, recorder.labels, 1, "banner with actions not displayed with buttons") {
+		assert.Equal(t, []string{"Accept peering", "Dismiss"}, recorder.labels[0])
+		assert.Equal(t, uint32(0), recorder.replaced[0])
+	}
+	recorder.onAction(0)
+	assert.Equal(t, 1, accepted, "action handler not performed")
+	//the actions without handler and the unknown actions only close the banner
+	recorder.onAction(1)
+	recorder.onAction(5)
+	assert.Equal(t, 1, accepted)
+	//an updated notification replaces its banner
+	n.Message = "updated"
+	i.ShowNotification(n)
+	if assert.Len(t, recorder.labels, 2) {
+		assert.Equal(t, uint32(1), recorder.replaced[1], "banner of the active notification not replaced")
+	}
+	//the banners follow the notification level
+	i.DismissNotification("request")
+	i.config.notifyLevel = NotifyLevelMin
+	i.ShowNotification(n)
+	assert.Len(t, recorder.labels, 2, "banner displayed with NotifyLevelMin")
+}
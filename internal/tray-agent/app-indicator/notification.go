@@ -0,0 +1,357 @@
+package app_indicator
+
+import (
+	"fmt"
+	"github.com/agrison/go-commons-lang/stringUtils"
+	bip "github.com/gen2brain/beeep"
+	"github.com/gen2brain/dlgs"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/i18n"
+	"github.com/ozgio/strutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*This file contains the unified notification API of the Indicator. A Notification carries the whole description of
+an event (severity, category, target resource and the actions the user can take), and ShowNotification presents it
+as a desktop banner or as a dialog box. The other notification helpers (e.g. Notify, ShowError, ShowClientError) are
+shorthands building a Notification.
+
+The Notifications with an ID are tracked while active: a Notification replaces the active one with the same ID, and
+it is not displayed again while its content does not change (e.g. a failure reported at each check).*/
+
+//NotificationIDConnection is the ID of the Notifications about the connection with the cluster.
+const NotificationIDConnection = "connection"
+
+//Severity is the severity of a Notification.
+type Severity int
+
+const (
+	//SeverityInfo signals an informative event.
+	SeverityInfo Severity = iota
+	//SeverityWarning signals an event the user should be aware of.
+	SeverityWarning
+	//SeverityError signals a failure. The error Notifications are critical: by default, they are displayed as
+	//banners also during the quiet hours (see SetQuietThreshold).
+	SeverityError
+)
+
+//severityNames maps the Severity values into their names.
+var severityNames = map[Severity]string{
+	SeverityInfo:    "info",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+}
+
+//String returns the name of the Severity, e.g. "warning".
+func (s Severity) String() string {
+	if name, present := severityNames[s]; present {
+		return name
+	}
+	return "unknown"
+}
+
+//ParseSeverity returns the Severity with a given name (e.g. "warning", or its "warn" abbreviation).
+func ParseSeverity(name string) (Severity, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "warn" {
+		return SeverityWarning, nil
+	}
+	for s, n := range severityNames {
+		if n == name {
+			return s, nil
+		}
+	}
+	return SeverityInfo, fmt.Errorf("invalid severity '%s', expected info, warning or error", name)
+}
+
+//NotificationCategory is the subject of a Notification.
+type NotificationCategory string
+
+const (
+	//CategoryGeneral is the category of the Notifications without a specific subject.
+	CategoryGeneral NotificationCategory = ""
+	//CategoryConnection is the category of the Notifications about the connection with the cluster.
+	CategoryConnection NotificationCategory = "connection"
+	//CategoryPeering is the category of the Notifications about the peerings.
+	CategoryPeering NotificationCategory = "peering"
+	//CategoryResources is the category of the Notifications about the resources and the workloads.
+	CategoryResources NotificationCategory = "resources"
+	//CategoryOperation is the category of the Notifications about the operations started by the user.
+	CategoryOperation NotificationCategory = "operation"
+	//CategorySecurity is the category of the Notifications about credentials and identities.
+	CategorySecurity NotificationCategory = "security"
+)
+
+//NotificationTarget is the resource a Notification refers to.
+type NotificationTarget struct {
+	//Kind is the kind of the resource, e.g. "ForeignCluster".
+	Kind string
+	//Name is the name of the resource, e.g. "namespace/name" for namespaced resources.
+	Name string
+}
+
+//String returns the description of the NotificationTarget, e.g. "ForeignCluster/name", or an empty string if no
+//resource is targeted.
+func (t NotificationTarget) String() string {
+	if t.Name == "" {
+		return ""
+	}
+	return t.Kind + "/" + t.Name
+}
+
+//NotificationAction is an action the user can perform in response to a Notification.
+type NotificationAction struct {
+	//Label is the text describing the action, e.g. "Open the dashboard".
+	Label string
+	//Handler performs the action. If nil, the action only closes the Notification (e.g. "Dismiss").
+	Handler ClickHandler
+}
+
+//Notification describes an event to be presented to the user.
+type Notification struct {
+	//ID identifies the Notification across its updates. If empty, the Notification is not tracked.
+	ID       string
+	Title    string
+	Message  string
+	Severity Severity
+	Category NotificationCategory
+	//Target is the resource the Notification refers to, if any.
+	Target NotificationTarget
+	//Actions are the actions offered to the user, as buttons of the desktop banner (if supported by the
+	//notification server) or as choices of the dialog box.
+	Actions []NotificationAction
+	//Dialog specifies whether the Notification is displayed as a dialog box, regardless of the NotifyLevel, instead
+	//of a desktop banner.
+	Dialog bool
+	//Time is the instant the Notification has been shown, set by ShowNotification.
+	Time time.Time
+	//trayIcon is the Icon displayed in the tray bar after the Notification, if trayIconSet.
+	trayIcon    Icon
+	trayIconSet bool
+	//bannerIcon is the NotifyIcon displayed inside the banner, if bannerIconSet. It defaults to the one of the
+	//Severity.
+	bannerIcon    NotifyIcon
+	bannerIconSet bool
+}
+
+//WithTrayIcon returns a copy of the Notification changing the tray icon to icon when displayed.
+func (n Notification) WithTrayIcon(icon Icon) Notification {
+	n.trayIcon, n.trayIconSet = icon, true
+	return n
+}
+
+//TrayIcon returns the Icon displayed in the tray bar after the Notification, or IconLiqoNil if the current one
+//is kept.
+func (n Notification) TrayIcon() Icon {
+	if !n.trayIconSet {
+		return IconLiqoNil
+	}
+	return n.trayIcon
+}
+
+//BannerIcon returns the NotifyIcon displayed inside the banner of the Notification.
+func (n Notification) BannerIcon() NotifyIcon {
+	switch {
+	case n.bannerIconSet:
+		return n.bannerIcon
+	case n.Severity == SeverityError:
+		return NotifyIconError
+	case n.Severity == SeverityWarning:
+		return NotifyIconWarning
+	default:
+		return NotifyIconDefault
+	}
+}
+
+//sameContent returns whether two Notifications display the same content.
+func (n Notification) sameContent(other Notification) bool {
+	return n.Title == other.Title && n.Message == other.Message && n.Severity == other.Severity &&
+		n.Dialog == other.Dialog
+}
+
+//ShowNotification presents a Notification to the user. Desktop banners follow the NotifyLevel of the Indicator and
+//the quiet hours, while dialog boxes are always displayed: if the Notification has some Actions, the banner offers
+//them as buttons and the dialog box asks the user which one to perform. A Notification with the same content of the active one with the same ID is
+//not displayed again.
+func (i *Indicator) ShowNotification(n Notification) {
+	n.Time = i.Now()
+	if n.ID != "" && !i.trackNotification(n) {
+		return
+	}
+	//the Notification is tracked in English, and displayed in the current language
+	n = n.translated()
+	if i.logNotification(n) {
+		i.SetIcon(n.TrayIcon())
+		return
+	}
+	if !n.Dialog {
+		i.showBanner(n)
+		return
+	}
+	i.SetIcon(n.TrayIcon())
+	if action := i.showDialog(n); action != nil && action.Handler != nil {
+		action.Handler.HandleClick(i.ctx, &ClickEvent{Indicator: i, Time: i.Now()})
+	}
+}
+
+//translated returns a copy of the Notification, with its texts translated in the current language.
+func (n Notification) translated() Notification {
+	n.Title, n.Message = i18n.T(n.Title), i18n.T(n.Message)
+	actions := make([]NotificationAction, len(n.Actions))
+	for index, a := range n.Actions {
+		actions[index] = NotificationAction{Label: i18n.T(a.Label), Handler: a.Handler}
+	}
+	if n.Actions != nil {
+		n.Actions = actions
+	}
+	return n
+}
+
+//logNotification hands a Notification to the GuiBackend, if it cannot display banners and dialog boxes, returning
+//whether it did. The Actions of such Notification are not performed.
+func (i *Indicator) logNotification(n Notification) bool {
+	p, ok := i.gProvider.(*guiProvider)
+	if !ok {
+		return false
+	}
+	logger, ok := p.backend.(notificationLogger)
+	if ok {
+		logger.notify(n)
+	}
+	return ok
+}
+
+//trackNotification records a Notification with an ID as the active one, returning false if it has the same content
+//of the previous one.
+func (i *Indicator) trackNotification(n Notification) bool {
+	i.notificationsMutex.Lock()
+	defer i.notificationsMutex.Unlock()
+	if old, present := i.notifications[n.ID]; present && old.sameContent(n) {
+		return false
+	}
+	i.notifications[n.ID] = n
+	return true
+}
+
+//showBanner displays a Notification as a desktop banner, depending on the current NotifyLevel of the Indicator.
+//If present in xdg.DirData, the NotifyIcon of the Notification is shown inside the banner.
+//During the quiet hours (see SetQuietHours, SetQuietUntil and SetDoNotDisturb), only the Notifications reaching the
+//quiet threshold (see SetQuietThreshold) are displayed as banners. While the desktop session is locked, the other
+//ones are deferred until it is unlocked (see SetSessionLocked).
+//The Actions of the Notification are offered as buttons of the banner, if supported (see showActionBanner).
+func (i *Indicator) showBanner(n Notification) {
+	gr := i.graphicResource[resourceDesktop]
+	gr.Lock()
+	defer gr.Unlock()
+	level := i.config.notifyLevel
+	if level == NotifyLevelMax && n.Severity < i.config.quietThreshold && i.config.quiet(i.Now()) {
+		level = NotifyLevelMin
+	}
+	if level == NotifyLevelMax && n.Severity < i.config.quietThreshold && i.sessionLocked {
+		i.deferBanner(n)
+		level = NotifyLevelMin
+	}
+	switch level {
+	case NotifyLevelMin:
+		i.SetIcon(n.TrayIcon())
+	case NotifyLevelMax:
+		i.SetIcon(n.TrayIcon())
+		iconPath := filepath.Join(i.config.notifyIconPath, notifyIconFile(n.BannerIcon()))
+		if len(n.Actions) > 0 && i.showActionBanner(n, iconPath) {
+			return
+		}
+		if !i.gProvider.Mocked() {
+			/*The golang guidelines suggests error messages should not start with a capitalized letter.
+			Therefore, since the message is sometimes an error, the Capitalize() function overcomes this problem,
+			correctly displaying the string to the user.*/
+			_ = bip.Notify(n.Title, stringUtils.Capitalize(n.Message), iconPath)
+		}
+	}
+}
+
+//notifyIconFile returns the name of the image file of a NotifyIcon.
+func notifyIconFile(icon NotifyIcon) string {
+	switch icon {
+	case NotifyIconNil:
+		return ""
+	case NotifyIconWarning:
+		return "liqo-warning.png"
+	case NotifyIconWhite:
+		return "liqo-main-white.png"
+	case NotifyIconError:
+		return "liqo-error.png"
+	default:
+		return "liqo-main-black.png"
+	}
+}
+
+//showDialog displays a Notification as a dialog box, returning the action selected by the user, if any.
+func (i *Indicator) showDialog(n Notification) *NotificationAction {
+	gr := i.graphicResource[resourceDesktop]
+	gr.Lock()
+	defer gr.Unlock()
+	if i.gProvider.Mocked() {
+		return nil
+	}
+	message := fmt.Sprintln(strutil.CenterText("", menuWidth*2), n.Message)
+	switch len(n.Actions) {
+	case 0:
+		switch n.Severity {
+		case SeverityError:
+			_, _ = dlgs.Error(n.Title, message)
+		case SeverityWarning:
+			_, _ = dlgs.Warning(n.Title, message)
+		default:
+			_, _ = dlgs.Info(n.Title, message)
+		}
+	case 1:
+		if ok, _ := dlgs.Question(n.Title, message+"\n"+n.Actions[0].Label+"?", false); ok {
+			return &n.Actions[0]
+		}
+	default:
+		labels := make([]string, len(n.Actions))
+		for index, a := range n.Actions {
+			labels[index] = a.Label
+		}
+		choice, ok, _ := dlgs.List(n.Title, n.Message, labels)
+		for index := range n.Actions {
+			if ok && labels[index] == choice {
+				return &n.Actions[index]
+			}
+		}
+	}
+	return nil
+}
+
+//Notification returns the active Notification with a specific ID, if any.
+func (i *Indicator) Notification(id string) (Notification, bool) {
+	i.notificationsMutex.RLock()
+	defer i.notificationsMutex.RUnlock()
+	n, present := i.notifications[id]
+	return n, present
+}
+
+//Notifications returns the active Notifications with an ID, the most recent first.
+func (i *Indicator) Notifications() []Notification {
+	i.notificationsMutex.RLock()
+	defer i.notificationsMutex.RUnlock()
+	notifications := make([]Notification, 0, len(i.notifications))
+	for _, n := range i.notifications {
+		notifications = append(notifications, n)
+	}
+	sort.Slice(notifications, func(a, b int) bool {
+		return notifications[a].Time.After(notifications[b].Time)
+	})
+	return notifications
+}
+
+//DismissNotification removes the active Notification with a specific ID, which is then displayed again if shown.
+func (i *Indicator) DismissNotification(id string) {
+	i.notificationsMutex.Lock()
+	delete(i.notifications, id)
+	delete(i.bannerIDs, id)
+	i.notificationsMutex.Unlock()
+	i.dropDeferredBanner(id)
+}
@@ -1,13 +1,9 @@
 package app_indicator
 
 import (
+	"errors"
 	"fmt"
-	"github.com/agrison/go-commons-lang/stringUtils"
-	bip "github.com/gen2brain/beeep"
-	"github.com/gen2brain/dlgs"
-	"github.com/ozgio/strutil"
-	"path/filepath"
-	"strconv"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
 	"strings"
 )
 
@@ -56,9 +52,8 @@ const (
 	NotifyEventPeeringOff
 )
 
-//Notify manages Indicator notification logic. Depending on the current NotifyLevel of the Indicator,
-//it changes the Indicator tray icon and displays a desktop banner, having title 'title' and 'message' as body.
-//If present in client.EnvLiqoPath, also 'notifyIcon' is shown inside the banner.
+//Notify is a shorthand of ShowNotification displaying a desktop banner, having title 'title' and 'message' as
+//body. The NotifyIconError and NotifyIconWarning icons set the SeverityError and SeverityWarning severity.
 //
 //The "nil" values can be used for both 'notifyIcon' and 'indicatorIcon':
 //
@@ -66,40 +61,15 @@ const (
 //
 //	IconLiqoNil : don't change current Indicator icon
 func (i *Indicator) Notify(title string, message string, notifyIcon NotifyIcon, indicatorIcon Icon) {
-	gr := i.graphicResource[resourceDesktop]
-	gr.Lock()
-	defer gr.Unlock()
-	switch i.config.notifyLevel {
-	case NotifyLevelOff:
-		return
-	case NotifyLevelMin:
-		i.SetIcon(indicatorIcon)
-	case NotifyLevelMax:
-		i.SetIcon(indicatorIcon)
-		var icoName string
-		switch notifyIcon {
-		case NotifyIconNil:
-			icoName = ""
-		case NotifyIconDefault:
-			icoName = "liqo-main-black.png"
-		case NotifyIconWarning:
-			icoName = "liqo-warning.png"
-		case NotifyIconWhite:
-			icoName = "liqo-main-white.png"
-		case NotifyIconError:
-			icoName = "liqo-error.png"
-		default:
-			icoName = "liqo-main-black.png"
-		}
-		if !i.gProvider.Mocked() {
-			/*The golang guidelines suggests error messages should not start with a capitalized letter.
-			Therefore, since Notify sometimes receives an error as 'message', the Capitalize() function
-			overcomes this problem, correctly displaying the string to the user.*/
-			_ = bip.Notify(title, stringUtils.Capitalize(message), filepath.Join(i.config.notifyIconPath, icoName))
-		}
-	default:
-		return
+	n := Notification{Title: title, Message: message, Severity: SeverityInfo}.WithTrayIcon(indicatorIcon)
+	switch notifyIcon {
+	case NotifyIconError:
+		n.Severity = SeverityError
+	case NotifyIconWarning:
+		n.Severity = SeverityWarning
 	}
+	n.bannerIcon, n.bannerIconSet = notifyIcon, true
+	i.ShowNotification(n)
 }
 
 //NotificationSetLevel sets the level of the indicator notification system:
@@ -122,29 +92,42 @@ func (i *Indicator) NotificationSetLevel(level NotifyLevel) {
 	}
 }
 
-//NotifyNoConnection is an already configured Notify() call to notify the absence of
+//notifyLevelNames maps the names of the NotifyLevel values used in the configuration files.
+var notifyLevelNames = map[string]NotifyLevel{
+	"off":    NotifyLevelOff,
+	"icon":   NotifyLevelMin,
+	"banner": NotifyLevelMax,
+}
+
+//ParseNotifyLevel returns the NotifyLevel with the given configuration name ("off", "icon" or "banner").
+func ParseNotifyLevel(name string) (level NotifyLevel, valid bool) {
+	level, valid = notifyLevelNames[strings.ToLower(strings.TrimSpace(name))]
+	return
+}
+
+//NotifyNoConnection is an already configured ShowNotification() call to notify the absence of
 //connection with the cluster pointed by $LIQO_KCONFIG.
 func (i *Indicator) NotifyNoConnection() {
-	i.Notify("Liqo Agent: NO CONNECTION", "Agent could not connect to the desired cluster",
-		NotifyIconWarning, IconLiqoWarning)
+	i.ShowNotification(Notification{
+		ID:       NotificationIDConnection,
+		Title:    "Liqo Agent: NO CONNECTION",
+		Message:  "Agent could not connect to the desired cluster",
+		Severity: SeverityWarning,
+		Category: CategoryConnection,
+	}.WithTrayIcon(IconLiqoWarning))
 }
 
-//NotifyPeering is a semi-configured Notify() call to notify events related to peerings involving a specific peer.
+//NotifyPeering is a semi-configured ShowNotification() call to notify events related to peerings involving a
+//specific peer. The notifications of each peering replace each other.
 func (i *Indicator) NotifyPeering(direction PeeringType, event NotifyPeeringEvent, peer *PeerInfo) {
 	var (
-		header      []string
-		body        []string
-		peerName    string
-		desktopIcon NotifyIcon
-		trayIcon    Icon
+		header   []string
+		body     []string
+		peerName string
 	)
 	peer.RLock()
 	defer peer.RUnlock()
-	if peer.Unknown {
-		peerName = strings.Join([]string{"UNKNOWN", strconv.Itoa(peer.UnknownId)}, " ")
-	} else {
-		peerName = peer.ClusterName
-	}
+	peerName = peer.DisplayName()
 	switch event {
 	case NotifyEventPeeringOn:
 		header = append(header, "NEW")
@@ -155,8 +138,6 @@ func (i *Indicator) NotifyPeering(direction PeeringType, event NotifyPeeringEven
 			header = append(header, "PEERING ACCEPTED")
 			body = append(body, "You are now sharing resources to", peerName)
 		}
-		desktopIcon = NotifyIconDefault
-		trayIcon = IconLiqoPurple
 	case NotifyEventPeeringOff:
 		if direction == PeeringOutgoing {
 			header = append(header, "OUTGOING")
@@ -166,21 +147,29 @@ func (i *Indicator) NotifyPeering(direction PeeringType, event NotifyPeeringEven
 			body = append(body, "You stopped sharing resources to", peerName)
 		}
 		header = append(header, "PEERING CLOSED")
-		desktopIcon = NotifyIconDefault
-		trayIcon = IconLiqoPurple
 		//expand for additional events
 	}
-	i.Notify(strings.Join(header, " "), strings.Join(body, " "), desktopIcon, trayIcon)
+	i.ShowNotification(Notification{
+		ID:       fmt.Sprintf("peering/%s/%s", peeringDirectionName(direction), peer.ClusterID),
+		Title:    strings.Join(header, " "),
+		Message:  strings.Join(body, " "),
+		Severity: SeverityInfo,
+		Category: CategoryPeering,
+		Target:   NotificationTarget{Kind: "ForeignCluster", Name: peer.ForeignClusterResourceName},
+	}.WithTrayIcon(IconLiqoPurple))
+}
+
+//peeringDirectionName returns the name of a PeeringType used in the Notification IDs.
+func peeringDirectionName(direction PeeringType) string {
+	if direction == PeeringIncoming {
+		return "incoming"
+	}
+	return "outgoing"
 }
 
 //ShowWarning displays a Warning window box.
 func (i *Indicator) ShowWarning(title, message string) {
-	gr := i.graphicResource[resourceDesktop]
-	gr.Lock()
-	defer gr.Unlock()
-	if !GetGuiProvider().Mocked() {
-		_, _ = dlgs.Warning(title, fmt.Sprintln(strutil.CenterText("", menuWidth*2), message))
-	}
+	i.ShowNotification(Notification{Title: title, Message: message, Severity: SeverityWarning, Dialog: true})
 }
 
 //ShowWarningForbiddenTethered is an already configured ShowWarning() call to warn users
@@ -192,20 +181,75 @@ func (i *Indicator) ShowWarningForbiddenTethered() {
 
 //ShowError displays an Error window box.
 func (i *Indicator) ShowError(title, message string) {
-	gr := i.graphicResource[resourceDesktop]
-	gr.Lock()
-	defer gr.Unlock()
-	if !GetGuiProvider().Mocked() {
-		_, _ = dlgs.Error(title, fmt.Sprintln(strutil.CenterText("", menuWidth*2), message))
-	}
+	i.ShowNotification(Notification{Title: title, Message: message, Severity: SeverityError, Dialog: true})
 }
 
-//ShowErrorNoConnection is an already configured ShowError() call to warn
+//ShowErrorNoConnection is an already configured ShowNotification() call to warn
 //the user about kubeconfig misconfiguration.
 func (i *Indicator) ShowErrorNoConnection() {
-	if !GetGuiProvider().Mocked() {
-		_, _ = dlgs.Error("LIQO AGENT", fmt.Sprintln(strutil.CenterText("", menuWidth*2),
-			"Liqo Agent could not find a valid kubeconfig file.\n",
-			"Please restart the Agent after providing a correct configuration."))
+	i.ShowNotification(Notification{
+		Title: "LIQO AGENT",
+		Message: "Liqo Agent could not find a valid kubeconfig file.\n" +
+			"Please restart the Agent after providing a correct configuration.",
+		Severity: SeverityError,
+		Category: CategoryConnection,
+		Dialog:   true,
+	})
+}
+
+//errorNotice describes how the failure of an AgentController operation is presented to the user.
+type errorNotice struct {
+	//message explains the failure to the user.
+	message string
+	//icon is the Indicator icon displayed after the failure.
+	icon Icon
+	//dialog specifies whether the failure requires a dialog box (true) or a desktop notification (false).
+	dialog bool
+	//severe specifies whether the dialog box is an Error (true) or a Warning (false) one.
+	severe bool
+}
+
+//newErrorNotice returns the errorNotice for err, according to its client error class.
+func newErrorNotice(err error) errorNotice {
+	switch {
+	case errors.Is(err, client.ErrNotConnected):
+		return errorNotice{message: "Agent could not connect to the desired cluster.", icon: IconLiqoNoConn}
+	case errors.Is(err, client.ErrTimeout):
+		return errorNotice{message: "The cluster did not reply in time, please retry later.", icon: IconLiqoWarning}
+	case errors.Is(err, client.ErrForbidden):
+		return errorNotice{message: "The credentials used by the Agent are not authorized to perform this operation.",
+			icon: IconLiqoWarning, dialog: true}
+	case errors.Is(err, client.ErrCRDMissing):
+		return errorNotice{message: "Liqo does not seem to be installed in the connected cluster.",
+			icon: IconLiqoWarning, dialog: true, severe: true}
+	case errors.Is(err, client.ErrVersionSkew):
+		return errorNotice{message: "The cluster runs a Liqo version not supported by this Agent.\n" +
+			"Please align the versions of Liqo and Liqo Agent.", icon: IconLiqoWarning, dialog: true, severe: true}
+	default:
+		return errorNotice{message: "The operation failed.", icon: IconLiqoWarning, dialog: true, severe: true}
+	}
+}
+
+//ShowClientError presents the failure of an AgentController operation, choosing the Indicator icon and the kind
+//of notification according to the client error class of err: transient failures (e.g. client.ErrTimeout) are
+//notified with a desktop banner, while the ones requiring an intervention of the user open a dialog box.
+func (i *Indicator) ShowClientError(title string, err error) {
+	if err == nil {
+		return
+	}
+	notice := newErrorNotice(err)
+	n := Notification{
+		Title:    title,
+		Message:  notice.message,
+		Severity: SeverityWarning,
+		Category: CategoryOperation,
+		Dialog:   notice.dialog,
+	}.WithTrayIcon(notice.icon)
+	if notice.dialog {
+		n.Message += "\n\n" + err.Error()
+	}
+	if notice.severe {
+		n.Severity = SeverityError
 	}
+	i.ShowNotification(n)
 }
@@ -1,6 +1,8 @@
 package app_indicator
 
 import (
+	"errors"
+	"fmt"
 	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -65,3 +67,63 @@ func TestIndicator_NotifyFunctions(t *testing.T) {
 	i.NotifyNoConnection()
 	assert.Equal(t, IconLiqoWarning, i.icon, "NotifyNoConnection: indicator icon not correctly set")
 }
+
+func TestNewErrorNotice(t *testing.T) {
+	notice := newErrorNotice(fmt.Errorf("wrapped: %w", client.ErrTimeout))
+	assert.False(t, notice.dialog, "transient errors should not open a dialog box")
+	notice = newErrorNotice(client.ErrNotConnected)
+	assert.Equal(t, IconLiqoNoConn, notice.icon)
+	assert.False(t, notice.dialog, "transient errors should not open a dialog box")
+	notice = newErrorNotice(client.ErrForbidden)
+	assert.True(t, notice.dialog && !notice.severe, "forbidden operations should open a warning dialog box")
+	for _, err := range []error{client.ErrCRDMissing, client.ErrVersionSkew, errors.New("generic")} {
+		notice = newErrorNotice(err)
+		assert.True(t, notice.dialog && notice.severe, "wrong notice for error '%v'", err)
+	}
+}
+
+func TestShowNotification(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	i := GetIndicator()
+	i.config.notifyLevel = NotifyLevelMax
+	n := Notification{ID: "test", Title: "title", Message: "message", Severity: SeverityWarning,
+		Category: CategoryPeering, Target: NotificationTarget{Kind: "ForeignCluster", Name: "fc"}}
+	assert.Equal(t, NotifyIconWarning, n.BannerIcon())
+	assert.Equal(t, IconLiqoNil, n.TrayIcon())
+	assert.Equal(t, "ForeignCluster/fc", n.Target.String())
+	assert.Equal(t, "warning", n.Severity.String())
+	i.ShowNotification(n.WithTrayIcon(IconLiqoOrange))
+	assert.Equal(t, IconLiqoOrange, i.icon)
+	active, present := i.Notification("test")
+	if assert.True(t, present, "notification not tracked") {
+		assert.Equal(t, CategoryPeering, active.Category)
+		assert.False(t, active.Time.IsZero())
+	}
+	//a notification with the same content is not displayed again
+	i.ShowNotification(n.WithTrayIcon(IconLiqoRed))
+	assert.Equal(t, IconLiqoOrange, i.icon, "repeated notification displayed")
+	//a notification with a new content replaces the active one
+	n.Message = "updated"
+	i.ShowNotification(n.WithTrayIcon(IconLiqoRed))
+	assert.Equal(t, IconLiqoRed, i.icon)
+	i.ShowNotification(Notification{ID: "other", Title: "other"})
+	if notifications := i.Notifications(); assert.Len(t, notifications, 2) {
+		assert.Equal(t, "other", notifications[0].ID)
+		assert.Equal(t, "updated", notifications[1].Message)
+	}
+	//a dismissed notification is displayed again
+	i.DismissNotification("test")
+	n.Message = "message"
+	i.ShowNotification(n.WithTrayIcon(IconLiqoOrange))
+	assert.Equal(t, IconLiqoOrange, i.icon)
+	//the notifications without ID are not tracked
+	i.ShowNotification(Notification{Title: "untracked"})
+	assert.Len(t, i.Notifications(), 2)
+	//the dialog boxes are displayed regardless of the notification level
+	i.config.notifyLevel = NotifyLevelOff
+	i.ShowNotification(Notification{Title: "dialog", Severity: SeverityError, Dialog: true}.WithTrayIcon(IconLiqoWarning))
+	assert.Equal(t, IconLiqoWarning, i.icon)
+}
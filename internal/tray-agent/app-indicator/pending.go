@@ -0,0 +1,160 @@
+package app_indicator
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/format"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//PendingKind distinguishes the items awaiting an input of the user.
+type PendingKind int
+
+const (
+	//PendingRequest is a request awaiting a decision of the user, e.g. a peering approval.
+	PendingRequest PendingKind = iota
+	//PendingProblem is a problem requiring an action of the user, e.g. expired credentials or a failed operation.
+	PendingProblem
+)
+
+//PendingItem is an item awaiting an input of the user, collected by the PendingRegistry.
+type PendingItem struct {
+	//ID identifies the item, e.g. "credentials". Adding an item with the ID of an existing one replaces it.
+	ID string
+	//Kind is the kind of the item.
+	Kind PendingKind
+	//Title is the short description of the item displayed in the menu.
+	Title string
+	//Since is the time the item was registered at. If zero, it is set by Add.
+	Since time.Time
+	//Action is the handler executed when the user selects the item, leading to the place where the item can be
+	//handled. If nil, the item is only displayed.
+	Action ClickHandler
+}
+
+//PendingRegistry collects the items awaiting an input of the user (pending requests and problems), so that they
+//can be summarized in a single place. It is safe for concurrent use.
+type PendingRegistry struct {
+	mutex sync.RWMutex
+	items map[string]*PendingItem
+	//observers are called after each change of the registry.
+	observers []func()
+}
+
+//newPendingRegistry returns an empty PendingRegistry.
+func newPendingRegistry() *PendingRegistry {
+	return &PendingRegistry{items: make(map[string]*PendingItem)}
+}
+
+//Add registers an item, replacing the one with the same ID, if any. The replaced item keeps its Since time.
+func (r *PendingRegistry) Add(item PendingItem) {
+	r.mutex.Lock()
+	if old, present := r.items[item.ID]; present && item.Since.IsZero() {
+		item.Since = old.Since
+	}
+	if item.Since.IsZero() {
+		item.Since = time.Now()
+	}
+	r.items[item.ID] = &item
+	r.mutex.Unlock()
+	r.changed()
+}
+
+//Remove removes the item with the given ID, if registered.
+func (r *PendingRegistry) Remove(id string) {
+	r.mutex.Lock()
+	_, present := r.items[id]
+	delete(r.items, id)
+	r.mutex.Unlock()
+	if present {
+		r.changed()
+	}
+}
+
+//Item returns a copy of the item with the given ID.
+func (r *PendingRegistry) Item(id string) (item PendingItem, present bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if it, present := r.items[id]; present {
+		return *it, true
+	}
+	return PendingItem{}, false
+}
+
+//Items returns a copy of the registered items: the problems first, then the requests, each from the oldest one.
+func (r *PendingRegistry) Items() []PendingItem {
+	r.mutex.RLock()
+	items := make([]PendingItem, 0, len(r.items))
+	for _, it := range r.items {
+		items = append(items, *it)
+	}
+	r.mutex.RUnlock()
+	sort.Slice(items, func(a, b int) bool {
+		if items[a].Kind != items[b].Kind {
+			return items[a].Kind == PendingProblem
+		}
+		if !items[a].Since.Equal(items[b].Since) {
+			return items[a].Since.Before(items[b].Since)
+		}
+		return items[a].ID < items[b].ID
+	})
+	return items
+}
+
+//Counts returns the number of pending requests and problems.
+func (r *PendingRegistry) Counts() (requests int, problems int) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, it := range r.items {
+		if it.Kind == PendingProblem {
+			problems++
+		} else {
+			requests++
+		}
+	}
+	return
+}
+
+//Len returns the number of registered items.
+func (r *PendingRegistry) Len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return len(r.items)
+}
+
+//Summary returns a description of the registered items, e.g. "2 pending requests, 1 problem".
+//It is empty if there is no item.
+func (r *PendingRegistry) Summary() string {
+	requests, problems := r.Counts()
+	var parts []string
+	if requests > 0 {
+		parts = append(parts, format.Count(requests, "pending request", "pending requests"))
+	}
+	if problems > 0 {
+		parts = append(parts, format.Count(problems, "problem", "problems"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+//OnChange registers a callback executed after each change of the registry.
+func (r *PendingRegistry) OnChange(callback func()) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.observers = append(r.observers, callback)
+}
+
+//changed runs the observers of the registry.
+func (r *PendingRegistry) changed() {
+	r.mutex.RLock()
+	observers := append([]func(){}, r.observers...)
+	r.mutex.RUnlock()
+	for _, o := range observers {
+		o()
+	}
+}
+
+//Pending returns the PendingRegistry of the Indicator.
+func (i *Indicator) Pending() *PendingRegistry {
+	return i.pending
+}
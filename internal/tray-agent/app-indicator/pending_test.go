@@ -0,0 +1,52 @@
+package app_indicator
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPendingRegistry(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	DestroyStatus()
+	i := GetIndicator()
+	r := i.Pending()
+	changes := 0
+	r.OnChange(func() {
+		changes++
+	})
+	assert.Empty(t, r.Summary(), "summary of an empty registry")
+	assert.Empty(t, i.Label())
+	start := time.Now()
+	r.Add(PendingItem{ID: "req1", Kind: PendingRequest, Title: "request 1", Since: start})
+	r.Add(PendingItem{ID: "req2", Kind: PendingRequest, Title: "request 2", Since: start.Add(time.Second)})
+	r.Add(PendingItem{ID: "problem", Kind: PendingProblem, Title: "problem", Since: start.Add(2 * time.Second)})
+	assert.Equal(t, 3, changes)
+	assert.Equal(t, "2 pending requests, 1 problem", r.Summary())
+	//problems come first, then the oldest items
+	items := r.Items()
+	if assert.Len(t, items, 3) {
+		assert.Equal(t, []string{"problem", "req1", "req2"}, []string{items[0].ID, items[1].ID, items[2].ID})
+	}
+	//the badge counts the pending items
+	assert.Equal(t, pendingBadge+"3", i.Label())
+	//a replaced item keeps its registration time
+	r.Add(PendingItem{ID: "req1", Kind: PendingRequest, Title: "request 1 updated"})
+	item, present := r.Item("req1")
+	assert.True(t, present)
+	assert.Equal(t, "request 1 updated", item.Title)
+	assert.True(t, item.Since.Equal(start), "registration time of a replaced item changed")
+	r.Remove("req1")
+	r.Remove("req2")
+	r.Remove("missing")
+	assert.Equal(t, 6, changes, "removal of a missing item notified")
+	assert.Equal(t, "1 problem", r.Summary())
+	r.Remove("problem")
+	assert.Zero(t, r.Len())
+	assert.Empty(t, i.Label(), "badge not removed")
+	i.Quit()
+}
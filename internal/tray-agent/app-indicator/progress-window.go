@@ -0,0 +1,93 @@
+package app_indicator
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+//ProgressWindow is a small window displaying the progress of a long running operation, e.g. the startup of the Agent.
+type ProgressWindow interface {
+	//Update sets the completed percentage (0-100) of the operation and the description of its current step.
+	Update(percent int, text string)
+	//Close closes the window. Further updates are ignored.
+	Close()
+}
+
+//progressWindowCommand is the command opening the ProgressWindow, reading the updates from its stdin.
+const progressWindowCommand = "zenity"
+
+//NewProgressWindow opens a ProgressWindow with the given title. When no window can be opened (the GUI is mocked or
+//the system lacks the zenity utility), the returned ProgressWindow silently ignores the updates.
+func NewProgressWindow(title string) ProgressWindow {
+	if GetGuiProvider().Mocked() {
+		return nopProgressWindow{}
+	}
+	path, err := exec.LookPath(progressWindowCommand)
+	if err != nil {
+		return nopProgressWindow{}
+	}
+	cmd := exec.Command(path, "--progress", "--title="+title, "--text=", "--percentage=0", "--no-cancel",
+		"--auto-close", "--width=360")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nopProgressWindow{}
+	}
+	if err = cmd.Start(); err != nil {
+		return nopProgressWindow{}
+	}
+	w := &zenityProgressWindow{cmd: cmd, stdin: stdin}
+	go func() {
+		//the window may also be closed by the user
+		_ = cmd.Wait()
+		w.Close()
+	}()
+	return w
+}
+
+//zenityProgressWindow is a ProgressWindow implemented by a 'zenity --progress' dialog.
+type zenityProgressWindow struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	closed bool
+	sync.Mutex
+}
+
+func (w *zenityProgressWindow) Update(percent int, text string) {
+	w.Lock()
+	defer w.Unlock()
+	if w.closed {
+		return
+	}
+	if percent < 0 {
+		percent = 0
+	} else if percent > 99 {
+		//the window auto-closes at 100%: the final state is kept until Close
+		percent = 99
+	}
+	//zenity reads the percentage and, on lines starting with '#', the text
+	text = strings.ReplaceAll(text, "\n", " ")
+	if _, err := fmt.Fprintf(w.stdin, "%d\n# %s\n", percent, text); err != nil {
+		w.closed = true
+	}
+}
+
+func (w *zenityProgressWindow) Close() {
+	w.Lock()
+	defer w.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	_, _ = fmt.Fprintln(w.stdin, "100")
+	_ = w.stdin.Close()
+}
+
+//nopProgressWindow is a ProgressWindow displaying nothing.
+type nopProgressWindow struct{}
+
+func (nopProgressWindow) Update(int, string) {}
+
+func (nopProgressWindow) Close() {}
@@ -0,0 +1,167 @@
+package app_indicator
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/format"
+	"sync"
+	"time"
+)
+
+/*This file contains the display of the long running operations (e.g. the establishment of a peering) in the menu.
+Each operation started by ShowProgress is displayed by a disabled entry right below the STATUS MenuNode, with a
+textual progress bar:
+	Establishing peering ▕███▌░░░░░░▏ 35%
+The entries are reused by the following operations once Done, since the menu entries cannot be removed.*/
+
+const (
+	//progressBarWidth is the width, in cells, of the progress bars.
+	progressBarWidth = 10
+	//progressPulseInterval is the interval the tray icon pulses at while an operation with a pulsing icon is in
+	//progress.
+	progressPulseInterval = 700 * time.Millisecond
+)
+
+//Progress is the handle of a long running operation displayed in the menu by ShowProgress. It is safe for concurrent
+//use.
+type Progress struct {
+	indicator *Indicator
+	tag       string
+	title     string
+	//node is the entry displaying the operation.
+	node *MenuNode
+	//percent is the completed percentage of the operation, between 0 and 100.
+	percent int
+	//pulse contains the Icons cycled by the tray icon while the operation is in progress, if pulsing.
+	pulse []Icon
+	//done specifies whether the operation is completed: further updates are ignored.
+	done bool
+	sync.Mutex
+}
+
+//ShowProgress displays a long running operation, identified by tag, in the menu, at 0%. If an operation with the
+//same tag is in progress, it is restarted with the new title. The returned Progress updates the display until Done.
+func (i *Indicator) ShowProgress(tag string, title string) *Progress {
+	i.progressMutex.Lock()
+	p, present := i.progress[tag]
+	if !present {
+		p = &Progress{indicator: i, tag: tag, node: i.progressNode()}
+		i.progress[tag] = p
+	}
+	i.progressMutex.Unlock()
+	p.Lock()
+	defer p.Unlock()
+	p.title, p.percent = title, 0
+	p.render()
+	p.node.SetIsVisible(true)
+	return p
+}
+
+//Progress returns the handle of the operation in progress with the given tag, if any.
+func (i *Indicator) Progress(tag string) (p *Progress, present bool) {
+	i.progressMutex.Lock()
+	defer i.progressMutex.Unlock()
+	p, present = i.progress[tag]
+	return
+}
+
+//progressNode returns an entry to display an operation: a free one, or a new one placed after the entries in use
+//(or after the STATUS MenuNode, if none). It must be called holding the progressMutex.
+func (i *Indicator) progressNode() *MenuNode {
+	if n := len(i.progressFree); n > 0 {
+		node := i.progressFree[n-1]
+		i.progressFree = i.progressFree[:n-1]
+		return node
+	}
+	anchor := i.menuStatusNode
+	for _, p := range i.progress {
+		if p.node.IsVisible() {
+			anchor = p.node
+		}
+	}
+	node := newMenuNode(i, NodeTypeStatus, false, nil)
+	if err := node.MoveAfter(anchor); err != nil {
+		logger.Debug("cannot move the progress entry", "err", err)
+	}
+	return node
+}
+
+//render updates the title of the entry of the Progress. It must be called holding its lock.
+func (p *Progress) render() {
+	ratio := float64(p.percent) / 100
+	p.node.SetTitle(p.title + " " + format.Bar(ratio, progressBarWidth) + " " + format.Percent(ratio))
+}
+
+//Update sets the completed percentage (0-100) of the operation. The values out of range are clamped.
+func (p *Progress) Update(percent int) {
+	p.Lock()
+	defer p.Unlock()
+	if p.done {
+		return
+	}
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+	p.percent = percent
+	p.render()
+}
+
+//Percent returns the completed percentage of the operation.
+func (p *Progress) Percent() int {
+	p.Lock()
+	defer p.Unlock()
+	return p.percent
+}
+
+//Title returns the title of the entry displaying the operation, including its progress bar.
+func (p *Progress) Title() string {
+	return p.node.Title()
+}
+
+//WithPulsingIcon makes the tray icon pulse while the operation is in progress, until Done or the next change of the
+//tray icon (see SetIconAnimation).
+func (p *Progress) WithPulsingIcon() *Progress {
+	p.Lock()
+	defer p.Unlock()
+	if p.done {
+		return p
+	}
+	i := p.indicator
+	current := i.Icon()
+	if cycle, _ := i.IconAnimation(); cycle != nil {
+		current = cycle[0]
+	}
+	dimmed := IconLiqoOff
+	if current == IconLiqoOff {
+		dimmed = IconLiqoMain
+	}
+	p.pulse = []Icon{current, dimmed}
+	i.SetIconAnimation(p.pulse, progressPulseInterval)
+	return p
+}
+
+//Done completes the operation, removing its entry from the menu. If the tray icon is still pulsing for the
+//operation, the icon displayed before is restored.
+func (p *Progress) Done() {
+	p.Lock()
+	if p.done {
+		p.Unlock()
+		return
+	}
+	p.done = true
+	pulse := p.pulse
+	p.Unlock()
+	i := p.indicator
+	if pulse != nil {
+		if cycle, interval := i.IconAnimation(); interval == progressPulseInterval && sameIcons(cycle, pulse) {
+			i.SetIcon(pulse[0])
+		}
+	}
+	p.node.SetIsVisible(false)
+	i.progressMutex.Lock()
+	defer i.progressMutex.Unlock()
+	if i.progress[p.tag] == p {
+		delete(i.progress, p.tag)
+	}
+	i.progressFree = append(i.progressFree, p.node)
+}
@@ -0,0 +1,51 @@
+package app_indicator
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	i := newTestIndicator(t, NewMockedGuiProvider())
+	defer i.Quit()
+	quick := i.AddQuick("Quick", "Q_TEST", nil)
+	p := i.ShowProgress("peering/cl1", "Establishing peering")
+	assert.Equal(t, "Establishing peering ▕░░░░░░░░░░▏ 0%", p.Title())
+	assert.True(t, p.node.IsVisible(), "progress not displayed")
+	assert.False(t, p.node.IsEnabled(), "progress entry enabled")
+	//the entry is displayed right below the STATUS MenuNode
+	i.nodesMutex.Lock()
+	assert.Equal(t, indexOfNode(i.topNodes, i.menuStatusNode)+1, indexOfNode(i.topNodes, p.node))
+	assert.Less(t, indexOfNode(i.topNodes, p.node), indexOfNode(i.topNodes, quick))
+	i.nodesMutex.Unlock()
+	p.Update(35)
+	assert.Equal(t, "Establishing peering ▕███▌░░░░░░▏ 35%", p.Title())
+	p.Update(150)
+	assert.Equal(t, 100, p.Percent())
+	found, present := i.Progress("peering/cl1")
+	assert.True(t, present)
+	assert.Equal(t, p, found)
+	//the pulsing icon is restored once done
+	i.SetIcon(IconLiqoGreen)
+	p.WithPulsingIcon()
+	cycle, _ := i.IconAnimation()
+	assert.Equal(t, []Icon{IconLiqoGreen, IconLiqoOff}, cycle)
+	p.Done()
+	cycle, _ = i.IconAnimation()
+	assert.Nil(t, cycle, "icon still pulsing")
+	assert.Equal(t, IconLiqoGreen, i.Icon())
+	assert.False(t, p.node.IsVisible(), "completed progress displayed")
+	_, present = i.Progress("peering/cl1")
+	assert.False(t, present)
+	//the updates after Done are ignored, and the entry is reused
+	p.Update(50)
+	assert.Equal(t, 100, p.Percent())
+	other := i.ShowProgress("upgrade", "Upgrading")
+	assert.Equal(t, p.node, other.node)
+	assert.Equal(t, "Upgrading ▕░░░░░░░░░░▏ 0%", other.Title())
+	//an icon set meanwhile is kept
+	other.WithPulsingIcon()
+	i.SetIcon(IconLiqoRed)
+	other.Done()
+	assert.Equal(t, IconLiqoRed, i.Icon())
+}
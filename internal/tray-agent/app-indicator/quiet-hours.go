@@ -0,0 +1,221 @@
+package app_indicator
+
+import (
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"sort"
+	"strings"
+	"time"
+)
+
+//quietHoursLookahead is the maximum time span searched for the next change of a QuietSchedule.
+const quietHoursLookahead = 8 * 24 * time.Hour
+
+//weekdays maps the accepted day names into the corresponding time.Weekday.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+//quietRule is a recurring time interval during which only critical notifications are delivered.
+type quietRule struct {
+	//from and to are the bounds of the interval, in minutes since midnight. When from > to, the interval spans
+	//midnight. When from == to, the rule lasts the whole day.
+	from, to int
+	//days contains the days the interval starts on. If empty, the rule applies every day.
+	days map[time.Weekday]bool
+}
+
+//QuietSchedule is a set of recurring quiet hours.
+type QuietSchedule struct {
+	rules []quietRule
+}
+
+//NewQuietSchedule builds a QuietSchedule from the quiet hours settings of the Agent configuration file.
+func NewQuietSchedule(rules []client.QuietHoursRule) (*QuietSchedule, error) {
+	s := &QuietSchedule{}
+	for _, r := range rules {
+		rule := quietRule{days: make(map[time.Weekday]bool)}
+		var err error
+		if rule.from, err = parseClock(r.From); err != nil {
+			return nil, err
+		}
+		if rule.to, err = parseClock(r.To); err != nil {
+			return nil, err
+		}
+		for _, d := range r.Days {
+			day, valid := parseDay(d)
+			if !valid {
+				return nil, fmt.Errorf("invalid day '%s'", d)
+			}
+			rule.days[day] = true
+		}
+		s.rules = append(s.rules, rule)
+	}
+	return s, nil
+}
+
+//parseClock parses a "HH:MM" time of the day, returning the minutes since midnight. An empty string means midnight.
+func parseClock(clock string) (int, error) {
+	if clock == "" {
+		return 0, nil
+	}
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time '%s', expected HH:MM", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+//parseDay returns the time.Weekday of a day name, matched case-insensitively on its first three letters.
+func parseDay(day string) (time.Weekday, bool) {
+	//the name is lowercased before being shortened, since lowercasing can change its length in bytes
+	prefix := strings.ToLower(day)
+	if len(prefix) > 3 {
+		prefix = prefix[:3]
+	}
+	weekday, valid := weekdays[prefix]
+	return weekday, valid
+}
+
+//Empty returns whether the schedule contains no quiet hours.
+func (s *QuietSchedule) Empty() bool {
+	return s == nil || len(s.rules) == 0
+}
+
+//Active returns whether t falls within the quiet hours.
+func (s *QuietSchedule) Active(t time.Time) bool {
+	if s == nil {
+		return false
+	}
+	for _, r := range s.rules {
+		if r.active(t) {
+			return true
+		}
+	}
+	return false
+}
+
+//NextChange returns the first instant after t when the quiet hours start or end. If the state never changes,
+//ok == false.
+func (s *QuietSchedule) NextChange(t time.Time) (next time.Time, ok bool) {
+	if s.Empty() {
+		return time.Time{}, false
+	}
+	//the state can only change at midnight or at the bounds of a rule interval
+	var candidates []time.Time
+	end := t.Add(quietHoursLookahead)
+	for day := t; !day.After(end.Add(24 * time.Hour)); day = day.AddDate(0, 0, 1) {
+		y, m, d := day.Date()
+		candidates = append(candidates, time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+		for _, r := range s.rules {
+			for _, bound := range []int{r.from, r.to} {
+				candidates = append(candidates, time.Date(y, m, d, bound/60, bound%60, 0, 0, t.Location()))
+			}
+		}
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		return candidates[a].Before(candidates[b])
+	})
+	current := s.Active(t)
+	for _, next = range candidates {
+		if next.After(t) && !next.After(end) && s.Active(next) != current {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+//active returns whether t falls within the rule interval.
+func (r *quietRule) active(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	today := r.appliesOn(t.Weekday())
+	switch {
+	case r.from == r.to:
+		return today
+	case r.from < r.to:
+		return today && m >= r.from && m < r.to
+	default:
+		yesterday := r.appliesOn((t.Weekday() + 6) % 7)
+		return (today && m >= r.from) || (yesterday && m < r.to)
+	}
+}
+
+//appliesOn returns whether the rule interval starts on a day.
+func (r *quietRule) appliesOn(day time.Weekday) bool {
+	return len(r.days) == 0 || r.days[day]
+}
+
+//SetQuietHours sets the quiet hours during which only critical notifications (see SetQuietThreshold) are
+//displayed as desktop banners. A nil schedule disables the quiet hours.
+func (i *Indicator) SetQuietHours(schedule *QuietSchedule) {
+	gr := i.graphicResource[resourceDesktop]
+	gr.Lock()
+	defer gr.Unlock()
+	i.config.quietHours = schedule
+}
+
+//SetQuietUntil silences the non critical notifications, as during the quiet hours, until a given instant. A zero
+//instant ends the quiet period.
+func (i *Indicator) SetQuietUntil(until time.Time) {
+	gr := i.graphicResource[resourceDesktop]
+	gr.Lock()
+	defer gr.Unlock()
+	i.config.quietUntil = until
+}
+
+//QuietUntil returns the end of the quiet period set with SetQuietUntil, if any.
+func (i *Indicator) QuietUntil() time.Time {
+	gr := i.graphicResource[resourceDesktop]
+	gr.RLock()
+	defer gr.RUnlock()
+	return i.config.quietUntil
+}
+
+//SetDoNotDisturb enables or disables the Do Not Disturb mode, silencing the notifications as during the quiet hours
+//until disabled. The tray icon keeps displaying the notified events.
+func (i *Indicator) SetDoNotDisturb(enabled bool) {
+	gr := i.graphicResource[resourceDesktop]
+	gr.Lock()
+	defer gr.Unlock()
+	i.config.doNotDisturb = enabled
+}
+
+//DoNotDisturb returns whether the Do Not Disturb mode is enabled.
+func (i *Indicator) DoNotDisturb() bool {
+	gr := i.graphicResource[resourceDesktop]
+	gr.RLock()
+	defer gr.RUnlock()
+	return i.config.doNotDisturb
+}
+
+//SetQuietThreshold sets the minimum Severity of the notifications displayed as banners during the quiet hours, the
+//quiet period and the Do Not Disturb mode. It defaults to SeverityError.
+func (i *Indicator) SetQuietThreshold(threshold Severity) {
+	gr := i.graphicResource[resourceDesktop]
+	gr.Lock()
+	defer gr.Unlock()
+	i.config.quietThreshold = threshold
+}
+
+//QuietThreshold returns the minimum Severity of the notifications displayed as banners while the notifications are
+//silenced.
+func (i *Indicator) QuietThreshold() Severity {
+	gr := i.graphicResource[resourceDesktop]
+	gr.RLock()
+	defer gr.RUnlock()
+	return i.config.quietThreshold
+}
+
+//quiet returns whether t falls within the quiet hours or the quiet period, or the Do Not Disturb mode is enabled.
+func (c *config) quiet(t time.Time) bool {
+	return c.doNotDisturb || c.quietHours.Active(t) || t.Before(c.quietUntil)
+}
+
+//QuietHours returns the current quiet hours schedule, if any.
+func (i *Indicator) QuietHours() *QuietSchedule {
+	gr := i.graphicResource[resourceDesktop]
+	gr.RLock()
+	defer gr.RUnlock()
+	return i.config.quietHours
+}
@@ -0,0 +1,98 @@
+package app_indicator
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestQuietSchedule(t *testing.T) {
+	_, err := NewQuietSchedule([]client.QuietHoursRule{{From: "25:00"}})
+	assert.Error(t, err, "invalid time accepted")
+	_, err = NewQuietSchedule([]client.QuietHoursRule{{Days: []string{"someday"}}})
+	assert.Error(t, err, "invalid day accepted")
+	//the Kelvin sign is shortened by lowercasing
+	_, err = NewQuietSchedule([]client.QuietHoursRule{{Days: []string{"\u212A\u212A"}}})
+	assert.Error(t, err, "invalid day accepted")
+	s, err := NewQuietSchedule([]client.QuietHoursRule{
+		{From: "22:00", To: "08:00"},
+		{Days: []string{"sat", "Sunday"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//2021-04-21 is a Wednesday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2021, time.April, day, hour, minute, 0, 0, time.Local)
+	}
+	assert.False(t, s.Active(at(21, 12, 0)))
+	assert.True(t, s.Active(at(21, 22, 0)))
+	assert.True(t, s.Active(at(22, 7, 59)), "interval spanning midnight not active after midnight")
+	assert.False(t, s.Active(at(22, 8, 0)))
+	assert.True(t, s.Active(at(24, 12, 0)), "weekend not quiet")
+	next, ok := s.NextChange(at(21, 12, 30))
+	assert.True(t, ok)
+	assert.Equal(t, at(21, 22, 0), next)
+	//from Friday night to Monday morning
+	next, _ = s.NextChange(at(23, 23, 0))
+	assert.Equal(t, at(26, 8, 0), next)
+	//a change at the given instant is not returned
+	next, _ = s.NextChange(at(21, 22, 0))
+	assert.Equal(t, at(22, 8, 0), next)
+	always, _ := NewQuietSchedule([]client.QuietHoursRule{{}})
+	_, ok = always.NextChange(at(21, 12, 0))
+	assert.False(t, ok, "change found for quiet hours lasting the whole week")
+	var empty *QuietSchedule
+	assert.False(t, empty.Active(time.Now()))
+	assert.True(t, empty.Empty())
+}
+
+func TestIndicator_QuietHours(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	i := GetIndicator()
+	s, _ := NewQuietSchedule([]client.QuietHoursRule{{}})
+	i.SetQuietHours(s)
+	assert.Equal(t, s, i.QuietHours())
+	//during the quiet hours the icon still changes
+	i.config.notifyLevel = NotifyLevelMax
+	i.Notify("", "", NotifyIconDefault, IconLiqoOrange)
+	assert.Equal(t, IconLiqoOrange, i.Icon())
+	i.SetQuietHours(nil)
+	//during a quiet period only the critical notifications are displayed as banners
+	recorder := &bannerRecorder{}
+	i.actionNotifier = recorder
+	n := Notification{ID: "quiet", Actions: []NotificationAction{{Label: "Dismiss"}}}
+	i.SetQuietUntil(time.Now().Add(time.Hour))
+	i.ShowNotification(n)
+	assert.Empty(t, recorder.labels, "banner displayed during the quiet period")
+	n.Severity = SeverityError
+	i.ShowNotification(n)
+	assert.Len(t, recorder.labels, 1, "critical banner not displayed during the quiet period")
+	i.SetQuietUntil(time.Time{})
+	assert.True(t, i.QuietUntil().IsZero())
+	n.Severity = SeverityInfo
+	i.ShowNotification(n)
+	assert.Len(t, recorder.labels, 2, "banner not displayed after the quiet period")
+	//the Do Not Disturb mode silences the notifications below the quiet threshold, still updating the icon
+	i.SetDoNotDisturb(true)
+	i.SetQuietThreshold(SeverityWarning)
+	assert.True(t, i.DoNotDisturb())
+	n.Severity, n.Message = SeverityInfo, "info"
+	i.ShowNotification(n.WithTrayIcon(IconLiqoPurple))
+	assert.Len(t, recorder.labels, 2, "banner below the threshold displayed in Do Not Disturb mode")
+	assert.Equal(t, IconLiqoPurple, i.Icon(), "icon not updated in Do Not Disturb mode")
+	n.Severity, n.Message = SeverityWarning, "warning"
+	i.ShowNotification(n)
+	assert.Len(t, recorder.labels, 3, "banner reaching the threshold not displayed in Do Not Disturb mode")
+	i.SetDoNotDisturb(false)
+	i.SetQuietThreshold(SeverityError)
+	severity, err := ParseSeverity(" Warn")
+	assert.NoError(t, err)
+	assert.Equal(t, SeverityWarning, severity)
+	_, err = ParseSeverity("critical")
+	assert.Error(t, err)
+}
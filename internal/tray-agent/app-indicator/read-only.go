@@ -0,0 +1,51 @@
+package app_indicator
+
+/*This file contains the read-only mode of the Indicator. The MenuNodes performing write actions (e.g. starting a
+peering or changing the Agent installation) are marked with SetWriteAction: while the read-only mode is enabled, they
+are disabled and their clicks are ignored, so that the Agent is strictly observational.*/
+
+//SetWriteAction marks the MenuNode as performing a write action, which is not allowed in read-only mode.
+func (n *MenuNode) SetWriteAction(isWrite bool) {
+	i := n.indicator
+	i.readOnlyMutex.Lock()
+	if isWrite {
+		i.writeNodes[n] = true
+	} else {
+		delete(i.writeNodes, n)
+	}
+	i.readOnlyMutex.Unlock()
+	n.Lock()
+	defer n.Unlock()
+	n.isWrite = isWrite
+	n.applyEnabled()
+}
+
+//IsWriteAction returns whether the MenuNode performs a write action.
+func (n *MenuNode) IsWriteAction() bool {
+	n.RLock()
+	defer n.RUnlock()
+	return n.isWrite
+}
+
+//SetReadOnly enables or disables the read-only mode of the Indicator, updating all the write action MenuNodes.
+func (i *Indicator) SetReadOnly(readOnly bool) {
+	i.readOnlyMutex.Lock()
+	i.readOnly = readOnly
+	nodes := make([]*MenuNode, 0, len(i.writeNodes))
+	for n := range i.writeNodes {
+		nodes = append(nodes, n)
+	}
+	i.readOnlyMutex.Unlock()
+	for _, n := range nodes {
+		n.Lock()
+		n.applyEnabled()
+		n.Unlock()
+	}
+}
+
+//ReadOnly returns whether the Indicator is in read-only mode.
+func (i *Indicator) ReadOnly() bool {
+	i.readOnlyMutex.RLock()
+	defer i.readOnlyMutex.RUnlock()
+	return i.readOnly
+}
@@ -0,0 +1,152 @@
+package app_indicator
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/metrics"
+	"sync"
+	"time"
+)
+
+/*This file contains the refresh pipeline of the STATUS MenuNode and of the Indicator label. The refreshes requested
+by the Listeners, the Timers and the menu entries (see RefreshStatus and RefreshLabel) are performed at most once per
+refresh interval: a request arriving within the interval from the last refresh is merged with the other ones into a
+single refresh, performed at the end of the interval.*/
+
+//DefaultRefreshInterval is the minimum interval between two refreshes of the STATUS MenuNode and of the label.
+const DefaultRefreshInterval = 200 * time.Millisecond
+
+//RefreshStats are the execution metrics of the refreshes of the STATUS MenuNode and of the label.
+type RefreshStats struct {
+	//Requested is the number of requested refreshes.
+	Requested int
+	//Performed is the number of performed refreshes. The difference with Requested is the number of requests
+	//merged into other refreshes.
+	Performed int
+	//TotalTime is the overall duration of the refreshes.
+	TotalTime time.Duration
+	//MaxTime is the duration of the longest refresh.
+	MaxTime time.Duration
+	//LastTime is the duration of the last refresh.
+	LastTime time.Duration
+	//LastRefresh is the instant of the last refresh.
+	LastRefresh time.Time
+}
+
+//Merged returns the number of requests merged into other refreshes.
+func (s RefreshStats) Merged() int {
+	return s.Requested - s.Performed
+}
+
+//AverageTime returns the average duration of the refreshes.
+func (s RefreshStats) AverageTime() time.Duration {
+	if s.Performed == 0 {
+		return 0
+	}
+	return s.TotalTime / time.Duration(s.Performed)
+}
+
+//refresher collects the refresh requests of an Indicator.
+type refresher struct {
+	mutex sync.Mutex
+	//interval is the minimum interval between two refreshes. If zero, each request is performed immediately.
+	interval time.Duration
+	//pending specifies whether a refresh has been requested since the last one, and pendingStatus whether it
+	//includes the STATUS MenuNode.
+	pending       bool
+	pendingStatus bool
+	//timer performs the pending refresh at the end of the interval, if scheduled.
+	timer *time.Timer
+	stats RefreshStats
+}
+
+//RefreshStatus requests the update of the contents of the STATUS MenuNode and the Indicator Label.
+func (i *Indicator) RefreshStatus() {
+	i.requestRefresh(true)
+}
+
+//RefreshLabel requests the update of the content of the Indicator label according to its custom format (see
+//SetLabelFormat) or LabelMode: the total number of both incoming and outgoing peerings currently active or the
+//trend of the acquired CPU, followed by the badge counting the items awaiting an input of the user.
+func (i *Indicator) RefreshLabel() {
+	i.requestRefresh(false)
+}
+
+//requestRefresh performs a refresh of the label, and of the STATUS MenuNode if status is true, or schedules it at
+//the end of the refresh interval.
+func (i *Indicator) requestRefresh(status bool) {
+	r := &i.refresher
+	r.mutex.Lock()
+	r.stats.Requested++
+	r.pending = true
+	r.pendingStatus = r.pendingStatus || status
+	if r.timer != nil {
+		r.mutex.Unlock()
+		return
+	}
+	if wait := r.interval - time.Since(r.stats.LastRefresh); wait > 0 {
+		r.timer = time.AfterFunc(wait, i.FlushRefresh)
+		r.mutex.Unlock()
+		return
+	}
+	r.mutex.Unlock()
+	i.FlushRefresh()
+}
+
+//FlushRefresh immediately performs the pending refresh, if any.
+func (i *Indicator) FlushRefresh() {
+	r := &i.refresher
+	r.mutex.Lock()
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	if !r.pending {
+		r.mutex.Unlock()
+		return
+	}
+	status := r.pendingStatus
+	r.pending, r.pendingStatus = false, false
+	start := time.Now()
+	r.stats.LastRefresh = start
+	r.mutex.Unlock()
+	if status {
+		i.menuStatusNode.SetTitle(i.status.GoString())
+	}
+	i.renderLabel()
+	elapsed := time.Since(start)
+	metrics.GetRegistry().Timer("refresh_seconds", "Duration of the refreshes of the status and of the tray label",
+		nil).Observe(elapsed)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.stats.Performed++
+	r.stats.TotalTime += elapsed
+	r.stats.LastTime = elapsed
+	if elapsed > r.stats.MaxTime {
+		r.stats.MaxTime = elapsed
+	}
+}
+
+//SetRefreshInterval sets the minimum interval between two refreshes of the STATUS MenuNode and of the label.
+//If zero, each refresh is performed immediately.
+func (i *Indicator) SetRefreshInterval(interval time.Duration) {
+	if interval < 0 {
+		interval = 0
+	}
+	i.refresher.mutex.Lock()
+	i.refresher.interval = interval
+	i.refresher.mutex.Unlock()
+	i.FlushRefresh()
+}
+
+//RefreshInterval returns the minimum interval between two refreshes of the STATUS MenuNode and of the label.
+func (i *Indicator) RefreshInterval() time.Duration {
+	i.refresher.mutex.Lock()
+	defer i.refresher.mutex.Unlock()
+	return i.refresher.interval
+}
+
+//RefreshStats returns the execution metrics of the refreshes of the STATUS MenuNode and of the label.
+func (i *Indicator) RefreshStats() RefreshStats {
+	i.refresher.mutex.Lock()
+	defer i.refresher.mutex.Unlock()
+	return i.refresher.stats
+}
@@ -0,0 +1,57 @@
+package app_indicator
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRefreshPipeline(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	DestroyStatus()
+	i := GetIndicator()
+	st := i.Status().(*Status)
+	//the mocked Indicator refreshes immediately
+	assert.Equal(t, time.Duration(0), i.RefreshInterval())
+	before := i.RefreshStats()
+	st.SetRunning(StatRunOn)
+	st.incDecPeerings(PeeringOutgoing, true)
+	i.RefreshStatus()
+	assert.Equal(t, "(IN:0/OUT:1)", i.label, "label not refreshed immediately")
+	after := i.RefreshStats()
+	assert.Equal(t, before.Performed+1, after.Performed)
+	assert.Equal(t, 0, after.Merged()-before.Merged())
+	//within the refresh interval, the requests are merged into a single refresh
+	i.SetRefreshInterval(time.Hour)
+	i.RefreshStatus()
+	before = i.RefreshStats()
+	st.incDecPeerings(PeeringIncoming, true)
+	i.RefreshLabel()
+	i.RefreshStatus()
+	i.RefreshLabel()
+	assert.Equal(t, "(IN:0/OUT:1)", i.label, "refresh not delayed within the refresh interval")
+	i.FlushRefresh()
+	assert.Equal(t, "(IN:1/OUT:1)", i.label, "pending refresh not performed")
+	after = i.RefreshStats()
+	assert.Equal(t, before.Performed+1, after.Performed, "requests not merged")
+	assert.Equal(t, before.Requested+3, after.Requested)
+	assert.False(t, after.LastRefresh.IsZero())
+	//the pending refresh is performed at the end of the interval
+	i.SetRefreshInterval(20 * time.Millisecond)
+	st.incDecPeerings(PeeringIncoming, false)
+	i.RefreshLabel()
+	assert.Eventually(t, func() bool {
+		gr := i.graphicResource[resourceLabel]
+		gr.RLock()
+		defer gr.RUnlock()
+		return i.label == "(IN:0/OUT:1)"
+	}, time.Second, 5*time.Millisecond, "pending refresh not performed at the end of the interval")
+	i.SetRefreshInterval(0)
+	st.incDecPeerings(PeeringOutgoing, false)
+	st.SetRunning(StatRunOff)
+	i.RefreshStatus()
+}
@@ -0,0 +1,217 @@
+package app_indicator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Schedule defines the instants a Timer is triggered at.
+type Schedule interface {
+	//Next returns the first trigger strictly after the given instant. If there are no more triggers,
+	//ok == false.
+	Next(after time.Time) (next time.Time, ok bool)
+	//String returns a readable description of the Schedule, e.g. "every 30s".
+	String() string
+}
+
+//intervalSchedule triggers a Timer periodically.
+type intervalSchedule time.Duration
+
+//Every returns a Schedule triggering a Timer once per interval.
+func Every(interval time.Duration) Schedule {
+	return intervalSchedule(interval)
+}
+
+//Next returns the instant an interval after the given one.
+func (s intervalSchedule) Next(after time.Time) (time.Time, bool) {
+	return after.Add(time.Duration(s)), true
+}
+
+//String returns the description of the intervalSchedule, e.g. "every 30s".
+func (s intervalSchedule) String() string {
+	return "every " + time.Duration(s).String()
+}
+
+//atSchedule triggers a Timer once.
+type atSchedule time.Time
+
+//At returns a Schedule triggering a Timer once, at the given instant. An instant in the past never triggers it.
+func At(at time.Time) Schedule {
+	return atSchedule(at)
+}
+
+//Next returns the instant of the atSchedule, if it follows the given one.
+func (s atSchedule) Next(after time.Time) (time.Time, bool) {
+	at := time.Time(s)
+	if !at.After(after) {
+		return time.Time{}, false
+	}
+	return at, true
+}
+
+//String returns the description of the atSchedule, e.g. "at 2021-04-20 18:30".
+func (s atSchedule) String() string {
+	return "at " + time.Time(s).Format("2006-01-02 15:04")
+}
+
+//cronSchedule triggers a Timer at the instants matching a cron expression. Each field contains the set of its
+//matching values as a bitmask.
+type cronSchedule struct {
+	expression string
+	minute     uint64
+	hour       uint64
+	dom        uint64
+	month      uint64
+	dow        uint64
+	//anyDom and anyDow specify whether the day of the month and of the week are unrestricted ('*'): if both
+	//are restricted, a day matches if either of them does.
+	anyDom bool
+	anyDow bool
+}
+
+//cronField contains the bounds of a field of a cron expression.
+type cronField struct {
+	name string
+	min  int
+	max  int
+}
+
+//cronFields contains the fields of a cron expression, in order.
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+//cronDescriptors contains the predefined cron expressions.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+//cronSearchLimit is the time span searched for the next trigger of a cronSchedule, e.g. for "0 0 30 2 *".
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+//Cron returns a Schedule triggering a Timer at the instants (in local time) matching a standard cron expression:
+//minute, hour, day of month, month and day of week, each one a list of values, ranges ('1-5') and steps ('*/15').
+//The predefined expressions (e.g. "@hourly" and "@daily") are supported as well.
+func Cron(expression string) (Schedule, error) {
+	spec := strings.TrimSpace(expression)
+	if descriptor, present := cronDescriptors[spec]; present {
+		spec = descriptor
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression '%s': %d fields expected", expression, len(cronFields))
+	}
+	masks := make([]uint64, len(fields))
+	for n, field := range fields {
+		mask, err := parseCronField(field, cronFields[n])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression '%s': %v", expression, err)
+		}
+		masks[n] = mask
+	}
+	//Sunday is both 0 and 7
+	if masks[4]&(1<<7) != 0 {
+		masks[4] |= 1
+	}
+	return &cronSchedule{
+		expression: expression,
+		minute:     masks[0],
+		hour:       masks[1],
+		dom:        masks[2],
+		month:      masks[3],
+		dow:        masks[4],
+		anyDom:     fields[2] == "*",
+		anyDow:     fields[4] == "*",
+	}, nil
+}
+
+//parseCronField returns the bitmask of the values matching a field of a cron expression.
+func parseCronField(field string, bounds cronField) (uint64, error) {
+	var mask uint64
+	for _, item := range strings.Split(field, ",") {
+		rangeSpec, step := item, 1
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			var err error
+			rangeSpec = item[:idx]
+			if step, err = strconv.Atoi(item[idx+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %s '%s'", bounds.name, item)
+			}
+		}
+		low, high := bounds.min, bounds.max
+		if rangeSpec != "*" {
+			parts := strings.SplitN(rangeSpec, "-", 2)
+			var err error
+			if low, err = strconv.Atoi(parts[0]); err != nil {
+				return 0, fmt.Errorf("invalid %s '%s'", bounds.name, item)
+			}
+			high = low
+			if len(parts) == 2 {
+				if high, err = strconv.Atoi(parts[1]); err != nil {
+					return 0, fmt.Errorf("invalid %s '%s'", bounds.name, item)
+				}
+			} else if step > 1 {
+				//'a/n' stands for 'a-max/n'
+				high = bounds.max
+			}
+		}
+		if low < bounds.min || high > bounds.max || low > high {
+			return 0, fmt.Errorf("%s '%s' out of range %d-%d", bounds.name, item, bounds.min, bounds.max)
+		}
+		for v := low; v <= high; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+	return mask, nil
+}
+
+//matches returns whether the value is set in the bitmask.
+func matches(mask uint64, value int) bool {
+	return mask&(1<<uint(value)) != 0
+}
+
+//dayMatches returns whether a day matches the day of month and day of week fields.
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom, dow := matches(s.dom, t.Day()), matches(s.dow, int(t.Weekday()))
+	if s.anyDom || s.anyDow {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+//Next returns the first instant, after the given one, matching the cron expression.
+func (s *cronSchedule) Next(after time.Time) (time.Time, bool) {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(cronSearchLimit)
+	for t.Before(limit) {
+		switch {
+		case !matches(s.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !matches(s.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !matches(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+//String returns the description of the cronSchedule, e.g. "cron 0 9 * * 1-5".
+func (s *cronSchedule) String() string {
+	return "cron " + s.expression
+}
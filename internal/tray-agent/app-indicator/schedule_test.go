@@ -0,0 +1,113 @@
+package app_indicator
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCron(t *testing.T) {
+	//Tuesday
+	base := time.Date(2021, time.April, 20, 10, 7, 30, 0, time.Local)
+	tests := []struct {
+		expression string
+		next       time.Time
+	}{
+		{"*/15 * * * *", time.Date(2021, time.April, 20, 10, 15, 0, 0, time.Local)},
+		{"0 9 * * 1-5", time.Date(2021, time.April, 21, 9, 0, 0, 0, time.Local)},
+		{"30 18 * * 0,6", time.Date(2021, time.April, 24, 18, 30, 0, 0, time.Local)},
+		{"0 0 * * 7", time.Date(2021, time.April, 25, 0, 0, 0, 0, time.Local)},
+		{"0 12 1 * 3", time.Date(2021, time.April, 21, 12, 0, 0, 0, time.Local)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.Local)},
+		{"@hourly", time.Date(2021, time.April, 20, 11, 0, 0, 0, time.Local)},
+		{"@monthly", time.Date(2021, time.May, 1, 0, 0, 0, 0, time.Local)},
+		{"8/20 10 * * *", time.Date(2021, time.April, 20, 10, 8, 0, 0, time.Local)},
+	}
+	for _, test := range tests {
+		s, err := Cron(test.expression)
+		if !assert.NoError(t, err, test.expression) {
+			continue
+		}
+		next, ok := s.Next(base)
+		assert.True(t, ok, test.expression)
+		assert.Equal(t, test.next, next, test.expression)
+	}
+	for _, invalid := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *",
+		"a * * * *"} {
+		_, err := Cron(invalid)
+		assert.Error(t, err, invalid)
+	}
+	s, _ := Cron("0 0 30 2 *")
+	_, ok := s.Next(base)
+	assert.False(t, ok, "impossible date scheduled")
+	assert.Equal(t, "cron 0 0 30 2 *", s.String())
+}
+
+func TestAt(t *testing.T) {
+	at := time.Date(2021, time.April, 20, 18, 30, 0, 0, time.Local)
+	s := At(at)
+	next, ok := s.Next(at.Add(-time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, at, next)
+	_, ok = s.Next(at)
+	assert.False(t, ok, "one-shot schedule triggered twice")
+	assert.Equal(t, "at 2021-04-20 18:30", s.String())
+}
+
+func TestStartScheduledTimer(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	i := GetIndicator()
+	fired := make(chan struct{}, 10)
+	callback := func(args ...interface{}) {
+		fired <- struct{}{}
+	}
+	//one-shot Timer
+	assert.NoError(t, i.StartScheduledTimer("T_AT", At(time.Now().Add(50*time.Millisecond)), callback))
+	assert.Error(t, i.StartScheduledTimer("T_AT", At(time.Now()), callback), "Timer registered twice")
+	timer, present := i.Timer("T_AT")
+	if !assert.True(t, present) {
+		return
+	}
+	assert.Zero(t, timer.Interval())
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("one-shot Timer not triggered")
+	}
+	assert.Eventually(t, func() bool {
+		return timer.NextFire().IsZero()
+	}, time.Second, 10*time.Millisecond, "one-shot Timer rescheduled")
+	//a paused Timer is still triggered on request
+	cron, _ := Cron("@yearly")
+	assert.NoError(t, i.StartScheduledTimer("T_CRON", cron, callback))
+	timer, _ = i.Timer("T_CRON")
+	timer.Pause()
+	assert.False(t, timer.Active())
+	timer.Trigger()
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("Timer not triggered on request")
+	}
+	timer.Resume()
+	assert.True(t, timer.Active())
+	assert.True(t, timer.NextFire().After(time.Now()))
+	//a rescheduled Timer
+	timer.SetInterval(50 * time.Millisecond)
+	assert.Equal(t, 50*time.Millisecond, timer.Interval())
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("rescheduled Timer not triggered")
+	}
+	timer.SetSchedule(At(time.Time{}))
+	assert.Zero(t, timer.Interval())
+	assert.Eventually(t, func() bool {
+		return timer.NextFire().IsZero()
+	}, time.Second, 10*time.Millisecond, "Timer not rescheduled")
+	i.Quit()
+}
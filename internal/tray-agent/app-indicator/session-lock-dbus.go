@@ -0,0 +1,115 @@
+// +build linux
+
+package app_indicator
+
+import (
+	"errors"
+	"github.com/godbus/dbus/v5"
+	"os"
+)
+
+//The D-Bus session lock watcher follows the ActiveChanged signal of the screen savers on the session bus (the
+//freedesktop and GNOME ones) and the LockedHint property of the logind session on the system bus.
+func init() {
+	sessionLockWatcherFactory = newDbusSessionLockWatcher
+}
+
+const (
+	fdoScreenSaverIface   = "org.freedesktop.ScreenSaver"
+	gnomeScreenSaverIface = "org.gnome.ScreenSaver"
+	logindName            = "org.freedesktop.login1"
+	logindPath            = "/org/freedesktop/login1"
+	logindManagerIface    = "org.freedesktop.login1.Manager"
+	logindSessionIface    = "org.freedesktop.login1.Session"
+	dbusPropertiesIface   = "org.freedesktop.DBus.Properties"
+	//logindLockedHint is the property of a logind session specifying whether it is locked.
+	logindLockedHint = "LockedHint"
+)
+
+func newDbusSessionLockWatcher(onChange func(locked bool)) (func(), error) {
+	signals := make(chan *dbus.Signal, 10)
+	var stops []func()
+	if conn, err := dbus.SessionBus(); err == nil {
+		for _, iface := range []string{fdoScreenSaverIface, gnomeScreenSaverIface} {
+			options := []dbus.MatchOption{dbus.WithMatchInterface(iface), dbus.WithMatchMember("ActiveChanged")}
+			if conn.AddMatchSignal(options...) == nil {
+				stops = append(stops, func() { _ = conn.RemoveMatchSignal(options...) })
+			}
+		}
+		if len(stops) > 0 {
+			conn.Signal(signals)
+			stops = append(stops, func() { conn.RemoveSignal(signals) })
+		}
+	}
+	if stop, err := watchLogindSession(signals); err == nil {
+		stops = append(stops, stop)
+	}
+	if len(stops) == 0 {
+		return nil, errors.New("neither the screen saver nor the logind session are available on D-Bus")
+	}
+	done := make(chan struct{})
+	go dispatchSessionLock(signals, done, onChange)
+	return func() {
+		for _, stop := range stops {
+			stop()
+		}
+		close(done)
+	}, nil
+}
+
+//watchLogindSession subscribes to the property changes of the logind session of the Agent, returning the function
+//unsubscribing from them.
+func watchLogindSession(signals chan<- *dbus.Signal) (func(), error) {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return nil, err
+	}
+	var session dbus.ObjectPath
+	if err := conn.Object(logindName, logindPath).Call(logindManagerIface+".GetSessionByPID", 0,
+		uint32(os.Getpid())).Store(&session); err != nil {
+		return nil, err
+	}
+	options := []dbus.MatchOption{dbus.WithMatchObjectPath(session), dbus.WithMatchInterface(dbusPropertiesIface),
+		dbus.WithMatchMember("PropertiesChanged")}
+	if err := conn.AddMatchSignal(options...); err != nil {
+		return nil, err
+	}
+	conn.Signal(signals)
+	return func() {
+		_ = conn.RemoveMatchSignal(options...)
+		conn.RemoveSignal(signals)
+	}, nil
+}
+
+//dispatchSessionLock calls onChange at each change of the lock state carried by the signals, until done is closed.
+func dispatchSessionLock(signals <-chan *dbus.Signal, done <-chan struct{}, onChange func(locked bool)) {
+	for {
+		select {
+		case <-done:
+			return
+		case s := <-signals:
+			if locked, ok := sessionLockState(s); ok {
+				onChange(locked)
+			}
+		}
+	}
+}
+
+//sessionLockState returns the lock state carried by a signal, if any.
+func sessionLockState(s *dbus.Signal) (locked bool, ok bool) {
+	switch s.Name {
+	case fdoScreenSaverIface + ".ActiveChanged", gnomeScreenSaverIface + ".ActiveChanged":
+		if len(s.Body) > 0 {
+			locked, ok = s.Body[0].(bool)
+		}
+	case dbusPropertiesIface + ".PropertiesChanged":
+		if len(s.Body) < 2 || s.Body[0] != logindSessionIface {
+			return false, false
+		}
+		changed, _ := s.Body[1].(map[string]dbus.Variant)
+		if hint, present := changed[logindLockedHint]; present {
+			locked, ok = hint.Value().(bool)
+		}
+	}
+	return locked, ok
+}
@@ -0,0 +1,156 @@
+package app_indicator
+
+import (
+	"errors"
+	"fmt"
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/i18n"
+	"strings"
+)
+
+/*This file contains the deferral of the notifications raised while the desktop session is locked. The banners would
+be displayed over the lock screen, where the user likely misses them and anyone passing by can read them: while the
+session is locked, the Notifications below the quiet threshold (see SetQuietThreshold) only update the tray icon, and
+they are delivered as a single digest banner when the session is unlocked.*/
+
+const (
+	//maxDeferredBanners is the maximum number of deferred Notifications kept for the digest. The oldest ones are
+	//only counted.
+	maxDeferredBanners = 50
+	//maxDigestLines is the maximum number of Notifications listed in the digest banner.
+	maxDigestLines = 5
+)
+
+//sessionLockWatcherFactory starts watching the lock state of the desktop session on the current platform, calling
+//onChange at each change, and returns the function stopping it. It returns an error if the lock state cannot be
+//observed.
+var sessionLockWatcherFactory func(onChange func(locked bool)) (stop func(), err error)
+
+//WatchSessionLock starts deferring the Notifications raised while the desktop session is locked. It returns an error
+//if the lock state of the session cannot be observed on this platform.
+func (i *Indicator) WatchSessionLock() error {
+	if i.gProvider.Mocked() || sessionLockWatcherFactory == nil {
+		return errors.New("session lock monitoring not supported")
+	}
+	gr := i.graphicResource[resourceDesktop]
+	gr.Lock()
+	watching := i.sessionLockStop != nil
+	gr.Unlock()
+	if watching {
+		return nil
+	}
+	stop, err := sessionLockWatcherFactory(i.SetSessionLocked)
+	if err != nil {
+		return err
+	}
+	gr.Lock()
+	i.sessionLockStop = stop
+	gr.Unlock()
+	return nil
+}
+
+//StopWatchingSessionLock stops observing the lock state of the desktop session, delivering the deferred
+//Notifications, if any.
+func (i *Indicator) StopWatchingSessionLock() {
+	gr := i.graphicResource[resourceDesktop]
+	gr.Lock()
+	stop := i.sessionLockStop
+	i.sessionLockStop = nil
+	gr.Unlock()
+	if stop != nil {
+		stop()
+	}
+	i.SetSessionLocked(false)
+}
+
+//SetSessionLocked sets the lock state of the desktop session. When the session is unlocked, the Notifications
+//deferred while it was locked are delivered as a digest banner.
+func (i *Indicator) SetSessionLocked(locked bool) {
+	gr := i.graphicResource[resourceDesktop]
+	gr.Lock()
+	i.sessionLocked = locked
+	var deferred []Notification
+	dropped := 0
+	if !locked {
+		deferred, dropped = i.deferredBanners, i.droppedBanners
+		i.deferredBanners, i.droppedBanners = nil, 0
+	}
+	gr.Unlock()
+	switch {
+	case len(deferred) == 0:
+	case len(deferred) == 1 && dropped == 0:
+		//the tray icon already displayed the Notification
+		n := deferred[0]
+		n.trayIconSet = false
+		i.showBanner(n)
+	default:
+		i.showBanner(digestNotification(deferred, dropped))
+	}
+}
+
+//SessionLocked returns whether the desktop session is locked.
+func (i *Indicator) SessionLocked() bool {
+	gr := i.graphicResource[resourceDesktop]
+	gr.RLock()
+	defer gr.RUnlock()
+	return i.sessionLocked
+}
+
+//deferBanner keeps a Notification for the digest delivered when the session is unlocked, replacing the deferred one
+//with the same ID. The caller holds the resourceDesktop lock.
+func (i *Indicator) deferBanner(n Notification) {
+	if n.ID != "" {
+		for index, d := range i.deferredBanners {
+			if d.ID == n.ID {
+				i.deferredBanners = append(i.deferredBanners[:index], i.deferredBanners[index+1:]...)
+				break
+			}
+		}
+	}
+	if len(i.deferredBanners) == maxDeferredBanners {
+		i.deferredBanners = i.deferredBanners[1:]
+		i.droppedBanners++
+	}
+	i.deferredBanners = append(i.deferredBanners, n)
+}
+
+//dropDeferredBanner removes the deferred Notification with a specific ID, e.g. because it has been dismissed.
+func (i *Indicator) dropDeferredBanner(id string) {
+	gr := i.graphicResource[resourceDesktop]
+	gr.Lock()
+	defer gr.Unlock()
+	for index, d := range i.deferredBanners {
+		if d.ID == id {
+			i.deferredBanners = append(i.deferredBanners[:index], i.deferredBanners[index+1:]...)
+			return
+		}
+	}
+}
+
+//digestNotification returns the Notification summarizing the ones deferred while the session was locked, listing
+//the most recent ones, e.g.
+//	Liqo Agent: 3 NOTIFICATIONS WHILE LOCKED
+//	Peering established: ...
+//	Connection lost: ...
+func digestNotification(deferred []Notification, dropped int) Notification {
+	digest := Notification{
+		Title:    i18n.T(fmt.Sprintf("Liqo Agent: %d NOTIFICATIONS WHILE LOCKED", len(deferred)+dropped)),
+		Category: CategoryGeneral,
+		Actions:  []NotificationAction{{Label: i18n.T("Dismiss")}},
+	}
+	lines := make([]string, 0, maxDigestLines+1)
+	for index := len(deferred) - 1; index >= 0; index-- {
+		n := deferred[index]
+		if n.Severity > digest.Severity {
+			digest.Severity = n.Severity
+		}
+		if len(lines) < maxDigestLines {
+			lines = append(lines, strings.TrimPrefix(n.Title, "Liqo Agent: ")+": "+n.Message)
+		}
+	}
+	if others := len(deferred) + dropped - len(lines); others > 0 {
+		lines = append(lines, i18n.T(fmt.Sprintf("and %d more", others)))
+	}
+	//the deferred Notifications are already translated
+	digest.Message = strings.Join(lines, "\n")
+	return digest
+}
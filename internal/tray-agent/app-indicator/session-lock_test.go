@@ -0,0 +1,59 @@
+package app_indicator
+
+import (
+	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestIndicator_SessionLock(t *testing.T) {
+	UseMockedGuiProvider()
+	client.UseMockedAgentController()
+	DestroyMockedIndicator()
+	client.DestroyMockedAgentController()
+	i := GetIndicator()
+	assert.Error(t, i.WatchSessionLock(), "session lock watched with a mocked GUI")
+	recorder := &bannerRecorder{}
+	i.actionNotifier = recorder
+	i.config.notifyLevel = NotifyLevelMax
+	dismiss := []NotificationAction{{Label: "Dismiss"}}
+	i.SetSessionLocked(true)
+	assert.True(t, i.SessionLocked())
+	//while the session is locked, the non critical banners are deferred and the icon still changes
+	i.ShowNotification(Notification{ID: "a", Title: "Liqo Agent: A", Message: "first", Actions: dismiss}.
+		WithTrayIcon(IconLiqoPurple))
+	assert.Empty(t, recorder.labels, "banner displayed while the session is locked")
+	assert.Equal(t, IconLiqoPurple, i.Icon(), "icon not updated while the session is locked")
+	i.ShowNotification(Notification{ID: "b", Title: "Liqo Agent: B", Message: "second", Actions: dismiss})
+	i.ShowNotification(Notification{ID: "a", Title: "Liqo Agent: A", Message: "updated", Actions: dismiss})
+	i.ShowNotification(Notification{ID: "c", Title: "Liqo Agent: C", Actions: dismiss})
+	i.DismissNotification("c")
+	//the critical ones are displayed anyway
+	i.ShowNotification(Notification{ID: "d", Title: "Liqo Agent: D", Severity: SeverityError, Actions: dismiss})
+	assert.Len(t, recorder.labels, 1, "critical banner not displayed while the session is locked")
+	//the unlock delivers a digest of the deferred ones, the most recent first
+	i.SetSessionLocked(false)
+	if assert.Len(t, recorder.titles, 2, "digest not delivered when the session is unlocked") {
+		assert.Equal(t, "Liqo Agent: 2 NOTIFICATIONS WHILE LOCKED", recorder.titles[1])
+		assert.Equal(t, "A: updated\nB: second", recorder.messages[1])
+	}
+	i.SetSessionLocked(false)
+	assert.Len(t, recorder.titles, 2, "digest delivered twice")
+	//a single deferred Notification is delivered as is
+	i.SetSessionLocked(true)
+	i.ShowNotification(Notification{ID: "e", Title: "Liqo Agent: E", Message: "single", Actions: dismiss})
+	i.SetSessionLocked(false)
+	if assert.Len(t, recorder.titles, 3) {
+		assert.Equal(t, "Liqo Agent: E", recorder.titles[2])
+	}
+	//the digest lists the most recent ones, counting the others
+	deferred := make([]Notification, maxDeferredBanners)
+	for index := range deferred {
+		deferred[index] = Notification{Title: "N", Severity: SeverityWarning}
+	}
+	digest := digestNotification(deferred, 3)
+	assert.Equal(t, SeverityWarning, digest.Severity)
+	assert.Equal(t, maxDigestLines+1, len(strings.Split(digest.Message, "\n")))
+	assert.True(t, strings.HasSuffix(digest.Message, "and 48 more"), digest.Message)
+}
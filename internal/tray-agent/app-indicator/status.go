@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/liqotech/liqo-agent/internal/tray-agent/agent/client"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -164,6 +166,8 @@ type StatusInterface interface {
 	Peers() int
 	//Peer returns data related to a cluster if it is currently discovered by the home cluster.
 	Peer(clusterId string) (peer *PeerInfo, present bool)
+	//PeerList returns the data of all the peers currently discovered by the home cluster.
+	PeerList() []*PeerInfo
 	//AddOrUpdatePeer updates the internal information on an existing or newly discovered peer.
 	//In case no info about the peer's common name is provided, a placeholder "unknown identifier"
 	//is assigned to allow the user to visually distinguish between different unknown peers.
@@ -171,8 +175,29 @@ type StatusInterface interface {
 	AddOrUpdatePeer(data *client.NotifyDataForeignCluster) *PeerInfo
 	//RemovePeer removes a peer from the currently registered ones.
 	RemovePeer(data *client.NotifyDataForeignCluster) *PeerInfo
+	//ClusterName returns the common name of the cluster LiqoAgent is currently connected to.
+	ClusterName() string
 	//SetClusterName sets the common name of the cluster LiqoAgent is currently connected to.
 	SetClusterName(clusterName string)
+	//CacheSync returns the progress of the initial synchronization of the Agent caches.
+	CacheSync() client.CacheSyncProgress
+	//SetCacheSync sets the progress of the initial synchronization of the Agent caches.
+	SetCacheSync(progress client.CacheSyncProgress)
+	//Offloading returns the state of the namespaces whose offloading is enabled.
+	Offloading() []*client.NamespaceOffloading
+	//SetOffloading sets the state of the offloading of the namespaces. Only the enabled ones are kept.
+	SetOffloading(namespaces []*client.NamespaceOffloading)
+	//PeeringPhase returns the phase of a peering with a peer, client.PeeringNone if the peer is not discovered.
+	PeeringPhase(clusterId string, peering PeeringType) client.PeeringPhase
+	//OnPeeringPhaseChange registers a callback invoked at each transition of the phase of a peering, after the
+	//Status has been updated.
+	OnPeeringPhaseChange(callback func(t PeeringTransition))
+	//TunnelHealth returns the last health check of the network connectivity towards a peer.
+	TunnelHealth(clusterId string) (health *client.TunnelHealth, present bool)
+	//SetTunnelHealth stores the health check of the network connectivity towards a discovered peer.
+	SetTunnelHealth(health *client.TunnelHealth)
+	//SetPeerPreferences sets the preferences of the user about the peers (e.g. their aliases), by ClusterID.
+	SetPeerPreferences(prefs map[string]client.PeerPreferences)
 	//GoString produces a textual digest on the main status data managed by
 	//a Status instance.
 	GoString() string
@@ -181,17 +206,23 @@ type StatusInterface interface {
 //GetStatus initializes and returns the Status singleton. This function should not be called before Run().
 func GetStatus() StatusInterface {
 	if statusBlock == nil {
-		/*statusBlock boots up with default settings:
-			- OFF Liqo status
-			- Autonomous mode
-		Further changes are up to other Indicator components.*/
-		statusBlock = &Status{
-			peerList: make(map[string]*PeerInfo),
-		}
+		statusBlock = NewStatus()
 	}
 	return statusBlock
 }
 
+//NewStatus returns a new Status, independent of the singleton returned by GetStatus.
+func NewStatus() *Status {
+	/*the Status boots up with default settings:
+		- OFF Liqo status
+		- Autonomous mode
+	Further changes are up to other Indicator components.*/
+	return &Status{
+		peerList:     make(map[string]*PeerInfo),
+		tunnelHealth: make(map[string]*client.TunnelHealth),
+	}
+}
+
 //Status defines a data structure containing information about the current status of the Liqo instance,
 //e.g. if it is running, the selected working mode and a summary of the active peerings.
 type Status struct {
@@ -217,6 +248,17 @@ type Status struct {
 	//peerList stores details on the currently discovered peers, organized by their cluster id.
 	//This kind of information has its visual representation in the peers list of the tray menu.
 	peerList map[string]*PeerInfo
+	//cacheSync is the progress of the initial synchronization of the Agent caches.
+	cacheSync client.CacheSyncProgress
+	//offloading contains the state of the namespaces whose offloading is enabled.
+	offloading []*client.NamespaceOffloading
+	//tunnelHealth contains the last health check of the network connectivity towards the peers, organized by
+	//their cluster id.
+	tunnelHealth map[string]*client.TunnelHealth
+	//phaseCallbacks contains the callbacks registered with OnPeeringPhaseChange.
+	phaseCallbacks []func(t PeeringTransition)
+	//peerPreferences contains the preferences of the user about the peers, by ClusterID.
+	peerPreferences map[string]client.PeerPreferences
 	//mutex for the Status.
 	sync.RWMutex
 }
@@ -235,9 +277,43 @@ type PeerInfo struct {
 	UnknownId           int
 	OutPeeringConnected bool
 	InPeeringConnected  bool
+	//OutCpuQuota is the literal representation of the CPU quota shared by the peer in the active outgoing peering.
+	OutCpuQuota string
+	//OutMemQuota is the literal representation of the memory quota shared by the peer in the active outgoing peering.
+	OutMemQuota string
+	//OutPeeringPhase is the phase of the outgoing peering.
+	OutPeeringPhase client.PeeringPhase
+	//InPeeringPhase is the phase of the incoming peering.
+	InPeeringPhase client.PeeringPhase
+	//Alias is the friendly name assigned to the peer by the user, if any.
+	Alias string
+	//Favorite specifies whether the peer is pinned at the top of the peers list.
+	Favorite bool
 	sync.RWMutex
 }
 
+//DisplayName returns the name the peer is displayed with: its Alias, if set, or its ClusterName. The unknown peers
+//are named by their serial number. It must be called holding the lock of the PeerInfo.
+func (peer *PeerInfo) DisplayName() string {
+	switch {
+	case peer.Alias != "":
+		return peer.Alias
+	case peer.Unknown:
+		return unknownClusterNameLabel + " " + strconv.Itoa(peer.UnknownId)
+	default:
+		return peer.ClusterName
+	}
+}
+
+//PeeringTransition describes a change of the phase of a peering with a peer.
+type PeeringTransition struct {
+	//Peer is the peer of the peering, already removed from the Status if it is no more discovered.
+	Peer    *PeerInfo
+	Peering PeeringType
+	From    client.PeeringPhase
+	To      client.PeeringPhase
+}
+
 //incDecPeers increments (add = true) or decrements the number of available peers.
 func (st *Status) incDecPeers(add bool) {
 	if add {
@@ -278,6 +354,17 @@ func (st *Status) Peer(clusterId string) (peer *PeerInfo, present bool) {
 	return
 }
 
+//PeerList returns the data of all the peers currently discovered by the home cluster.
+func (st *Status) PeerList() []*PeerInfo {
+	st.RLock()
+	defer st.RUnlock()
+	peers := make([]*PeerInfo, 0, len(st.peerList))
+	for _, peer := range st.peerList {
+		peers = append(peers, peer)
+	}
+	return peers
+}
+
 //addPeer registers a newly discovered peer. In case no info about the peer's common name is provided,
 //a placeholder "unknown identifier" is assigned to allow the user to visually distinguish between different unknown peers.
 //When the number of unknown peers is decremented to 0, the identifier number is reset.
@@ -290,6 +377,8 @@ func (st *Status) addPeer(data *client.NotifyDataForeignCluster) *PeerInfo {
 		ClusterID:                  data.ClusterID,
 		OutPeeringConnected:        data.OutPeering.Connected,
 		InPeeringConnected:         data.InPeering.Connected,
+		OutCpuQuota:                data.OutPeering.CpuQuota,
+		OutMemQuota:                data.OutPeering.MemQuota,
 	}
 	//- manage peer name
 	if data.ClusterName != "" {
@@ -301,6 +390,8 @@ func (st *Status) addPeer(data *client.NotifyDataForeignCluster) *PeerInfo {
 		//generate serial unknown serial identity
 		peer.UnknownId = st.unknownId
 	}
+	prefs := st.peerPreferences[data.ClusterID]
+	peer.Alias, peer.Favorite = prefs.Alias, prefs.Favorite
 	//- manage peerings
 	st.peerList[data.ClusterID] = peer
 	st.incDecPeers(true)
@@ -343,6 +434,8 @@ func (st *Status) updatePeer(data *client.NotifyDataForeignCluster) *PeerInfo {
 		peer.OutPeeringConnected = false
 		st.incDecPeerings(PeeringOutgoing, false)
 	}
+	peer.OutCpuQuota = data.OutPeering.CpuQuota
+	peer.OutMemQuota = data.OutPeering.MemQuota
 	//- check incoming peering status
 	if !peer.InPeeringConnected && data.InPeering.Connected {
 		//new incoming peering connected
@@ -361,6 +454,16 @@ func (st *Status) updatePeer(data *client.NotifyDataForeignCluster) *PeerInfo {
 //is assigned to allow the user to visually distinguish between different unknown peers.
 //When the number of unknown peers is decremented to 0, the identifier number is reset.
 func (st *Status) AddOrUpdatePeer(data *client.NotifyDataForeignCluster) *PeerInfo {
+	peer, transitions, callbacks := st.addOrUpdatePeer(data)
+	//the callbacks are invoked outside the lock, being free to query the Status
+	runPhaseCallbacks(callbacks, transitions)
+	return peer
+}
+
+//addOrUpdatePeer implements AddOrUpdatePeer, returning the transitions of the phases of the peerings and the
+//callbacks to invoke for them.
+func (st *Status) addOrUpdatePeer(data *client.NotifyDataForeignCluster) (*PeerInfo, []PeeringTransition,
+	[]func(t PeeringTransition)) {
 	st.Lock()
 	defer st.Unlock()
 	//remove this check when handling the case of unauthorized ForeignCluster (probably manually discovered)
@@ -368,15 +471,27 @@ func (st *Status) AddOrUpdatePeer(data *client.NotifyDataForeignCluster) *PeerIn
 	if data.ClusterID == "" {
 		panic("clusterId of a NotifyDataForeignCluster object should always be not empty")
 	}
-	_, present := st.peerList[data.ClusterID]
-	if !present {
-		return st.addPeer(data)
+	var peer *PeerInfo
+	if _, present := st.peerList[data.ClusterID]; !present {
+		peer = st.addPeer(data)
+	} else {
+		peer = st.updatePeer(data)
 	}
-	return st.updatePeer(data)
+	transitions := peer.setPhases(data.OutPeering.Phase, data.InPeering.Phase)
+	return peer, transitions, st.phaseCallbacks
 }
 
 //RemovePeer removes a peer from the currently registered ones.
 func (st *Status) RemovePeer(data *client.NotifyDataForeignCluster) *PeerInfo {
+	peer, transitions, callbacks := st.removePeer(data)
+	runPhaseCallbacks(callbacks, transitions)
+	return peer
+}
+
+//removePeer implements RemovePeer, returning the transitions of the phases of the peerings and the callbacks to
+//invoke for them.
+func (st *Status) removePeer(data *client.NotifyDataForeignCluster) (*PeerInfo, []PeeringTransition,
+	[]func(t PeeringTransition)) {
 	st.Lock()
 	defer st.Unlock()
 	peer, present := st.peerList[data.ClusterID]
@@ -385,7 +500,7 @@ func (st *Status) RemovePeer(data *client.NotifyDataForeignCluster) *PeerInfo {
 		//The function can safely recover from the error by ignoring the input data. This way the Status db
 		//keeps its consistency and the visual representation of the information on the tray menu will
 		//reconcile in short time.
-		return &PeerInfo{ClusterID: data.ClusterID}
+		return &PeerInfo{ClusterID: data.ClusterID}, nil, nil
 	}
 	//- check if peer had unknown identity
 	if peer.Unknown {
@@ -399,8 +514,57 @@ func (st *Status) RemovePeer(data *client.NotifyDataForeignCluster) *PeerInfo {
 		st.incDecPeerings(PeeringIncoming, false)
 	}
 	delete(st.peerList, data.ClusterID)
+	delete(st.tunnelHealth, data.ClusterID)
 	st.incDecPeers(false)
-	return peer
+	transitions := peer.setPhases(client.PeeringNone, client.PeeringNone)
+	return peer, transitions, st.phaseCallbacks
+}
+
+//setPhases sets the phases of the peerings with the peer, returning their transitions.
+func (peer *PeerInfo) setPhases(out client.PeeringPhase, in client.PeeringPhase) []PeeringTransition {
+	var transitions []PeeringTransition
+	if peer.OutPeeringPhase != out {
+		transitions = append(transitions, PeeringTransition{Peer: peer, Peering: PeeringOutgoing,
+			From: peer.OutPeeringPhase, To: out})
+		peer.OutPeeringPhase = out
+	}
+	if peer.InPeeringPhase != in {
+		transitions = append(transitions, PeeringTransition{Peer: peer, Peering: PeeringIncoming,
+			From: peer.InPeeringPhase, To: in})
+		peer.InPeeringPhase = in
+	}
+	return transitions
+}
+
+//runPhaseCallbacks invokes the callbacks registered with OnPeeringPhaseChange for each transition.
+func runPhaseCallbacks(callbacks []func(t PeeringTransition), transitions []PeeringTransition) {
+	for _, t := range transitions {
+		for _, callback := range callbacks {
+			callback(t)
+		}
+	}
+}
+
+//PeeringPhase returns the phase of a peering with a peer, client.PeeringNone if the peer is not discovered.
+func (st *Status) PeeringPhase(clusterId string, peering PeeringType) client.PeeringPhase {
+	st.RLock()
+	defer st.RUnlock()
+	peer, present := st.peerList[clusterId]
+	if !present {
+		return client.PeeringNone
+	}
+	if peering == PeeringIncoming {
+		return peer.InPeeringPhase
+	}
+	return peer.OutPeeringPhase
+}
+
+//OnPeeringPhaseChange registers a callback invoked at each transition of the phase of a peering, after the
+//Status has been updated.
+func (st *Status) OnPeeringPhaseChange(callback func(t PeeringTransition)) {
+	st.Lock()
+	defer st.Unlock()
+	st.phaseCallbacks = append(st.phaseCallbacks, callback)
 }
 
 //IsTetheredCompliant checks if the TETHERED mode is eligible
@@ -539,6 +703,26 @@ func (st *Status) GoString() string {
 		str.WriteString("❗ " + unknownClusterNameDescription + " ❗\n")
 	}
 	str.WriteString(fmt.Sprintf("Mode: %v", st.mode))
+	if !st.cacheSync.Done() {
+		str.WriteString("\nLoading: " + st.cacheSync.String())
+	}
+	for _, peer := range st.favoritePeers() {
+		state := "discovered"
+		if peer.OutPeeringConnected || peer.InPeeringConnected {
+			state = "peered"
+		}
+		str.WriteString(fmt.Sprintf("\n★ %s: %s", peer.DisplayName(), state))
+	}
+	for _, ns := range st.offloading {
+		str.WriteString(fmt.Sprintf("\nOffloading %s: %s", ns.Name, ns.State))
+	}
+	for _, id := range st.degradedTunnels() {
+		name := id
+		if peer, present := st.peerList[id]; present && (!peer.Unknown || peer.Alias != "") {
+			name = peer.DisplayName()
+		}
+		str.WriteString(fmt.Sprintf("\nTunnel %s: degraded (%s)", name, st.tunnelHealth[id].Reason()))
+	}
 	return str.String()
 }
 
@@ -547,12 +731,6 @@ func (i *Indicator) Status() StatusInterface {
 	return i.status
 }
 
-//RefreshStatus updates the contents of the STATUS MenuNode and the Indicator Label.
-func (i *Indicator) RefreshStatus() {
-	i.menuStatusNode.SetTitle(i.status.GoString())
-	i.RefreshLabel()
-}
-
 //DestroyStatus is a testing function used to refresh the Status component.
 func DestroyStatus() {
 	if GetGuiProvider().Mocked() {
@@ -560,6 +738,102 @@ func DestroyStatus() {
 	}
 }
 
+//ClusterName returns the common name of the cluster LiqoAgent is currently connected to.
+func (st *Status) ClusterName() string {
+	st.RLock()
+	defer st.RUnlock()
+	return st.clusterName
+}
+
+//CacheSync returns the progress of the initial synchronization of the Agent caches.
+func (st *Status) CacheSync() client.CacheSyncProgress {
+	st.RLock()
+	defer st.RUnlock()
+	return st.cacheSync
+}
+
+//SetCacheSync sets the progress of the initial synchronization of the Agent caches.
+func (st *Status) SetCacheSync(progress client.CacheSyncProgress) {
+	st.Lock()
+	defer st.Unlock()
+	st.cacheSync = progress
+}
+
+//Offloading returns the state of the namespaces whose offloading is enabled.
+func (st *Status) Offloading() []*client.NamespaceOffloading {
+	st.RLock()
+	defer st.RUnlock()
+	return append([]*client.NamespaceOffloading(nil), st.offloading...)
+}
+
+//SetOffloading sets the state of the offloading of the namespaces. Only the enabled ones are kept.
+func (st *Status) SetOffloading(namespaces []*client.NamespaceOffloading) {
+	st.Lock()
+	defer st.Unlock()
+	st.offloading = nil
+	for _, ns := range namespaces {
+		if ns.Enabled() {
+			st.offloading = append(st.offloading, ns)
+		}
+	}
+}
+
+//TunnelHealth returns the last health check of the network connectivity towards a peer.
+func (st *Status) TunnelHealth(clusterId string) (health *client.TunnelHealth, present bool) {
+	st.RLock()
+	defer st.RUnlock()
+	health, present = st.tunnelHealth[clusterId]
+	return
+}
+
+//SetTunnelHealth stores the health check of the network connectivity towards a discovered peer. The checks of
+//peers not in the Status are ignored.
+func (st *Status) SetTunnelHealth(health *client.TunnelHealth) {
+	st.Lock()
+	defer st.Unlock()
+	if _, present := st.peerList[health.ClusterID]; present {
+		st.tunnelHealth[health.ClusterID] = health
+	}
+}
+
+//SetPeerPreferences sets the preferences of the user about the peers (e.g. their aliases), by ClusterID.
+func (st *Status) SetPeerPreferences(prefs map[string]client.PeerPreferences) {
+	st.Lock()
+	defer st.Unlock()
+	st.peerPreferences = prefs
+	for id, peer := range st.peerList {
+		peer.Lock()
+		peer.Alias, peer.Favorite = prefs[id].Alias, prefs[id].Favorite
+		peer.Unlock()
+	}
+}
+
+//favoritePeers returns the favorite peers, sorted by name. It must be called holding the lock of the Status.
+func (st *Status) favoritePeers() []*PeerInfo {
+	var favorites []*PeerInfo
+	for _, peer := range st.peerList {
+		if peer.Favorite {
+			favorites = append(favorites, peer)
+		}
+	}
+	sort.Slice(favorites, func(a, b int) bool {
+		return favorites[a].DisplayName() < favorites[b].DisplayName()
+	})
+	return favorites
+}
+
+//degradedTunnels returns the sorted cluster ids of the peers whose connectivity is degraded.
+func (st *Status) degradedTunnels() []string {
+	var ids []string
+	for id, health := range st.tunnelHealth {
+		if health.Degraded() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 //SetClusterName sets the common name of the cluster LiqoAgent is currently connected to.
 func (st *Status) SetClusterName(clusterName string) {
 	st.Lock()
@@ -25,3 +25,118 @@ func TestStatus(t *testing.T) {
 	assert.Equal(t, 0, stat.Peerings(PeeringOutgoing))
 	assert.Equal(t, 0, stat.Peerings(PeeringIncoming))
 }
+
+func TestStatus_CacheSync(t *testing.T) {
+	UseMockedGuiProvider()
+	DestroyStatus()
+	st := GetStatus()
+	assert.NotContains(t, st.GoString(), "Loading", "progress displayed before any warm-up")
+	st.SetCacheSync(client.CacheSyncProgress{Synced: 3, Total: 10})
+	assert.Contains(t, st.GoString(), "Loading: 3/10 caches synced")
+	st.SetCacheSync(client.CacheSyncProgress{Synced: 10, Total: 10})
+	assert.NotContains(t, st.GoString(), "Loading", "progress displayed after the warm-up")
+}
+
+func TestStatus_Offloading(t *testing.T) {
+	UseMockedGuiProvider()
+	DestroyStatus()
+	st := GetStatus()
+	st.SetOffloading([]*client.NamespaceOffloading{
+		{Name: "default", State: client.OffloadingDisabled},
+		{Name: "web", State: client.OffloadingReady},
+		{Name: "jobs", State: client.OffloadingError},
+	})
+	assert.Len(t, st.Offloading(), 2, "namespaces without offloading kept")
+	assert.NotContains(t, st.GoString(), "Offloading default")
+	assert.Contains(t, st.GoString(), "Offloading web: Ready")
+	assert.Contains(t, st.GoString(), "Offloading jobs: Error")
+	st.SetOffloading(nil)
+	assert.NotContains(t, st.GoString(), "Offloading")
+}
+
+func TestStatus_PeeringPhase(t *testing.T) {
+	UseMockedGuiProvider()
+	DestroyStatus()
+	st := GetStatus()
+	var transitions []PeeringTransition
+	st.OnPeeringPhaseChange(func(tr PeeringTransition) {
+		//the Status is already updated
+		assert.Equal(t, tr.To, st.PeeringPhase(tr.Peer.ClusterID, tr.Peering))
+		transitions = append(transitions, tr)
+	})
+	data := &client.NotifyDataForeignCluster{ClusterID: "phase1", ClusterName: "peer1"}
+	data.OutPeering.Phase = client.PeeringAuthenticating
+	st.AddOrUpdatePeer(data)
+	assert.Equal(t, client.PeeringAuthenticating, st.PeeringPhase("phase1", PeeringOutgoing))
+	assert.Equal(t, client.PeeringNone, st.PeeringPhase("phase1", PeeringIncoming))
+	if assert.Len(t, transitions, 1) {
+		assert.Equal(t, PeeringOutgoing, transitions[0].Peering)
+		assert.Equal(t, client.PeeringNone, transitions[0].From)
+	}
+	//unchanged phases trigger no callbacks
+	st.AddOrUpdatePeer(data)
+	assert.Len(t, transitions, 1)
+	data.OutPeering.Phase = client.PeeringEstablished
+	data.OutPeering.Connected = true
+	data.InPeering.Phase = client.PeeringPending
+	st.AddOrUpdatePeer(data)
+	if assert.Len(t, transitions, 3) {
+		assert.Equal(t, client.PeeringAuthenticating, transitions[1].From)
+		assert.Equal(t, client.PeeringEstablished, transitions[1].To)
+		assert.Equal(t, PeeringIncoming, transitions[2].Peering)
+	}
+	assert.Equal(t, 1, st.Peerings(PeeringOutgoing))
+	//the removal of a peer ends its peerings
+	transitions = nil
+	st.RemovePeer(data)
+	assert.Len(t, transitions, 2)
+	for _, tr := range transitions {
+		assert.Equal(t, client.PeeringNone, tr.To)
+		assert.Equal(t, "peer1", tr.Peer.ClusterName)
+	}
+	assert.Equal(t, client.PeeringNone, st.PeeringPhase("phase1", PeeringOutgoing))
+}
+
+func TestStatus_TunnelHealth(t *testing.T) {
+	UseMockedGuiProvider()
+	DestroyStatus()
+	st := GetStatus()
+	data := &client.NotifyDataForeignCluster{ClusterID: "tunnel1", ClusterName: "peer1"}
+	//checks of undiscovered peers are ignored
+	st.SetTunnelHealth(&client.TunnelHealth{ClusterID: "tunnel1"})
+	_, present := st.TunnelHealth("tunnel1")
+	assert.False(t, present)
+	st.AddOrUpdatePeer(data)
+	st.SetTunnelHealth(&client.TunnelHealth{ClusterID: "tunnel1", GatewayReady: true, Reachable: true})
+	health, present := st.TunnelHealth("tunnel1")
+	if assert.True(t, present) {
+		assert.True(t, health.Degraded())
+	}
+	assert.Contains(t, st.GoString(), "Tunnel peer1: degraded (no tunnel established)")
+	st.SetTunnelHealth(&client.TunnelHealth{ClusterID: "tunnel1", Connection: "connected", GatewayReady: true,
+		Reachable: true})
+	assert.NotContains(t, st.GoString(), "Tunnel")
+	st.RemovePeer(data)
+	_, present = st.TunnelHealth("tunnel1")
+	assert.False(t, present, "tunnel health of a removed peer kept")
+}
+
+func TestStatus_PeerPreferences(t *testing.T) {
+	UseMockedGuiProvider()
+	DestroyStatus()
+	st := GetStatus()
+	st.SetPeerPreferences(map[string]client.PeerPreferences{"pref1": {Alias: "staging", Favorite: true}})
+	peer := st.AddOrUpdatePeer(&client.NotifyDataForeignCluster{ClusterID: "pref1", ClusterName: "peer1"})
+	other := st.AddOrUpdatePeer(&client.NotifyDataForeignCluster{ClusterID: "pref2"})
+	assert.Equal(t, "staging", peer.DisplayName())
+	assert.True(t, peer.Favorite)
+	assert.Equal(t, "UNKNOWN 1", other.DisplayName())
+	assert.Contains(t, st.GoString(), "★ staging: discovered")
+	//the changes apply to the peers already discovered
+	st.SetPeerPreferences(map[string]client.PeerPreferences{"pref2": {Alias: "edge", Favorite: true}})
+	assert.Equal(t, "peer1", peer.DisplayName())
+	assert.False(t, peer.Favorite)
+	assert.Equal(t, "edge", other.DisplayName())
+	assert.NotContains(t, st.GoString(), "staging")
+	assert.Contains(t, st.GoString(), "★ edge: discovered")
+}
@@ -2,6 +2,8 @@ package app_indicator
 
 import (
 	"errors"
+	"sort"
+	"sync"
 	"time"
 )
 
@@ -9,25 +11,119 @@ import (
 type Timer struct {
 	//tag is the Timer id
 	tag string
-	//controller is the channel that lets control the callback execution, allowing or preventing it whether the channel
-	//receive a true or false value.
-	controller chan bool
+	//schedule defines the instants of the callback execution.
+	schedule Schedule
 	//active defines if the time triggered callback is executed (active = true)
 	active bool
-	//quitCh is the stop chan used to permanently stop the time loop
-	quitCh chan struct{}
+	//nextFire is the instant of the next trigger of the Timer.
+	nextFire time.Time
+	//quitCh is the stop chan used to permanently stop the time loop, closed when the Indicator quits
+	quitCh <-chan struct{}
+	//triggerCh requests an immediate execution of the callback.
+	triggerCh chan struct{}
+	//rescheduleCh signals a change of the schedule.
+	rescheduleCh chan struct{}
+	//mutex protects schedule, active and nextFire.
+	mutex sync.RWMutex
 }
 
 //SetActive controls the Timer behavior, allowing or not future calls of the associated callback.
 func (t *Timer) SetActive(active bool) {
-	t.controller <- active
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.active = active
 }
 
 //Active returns if the Timer is currently active, i.e. timed calls of the associated callback are allowed.
 func (t *Timer) Active() bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
 	return t.active
 }
 
+//Pause stops the timed calls of the associated callback, until Resume is called.
+func (t *Timer) Pause() {
+	t.SetActive(false)
+}
+
+//Resume allows again the timed calls of the associated callback.
+func (t *Timer) Resume() {
+	t.SetActive(true)
+}
+
+//Trigger requests an immediate execution of the associated callback, even if the Timer is paused. The next timed
+//call is then computed from the end of the execution. A request issued while another one is pending is ignored.
+func (t *Timer) Trigger() {
+	select {
+	case t.triggerCh <- struct{}{}:
+	default:
+	}
+}
+
+//Tag returns the id of the Timer.
+func (t *Timer) Tag() string {
+	return t.tag
+}
+
+//Interval returns the period of the callback execution, zero if the Timer is not triggered periodically (e.g. it
+//follows a cron expression).
+func (t *Timer) Interval() time.Duration {
+	if interval, ok := t.Schedule().(intervalSchedule); ok {
+		return time.Duration(interval)
+	}
+	return 0
+}
+
+//Schedule returns the Schedule of the Timer.
+func (t *Timer) Schedule() Schedule {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.schedule
+}
+
+//SetSchedule replaces the Schedule of the Timer. The next trigger is computed again from the current instant.
+func (t *Timer) SetSchedule(schedule Schedule) {
+	t.mutex.Lock()
+	t.schedule = schedule
+	t.mutex.Unlock()
+	select {
+	case t.rescheduleCh <- struct{}{}:
+	default:
+	}
+}
+
+//SetInterval makes the Timer trigger periodically, with the provided period. The Timer is not rescheduled if it
+//already has that period, so that a periodic call is not postponed.
+func (t *Timer) SetInterval(interval time.Duration) {
+	if t.Interval() == interval {
+		return
+	}
+	t.SetSchedule(Every(interval))
+}
+
+//NextFire returns the instant of the next trigger of the Timer, zero if it will not be triggered anymore. The
+//callback is executed only if the Timer is active at that time.
+func (t *Timer) NextFire() time.Time {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.nextFire
+}
+
+//scheduleNext records the next trigger of the Timer, returning the channel signaling it (nil if the Timer will
+//not be triggered anymore).
+func (t *Timer) scheduleNext() <-chan time.Time {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	now := time.Now()
+	next, ok := t.schedule.Next(now)
+	if !ok {
+		t.nextFire = time.Time{}
+		return nil
+	}
+	t.nextFire = next
+	return time.After(next.Sub(now))
+}
+
 //StartTimer registers a new Timer in charge of controlling the loop execution of callback. The Timer starts
 //automatically and can be controlled using (*Timer).SetActive() .
 //
@@ -35,37 +131,68 @@ func (t *Timer) Active() bool {
 //
 //	- interval : specifies the time interval after which the callback execution is triggered.
 func (i *Indicator) StartTimer(tag string, interval time.Duration, callback func(args ...interface{}), args ...interface{}) error {
+	return i.StartScheduledTimer(tag, Every(interval), callback, args...)
+}
+
+//StartScheduledTimer registers a new Timer executing callback at the instants defined by a Schedule, e.g. a cron
+//expression (see Cron) or a single instant (see At). The Timer starts automatically and can be controlled using
+//(*Timer).Pause(), (*Timer).Resume() and (*Timer).Trigger() .
+func (i *Indicator) StartScheduledTimer(tag string, schedule Schedule, callback func(args ...interface{}), args ...interface{}) error {
+	i.timersMutex.Lock()
+	defer i.timersMutex.Unlock()
 	if _, present := i.timers[tag]; present {
 		return errors.New("A Timer with the same tag already exists")
 	}
 	t := &Timer{
-		tag:        tag,
-		controller: make(chan bool, 2),
-		quitCh:     i.quitChan,
-		active:     true,
+		tag:          tag,
+		schedule:     schedule,
+		quitCh:       i.ctx.Done(),
+		triggerCh:    make(chan struct{}, 1),
+		rescheduleCh: make(chan struct{}, 1),
+		active:       true,
 	}
 	i.timers[tag] = t
-	go func(timer *Timer) {
+	i.goTracked(func() {
+		timer := t
+		fire := timer.scheduleNext()
 		for {
 			select {
-			case <-time.After(interval):
-				if timer.active {
+			case <-fire:
+				if timer.Active() {
 					callback(args...)
 				}
-			case stat, open := <-timer.controller:
-				if open {
-					timer.active = stat
-				}
+				fire = timer.scheduleNext()
+			case <-timer.triggerCh:
+				callback(args...)
+				fire = timer.scheduleNext()
+			case <-timer.rescheduleCh:
+				fire = timer.scheduleNext()
 			case <-timer.quitCh:
 				return
 			}
 		}
-	}(t)
+	})
 	return nil
 }
 
 //Timer returns the registered Timer for the specified tag. If such Timer does not exist, present == false.
 func (i *Indicator) Timer(tag string) (timer *Timer, present bool) {
+	i.timersMutex.RLock()
+	defer i.timersMutex.RUnlock()
 	timer, present = i.timers[tag]
 	return
 }
+
+//Timers returns all the registered Timers, sorted by tag.
+func (i *Indicator) Timers() []*Timer {
+	i.timersMutex.RLock()
+	defer i.timersMutex.RUnlock()
+	timers := make([]*Timer, 0, len(i.timers))
+	for _, t := range i.timers {
+		timers = append(timers, t)
+	}
+	sort.Slice(timers, func(a, b int) bool {
+		return timers[a].tag < timers[b].tag
+	})
+	return timers
+}